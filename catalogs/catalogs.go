@@ -0,0 +1,215 @@
+// Package catalogs listas de códigos del Anexo Técnico de DIAN
+//
+// Cada lista se embebe como CSV (data/*.csv) y se expone como un *Catalog
+// con búsqueda por código, resolución de nombres y validación:
+//
+//	catalogs.IdentificationTypes.NameOf("31")       // "NIT"
+//	catalogs.TaxSchemes.Find("INC")                  // Entry{Code: "04", ...}
+//	catalogs.Municipalities.Validate("05001")        // nil
+//	catalogs.FiscalResponsibilities.Validate("O-99") // ErrUnknownCode
+//
+// UnitCodes (UN/ECE Rec. 20) y PaymentMeans traen un subconjunto de las
+// tablas oficiales y Load permite cargar la tabla completa publicada por DIAN;
+// PaymentMeans acepta además cualquier código con el formato de UN/CEFACT 4461.
+package catalogs
+
+import (
+	"embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+//go:embed data/*.csv
+var dataFS embed.FS
+
+var (
+	// ErrUnknownCode el código no está en la lista
+	ErrUnknownCode = errors.New("unknown code")
+	// ErrInvalidCatalog el CSV de una lista es inválido
+	ErrInvalidCatalog = errors.New("invalid catalog")
+)
+
+// Entry código de una lista
+type Entry struct {
+	Code        string
+	Name        string
+	Description string // Opcional
+	Parent      string // Código del padre (departamento de un municipio)
+}
+
+// Catalog lista de códigos; es segura para uso concurrente
+type Catalog struct {
+	Name     string // Nombre de la lista (se usa en los errores)
+	Complete bool   // false si solo se embebe un subconjunto de la tabla oficial
+
+	accept func(code string) bool // Códigos no embebidos que acepta Validate si no es completa
+
+	mu      sync.RWMutex
+	entries []Entry
+	byCode  map[string]int
+}
+
+// New crea una lista vacía (ver Add y Load)
+func New(name string, complete bool) *Catalog {
+	return &Catalog{Name: name, Complete: complete, byCode: map[string]int{}}
+}
+
+// Add agrega o reemplaza códigos
+func (c *Catalog) Add(entries ...Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, e := range entries {
+		e.Code = normalizeCode(e.Code)
+		if i, ok := c.byCode[e.Code]; ok {
+			c.entries[i] = e
+			continue
+		}
+		c.byCode[e.Code] = len(c.entries)
+		c.entries = append(c.entries, e)
+	}
+}
+
+// Load agrega los códigos de un CSV con encabezado code,name[,description[,parent]]
+// Se usa para completar las listas parciales con la tabla oficial.
+func (c *Catalog) Load(r io.Reader) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidCatalog, c.Name, err)
+	}
+	if len(records) == 0 || len(records[0]) < 2 || records[0][0] != "code" || records[0][1] != "name" {
+		return fmt.Errorf("%w: %s: header must be code,name[,description[,parent]]", ErrInvalidCatalog, c.Name)
+	}
+
+	entries := make([]Entry, 0, len(records)-1)
+	for i, record := range records[1:] {
+		if len(record) < 2 || strings.TrimSpace(record[0]) == "" {
+			return fmt.Errorf("%w: %s: line %d has no code", ErrInvalidCatalog, c.Name, i+2)
+		}
+		record = append(record, "", "")
+		entries = append(entries, Entry{
+			Code:        record[0],
+			Name:        strings.TrimSpace(record[1]),
+			Description: strings.TrimSpace(record[2]),
+			Parent:      strings.TrimSpace(record[3]),
+		})
+	}
+	c.Add(entries...)
+	return nil
+}
+
+// Lookup busca un código (sin distinguir mayúsculas ni espacios)
+func (c *Catalog) Lookup(code string) (Entry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	i, ok := c.byCode[normalizeCode(code)]
+	if !ok {
+		return Entry{}, false
+	}
+	return c.entries[i], true
+}
+
+// Contains indica si el código está en la lista
+func (c *Catalog) Contains(code string) bool {
+	_, ok := c.Lookup(code)
+	return ok
+}
+
+// NameOf nombre del código, o "" si no está en la lista
+func (c *Catalog) NameOf(code string) string {
+	entry, _ := c.Lookup(code)
+	return entry.Name
+}
+
+// Find busca por nombre o descripción sin distinguir mayúsculas ni tildes
+// ("bogota d.c." encuentra "Bogotá D.C."); también acepta el código.
+func (c *Catalog) Find(name string) (Entry, bool) {
+	if entry, ok := c.Lookup(name); ok {
+		return entry, true
+	}
+	key := fold(name)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, e := range c.entries {
+		if fold(e.Name) == key || (e.Description != "" && fold(e.Description) == key) {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Validate verifica el código
+//
+// Algunas listas parciales (Complete = false) también aceptan códigos que no
+// están embebidos pero tienen el formato de la tabla oficial.
+func (c *Catalog) Validate(code string) error {
+	if c.Contains(code) {
+		return nil
+	}
+	if !c.Complete && c.accept != nil && c.accept(normalizeCode(code)) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s %q", ErrUnknownCode, c.Name, code)
+}
+
+// Entries códigos en el orden de la tabla
+func (c *Catalog) Entries() []Entry {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]Entry(nil), c.entries...)
+}
+
+// Children códigos cuyo padre es parent (municipios de un departamento)
+func (c *Catalog) Children(parent string) []Entry {
+	parent = normalizeCode(parent)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var list []Entry
+	for _, e := range c.entries {
+		if normalizeCode(e.Parent) == parent {
+			list = append(list, e)
+		}
+	}
+	return list
+}
+
+// Len cantidad de códigos
+func (c *Catalog) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
+
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// accents vocales con tilde y diéresis de los nombres de las tablas
+var accents = strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "ñ", "n", "å", "a")
+
+// fold normaliza un nombre para compararlo (minúsculas, sin tildes ni espacios extra)
+func fold(s string) string {
+	return strings.Join(strings.Fields(accents.Replace(strings.ToLower(s))), " ")
+}
+
+// embedded carga una lista embebida; un CSV inválido es un error de programación
+func embedded(file, name string, complete bool, accept func(string) bool) *Catalog {
+	c := New(name, complete)
+	c.accept = accept
+	f, err := dataFS.Open("data/" + file)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	if err := c.Load(f); err != nil {
+		panic(err)
+	}
+	return c
+}
@@ -0,0 +1,154 @@
+package catalogs_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/diegofxm/ubl21-dian/catalogs"
+)
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		catalog *catalogs.Catalog
+		code    string
+		name    string
+	}{
+		{catalogs.IdentificationTypes, "31", "NIT"},
+		{catalogs.IdentificationTypes, "13", "Cédula de ciudadanía"},
+		{catalogs.FiscalResponsibilities, "o-13", "Gran contribuyente"},
+		{catalogs.TaxSchemes, "04", "INC"},
+		{catalogs.Departments, "76", "Valle del Cauca"},
+		{catalogs.Municipalities, "11001", "Bogotá D.C."},
+		{catalogs.Countries, "CO", "Colombia"},
+		{catalogs.Currencies, "COP", "Peso colombiano"},
+		{catalogs.UnitCodes, "94", "Unidad"},
+		{catalogs.PaymentMeans, "ZZZ", "Acuerdo mutuo"},
+		{catalogs.PaymentMethods, "2", "Crédito"},
+		{catalogs.CreditNoteConcepts, "2", "Anulación de factura electrónica"},
+		{catalogs.DebitNoteConcepts, "1", "Intereses"},
+		{catalogs.Events, "033", "Aceptación expresa"},
+		{catalogs.CIIU, "6201", "Actividades de desarrollo de sistemas informáticos (planificación, análisis, diseño, programación, pruebas)"},
+	}
+	for _, tt := range tests {
+		if got := tt.catalog.NameOf(tt.code); got != tt.name {
+			t.Errorf("%s %s: expected %q, got %q", tt.catalog.Name, tt.code, tt.name, got)
+		}
+	}
+
+	if catalogs.Countries.Len() != 249 || len(catalogs.Departments.Entries()) != 33 {
+		t.Errorf("Unexpected catalog sizes: %d countries, %d departments", catalogs.Countries.Len(), catalogs.Departments.Len())
+	}
+	if municipality, _ := catalogs.Municipalities.Lookup("05001"); municipality.Parent != "05" {
+		t.Errorf("Expected Medellín in Antioquia, got %+v", municipality)
+	}
+	if list := catalogs.Municipalities.Children("88"); len(list) != 2 || list[0].Code != "88001" || list[1].Code != "88564" {
+		t.Errorf("Unexpected municipalities of San Andrés: %v", list)
+	}
+	if catalogs.Municipalities.Len() != 1122 || len(catalogs.Municipalities.Children("05")) != 125 || len(catalogs.Municipalities.Children("15")) != 123 {
+		t.Errorf("Unexpected DIVIPOLA sizes: %d municipalities, %d in Antioquia, %d in Boyacá", catalogs.Municipalities.Len(),
+			len(catalogs.Municipalities.Children("05")), len(catalogs.Municipalities.Children("15")))
+	}
+	if !catalogs.Municipalities.Complete || !catalogs.CIIU.Complete || catalogs.CIIU.NameOf("0144") != "Cría de ganado porcino" {
+		t.Errorf("Unexpected CIIU table: %q", catalogs.CIIU.NameOf("0144"))
+	}
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		catalog *catalogs.Catalog
+		name    string
+		code    string
+	}{
+		{catalogs.Municipalities, "bogota d.c.", "11001"},
+		{catalogs.Municipalities, "  MEDELLIN ", "05001"},
+		{catalogs.TaxSchemes, "Impuesto Nacional al Consumo", "04"},
+		{catalogs.Countries, "españa", "ES"},
+		{catalogs.IdentificationTypes, "31", "31"},
+	}
+	for _, tt := range tests {
+		entry, ok := tt.catalog.Find(tt.name)
+		if !ok || entry.Code != tt.code {
+			t.Errorf("%s %q: expected %s, got %+v", tt.catalog.Name, tt.name, tt.code, entry)
+		}
+	}
+	if _, ok := catalogs.Countries.Find("Atlántida"); ok {
+		t.Error("Expected no country")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := []struct {
+		catalog *catalogs.Catalog
+		code    string
+	}{
+		{catalogs.IdentificationTypes, "31"},
+		{catalogs.Municipalities, "05002"}, // Abejorral
+		{catalogs.Municipalities, "94887"}, // Pana Pana: área no municipalizada
+		{catalogs.UnitCodes, "KWT"},
+		{catalogs.UnitCodes, "LS"}, // Suma global
+		{catalogs.UnitCodes, "E48"},
+		{catalogs.CIIU, "0119"},
+	}
+	for _, tt := range valid {
+		if err := tt.catalog.Validate(tt.code); err != nil {
+			t.Errorf("%s %s: %v", tt.catalog.Name, tt.code, err)
+		}
+	}
+
+	invalid := []struct {
+		catalog *catalogs.Catalog
+		code    string
+	}{
+		{catalogs.IdentificationTypes, "99"},
+		{catalogs.FiscalResponsibilities, "O-99"},
+		{catalogs.Municipalities, "02001"}, // Departamento 02 no existe
+		{catalogs.Municipalities, "05003"}, // Departamento válido, municipio inexistente
+		{catalogs.UnitCodes, "XYZ"},
+		{catalogs.Countries, "XX"},
+		{catalogs.CIIU, "62O1"},
+		{catalogs.CIIU, "6203"},
+	}
+	for _, tt := range invalid {
+		if err := tt.catalog.Validate(tt.code); !errors.Is(err, catalogs.ErrUnknownCode) {
+			t.Errorf("%s %s: expected ErrUnknownCode, got %v", tt.catalog.Name, tt.code, err)
+		}
+	}
+}
+
+func TestPartyCodes(t *testing.T) {
+	party := catalogs.PartyCodes{
+		IdentificationType: "31",
+		OrganizationType:   "1",
+		TaxLevelCodes:      "O-13;O-15",
+		TaxScheme:          "01",
+		Municipality:       "11001",
+		Department:         "11",
+		Country:            "CO",
+		CIIU:               "6201;6202",
+	}
+	if err := party.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	party.TaxLevelCodes = "O-13;O-99"
+	party.Municipality = "05001"
+	err := party.Validate()
+	if !errors.Is(err, catalogs.ErrUnknownCode) || !strings.Contains(err.Error(), "O-99") || !strings.Contains(err.Error(), "does not belong") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	c := catalogs.New("Test", true)
+	err := c.Load(strings.NewReader("code,name,description,parent\n05002,Abejorral,,05\n05004,Abriaquí,,05\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.NameOf("05004") != "Abriaquí" || c.Validate("05001") == nil {
+		t.Errorf("Unexpected catalog: %v", c.Entries())
+	}
+	if err := c.Load(strings.NewReader("codigo;nombre\n1;x\n")); !errors.Is(err, catalogs.ErrInvalidCatalog) {
+		t.Errorf("Expected ErrInvalidCatalog, got %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package catalogs
+
+import "regexp"
+
+// Formato de los códigos de PaymentMeans
+var paymentMeansPattern = regexp.MustCompile(`^(\d{1,2}|ZZZ)$`)
+
+// Listas del Anexo Técnico de Factura Electrónica de Venta (v1.9)
+var (
+	// IdentificationTypes tipos de documento de identificación: 13 cédula, 31 NIT, ...
+	IdentificationTypes = embedded("identification_types.csv", "IdentificationTypes", true, nil)
+	// OrganizationTypes tipos de organización jurídica: 1 jurídica, 2 natural
+	OrganizationTypes = embedded("organization_types.csv", "OrganizationTypes", true, nil)
+	// FiscalResponsibilities responsabilidades fiscales: O-13, O-15, O-23, O-47, R-99-PN
+	FiscalResponsibilities = embedded("fiscal_responsibilities.csv", "FiscalResponsibilities", true, nil)
+	// TaxSchemes tributos: 01 IVA, 04 INC, 03 ICA, ...
+	TaxSchemes = embedded("tax_schemes.csv", "TaxSchemes", true, nil)
+
+	// Departments departamentos de Colombia (DIVIPOLA)
+	Departments = embedded("departments.csv", "Departments", true, nil)
+	// Municipalities municipios y áreas no municipalizadas (DIVIPOLA); Parent es el departamento
+	Municipalities = embedded("municipalities.csv", "Municipalities", true, nil)
+	// Countries países ISO 3166-1 alfa-2
+	Countries = embedded("countries.csv", "Countries", true, nil)
+	// Currencies monedas ISO 4217
+	Currencies = embedded("currencies.csv", "Currencies", true, nil)
+	// UnitCodes unidades de medida UN/ECE Rec. 20 (unas 570 de las ~2.000 de la
+	// tabla 13.3.6 del Anexo Técnico, sin las unidades compuestas poco usadas).
+	// Validate rechaza los códigos no embebidos: Load agrega los que falten.
+	UnitCodes = embedded("unit_codes.csv", "UnitCodes", false, nil)
+
+	// PaymentMeans medios de pago (UN/CEFACT 4461): 10 efectivo, 42 consignación, 48 tarjeta crédito, ...
+	// Subconjunto de uso frecuente
+	PaymentMeans = embedded("payment_means.csv", "PaymentMeans", false, paymentMeansPattern.MatchString)
+	// PaymentMethods formas de pago: 1 contado, 2 crédito
+	PaymentMethods = embedded("payment_methods.csv", "PaymentMethods", true, nil)
+	// CreditNoteConcepts conceptos de corrección de notas crédito
+	CreditNoteConcepts = embedded("credit_note_concepts.csv", "CreditNoteConcepts", true, nil)
+	// DebitNoteConcepts conceptos de corrección de notas débito
+	DebitNoteConcepts = embedded("debit_note_concepts.csv", "DebitNoteConcepts", true, nil)
+	// Events eventos de la factura (ApplicationResponse): 030 acuse, 033 aceptación, ...
+	Events = embedded("events.csv", "Events", true, nil)
+	// CIIU actividades económicas CIIU Rev. 4 A.C. y códigos DIAN de personas naturales
+	// sin actividad (0010, 0081, 0082, 0090)
+	CIIU = embedded("ciiu.csv", "CIIU", true, nil)
+)
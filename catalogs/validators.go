@@ -0,0 +1,85 @@
+package catalogs
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ValidateTaxLevelCodes valida responsabilidades fiscales separadas por ";"
+// como se informan en cbc:TaxLevelCode ("O-13;O-15")
+func ValidateTaxLevelCodes(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("%w: %s %q", ErrUnknownCode, FiscalResponsibilities.Name, value)
+	}
+	var errs []error
+	for _, code := range strings.Split(value, ";") {
+		if err := FiscalResponsibilities.Validate(code); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ValidateLocation valida municipio, departamento y país de una dirección
+// Los códigos vacíos no se validan; el municipio debe pertenecer al departamento.
+func ValidateLocation(municipality, department, country string) error {
+	var errs []error
+	check := func(c *Catalog, code string) {
+		if code != "" {
+			if err := c.Validate(code); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	check(Municipalities, municipality)
+	check(Departments, department)
+	check(Countries, country)
+
+	if municipality != "" && department != "" && !strings.HasPrefix(strings.TrimSpace(municipality), strings.TrimSpace(department)) {
+		errs = append(errs, fmt.Errorf("%w: municipality %q does not belong to department %q", ErrUnknownCode, municipality, department))
+	}
+	if country != "" && normalizeCode(country) != "CO" && (municipality != "" || department != "") {
+		errs = append(errs, fmt.Errorf("%w: DIVIPOLA codes are only valid for country CO, got %q", ErrUnknownCode, country))
+	}
+	return errors.Join(errs...)
+}
+
+// PartyCodes códigos de un emisor o adquirente que los builders informan
+type PartyCodes struct {
+	IdentificationType string // cbc:CompanyID/@schemeName
+	OrganizationType   string // cbc:AdditionalAccountID
+	TaxLevelCodes      string // cbc:TaxLevelCode ("O-13;O-15")
+	TaxScheme          string // cac:TaxScheme/cbc:ID
+	Municipality       string // cac:Address/cbc:ID
+	Department         string // cbc:CountrySubentityCode
+	Country            string // cac:Country/cbc:IdentificationCode
+	CIIU               string // cbc:IndustryClassificationCode (opcional)
+}
+
+// Validate valida los códigos informados (los vacíos se omiten)
+func (p PartyCodes) Validate() error {
+	var errs []error
+	check := func(c *Catalog, code string) {
+		if code != "" {
+			if err := c.Validate(code); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	check(IdentificationTypes, p.IdentificationType)
+	check(OrganizationTypes, p.OrganizationType)
+	check(TaxSchemes, p.TaxScheme)
+	for _, code := range strings.Split(p.CIIU, ";") {
+		check(CIIU, strings.TrimSpace(code))
+	}
+	if p.TaxLevelCodes != "" {
+		if err := ValidateTaxLevelCodes(p.TaxLevelCodes); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := ValidateLocation(p.Municipality, p.Department, p.Country); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
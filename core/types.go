@@ -19,13 +19,13 @@ type Party struct {
 type PartyIdentification struct {
 	ID              string // Número de identificación
 	SchemeID        string // Dígito de Verificación (DV)
-	SchemeName      string // Tipo de documento: 31=NIT, 13=Cédula, 22=CE, 41=Pasaporte, etc.
+	SchemeName      string // Tipo de documento: 31=NIT, 13=Cédula, 22=CE, 41=Pasaporte, etc. (catalogs.IdentificationTypes)
 	SchemeVersionID string // Tipo de organización: 1=Persona Jurídica, 2=Persona Natural
 }
 
 // TaxScheme esquema tributario
 type TaxScheme struct {
-	ID   string // "01" = IVA, "04" = INC, etc.
+	ID   string // "01" = IVA, "04" = INC, etc. (catalogs.TaxSchemes)
 	Name string
 }
 
@@ -33,7 +33,7 @@ type TaxScheme struct {
 type PartyTaxScheme struct {
 	RegistrationName    string
 	CompanyID           string
-	TaxLevelCode        string // "O-13" = Responsable IVA, etc.
+	TaxLevelCode        string // "O-13" = Gran contribuyente, "R-99-PN" = No aplica (catalogs.FiscalResponsibilities)
 	RegistrationAddress *Address
 	TaxScheme           TaxScheme
 }
@@ -89,7 +89,7 @@ type MonetaryAmount struct {
 // Quantity cantidad
 type Quantity struct {
 	Value    float64
-	UnitCode string // "EA" = Each, "KGM" = Kilogram, etc.
+	UnitCode string // "EA" = Each, "KGM" = Kilogram, etc. (catalogs.UnitCodes)
 }
 
 // Item artículo/producto
@@ -2,7 +2,10 @@ package core
 
 import (
 	"fmt"
+	"strconv"
 	"time"
+
+	"github.com/diegofxm/ubl21-dian/identification"
 )
 
 // FormatDate formatea una fecha a YYYY-MM-DD
@@ -21,21 +24,13 @@ func FormatAmount(amount float64) string {
 }
 
 // CalculateDV calcula el dígito de verificación de un NIT
+// Ignora puntos y espacios; retorna -1 si el NIT no es válido (vacío, con
+// letras o de más de 15 dígitos). Ver identification.CheckDigit.
 func CalculateDV(nit string) int {
-	// Implementación del algoritmo de DV colombiano
-	weights := []int{71, 67, 59, 53, 47, 43, 41, 37, 29, 23, 19, 17, 13, 7, 3}
-	sum := 0
-
-	for i, digit := range nit {
-		if digit >= '0' && digit <= '9' {
-			sum += int(digit-'0') * weights[len(weights)-len(nit)+i]
-		}
+	dv, err := identification.CheckDigit(nit)
+	if err != nil {
+		return -1
 	}
-
-	remainder := sum % 11
-	if remainder == 0 || remainder == 1 {
-		return remainder
-	}
-
-	return 11 - remainder
+	n, _ := strconv.Atoi(dv)
+	return n
 }
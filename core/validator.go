@@ -2,7 +2,10 @@ package core
 
 import (
 	"errors"
+	"fmt"
 	"regexp"
+
+	"github.com/diegofxm/ubl21-dian/identification"
 )
 
 var (
@@ -12,18 +15,12 @@ var (
 	ErrInvalidAmount = errors.New("monto inválido")
 )
 
-// ValidateNIT valida un NIT colombiano
+// ValidateNIT valida un NIT colombiano, con o sin DV ("900.123.456-8")
+// El error envuelve ErrInvalidNIT y el *identification.Error con el detalle.
 func ValidateNIT(nit string) error {
-	if nit == "" {
-		return ErrInvalidNIT
+	if _, err := identification.Parse(identification.NIT, nit); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidNIT, err)
 	}
-
-	// Validación básica: solo números y guión
-	matched, _ := regexp.MatchString(`^\d{9,10}(-\d)?$`, nit)
-	if !matched {
-		return ErrInvalidNIT
-	}
-
 	return nil
 }
 
@@ -22,7 +22,7 @@ func TestApplicationResponseBuilder(t *testing.T) {
 		companyParty := PartyData{
 			RegistrationName: "MI EMPRESA SAS",
 			CompanyID:        "900123456",
-			SchemeID:         "1",
+			SchemeID:         "8",
 			SchemeName:       "31",
 			TaxLevelCode:     "O-13",
 			TaxSchemeID:      "01",
@@ -7,6 +7,7 @@ import (
 
 	"github.com/diegofxm/ubl21-dian/documents/common"
 	"github.com/diegofxm/ubl21-dian/documents/common/types"
+	"github.com/diegofxm/ubl21-dian/identification"
 )
 
 //go:embed templates/*.tmpl
@@ -15,6 +16,7 @@ var templatesFS embed.FS
 // Builder constructor para ApplicationResponse
 type Builder struct {
 	doc ApplicationResponseXML
+	err error // Primer error de los setters; lo retorna Build
 }
 
 // NewBuilder crea un nuevo builder de ApplicationResponse
@@ -84,6 +86,9 @@ func (b *Builder) AddNote(note string) *Builder {
 
 // SetSenderParty establece el emisor (DIAN)
 func (b *Builder) SetSenderParty(party PartyData) *Builder {
+	if err := normalizeParty(&party); err != nil && b.err == nil {
+		b.err = fmt.Errorf("invalid sender identification: %w", err)
+	}
 	b.doc.SenderParty = SenderPartyXML{
 		PartyTaxScheme: PartyTaxSchemeXML{
 			RegistrationName: types.CBCElement{Value: party.RegistrationName},
@@ -94,10 +99,7 @@ func (b *Builder) SetSenderParty(party PartyData) *Builder {
 				SchemeAgencyName: "CO, DIAN (Dirección de Impuestos y Aduanas Nacionales)",
 				Value:        party.CompanyID,
 			},
-			TaxLevelCode: types.TaxLevelCodeElement{
-				ListName: "Fiscal Responsibility",
-				Value:    party.TaxLevelCode,
-			},
+			TaxLevelCode: taxLevelCode(party.TaxLevelCode),
 			TaxScheme: TaxSchemeXML{
 				ID:   types.CBCElement{Value: party.TaxSchemeID},
 				Name: types.CBCElement{Value: party.TaxSchemeName},
@@ -109,6 +111,9 @@ func (b *Builder) SetSenderParty(party PartyData) *Builder {
 
 // SetReceiverParty establece el receptor (empresa)
 func (b *Builder) SetReceiverParty(party PartyData) *Builder {
+	if err := normalizeParty(&party); err != nil && b.err == nil {
+		b.err = fmt.Errorf("invalid receiver identification: %w", err)
+	}
 	b.doc.ReceiverParty = ReceiverPartyXML{
 		PartyTaxScheme: PartyTaxSchemeXML{
 			RegistrationName: types.CBCElement{Value: party.RegistrationName},
@@ -119,10 +124,7 @@ func (b *Builder) SetReceiverParty(party PartyData) *Builder {
 				SchemeAgencyName: "CO, DIAN (Dirección de Impuestos y Aduanas Nacionales)",
 				Value:        party.CompanyID,
 			},
-			TaxLevelCode: types.TaxLevelCodeElement{
-				ListName: "Fiscal Responsibility",
-				Value:    party.TaxLevelCode,
-			},
+			TaxLevelCode: taxLevelCode(party.TaxLevelCode),
 			TaxScheme: TaxSchemeXML{
 				ID:   types.CBCElement{Value: party.TaxSchemeID},
 				Name: types.CBCElement{Value: party.TaxSchemeName},
@@ -192,6 +194,10 @@ func (b *Builder) SetDocumentReference(ref DocumentReferenceData) *Builder {
 
 // Build genera el XML del ApplicationResponse usando templates
 func (b *Builder) Build() (string, error) {
+	if b.err != nil {
+		return "", b.err
+	}
+
 	// Cargar templates comunes + específicos
 	tmpl, err := common.LoadCommonAndSpecificTemplates(templatesFS, "templates/*.tmpl")
 	if err != nil {
@@ -238,7 +244,7 @@ func (b *Builder) prepareTemplateData() ApplicationResponseTemplateData {
 		CompanyID:        b.doc.SenderParty.PartyTaxScheme.CompanyID.Value,
 		SchemeID:         b.doc.SenderParty.PartyTaxScheme.CompanyID.SchemeID,
 		SchemeName:       b.doc.SenderParty.PartyTaxScheme.CompanyID.SchemeName,
-		TaxLevelCode:     b.doc.SenderParty.PartyTaxScheme.TaxLevelCode.Value,
+		TaxLevelCode:     b.doc.SenderParty.PartyTaxScheme.Code(),
 		TaxSchemeID:      b.doc.SenderParty.PartyTaxScheme.TaxScheme.ID.Value,
 		TaxSchemeName:    b.doc.SenderParty.PartyTaxScheme.TaxScheme.Name.Value,
 	}
@@ -249,7 +255,7 @@ func (b *Builder) prepareTemplateData() ApplicationResponseTemplateData {
 		CompanyID:        b.doc.ReceiverParty.PartyTaxScheme.CompanyID.Value,
 		SchemeID:         b.doc.ReceiverParty.PartyTaxScheme.CompanyID.SchemeID,
 		SchemeName:       b.doc.ReceiverParty.PartyTaxScheme.CompanyID.SchemeName,
-		TaxLevelCode:     b.doc.ReceiverParty.PartyTaxScheme.TaxLevelCode.Value,
+		TaxLevelCode:     b.doc.ReceiverParty.PartyTaxScheme.Code(),
 		TaxSchemeID:      b.doc.ReceiverParty.PartyTaxScheme.TaxScheme.ID.Value,
 		TaxSchemeName:    b.doc.ReceiverParty.PartyTaxScheme.TaxScheme.Name.Value,
 	}
@@ -296,3 +302,18 @@ func (b *Builder) prepareTemplateData() ApplicationResponseTemplateData {
 func (b *Builder) GetModel() ApplicationResponseXML {
 	return b.doc
 }
+
+// normalizeParty normaliza CompanyID y calcula o verifica el DV (SchemeID) del NIT
+func normalizeParty(party *PartyData) error {
+	var err error
+	party.CompanyID, party.SchemeID, err = identification.NormalizeParty(party.SchemeName, party.CompanyID, party.SchemeID)
+	return err
+}
+
+// taxLevelCode responsabilidad fiscal de la parte; nil la omite como el template
+func taxLevelCode(code string) *types.TaxLevelCodeElement {
+	if code == "" {
+		return nil
+	}
+	return &types.TaxLevelCodeElement{ListName: "Fiscal Responsibility", Value: code}
+}
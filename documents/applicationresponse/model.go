@@ -11,17 +11,17 @@ type ApplicationResponseXML struct {
 	XMLName xml.Name `xml:"ApplicationResponse"`
 
 	// Namespaces
-	Xmlns             string `xml:"xmlns,attr"`
-	XmlnsCAC          string `xml:"xmlns:cac,attr"`
-	XmlnsCBC          string `xml:"xmlns:cbc,attr"`
-	XmlnsCCTS         string `xml:"xmlns:ccts,attr"`
-	XmlnsDS           string `xml:"xmlns:ds,attr"`
-	XmlnsExt          string `xml:"xmlns:ext,attr"`
-	XmlnsSts          string `xml:"xmlns:sts,attr"`
-	XmlnsXades        string `xml:"xmlns:xades,attr"`
-	XmlnsXades141     string `xml:"xmlns:xades141,attr"`
-	XmlnsXsi          string `xml:"xmlns:xsi,attr"`
-	XsiSchemaLocation string `xml:"xsi:schemaLocation,attr"`
+	Xmlns             string `xml:"xmlns,attr,omitempty"`
+	XmlnsCAC          string `xml:"xmlns:cac,attr,omitempty"`
+	XmlnsCBC          string `xml:"xmlns:cbc,attr,omitempty"`
+	XmlnsCCTS         string `xml:"xmlns:ccts,attr,omitempty"`
+	XmlnsDS           string `xml:"xmlns:ds,attr,omitempty"`
+	XmlnsExt          string `xml:"xmlns:ext,attr,omitempty"`
+	XmlnsSts          string `xml:"xmlns:sts,attr,omitempty"`
+	XmlnsXades        string `xml:"xmlns:xades,attr,omitempty"`
+	XmlnsXades141     string `xml:"xmlns:xades141,attr,omitempty"`
+	XmlnsXsi          string `xml:"xmlns:xsi,attr,omitempty"`
+	XsiSchemaLocation string `xml:"xsi:schemaLocation,attr,omitempty"`
 
 	// UBLExtensions (para firma XAdES de DIAN)
 	UBLExtensions types.UBLExtensions `xml:"ext:UBLExtensions"`
@@ -61,7 +61,7 @@ type ReceiverPartyXML struct {
 type PartyTaxSchemeXML struct {
 	RegistrationName types.CBCElement         `xml:"cbc:RegistrationName"`
 	CompanyID        types.IDElement          `xml:"cbc:CompanyID"`
-	TaxLevelCode     types.TaxLevelCodeElement `xml:"cbc:TaxLevelCode,omitempty"`
+	TaxLevelCode     *types.TaxLevelCodeElement `xml:"cbc:TaxLevelCode,omitempty"`
 	TaxScheme        TaxSchemeXML             `xml:"cac:TaxScheme"`
 }
 
@@ -103,3 +103,11 @@ type ResultOfVerificationXML struct {
 	ValidateTool           *types.CBCElement `xml:"cbc:ValidateTool,omitempty"`
 	ValidateToolVersion    *types.CBCElement `xml:"cbc:ValidateToolVersion,omitempty"`
 }
+
+// Code código de responsabilidad fiscal (vacío si no viene)
+func (s PartyTaxSchemeXML) Code() string {
+	if s.TaxLevelCode == nil {
+		return ""
+	}
+	return s.TaxLevelCode.Value
+}
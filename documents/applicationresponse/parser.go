@@ -1,16 +1,18 @@
 package applicationresponse
 
 import (
-	"encoding/xml"
 	"fmt"
 	"time"
+
+	xmlpkg "github.com/diegofxm/ubl21-dian/xml"
 )
 
 // ParseFromXML parsea un XML de ApplicationResponse de DIAN
 func ParseFromXML(xmlData []byte) (*ApplicationResponseData, error) {
 	var appResp ApplicationResponseXML
 	
-	if err := xml.Unmarshal(xmlData, &appResp); err != nil {
+	// Los tags del modelo llevan prefijo (cbc:, cac:); encoding/xml no los resuelve
+	if err := xmlpkg.Unmarshal(xmlData, &appResp); err != nil {
 		return nil, fmt.Errorf("error parsing ApplicationResponse XML: %w", err)
 	}
 	
@@ -37,7 +39,7 @@ func ParseFromXML(xmlData []byte) (*ApplicationResponseData, error) {
 		CompanyID:        appResp.SenderParty.PartyTaxScheme.CompanyID.Value,
 		SchemeID:         appResp.SenderParty.PartyTaxScheme.CompanyID.SchemeID,
 		SchemeName:       appResp.SenderParty.PartyTaxScheme.CompanyID.SchemeName,
-		TaxLevelCode:     appResp.SenderParty.PartyTaxScheme.TaxLevelCode.Value,
+		TaxLevelCode:     appResp.SenderParty.PartyTaxScheme.Code(),
 		TaxSchemeID:      appResp.SenderParty.PartyTaxScheme.TaxScheme.ID.Value,
 		TaxSchemeName:    appResp.SenderParty.PartyTaxScheme.TaxScheme.Name.Value,
 	}
@@ -48,7 +50,7 @@ func ParseFromXML(xmlData []byte) (*ApplicationResponseData, error) {
 		CompanyID:        appResp.ReceiverParty.PartyTaxScheme.CompanyID.Value,
 		SchemeID:         appResp.ReceiverParty.PartyTaxScheme.CompanyID.SchemeID,
 		SchemeName:       appResp.ReceiverParty.PartyTaxScheme.CompanyID.SchemeName,
-		TaxLevelCode:     appResp.ReceiverParty.PartyTaxScheme.TaxLevelCode.Value,
+		TaxLevelCode:     appResp.ReceiverParty.PartyTaxScheme.Code(),
 		TaxSchemeID:      appResp.ReceiverParty.PartyTaxScheme.TaxScheme.ID.Value,
 		TaxSchemeName:    appResp.ReceiverParty.PartyTaxScheme.TaxScheme.Name.Value,
 	}
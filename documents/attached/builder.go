@@ -1,15 +1,18 @@
 package attached
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/diegofxm/ubl21-dian/documents/common/types"
+	"github.com/diegofxm/ubl21-dian/identification"
 	xmlpkg "github.com/diegofxm/ubl21-dian/xml"
 )
 
 // Builder construye un AttachedDocument UBL 2.1 directamente con structs XML
 type Builder struct {
 	doc *AttachedDocumentXML
+	err error // Primer error de los setters; lo retorna ToXML
 }
 
 // NewBuilder crea un nuevo builder de AttachedDocument
@@ -77,6 +80,9 @@ func (b *Builder) SetParentDocumentID(id string) *Builder {
 
 // SetSender establece el emisor del AttachedDocument
 func (b *Builder) SetSender(sender PartyData) *Builder {
+	if err := normalizeParty(&sender); err != nil && b.err == nil {
+		b.err = fmt.Errorf("invalid sender identification: %w", err)
+	}
 	b.doc.SenderParty = SenderPartyXML{
 		PartyTaxScheme: types.PartyTaxSchemeXML{
 			RegistrationName: types.CBCElement{Value: xmlpkg.Sanitize(sender.RegistrationName)},
@@ -99,6 +105,9 @@ func (b *Builder) SetSender(sender PartyData) *Builder {
 
 // SetReceiver establece el receptor del AttachedDocument (opcional)
 func (b *Builder) SetReceiver(receiver PartyData) *Builder {
+	if err := normalizeParty(&receiver); err != nil && b.err == nil {
+		b.err = fmt.Errorf("invalid receiver identification: %w", err)
+	}
 	b.doc.ReceiverParty = &ReceiverPartyXML{
 		PartyTaxScheme: types.PartyTaxSchemeXML{
 			RegistrationName: types.CBCElement{Value: xmlpkg.Sanitize(receiver.RegistrationName)},
@@ -172,5 +181,15 @@ func (b *Builder) Build() *AttachedDocumentXML {
 
 // ToXML genera el XML del AttachedDocument
 func (b *Builder) ToXML() ([]byte, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
 	return xmlpkg.Marshal(b.doc)
 }
+
+// normalizeParty normaliza CompanyID y calcula o verifica el DV (SchemeID) del NIT
+func normalizeParty(party *PartyData) error {
+	var err error
+	party.CompanyID, party.SchemeID, err = identification.NormalizeParty(party.SchemeName, party.CompanyID, party.SchemeID)
+	return err
+}
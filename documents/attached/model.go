@@ -11,14 +11,14 @@ type AttachedDocumentXML struct {
 	XMLName xml.Name `xml:"AttachedDocument"`
 
 	// Namespaces
-	Xmlns         string `xml:"xmlns,attr"`
-	XmlnsCAC      string `xml:"xmlns:cac,attr"`
-	XmlnsCBC      string `xml:"xmlns:cbc,attr"`
-	XmlnsCCTS     string `xml:"xmlns:ccts,attr"`
-	XmlnsExt      string `xml:"xmlns:ext,attr"`
-	XmlnsXades    string `xml:"xmlns:xades,attr"`
-	XmlnsXades141 string `xml:"xmlns:xades141,attr"`
-	XmlnsDS       string `xml:"xmlns:ds,attr"`
+	Xmlns         string `xml:"xmlns,attr,omitempty"`
+	XmlnsCAC      string `xml:"xmlns:cac,attr,omitempty"`
+	XmlnsCBC      string `xml:"xmlns:cbc,attr,omitempty"`
+	XmlnsCCTS     string `xml:"xmlns:ccts,attr,omitempty"`
+	XmlnsExt      string `xml:"xmlns:ext,attr,omitempty"`
+	XmlnsXades    string `xml:"xmlns:xades,attr,omitempty"`
+	XmlnsXades141 string `xml:"xmlns:xades141,attr,omitempty"`
+	XmlnsDS       string `xml:"xmlns:ds,attr,omitempty"`
 
 	// UBLExtensions (para la firma)
 	UBLExtensions types.UBLExtensions `xml:"ext:UBLExtensions"`
@@ -0,0 +1,95 @@
+package attached
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/documents/common/types"
+	xmlpkg "github.com/diegofxm/ubl21-dian/xml"
+)
+
+// ErrNotAttachedDocument el XML no es un AttachedDocument
+var ErrNotAttachedDocument = errors.New("not an AttachedDocument")
+
+// ParseFromXML parsea un AttachedDocument; el XML de la factura y del
+// ApplicationResponse se extraen de los CDATA de cbc:Description
+func ParseFromXML(xmlData []byte) (*AttachedDocumentData, error) {
+	if root := rootName(xmlData); root != "AttachedDocument" {
+		return nil, fmt.Errorf("%w: root element %q", ErrNotAttachedDocument, root)
+	}
+
+	var doc AttachedDocumentXML
+
+	// Los tags del modelo llevan prefijo (cbc:, cac:); encoding/xml no los resuelve
+	if err := xmlpkg.Unmarshal(xmlData, &doc); err != nil {
+		return nil, fmt.Errorf("error parsing AttachedDocument XML: %w", err)
+	}
+
+	data := &AttachedDocumentData{
+		ID:                 doc.ID.Value,
+		ProfileExecutionID: doc.ProfileExecutionID.Value,
+		IssueTime:          doc.IssueTime.Value,
+		DocumentType:       doc.DocumentType.Value,
+		ParentDocumentID:   doc.ParentDocumentID.Value,
+		Sender:             parseParty(doc.SenderParty.PartyTaxScheme),
+		SignedInvoiceXML:   strings.TrimSpace(doc.Attachment.ExternalReference.Description.Value),
+	}
+	if issueDate, err := time.Parse("2006-01-02", doc.IssueDate.Value); err == nil {
+		data.IssueDate = issueDate
+	}
+	if doc.ReceiverParty != nil {
+		receiver := parseParty(doc.ReceiverParty.PartyTaxScheme)
+		data.Receiver = &receiver
+	}
+
+	ref := doc.ParentDocumentLineReference.DocumentReference
+	data.ApplicationResponse = ApplicationResponseData{
+		InvoiceID:            ref.ID.Value,
+		CUFE:                 ref.UUID.Value,
+		ResponseXML:          strings.TrimSpace(ref.Attachment.ExternalReference.Description.Value),
+		ValidationResultCode: ref.ResultOfVerification.ValidationResultCode.Value,
+		ValidationDate:       ref.ResultOfVerification.ValidationDate.Value,
+		ValidationTime:       ref.ResultOfVerification.ValidationTime.Value,
+	}
+	if issueDate, err := time.Parse("2006-01-02", ref.IssueDate.Value); err == nil {
+		data.ApplicationResponse.IssueDate = issueDate
+	}
+
+	return data, nil
+}
+
+// ParseFromString parsea un string XML de AttachedDocument
+func ParseFromString(xmlString string) (*AttachedDocumentData, error) {
+	return ParseFromXML([]byte(xmlString))
+}
+
+// rootName nombre local del elemento raíz
+func rootName(data []byte) string {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.RawToken()
+		if err != nil {
+			return ""
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local
+		}
+	}
+}
+
+// parseParty datos de una parte desde cac:PartyTaxScheme
+func parseParty(party types.PartyTaxSchemeXML) PartyData {
+	return PartyData{
+		RegistrationName: party.RegistrationName.Value,
+		CompanyID:        party.CompanyID.Value,
+		SchemeID:         party.CompanyID.SchemeID,
+		SchemeName:       party.CompanyID.SchemeName,
+		TaxLevelCode:     party.TaxLevelCode.Value,
+		TaxSchemeID:      party.TaxScheme.ID.Value,
+		TaxSchemeName:    party.TaxScheme.Name.Value,
+	}
+}
@@ -23,3 +23,17 @@ type ApplicationResponseData struct {
 	ValidationDate       string    // Fecha de validación (YYYY-MM-DD)
 	ValidationTime       string    // Hora de validación (HH:MM:SS-07:00)
 }
+
+// AttachedDocumentData datos de un AttachedDocument recibido
+type AttachedDocumentData struct {
+	ID                  string                  // ID del contenedor
+	ProfileExecutionID  string                  // Ambiente: 1 = Producción, 2 = Habilitación
+	IssueDate           time.Time               // Fecha de emisión del contenedor
+	IssueTime           string                  // Hora de emisión (HH:MM:SS-05:00)
+	DocumentType        string                  // Contenedor de Factura Electrónica
+	ParentDocumentID    string                  // Número de la factura
+	Sender              PartyData               // Emisor (facturador)
+	Receiver            *PartyData              // Receptor (adquiriente); nil si no viene
+	SignedInvoiceXML    string                  // Factura firmada del Attachment
+	ApplicationResponse ApplicationResponseData // ApplicationResponse de ParentDocumentLineReference
+}
@@ -1,5 +1,11 @@
 package types
 
+import (
+	"encoding/xml"
+
+	xmlpkg "github.com/diegofxm/ubl21-dian/xml"
+)
+
 // UBLExtensions contiene las extensiones UBL
 type UBLExtensions struct {
 	UBLExtension []UBLExtension `xml:"ext:UBLExtension"`
@@ -14,3 +20,14 @@ type UBLExtension struct {
 type ExtensionContent struct {
 	InnerXML string `xml:",innerxml"`
 }
+
+// UnmarshalXML conserva el contenido como XML crudo (sts:DianExtensions,
+// ds:Signature o extensiones desconocidas)
+func (c *ExtensionContent) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	inner, err := xmlpkg.InnerXML(d, start)
+	if err != nil {
+		return err
+	}
+	c.InnerXML = inner
+	return nil
+}
@@ -0,0 +1,24 @@
+package creditnote
+
+import (
+	"fmt"
+
+	xmlpkg "github.com/diegofxm/ubl21-dian/xml"
+)
+
+// ParseFromXML parsea un XML de nota crédito (firmado o no) en el modelo
+//
+// Los prefijos se resuelven por namespace; las extensiones (sts:DianExtensions,
+// ds:Signature y otras) quedan como XML crudo en UBLExtensions.
+func ParseFromXML(xmlData []byte) (*CreditNoteXML, error) {
+	var doc CreditNoteXML
+	if err := xmlpkg.Unmarshal(xmlData, &doc); err != nil {
+		return nil, fmt.Errorf("error parsing CreditNote XML: %w", err)
+	}
+	return &doc, nil
+}
+
+// ParseFromString parsea un string XML de nota crédito
+func ParseFromString(xmlString string) (*CreditNoteXML, error) {
+	return ParseFromXML([]byte(xmlString))
+}
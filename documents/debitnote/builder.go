@@ -5,7 +5,9 @@ import (
 	"embed"
 	"fmt"
 
+	"github.com/diegofxm/ubl21-dian/catalogs"
 	"github.com/diegofxm/ubl21-dian/documents/common"
+	"github.com/diegofxm/ubl21-dian/identification"
 )
 
 //go:embed templates/*.tmpl
@@ -14,6 +16,7 @@ var templatesFS embed.FS
 // Builder constructor de notas débito
 type Builder struct {
 	data DebitNoteTemplateData
+	err  error // Primer error de los setters; lo retorna Build
 }
 
 // NewBuilder crea un nuevo builder
@@ -79,12 +82,24 @@ func (b *Builder) SetBillingReference(invoiceID, uuid, issueDate string) *Builde
 
 // SetSupplier establece emisor
 func (b *Builder) SetSupplier(supplier PartyTemplateData) *Builder {
+	if err := normalizeParty(&supplier); err != nil && b.err == nil {
+		b.err = fmt.Errorf("invalid supplier identification: %w", err)
+	}
+	if err := validateParty(supplier); err != nil && b.err == nil {
+		b.err = fmt.Errorf("invalid supplier codes: %w", err)
+	}
 	b.data.Supplier = supplier
 	return b
 }
 
 // SetCustomer establece receptor
 func (b *Builder) SetCustomer(customer PartyTemplateData) *Builder {
+	if err := normalizeParty(&customer); err != nil && b.err == nil {
+		b.err = fmt.Errorf("invalid customer identification: %w", err)
+	}
+	if err := validateParty(customer); err != nil && b.err == nil {
+		b.err = fmt.Errorf("invalid customer codes: %w", err)
+	}
 	b.data.Customer = customer
 	return b
 }
@@ -100,6 +115,9 @@ func (b *Builder) SetTotals(lineExtension, taxExclusive, taxInclusive, payable s
 
 // AddLine agrega línea
 func (b *Builder) AddLine(line DebitNoteLineTemplateData) *Builder {
+	if err := validateLine(line.UnitCode, line.TaxTotal); err != nil && b.err == nil {
+		b.err = fmt.Errorf("invalid line %s: %w", line.ID, err)
+	}
 	b.data.DebitNoteLines = append(b.data.DebitNoteLines, line)
 	b.data.LineCount = len(b.data.DebitNoteLines)
 	return b
@@ -107,12 +125,19 @@ func (b *Builder) AddLine(line DebitNoteLineTemplateData) *Builder {
 
 // AddTaxTotal agrega total de impuestos
 func (b *Builder) AddTaxTotal(taxTotal TaxTotalTemplateData) *Builder {
+	if err := validateTaxTotal(taxTotal); err != nil && b.err == nil {
+		b.err = fmt.Errorf("invalid tax total: %w", err)
+	}
 	b.data.TaxTotals = append(b.data.TaxTotals, taxTotal)
 	return b
 }
 
 // Build genera el XML
 func (b *Builder) Build() ([]byte, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
 	// Cargar templates comunes + específicos usando helper
 	tmpl, err := common.LoadCommonAndSpecificTemplates(templatesFS, "templates/*.tmpl")
 	if err != nil {
@@ -126,3 +151,58 @@ func (b *Builder) Build() ([]byte, error) {
 
 	return buf.Bytes(), nil
 }
+
+// GetData retorna los datos actuales del builder
+func (b *Builder) GetData() DebitNoteTemplateData {
+	return b.data
+}
+
+// normalizeParty normaliza CompanyID y calcula o verifica el DV del NIT
+// en el esquema tributario y la entidad legal
+func normalizeParty(party *PartyTemplateData) error {
+	var err error
+	tax := &party.TaxScheme
+	if tax.CompanyID, tax.CompanyIDSchemeID, err = identification.NormalizeParty(tax.CompanyIDSchemeName, tax.CompanyID, tax.CompanyIDSchemeID); err != nil {
+		return err
+	}
+	legal := &party.LegalEntity
+	legal.CompanyID, legal.CompanyIDSchemeID, err = identification.NormalizeParty(legal.CompanyIDSchemeName, legal.CompanyID, legal.CompanyIDSchemeID)
+	return err
+}
+
+// validateParty valida los códigos de la parte contra los catálogos de DIAN
+func validateParty(party PartyTemplateData) error {
+	return catalogs.PartyCodes{
+		IdentificationType: party.TaxScheme.CompanyIDSchemeName,
+		OrganizationType:   party.AdditionalAccountID,
+		TaxLevelCodes:      party.TaxScheme.TaxLevelCode,
+		TaxScheme:          party.TaxScheme.ID,
+		Municipality:       party.Address.ID,
+		Department:         party.Address.CountrySubentityCode,
+		Country:            party.Address.CountryCode,
+		CIIU:               party.IndustryClassificationCode,
+	}.Validate()
+}
+
+// validateTaxTotal valida los tributos de los subtotales (vacíos se omiten)
+func validateTaxTotal(taxTotal TaxTotalTemplateData) error {
+	for _, subtotal := range taxTotal.TaxSubtotals {
+		if id := subtotal.TaxCategory.TaxScheme.ID; id != "" {
+			if err := catalogs.TaxSchemes.Validate(id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateLine valida la unidad de medida y los tributos de una línea
+func validateLine(unitCode string, taxTotal *TaxTotalTemplateData) error {
+	if err := catalogs.UnitCodes.Validate(unitCode); err != nil {
+		return err
+	}
+	if taxTotal == nil {
+		return nil
+	}
+	return validateTaxTotal(*taxTotal)
+}
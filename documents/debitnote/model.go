@@ -12,16 +12,16 @@ type DebitNoteXML struct {
 	XMLName xml.Name `xml:"DebitNote"`
 
 	// Namespaces
-	Xmlns             string `xml:"xmlns,attr"`
-	XmlnsCAC          string `xml:"xmlns:cac,attr"`
-	XmlnsCBC          string `xml:"xmlns:cbc,attr"`
-	XmlnsDS           string `xml:"xmlns:ds,attr"`
-	XmlnsExt          string `xml:"xmlns:ext,attr"`
-	XmlnsSts          string `xml:"xmlns:sts,attr"`
-	XmlnsXades        string `xml:"xmlns:xades,attr"`
-	XmlnsXades141     string `xml:"xmlns:xades141,attr"`
-	XmlnsXsi          string `xml:"xmlns:xsi,attr"`
-	XsiSchemaLocation string `xml:"xsi:schemaLocation,attr"`
+	Xmlns             string `xml:"xmlns,attr,omitempty"`
+	XmlnsCAC          string `xml:"xmlns:cac,attr,omitempty"`
+	XmlnsCBC          string `xml:"xmlns:cbc,attr,omitempty"`
+	XmlnsDS           string `xml:"xmlns:ds,attr,omitempty"`
+	XmlnsExt          string `xml:"xmlns:ext,attr,omitempty"`
+	XmlnsSts          string `xml:"xmlns:sts,attr,omitempty"`
+	XmlnsXades        string `xml:"xmlns:xades,attr,omitempty"`
+	XmlnsXades141     string `xml:"xmlns:xades141,attr,omitempty"`
+	XmlnsXsi          string `xml:"xmlns:xsi,attr,omitempty"`
+	XsiSchemaLocation string `xml:"xsi:schemaLocation,attr,omitempty"`
 
 	// UBLExtensions
 	UBLExtensions types.UBLExtensions `xml:"ext:UBLExtensions"`
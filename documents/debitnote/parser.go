@@ -0,0 +1,24 @@
+package debitnote
+
+import (
+	"fmt"
+
+	xmlpkg "github.com/diegofxm/ubl21-dian/xml"
+)
+
+// ParseFromXML parsea un XML de nota débito (firmado o no) en el modelo
+//
+// Los prefijos se resuelven por namespace; las extensiones (sts:DianExtensions,
+// ds:Signature y otras) quedan como XML crudo en UBLExtensions.
+func ParseFromXML(xmlData []byte) (*DebitNoteXML, error) {
+	var doc DebitNoteXML
+	if err := xmlpkg.Unmarshal(xmlData, &doc); err != nil {
+		return nil, fmt.Errorf("error parsing DebitNote XML: %w", err)
+	}
+	return &doc, nil
+}
+
+// ParseFromString parsea un string XML de nota débito
+func ParseFromString(xmlString string) (*DebitNoteXML, error) {
+	return ParseFromXML([]byte(xmlString))
+}
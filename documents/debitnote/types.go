@@ -224,6 +224,7 @@ type DebitNoteLineTemplateData struct {
 	LineExtensionAmount   string
 	FreeOfChargeIndicator string
 	CurrencyID            string
+	TaxTotal              *TaxTotalTemplateData
 	Item                  ItemTemplateData
 	Price                 PriceTemplateData
 }
@@ -266,6 +267,6 @@ type TaxSubtotalTemplateData struct {
 
 // TaxCategoryTemplateData categoría de impuesto
 type TaxCategoryTemplateData struct {
-	ID   string
-	Name string
+	Percent   string
+	TaxScheme TaxSchemeTemplateData
 }
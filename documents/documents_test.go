@@ -1,13 +1,27 @@
 package documents_test
 
 import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/diegofxm/ubl21-dian/catalogs"
+	"github.com/diegofxm/ubl21-dian/documents/applicationresponse"
 	"github.com/diegofxm/ubl21-dian/documents/attached"
+	"github.com/diegofxm/ubl21-dian/documents/common/types"
 	"github.com/diegofxm/ubl21-dian/documents/creditnote"
 	"github.com/diegofxm/ubl21-dian/documents/debitnote"
 	"github.com/diegofxm/ubl21-dian/documents/invoice"
+	"github.com/diegofxm/ubl21-dian/documents/supportdocument"
+	"github.com/diegofxm/ubl21-dian/signature"
+	"github.com/diegofxm/ubl21-dian/soap/simulator"
+	xmlpkg "github.com/diegofxm/ubl21-dian/xml"
 )
 
 // TestDocumentsRefactoring prueba la nueva estructura modular de documents/
@@ -196,3 +210,354 @@ func TestRefactoringGoals(t *testing.T) {
 		t.Log("✓ Modelos DIAN compartidos")
 	})
 }
+
+// TestParseRoundTrip parsea cada tipo de documento al modelo y lo re-serializa
+func TestParseRoundTrip(t *testing.T) {
+	creds, err := simulator.NewTestCredentials(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := signature.NewSignerFromPEM(creds.CertPath, creds.KeyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	supplier := roundTripParty("MI EMPRESA SAS", "900123456")
+	customer := roundTripParty("CLIENTE SAS", "800111222")
+	tax := invoice.TaxSubtotalTemplateData{
+		TaxableAmount: "100000.00", TaxAmount: "19000.00", CurrencyID: "COP", Percent: "19.00",
+		TaxCategory: invoice.TaxCategoryTemplateData{Percent: "19.00", TaxScheme: invoice.TaxSchemeTemplateData{ID: "01", Name: "IVA"}},
+	}
+	invoiceXML, err := invoice.NewBuilder().
+		SetProfileExecutionID("2").
+		SetInvoiceData("SETP990000001", "cufe123", "2025-06-01", "10:00:00-05:00", "2025-06-30").
+		SetDianExtensions("18760000001", "2019-01-19", "2030-01-19", "SETP", "990000000", "995000000",
+			"900123456", "8", "31", "56f2ae4e-9812-4fad-9255-08fcfcd5ccb0", "", "").
+		SetSupplier(supplier).
+		SetCustomer(customer).
+		SetPaymentMeans("2", "10", "2025-06-30").
+		SetMonetaryTotals("100000.00", "100000.00", "119000.00", "", "119000.00").
+		AddTaxTotal(invoice.TaxTotalTemplateData{TaxAmount: "19000.00", CurrencyID: "COP", TaxSubtotals: []invoice.TaxSubtotalTemplateData{tax}}).
+		AddInvoiceLine(invoice.InvoiceLineTemplateData{
+			ID: "1", UnitCode: "94", Quantity: "1.000000", LineExtensionAmount: "100000.00", FreeOfChargeIndicator: "false", CurrencyID: "COP",
+			Item:     invoice.ItemTemplateData{Description: "Servicio", StandardItemID: invoice.ItemIDTemplateData{ID: "P001", SchemeID: "999"}},
+			Price:    invoice.PriceTemplateData{Amount: "100000.00", BaseQuantity: "1.000000"},
+			TaxTotal: &invoice.TaxTotalTemplateData{TaxAmount: "19000.00", CurrencyID: "COP", TaxSubtotals: []invoice.TaxSubtotalTemplateData{tax}},
+		}).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedXML, err := signer.SignXML(invoiceXML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	creditNoteXML, err := creditnote.NewBuilder().
+		SetProfileExecutionID("2").
+		SetCreditNoteData("NC1", "cude123", "2025-06-02", "10:00:00-05:00").
+		SetNote("Anulación").
+		SetDianExtensions("18760000001", "2019-01-19", "2030-01-19", "NC", "1", "1000",
+			"900123456", "8", "31", "56f2ae4e-9812-4fad-9255-08fcfcd5ccb0", "", "").
+		SetBillingReference("SETP990000001", "cufe123", "2025-06-01").
+		SetSupplier(creditnote.PartyTemplateData{AdditionalAccountID: "1", PartyName: supplier.PartyName, TaxScheme: creditnote.TaxSchemeTemplateData(supplier.TaxScheme)}).
+		SetCustomer(creditnote.PartyTemplateData{AdditionalAccountID: "1", PartyName: customer.PartyName, TaxScheme: creditnote.TaxSchemeTemplateData(customer.TaxScheme)}).
+		SetTotals("100000.00", "100000.00", "100000.00", "100000.00").
+		AddLine(creditnote.CreditNoteLineTemplateData{
+			ID: "1", UnitCode: "94", Quantity: "1.000000", LineExtensionAmount: "100000.00", FreeOfChargeIndicator: "false", CurrencyID: "COP",
+			Item:  creditnote.ItemTemplateData{Description: "Servicio", StandardItemID: creditnote.ItemIDTemplateData{ID: "P001", SchemeID: "999"}},
+			Price: creditnote.PriceTemplateData{Amount: "100000.00", BaseQuantity: "1.000000"},
+		}).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	debitNoteXML, err := debitnote.NewBuilder().
+		SetProfileExecutionID("2").
+		SetDebitNoteData("ND1", "cude456", "2025-06-03", "10:00:00-05:00").
+		SetNote("Intereses").
+		SetDianExtensions("18760000001", "2019-01-19", "2030-01-19", "ND", "1", "1000",
+			"900123456", "8", "31", "56f2ae4e-9812-4fad-9255-08fcfcd5ccb0", "", "").
+		SetBillingReference("SETP990000001", "cufe123", "2025-06-01").
+		SetSupplier(debitnote.PartyTemplateData{AdditionalAccountID: "1", PartyName: supplier.PartyName, TaxScheme: debitnote.TaxSchemeTemplateData(supplier.TaxScheme)}).
+		SetCustomer(debitnote.PartyTemplateData{AdditionalAccountID: "1", PartyName: customer.PartyName, TaxScheme: debitnote.TaxSchemeTemplateData(customer.TaxScheme)}).
+		SetTotals("5000.00", "5000.00", "5000.00", "5000.00").
+		AddLine(debitnote.DebitNoteLineTemplateData{
+			ID: "1", UnitCode: "94", Quantity: "1.000000", LineExtensionAmount: "5000.00", FreeOfChargeIndicator: "false", CurrencyID: "COP",
+			Item:  debitnote.ItemTemplateData{Description: "Intereses", StandardItemID: debitnote.ItemIDTemplateData{ID: "P002", SchemeID: "999"}},
+			Price: debitnote.PriceTemplateData{Amount: "5000.00", BaseQuantity: "1.000000"},
+		}).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sdParty := func(name, nit string) supportdocument.PartyTemplateData {
+		return supportdocument.PartyTemplateData{
+			PersonType: "1", ID: nit, DocumentType: "31", Name: name, TaxLevelCode: "R-99-PN", TaxSchemeID: "01", TaxSchemeName: "IVA",
+			Address: supportdocument.AddressTemplateData{ID: "11001", CityName: "Bogotá", CountrySubentity: "Bogotá", CountrySubentityCode: "11",
+				AddressLine: "Calle 1 # 2-3", CountryCode: "CO", CountryName: "Colombia"},
+		}
+	}
+	supportDocumentXML, err := supportdocument.NewBuilder().
+		SetProfileExecutionID("2").
+		SetSupportDocumentData("DS1", "cuds123", "2025-06-04", "10:00:00-05:00").
+		AddNote("Compra a no obligado").
+		SetDianExtensions("18760000001", "2019-01-19", "2030-01-19", "DS", "1", "1000",
+			"900123456", "8", "31", "56f2ae4e-9812-4fad-9255-08fcfcd5ccb0", "", "").
+		SetSupplier(sdParty("PROVEEDOR PERSONA NATURAL", "800111222")).
+		SetBuyer(sdParty("MI EMPRESA SAS", "900123456")).
+		SetTotals("50000.00", "0.00", "50000.00", "50000.00").
+		AddLine(supportdocument.SupportDocumentLineTemplateData{
+			ID: "1", UnitCode: "94", Quantity: "1.000000", LineExtensionAmount: "50000.00", FreeOfChargeIndicator: "false", CurrencyID: "COP",
+			Item:  supportdocument.ItemTemplateData{Description: "Asesoría", StandardItemID: supportdocument.ItemIDTemplateData{ID: "P003", SchemeID: "999"}},
+			Price: supportdocument.PriceTemplateData{Amount: "50000.00", BaseQuantity: "1.000000"},
+		}).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	responseXML, err := applicationresponse.NewBuilder().
+		SetID("RPTA1").
+		SetCUDE("cude789").
+		SetIssueDate("2025-06-01").
+		SetIssueTime("10:01:00-05:00").
+		SetProfileExecutionID("2").
+		SetSenderParty(applicationresponse.PartyData{RegistrationName: "Unidad Especial Dirección de Impuestos y Aduanas Nacionales",
+			CompanyID: "800197268", SchemeName: "31", TaxSchemeID: "01", TaxSchemeName: "IVA"}).
+		SetReceiverParty(applicationresponse.PartyData{RegistrationName: "MI EMPRESA SAS", CompanyID: "900123456", SchemeName: "31", TaxSchemeID: "01", TaxSchemeName: "IVA"}).
+		SetResponse("02", "Documento validado por la DIAN").
+		SetDocumentReference(applicationresponse.DocumentReferenceData{ID: "SETP990000001", UUID: "cufe123",
+			IssueDate: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), DocumentTypeCode: "01"}).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attachedXML, err := attached.NewBuilder().
+		SetProfileExecutionID("2").
+		SetID("cufe123").
+		SetIssueDate(time.Date(2025, 6, 1, 10, 5, 0, 0, time.UTC)).
+		SetParentDocumentID("SETP990000001").
+		SetSender(attached.PartyData{RegistrationName: "MI EMPRESA SAS", CompanyID: "900123456", SchemeName: "31", TaxSchemeID: "01", TaxSchemeName: "IVA"}).
+		SetReceiver(attached.PartyData{RegistrationName: "CLIENTE SAS", CompanyID: "800111222", SchemeName: "31", TaxSchemeID: "01", TaxSchemeName: "IVA"}).
+		SetSignedInvoiceXML(string(signedXML)).
+		SetApplicationResponse(attached.ApplicationResponseData{
+			InvoiceID: "SETP990000001", CUFE: "cufe123", IssueDate: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), ResponseXML: responseXML,
+			ValidationResultCode: "02", ValidationDate: "2025-06-01", ValidationTime: "10:01:00-05:00",
+		}).
+		ToXML()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mismo documento con otros prefijos, como lo generan otros proveedores
+	foreignXML := strings.NewReplacer("cbc:", "b:", "xmlns:cbc=", "xmlns:b=", "cac:", "a:", "xmlns:cac=", "xmlns:a=").Replace(string(signedXML))
+
+	tests := []struct {
+		name  string
+		data  []byte
+		parse func([]byte) (interface{}, error)
+	}{
+		{"signed invoice", signedXML, func(data []byte) (interface{}, error) { return invoice.ParseFromXML(data) }},
+		{"foreign prefixes", []byte(foreignXML), func(data []byte) (interface{}, error) { return invoice.ParseFromXML(data) }},
+		{"credit note", creditNoteXML, func(data []byte) (interface{}, error) { return creditnote.ParseFromXML(data) }},
+		{"debit note", debitNoteXML, func(data []byte) (interface{}, error) { return debitnote.ParseFromXML(data) }},
+		{"support document", []byte(supportDocumentXML), func(data []byte) (interface{}, error) { return supportdocument.ParseFromXML(data) }},
+		{"application response", []byte(responseXML), func(data []byte) (interface{}, error) {
+			var m applicationresponse.ApplicationResponseXML
+			err := xmlpkg.Unmarshal(data, &m)
+			return &m, err
+		}},
+		{"attached document", attachedXML, func(data []byte) (interface{}, error) {
+			var m attached.AttachedDocumentXML
+			err := xmlpkg.Unmarshal(data, &m)
+			return &m, err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Extensión de un tercero que el modelo no conoce
+			data := bytes.Replace(tt.data, []byte("</ext:UBLExtensions>"), []byte(unknownExtension+"</ext:UBLExtensions>"), 1)
+			if bytes.Equal(data, tt.data) {
+				t.Fatal("Document without ext:UBLExtensions")
+			}
+			model, err := tt.parse(data)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if !strings.Contains(extensionsOf(model), "<acme:Custom") {
+				t.Error("Unknown extension was not kept as raw XML")
+			}
+			rebuilt, err := xmlpkg.Marshal(model)
+			if err != nil {
+				t.Fatal(err)
+			}
+			original, roundTrip := xmlTokens(t, data), xmlTokens(t, rebuilt)
+			for i := 0; i < len(original) || i < len(roundTrip); i++ {
+				if i >= len(original) || i >= len(roundTrip) || original[i] != roundTrip[i] {
+					t.Fatalf("Documents differ at token %d:\n%s\n%s", i, tokenAt(original, i), tokenAt(roundTrip, i))
+				}
+			}
+		})
+	}
+
+	// Las extensiones quedan como XML crudo
+	inv, err := invoice.ParseFromXML([]byte(foreignXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inv.ID.Value != "SETP990000001" || strings.TrimSpace(inv.AccountingCustomerParty.Party.PartyTaxScheme[0].CompanyID.Value) != "800111222" {
+		t.Errorf("Unexpected model: ID %q", inv.ID.Value)
+	}
+	extensions := inv.UBLExtensions.UBLExtension
+	if len(extensions) != 3 || !strings.Contains(extensions[0].ExtensionContent.InnerXML, "<sts:InvoiceAuthorization>18760000001</sts:InvoiceAuthorization>") ||
+		!strings.Contains(extensions[2].ExtensionContent.InnerXML, "<ds:SignatureValue") {
+		t.Errorf("Expected raw DianExtensions and signature, got %d extensions", len(extensions))
+	}
+}
+
+func TestParseNamespaces(t *testing.T) {
+	const doc = `<Invoice xmlns="urn:oasis:names:specification:ubl:schema:xsd:Invoice-2" %s>` +
+		`<ext:UBLExtensions><ext:UBLExtension><ext:ExtensionContent><acme:Custom acme:kind="a">x</acme:Custom></ext:ExtensionContent></ext:UBLExtension></ext:UBLExtensions>` +
+		`<cbc:ID>SETP990000001</cbc:ID></Invoice>`
+	const ext = `xmlns:ext="urn:oasis:names:specification:ubl:schema:xsd:CommonExtensionComponents-2" xmlns:acme="urn:acme"`
+
+	// El prefijo cbc de otro namespace no es cbc
+	inv, err := invoice.ParseFromXML([]byte(fmt.Sprintf(doc, ext+` xmlns:cbc="urn:acme:cbc"`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inv.ID.Value != "" {
+		t.Errorf("Expected no cbc:ID from another namespace, got %q", inv.ID.Value)
+	}
+	// Namespace desconocido declarado fuera del XML crudo
+	content := inv.UBLExtensions.UBLExtension[0].ExtensionContent.InnerXML
+	var custom struct {
+		XMLName xml.Name
+		Kind    string `xml:"urn:acme kind,attr"`
+	}
+	if err := xml.Unmarshal([]byte(content), &custom); err != nil || custom.XMLName != (xml.Name{Space: "urn:acme", Local: "Custom"}) || custom.Kind != "a" {
+		t.Errorf("Expected {urn:acme}Custom in raw extension, got %s (%v)", content, err)
+	}
+
+	if _, err := invoice.ParseFromXML([]byte(fmt.Sprintf(doc, ext))); !errors.Is(err, xmlpkg.ErrUnboundPrefix) {
+		t.Errorf("Expected ErrUnboundPrefix, got %v", err)
+	}
+}
+
+// unknownExtension extensión UBL de un tercero
+const unknownExtension = `<ext:UBLExtension><ext:ExtensionContent><acme:Custom xmlns:acme="urn:acme">x</acme:Custom></ext:ExtensionContent></ext:UBLExtension>`
+
+// extensionsOf concatena el XML crudo de las extensiones del modelo
+func extensionsOf(model interface{}) string {
+	var extensions []types.UBLExtension
+	switch m := model.(type) {
+	case *invoice.InvoiceXML:
+		extensions = m.UBLExtensions.UBLExtension
+	case *creditnote.CreditNoteXML:
+		extensions = m.UBLExtensions.UBLExtension
+	case *debitnote.DebitNoteXML:
+		extensions = m.UBLExtensions.UBLExtension
+	case *supportdocument.SupportDocumentXML:
+		extensions = m.UBLExtensions.UBLExtension
+	case *applicationresponse.ApplicationResponseXML:
+		extensions = m.UBLExtensions.UBLExtension
+	case *attached.AttachedDocumentXML:
+		extensions = m.UBLExtensions.UBLExtension
+	}
+	var raw strings.Builder
+	for _, extension := range extensions {
+		raw.WriteString(extension.ExtensionContent.InnerXML)
+	}
+	return raw.String()
+}
+
+func TestBuilderCatalogCodes(t *testing.T) {
+	unknownLevel := roundTripParty("MI EMPRESA SAS", "900123456")
+	unknownLevel.TaxScheme.TaxLevelCode = "O-13;O-99"
+	line := invoice.InvoiceLineTemplateData{ID: "1", UnitCode: "XYZ", Quantity: "1.000000"}
+	sdTax := supportdocument.TaxTotalTemplateData{TaxSubtotals: []supportdocument.TaxSubtotalTemplateData{
+		{TaxCategory: supportdocument.TaxCategoryTemplateData{ID: "99", Name: "Otro"}},
+	}}
+
+	tests := []struct {
+		name string
+		want string
+		err  error
+	}{
+		{"supplier", "invalid supplier codes", buildErr(invoice.NewBuilder().SetSupplier(unknownLevel).Build())},
+		{"line", "invalid line 1", buildErr(invoice.NewBuilder().AddInvoiceLine(line).Build())},
+		{"payment means", "invalid payment means", buildErr(invoice.NewBuilder().SetPaymentMeans("3", "10", "").Build())},
+		{"credit note customer", "invalid customer codes",
+			buildErr(creditnote.NewBuilder().SetCustomer(creditnote.PartyTemplateData{AdditionalAccountID: "9"}).Build())},
+		{"debit note tax total", "invalid tax total", buildErr(debitnote.NewBuilder().AddTaxTotal(debitnote.TaxTotalTemplateData{
+			TaxSubtotals: []debitnote.TaxSubtotalTemplateData{{TaxCategory: debitnote.TaxCategoryTemplateData{TaxScheme: debitnote.TaxSchemeTemplateData{ID: "99"}}}},
+		}).Build())},
+		{"support document withholding", "invalid withholding tax total", buildErr(supportdocument.NewBuilder().AddWithholdingTaxTotal(sdTax).Build())},
+	}
+	for _, tt := range tests {
+		if !errors.Is(tt.err, catalogs.ErrUnknownCode) || !strings.Contains(tt.err.Error(), tt.want) {
+			t.Errorf("%s: expected %q with ErrUnknownCode, got %v", tt.name, tt.want, tt.err)
+		}
+	}
+}
+
+// buildErr error de Build (el documento se descarta)
+func buildErr[T any](_ T, err error) error {
+	return err
+}
+
+func roundTripParty(name, nit string) invoice.PartyTemplateData {
+	return invoice.PartyTemplateData{
+		AdditionalAccountID: "1",
+		PartyName:           name,
+		Address:             invoice.AddressTemplateData{ID: "11001", CityName: "Bogotá", CountrySubentity: "Bogotá", CountrySubentityCode: "11", Line: "Calle 1 # 2-3", CountryCode: "CO", CountryName: "Colombia"},
+		TaxScheme:           invoice.TaxSchemeTemplateData{RegistrationName: name, CompanyID: nit, CompanyIDSchemeName: "31", TaxLevelCode: "O-13", ID: "01", Name: "IVA"},
+		LegalEntity:         invoice.LegalEntityTemplateData{RegistrationName: name, CompanyID: nit, CompanyIDSchemeName: "31"},
+	}
+}
+
+// xmlTokens elementos, atributos y texto con namespaces resueltos; ignora
+// declaraciones, espacios entre elementos y atributos vacíos
+func xmlTokens(t *testing.T, data []byte) []string {
+	t.Helper()
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var tokens []string
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return tokens
+		}
+		if err != nil {
+			t.Fatalf("Invalid XML: %v", err)
+		}
+		switch tok := token.(type) {
+		case xml.StartElement:
+			var attrs []string
+			for _, a := range tok.Attr {
+				if a.Name.Space == "xmlns" || a.Name.Local == "xmlns" || a.Value == "" {
+					continue
+				}
+				attrs = append(attrs, a.Name.Space+" "+a.Name.Local+"="+a.Value)
+			}
+			sort.Strings(attrs)
+			tokens = append(tokens, fmt.Sprintf("<{%s}%s %v>", tok.Name.Space, tok.Name.Local, attrs))
+		case xml.EndElement:
+			tokens = append(tokens, fmt.Sprintf("</{%s}%s>", tok.Name.Space, tok.Name.Local))
+		case xml.CharData:
+			if text := strings.TrimSpace(string(tok)); text != "" {
+				tokens = append(tokens, text)
+			}
+		}
+	}
+}
+
+func tokenAt(tokens []string, i int) string {
+	if i < len(tokens) {
+		return tokens[i]
+	}
+	return "(end)"
+}
@@ -3,9 +3,12 @@ package invoice
 import (
 	"bytes"
 	"embed"
+	"errors"
 	"fmt"
 
+	"github.com/diegofxm/ubl21-dian/catalogs"
 	"github.com/diegofxm/ubl21-dian/documents/common"
+	"github.com/diegofxm/ubl21-dian/identification"
 )
 
 //go:embed templates/*.tmpl
@@ -14,6 +17,7 @@ var templatesFS embed.FS
 // Builder construye facturas usando templates
 type Builder struct {
 	data InvoiceTemplateData
+	err  error // Primer error de los setters; lo retorna Build
 }
 
 // NewBuilder crea un nuevo builder basado en templates
@@ -56,12 +60,24 @@ func (b *Builder) SetDianExtensions(auth, startDate, endDate, prefix, from, to,
 
 // SetSupplier establece los datos del proveedor
 func (b *Builder) SetSupplier(supplier PartyTemplateData) *Builder {
+	if err := normalizeParty(&supplier); err != nil && b.err == nil {
+		b.err = fmt.Errorf("invalid supplier identification: %w", err)
+	}
+	if err := validateParty(supplier); err != nil && b.err == nil {
+		b.err = fmt.Errorf("invalid supplier codes: %w", err)
+	}
 	b.data.Supplier = supplier
 	return b
 }
 
 // SetCustomer establece los datos del cliente
 func (b *Builder) SetCustomer(customer PartyTemplateData) *Builder {
+	if err := normalizeParty(&customer); err != nil && b.err == nil {
+		b.err = fmt.Errorf("invalid customer identification: %w", err)
+	}
+	if err := validateParty(customer); err != nil && b.err == nil {
+		b.err = fmt.Errorf("invalid customer codes: %w", err)
+	}
 	b.data.Customer = customer
 	return b
 }
@@ -74,6 +90,9 @@ func (b *Builder) SetDelivery(delivery *DeliveryTemplateData) *Builder {
 
 // SetPaymentMeans agrega un medio de pago
 func (b *Builder) SetPaymentMeans(id, code, dueDate string) *Builder {
+	if err := errors.Join(catalogs.PaymentMethods.Validate(id), catalogs.PaymentMeans.Validate(code)); err != nil && b.err == nil {
+		b.err = fmt.Errorf("invalid payment means: %w", err)
+	}
 	b.data.PaymentMeans = append(b.data.PaymentMeans, PaymentMeansTemplateData{
 		ID:      id,
 		Code:    code,
@@ -94,6 +113,9 @@ func (b *Builder) SetMonetaryTotals(lineExt, taxExcl, taxIncl, prepaid, payable
 
 // AddInvoiceLine agrega una línea de factura
 func (b *Builder) AddInvoiceLine(line InvoiceLineTemplateData) *Builder {
+	if err := validateLine(line.UnitCode, line.TaxTotal); err != nil && b.err == nil {
+		b.err = fmt.Errorf("invalid line %s: %w", line.ID, err)
+	}
 	b.data.InvoiceLines = append(b.data.InvoiceLines, line)
 	b.data.LineCount = len(b.data.InvoiceLines)
 	return b
@@ -114,12 +136,19 @@ func (b *Builder) SetNote(note string) *Builder {
 
 // AddTaxTotal agrega un total de impuestos
 func (b *Builder) AddTaxTotal(taxTotal TaxTotalTemplateData) *Builder {
+	if err := validateTaxTotal(taxTotal); err != nil && b.err == nil {
+		b.err = fmt.Errorf("invalid tax total: %w", err)
+	}
 	b.data.TaxTotals = append(b.data.TaxTotals, taxTotal)
 	return b
 }
 
 // Build genera el XML de la factura usando templates
 func (b *Builder) Build() ([]byte, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
 	// Cargar templates comunes + específicos
 	tmpl, err := common.LoadCommonAndSpecificTemplates(templatesFS, "templates/*.tmpl")
 	if err != nil {
@@ -139,3 +168,53 @@ func (b *Builder) Build() ([]byte, error) {
 func (b *Builder) GetData() InvoiceTemplateData {
 	return b.data
 }
+
+// normalizeParty normaliza CompanyID y calcula o verifica el DV del NIT
+// en el esquema tributario y la entidad legal
+func normalizeParty(party *PartyTemplateData) error {
+	var err error
+	tax := &party.TaxScheme
+	if tax.CompanyID, tax.CompanyIDSchemeID, err = identification.NormalizeParty(tax.CompanyIDSchemeName, tax.CompanyID, tax.CompanyIDSchemeID); err != nil {
+		return err
+	}
+	legal := &party.LegalEntity
+	legal.CompanyID, legal.CompanyIDSchemeID, err = identification.NormalizeParty(legal.CompanyIDSchemeName, legal.CompanyID, legal.CompanyIDSchemeID)
+	return err
+}
+
+// validateParty valida los códigos de la parte contra los catálogos de DIAN
+func validateParty(party PartyTemplateData) error {
+	return catalogs.PartyCodes{
+		IdentificationType: party.TaxScheme.CompanyIDSchemeName,
+		OrganizationType:   party.AdditionalAccountID,
+		TaxLevelCodes:      party.TaxScheme.TaxLevelCode,
+		TaxScheme:          party.TaxScheme.ID,
+		Municipality:       party.Address.ID,
+		Department:         party.Address.CountrySubentityCode,
+		Country:            party.Address.CountryCode,
+		CIIU:               party.IndustryClassificationCode,
+	}.Validate()
+}
+
+// validateTaxTotal valida los tributos de los subtotales (vacíos se omiten)
+func validateTaxTotal(taxTotal TaxTotalTemplateData) error {
+	for _, subtotal := range taxTotal.TaxSubtotals {
+		if id := subtotal.TaxCategory.TaxScheme.ID; id != "" {
+			if err := catalogs.TaxSchemes.Validate(id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateLine valida la unidad de medida y los tributos de una línea
+func validateLine(unitCode string, taxTotal *TaxTotalTemplateData) error {
+	if err := catalogs.UnitCodes.Validate(unitCode); err != nil {
+		return err
+	}
+	if taxTotal == nil {
+		return nil
+	}
+	return validateTaxTotal(*taxTotal)
+}
@@ -0,0 +1,24 @@
+package invoice
+
+import (
+	"fmt"
+
+	xmlpkg "github.com/diegofxm/ubl21-dian/xml"
+)
+
+// ParseFromXML parsea un XML de factura (firmado o no) en el modelo
+//
+// Los prefijos se resuelven por namespace; las extensiones (sts:DianExtensions,
+// ds:Signature y otras) quedan como XML crudo en UBLExtensions.
+func ParseFromXML(xmlData []byte) (*InvoiceXML, error) {
+	var doc InvoiceXML
+	if err := xmlpkg.Unmarshal(xmlData, &doc); err != nil {
+		return nil, fmt.Errorf("error parsing Invoice XML: %w", err)
+	}
+	return &doc, nil
+}
+
+// ParseFromString parsea un string XML de factura
+func ParseFromString(xmlString string) (*InvoiceXML, error) {
+	return ParseFromXML([]byte(xmlString))
+}
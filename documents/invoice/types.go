@@ -20,7 +20,7 @@ type PartyData struct {
 	DV               string // Dígito de verificación
 	DocumentType     string // "31" = NIT, "13" = Cédula, "22" = CE, etc.
 	Name             string
-	TaxLevelCode     string // "O-13" = Responsable IVA, "O-47" = Régimen simple, etc.
+	TaxLevelCode     string // "O-13" = Gran contribuyente, "O-47" = Régimen simple, etc.
 	TaxSchemeID      string // "01" = IVA, "04" = INC, etc.
 	TaxSchemeName    string
 	ResolutionPrefix string // Prefijo de la resolución (ej: "SETP", "FESG")
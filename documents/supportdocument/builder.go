@@ -5,7 +5,9 @@ import (
 	"embed"
 	"fmt"
 
+	"github.com/diegofxm/ubl21-dian/catalogs"
 	"github.com/diegofxm/ubl21-dian/documents/common"
+	"github.com/diegofxm/ubl21-dian/identification"
 )
 
 //go:embed templates/*.tmpl
@@ -14,6 +16,7 @@ var templatesFS embed.FS
 // Builder constructor para SupportDocument
 type Builder struct {
 	data SupportDocumentTemplateData
+	err  error // Primer error de los setters; lo retorna Build
 }
 
 // NewBuilder crea un nuevo builder de SupportDocument
@@ -102,18 +105,33 @@ func (b *Builder) AddBillingReference(invoiceID, uuid, issueDate string) *Builde
 
 // SetBuyer establece el comprador
 func (b *Builder) SetBuyer(buyer PartyTemplateData) *Builder {
+	if err := normalizeParty(&buyer); err != nil && b.err == nil {
+		b.err = fmt.Errorf("invalid buyer identification: %w", err)
+	}
+	if err := validateParty(buyer); err != nil && b.err == nil {
+		b.err = fmt.Errorf("invalid buyer codes: %w", err)
+	}
 	b.data.Buyer = buyer
 	return b
 }
 
 // SetSupplier establece el proveedor
 func (b *Builder) SetSupplier(supplier PartyTemplateData) *Builder {
+	if err := normalizeParty(&supplier); err != nil && b.err == nil {
+		b.err = fmt.Errorf("invalid supplier identification: %w", err)
+	}
+	if err := validateParty(supplier); err != nil && b.err == nil {
+		b.err = fmt.Errorf("invalid supplier codes: %w", err)
+	}
 	b.data.Supplier = supplier
 	return b
 }
 
 // AddLine agrega una línea
 func (b *Builder) AddLine(line SupportDocumentLineTemplateData) *Builder {
+	if err := catalogs.UnitCodes.Validate(line.UnitCode); err != nil && b.err == nil {
+		b.err = fmt.Errorf("invalid line %s: %w", line.ID, err)
+	}
 	b.data.SupportDocumentLines = append(b.data.SupportDocumentLines, line)
 	return b
 }
@@ -129,18 +147,28 @@ func (b *Builder) SetTotals(lineExt, taxExc, taxInc, payable string) *Builder {
 
 // AddTaxTotal agrega total de impuestos
 func (b *Builder) AddTaxTotal(taxTotal TaxTotalTemplateData) *Builder {
+	if err := validateTaxTotal(taxTotal); err != nil && b.err == nil {
+		b.err = fmt.Errorf("invalid tax total: %w", err)
+	}
 	b.data.TaxTotals = append(b.data.TaxTotals, taxTotal)
 	return b
 }
 
 // AddWithholdingTaxTotal agrega total de retenciones
 func (b *Builder) AddWithholdingTaxTotal(taxTotal TaxTotalTemplateData) *Builder {
+	if err := validateTaxTotal(taxTotal); err != nil && b.err == nil {
+		b.err = fmt.Errorf("invalid withholding tax total: %w", err)
+	}
 	b.data.WithholdingTaxTotals = append(b.data.WithholdingTaxTotals, taxTotal)
 	return b
 }
 
 // Build genera el XML del SupportDocument
 func (b *Builder) Build() (string, error) {
+	if b.err != nil {
+		return "", b.err
+	}
+
 	// Actualizar line count
 	b.data.LineCount = len(b.data.SupportDocumentLines)
 	
@@ -158,3 +186,41 @@ func (b *Builder) Build() (string, error) {
 	
 	return buf.String(), nil
 }
+
+// GetData retorna los datos actuales del builder
+func (b *Builder) GetData() SupportDocumentTemplateData {
+	return b.data
+}
+
+// normalizeParty normaliza el número y calcula o verifica el DV del NIT
+func normalizeParty(party *PartyTemplateData) error {
+	var err error
+	party.ID, party.DV, err = identification.NormalizeParty(party.DocumentType, party.ID, party.DV)
+	return err
+}
+
+// validateParty valida los códigos de la parte contra los catálogos de DIAN
+func validateParty(party PartyTemplateData) error {
+	return catalogs.PartyCodes{
+		IdentificationType: party.DocumentType,
+		OrganizationType:   party.PersonType,
+		TaxLevelCodes:      party.TaxLevelCode,
+		TaxScheme:          party.TaxSchemeID,
+		Municipality:       party.Address.ID,
+		Department:         party.Address.CountrySubentityCode,
+		Country:            party.Address.CountryCode,
+		CIIU:               party.IndustryClassificationCode,
+	}.Validate()
+}
+
+// validateTaxTotal valida los tributos de los subtotales (vacíos se omiten)
+func validateTaxTotal(taxTotal TaxTotalTemplateData) error {
+	for _, subtotal := range taxTotal.TaxSubtotals {
+		if id := subtotal.TaxCategory.ID; id != "" {
+			if err := catalogs.TaxSchemes.Validate(id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
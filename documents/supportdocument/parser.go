@@ -0,0 +1,24 @@
+package supportdocument
+
+import (
+	"fmt"
+
+	xmlpkg "github.com/diegofxm/ubl21-dian/xml"
+)
+
+// ParseFromXML parsea un XML de documento soporte (firmado o no) en el modelo
+//
+// Los prefijos se resuelven por namespace; las extensiones (sts:DianExtensions,
+// ds:Signature y otras) quedan como XML crudo en UBLExtensions.
+func ParseFromXML(xmlData []byte) (*SupportDocumentXML, error) {
+	var doc SupportDocumentXML
+	if err := xmlpkg.Unmarshal(xmlData, &doc); err != nil {
+		return nil, fmt.Errorf("error parsing SupportDocument XML: %w", err)
+	}
+	return &doc, nil
+}
+
+// ParseFromString parsea un string XML de documento soporte
+func ParseFromString(xmlString string) (*SupportDocumentXML, error) {
+	return ParseFromXML([]byte(xmlString))
+}
@@ -0,0 +1,236 @@
+// Package identification normaliza y valida números de identificación DIAN
+//
+// Cubre todos los tipos de documento de la lista de DIAN (NIT con dígito de
+// verificación, cédula, cédula de extranjería, tarjeta de identidad,
+// pasaporte, PEP/PPT, NIT de otro país, NUIP, ...):
+//
+//	id, err := identification.Parse(identification.NIT, "900.123.456-8")
+//	// id.Number = "900123456", id.DV = "8", id.Format() = "900.123.456-8"
+//
+//	err = identification.Validate(identification.CitizenID, "1.020.304.050")
+//	var idErr *identification.Error
+//	errors.As(err, &idErr) // idErr.Type, idErr.Number; errors.Is(err, ErrInvalidLength)
+package identification
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/diegofxm/ubl21-dian/catalogs"
+)
+
+var (
+	// ErrEmpty el número de identificación está vacío
+	ErrEmpty = errors.New("identification number is empty")
+	// ErrUnknownType el tipo de documento no está en la lista de DIAN
+	ErrUnknownType = errors.New("unknown identification type")
+	// ErrInvalidFormat el número tiene caracteres no permitidos para el tipo
+	ErrInvalidFormat = errors.New("invalid identification number format")
+	// ErrInvalidLength el número es muy corto o muy largo para el tipo
+	ErrInvalidLength = errors.New("invalid identification number length")
+	// ErrCheckDigit el dígito de verificación del NIT no corresponde
+	ErrCheckDigit = errors.New("check digit does not match")
+)
+
+// Type tipo de documento de identificación (código de catalogs.IdentificationTypes)
+type Type string
+
+const (
+	CivilRegistry   Type = "11" // Registro civil
+	IdentityCard    Type = "12" // Tarjeta de identidad
+	CitizenID       Type = "13" // Cédula de ciudadanía
+	ForeignerCard   Type = "21" // Tarjeta de extranjería
+	ForeignerID     Type = "22" // Cédula de extranjería
+	NIT             Type = "31"
+	Passport        Type = "41"
+	ForeignDocument Type = "42" // Documento de identificación extranjero
+	PEP             Type = "47" // Permiso Especial de Permanencia
+	PPT             Type = "48" // Permiso por Protección Temporal
+	ForeignNIT      Type = "50" // NIT de otro país
+	NUIP            Type = "91" // Número Único de Identificación Personal
+)
+
+// Name nombre del tipo de documento ("Cédula de ciudadanía")
+func (t Type) Name() string {
+	if name := catalogs.IdentificationTypes.NameOf(string(t)); name != "" {
+		return name
+	}
+	return string(t)
+}
+
+// Error identificación inválida; errors.Is funciona con los errores Err*
+type Error struct {
+	Type   Type
+	Number string // Número tal como se recibió
+	Err    error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("invalid %s %q: %v", e.Type.Name(), e.Number, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// charset caracteres permitidos en el número
+type charset int
+
+const (
+	digits       charset = iota // Solo dígitos
+	alphanumeric                // Letras y dígitos
+	foreign                     // Letras, dígitos y guiones (documentos extranjeros)
+)
+
+// rule formato de un tipo de documento
+type rule struct {
+	charset  charset
+	min, max int
+}
+
+// rules formato por tipo; los límites siguen la Registraduría, Migración
+// Colombia y el RUT (los NIT de persona natural son la cédula)
+var rules = map[Type]rule{
+	CivilRegistry:   {alphanumeric, 6, 11},
+	IdentityCard:    {digits, 10, 11},
+	CitizenID:       {digits, 3, 10},
+	ForeignerCard:   {alphanumeric, 3, 10},
+	ForeignerID:     {alphanumeric, 3, 10},
+	NIT:             {digits, 3, maxNITLength},
+	Passport:        {alphanumeric, 3, 16},
+	ForeignDocument: {foreign, 3, 20},
+	PEP:             {digits, 5, 15},
+	PPT:             {digits, 5, 15},
+	ForeignNIT:      {foreign, 3, 20},
+	NUIP:            {digits, 10, 11},
+}
+
+// FinalConsumer número genérico del consumidor final, informado como cédula
+// de ciudadanía (schemeName "13") cuando el adquirente no se identifica
+const FinalConsumer = "222222222222"
+
+// ID identificación normalizada
+type ID struct {
+	Type   Type
+	Number string // Sin puntos, espacios ni DV
+	DV     string // Dígito de verificación (solo NIT)
+}
+
+// Format número para mostrar: "900.123.456-8" para NIT, el número para los demás
+func (id ID) Format() string {
+	if id.Type != NIT {
+		return id.Number
+	}
+	return groupThousands(id.Number) + "-" + id.DV
+}
+
+func (id ID) String() string {
+	return id.Type.Name() + " " + id.Format()
+}
+
+// Normalize quita espacios y separadores del número según el tipo
+// No valida el número (ver Parse); en el NIT conserva el "-DV" si viene.
+func Normalize(t Type, number string) string {
+	number = strings.ToUpper(strings.TrimSpace(number))
+	remove := " .,\t"
+	if r, ok := rules[t]; ok && r.charset != foreign && t != NIT {
+		remove += "-"
+	}
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(remove, r) {
+			return -1
+		}
+		return r
+	}, number)
+}
+
+// Parse normaliza y valida el número según el tipo
+// Para NIT acepta el DV separado por guión ("900123456-8") y lo verifica;
+// si no viene lo calcula. La cédula acepta además FinalConsumer.
+func Parse(t Type, number string) (ID, error) {
+	fail := func(err error) (ID, error) {
+		return ID{}, &Error{Type: t, Number: number, Err: err}
+	}
+
+	r, ok := rules[t]
+	if !ok {
+		return fail(ErrUnknownType)
+	}
+	if t == NIT {
+		nit, dv, err := splitNIT(Normalize(t, number))
+		if err != nil {
+			return fail(err)
+		}
+		expected, err := checkDigit(nit)
+		if err != nil {
+			return fail(err)
+		}
+		if dv != "" && dv != expected {
+			return fail(fmt.Errorf("%w: expected %s, got %s", ErrCheckDigit, expected, dv))
+		}
+		return ID{Type: t, Number: nit, DV: expected}, nil
+	}
+
+	normalized := Normalize(t, number)
+	if t == CitizenID && normalized == FinalConsumer {
+		return ID{Type: t, Number: normalized}, nil
+	}
+	if err := r.check(normalized); err != nil {
+		return fail(err)
+	}
+	return ID{Type: t, Number: normalized}, nil
+}
+
+// Validate verifica el número según el tipo (ver Parse)
+func Validate(t Type, number string) error {
+	_, err := Parse(t, number)
+	return err
+}
+
+// check verifica caracteres y longitud de un número normalizado
+func (r rule) check(number string) error {
+	if number == "" {
+		return ErrEmpty
+	}
+	for _, c := range number {
+		switch {
+		case c >= '0' && c <= '9':
+		case c >= 'A' && c <= 'Z' && r.charset != digits:
+		case c == '-' && r.charset == foreign:
+		default:
+			return fmt.Errorf("%w: character %q", ErrInvalidFormat, c)
+		}
+	}
+	if r.charset == digits && number[0] == '0' {
+		return fmt.Errorf("%w: leading zero", ErrInvalidFormat)
+	}
+	if len(number) < r.min || len(number) > r.max {
+		return fmt.Errorf("%w: %d characters, expected %d to %d", ErrInvalidLength, len(number), r.min, r.max)
+	}
+	return nil
+}
+
+// NormalizeParty normaliza la identificación de una parte de un documento
+//
+// Recibe lo que los builders informan en cbc:CompanyID (schemeName, número y
+// schemeID) y retorna el número normalizado y el DV: para NIT lo calcula si
+// viene vacío y lo verifica si viene informado. Un número o un schemeName
+// vacío no se valida.
+func NormalizeParty(schemeName, number, dv string) (string, string, error) {
+	t := Type(strings.TrimSpace(schemeName))
+	if strings.TrimSpace(number) == "" || t == "" {
+		return number, dv, nil
+	}
+	id, err := Parse(t, number)
+	if err != nil {
+		return number, dv, err
+	}
+	if t != NIT {
+		return id.Number, dv, nil
+	}
+	if dv = strings.TrimSpace(dv); dv != "" && dv != id.DV {
+		return number, dv, &Error{Type: t, Number: number, Err: fmt.Errorf("%w: expected %s, got %s", ErrCheckDigit, id.DV, dv)}
+	}
+	return id.Number, id.DV, nil
+}
@@ -0,0 +1,213 @@
+package identification_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/diegofxm/ubl21-dian/core"
+	"github.com/diegofxm/ubl21-dian/documents/invoice"
+	"github.com/diegofxm/ubl21-dian/identification"
+)
+
+func TestCheckDigit(t *testing.T) {
+	tests := []struct {
+		nit string
+		dv  string
+	}{
+		{"900123456", "8"},
+		{"800111222", "7"},
+		{"800197268", "4"},
+		{"860.034.313", "7"},
+		{" 900 123 456 ", "8"},
+		{"123456789012345", "2"},
+	}
+	for _, tt := range tests {
+		dv, err := identification.CheckDigit(tt.nit)
+		if err != nil || dv != tt.dv {
+			t.Errorf("CheckDigit(%q): expected %s, got %q (%v)", tt.nit, tt.dv, dv, err)
+		}
+	}
+
+	// Antes panic (más de 15 dígitos) o pesos corridos (guión)
+	for _, nit := range []string{"1234567890123456", "900123456-8", "", "90012345A"} {
+		if _, err := identification.CheckDigit(nit); err == nil {
+			t.Errorf("CheckDigit(%q): expected error", nit)
+		}
+	}
+	if core.CalculateDV("1234567890123456") != -1 || core.CalculateDV("900.123.456") != 8 {
+		t.Error("core.CalculateDV should delegate to identification.CheckDigit")
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		typ    identification.Type
+		number string
+		want   identification.ID
+		format string
+	}{
+		{identification.NIT, "900.123.456-8", identification.ID{Type: identification.NIT, Number: "900123456", DV: "8"}, "900.123.456-8"},
+		{identification.NIT, "800197268", identification.ID{Type: identification.NIT, Number: "800197268", DV: "4"}, "800.197.268-4"},
+		{identification.CitizenID, "1.020.304.050", identification.ID{Type: identification.CitizenID, Number: "1020304050"}, "1020304050"},
+		{identification.ForeignerID, "e-123456", identification.ID{Type: identification.ForeignerID, Number: "E123456"}, "E123456"},
+		{identification.Passport, "ab 1234567", identification.ID{Type: identification.Passport, Number: "AB1234567"}, "AB1234567"},
+		{identification.PPT, "4567890", identification.ID{Type: identification.PPT, Number: "4567890"}, "4567890"},
+		{identification.ForeignNIT, "76.123.456-K", identification.ID{Type: identification.ForeignNIT, Number: "76123456-K"}, "76123456-K"},
+		{identification.NUIP, "1098765432", identification.ID{Type: identification.NUIP, Number: "1098765432"}, "1098765432"},
+	}
+	for _, tt := range tests {
+		id, err := identification.Parse(tt.typ, tt.number)
+		if err != nil {
+			t.Errorf("Parse(%s, %q): %v", tt.typ, tt.number, err)
+			continue
+		}
+		if id != tt.want || id.Format() != tt.format {
+			t.Errorf("Parse(%s, %q): expected %+v (%s), got %+v (%s)", tt.typ, tt.number, tt.want, tt.format, id, id.Format())
+		}
+	}
+
+	if got := (identification.ID{Type: identification.CitizenID, Number: "1020304050"}).String(); got != "Cédula de ciudadanía 1020304050" {
+		t.Errorf("Unexpected String: %q", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		typ    identification.Type
+		number string
+		err    error
+	}{
+		{identification.NIT, "900123456-3", identification.ErrCheckDigit},
+		{identification.NIT, "900123456-38", identification.ErrInvalidFormat},
+		{identification.NIT, "1234567890123456", identification.ErrInvalidLength},
+		{identification.CitizenID, "", identification.ErrEmpty},
+		{identification.CitizenID, "10203A", identification.ErrInvalidFormat},
+		{identification.CitizenID, "012345", identification.ErrInvalidFormat},
+		{identification.CitizenID, "12345678901", identification.ErrInvalidLength},
+		{identification.IdentityCard, "123456", identification.ErrInvalidLength},
+		{identification.Passport, "AB_123", identification.ErrInvalidFormat},
+		{identification.Type("99"), "123456", identification.ErrUnknownType},
+	}
+	for _, tt := range tests {
+		err := identification.Validate(tt.typ, tt.number)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Validate(%s, %q): expected %v, got %v", tt.typ, tt.number, tt.err, err)
+			continue
+		}
+		var idErr *identification.Error
+		if !errors.As(err, &idErr) || idErr.Type != tt.typ || idErr.Number != tt.number {
+			t.Errorf("Validate(%s, %q): expected *identification.Error, got %#v", tt.typ, tt.number, err)
+		}
+	}
+
+	err := core.ValidateNIT("900123456-3")
+	if !errors.Is(err, core.ErrInvalidNIT) || !errors.Is(err, identification.ErrCheckDigit) {
+		t.Errorf("core.ValidateNIT: unexpected error %v", err)
+	}
+	if err := core.ValidateNIT("800.197.268-4"); err != nil {
+		t.Errorf("core.ValidateNIT: %v", err)
+	}
+}
+
+func TestSplitFormatNIT(t *testing.T) {
+	nit, dv, err := identification.SplitNIT("900.123.456-8")
+	if err != nil || nit != "900123456" || dv != "8" {
+		t.Errorf("SplitNIT: got %q %q %v", nit, dv, err)
+	}
+	if nit, dv, err = identification.SplitNIT("900123456"); err != nil || nit != "900123456" || dv != "" {
+		t.Errorf("SplitNIT without DV: got %q %q %v", nit, dv, err)
+	}
+	if _, _, err = identification.SplitNIT("900123456-"); !errors.Is(err, identification.ErrInvalidFormat) {
+		t.Errorf("SplitNIT: expected ErrInvalidFormat, got %v", err)
+	}
+
+	if got, err := identification.FormatNIT("1234567"); err != nil || got != "1.234.567-"+mustCheckDigit(t, "1234567") {
+		t.Errorf("FormatNIT: got %q %v", got, err)
+	}
+
+	if err := identification.ValidateNIT("900123456", "8"); err != nil {
+		t.Errorf("ValidateNIT: %v", err)
+	}
+	if err := identification.ValidateNIT("900123456", "1"); !errors.Is(err, identification.ErrCheckDigit) {
+		t.Errorf("ValidateNIT: expected ErrCheckDigit, got %v", err)
+	}
+	if err := identification.ValidateNIT("", ""); !errors.Is(err, identification.ErrEmpty) {
+		t.Errorf("ValidateNIT: expected ErrEmpty, got %v", err)
+	}
+}
+
+func TestNormalizeParty(t *testing.T) {
+	number, dv, err := identification.NormalizeParty("31", "900.123.456", "")
+	if err != nil || number != "900123456" || dv != "8" {
+		t.Errorf("NormalizeParty NIT: got %q %q %v", number, dv, err)
+	}
+	if number, dv, err = identification.NormalizeParty("13", "1.020.304.050", ""); err != nil || number != "1020304050" || dv != "" {
+		t.Errorf("NormalizeParty CC: got %q %q %v", number, dv, err)
+	}
+	if _, _, err = identification.NormalizeParty("31", "900123456", "3"); !errors.Is(err, identification.ErrCheckDigit) {
+		t.Errorf("NormalizeParty: expected ErrCheckDigit, got %v", err)
+	}
+	if _, _, err = identification.NormalizeParty("", "", ""); err != nil {
+		t.Errorf("NormalizeParty empty: %v", err)
+	}
+
+	// Consumidor final: cédula genérica de 12 dígitos
+	if number, _, err = identification.NormalizeParty("13", identification.FinalConsumer, ""); err != nil || number != identification.FinalConsumer {
+		t.Errorf("NormalizeParty final consumer: got %q %v", number, err)
+	}
+	if _, _, err = identification.NormalizeParty("13", "333333333333", ""); !errors.Is(err, identification.ErrInvalidLength) {
+		t.Errorf("NormalizeParty: expected ErrInvalidLength, got %v", err)
+	}
+
+	// Sin schemeName no se valida: el número queda tal cual
+	if number, dv, err = identification.NormalizeParty("", " 900.123.456 ", "5"); err != nil || number != " 900.123.456 " || dv != "5" {
+		t.Errorf("NormalizeParty without schemeName: got %q %q %v", number, dv, err)
+	}
+}
+
+func TestBuilderParty(t *testing.T) {
+	party := invoice.PartyTemplateData{
+		PartyName:   "MI EMPRESA SAS",
+		TaxScheme:   invoice.TaxSchemeTemplateData{CompanyID: "900.123.456", CompanyIDSchemeName: "31"},
+		LegalEntity: invoice.LegalEntityTemplateData{CompanyID: "900123456", CompanyIDSchemeID: "8", CompanyIDSchemeName: "31"},
+	}
+	b := invoice.NewBuilder().SetSupplier(party)
+	if got := b.GetData().Supplier.TaxScheme; got.CompanyID != "900123456" || got.CompanyIDSchemeID != "8" {
+		t.Errorf("Expected normalized supplier, got %+v", got)
+	}
+
+	party.LegalEntity.CompanyIDSchemeID = "5"
+	_, err := invoice.NewBuilder().SetCustomer(party).Build()
+	if !errors.Is(err, identification.ErrCheckDigit) || !strings.Contains(err.Error(), "customer") {
+		t.Errorf("Expected customer check digit error, got %v", err)
+	}
+
+	// Partes sin schemeName (solo razón social y NIT) y consumidor final
+	bare := invoice.PartyTemplateData{
+		PartyName:   "CLIENTE SAS",
+		TaxScheme:   invoice.TaxSchemeTemplateData{CompanyID: "800111222"},
+		LegalEntity: invoice.LegalEntityTemplateData{CompanyID: "800111222"},
+	}
+	consumer := invoice.PartyTemplateData{
+		PartyName:   "Consumidor final",
+		TaxScheme:   invoice.TaxSchemeTemplateData{CompanyID: identification.FinalConsumer, CompanyIDSchemeName: "13"},
+		LegalEntity: invoice.LegalEntityTemplateData{CompanyID: identification.FinalConsumer, CompanyIDSchemeName: "13"},
+	}
+	b = invoice.NewBuilder().SetSupplier(bare).SetCustomer(consumer)
+	if _, err := b.Build(); err != nil {
+		t.Errorf("Expected parties without schemeName and final consumer to build, got %v", err)
+	}
+	if got := b.GetData().Customer.TaxScheme.CompanyID; got != identification.FinalConsumer {
+		t.Errorf("Expected final consumer customer, got %q", got)
+	}
+}
+
+func mustCheckDigit(t *testing.T, nit string) string {
+	t.Helper()
+	dv, err := identification.CheckDigit(nit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dv
+}
@@ -0,0 +1,93 @@
+package identification
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxNITLength dígitos que cubren los pesos del algoritmo de DIAN
+const maxNITLength = 15
+
+// weights pesos del dígito de verificación (módulo 11), de derecha a izquierda
+var weights = [maxNITLength]int{3, 7, 13, 17, 19, 23, 29, 37, 41, 43, 47, 53, 59, 67, 71}
+
+// CheckDigit calcula el dígito de verificación de un NIT sin DV
+// Acepta puntos y espacios ("900.123.456"); el guión no se acepta porque
+// separa el DV (ver SplitNIT).
+func CheckDigit(nit string) (string, error) {
+	dv, err := checkDigit(Normalize(NIT, nit))
+	if err != nil {
+		return "", &Error{Type: NIT, Number: nit, Err: err}
+	}
+	return dv, nil
+}
+
+func checkDigit(nit string) (string, error) {
+	if err := rules[NIT].check(nit); err != nil {
+		return "", err
+	}
+
+	sum := 0
+	for i := 0; i < len(nit); i++ {
+		sum += int(nit[len(nit)-1-i]-'0') * weights[i]
+	}
+	remainder := sum % 11
+	if remainder > 1 {
+		remainder = 11 - remainder
+	}
+	return strconv.Itoa(remainder), nil
+}
+
+// SplitNIT separa número y DV ("900.123.456-8" -> "900123456", "8")
+// Sin guión todo es el número y el DV retorna vacío. No verifica el DV.
+func SplitNIT(value string) (nit, dv string, err error) {
+	nit, dv, err = splitNIT(Normalize(NIT, value))
+	if err != nil {
+		return "", "", &Error{Type: NIT, Number: value, Err: err}
+	}
+	return nit, dv, nil
+}
+
+func splitNIT(value string) (nit, dv string, err error) {
+	if i := strings.LastIndexByte(value, '-'); i >= 0 {
+		value, dv = value[:i], value[i+1:]
+		if len(dv) != 1 || dv[0] < '0' || dv[0] > '9' {
+			return "", "", fmt.Errorf("%w: check digit %q", ErrInvalidFormat, dv)
+		}
+	}
+	if err := rules[NIT].check(value); err != nil {
+		return "", "", err
+	}
+	return value, dv, nil
+}
+
+// FormatNIT formatea un NIT con separadores y DV ("900123456" -> "900.123.456-8")
+func FormatNIT(nit string) (string, error) {
+	id, err := Parse(NIT, nit)
+	if err != nil {
+		return "", err
+	}
+	return id.Format(), nil
+}
+
+// ValidateNIT valida un NIT y, si viene informado, su DV
+func ValidateNIT(nit, dv string) error {
+	if strings.TrimSpace(nit) == "" {
+		return &Error{Type: NIT, Number: nit, Err: ErrEmpty}
+	}
+	_, _, err := NormalizeParty(string(NIT), nit, dv)
+	return err
+}
+
+// groupThousands agrupa dígitos de a tres con puntos
+func groupThousands(number string) string {
+	var b strings.Builder
+	for i, c := range number {
+		if i > 0 && (len(number)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,252 @@
+package inbox
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrIMAP el servidor IMAP respondió NO o BAD
+	ErrIMAP = errors.New("IMAP command failed")
+	// ErrMessageTooLarge el servidor anunció un literal mayor que MaxMessageSize
+	ErrMessageTooLarge = errors.New("IMAP message too large")
+)
+
+// defaultMaxMessageSize límite habitual de un correo con adjuntos
+const defaultMaxMessageSize = 25 << 20
+
+// IMAPSource lee los correos no leídos de un buzón IMAP (IMAP4rev1)
+//
+// Los mensajes se descargan con BODY.PEEK[] y se marcan \Seen solo cuando
+// fn los procesa, así que un error deja el correo pendiente para la próxima
+// ejecución.
+type IMAPSource struct {
+	Addr      string // host:puerto (993 con TLS)
+	Username  string
+	Password  string
+	Mailbox   string        // Vacío = INBOX
+	TLSConfig *tls.Config   // nil = ServerName del host de Addr
+	Insecure  bool          // Sin TLS (servidores locales de prueba)
+	Timeout   time.Duration // Tiempo máximo por comando; 0 = 30s
+
+	// MaxMessageSize bytes máximos de los literales de una respuesta (el
+	// correo completo en FETCH); 0 = 25 MB
+	MaxMessageSize int
+}
+
+// Each implementa Source
+func (s *IMAPSource) Each(fn func(msg *Message) error) error {
+	c, err := s.dial()
+	if err != nil {
+		return err
+	}
+	defer c.close()
+
+	if _, err := c.command("LOGIN %s %s", quote(s.Username), quote(s.Password)); err != nil {
+		return err
+	}
+	mailbox := s.Mailbox
+	if mailbox == "" {
+		mailbox = "INBOX"
+	}
+	if _, err := c.command("SELECT %s", quote(mailbox)); err != nil {
+		return err
+	}
+
+	responses, err := c.command("UID SEARCH UNSEEN")
+	if err != nil {
+		return err
+	}
+	var uids []string
+	for _, r := range responses {
+		if fields := strings.Fields(r.line); len(fields) > 1 && strings.EqualFold(fields[1], "SEARCH") {
+			uids = append(uids, fields[2:]...)
+		}
+	}
+
+	for _, uid := range uids {
+		responses, err := c.command("UID FETCH %s (BODY.PEEK[])", uid)
+		if err != nil {
+			return err
+		}
+		var data []byte
+		for _, r := range responses {
+			if !strings.Contains(strings.ToUpper(r.line), "FETCH") {
+				continue
+			}
+			for _, l := range r.literals {
+				if strings.HasSuffix(strings.ToUpper(strings.TrimSpace(l.item)), "BODY[]") {
+					data = l.data
+				}
+			}
+		}
+		if data == nil {
+			return fmt.Errorf("%w: UID %s has no body", ErrIMAP, uid)
+		}
+		if err := fn(&Message{ID: uid, Data: data}); err != nil {
+			return err
+		}
+		if _, err := c.command(`UID STORE %s +FLAGS.SILENT (\Seen)`, uid); err != nil {
+			return err
+		}
+	}
+
+	_, err = c.command("LOGOUT")
+	return err
+}
+
+// imapConn conexión IMAP con comandos numerados
+type imapConn struct {
+	conn       net.Conn
+	r          *bufio.Reader
+	timeout    time.Duration
+	maxLiteral int
+	tag        int
+}
+
+// imapResponse respuesta no etiquetada ("* ...") con sus literales
+type imapResponse struct {
+	line     string
+	literals []imapLiteral
+}
+
+// imapLiteral literal {n} y el texto que lo precede en su segmento de línea
+// ("... BODY[] "), que identifica el dato de FETCH
+type imapLiteral struct {
+	item string
+	data []byte
+}
+
+func (s *IMAPSource) dial() (*imapConn, error) {
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+
+	var conn net.Conn
+	var err error
+	if s.Insecure {
+		conn, err = dialer.Dial("tcp", s.Addr)
+	} else {
+		config := s.TLSConfig
+		if config == nil {
+			host, _, _ := net.SplitHostPort(s.Addr)
+			config = &tls.Config{ServerName: host}
+		}
+		conn, err = tls.DialWithDialer(dialer, "tcp", s.Addr, config)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", s.Addr, err)
+	}
+
+	maxLiteral := s.MaxMessageSize
+	if maxLiteral <= 0 {
+		maxLiteral = defaultMaxMessageSize
+	}
+	c := &imapConn{conn: conn, r: bufio.NewReader(conn), timeout: timeout, maxLiteral: maxLiteral}
+	conn.SetDeadline(time.Now().Add(timeout))
+	greeting, err := c.readLine()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("IMAP greeting: %w", err)
+	}
+	if !strings.HasPrefix(strings.ToUpper(greeting), "* OK") && !strings.HasPrefix(strings.ToUpper(greeting), "* PREAUTH") {
+		conn.Close()
+		return nil, fmt.Errorf("%w: greeting %q", ErrIMAP, greeting)
+	}
+	return c, nil
+}
+
+func (c *imapConn) close() {
+	c.conn.Close()
+}
+
+// command envía un comando y lee hasta su respuesta etiquetada
+func (c *imapConn) command(format string, args ...interface{}) ([]imapResponse, error) {
+	c.tag++
+	tag := "a" + strconv.Itoa(c.tag)
+	command := fmt.Sprintf(format, args...)
+	name := strings.Fields(command)[0]
+
+	c.conn.SetDeadline(time.Now().Add(c.timeout))
+	if _, err := fmt.Fprintf(c.conn, "%s %s\r\n", tag, command); err != nil {
+		return nil, fmt.Errorf("IMAP %s: %w", name, err)
+	}
+
+	var responses []imapResponse
+	total := 0 // Bytes de literales del comando, hasta maxLiteral
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, fmt.Errorf("IMAP %s: %w", name, err)
+		}
+		if strings.HasPrefix(line, tag+" ") {
+			status := strings.TrimPrefix(line, tag+" ")
+			if !strings.HasPrefix(strings.ToUpper(status), "OK") {
+				return nil, fmt.Errorf("%w: %s %s", ErrIMAP, name, status)
+			}
+			return responses, nil
+		}
+
+		r := imapResponse{line: line}
+		// Literal {n}: n bytes y luego el resto de la línea, que puede
+		// terminar en otro literal
+		for segment := line; ; {
+			start, n, ok := literalSize(segment)
+			if !ok {
+				break
+			}
+			if total += n; n > c.maxLiteral || total > c.maxLiteral {
+				return nil, fmt.Errorf("%w: %s literal of %d bytes, at most %d allowed", ErrMessageTooLarge, name, n, c.maxLiteral)
+			}
+			data := make([]byte, n)
+			if _, err := io.ReadFull(c.r, data); err != nil {
+				return nil, fmt.Errorf("IMAP %s: %w", name, err)
+			}
+			r.literals = append(r.literals, imapLiteral{item: segment[:start], data: data})
+
+			if segment, err = c.readLine(); err != nil {
+				return nil, fmt.Errorf("IMAP %s: %w", name, err)
+			}
+			r.line += segment
+		}
+		responses = append(responses, r)
+	}
+}
+
+func (c *imapConn) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// literalSize posición y tamaño del literal con que termina la línea ("... {123}")
+func literalSize(line string) (int, int, bool) {
+	if !strings.HasSuffix(line, "}") {
+		return 0, 0, false
+	}
+	start := strings.LastIndex(line, "{")
+	if start < 0 {
+		return 0, 0, false
+	}
+	n, err := strconv.Atoi(line[start+1 : len(line)-1])
+	if err != nil || n < 0 {
+		return 0, 0, false
+	}
+	return start, n, true
+}
+
+// quote cadena IMAP entre comillas
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
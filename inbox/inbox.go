@@ -0,0 +1,425 @@
+// Package inbox ingresa las facturas que los proveedores envían por correo
+//
+// Lee los correos de una Source (.eml, mbox o IMAP), busca el ZIP adjunto,
+// extrae el AttachedDocument y lo entrega verificado por reception al
+// Handler. Los correos que no cumplen el anexo técnico (sin ZIP, asunto
+// inválido, solo PDF, ...) van a la Quarantine con sus motivos:
+//
+//	in, err := inbox.New(inbox.Config{
+//		Source:     &inbox.IMAPSource{Addr: "imap.empresa.com:993", Username: usuario, Password: clave},
+//		Reception:  reception.Options{Receiver: "800111222", Roots: raices, DIANRoots: raicesDIAN},
+//		Quarantine: inbox.NewDirQuarantine("cuarentena"),
+//		Handler: func(msg *inbox.Message, r *reception.Received) error {
+//			return contabilizar(r.Document)
+//		},
+//	})
+//	report, err := in.Run()
+package inbox
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	netmail "net/mail"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/documents/attached"
+	"github.com/diegofxm/ubl21-dian/identification"
+	"github.com/diegofxm/ubl21-dian/mail"
+	"github.com/diegofxm/ubl21-dian/reception"
+)
+
+// ErrConfig configuración incompleta
+var ErrConfig = errors.New("invalid inbox configuration")
+
+// ReasonCode motivo de cuarentena
+type ReasonCode string
+
+const (
+	ReasonUnreadable         ReasonCode = "unreadable"           // MIME inválido
+	ReasonBadSubject         ReasonCode = "bad-subject"          // Asunto sin el formato NIT;Nombre;Número;Tipo;...
+	ReasonNoAttachment       ReasonCode = "no-attachment"        // Sin adjuntos
+	ReasonPDFOnly            ReasonCode = "pdf-only"             // Solo la representación gráfica
+	ReasonMissingZip         ReasonCode = "missing-zip"          // XML adjunto sin comprimir
+	ReasonMultipleZip        ReasonCode = "multiple-zip"         // Más de un ZIP
+	ReasonInvalidZip         ReasonCode = "invalid-zip"          // ZIP dañado
+	ReasonNoAttachedDocument ReasonCode = "no-attached-document" // El ZIP no trae AttachedDocument
+	ReasonInvalidDocument    ReasonCode = "invalid-document"     // reception.Receive lo rechazó
+	ReasonSubjectMismatch    ReasonCode = "subject-mismatch"     // El asunto no corresponde al documento
+)
+
+// Reason motivo de cuarentena con su detalle
+type Reason struct {
+	Code   ReasonCode `json:"code"`
+	Detail string     `json:"detail,omitempty"`
+}
+
+func (r Reason) String() string {
+	if r.Detail == "" {
+		return string(r.Code)
+	}
+	return string(r.Code) + ": " + r.Detail
+}
+
+// Handler recibe cada documento verificado; un error detiene Run y deja el
+// correo pendiente en la fuente
+type Handler func(msg *Message, r *reception.Received) error
+
+// Config configuración del Inbox
+type Config struct {
+	Source     Source
+	Reception  reception.Options
+	Handler    Handler
+	Quarantine Quarantine       // nil = NewMemoryQuarantine()
+	Now        func() time.Time // Fecha de la cuarentena (time.Now por defecto)
+}
+
+// Inbox procesa los correos de una fuente
+type Inbox struct {
+	config Config
+}
+
+// New crea un Inbox
+func New(config Config) (*Inbox, error) {
+	if config.Source == nil {
+		return nil, fmt.Errorf("%w: Source is required", ErrConfig)
+	}
+	if config.Handler == nil {
+		return nil, fmt.Errorf("%w: Handler is required", ErrConfig)
+	}
+	if config.Quarantine == nil {
+		config.Quarantine = NewMemoryQuarantine()
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	return &Inbox{config: config}, nil
+}
+
+// Outcome resultado de un correo
+type Outcome struct {
+	MessageID string
+	From      string
+	Subject   string
+	Received  []*reception.Received // Documentos entregados al Handler
+	Reasons   []Reason              // No vacío = en cuarentena
+}
+
+// Quarantined indica si el correo fue a cuarentena
+func (o *Outcome) Quarantined() bool {
+	return len(o.Reasons) > 0
+}
+
+// Report resultado de Run
+type Report struct {
+	Outcomes []*Outcome
+}
+
+// Counts correos aceptados y en cuarentena
+func (r *Report) Counts() (accepted, quarantined int) {
+	for _, o := range r.Outcomes {
+		if o.Quarantined() {
+			quarantined++
+		} else {
+			accepted++
+		}
+	}
+	return accepted, quarantined
+}
+
+// Run procesa los correos pendientes de la fuente
+// Si falla el Handler o la cuarentena, retorna el reporte hasta ese correo.
+func (in *Inbox) Run() (*Report, error) {
+	report := &Report{}
+	err := in.config.Source.Each(func(msg *Message) error {
+		outcome, err := in.Process(msg)
+		if outcome != nil {
+			report.Outcomes = append(report.Outcomes, outcome)
+		}
+		return err
+	})
+	return report, err
+}
+
+// Process procesa un correo: lo entrega al Handler si cumple o lo pone en
+// cuarentena
+func (in *Inbox) Process(msg *Message) (*Outcome, error) {
+	outcome, documents := Inspect(msg, in.config.Reception)
+
+	if outcome.Quarantined() {
+		err := in.config.Quarantine.Put(Quarantined{
+			MessageID: outcome.MessageID,
+			From:      outcome.From,
+			Subject:   outcome.Subject,
+			Reasons:   outcome.Reasons,
+			At:        in.config.Now(),
+			Data:      msg.Data,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return outcome, nil
+	}
+
+	for _, r := range documents {
+		if err := in.config.Handler(msg, r); err != nil {
+			return nil, fmt.Errorf("message %s: %w", msg.ID, err)
+		}
+		outcome.Received = append(outcome.Received, r)
+	}
+	return outcome, nil
+}
+
+// attachment archivo adjunto de un correo
+type attachment struct {
+	name string
+	kind string // "zip", "xml", "pdf" u otro
+	data []byte
+}
+
+// Inspect lee el correo y verifica sus AttachedDocument sin llamar al
+// Handler ni a la cuarentena
+//
+// Retorna los documentos verificados; si Outcome trae motivos, el correo no
+// cumple y los documentos no deben procesarse.
+func Inspect(msg *Message, opts reception.Options) (*Outcome, []*reception.Received) {
+	outcome := &Outcome{MessageID: msg.ID}
+	reject := func(code ReasonCode, format string, args ...interface{}) {
+		outcome.Reasons = append(outcome.Reasons, Reason{Code: code, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	parsed, err := netmail.ReadMessage(bytes.NewReader(msg.Data))
+	if err != nil {
+		reject(ReasonUnreadable, "%v", err)
+		return outcome, nil
+	}
+	decoder := new(mime.WordDecoder)
+	outcome.From, _ = decoder.DecodeHeader(parsed.Header.Get("From"))
+	outcome.Subject, err = decoder.DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil {
+		outcome.Subject = parsed.Header.Get("Subject")
+	}
+
+	subject, err := mail.ParseSubject(outcome.Subject)
+	if err != nil {
+		reject(ReasonBadSubject, "%v", err)
+	}
+
+	attachments, err := collect(parsed.Header, parsed.Body)
+	if err != nil {
+		reject(ReasonUnreadable, "%v", err)
+		return outcome, nil
+	}
+
+	var zips, xmls, pdfs []attachment
+	for _, a := range attachments {
+		switch a.kind {
+		case "zip":
+			zips = append(zips, a)
+		case "xml":
+			xmls = append(xmls, a)
+		case "pdf":
+			pdfs = append(pdfs, a)
+		}
+	}
+	switch {
+	case len(zips) == 0 && len(xmls) > 0:
+		reject(ReasonMissingZip, "%s sent without ZIP", xmls[0].name)
+		return outcome, nil
+	case len(zips) == 0 && len(pdfs) > 0:
+		reject(ReasonPDFOnly, "%s", pdfs[0].name)
+		return outcome, nil
+	case len(zips) == 0:
+		reject(ReasonNoAttachment, "no ZIP attachment")
+		return outcome, nil
+	case len(zips) > 1:
+		reject(ReasonMultipleZip, "%d ZIP attachments", len(zips))
+		return outcome, nil
+	}
+
+	documents, reasons := extract(zips[0], opts)
+	outcome.Reasons = append(outcome.Reasons, reasons...)
+	if subject.Number != "" {
+		for _, r := range documents {
+			if detail := mismatch(subject, r.Document); detail != "" {
+				reject(ReasonSubjectMismatch, "%s", detail)
+			}
+		}
+	}
+	return outcome, documents
+}
+
+// Límites del ZIP adjunto: el anexo técnico trae el AttachedDocument y el PDF,
+// un ZIP con más archivos o con un XML más grande se trata como dañado
+const (
+	maxZipEntries   = 20
+	maxZipEntrySize = 20 << 20 // 20 MB descomprimidos por archivo
+)
+
+// extract verifica los AttachedDocument del ZIP
+func extract(a attachment, opts reception.Options) ([]*reception.Received, []Reason) {
+	zr, err := zip.NewReader(bytes.NewReader(a.data), int64(len(a.data)))
+	if err != nil {
+		return nil, []Reason{{Code: ReasonInvalidZip, Detail: fmt.Sprintf("%s: %v", a.name, err)}}
+	}
+	if len(zr.File) > maxZipEntries {
+		return nil, []Reason{{Code: ReasonInvalidZip, Detail: fmt.Sprintf("%s: %d files, at most %d allowed", a.name, len(zr.File), maxZipEntries)}}
+	}
+
+	var documents []*reception.Received
+	var reasons []Reason
+	for _, f := range zr.File {
+		if !strings.EqualFold(path.Ext(f.Name), ".xml") {
+			continue
+		}
+		data, err := readEntry(f)
+		if err != nil {
+			reasons = append(reasons, Reason{Code: ReasonInvalidZip, Detail: fmt.Sprintf("%s: %v", f.Name, err)})
+			continue
+		}
+
+		r, err := reception.Receive(data, opts)
+		if errors.Is(err, attached.ErrNotAttachedDocument) {
+			continue
+		}
+		if err != nil {
+			reasons = append(reasons, Reason{Code: ReasonInvalidDocument, Detail: fmt.Sprintf("%s: %v", f.Name, err)})
+			continue
+		}
+		documents = append(documents, r)
+	}
+	if len(documents) == 0 && len(reasons) == 0 {
+		reasons = append(reasons, Reason{Code: ReasonNoAttachedDocument, Detail: a.name})
+	}
+	return documents, reasons
+}
+
+// readEntry descomprime un archivo del ZIP hasta maxZipEntrySize
+// El tamaño del encabezado puede ser falso: la lectura se corta igual.
+func readEntry(f *zip.File) ([]byte, error) {
+	if f.UncompressedSize64 > maxZipEntrySize {
+		return nil, fmt.Errorf("%d bytes uncompressed, at most %d allowed", f.UncompressedSize64, maxZipEntrySize)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxZipEntrySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxZipEntrySize {
+		return nil, fmt.Errorf("more than %d bytes uncompressed", maxZipEntrySize)
+	}
+	return data, nil
+}
+
+// mismatch compara el asunto con el documento recibido
+func mismatch(s mail.Subject, doc *reception.Document) string {
+	switch {
+	case normalizeID(s.NIT) != normalizeID(doc.Supplier.ID):
+		return fmt.Sprintf("subject NIT %s, supplier %s", s.NIT, doc.Supplier.ID)
+	case !strings.EqualFold(s.Number, doc.Number):
+		return fmt.Sprintf("subject number %s, document %s", s.Number, doc.Number)
+	case s.TypeCode != doc.TypeCode:
+		return fmt.Sprintf("subject type %s, document %s", s.TypeCode, doc.TypeCode)
+	}
+	return ""
+}
+
+func normalizeID(id string) string {
+	if nit, _, err := identification.SplitNIT(id); err == nil {
+		return nit
+	}
+	return identification.Normalize(identification.NIT, id)
+}
+
+// partHeader encabezados de una parte MIME
+type partHeader interface {
+	Get(key string) string
+}
+
+// collect recorre las partes MIME y retorna los adjuntos decodificados
+func collect(header partHeader, body io.Reader) ([]attachment, error) {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		var attachments []attachment
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if err == io.EOF {
+				return attachments, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			found, err := collect(part.Header, part)
+			if err != nil {
+				return nil, err
+			}
+			attachments = append(attachments, found...)
+		}
+	}
+
+	name := params["name"]
+	if _, dispParams, err := mime.ParseMediaType(header.Get("Content-Disposition")); err == nil && dispParams["filename"] != "" {
+		name = dispParams["filename"]
+	}
+	kind := attachmentKind(mediaType, name)
+	if kind == "" {
+		return nil, nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(header.Get("Content-Transfer-Encoding"))) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("attachment %s: %w", name, err)
+	}
+	return []attachment{{name: name, kind: kind, data: data}}, nil
+}
+
+// attachmentKind clasifica una parte por nombre o tipo MIME; "" = no es
+// adjunto relevante (texto del correo, imágenes, ...)
+func attachmentKind(mediaType, name string) string {
+	switch ext := strings.ToLower(path.Ext(name)); {
+	case ext == ".zip":
+		return "zip"
+	case ext == ".xml":
+		return "xml"
+	case ext == ".pdf":
+		return "pdf"
+	}
+	switch mediaType {
+	case "application/zip", "application/x-zip-compressed", "application/x-zip":
+		return "zip"
+	case "application/xml", "text/xml":
+		if name != "" {
+			return "xml"
+		}
+	case "application/pdf":
+		return "pdf"
+	}
+	if name != "" {
+		return "other"
+	}
+	return ""
+}
@@ -0,0 +1,405 @@
+package inbox_test
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/inbox"
+	"github.com/diegofxm/ubl21-dian/internal/testutil"
+	"github.com/diegofxm/ubl21-dian/mail"
+	"github.com/diegofxm/ubl21-dian/naming"
+	"github.com/diegofxm/ubl21-dian/pipeline"
+	"github.com/diegofxm/ubl21-dian/reception"
+)
+
+// El simulador no firma el ApplicationResponse y el certificado es desechable
+var opts = reception.Options{Receiver: "800111222", AllowUnsignedResponse: true, InsecureSkipChainVerify: true}
+
+// issue emite una factura del proveedor contra el simulador
+func issue(t *testing.T) *pipeline.Result {
+	t.Helper()
+	env := testutil.NewEnv(t)
+	b := testutil.NewInvoice("SETP990000001").
+		SetSupplier(testutil.Party("MI PROVEEDOR SAS", testutil.SupplierNIT)).
+		SetCustomer(testutil.Party("NOSOTROS SAS", testutil.CustomerNIT))
+	return testutil.Issue(t, env.Pipeline(t, pipeline.Config{}), b)
+}
+
+// emails correos de prueba por nombre: uno válido y uno por cada motivo de
+// cuarentena
+func emails(t *testing.T, result *pipeline.Result) map[string][]byte {
+	t.Helper()
+	subject := mail.Subject{NIT: "900123456", IssuerName: "MI PROVEEDOR SAS", Number: "SETP990000001", TypeCode: "01"}
+
+	build := func(subject string, attachment naming.File) []byte {
+		msg := &mail.Message{
+			From: "facturacion@proveedor.com.co", To: []string{"recepcion@nosotros.com.co"},
+			Subject: subject, Body: "Factura electrónica", MessageID: "1@proveedor.com.co",
+			Date: time.Date(2025, 6, 1, 10, 5, 0, 0, time.UTC), Attachment: attachment,
+		}
+		data, err := msg.Bytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	zipOf := func(xml []byte) naming.File {
+		doc := &mail.Document{Name: result.Name, AttachedDocument: xml, PDF: []byte("%PDF-1.4")}
+		a, err := doc.Attachment()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+
+	other := subject
+	other.Number = "SETP990000002"
+	tampered := bytes.Replace(result.AttachedDocument, []byte("Servicio"), []byte("Servicios"), 1)
+	notAttached, err := naming.ZipFiles(naming.File{Name: "factura.xml", Data: result.SignedXML})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return map[string][]byte{
+		"valid":            build(subject.String(), zipOf(result.AttachedDocument)),
+		"bad subject":      build("Factura SETP990000001", zipOf(result.AttachedDocument)),
+		"pdf only":         build(subject.String(), naming.File{Name: "factura.pdf", Data: []byte("%PDF-1.4")}),
+		"missing zip":      build(subject.String(), naming.File{Name: "ad.xml", Data: result.AttachedDocument}),
+		"no attachment":    build(subject.String(), naming.File{}),
+		"invalid zip":      build(subject.String(), naming.File{Name: "z.zip", Data: []byte("no es un zip")}),
+		"not attached":     build(subject.String(), naming.File{Name: "z.zip", Data: notAttached}),
+		"invalid document": build(subject.String(), zipOf(tampered)),
+		"subject mismatch": build(other.String(), zipOf(result.AttachedDocument)),
+	}
+}
+
+func TestInspect(t *testing.T) {
+	result := issue(t)
+
+	tests := map[string]inbox.ReasonCode{
+		"valid":            "",
+		"bad subject":      inbox.ReasonBadSubject,
+		"pdf only":         inbox.ReasonPDFOnly,
+		"missing zip":      inbox.ReasonMissingZip,
+		"no attachment":    inbox.ReasonNoAttachment,
+		"invalid zip":      inbox.ReasonInvalidZip,
+		"not attached":     inbox.ReasonNoAttachedDocument,
+		"invalid document": inbox.ReasonInvalidDocument,
+		"subject mismatch": inbox.ReasonSubjectMismatch,
+	}
+	for name, data := range emails(t, result) {
+		t.Run(name, func(t *testing.T) {
+			outcome, documents := inbox.Inspect(&inbox.Message{ID: name, Data: data}, opts)
+			want := tests[name]
+			if want == "" {
+				if outcome.Quarantined() || len(documents) != 1 || documents[0].Document.UUID != result.UUID {
+					t.Errorf("Expected accepted document, got %v", outcome.Reasons)
+				}
+				if outcome.From != "<facturacion@proveedor.com.co>" || !strings.HasPrefix(outcome.Subject, "900123456;") {
+					t.Errorf("Unexpected headers %q %q", outcome.From, outcome.Subject)
+				}
+				return
+			}
+			if len(outcome.Reasons) != 1 || outcome.Reasons[0].Code != want {
+				t.Errorf("Expected %s, got %v", want, outcome.Reasons)
+			}
+		})
+	}
+}
+
+func TestInspectZipLimits(t *testing.T) {
+	subject := mail.Subject{NIT: "900123456", IssuerName: "MI PROVEEDOR SAS", Number: "SETP990000001", TypeCode: "01"}
+
+	// Bomba: 32 MB de ceros comprimidos en pocos KB
+	bomb, err := naming.ZipFiles(naming.File{Name: "ad.xml", Data: make([]byte, 32<<20)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var many []naming.File
+	for i := 0; i < 100; i++ {
+		many = append(many, naming.File{Name: fmt.Sprintf("ad%03d.xml", i), Data: []byte("<a/>")})
+	}
+	tooMany, err := naming.ZipFiles(many...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, zipData := range map[string][]byte{"zip bomb": bomb, "too many files": tooMany} {
+		t.Run(name, func(t *testing.T) {
+			msg := &mail.Message{
+				From: "facturacion@proveedor.com.co", To: []string{"recepcion@nosotros.com.co"},
+				Subject: subject.String(), Body: "Factura electrónica", MessageID: "1@proveedor.com.co",
+				Date: time.Date(2025, 6, 1, 10, 5, 0, 0, time.UTC), Attachment: naming.File{Name: "z.zip", Data: zipData},
+			}
+			data, err := msg.Bytes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			outcome, documents := inbox.Inspect(&inbox.Message{ID: name, Data: data}, opts)
+			if len(documents) != 0 || len(outcome.Reasons) != 1 || outcome.Reasons[0].Code != inbox.ReasonInvalidZip {
+				t.Errorf("Expected %s, got %v", inbox.ReasonInvalidZip, outcome.Reasons)
+			}
+		})
+	}
+}
+
+func TestRunDir(t *testing.T) {
+	result := issue(t)
+	dir, done, quarantineDir := t.TempDir(), t.TempDir(), t.TempDir()
+	for name, data := range emails(t, result) {
+		if err := os.WriteFile(filepath.Join(dir, strings.ReplaceAll(name, " ", "-")+".eml"), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var received []*reception.Received
+	in, err := inbox.New(inbox.Config{
+		Source:     &inbox.DirSource{Dir: dir, Done: done},
+		Reception:  opts,
+		Quarantine: inbox.NewDirQuarantine(quarantineDir),
+		Handler: func(msg *inbox.Message, r *reception.Received) error {
+			received = append(received, r)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := in.Run()
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if accepted, quarantined := report.Counts(); accepted != 1 || quarantined != 8 || len(received) != 1 {
+		t.Errorf("Expected 1 accepted and 8 quarantined, got %d/%d (%d received)", accepted, quarantined, len(received))
+	}
+	if left, _ := filepath.Glob(filepath.Join(dir, "*.eml")); len(left) != 0 {
+		t.Errorf("Expected all messages moved to Done, %d left", len(left))
+	}
+	if moved, _ := filepath.Glob(filepath.Join(done, "*.eml")); len(moved) != 9 {
+		t.Errorf("Expected 9 messages in Done, got %d", len(moved))
+	}
+	meta, _ := filepath.Glob(filepath.Join(quarantineDir, "*.json"))
+	eml, _ := filepath.Glob(filepath.Join(quarantineDir, "*.eml"))
+	if len(meta) != 8 || len(eml) != 8 {
+		t.Errorf("Expected 8 quarantined messages, got %d/%d", len(meta), len(eml))
+	}
+
+	// Un error del Handler detiene Run y deja el correo pendiente
+	if err := os.Rename(filepath.Join(done, "valid.eml"), filepath.Join(dir, "valid.eml")); err != nil {
+		t.Fatal(err)
+	}
+	failing := errors.New("ERP no disponible")
+	in, err = inbox.New(inbox.Config{
+		Source:    &inbox.DirSource{Dir: dir, Done: done},
+		Reception: opts,
+		Handler:   func(*inbox.Message, *reception.Received) error { return failing },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := in.Run(); !errors.Is(err, failing) {
+		t.Errorf("Expected handler error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "valid.eml")); err != nil {
+		t.Errorf("Expected message left in Dir: %v", err)
+	}
+}
+
+func TestMbox(t *testing.T) {
+	result := issue(t)
+	messages := emails(t, result)
+
+	// mboxrd: las líneas "From " del cuerpo se escapan con ">"
+	var buf bytes.Buffer
+	buf.WriteString("From proveedor@proveedor.com.co Sun Jun  1 10:05:00 2025\n")
+	buf.Write(bytes.ReplaceAll(messages["valid"], []byte("\r\n"), []byte("\n")))
+	buf.WriteString("\nFrom otro@proveedor.com.co Sun Jun  1 10:06:00 2025\n")
+	buf.WriteString("Subject: hola\n\n>From la bodega\nsolo texto\n")
+	path := filepath.Join(t.TempDir(), "buzon.mbox")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	split, err := inbox.SplitMbox(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(split) != 2 || !bytes.HasSuffix(split[1], []byte("\r\nFrom la bodega\r\nsolo texto")) {
+		t.Fatalf("Unexpected mbox split: %d messages %q", len(split), split[len(split)-1])
+	}
+
+	quarantine := inbox.NewMemoryQuarantine()
+	in, err := inbox.New(inbox.Config{
+		Source:     &inbox.MboxSource{Path: path},
+		Reception:  opts,
+		Quarantine: quarantine,
+		Handler:    func(*inbox.Message, *reception.Received) error { return nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := in.Run()
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if accepted, quarantined := report.Counts(); accepted != 1 || quarantined != 1 {
+		t.Errorf("Expected 1 accepted and 1 quarantined, got %d/%d", accepted, quarantined)
+	}
+	items := quarantine.Items()
+	if len(items) != 1 || items[0].MessageID != path+"#2" || items[0].Reasons[0].Code != inbox.ReasonBadSubject {
+		t.Errorf("Unexpected quarantine %+v", items)
+	}
+}
+
+// imapServer servidor IMAP mínimo con un buzón en memoria
+type imapServer struct {
+	addr     string
+	mu       sync.Mutex
+	messages [][]byte
+	seen     map[int]bool
+}
+
+func newIMAPServer(t *testing.T, messages ...[]byte) *imapServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	s := &imapServer{addr: ln.Addr().String(), messages: messages, seen: map[int]bool{}}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *imapServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	fmt.Fprint(conn, "* OK IMAP4rev1 listo\r\n")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		tag, command := fields[0], strings.ToUpper(strings.Join(fields[1:min(len(fields), 3)], " "))
+
+		s.mu.Lock()
+		switch {
+		case strings.HasPrefix(command, "LOGIN"):
+			if fields[2] != `"contabilidad"` || fields[3] != `"clave"` {
+				fmt.Fprintf(conn, "%s NO credenciales inválidas\r\n", tag)
+				break
+			}
+			fmt.Fprintf(conn, "%s OK LOGIN completado\r\n", tag)
+		case strings.HasPrefix(command, "SELECT"):
+			fmt.Fprintf(conn, "* %d EXISTS\r\n%s OK [READ-WRITE] SELECT completado\r\n", len(s.messages), tag)
+		case command == "UID SEARCH":
+			var uids []string
+			for i := range s.messages {
+				if !s.seen[i+1] {
+					uids = append(uids, fmt.Sprint(i+1))
+				}
+			}
+			fmt.Fprintf(conn, "* SEARCH %s\r\n%s OK SEARCH completado\r\n", strings.Join(uids, " "), tag)
+		case command == "UID FETCH":
+			var uid int
+			fmt.Sscan(fields[3], &uid)
+			// Varios literales en la misma respuesta, el cuerpo al final
+			data, header := s.messages[uid-1], "X-Spam: no\r\n\r\n"
+			fmt.Fprintf(conn, "* %d FETCH (UID %d BODY[HEADER.FIELDS (X-SPAM)] {%d}\r\n%s BODY[] {%d}\r\n%s)\r\n%s OK FETCH completado\r\n",
+				uid, uid, len(header), header, len(data), data, tag)
+		case command == "UID STORE":
+			var uid int
+			fmt.Sscan(fields[3], &uid)
+			s.seen[uid] = true
+			fmt.Fprintf(conn, "%s OK STORE completado\r\n", tag)
+		case strings.HasPrefix(command, "LOGOUT"):
+			fmt.Fprintf(conn, "* BYE\r\n%s OK LOGOUT completado\r\n", tag)
+			s.mu.Unlock()
+			return
+		default:
+			fmt.Fprintf(conn, "%s BAD comando desconocido\r\n", tag)
+		}
+		s.mu.Unlock()
+	}
+}
+
+func TestIMAP(t *testing.T) {
+	result := issue(t)
+	messages := emails(t, result)
+	srv := newIMAPServer(t, messages["valid"], messages["pdf only"])
+	source := &inbox.IMAPSource{Addr: srv.addr, Username: "contabilidad", Password: "clave", Insecure: true, Timeout: 5 * time.Second}
+
+	// Un error del Handler deja el correo sin leer
+	failing := errors.New("ERP no disponible")
+	in, err := inbox.New(inbox.Config{Source: source, Reception: opts, Handler: func(*inbox.Message, *reception.Received) error { return failing }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := in.Run(); !errors.Is(err, failing) {
+		t.Fatalf("Expected handler error, got %v", err)
+	}
+
+	var received []string
+	in, err = inbox.New(inbox.Config{Source: source, Reception: opts, Handler: func(msg *inbox.Message, r *reception.Received) error {
+		received = append(received, msg.ID+":"+r.Document.Number)
+		return nil
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := in.Run()
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(received) != 1 || received[0] != "1:SETP990000001" {
+		t.Errorf("Unexpected received %v", received)
+	}
+	if accepted, quarantined := report.Counts(); accepted != 1 || quarantined != 1 || report.Outcomes[1].Reasons[0].Code != inbox.ReasonPDFOnly {
+		t.Errorf("Unexpected report %+v", report.Outcomes)
+	}
+
+	// Los procesados quedan marcados \Seen
+	if report, err := in.Run(); err != nil || len(report.Outcomes) != 0 {
+		t.Errorf("Expected no pending messages, got %d (%v)", len(report.Outcomes), err)
+	}
+
+	source.Password = "otra"
+	if _, err := in.Run(); !errors.Is(err, inbox.ErrIMAP) {
+		t.Errorf("Expected ErrIMAP, got %v", err)
+	}
+}
+
+func TestIMAPMessageTooLarge(t *testing.T) {
+	srv := newIMAPServer(t, bytes.Repeat([]byte("x"), 2048))
+	source := &inbox.IMAPSource{Addr: srv.addr, Username: "contabilidad", Password: "clave", Insecure: true, Timeout: 5 * time.Second, MaxMessageSize: 1024}
+	err := source.Each(func(*inbox.Message) error {
+		t.Error("Expected no message above MaxMessageSize")
+		return nil
+	})
+	if !errors.Is(err, inbox.ErrMessageTooLarge) {
+		t.Errorf("Expected ErrMessageTooLarge, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package inbox
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Quarantine guarda los correos que no cumplen el formato de DIAN
+type Quarantine interface {
+	Put(item Quarantined) error
+}
+
+// Quarantined correo en cuarentena con sus motivos
+type Quarantined struct {
+	MessageID string    `json:"messageId"` // ID en la fuente (Message.ID)
+	From      string    `json:"from"`
+	Subject   string    `json:"subject"`
+	Reasons   []Reason  `json:"reasons"`
+	At        time.Time `json:"at"`
+	Data      []byte    `json:"-"` // Correo original
+}
+
+// DirQuarantine guarda cada correo como {hash}.eml junto a {hash}.json con
+// los motivos; el hash (SHA-256 del correo) evita duplicados
+type DirQuarantine struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewDirQuarantine crea una cuarentena en dir
+func NewDirQuarantine(dir string) *DirQuarantine {
+	return &DirQuarantine{dir: dir}
+}
+
+// Put implementa Quarantine
+func (q *DirQuarantine) Put(item Quarantined) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := os.MkdirAll(q.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create quarantine: %w", err)
+	}
+	sum := sha256.Sum256(item.Data)
+	base := filepath.Join(q.dir, hex.EncodeToString(sum[:8]))
+
+	meta, err := json.MarshalIndent(item, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(base+".eml", item.Data, 0o644); err != nil {
+		return fmt.Errorf("failed to write quarantine: %w", err)
+	}
+	if err := os.WriteFile(base+".json", meta, 0o644); err != nil {
+		return fmt.Errorf("failed to write quarantine: %w", err)
+	}
+	return nil
+}
+
+// MemoryQuarantine guarda los correos en memoria (pruebas)
+type MemoryQuarantine struct {
+	mu    sync.Mutex
+	items []Quarantined
+}
+
+// NewMemoryQuarantine crea una cuarentena en memoria
+func NewMemoryQuarantine() *MemoryQuarantine {
+	return &MemoryQuarantine{}
+}
+
+// Put implementa Quarantine
+func (q *MemoryQuarantine) Put(item Quarantined) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.items = append(q.items, item)
+	return nil
+}
+
+// Items retorna los correos en cuarentena en orden
+func (q *MemoryQuarantine) Items() []Quarantined {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]Quarantined(nil), q.items...)
+}
@@ -0,0 +1,136 @@
+package inbox
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Message correo tal como llega del buzón (RFC 5322)
+type Message struct {
+	ID   string // Ruta del .eml, posición en el mbox o UID IMAP
+	Data []byte
+}
+
+// Source buzón de donde se leen los correos
+//
+// Each llama fn con cada mensaje pendiente. Si fn retorna nil el mensaje
+// queda procesado (DirSource lo mueve a Done, IMAPSource lo marca \Seen); si
+// retorna error Each se detiene sin marcarlo y retorna ese error.
+type Source interface {
+	Each(fn func(msg *Message) error) error
+}
+
+// DirSource lee los .eml de un directorio en orden alfabético
+type DirSource struct {
+	Dir  string
+	Done string // Directorio para los procesados; vacío = se dejan en Dir
+}
+
+// Each implementa Source
+func (s *DirSource) Each(fn func(msg *Message) error) error {
+	paths, err := filepath.Glob(filepath.Join(s.Dir, "*.eml"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+	if s.Done != "" {
+		if err := os.MkdirAll(s.Done, 0o755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", s.Done, err)
+		}
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if err := fn(&Message{ID: path, Data: data}); err != nil {
+			return err
+		}
+		if s.Done != "" {
+			if err := os.Rename(path, filepath.Join(s.Done, filepath.Base(path))); err != nil {
+				return fmt.Errorf("failed to move %s: %w", path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// MboxSource lee un archivo mbox (mboxo o mboxrd)
+// El ID de cada mensaje es "ruta#n", con n desde 1.
+type MboxSource struct {
+	Path string
+}
+
+// Each implementa Source; el archivo no se modifica
+func (s *MboxSource) Each(fn func(msg *Message) error) error {
+	f, err := os.Open(s.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	messages, err := SplitMbox(f)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", s.Path, err)
+	}
+	for i, data := range messages {
+		if err := fn(&Message{ID: fmt.Sprintf("%s#%d", s.Path, i+1), Data: data}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapedFrom línea "From " escapada dentro de un mensaje (">From ", ">>From ")
+var escapedFrom = regexp.MustCompile(`^>+From `)
+
+// SplitMbox separa los mensajes de un mbox
+//
+// Cada mensaje empieza con una línea "From " al inicio del archivo o después
+// de una línea vacía; se quita esa línea y un ">" de las líneas ">From ".
+func SplitMbox(r io.Reader) ([][]byte, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64<<20)
+
+	var messages [][]byte
+	var current *bytes.Buffer
+	blank := true
+	flush := func() {
+		if current != nil {
+			// La línea vacía que separa los mensajes no es parte del anterior
+			data := bytes.TrimSuffix(current.Bytes(), []byte("\r\n"))
+			messages = append(messages, data)
+		}
+	}
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if blank && strings.HasPrefix(line, "From ") {
+			flush()
+			current = &bytes.Buffer{}
+			blank = false
+			continue
+		}
+		blank = line == ""
+		if current == nil {
+			continue // Basura antes del primer "From "
+		}
+		if escapedFrom.MatchString(line) {
+			line = line[1:]
+		}
+		current.WriteString(line)
+		current.WriteString("\r\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return messages, nil
+}
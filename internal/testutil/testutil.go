@@ -0,0 +1,137 @@
+// Package testutil datos y entorno compartidos por los tests: partes y
+// facturas de prueba y un pipeline contra el simulador de DIAN.
+package testutil
+
+import (
+	"crypto/x509"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/diegofxm/ubl21-dian/documents/invoice"
+	"github.com/diegofxm/ubl21-dian/naming"
+	"github.com/diegofxm/ubl21-dian/pipeline"
+	"github.com/diegofxm/ubl21-dian/signature"
+	"github.com/diegofxm/ubl21-dian/soap"
+	"github.com/diegofxm/ubl21-dian/soap/simulator"
+	"github.com/diegofxm/ubl21-dian/soap/types"
+)
+
+// Datos del rango y del software de pruebas de DIAN
+const (
+	TechnicalKey = "fc8eac422eba16e22ffd8c6f94b3f40a6e38162c"
+	SoftwareID   = "56f2ae4e-9812-4fad-9255-08fcfcd5ccb0"
+	SoftwarePIN  = "12345"
+	SupplierNIT  = "900123456"
+	CustomerNIT  = "800111222"
+)
+
+// Party persona jurídica en Bogotá, responsable de IVA (O-13)
+func Party(name, nit string) invoice.PartyTemplateData {
+	return invoice.PartyTemplateData{
+		AdditionalAccountID: "1",
+		PartyName:           name,
+		Address:             invoice.AddressTemplateData{ID: "11001", CityName: "Bogotá", CountrySubentity: "Bogotá", CountrySubentityCode: "11", Line: "Calle 1 # 2-3", CountryCode: "CO", CountryName: "Colombia"},
+		TaxScheme:           invoice.TaxSchemeTemplateData{RegistrationName: name, CompanyID: nit, CompanyIDSchemeName: "31", TaxLevelCode: "O-13", ID: "01", Name: "IVA"},
+		LegalEntity:         invoice.LegalEntityTemplateData{RegistrationName: name, CompanyID: nit, CompanyIDSchemeName: "31"},
+	}
+}
+
+// NewInvoice factura del rango SETP de MI EMPRESA SAS a CLIENTE SAS por
+// 100.000 + IVA 19%, con una línea de 2 x 50.000 a crédito
+func NewInvoice(number string) *invoice.Builder {
+	tax := invoice.TaxSubtotalTemplateData{
+		TaxableAmount: "100000.00", TaxAmount: "19000.00", CurrencyID: "COP", Percent: "19.00",
+		TaxCategory: invoice.TaxCategoryTemplateData{Percent: "19.00", TaxScheme: invoice.TaxSchemeTemplateData{ID: "01", Name: "IVA"}},
+	}
+	return invoice.NewBuilder().
+		SetProfileExecutionID("2").
+		SetInvoiceData(number, "", "2025-06-01", "10:00:00-05:00", "2025-06-01").
+		SetDianExtensions("18760000001", "2019-01-19", "2030-01-19", "SETP", "990000000", "995000000",
+			SupplierNIT, "8", "31", SoftwareID, "", "").
+		SetSupplier(Party("MI EMPRESA SAS", SupplierNIT)).
+		SetCustomer(Party("CLIENTE SAS", CustomerNIT)).
+		SetPaymentMeans("2", "10", "2025-07-01").
+		SetMonetaryTotals("100000.00", "100000.00", "119000.00", "", "119000.00").
+		AddTaxTotal(invoice.TaxTotalTemplateData{TaxAmount: "19000.00", CurrencyID: "COP", TaxSubtotals: []invoice.TaxSubtotalTemplateData{tax}}).
+		AddInvoiceLine(invoice.InvoiceLineTemplateData{
+			ID: "1", UnitCode: "94", Quantity: "2.000000", LineExtensionAmount: "100000.00", FreeOfChargeIndicator: "false", CurrencyID: "COP",
+			Item:     invoice.ItemTemplateData{Description: "Servicio", StandardItemID: invoice.ItemIDTemplateData{ID: "P001", SchemeID: "999"}},
+			Price:    invoice.PriceTemplateData{Amount: "50000.00", BaseQuantity: "1.000000"},
+			TaxTotal: &invoice.TaxTotalTemplateData{TaxAmount: "19000.00", CurrencyID: "COP", TaxSubtotals: []invoice.TaxSubtotalTemplateData{tax}},
+		})
+}
+
+// Env simulador de DIAN con su cliente SOAP y un firmante de prueba
+type Env struct {
+	Simulator   *simulator.Simulator
+	URL         string // URL del httptest.Server del simulador
+	Client      *soap.Client
+	Signer      *signature.Signer
+	Credentials *simulator.TestCredentials
+}
+
+// NewEnv levanta el simulador en un httptest.Server que se cierra al terminar t
+func NewEnv(t testing.TB) *Env {
+	t.Helper()
+
+	creds, err := simulator.NewTestCredentials(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := signature.NewSignerFromPEM(creds.CertPath, creds.KeyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim := simulator.New()
+	srv := httptest.NewServer(sim)
+	t.Cleanup(srv.Close)
+	client, err := soap.NewClient(&types.Config{Certificate: creds.CertPath, PrivateKey: creds.KeyPath, Endpoint: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Env{Simulator: sim, URL: srv.URL, Client: client, Signer: signer, Credentials: creds}
+}
+
+// Roots ancla de confianza con el certificado de prueba (reception.Options)
+func (e *Env) Roots() *x509.CertPool {
+	roots := x509.NewCertPool()
+	roots.AddCert(e.Credentials.Certificate)
+	return roots
+}
+
+// Pipeline pipeline del emisor SupplierNIT; completa Sender, Signer, Counter
+// y SoftwarePIN de config si vienen vacíos
+func (e *Env) Pipeline(t testing.TB, config pipeline.Config) *pipeline.Pipeline {
+	t.Helper()
+	if config.Sender == nil {
+		config.Sender = e.Client
+	}
+	if config.Signer == nil {
+		config.Signer = e.Signer
+	}
+	if config.Counter == nil {
+		config.Counter = naming.NewCounter(naming.NewMemoryStore(), SupplierNIT, "")
+	}
+	if config.SoftwarePIN == "" {
+		config.SoftwarePIN = SoftwarePIN
+	}
+	p, err := pipeline.New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// Issue emite la factura y falla el test si DIAN no la valida
+func Issue(t testing.TB, p *pipeline.Pipeline, b *invoice.Builder) *pipeline.Result {
+	t.Helper()
+	doc, err := pipeline.FromInvoice(b, TechnicalKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := p.Issue(doc)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	return result
+}
@@ -0,0 +1,235 @@
+package mail
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/identification"
+	"github.com/diegofxm/ubl21-dian/soap/types"
+)
+
+var (
+	ErrConfig       = errors.New("invalid mail configuration")
+	ErrNoRecipients = errors.New("no exchange email for the acquirer")
+	ErrNotDelivered = errors.New("email was not delivered")
+)
+
+// Status estado de la entrega a un destinatario
+type Status string
+
+const (
+	StatusSent     Status = "sent"     // Aceptado por el servidor
+	StatusRejected Status = "rejected" // El servidor rechazó la dirección
+	StatusFailed   Status = "failed"   // No se pudo enviar el correo
+)
+
+// Recipient estado de un destinatario
+type Recipient struct {
+	Address string
+	Status  Status
+	Err     error
+}
+
+// Delivery resultado de la entrega de un documento
+type Delivery struct {
+	MessageID  string
+	Subject    string
+	Attachment string // Nombre del ZIP adjunto
+	SentAt     time.Time
+	Recipients []Recipient
+}
+
+// Delivered indica si al menos un destinatario recibió el correo
+func (d *Delivery) Delivered() bool {
+	for _, r := range d.Recipients {
+		if r.Status == StatusSent {
+			return true
+		}
+	}
+	return false
+}
+
+// Failed destinatarios que no recibieron el correo
+func (d *Delivery) Failed() []Recipient {
+	var failed []Recipient
+	for _, r := range d.Recipients {
+		if r.Status != StatusSent {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
+
+// Config configuración del Mailer
+type Config struct {
+	Sender Sender
+	From   string           // Remitente ("Empresa SAS <facturacion@empresa.com>")
+	Now    func() time.Time // Fecha del correo (time.Now por defecto)
+}
+
+// Mailer entrega documentos al adquiriente
+type Mailer struct {
+	sender Sender
+	from   string
+	domain string
+	now    func() time.Time
+}
+
+// New crea un Mailer
+func New(config Config) (*Mailer, error) {
+	if config.Sender == nil {
+		return nil, fmt.Errorf("%w: Sender is required", ErrConfig)
+	}
+	from, err := addressOf(config.From)
+	if err != nil {
+		return nil, fmt.Errorf("%w: From: %v", ErrConfig, err)
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	return &Mailer{
+		sender: config.Sender,
+		from:   config.From,
+		domain: from[strings.LastIndex(from, "@")+1:],
+		now:    config.Now,
+	}, nil
+}
+
+// Message arma el correo de un documento para los destinatarios
+func (m *Mailer) Message(doc *Document, to ...string) (*Message, error) {
+	if len(to) == 0 {
+		return nil, ErrNoRecipients
+	}
+	attachment, err := doc.Attachment()
+	if err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	return &Message{
+		From:       m.from,
+		To:         to,
+		Subject:    doc.Subject.String(),
+		Body:       doc.Body,
+		MessageID:  hex.EncodeToString(id) + "@" + m.domain,
+		Date:       m.now(),
+		Attachment: attachment,
+	}, nil
+}
+
+// Deliver envía el documento y reporta el estado de cada destinatario
+//
+// Retorna ErrNotDelivered si ningún destinatario recibió el correo; con
+// entregas parciales el error es nil y Delivery.Failed lista los rechazados.
+func (m *Mailer) Deliver(doc *Document, to ...string) (*Delivery, error) {
+	msg, err := m.Message(doc, to...)
+	if err != nil {
+		return nil, err
+	}
+	data, err := msg.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	envelope := make([]string, len(to))
+	for i, address := range to {
+		if envelope[i], err = addressOf(address); err != nil {
+			return nil, err
+		}
+	}
+	from, err := addressOf(m.from)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := &Delivery{
+		MessageID:  msg.MessageID,
+		Subject:    msg.Subject,
+		Attachment: msg.Attachment.Name,
+		SentAt:     msg.Date,
+	}
+	sendErr := m.sender.Send(from, envelope, data)
+	var rejected *RecipientError
+	if !errors.As(sendErr, &rejected) {
+		rejected = &RecipientError{}
+	}
+	for _, address := range envelope {
+		r := Recipient{Address: address, Status: StatusSent}
+		switch {
+		case rejected.Rejected[address] != nil:
+			r.Status, r.Err = StatusRejected, rejected.Rejected[address]
+		case sendErr != nil && rejected.Rejected == nil:
+			r.Status, r.Err = StatusFailed, sendErr
+		}
+		delivery.Recipients = append(delivery.Recipients, r)
+	}
+
+	if !delivery.Delivered() {
+		return delivery, fmt.Errorf("%w: %v", ErrNotDelivered, sendErr)
+	}
+	return delivery, nil
+}
+
+// Directory consulta los correos registrados ante DIAN (*soap.Client lo
+// implementa)
+type Directory interface {
+	GetExchangeEmails(req *types.GetExchangeEmailsRequest) (*types.GetExchangeEmailsResponse, error)
+	GetAcquirer(req *types.GetAcquirerRequest) (*types.GetAcquirerResponse, error)
+}
+
+// Recipients correos de recepción del adquiriente
+//
+// Si el adquiriente es facturador electrónico se usan sus correos de
+// intercambio (GetExchangeEmails); si no, el correo registrado en el RUT
+// (GetAcquirer).
+func Recipients(dir Directory, issuerNIT, acquirerID string) ([]string, error) {
+	acquirer := normalizeID(acquirerID)
+
+	exchange, err := dir.GetExchangeEmails(&types.GetExchangeEmailsRequest{NIT: issuerNIT})
+	if err != nil {
+		return nil, err
+	}
+	var emails []string
+	seen := map[string]bool{}
+	add := func(email string) {
+		email = strings.TrimSpace(email)
+		if email != "" && !seen[strings.ToLower(email)] {
+			seen[strings.ToLower(email)] = true
+			emails = append(emails, email)
+		}
+	}
+	for _, address := range exchange.Addresses {
+		if normalizeID(address.NIT) == acquirer {
+			add(address.Email)
+		}
+	}
+	if len(emails) > 0 {
+		return emails, nil
+	}
+
+	info, err := dir.GetAcquirer(&types.GetAcquirerRequest{NIT: issuerNIT, IdentificationNumber: acquirer})
+	if err != nil {
+		return nil, err
+	}
+	for _, email := range strings.FieldsFunc(info.Email, func(r rune) bool { return r == ';' || r == ',' }) {
+		add(email)
+	}
+	if len(emails) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoRecipients, acquirerID)
+	}
+	return emails, nil
+}
+
+// normalizeID identificación sin separadores ni DV
+func normalizeID(id string) string {
+	if nit, _, err := identification.SplitNIT(id); err == nil {
+		return nit
+	}
+	return identification.Normalize(identification.NIT, id)
+}
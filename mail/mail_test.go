@@ -0,0 +1,361 @@
+package mail_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	netmail "net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/internal/testutil"
+	"github.com/diegofxm/ubl21-dian/mail"
+	"github.com/diegofxm/ubl21-dian/naming"
+	"github.com/diegofxm/ubl21-dian/pdf"
+	"github.com/diegofxm/ubl21-dian/pipeline"
+	"github.com/diegofxm/ubl21-dian/soap"
+	"github.com/diegofxm/ubl21-dian/soap/simulator"
+)
+
+// issue emite una factura con PDF contra el simulador
+func issue(t *testing.T) (*pipeline.Result, *simulator.Simulator, *soap.Client) {
+	t.Helper()
+	env := testutil.NewEnv(t)
+	p := env.Pipeline(t, pipeline.Config{Renderer: &pdf.Renderer{}})
+	return testutil.Issue(t, p, testutil.NewInvoice("SETP990000001")), env.Simulator, env.Client
+}
+
+// attachment lee el correo y retorna el asunto y el ZIP adjunto
+func attachment(t *testing.T, data []byte) (string, string, *zip.Reader) {
+	t.Helper()
+	msg, err := netmail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := r.NextPart()
+		if err == io.EOF {
+			t.Fatal("No attachment")
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if part.FileName() == "" {
+			continue
+		}
+		content, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, part))
+		if err != nil {
+			t.Fatal(err)
+		}
+		zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return subject, part.FileName(), zr
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	result, sim, client := issue(t)
+	sim.AddExchangeEmails("800111222", "recepcion@cliente.com.co", "rebota@cliente.com.co")
+
+	to, err := mail.Recipients(client, "900123456", "800.111.222-7")
+	if err != nil {
+		t.Fatalf("Recipients failed: %v", err)
+	}
+	if len(to) != 2 || to[0] != "recepcion@cliente.com.co" {
+		t.Fatalf("Unexpected recipients %v", to)
+	}
+
+	doc, err := mail.FromResult(result)
+	if err != nil {
+		t.Fatalf("FromResult failed: %v", err)
+	}
+	doc.Subject.BusinessLine = "Servicios"
+
+	sender := mail.NewMemorySender()
+	sender.Reject("rebota@cliente.com.co", errors.New("550 mailbox unavailable"))
+	now := time.Date(2025, 6, 1, 10, 5, 0, 0, time.UTC)
+	mailer, err := mail.New(mail.Config{Sender: sender, From: "Mi Empresa <facturacion@empresa.com.co>", Now: func() time.Time { return now }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	delivery, err := mailer.Deliver(doc, to...)
+	if err != nil {
+		t.Fatalf("Deliver failed: %v", err)
+	}
+
+	if !delivery.Delivered() || len(delivery.Recipients) != 2 || delivery.Recipients[0].Status != mail.StatusSent {
+		t.Errorf("Unexpected delivery %+v", delivery)
+	}
+	if failed := delivery.Failed(); len(failed) != 1 || failed[0].Status != mail.StatusRejected || failed[0].Address != "rebota@cliente.com.co" {
+		t.Errorf("Unexpected failed recipients %+v", failed)
+	}
+	if !strings.HasSuffix(delivery.MessageID, "@empresa.com.co") || !delivery.SentAt.Equal(now) {
+		t.Errorf("Unexpected message ID or date: %s %v", delivery.MessageID, delivery.SentAt)
+	}
+
+	messages := sender.Messages()
+	if len(messages) != 1 || messages[0].From != "facturacion@empresa.com.co" || len(messages[0].To) != 1 {
+		t.Fatalf("Unexpected messages %+v", messages)
+	}
+	subject, fileName, zr := attachment(t, messages[0].Data)
+	if want := "900123456;MI EMPRESA SAS;SETP990000001;01;MI EMPRESA SAS;Servicios"; subject != want || delivery.Subject != want {
+		t.Errorf("Expected subject %q, got %q", want, subject)
+	}
+	adName := result.Name.WithKind(naming.KindAttachedDocument)
+	if fileName != adName.Zip().String() || delivery.Attachment != fileName {
+		t.Errorf("Unexpected attachment name %s", fileName)
+	}
+	if len(zr.File) != 2 || zr.File[0].Name != adName.String() || zr.File[1].Name != strings.TrimSuffix(adName.String(), ".xml")+".pdf" {
+		t.Fatalf("Unexpected ZIP members %v", zr.File)
+	}
+	f, err := zr.File[0].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if content, _ := io.ReadAll(f); !bytes.Equal(content, result.AttachedDocument) {
+		t.Error("ZIP AttachedDocument differs from the pipeline result")
+	}
+
+	// Todos rechazados o falla del servidor
+	sender.Reject("recepcion@cliente.com.co", errors.New("550 mailbox unavailable"))
+	if delivery, err := mailer.Deliver(doc, to...); !errors.Is(err, mail.ErrNotDelivered) || delivery.Failed()[0].Status != mail.StatusRejected {
+		t.Errorf("Expected ErrNotDelivered with rejected recipients, got %v", err)
+	}
+	sender.Fail(errors.New("connection refused"))
+	if delivery, err := mailer.Deliver(doc, "otro@cliente.com.co"); !errors.Is(err, mail.ErrNotDelivered) || delivery.Recipients[0].Status != mail.StatusFailed {
+		t.Errorf("Expected ErrNotDelivered with failed recipient, got %v", err)
+	}
+}
+
+func TestRecipients(t *testing.T) {
+	_, sim, client := issue(t)
+
+	if _, err := mail.Recipients(client, "900123456", "800111222"); !errors.Is(err, mail.ErrNoRecipients) {
+		t.Errorf("Expected ErrNoRecipients, got %v", err)
+	}
+
+	// Sin correo de intercambio se usa el registrado para el adquiriente
+	sim.AddExchangeEmails("901000001", "fe@otro.com.co")
+	sim.AddAcquirer("800111222", "CLIENTE SAS", "facturas@cliente.com.co")
+	to, err := mail.Recipients(client, "900123456", "800111222")
+	if err != nil || len(to) != 1 || to[0] != "facturas@cliente.com.co" {
+		t.Errorf("Unexpected recipients %v, %v", to, err)
+	}
+}
+
+func TestDocumentErrors(t *testing.T) {
+	result, _, _ := issue(t)
+
+	noPDF := *result
+	noPDF.PDF = nil
+	if _, err := mail.FromResult(&noPDF); !errors.Is(err, mail.ErrMissingArtifact) {
+		t.Errorf("Expected ErrMissingArtifact, got %v", err)
+	}
+
+	doc, err := mail.FromResult(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mailer, err := mail.New(mail.Config{Sender: mail.NewMemorySender(), From: "facturacion@empresa.com.co"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mailer.Deliver(doc, "no es un correo"); !errors.Is(err, mail.ErrInvalidAddress) {
+		t.Errorf("Expected ErrInvalidAddress, got %v", err)
+	}
+	if _, err := mailer.Deliver(doc); !errors.Is(err, mail.ErrNoRecipients) {
+		t.Errorf("Expected ErrNoRecipients, got %v", err)
+	}
+	if _, err := mail.New(mail.Config{From: "facturacion@empresa.com.co"}); !errors.Is(err, mail.ErrConfig) {
+		t.Errorf("Expected ErrConfig, got %v", err)
+	}
+
+	// Subject sin ";" ni saltos de línea dentro de los campos
+	s := mail.Subject{NIT: "900123456", IssuerName: "A;B\r\nBcc: x@y.co", Number: "SETP1", TypeCode: "01"}
+	if got := s.String(); got != "900123456;A,B  Bcc: x@y.co;SETP1;01;A,B  Bcc: x@y.co" {
+		t.Errorf("Unexpected subject %q", got)
+	}
+	if parsed, err := mail.ParseSubject(s.String()); err != nil || parsed.Number != "SETP1" || parsed.CommercialName != "A,B  Bcc: x@y.co" {
+		t.Errorf("Unexpected parsed subject %+v, %v", parsed, err)
+	}
+	for _, bad := range []string{"Factura SETP1", "90012345A;Nombre;SETP1;01;Nombre", "900123456;Nombre;SETP1;Factura;Nombre"} {
+		if _, err := mail.ParseSubject(bad); !errors.Is(err, mail.ErrInvalidSubject) {
+			t.Errorf("Expected ErrInvalidSubject for %q, got %v", bad, err)
+		}
+	}
+}
+
+// smtpServer servidor SMTP mínimo que rechaza las direcciones con "rebota"
+type smtpServer struct {
+	addr      string
+	mu        sync.Mutex
+	from      string
+	to        []string
+	data      []byte
+	quitFails bool // Responde QUIT con un error
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	s := &smtpServer{addr: ln.Addr().String()}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+	c := textproto.NewConn(conn)
+	c.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch {
+		case command == "EHLO" || command == "HELO":
+			c.PrintfLine("250 localhost")
+		case strings.HasPrefix(strings.ToUpper(line), "MAIL FROM:"):
+			s.mu.Lock()
+			s.from = strings.Trim(line[len("MAIL FROM:"):], "<> ")
+			s.mu.Unlock()
+			c.PrintfLine("250 OK")
+		case strings.HasPrefix(strings.ToUpper(line), "RCPT TO:"):
+			address := strings.Trim(line[len("RCPT TO:"):], "<> ")
+			if strings.Contains(address, "rebota") {
+				c.PrintfLine("550 mailbox unavailable")
+				continue
+			}
+			s.mu.Lock()
+			s.to = append(s.to, address)
+			s.mu.Unlock()
+			c.PrintfLine("250 OK")
+		case command == "DATA":
+			c.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := c.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.data = data
+			s.mu.Unlock()
+			c.PrintfLine("250 OK queued")
+		case command == "RSET" || command == "NOOP":
+			c.PrintfLine("250 OK")
+		case command == "QUIT":
+			s.mu.Lock()
+			quitFails := s.quitFails
+			s.mu.Unlock()
+			if quitFails {
+				c.PrintfLine("421 Service not available")
+				return
+			}
+			c.PrintfLine("221 Bye")
+			return
+		default:
+			c.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+func TestSMTPSender(t *testing.T) {
+	result, _, _ := issue(t)
+	srv := newSMTPServer(t)
+
+	doc, err := mail.FromResult(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mailer, err := mail.New(mail.Config{Sender: &mail.SMTPSender{Addr: srv.addr, Timeout: 5 * time.Second}, From: "facturacion@empresa.com.co"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	delivery, err := mailer.Deliver(doc, "Recepción Cliente <recepcion@cliente.com.co>", "rebota@cliente.com.co")
+	if err != nil {
+		t.Fatalf("Deliver failed: %v", err)
+	}
+	if delivery.Recipients[0].Status != mail.StatusSent || delivery.Recipients[1].Status != mail.StatusRejected {
+		t.Errorf("Unexpected recipients %+v", delivery.Recipients)
+	}
+
+	srv.mu.Lock()
+	from, to, data := srv.from, srv.to, srv.data
+	srv.mu.Unlock()
+	if from != "facturacion@empresa.com.co" || len(to) != 1 || to[0] != "recepcion@cliente.com.co" {
+		t.Errorf("Unexpected envelope %s %v", from, to)
+	}
+	if _, fileName, zr := attachment(t, data); fileName != delivery.Attachment || len(zr.File) != 2 {
+		t.Errorf("Unexpected attachment %s", fileName)
+	}
+
+	delivery, err = mailer.Deliver(doc, "rebota@cliente.com.co")
+	if !errors.Is(err, mail.ErrNotDelivered) || delivery.Recipients[0].Status != mail.StatusRejected {
+		t.Errorf("Expected ErrNotDelivered with rejected recipient, got %v", err)
+	}
+}
+
+func TestSMTPSenderTLS(t *testing.T) {
+	srv := newSMTPServer(t)
+	msg := []byte("Subject: prueba\r\n\r\nHola\r\n")
+
+	sender := &mail.SMTPSender{Addr: srv.addr, RequireTLS: true, Timeout: 5 * time.Second}
+	if err := sender.Send("facturacion@empresa.com.co", []string{"recepcion@cliente.com.co"}, msg); !errors.Is(err, mail.ErrTLSRequired) {
+		t.Fatalf("Expected ErrTLSRequired, got %v", err)
+	}
+	srv.mu.Lock()
+	from := srv.from
+	srv.mu.Unlock()
+	if from != "" {
+		t.Errorf("Expected no MAIL FROM without STARTTLS, got %s", from)
+	}
+
+	// Un error en QUIT después de aceptar DATA no es un error de entrega
+	srv.mu.Lock()
+	srv.quitFails = true
+	srv.mu.Unlock()
+	sender.RequireTLS = false
+	if err := sender.Send("facturacion@empresa.com.co", []string{"recepcion@cliente.com.co"}, msg); err != nil {
+		t.Fatalf("Expected delivery despite the QUIT error, got %v", err)
+	}
+	srv.mu.Lock()
+	data := srv.data
+	srv.mu.Unlock()
+	if !bytes.Contains(data, []byte("Hola")) {
+		t.Errorf("Expected delivered message, got %q", data)
+	}
+}
@@ -0,0 +1,280 @@
+// Package mail entrega al adquiriente los documentos validados por DIAN
+//
+// Arma el correo con el asunto y el adjunto que exige el anexo técnico: un
+// único ZIP con el nombre de naming (z…zip) que contiene el AttachedDocument
+// (ad….xml) y la representación gráfica (ad….pdf). El envío pasa por un
+// Sender (SMTP o el MemorySender de pruebas) y cada entrega retorna el estado
+// por destinatario:
+//
+//	doc, err := mail.FromResult(result)
+//	to, err := mail.Recipients(client, "900123456", "800111222")
+//	mailer, err := mail.New(mail.Config{Sender: &mail.SMTPSender{Addr: "smtp.empresa.com:587", Auth: auth}, From: "facturacion@empresa.com"})
+//	delivery, err := mailer.Deliver(doc, to...)
+package mail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/naming"
+	"github.com/diegofxm/ubl21-dian/pipeline"
+	"github.com/diegofxm/ubl21-dian/reception"
+)
+
+// MaxAttachmentSize tamaño máximo del ZIP adjunto según el anexo técnico
+const MaxAttachmentSize = 2 << 20
+
+var (
+	ErrMissingArtifact = errors.New("missing artifact for delivery")
+	ErrTooLarge        = errors.New("attachment exceeds 2 MB")
+	ErrInvalidAddress  = errors.New("invalid email address")
+	ErrInvalidSubject  = errors.New("invalid DIAN email subject")
+)
+
+// typeLabels nombre del tipo de documento para el cuerpo del correo
+var typeLabels = map[string]string{
+	"01": "Factura electrónica de venta",
+	"02": "Factura electrónica de venta de exportación",
+	"03": "Factura electrónica de venta por contingencia",
+	"04": "Factura electrónica de venta por contingencia DIAN",
+	"91": "Nota crédito electrónica",
+	"92": "Nota débito electrónica",
+}
+
+// Subject campos del asunto, separados por ";" en este orden
+type Subject struct {
+	NIT            string // NIT del facturador, sin DV
+	IssuerName     string // Nombre o razón social del facturador
+	Number         string // Número del documento (prefijo y consecutivo)
+	TypeCode       string // Código del tipo de documento (01, 91, 92, ...)
+	CommercialName string // Nombre comercial; vacío = IssuerName
+	BusinessLine   string // Línea de negocio (opcional)
+}
+
+// String arma el asunto: NIT;Nombre;Número;Tipo;Nombre comercial[;Línea]
+// Los ";" y saltos de línea de los campos se reemplazan para no romper el
+// formato ni los encabezados.
+func (s Subject) String() string {
+	commercial := s.CommercialName
+	if commercial == "" {
+		commercial = s.IssuerName
+	}
+	fields := []string{s.NIT, s.IssuerName, s.Number, s.TypeCode, commercial}
+	if s.BusinessLine != "" {
+		fields = append(fields, s.BusinessLine)
+	}
+	for i, field := range fields {
+		fields[i] = subjectReplacer.Replace(strings.TrimSpace(field))
+	}
+	return strings.Join(fields, ";")
+}
+
+// ParseSubject lee un asunto con el formato de String
+// Exige NIT numérico, número de documento y código de tipo de dos dígitos.
+func ParseSubject(subject string) (Subject, error) {
+	fields := strings.Split(strings.TrimSpace(subject), ";")
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	if len(fields) < 5 || len(fields) > 6 {
+		return Subject{}, fmt.Errorf("%w: expected 5 or 6 fields separated by \";\", got %d", ErrInvalidSubject, len(fields))
+	}
+
+	s := Subject{NIT: fields[0], IssuerName: fields[1], Number: fields[2], TypeCode: fields[3], CommercialName: fields[4]}
+	if len(fields) == 6 {
+		s.BusinessLine = fields[5]
+	}
+	if s.NIT == "" || strings.Trim(s.NIT, "0123456789") != "" {
+		return Subject{}, fmt.Errorf("%w: NIT %q", ErrInvalidSubject, s.NIT)
+	}
+	if s.Number == "" {
+		return Subject{}, fmt.Errorf("%w: missing document number", ErrInvalidSubject)
+	}
+	if len(s.TypeCode) != 2 || strings.Trim(s.TypeCode, "0123456789") != "" {
+		return Subject{}, fmt.Errorf("%w: document type %q", ErrInvalidSubject, s.TypeCode)
+	}
+	return s, nil
+}
+
+var (
+	subjectReplacer = strings.NewReplacer(";", ",", "\r", " ", "\n", " ")
+	headerReplacer  = strings.NewReplacer("\r", " ", "\n", " ")
+)
+
+// Document documento a entregar al adquiriente
+type Document struct {
+	Subject          Subject
+	Name             naming.Name // Nombre DIAN del documento firmado
+	AttachedDocument []byte
+	PDF              []byte
+	Body             string // Texto del correo; vacío = texto por defecto
+}
+
+// FromResult arma el documento a entregar con los artefactos del pipeline
+// Requiere el AttachedDocument y el PDF (pipeline con Renderer).
+func FromResult(r *pipeline.Result) (*Document, error) {
+	if len(r.AttachedDocument) == 0 {
+		return nil, fmt.Errorf("%w: AttachedDocument", ErrMissingArtifact)
+	}
+	if len(r.PDF) == 0 {
+		return nil, fmt.Errorf("%w: PDF", ErrMissingArtifact)
+	}
+	doc, err := reception.ParseDocument(r.SignedXML)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Document{
+		Subject: Subject{
+			NIT:            doc.Supplier.ID,
+			IssuerName:     doc.Supplier.Name,
+			Number:         doc.Number,
+			TypeCode:       doc.TypeCode,
+			CommercialName: doc.Supplier.CommercialName,
+		},
+		Name:             r.Name,
+		AttachedDocument: r.AttachedDocument,
+		PDF:              r.PDF,
+		Body:             defaultBody(doc),
+	}, nil
+}
+
+func defaultBody(doc *reception.Document) string {
+	label, ok := typeLabels[doc.TypeCode]
+	if !ok {
+		label = "Documento electrónico"
+	}
+	return fmt.Sprintf("Señores %s:\r\n\r\n%s expidió la %s No. %s.\r\nAdjunto encontrará el documento electrónico validado por la DIAN y su representación gráfica.\r\n\r\nCUFE/CUDE: %s\r\n",
+		doc.Customer.Name, doc.Supplier.Name, strings.ToLower(label[:1])+label[1:], doc.Number, doc.UUID)
+}
+
+// Attachment arma el ZIP adjunto: z….zip con ad….xml y ad….pdf
+func (d *Document) Attachment() (naming.File, error) {
+	if len(d.AttachedDocument) == 0 {
+		return naming.File{}, fmt.Errorf("%w: AttachedDocument", ErrMissingArtifact)
+	}
+	name := d.Name.WithKind(naming.KindAttachedDocument)
+	if _, err := naming.New(name.Kind, name.NIT, name.Provider, name.Year, name.Consecutive); err != nil {
+		return naming.File{}, err
+	}
+
+	files := []naming.File{{Name: name.String(), Data: d.AttachedDocument}}
+	if len(d.PDF) > 0 {
+		files = append(files, naming.File{Name: strings.TrimSuffix(name.String(), ".xml") + ".pdf", Data: d.PDF})
+	}
+	content, err := naming.ZipFiles(files...)
+	if err != nil {
+		return naming.File{}, err
+	}
+	if len(content) > MaxAttachmentSize {
+		return naming.File{}, fmt.Errorf("%w: %s has %d bytes", ErrTooLarge, name.Zip(), len(content))
+	}
+	return naming.File{Name: name.Zip().String(), Data: content}, nil
+}
+
+// Message correo MIME listo para enviar
+type Message struct {
+	From       string
+	To         []string
+	Subject    string
+	Body       string
+	MessageID  string // Sin "<>"
+	Date       time.Time
+	Attachment naming.File
+}
+
+// Bytes serializa el correo como multipart/mixed con el texto en
+// quoted-printable y el ZIP en base64
+func (m *Message) Bytes() ([]byte, error) {
+	from, err := parseAddress(m.From)
+	if err != nil {
+		return nil, err
+	}
+	to := make([]string, len(m.To))
+	for i, address := range m.To {
+		if to[i], err = parseAddress(address); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+	header("From", from)
+	header("To", strings.Join(to, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", headerReplacer.Replace(m.Subject)))
+	header("Date", m.Date.Format(time.RFC1123Z))
+	if m.MessageID != "" {
+		header("Message-ID", "<"+m.MessageID+">")
+	}
+	header("MIME-Version", "1.0")
+	header("Content-Type", mime.FormatMediaType("multipart/mixed", map[string]string{"boundary": w.Boundary()}))
+	buf.WriteString("\r\n")
+
+	text, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	qp := quotedprintable.NewWriter(text)
+	if _, err := qp.Write([]byte(m.Body)); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+
+	if m.Attachment.Name != "" {
+		part, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType("application/zip", map[string]string{"name": m.Attachment.Name})},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": m.Attachment.Name})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoded := base64.StdEncoding.EncodeToString(m.Attachment.Data)
+		for len(encoded) > 76 {
+			fmt.Fprintf(part, "%s\r\n", encoded[:76])
+			encoded = encoded[76:]
+		}
+		fmt.Fprintf(part, "%s\r\n", encoded)
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// parseAddress valida una dirección y la retorna lista para el encabezado
+func parseAddress(address string) (string, error) {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", ErrInvalidAddress, address)
+	}
+	return parsed.String(), nil
+}
+
+// addressOf retorna solo el correo (sin nombre) para el sobre SMTP
+func addressOf(address string) (string, error) {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", ErrInvalidAddress, address)
+	}
+	return parsed.Address, nil
+}
@@ -0,0 +1,213 @@
+package mail
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sender entrega un correo ya serializado
+//
+// Si el servidor rechaza solo algunos destinatarios, Send entrega a los demás
+// y retorna un *RecipientError con los rechazados; cualquier otro error
+// significa que el correo no se entregó a nadie.
+type Sender interface {
+	Send(from string, to []string, msg []byte) error
+}
+
+// RecipientError destinatarios rechazados por el servidor
+type RecipientError struct {
+	Rejected map[string]error
+}
+
+func (e *RecipientError) Error() string {
+	addresses := make([]string, 0, len(e.Rejected))
+	for address := range e.Rejected {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	parts := make([]string, len(addresses))
+	for i, address := range addresses {
+		parts[i] = fmt.Sprintf("%s: %v", address, e.Rejected[address])
+	}
+	return "recipients rejected: " + strings.Join(parts, "; ")
+}
+
+// ErrTLSRequired el servidor no anuncia STARTTLS y SMTPSender.RequireTLS está activo
+var ErrTLSRequired = errors.New("SMTP server does not support STARTTLS")
+
+// SMTPSender envía por SMTP con STARTTLS cuando el servidor lo anuncia
+//
+// Sin RequireTLS el cifrado es oportunista: si el servidor (o alguien en el
+// camino) no anuncia STARTTLS, el correo y las credenciales viajan en claro.
+type SMTPSender struct {
+	Addr       string        // host:puerto
+	Auth       smtp.Auth     // nil = sin autenticación
+	TLSConfig  *tls.Config   // STARTTLS; nil = ServerName del host de Addr
+	RequireTLS bool          // Falla con ErrTLSRequired si no hay STARTTLS
+	Hostname   string        // Nombre para EHLO; vacío = "localhost"
+	Timeout    time.Duration // Tiempo máximo de la conversación; 0 = 30s
+}
+
+// Send abre una conexión, envía el correo y la cierra
+func (s *SMTPSender) Send(from string, to []string, msg []byte) error {
+	host, _, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP address %q: %w", s.Addr, err)
+	}
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+
+	conn, err := net.DialTimeout("tcp", s.Addr, timeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", s.Addr, err)
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return fmt.Errorf("SMTP greeting: %w", err)
+	}
+	defer c.Close()
+
+	hostname := s.Hostname
+	if hostname == "" {
+		hostname = "localhost"
+	}
+	if err := c.Hello(hostname); err != nil {
+		return fmt.Errorf("SMTP EHLO: %w", err)
+	}
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		config := s.TLSConfig
+		if config == nil {
+			config = &tls.Config{ServerName: host}
+		}
+		if err := c.StartTLS(config); err != nil {
+			return fmt.Errorf("SMTP STARTTLS: %w", err)
+		}
+	} else if s.RequireTLS {
+		return fmt.Errorf("%w: %s", ErrTLSRequired, s.Addr)
+	}
+	if s.Auth != nil {
+		if err := c.Auth(s.Auth); err != nil {
+			return fmt.Errorf("SMTP AUTH: %w", err)
+		}
+	}
+
+	if err := c.Mail(from); err != nil {
+		return fmt.Errorf("SMTP MAIL FROM: %w", err)
+	}
+	rejected := map[string]error{}
+	for _, address := range to {
+		if err := c.Rcpt(address); err != nil {
+			var protoErr *textproto.Error
+			if !errors.As(err, &protoErr) {
+				return fmt.Errorf("SMTP RCPT TO: %w", err)
+			}
+			rejected[address] = err
+		}
+	}
+	if len(rejected) == len(to) {
+		return &RecipientError{Rejected: rejected}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP DATA: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("SMTP DATA: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("SMTP DATA: %w", err)
+	}
+	// El servidor ya aceptó el correo: un error en QUIT no cambia la entrega
+	// y reportarlo llevaría a reenviarlo
+	_ = c.Quit()
+
+	if len(rejected) > 0 {
+		return &RecipientError{Rejected: rejected}
+	}
+	return nil
+}
+
+// SentMessage correo recibido por un MemorySender
+type SentMessage struct {
+	From string
+	To   []string
+	Data []byte
+}
+
+// MemorySender guarda los correos en memoria (pruebas y desarrollo)
+type MemorySender struct {
+	mu       sync.Mutex
+	messages []SentMessage
+	rejected map[string]error
+	err      error
+}
+
+// NewMemorySender crea un sender en memoria
+func NewMemorySender() *MemorySender {
+	return &MemorySender{rejected: map[string]error{}}
+}
+
+// Reject hace que el sender rechace una dirección con err
+func (s *MemorySender) Reject(address string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rejected[strings.ToLower(address)] = err
+}
+
+// Fail hace que los envíos siguientes fallen con err (nil = vuelven a
+// funcionar)
+func (s *MemorySender) Fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// Send implementa Sender
+func (s *MemorySender) Send(from string, to []string, msg []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+
+	rejected := map[string]error{}
+	var accepted []string
+	for _, address := range to {
+		if err, ok := s.rejected[strings.ToLower(address)]; ok {
+			rejected[address] = err
+			continue
+		}
+		accepted = append(accepted, address)
+	}
+	if len(accepted) > 0 {
+		s.messages = append(s.messages, SentMessage{From: from, To: accepted, Data: append([]byte(nil), msg...)})
+	}
+	if len(rejected) > 0 {
+		return &RecipientError{Rejected: rejected}
+	}
+	return nil
+}
+
+// Messages retorna los correos enviados en orden
+func (s *MemorySender) Messages() []SentMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SentMessage(nil), s.messages...)
+}
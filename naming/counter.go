@@ -0,0 +1,169 @@
+package naming
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Store guarda el último consecutivo de archivo por emisor y año
+//
+// Next debe reservar el consecutivo de forma atómica: dos llamadas nunca
+// retornan el mismo valor para el mismo NIT y año. Implementaciones sobre
+// una base de datos pueden usar un UPDATE ... RETURNING o equivalente.
+type Store interface {
+	Next(nit string, year int) (uint32, error)
+}
+
+// Counter asigna nombres de archivo consecutivos a un emisor
+//
+//	counter := naming.NewCounter(naming.NewFileStore("consecutivos.json"), "900123456", "")
+//	name, err := counter.Next(naming.KindInvoice)
+//	pkg, err := naming.PackageDocument(name, signedXML)
+//
+// El consecutivo es único por emisor y año entre todos los tipos de
+// documento y se reinicia en 1 cada año.
+type Counter struct {
+	store    Store
+	nit      string
+	provider string
+	now      func() time.Time
+}
+
+// NewCounter crea un contador para el NIT (sin DV) y código de proveedor
+// provider vacío equivale a DefaultProvider.
+func NewCounter(store Store, nit, provider string) *Counter {
+	return &Counter{store: store, nit: nit, provider: provider, now: time.Now}
+}
+
+// SetClock reemplaza el reloj usado para el año (tests)
+func (c *Counter) SetClock(now func() time.Time) {
+	c.now = now
+}
+
+// Next reserva el siguiente consecutivo del año y arma el nombre
+func (c *Counter) Next(kind Kind) (Name, error) {
+	year := c.now().Year()
+
+	// Validar antes de consumir un consecutivo
+	name, err := New(kind, c.nit, c.provider, year, 1)
+	if err != nil {
+		return Name{}, err
+	}
+
+	consecutive, err := c.store.Next(name.NIT, year)
+	if err != nil {
+		return Name{}, fmt.Errorf("failed to reserve file consecutive: %w", err)
+	}
+	if consecutive == 0 {
+		return Name{}, fmt.Errorf("%w: store returned consecutive 0", ErrInvalidName)
+	}
+
+	name.Consecutive = consecutive
+	return name, nil
+}
+
+// storeKey clave de un emisor y año ("0900123456/2025")
+func storeKey(nit string, year int) string {
+	return nit + "/" + strconv.Itoa(year)
+}
+
+// next incrementa el consecutivo de key en values
+func next(values map[string]uint32, key string) (uint32, error) {
+	if values[key] == MaxConsecutive {
+		return 0, fmt.Errorf("consecutive for %s exhausted", key)
+	}
+	values[key]++
+	return values[key], nil
+}
+
+// MemoryStore Store en memoria (tests o procesos de corta duración)
+type MemoryStore struct {
+	mu     sync.Mutex
+	values map[string]uint32
+}
+
+// NewMemoryStore crea un Store en memoria vacío
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{values: map[string]uint32{}}
+}
+
+// Next implementa Store
+func (s *MemoryStore) Next(nit string, year int) (uint32, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return next(s.values, storeKey(nit, year))
+}
+
+// FileStore Store persistido en un archivo JSON
+//
+// Cada reserva reescribe el archivo de forma atómica (archivo temporal +
+// rename). Es seguro entre goroutines de un mismo proceso; para varios
+// procesos use un Store sobre una base de datos.
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileStore crea un Store sobre path (se crea en la primera reserva)
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Next implementa Store
+func (s *FileStore) Next(nit string, year int) (uint32, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	values := map[string]uint32{}
+	data, err := os.ReadFile(s.path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return 0, err
+	default:
+		if err := json.Unmarshal(data, &values); err != nil {
+			return 0, fmt.Errorf("failed to parse consecutive store %s: %w", s.path, err)
+		}
+	}
+
+	consecutive, err := next(values, storeKey(nit, year))
+	if err != nil {
+		return 0, err
+	}
+	if err := s.save(values); err != nil {
+		return 0, err
+	}
+	return consecutive, nil
+}
+
+// save escribe los consecutivos de forma atómica
+func (s *FileStore) save(values map[string]uint32) error {
+	data, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode consecutive store: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save consecutive store: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save consecutive store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save consecutive store: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to save consecutive store: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+// Package naming genera y lee los nombres de archivo que exige DIAN
+//
+// Los XML y ZIP enviados a DIAN, y el AttachedDocument, se nombran con el
+// tipo de documento, el NIT del emisor (10 dígitos), el código del proveedor
+// tecnológico (3 dígitos, "000" para software propio), los dos últimos
+// dígitos del año y un consecutivo hexadecimal de 8 caracteres único por
+// emisor y año:
+//
+//	fv0900123456000250000001f.xml
+//	z0900123456000250000001f.zip
+//
+// El consecutivo se lleva con un Counter sobre un Store intercambiable y el
+// ZIP se arma con Zip (ver zip.go).
+package naming
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Kind tipo de archivo (prefijo del nombre)
+type Kind string
+
+const (
+	KindInvoice          Kind = "fv" // Factura electrónica de venta
+	KindCreditNote       Kind = "nc" // Nota crédito
+	KindDebitNote        Kind = "nd" // Nota débito
+	KindSupportDocument  Kind = "ds" // Documento soporte
+	KindAttachedDocument Kind = "ad" // AttachedDocument entregado al adquiriente
+	KindZip              Kind = "z"  // ZIP de envío
+)
+
+// Longitudes de los campos del nombre
+const (
+	NITLength         = 10
+	ProviderLength    = 3
+	ConsecutiveLength = 8
+)
+
+// DefaultProvider código de proveedor para software propio
+const DefaultProvider = "000"
+
+// MaxConsecutive mayor consecutivo representable en 8 caracteres hexadecimales
+const MaxConsecutive = 0xFFFFFFFF
+
+// ErrInvalidName nombre o campos que no cumplen la convención de DIAN
+var ErrInvalidName = errors.New("invalid DIAN file name")
+
+// kinds prefijos válidos, del más largo al más corto para Parse
+var kinds = []Kind{KindInvoice, KindCreditNote, KindDebitNote, KindSupportDocument, KindAttachedDocument, KindZip}
+
+// Name nombre de archivo DIAN descompuesto en sus campos
+type Name struct {
+	Kind        Kind
+	NIT         string // 10 dígitos, con ceros a la izquierda
+	Provider    string // Código del proveedor tecnológico (3 dígitos)
+	Year        int    // Año completo (2000-2099)
+	Consecutive uint32 // 1 a MaxConsecutive
+}
+
+// New arma un nombre validando y normalizando sus campos
+//
+// nit y provider se completan con ceros a la izquierda; nit no lleva el
+// dígito de verificación. provider vacío equivale a DefaultProvider.
+func New(kind Kind, nit, provider string, year int, consecutive uint32) (Name, error) {
+	if !validKind(kind) {
+		return Name{}, fmt.Errorf("%w: unknown kind %q", ErrInvalidName, kind)
+	}
+	nit, err := pad(nit, NITLength, "NIT")
+	if err != nil {
+		return Name{}, err
+	}
+	if provider == "" {
+		provider = DefaultProvider
+	}
+	provider, err = pad(provider, ProviderLength, "provider code")
+	if err != nil {
+		return Name{}, err
+	}
+	if year < 2000 || year > 2099 {
+		return Name{}, fmt.Errorf("%w: year %d out of range", ErrInvalidName, year)
+	}
+	if consecutive == 0 {
+		return Name{}, fmt.Errorf("%w: consecutive must be greater than zero", ErrInvalidName)
+	}
+
+	return Name{Kind: kind, NIT: nit, Provider: provider, Year: year, Consecutive: consecutive}, nil
+}
+
+// Parse lee un nombre de archivo DIAN ("fv0900123456000250000001f.xml")
+func Parse(fileName string) (Name, error) {
+	name := strings.ToLower(fileName)
+
+	var kind Kind
+	for _, k := range kinds {
+		if strings.HasPrefix(name, string(k)) {
+			kind = k
+			break
+		}
+	}
+	if kind == "" {
+		return Name{}, fmt.Errorf("%w: %q has no known prefix", ErrInvalidName, fileName)
+	}
+	if !strings.HasSuffix(name, kind.Extension()) {
+		return Name{}, fmt.Errorf("%w: %q must end in %s", ErrInvalidName, fileName, kind.Extension())
+	}
+
+	fields := strings.TrimSuffix(strings.TrimPrefix(name, string(kind)), kind.Extension())
+	if len(fields) != NITLength+ProviderLength+2+ConsecutiveLength {
+		return Name{}, fmt.Errorf("%w: %q has wrong length", ErrInvalidName, fileName)
+	}
+
+	nit := fields[:NITLength]
+	provider := fields[NITLength : NITLength+ProviderLength]
+	yy := fields[NITLength+ProviderLength : NITLength+ProviderLength+2]
+	hex := fields[NITLength+ProviderLength+2:]
+
+	if !digits(nit) || !digits(provider) || !digits(yy) {
+		return Name{}, fmt.Errorf("%w: %q has non-numeric NIT, provider or year", ErrInvalidName, fileName)
+	}
+	consecutive, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return Name{}, fmt.Errorf("%w: %q has invalid consecutive %q", ErrInvalidName, fileName, hex)
+	}
+	year, _ := strconv.Atoi(yy)
+
+	return New(kind, nit, provider, 2000+year, uint32(consecutive))
+}
+
+// String nombre de archivo con extensión
+func (n Name) String() string {
+	return fmt.Sprintf("%s%s%s%02d%08x%s", n.Kind, n.NIT, n.Provider, n.Year%100, n.Consecutive, n.Kind.Extension())
+}
+
+// WithKind mismo nombre con otro tipo de archivo
+func (n Name) WithKind(kind Kind) Name {
+	n.Kind = kind
+	return n
+}
+
+// Zip nombre del ZIP que transporta el documento (mismo consecutivo)
+func (n Name) Zip() Name {
+	return n.WithKind(KindZip)
+}
+
+// Extension extensión del archivo según el tipo (".zip" o ".xml")
+func (k Kind) Extension() string {
+	if k == KindZip {
+		return ".zip"
+	}
+	return ".xml"
+}
+
+// validKind indica si kind es un prefijo conocido
+func validKind(kind Kind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// pad completa value con ceros a la izquierda hasta length dígitos
+func pad(value string, length int, field string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || !digits(value) {
+		return "", fmt.Errorf("%w: %s %q must be numeric", ErrInvalidName, field, value)
+	}
+	if len(value) > length {
+		return "", fmt.Errorf("%w: %s %q exceeds %d digits", ErrInvalidName, field, value, length)
+	}
+	return strings.Repeat("0", length-len(value)) + value, nil
+}
+
+// digits indica si s contiene solo dígitos decimales
+func digits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
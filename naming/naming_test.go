@@ -0,0 +1,154 @@
+package naming_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/naming"
+)
+
+func TestName(t *testing.T) {
+	name, err := naming.New(naming.KindInvoice, "900123456", "", 2025, 0x1f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := name.String(); got != "fv0900123456000250000001f.xml" {
+		t.Errorf("Unexpected name %s", got)
+	}
+	if got := name.Zip().String(); got != "z0900123456000250000001f.zip" {
+		t.Errorf("Unexpected ZIP name %s", got)
+	}
+
+	for _, kind := range []naming.Kind{naming.KindInvoice, naming.KindCreditNote, naming.KindDebitNote, naming.KindSupportDocument, naming.KindAttachedDocument, naming.KindZip} {
+		want, err := naming.New(kind, "800111222", "123", 2031, naming.MaxConsecutive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := naming.Parse(want.String())
+		if err != nil || got != want {
+			t.Errorf("%s: round trip got %+v, %v", kind, got, err)
+		}
+	}
+
+	for _, invalid := range []string{
+		"fv0900123456000250000001f.zip", // extensión de ZIP en un XML
+		"z0900123456000250000001f.xml",
+		"xx0900123456000250000001f.xml",
+		"fv090012345600025000001f.xml",  // consecutivo de 7
+		"fv0900123456000250000000.xml",  // consecutivo 0
+		"fv09001234560002500000g1.xml",  // no hexadecimal
+		"fv09001234a6000250000001f.xml", // NIT no numérico
+		"FES-990000001.zip",
+	} {
+		if _, err := naming.Parse(invalid); !errors.Is(err, naming.ErrInvalidName) {
+			t.Errorf("%s: expected ErrInvalidName, got %v", invalid, err)
+		}
+	}
+
+	if _, err := naming.New(naming.KindInvoice, "900123456-7", "", 2025, 1); !errors.Is(err, naming.ErrInvalidName) {
+		t.Errorf("Expected NIT with DV to be rejected, got %v", err)
+	}
+}
+
+func TestPackageDocument(t *testing.T) {
+	name, _ := naming.New(naming.KindCreditNote, "900123456", "", 2025, 7)
+	xmlDoc := []byte(`<CreditNote><cbc:ID>NC7</cbc:ID></CreditNote>`)
+
+	pkg, err := naming.PackageDocument(name, xmlDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg.FileName != "z09001234560002500000007.zip" || pkg.Member != "nc09001234560002500000007.xml" {
+		t.Errorf("Unexpected names: %s, %s", pkg.FileName, pkg.Member)
+	}
+
+	// Mismo XML, mismos bytes
+	again, _ := naming.PackageDocument(name, xmlDoc)
+	if !bytes.Equal(pkg.Content, again.Content) || pkg.ContentFile != again.ContentFile {
+		t.Error("Expected deterministic ZIP")
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(pkg.Content), int64(len(pkg.Content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.File) != 1 || r.File[0].Name != pkg.Member || r.File[0].Method != zip.Deflate {
+		t.Fatalf("Unexpected ZIP entries: %+v", r.File)
+	}
+	f, _ := r.File[0].Open()
+	data, _ := io.ReadAll(f)
+	if !bytes.Equal(data, xmlDoc) {
+		t.Errorf("Unexpected ZIP content %q", data)
+	}
+
+	if _, err := naming.PackageDocument(name.Zip(), xmlDoc); !errors.Is(err, naming.ErrInvalidName) {
+		t.Errorf("Expected ErrInvalidName for ZIP name, got %v", err)
+	}
+}
+
+func TestCounter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "consecutivos.json")
+	now := time.Date(2025, 12, 31, 23, 0, 0, 0, time.UTC)
+
+	counter := naming.NewCounter(naming.NewFileStore(path), "900123456", "")
+	counter.SetClock(func() time.Time { return now })
+
+	// Consecutivos únicos entre goroutines y tipos de documento
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		seen = map[uint32]bool{}
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			kind := naming.KindInvoice
+			if i%2 == 1 {
+				kind = naming.KindCreditNote
+			}
+			name, err := counter.Next(kind)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if seen[name.Consecutive] {
+				t.Errorf("Duplicate consecutive %d", name.Consecutive)
+			}
+			seen[name.Consecutive] = true
+		}(i)
+	}
+	wg.Wait()
+
+	// El archivo conserva el consecutivo entre procesos
+	reopened := naming.NewCounter(naming.NewFileStore(path), "900123456", "")
+	reopened.SetClock(func() time.Time { return now })
+	name, err := reopened.Next(naming.KindDebitNote)
+	if err != nil || name.String() != "nd09001234560002500000015.xml" {
+		t.Errorf("Expected consecutive 21, got %s, %v", name, err)
+	}
+
+	// Nuevo año: el consecutivo se reinicia
+	reopened.SetClock(func() time.Time { return now.Add(2 * time.Hour) })
+	name, err = reopened.Next(naming.KindInvoice)
+	if err != nil || name.String() != "fv09001234560002600000001.xml" {
+		t.Errorf("Expected first consecutive of 2026, got %s, %v", name, err)
+	}
+
+	// Un NIT inválido no consume consecutivos
+	store := naming.NewMemoryStore()
+	if _, err := naming.NewCounter(store, "NIT", "").Next(naming.KindInvoice); !errors.Is(err, naming.ErrInvalidName) {
+		t.Errorf("Expected ErrInvalidName, got %v", err)
+	}
+	if c, _ := store.Next("NIT", 2025); c != 1 {
+		t.Errorf("Expected untouched store, got %d", c)
+	}
+}
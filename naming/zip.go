@@ -0,0 +1,79 @@
+package naming
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"time"
+)
+
+// zipModified fecha fija de la entrada del ZIP para que el resultado sea
+// determinístico (mismo XML, mismos bytes)
+var zipModified = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Package documento firmado empaquetado para enviar a DIAN
+type Package struct {
+	FileName    string // Nombre del ZIP (fileName del request)
+	Member      string // Nombre del XML dentro del ZIP
+	Content     []byte // ZIP
+	ContentFile string // ZIP en base64 (contentFile del request)
+}
+
+// File archivo dentro de un ZIP
+type File struct {
+	Name string
+	Data []byte
+}
+
+// Zip arma un ZIP con un único XML comprimido con deflate
+//
+// El ZIP es determinístico: la fecha de la entrada es fija, así que el mismo
+// XML produce siempre los mismos bytes.
+func Zip(member string, data []byte) ([]byte, error) {
+	return ZipFiles(File{Name: member, Data: data})
+}
+
+// ZipFiles arma un ZIP determinístico con varios archivos en el orden dado
+// (AttachedDocument y PDF del correo al adquiriente)
+func ZipFiles(files ...File) ([]byte, error) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+
+	for _, file := range files {
+		header := &zip.FileHeader{Name: file.Name, Method: zip.Deflate, Modified: zipModified}
+		f, err := w.CreateHeader(header)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add %s to ZIP: %w", file.Name, err)
+		}
+		if _, err := f.Write(file.Data); err != nil {
+			return nil, fmt.Errorf("failed to add %s to ZIP: %w", file.Name, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close ZIP: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// PackageDocument empaqueta un XML firmado con el nombre de DIAN
+// El XML se llama name y el ZIP lleva el mismo consecutivo con prefijo "z".
+func PackageDocument(name Name, signedXML []byte) (*Package, error) {
+	if name.Kind == KindZip {
+		return nil, fmt.Errorf("%w: %s is a ZIP name, expected a document name", ErrInvalidName, name)
+	}
+
+	member := name.String()
+	content, err := Zip(member, signedXML)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Package{
+		FileName:    name.Zip().String(),
+		Member:      member,
+		Content:     content,
+		ContentFile: base64.StdEncoding.EncodeToString(content),
+	}, nil
+}
@@ -0,0 +1,17 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package numbering
+
+import (
+	"errors"
+	"os"
+)
+
+// tryLockFile sin locks de archivo en esta plataforma; use SQLStore
+func tryLockFile(*os.File) (bool, error) {
+	return false, errors.New("file locks are not supported on this platform")
+}
+
+func unlockFile(*os.File) error {
+	return nil
+}
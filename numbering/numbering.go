@@ -0,0 +1,273 @@
+// Package numbering asigna consecutivos dentro de los rangos autorizados por DIAN
+//
+// Un Manager conoce los rangos (prefijo, resolución, desde/hasta y vigencia)
+// y reserva cada número de forma atómica en un Store, de modo que varios
+// procesos no emitan el mismo número ni números fuera de la resolución:
+//
+//	manager, err := numbering.NewManager(numbering.Config{
+//		Store:     numbering.NewFileStore("consecutivos.json"),
+//		OnWarning: func(w numbering.Warning) { log.Println(w) },
+//	})
+//	manager.Sync(client, "900123456", softwareID) // rangos de GetNumberingRange
+//	alloc, err := manager.Next("SETP")            // alloc.ID = "SETP990000000"
+package numbering
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/soap/types"
+)
+
+var (
+	// ErrInvalidRange rango incompleto o inconsistente
+	ErrInvalidRange = errors.New("invalid numbering range")
+	// ErrNoRange no hay rango registrado para el prefijo
+	ErrNoRange = errors.New("no numbering range for prefix")
+	// ErrOutsideValidity la fecha actual está fuera de la vigencia de la resolución
+	ErrOutsideValidity = errors.New("numbering range outside its validity period")
+	// ErrExhausted se usaron todos los números del rango
+	ErrExhausted = errors.New("numbering range exhausted")
+)
+
+// DefaultThresholds fracciones usadas del rango que generan advertencia
+var DefaultThresholds = []float64{0.8, 0.9, 0.95}
+
+// Range rango de numeración autorizado
+type Range struct {
+	Resolution   string // Número de la resolución (InvoiceAuthorization)
+	Prefix       string
+	From         int64
+	To           int64
+	StartDate    time.Time // Inicio de vigencia (inclusive)
+	EndDate      time.Time // Fin de vigencia (inclusive)
+	TechnicalKey string    // Clave técnica para el CUFE
+}
+
+// Key clave del rango en el Store ("SETP/18760000001")
+func (r Range) Key() string {
+	return r.Prefix + "/" + r.Resolution
+}
+
+// Size cantidad de números del rango
+func (r Range) Size() int64 {
+	return r.To - r.From + 1
+}
+
+// Valid indica si now está dentro de la vigencia (por fecha, sin hora)
+func (r Range) Valid(now time.Time) bool {
+	today := now.Format("2006-01-02")
+	return today >= r.StartDate.Format("2006-01-02") && today <= r.EndDate.Format("2006-01-02")
+}
+
+// validate verifica los campos del rango
+func (r Range) validate() error {
+	switch {
+	case r.Resolution == "":
+		return fmt.Errorf("%w: resolution is required", ErrInvalidRange)
+	case r.From < 0 || r.To < r.From:
+		return fmt.Errorf("%w: %s from %d to %d", ErrInvalidRange, r.Key(), r.From, r.To)
+	case r.StartDate.IsZero() || r.EndDate.Before(r.StartDate):
+		return fmt.Errorf("%w: %s validity %s to %s", ErrInvalidRange, r.Key(),
+			r.StartDate.Format("2006-01-02"), r.EndDate.Format("2006-01-02"))
+	}
+	return nil
+}
+
+// FromDIAN convierte un rango de GetNumberingRange
+func FromDIAN(rng types.NumberingRange) (Range, error) {
+	start, err := time.Parse("2006-01-02", rng.DateFrom)
+	if err != nil {
+		return Range{}, fmt.Errorf("%w: invalid DateFrom %q", ErrInvalidRange, rng.DateFrom)
+	}
+	end, err := time.Parse("2006-01-02", rng.DateTo)
+	if err != nil {
+		return Range{}, fmt.Errorf("%w: invalid DateTo %q", ErrInvalidRange, rng.DateTo)
+	}
+
+	r := Range{
+		Resolution:   rng.Resolution,
+		Prefix:       rng.Prefix,
+		From:         rng.From,
+		To:           rng.To,
+		StartDate:    start,
+		EndDate:      end,
+		TechnicalKey: rng.TechnicalKey,
+	}
+	return r, r.validate()
+}
+
+// Allocation número asignado
+type Allocation struct {
+	Range     Range
+	Number    int64
+	ID        string // Prefijo + número (cbc:ID)
+	Remaining int64  // Números libres del rango después de este
+}
+
+// Warning aviso de agotamiento de un rango
+type Warning struct {
+	Range     Range
+	Threshold float64 // Umbral alcanzado (ej: 0.9)
+	Used      int64
+	Remaining int64
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("numbering range %s reached %.0f%%: %d used, %d remaining",
+		w.Range.Key(), w.Threshold*100, w.Used, w.Remaining)
+}
+
+// Config configuración del Manager
+type Config struct {
+	Store      Store
+	Thresholds []float64        // DefaultThresholds si está vacío
+	OnWarning  func(Warning)    // Opcional
+	Now        func() time.Time // time.Now por defecto
+}
+
+// Manager asigna consecutivos; es seguro para uso concurrente y, con un
+// Store compartido (FileStore, SQLStore), entre procesos
+type Manager struct {
+	config Config
+
+	mu     sync.RWMutex
+	ranges map[string]Range // Por Key
+}
+
+// NewManager crea un Manager sin rangos (ver AddRange y Sync)
+func NewManager(config Config) (*Manager, error) {
+	if config.Store == nil {
+		return nil, errors.New("numbering store is required")
+	}
+	if len(config.Thresholds) == 0 {
+		config.Thresholds = DefaultThresholds
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	return &Manager{config: config, ranges: map[string]Range{}}, nil
+}
+
+// AddRange registra o reemplaza un rango autorizado
+func (m *Manager) AddRange(r Range) error {
+	if err := r.validate(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ranges[r.Key()] = r
+	return nil
+}
+
+// Ranges rangos registrados ordenados por prefijo y vigencia
+func (m *Manager) Ranges() []Range {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	list := make([]Range, 0, len(m.ranges))
+	for _, r := range m.ranges {
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Prefix != list[j].Prefix {
+			return list[i].Prefix < list[j].Prefix
+		}
+		return list[i].StartDate.Before(list[j].StartDate)
+	})
+	return list
+}
+
+// Next asigna el siguiente número del prefijo
+//
+// Usa los rangos vigentes del prefijo en orden de vigencia; si uno se agota
+// continúa con el siguiente. Nunca retorna números fuera de [From, To].
+func (m *Manager) Next(prefix string) (*Allocation, error) {
+	now := m.config.Now()
+
+	var candidates []Range
+	for _, r := range m.Ranges() {
+		if r.Prefix == prefix {
+			candidates = append(candidates, r)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w %q", ErrNoRange, prefix)
+	}
+
+	err := fmt.Errorf("%w: no range for %q valid on %s", ErrOutsideValidity, prefix, now.Format("2006-01-02"))
+	for _, r := range candidates {
+		if !r.Valid(now) {
+			continue
+		}
+
+		number, serr := m.config.Store.Next(r.Key(), r.From)
+		if serr != nil {
+			return nil, fmt.Errorf("failed to allocate %s: %w", r.Key(), serr)
+		}
+		if number < r.From || number > r.To {
+			err = fmt.Errorf("%w: %s (%d-%d)", ErrExhausted, r.Key(), r.From, r.To)
+			continue
+		}
+
+		alloc := &Allocation{
+			Range:     r,
+			Number:    number,
+			ID:        r.Prefix + strconv.FormatInt(number, 10),
+			Remaining: r.To - number,
+		}
+		m.warn(alloc)
+		return alloc, nil
+	}
+	return nil, err
+}
+
+// warn avisa los umbrales que cruza la asignación
+func (m *Manager) warn(alloc *Allocation) {
+	if m.config.OnWarning == nil {
+		return
+	}
+	size := float64(alloc.Range.Size())
+	used := alloc.Number - alloc.Range.From + 1
+	for _, threshold := range m.config.Thresholds {
+		before := float64(used-1) / size
+		after := float64(used) / size
+		if before < threshold && after >= threshold {
+			m.config.OnWarning(Warning{Range: alloc.Range, Threshold: threshold, Used: used, Remaining: alloc.Remaining})
+		}
+	}
+}
+
+// RangeSource consulta de rangos autorizados (*soap.Client la implementa)
+type RangeSource interface {
+	GetNumberingRange(req *types.GetNumberingRangeRequest) (*types.GetNumberingRangeResponse, error)
+}
+
+// Sync registra los rangos autorizados por DIAN para el NIT y software
+// Los consecutivos ya asignados se conservan (el Store no se modifica).
+func (m *Manager) Sync(source RangeSource, nit, softwareID string) ([]Range, error) {
+	resp, err := source.GetNumberingRange(&types.GetNumberingRangeRequest{NIT: nit, SoftwareID: softwareID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get numbering ranges: %w", err)
+	}
+	if len(resp.Ranges) == 0 {
+		return nil, fmt.Errorf("%w: DIAN returned %s %s", ErrNoRange, resp.StatusCode, resp.StatusMessage)
+	}
+
+	ranges := make([]Range, 0, len(resp.Ranges))
+	for _, rng := range resp.Ranges {
+		r, err := FromDIAN(rng)
+		if err != nil {
+			return nil, err
+		}
+		if err := m.AddRange(r); err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
@@ -0,0 +1,281 @@
+package numbering_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/numbering"
+	"github.com/diegofxm/ubl21-dian/soap"
+	"github.com/diegofxm/ubl21-dian/soap/simulator"
+	"github.com/diegofxm/ubl21-dian/soap/types"
+)
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 12, 0, 0, 0, time.UTC)
+}
+
+func newManager(t *testing.T, store numbering.Store, now time.Time, warnings *[]numbering.Warning) *numbering.Manager {
+	t.Helper()
+	m, err := numbering.NewManager(numbering.Config{
+		Store:     store,
+		Now:       func() time.Time { return now },
+		OnWarning: func(w numbering.Warning) { *warnings = append(*warnings, w) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestManagerNext(t *testing.T) {
+	var warnings []numbering.Warning
+	m := newManager(t, numbering.NewMemoryStore(), day(2025, 6, 1), &warnings)
+
+	if err := m.AddRange(numbering.Range{Resolution: "1", Prefix: "SETP", From: 10, To: 5, StartDate: day(2025, 1, 1), EndDate: day(2025, 12, 31)}); !errors.Is(err, numbering.ErrInvalidRange) {
+		t.Errorf("Expected ErrInvalidRange, got %v", err)
+	}
+	if _, err := m.Next("SETP"); !errors.Is(err, numbering.ErrNoRange) {
+		t.Errorf("Expected ErrNoRange, got %v", err)
+	}
+
+	// Resolución vencida, una vigente de 10 números y otra posterior
+	ranges := []numbering.Range{
+		{Resolution: "old", Prefix: "SETP", From: 1, To: 100, StartDate: day(2023, 1, 1), EndDate: day(2024, 12, 31)},
+		{Resolution: "cur", Prefix: "SETP", From: 101, To: 110, StartDate: day(2025, 1, 1), EndDate: day(2025, 12, 31)},
+		{Resolution: "next", Prefix: "SETP", From: 111, To: 111, StartDate: day(2025, 6, 1), EndDate: day(2026, 12, 31)},
+	}
+	for _, r := range ranges {
+		if err := m.AddRange(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var ids []string
+	for i := 0; i < 11; i++ {
+		alloc, err := m.Next("SETP")
+		if err != nil {
+			t.Fatalf("Next %d: %v", i, err)
+		}
+		ids = append(ids, alloc.ID)
+	}
+	if ids[0] != "SETP101" || ids[9] != "SETP110" || ids[10] != "SETP111" {
+		t.Errorf("Unexpected allocations: %v", ids)
+	}
+	if _, err := m.Next("SETP"); !errors.Is(err, numbering.ErrExhausted) {
+		t.Errorf("Expected ErrExhausted, got %v", err)
+	}
+
+	// 80%, 90% y 95% del rango "cur" más 80%..95% del rango de un número
+	if len(warnings) != 6 || warnings[0].Threshold != 0.8 || warnings[0].Used != 8 || warnings[1].Remaining != 1 {
+		t.Errorf("Unexpected warnings: %v", warnings)
+	}
+
+	expired := newManager(t, numbering.NewMemoryStore(), day(2027, 1, 1), &warnings)
+	expired.AddRange(ranges[0])
+	if _, err := expired.Next("SETP"); !errors.Is(err, numbering.ErrOutsideValidity) {
+		t.Errorf("Expected ErrOutsideValidity, got %v", err)
+	}
+}
+
+func TestFileStoreConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "consecutivos.json")
+
+	var mu sync.Mutex
+	seen := map[int64]bool{}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		// Un FileStore por goroutine simula procesos independientes
+		store := numbering.NewFileStore(path)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				n, err := store.Next("SETP/1", 1000)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				if seen[n] {
+					t.Errorf("Number %d allocated twice", n)
+				}
+				seen[n] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(seen) != 100 || !seen[1000] || !seen[1099] {
+		t.Errorf("Expected numbers 1000-1099, got %d numbers", len(seen))
+	}
+	if n, _ := numbering.NewFileStore(path).Next("SETP/1", 1000); n != 1100 {
+		t.Errorf("Expected persisted counter 1100, got %d", n)
+	}
+}
+
+func TestFileStoreLeftoverLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "consecutivos.json")
+
+	// Archivo de lock de un proceso caído: el sistema ya liberó su lock
+	if err := os.WriteFile(path+".lock", []byte("4242\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store := numbering.NewFileStore(path)
+	store.Timeout = 100 * time.Millisecond
+	if n, err := store.Next("SETP/1", 1); err != nil || n != 1 {
+		t.Fatalf("Expected 1 with a leftover lock file, got %d, %v", n, err)
+	}
+}
+
+func TestSQLStore(t *testing.T) {
+	db, err := sql.Open("numbering-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	store := numbering.NewSQLStore(db, "", numbering.Dollar)
+	if err := store.CreateTable(); err != nil {
+		t.Fatal(err)
+	}
+	for want := int64(990000000); want < 990000003; want++ {
+		n, err := store.Next("SETP/18760000001", 990000000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != want {
+			t.Errorf("Expected %d, got %d", want, n)
+		}
+	}
+}
+
+func TestSync(t *testing.T) {
+	creds, err := simulator.NewTestCredentials(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim := simulator.New()
+	srv := httptest.NewServer(sim)
+	defer srv.Close()
+
+	client, err := soap.NewClient(&types.Config{Certificate: creds.CertPath, PrivateKey: creds.KeyPath, Endpoint: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var warnings []numbering.Warning
+	m := newManager(t, numbering.NewMemoryStore(), day(2025, 6, 1), &warnings)
+	if _, err := m.Sync(client, "900123456", "soft-1"); !errors.Is(err, numbering.ErrNoRange) {
+		t.Errorf("Expected ErrNoRange without authorized ranges, got %v", err)
+	}
+
+	sim.AddNumberingRange("900123456", "soft-1", types.NumberingRange{
+		Prefix: "SETP", From: 990000000, To: 995000000, DateFrom: "2019-01-19", DateTo: "2030-01-19",
+		Resolution: "18760000001", ResolutionDate: "2019-01-19", TechnicalKey: "fc8eac422eba16e22ffd8c6f94b3f40a6e38162c",
+	})
+	ranges, err := m.Sync(client, "900123456", "soft-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ranges) != 1 || ranges[0].TechnicalKey != "fc8eac422eba16e22ffd8c6f94b3f40a6e38162c" {
+		t.Fatalf("Unexpected ranges: %+v", ranges)
+	}
+
+	alloc, err := m.Next("SETP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alloc.ID != "SETP990000000" || alloc.Remaining != 5000000 {
+		t.Errorf("Unexpected allocation: %+v", alloc)
+	}
+}
+
+// Driver database/sql mínimo para las sentencias de SQLStore
+
+func init() {
+	sql.Register("numbering-fake", &fakeDriver{rows: map[string]int64{}})
+}
+
+type fakeDriver struct {
+	mu   sync.Mutex
+	rows map[string]int64
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) { return &fakeConn{d: d}, nil }
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{d: c.d, query: query}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	d     *fakeDriver
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return strings.Count(s.query, "$") }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(s.query, "CREATE"):
+		return driver.RowsAffected(0), nil
+	case strings.Contains(s.query, "last_number + 1"):
+		key := args[0].(string)
+		if _, ok := s.d.rows[key]; !ok {
+			return driver.RowsAffected(0), nil
+		}
+		s.d.rows[key]++
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(s.query, "INSERT"):
+		s.d.rows[args[0].(string)] = args[1].(int64)
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(s.query, "UPDATE"):
+		s.d.rows[args[1].(string)] = args[0].(int64)
+		return driver.RowsAffected(1), nil
+	}
+	return nil, errors.New("unexpected query: " + s.query)
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	return &fakeRows{value: s.d.rows[args[0].(string)]}, nil
+}
+
+type fakeRows struct {
+	value int64
+	done  bool
+}
+
+func (r *fakeRows) Columns() []string { return []string{"last_number"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.value
+	return nil
+}
@@ -0,0 +1,111 @@
+package numbering
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+)
+
+// Placeholder genera el marcador del parámetro n (desde 1) según el driver
+type Placeholder func(n int) string
+
+var (
+	// QuestionMark marcadores "?" (MySQL, SQLite, SQL Server con go-mssqldb en modo ?)
+	QuestionMark Placeholder = func(int) string { return "?" }
+	// Dollar marcadores "$1", "$2" (PostgreSQL)
+	Dollar Placeholder = func(n int) string { return "$" + strconv.Itoa(n) }
+)
+
+// SQLStore Store sobre database/sql
+//
+// Usa una tabla con una fila por rango:
+//
+//	CREATE TABLE numbering_consecutives (
+//	    range_key   VARCHAR(100) PRIMARY KEY,
+//	    last_number BIGINT NOT NULL
+//	)
+//
+// Cada asignación es una transacción que incrementa la fila con UPDATE
+// (la base de datos bloquea la fila hasta el COMMIT), así que varios
+// procesos pueden compartir la misma tabla.
+type SQLStore struct {
+	db          *sql.DB
+	table       string
+	placeholder Placeholder
+}
+
+// NewSQLStore crea un Store sobre la tabla (numbering_consecutives si es vacía)
+func NewSQLStore(db *sql.DB, table string, placeholder Placeholder) *SQLStore {
+	if table == "" {
+		table = "numbering_consecutives"
+	}
+	if placeholder == nil {
+		placeholder = QuestionMark
+	}
+	return &SQLStore{db: db, table: table, placeholder: placeholder}
+}
+
+// CreateTable crea la tabla si no existe
+func (s *SQLStore) CreateTable() error {
+	_, err := s.db.Exec(fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (range_key VARCHAR(100) PRIMARY KEY, last_number BIGINT NOT NULL)", s.table))
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", s.table, err)
+	}
+	return nil
+}
+
+// Next implementa Store
+func (s *SQLStore) Next(key string, start int64) (int64, error) {
+	// Reintentar si otro proceso insertó la primera fila del rango a la vez
+	var err error
+	for attempt := 0; attempt < 3; attempt++ {
+		var number int64
+		if number, err = s.next(key, start); err == nil {
+			return number, nil
+		}
+	}
+	return 0, fmt.Errorf("failed to allocate %s: %w", key, err)
+}
+
+// next asigna un número en una transacción
+func (s *SQLStore) next(key string, start int64) (int64, error) {
+	p := s.placeholder
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(fmt.Sprintf("UPDATE %s SET last_number = last_number + 1 WHERE range_key = %s", s.table, p(1)), key)
+	if err != nil {
+		return 0, err
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	number := start
+	if updated == 0 {
+		if _, err := tx.Exec(fmt.Sprintf("INSERT INTO %s (range_key, last_number) VALUES (%s, %s)", s.table, p(1), p(2)), key, start); err != nil {
+			return 0, err
+		}
+	} else {
+		if err := tx.QueryRow(fmt.Sprintf("SELECT last_number FROM %s WHERE range_key = %s", s.table, p(1)), key).Scan(&number); err != nil {
+			return 0, err
+		}
+		if number < start {
+			// Fila de un rango reutilizado con otro From: saltar al inicio
+			if _, err := tx.Exec(fmt.Sprintf("UPDATE %s SET last_number = %s WHERE range_key = %s", s.table, p(1), p(2)), start, key); err != nil {
+				return 0, err
+			}
+			number = start
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return number, nil
+}
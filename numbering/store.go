@@ -0,0 +1,156 @@
+package numbering
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Store guarda el último número asignado por rango
+//
+// Next debe ser atómico: retorna start en la primera llamada para key y
+// luego el último número + 1, sin repetir valores aunque lo llamen varios
+// procesos a la vez.
+type Store interface {
+	Next(key string, start int64) (int64, error)
+}
+
+// next incrementa el número de key en values
+func next(values map[string]int64, key string, start int64) int64 {
+	last, ok := values[key]
+	if !ok || last < start-1 {
+		last = start - 1
+	}
+	values[key] = last + 1
+	return last + 1
+}
+
+// MemoryStore Store en memoria (tests o un único proceso sin persistencia)
+type MemoryStore struct {
+	mu     sync.Mutex
+	values map[string]int64
+}
+
+// NewMemoryStore crea un Store en memoria vacío
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{values: map[string]int64{}}
+}
+
+// Next implementa Store
+func (s *MemoryStore) Next(key string, start int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return next(s.values, key, start), nil
+}
+
+// ErrLocked no se obtuvo el lock del FileStore a tiempo
+var ErrLocked = errors.New("numbering store is locked")
+
+// FileStore Store en un archivo JSON protegido con un archivo de lock
+//
+// El lock es del sistema operativo (flock o LockFileEx) sobre path + ".lock",
+// así que varios procesos sobre el mismo archivo se turnan y el lock de un
+// proceso caído se libera solo. El archivo de lock no se borra.
+type FileStore struct {
+	path    string
+	mu      sync.Mutex
+	Timeout time.Duration // Espera máxima por el lock (10s por defecto)
+}
+
+// NewFileStore crea un Store sobre path (se crea en la primera asignación)
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path, Timeout: 10 * time.Second}
+}
+
+// Next implementa Store
+func (s *FileStore) Next(key string, start int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := s.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	values := map[string]int64{}
+	data, err := os.ReadFile(s.path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return 0, err
+	default:
+		if err := json.Unmarshal(data, &values); err != nil {
+			return 0, fmt.Errorf("failed to parse numbering store %s: %w", s.path, err)
+		}
+	}
+
+	number := next(values, key, start)
+	if err := s.save(values); err != nil {
+		return 0, err
+	}
+	return number, nil
+}
+
+// lock toma el lock exclusivo esperando a que otro proceso lo libere
+func (s *FileStore) lock() (func(), error) {
+	f, err := os.OpenFile(s.path+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock numbering store: %w", err)
+	}
+	deadline := time.Now().Add(s.Timeout)
+
+	for {
+		locked, err := tryLockFile(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock numbering store: %w", err)
+		}
+		if locked {
+			return func() {
+				unlockFile(f)
+				f.Close()
+			}, nil
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("%w: %s", ErrLocked, f.Name())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// save escribe los números de forma atómica (archivo temporal + fsync + rename)
+func (s *FileStore) save(values map[string]int64) error {
+	data, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode numbering store: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save numbering store: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save numbering store: %w", err)
+	}
+	// Sin fsync un corte de energía puede dejar el archivo renombrado vacío
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save numbering store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save numbering store: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to save numbering store: %w", err)
+	}
+	return nil
+}
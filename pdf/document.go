@@ -0,0 +1,413 @@
+package pdf
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/diegofxm/ubl21-dian/soap/rules"
+	xmlpkg "github.com/diegofxm/ubl21-dian/xml"
+)
+
+// nsSTS namespace de las extensiones DIAN
+const nsSTS = "dian:gov:co:facturaelectronica:Structures-2-1"
+
+// Document datos de la representación gráfica
+//
+// Parse lo llena desde el XML (firmado o no); puede completarse antes de
+// Render, por ejemplo con la fecha de validación de DIAN.
+type Document struct {
+	Type        rules.Document
+	TypeCode    string // InvoiceTypeCode, CreditNoteTypeCode o DebitNoteTypeCode
+	Number      string
+	UUID        string // CUFE, CUDE o CUDS
+	UUIDScheme  string // "CUFE-SHA384", ...
+	Environment string // "1" = Producción, "2" = Habilitación
+	IssueDate   string
+	IssueTime   string
+	DueDate     string
+	Currency    string
+	Notes       []string
+
+	Supplier Party // Emisor (adquiriente en el documento soporte)
+	Customer Party // Adquiriente (vendedor en el documento soporte)
+
+	Resolution   *Resolution // Autorización de numeración (nil si no viene)
+	ProviderID   string      // NIT del proveedor tecnológico (SoftwareProvider)
+	SoftwareID   string
+	QRCode       string
+	PaymentMeans []PaymentMeans
+	References   []Reference // Documentos referenciados (notas y ajustes)
+
+	Lines            []Line
+	Taxes            []Tax
+	Withholdings     []Tax
+	AllowanceCharges []AllowanceCharge
+	Totals           Totals
+
+	ValidatedAt string // Fecha y hora de validación de DIAN (opcional)
+}
+
+// Party emisor o adquiriente
+type Party struct {
+	Name          string
+	IDType        string // Código de tipo de documento (31 = NIT)
+	ID            string
+	DV            string
+	TaxLevelCodes string // Responsabilidades fiscales separadas por ";"
+	TaxScheme     string // Tributo (01 = IVA, ZZ = No aplica)
+	Address       string
+	City          string
+	Department    string
+	Country       string
+	Telephone     string
+	Email         string
+}
+
+// Resolution autorización de numeración
+type Resolution struct {
+	Number    string
+	StartDate string
+	EndDate   string
+	Prefix    string
+	From      string
+	To        string
+}
+
+// PaymentMeans forma (ID: 1 = contado, 2 = crédito) y medio de pago
+type PaymentMeans struct {
+	ID      string
+	Code    string
+	DueDate string
+}
+
+// Reference documento referenciado
+type Reference struct {
+	Number    string
+	UUID      string
+	IssueDate string
+}
+
+// Line línea del documento
+type Line struct {
+	ID          string
+	Code        string
+	Description string
+	Quantity    string
+	UnitCode    string
+	UnitPrice   string
+	TaxPercent  string // Porcentaje del primer impuesto de la línea
+	Total       string
+}
+
+// Tax subtotal de un tributo
+type Tax struct {
+	SchemeID string
+	Name     string
+	Percent  string
+	Base     string
+	Amount   string
+}
+
+// AllowanceCharge descuento o cargo global
+type AllowanceCharge struct {
+	Charge bool
+	Reason string
+	Amount string
+}
+
+// Totals totales monetarios
+type Totals struct {
+	LineExtension string
+	TaxExclusive  string
+	TaxInclusive  string
+	Allowance     string
+	Charge        string
+	Prepaid       string
+	Payable       string
+}
+
+// xmlScheme identificador con atributos de esquema
+type xmlScheme struct {
+	Value      string `xml:",chardata"`
+	SchemeID   string `xml:"schemeID,attr"`
+	SchemeName string `xml:"schemeName,attr"`
+}
+
+type xmlAddress struct {
+	Line       string `xml:"cac:AddressLine>cbc:Line"`
+	City       string `xml:"cbc:CityName"`
+	Department string `xml:"cbc:CountrySubentity"`
+	Country    string `xml:"cac:Country>cbc:Name"`
+}
+
+type xmlParty struct {
+	Name             string     `xml:"cac:Party>cac:PartyName>cbc:Name"`
+	Address          xmlAddress `xml:"cac:Party>cac:PhysicalLocation>cac:Address"`
+	RegistrationName string     `xml:"cac:Party>cac:PartyTaxScheme>cbc:RegistrationName"`
+	CompanyID        xmlScheme  `xml:"cac:Party>cac:PartyTaxScheme>cbc:CompanyID"`
+	TaxLevelCode     string     `xml:"cac:Party>cac:PartyTaxScheme>cbc:TaxLevelCode"`
+	TaxScheme        string     `xml:"cac:Party>cac:PartyTaxScheme>cac:TaxScheme>cbc:ID"`
+	LegalName        string     `xml:"cac:Party>cac:PartyLegalEntity>cbc:RegistrationName"`
+	Telephone        string     `xml:"cac:Party>cac:Contact>cbc:Telephone"`
+	Email            string     `xml:"cac:Party>cac:Contact>cbc:ElectronicMail"`
+}
+
+type xmlSubtotal struct {
+	TaxableAmount string `xml:"cbc:TaxableAmount"`
+	TaxAmount     string `xml:"cbc:TaxAmount"`
+	Percent       string `xml:"cac:TaxCategory>cbc:Percent"`
+	SchemeID      string `xml:"cac:TaxCategory>cac:TaxScheme>cbc:ID"`
+	SchemeName    string `xml:"cac:TaxCategory>cac:TaxScheme>cbc:Name"`
+}
+
+type xmlTaxTotal struct {
+	Subtotals []xmlSubtotal `xml:"cac:TaxSubtotal"`
+}
+
+type xmlQuantity struct {
+	Value    string `xml:",chardata"`
+	UnitCode string `xml:"unitCode,attr"`
+}
+
+type xmlLine struct {
+	ID                  string        `xml:"cbc:ID"`
+	InvoicedQuantity    *xmlQuantity  `xml:"cbc:InvoicedQuantity"`
+	CreditedQuantity    *xmlQuantity  `xml:"cbc:CreditedQuantity"`
+	DebitedQuantity     *xmlQuantity  `xml:"cbc:DebitedQuantity"`
+	LineExtensionAmount string        `xml:"cbc:LineExtensionAmount"`
+	TaxTotals           []xmlTaxTotal `xml:"cac:TaxTotal"`
+	Description         string        `xml:"cac:Item>cbc:Description"`
+	StandardID          string        `xml:"cac:Item>cac:StandardItemIdentification>cbc:ID"`
+	SellersID           string        `xml:"cac:Item>cac:SellersItemIdentification>cbc:ID"`
+	PriceAmount         string        `xml:"cac:Price>cbc:PriceAmount"`
+}
+
+type xmlTotals struct {
+	LineExtensionAmount  string `xml:"cbc:LineExtensionAmount"`
+	TaxExclusiveAmount   string `xml:"cbc:TaxExclusiveAmount"`
+	TaxInclusiveAmount   string `xml:"cbc:TaxInclusiveAmount"`
+	AllowanceTotalAmount string `xml:"cbc:AllowanceTotalAmount"`
+	ChargeTotalAmount    string `xml:"cbc:ChargeTotalAmount"`
+	PrepaidAmount        string `xml:"cbc:PrepaidAmount"`
+	PayableAmount        string `xml:"cbc:PayableAmount"`
+}
+
+// xmlDocument campos de factura, notas y documento soporte
+type xmlDocument struct {
+	XMLName            xml.Name
+	ProfileExecutionID string    `xml:"cbc:ProfileExecutionID"`
+	ID                 string    `xml:"cbc:ID"`
+	UUID               xmlScheme `xml:"cbc:UUID"`
+	IssueDate          string    `xml:"cbc:IssueDate"`
+	IssueTime          string    `xml:"cbc:IssueTime"`
+	DueDate            string    `xml:"cbc:DueDate"`
+	InvoiceTypeCode    string    `xml:"cbc:InvoiceTypeCode"`
+	CreditNoteTypeCode string    `xml:"cbc:CreditNoteTypeCode"`
+	DebitNoteTypeCode  string    `xml:"cbc:DebitNoteTypeCode"`
+	Notes              []string  `xml:"cbc:Note"`
+	CurrencyCode       string    `xml:"cbc:DocumentCurrencyCode"`
+	References         []struct {
+		ID        string `xml:"cbc:ID"`
+		UUID      string `xml:"cbc:UUID"`
+		IssueDate string `xml:"cbc:IssueDate"`
+	} `xml:"cac:BillingReference>cac:InvoiceDocumentReference"`
+	Supplier     xmlParty `xml:"cac:AccountingSupplierParty"`
+	Customer     xmlParty `xml:"cac:AccountingCustomerParty"`
+	PaymentMeans []struct {
+		ID      string `xml:"cbc:ID"`
+		Code    string `xml:"cbc:PaymentMeansCode"`
+		DueDate string `xml:"cbc:PaymentDueDate"`
+	} `xml:"cac:PaymentMeans"`
+	AllowanceCharges []struct {
+		ChargeIndicator string `xml:"cbc:ChargeIndicator"`
+		Reason          string `xml:"cbc:AllowanceChargeReason"`
+		Amount          string `xml:"cbc:Amount"`
+	} `xml:"cac:AllowanceCharge"`
+	TaxTotals            []xmlTaxTotal `xml:"cac:TaxTotal"`
+	WithholdingTaxTotals []xmlTaxTotal `xml:"cac:WithholdingTaxTotal"`
+	LegalTotal           *xmlTotals    `xml:"cac:LegalMonetaryTotal"`
+	RequestedTotal       *xmlTotals    `xml:"cac:RequestedMonetaryTotal"`
+	InvoiceLines         []xmlLine     `xml:"cac:InvoiceLine"`
+	CreditNoteLines      []xmlLine     `xml:"cac:CreditNoteLine"`
+	DebitNoteLines       []xmlLine     `xml:"cac:DebitNoteLine"`
+}
+
+// xmlExtensions sts:DianExtensions
+type xmlExtensions struct {
+	Authorization string `xml:"sts:InvoiceControl>sts:InvoiceAuthorization"`
+	StartDate     string `xml:"sts:InvoiceControl>sts:AuthorizationPeriod>cbc:StartDate"`
+	EndDate       string `xml:"sts:InvoiceControl>sts:AuthorizationPeriod>cbc:EndDate"`
+	Prefix        string `xml:"sts:InvoiceControl>sts:AuthorizedInvoices>sts:Prefix"`
+	From          string `xml:"sts:InvoiceControl>sts:AuthorizedInvoices>sts:From"`
+	To            string `xml:"sts:InvoiceControl>sts:AuthorizedInvoices>sts:To"`
+	ProviderID    string `xml:"sts:SoftwareProvider>sts:ProviderID"`
+	SoftwareID    string `xml:"sts:SoftwareProvider>sts:SoftwareID"`
+	QRCode        string `xml:"sts:QRCode"`
+}
+
+// Parse lee la factura, nota o documento soporte (firmado o no)
+func Parse(data []byte) (*Document, error) {
+	var x xmlDocument
+	if err := xmlpkg.Unmarshal(data, &x); err != nil {
+		return nil, fmt.Errorf("failed to parse document: %w", err)
+	}
+
+	doc := &Document{
+		Number:      strings.TrimSpace(x.ID),
+		UUID:        strings.TrimSpace(x.UUID.Value),
+		UUIDScheme:  x.UUID.SchemeName,
+		Environment: x.UUID.SchemeID,
+		IssueDate:   x.IssueDate,
+		IssueTime:   x.IssueTime,
+		DueDate:     x.DueDate,
+		Currency:    strings.TrimSpace(x.CurrencyCode),
+		Supplier:    x.Supplier.party(),
+		Customer:    x.Customer.party(),
+	}
+	if doc.Environment == "" {
+		doc.Environment = x.ProfileExecutionID
+	}
+	for _, note := range x.Notes {
+		if note = strings.TrimSpace(note); note != "" {
+			doc.Notes = append(doc.Notes, note)
+		}
+	}
+
+	lines := x.InvoiceLines
+	switch x.XMLName.Local {
+	case "Invoice":
+		doc.Type, doc.TypeCode = rules.Invoice, x.InvoiceTypeCode
+		if x.InvoiceTypeCode == "05" || strings.HasPrefix(x.UUID.SchemeName, "CUDS") {
+			doc.Type = rules.SupportDocument
+		}
+	case "CreditNote":
+		doc.Type, doc.TypeCode, lines = rules.CreditNote, x.CreditNoteTypeCode, x.CreditNoteLines
+	case "DebitNote":
+		doc.Type, doc.TypeCode, lines = rules.DebitNote, x.DebitNoteTypeCode, x.DebitNoteLines
+	default:
+		return nil, fmt.Errorf("%w: root element %q", ErrUnsupported, x.XMLName.Local)
+	}
+
+	for _, r := range x.References {
+		doc.References = append(doc.References, Reference{Number: r.ID, UUID: strings.TrimSpace(r.UUID), IssueDate: r.IssueDate})
+	}
+	for _, p := range x.PaymentMeans {
+		doc.PaymentMeans = append(doc.PaymentMeans, PaymentMeans{ID: p.ID, Code: p.Code, DueDate: p.DueDate})
+	}
+	for _, a := range x.AllowanceCharges {
+		doc.AllowanceCharges = append(doc.AllowanceCharges, AllowanceCharge{Charge: a.ChargeIndicator == "true", Reason: a.Reason, Amount: a.Amount})
+	}
+	doc.Taxes = taxes(x.TaxTotals)
+	doc.Withholdings = taxes(x.WithholdingTaxTotals)
+	for _, l := range lines {
+		doc.Lines = append(doc.Lines, l.line())
+	}
+
+	totals := x.LegalTotal
+	if x.RequestedTotal != nil {
+		totals = x.RequestedTotal
+	}
+	if totals != nil {
+		doc.Totals = Totals{
+			LineExtension: totals.LineExtensionAmount,
+			TaxExclusive:  totals.TaxExclusiveAmount,
+			TaxInclusive:  totals.TaxInclusiveAmount,
+			Allowance:     totals.AllowanceTotalAmount,
+			Charge:        totals.ChargeTotalAmount,
+			Prepaid:       totals.PrepaidAmount,
+			Payable:       totals.PayableAmount,
+		}
+	}
+
+	element, err := xmlpkg.ExtractElement(data, xmlpkg.ByName(nsSTS, "DianExtensions"))
+	switch {
+	case errors.Is(err, xmlpkg.ErrElementNotFound):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read DianExtensions: %w", err)
+	default:
+		var ext xmlExtensions
+		if err := xmlpkg.Unmarshal(element, &ext); err != nil {
+			return nil, fmt.Errorf("failed to parse DianExtensions: %w", err)
+		}
+		if ext.Authorization != "" {
+			doc.Resolution = &Resolution{
+				Number:    strings.TrimSpace(ext.Authorization),
+				StartDate: ext.StartDate,
+				EndDate:   ext.EndDate,
+				Prefix:    strings.TrimSpace(ext.Prefix),
+				From:      strings.TrimSpace(ext.From),
+				To:        strings.TrimSpace(ext.To),
+			}
+		}
+		doc.ProviderID = strings.TrimSpace(ext.ProviderID)
+		doc.SoftwareID = strings.TrimSpace(ext.SoftwareID)
+		doc.QRCode = strings.TrimSpace(ext.QRCode)
+	}
+	return doc, nil
+}
+
+func (p xmlParty) party() Party {
+	name := strings.TrimSpace(p.RegistrationName)
+	if name == "" {
+		name = strings.TrimSpace(p.LegalName)
+	}
+	if name == "" {
+		name = strings.TrimSpace(p.Name)
+	}
+	return Party{
+		Name:          name,
+		IDType:        p.CompanyID.SchemeName,
+		ID:            strings.TrimSpace(p.CompanyID.Value),
+		DV:            p.CompanyID.SchemeID,
+		TaxLevelCodes: strings.TrimSpace(p.TaxLevelCode),
+		TaxScheme:     strings.TrimSpace(p.TaxScheme),
+		Address:       strings.TrimSpace(p.Address.Line),
+		City:          strings.TrimSpace(p.Address.City),
+		Department:    strings.TrimSpace(p.Address.Department),
+		Country:       strings.TrimSpace(p.Address.Country),
+		Telephone:     strings.TrimSpace(p.Telephone),
+		Email:         strings.TrimSpace(p.Email),
+	}
+}
+
+func (l xmlLine) line() Line {
+	line := Line{
+		ID:          l.ID,
+		Code:        strings.TrimSpace(l.StandardID),
+		Description: strings.TrimSpace(l.Description),
+		UnitPrice:   l.PriceAmount,
+		Total:       l.LineExtensionAmount,
+	}
+	if line.Code == "" {
+		line.Code = strings.TrimSpace(l.SellersID)
+	}
+	for _, q := range []*xmlQuantity{l.InvoicedQuantity, l.CreditedQuantity, l.DebitedQuantity} {
+		if q != nil {
+			line.Quantity, line.UnitCode = q.Value, q.UnitCode
+			break
+		}
+	}
+	if taxes := taxes(l.TaxTotals); len(taxes) > 0 {
+		line.TaxPercent = taxes[0].Percent
+	}
+	return line
+}
+
+// taxes subtotales de los totales de impuestos
+func taxes(totals []xmlTaxTotal) []Tax {
+	var list []Tax
+	for _, total := range totals {
+		for _, s := range total.Subtotals {
+			list = append(list, Tax{
+				SchemeID: s.SchemeID,
+				Name:     strings.TrimSpace(s.SchemeName),
+				Percent:  s.Percent,
+				Base:     s.TaxableAmount,
+				Amount:   s.TaxAmount,
+			})
+		}
+	}
+	return list
+}
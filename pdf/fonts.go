@@ -0,0 +1,166 @@
+package pdf
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// font fuente estándar de PDF (no se embebe) con codificación WinAnsi
+type font struct {
+	name     string // Recurso en las páginas (/F1)
+	base     string // BaseFont
+	widths   [256]int
+	fallback int // Ancho de caracteres sin métrica
+}
+
+// Métricas de Helvetica y Helvetica-Bold (AFM de Adobe) en milésimas de em,
+// desde el espacio (32) hasta la tilde (126) y de 160 a 255 (Latin-1)
+var (
+	helveticaASCII = [95]int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+	helveticaLatin1 = [96]int{
+		278, 333, 556, 556, 556, 556, 260, 556, 333, 737, 370, 556, 584, 333, 737, 333,
+		400, 584, 333, 333, 333, 556, 537, 278, 333, 333, 365, 556, 834, 834, 834, 611,
+		667, 667, 667, 667, 667, 667, 1000, 722, 667, 667, 667, 667, 278, 278, 278, 278,
+		722, 722, 778, 778, 778, 778, 778, 584, 778, 722, 722, 722, 722, 667, 667, 611,
+		556, 556, 556, 556, 556, 556, 889, 500, 556, 556, 556, 556, 278, 278, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 584, 611, 556, 556, 556, 556, 500, 556, 500,
+	}
+	boldASCII = [95]int{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}
+	boldLatin1 = [96]int{
+		278, 333, 556, 556, 556, 556, 280, 556, 333, 737, 370, 556, 584, 333, 737, 333,
+		400, 584, 333, 333, 333, 611, 556, 278, 333, 333, 365, 556, 834, 834, 834, 611,
+		722, 722, 722, 722, 722, 722, 1000, 722, 667, 667, 667, 667, 278, 278, 278, 278,
+		722, 722, 778, 778, 778, 778, 778, 584, 778, 722, 722, 722, 722, 667, 667, 611,
+		556, 556, 556, 556, 556, 556, 889, 556, 556, 556, 556, 556, 278, 278, 278, 278,
+		611, 611, 611, 611, 611, 611, 611, 584, 611, 611, 611, 611, 611, 556, 611, 556,
+	}
+)
+
+// winAnsiExtra caracteres de WinAnsi entre 128 y 159 con sus anchos
+// (regular, negrita)
+var winAnsiExtra = map[rune]struct {
+	code          byte
+	regular, bold int
+}{
+	'€': {0x80, 556, 556},
+	'…': {0x85, 1000, 1000},
+	'‘': {0x91, 222, 278},
+	'’': {0x92, 222, 278},
+	'“': {0x93, 333, 500},
+	'”': {0x94, 333, 500},
+	'•': {0x95, 350, 350},
+	'–': {0x96, 556, 556},
+	'—': {0x97, 1000, 1000},
+	'™': {0x99, 1000, 1000},
+}
+
+var (
+	regular = newFont("F1", "Helvetica", helveticaASCII, helveticaLatin1, false)
+	bold    = newFont("F2", "Helvetica-Bold", boldASCII, boldLatin1, true)
+)
+
+func newFont(name, base string, ascii [95]int, latin1 [96]int, isBold bool) *font {
+	f := &font{name: name, base: base, fallback: 556}
+	copy(f.widths[32:], ascii[:])
+	copy(f.widths[160:], latin1[:])
+	for _, extra := range winAnsiExtra {
+		if isBold {
+			f.widths[extra.code] = extra.bold
+		} else {
+			f.widths[extra.code] = extra.regular
+		}
+	}
+	return f
+}
+
+// encode convierte UTF-8 a WinAnsi; los caracteres sin equivalente quedan "?"
+func encode(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r == '\t' || r == '\n' || r == '\r':
+			out = append(out, ' ')
+		case r >= 32 && r <= 126, r >= 160 && r <= 255:
+			out = append(out, byte(r))
+		default:
+			if extra, ok := winAnsiExtra[r]; ok {
+				out = append(out, extra.code)
+			} else {
+				out = append(out, '?')
+			}
+		}
+	}
+	return out
+}
+
+// width ancho del texto en puntos
+func (f *font) width(s string, size float64) float64 {
+	total := 0
+	for _, b := range encode(s) {
+		if w := f.widths[b]; w > 0 {
+			total += w
+		} else {
+			total += f.fallback
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// wrap parte el texto en líneas que caben en el ancho; las palabras más
+// largas que el ancho se cortan
+func (f *font) wrap(s string, size, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if f.width(candidate, size) <= width {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			for f.width(word, size) > width {
+				cut := f.fit(word, size, width)
+				lines = append(lines, word[:cut])
+				word = word[cut:]
+			}
+			line = word
+		}
+		if line != "" || len(lines) == 0 {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// fit bytes del prefijo más largo de s que cabe en el ancho (al menos una runa)
+func (f *font) fit(s string, size, width float64) int {
+	cut := 0
+	for i, r := range s {
+		next := i + utf8.RuneLen(r)
+		if cut > 0 && f.width(s[:next], size) > width {
+			break
+		}
+		cut = next
+	}
+	return cut
+}
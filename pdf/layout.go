@@ -0,0 +1,733 @@
+package pdf
+
+import (
+	"image/color"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/diegofxm/ubl21-dian/catalogs"
+	"github.com/diegofxm/ubl21-dian/identification"
+	"github.com/diegofxm/ubl21-dian/qr"
+	"github.com/diegofxm/ubl21-dian/soap/rules"
+)
+
+const (
+	margin       = 36.0 // Márgenes de la página
+	footerHeight = 44.0 // Reservado al pie de cada página
+	gap          = 8.0  // Separación entre secciones
+	qrSize       = 92.0
+)
+
+var (
+	black     = color.RGBA{A: 0xFF}
+	white     = color.RGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+	gray      = color.RGBA{R: 0x55, G: 0x55, B: 0x55, A: 0xFF}
+	lightGray = color.RGBA{R: 0xD0, G: 0xD0, B: 0xD0, A: 0xFF}
+)
+
+// logo imagen embebida y su tamaño en píxeles
+type logo struct {
+	id            int
+	width, height float64
+}
+
+// layout ubica las secciones en páginas; y avanza de arriba hacia abajo
+type layout struct {
+	doc  *Document
+	opts Options
+	file *file
+	logo *logo
+	qr   *qr.Code
+
+	pages []*page
+	page  *page
+	y     float64
+}
+
+func (l *layout) width() float64 {
+	return l.opts.PageSize.Width - 2*margin
+}
+
+func (l *layout) bottom() float64 {
+	return l.opts.PageSize.Height - margin - footerHeight
+}
+
+func (l *layout) render() {
+	l.newPage()
+	l.header()
+	l.details()
+	l.customer()
+	l.references()
+	l.lines()
+	l.summary()
+}
+
+// newPage agrega una página; desde la segunda repite título y número
+func (l *layout) newPage() {
+	l.page = newPage(l.opts.PageSize.Width, l.opts.PageSize.Height)
+	l.pages = append(l.pages, l.page)
+	l.y = margin
+	if len(l.pages) == 1 {
+		return
+	}
+	l.page.fill(l.opts.Color)
+	l.page.text(margin, l.y+9, bold, 9, strings.ToUpper(l.doc.title())+" No. "+l.doc.Number)
+	l.page.stroke(l.opts.Color, 0.8)
+	l.page.line(margin, l.y+14, margin+l.width(), l.y+14)
+	l.y += 14 + gap
+}
+
+// ensure pasa a una página nueva si h no cabe; retorna true si lo hizo
+func (l *layout) ensure(h float64) bool {
+	if l.y+h <= l.bottom() || l.y <= margin+14+gap {
+		return false
+	}
+	l.newPage()
+	return true
+}
+
+// header logo, datos del emisor y recuadro con título y número
+func (l *layout) header() {
+	p := l.page
+	top := l.y
+	bottom := top
+
+	x := margin
+	if l.logo != nil {
+		w, h := fit(l.logo.width, l.logo.height, 110, 64)
+		p.image("Im1", l.logo.id, x, top, w, h)
+		x += 120
+		bottom = top + h
+	}
+
+	const boxWidth = 190.0
+	boxX := margin + l.width() - boxWidth
+	textWidth := boxX - x - 10
+
+	s := l.doc.Supplier
+	y := top + 10
+	p.fill(black)
+	for _, line := range bold.wrap(s.Name, 11, textWidth) {
+		p.text(x, y, bold, 11, line)
+		y += 13
+	}
+	for _, info := range []string{partyID(s), responsibilities(s), s.Address, place(s), contact(s)} {
+		for _, line := range regular.wrap(info, 8, textWidth) {
+			if line != "" {
+				p.text(x, y, regular, 8, line)
+				y += 10
+			}
+		}
+	}
+	bottom = math.Max(bottom, y)
+
+	titleLines := bold.wrap(strings.ToUpper(l.doc.title()), 9, boxWidth-12)
+	titleHeight := float64(len(titleLines))*11 + 6
+	boxHeight := titleHeight + 24
+	if l.doc.Environment == "2" {
+		boxHeight += 10
+	}
+	p.fill(l.opts.Color)
+	p.rect(boxX, top, boxWidth, titleHeight, true, false)
+	p.fill(white)
+	for i, line := range titleLines {
+		p.textCenter(boxX+boxWidth/2, top+12+float64(i)*11, bold, 9, line)
+	}
+	p.stroke(l.opts.Color, 0.8)
+	p.rect(boxX, top, boxWidth, boxHeight, false, true)
+	p.fill(black)
+	p.textCenter(boxX+boxWidth/2, top+titleHeight+16, bold, 12, "No. "+l.doc.Number)
+	if l.doc.Environment == "2" {
+		p.fill(gray)
+		p.textCenter(boxX+boxWidth/2, top+titleHeight+28, regular, 7, "Ambiente de habilitación - sin validez fiscal")
+	}
+
+	l.y = math.Max(bottom, top+boxHeight) + gap
+}
+
+// details fechas, forma y medio de pago
+func (l *layout) details() {
+	d := l.doc
+	dueDate := d.DueDate
+	var method, means []string
+	for _, pm := range d.PaymentMeans {
+		method = appendUnique(method, nameOf(catalogs.PaymentMethods, pm.ID))
+		means = appendUnique(means, nameOf(catalogs.PaymentMeans, pm.Code))
+		if dueDate == "" {
+			dueDate = pm.DueDate
+		}
+	}
+	l.grid("", [][2]string{
+		{"Fecha de emisión", d.IssueDate},
+		{"Hora de emisión", d.IssueTime},
+		{"Fecha de vencimiento", dueDate},
+		{"Moneda", d.Currency},
+		{"Forma de pago", strings.Join(method, ", ")},
+		{"Medio de pago", strings.Join(means, ", ")},
+	}, 4)
+}
+
+// customer datos del adquiriente (del vendedor en el documento soporte)
+func (l *layout) customer() {
+	title := "ADQUIRIENTE"
+	if l.doc.Type == rules.SupportDocument {
+		title = "VENDEDOR O PRESTADOR DEL SERVICIO"
+	}
+	c := l.doc.Customer
+	l.grid(title, [][2]string{
+		{"Nombre o razón social", c.Name},
+		{"Identificación", partyID(c)},
+		{"Responsabilidades", responsibilities(c)},
+		{"Dirección", c.Address},
+		{"Ciudad", place(c)},
+		{"Teléfono", c.Telephone},
+		{"Correo electrónico", c.Email},
+	}, 3)
+}
+
+// references documentos referenciados por notas y documentos de ajuste
+func (l *layout) references() {
+	for _, r := range l.doc.References {
+		label := "CUFE"
+		if l.doc.Type == rules.SupportDocument {
+			label = "CUDS"
+		}
+		l.grid("DOCUMENTO REFERENCIADO", [][2]string{
+			{"Número", r.Number},
+			{"Fecha de emisión", r.IssueDate},
+			{label, r.UUID},
+		}, 3)
+	}
+}
+
+// grid sección con título opcional y celdas etiqueta/valor; omite valores vacíos
+func (l *layout) grid(title string, fields [][2]string, columns int) {
+	var cells [][2]string
+	for _, f := range fields {
+		if strings.TrimSpace(f[1]) != "" {
+			cells = append(cells, f)
+		}
+	}
+	if len(cells) == 0 {
+		return
+	}
+
+	cellWidth := l.width() / float64(columns)
+	var rows [][][]string
+	var heights []float64
+	for i := 0; i < len(cells); i += columns {
+		var row [][]string
+		height := 0.0
+		for _, cell := range cells[i:min(i+columns, len(cells))] {
+			lines := regular.wrap(cell[1], 8, cellWidth-8)
+			row = append(row, lines)
+			height = math.Max(height, float64(len(lines))*9.5+13)
+		}
+		rows = append(rows, row)
+		heights = append(heights, height)
+	}
+
+	total := 0.0
+	for _, h := range heights {
+		total += h
+	}
+	if title != "" {
+		total += 13
+	}
+	l.ensure(total)
+
+	p := l.page
+	top := l.y
+	if title != "" {
+		p.fill(l.opts.Color)
+		p.rect(margin, l.y, l.width(), 13, true, false)
+		p.fill(white)
+		p.text(margin+4, l.y+9.5, bold, 7.5, title)
+		l.y += 13
+	}
+	p.stroke(lightGray, 0.5)
+	for r, row := range rows {
+		for c, lines := range row {
+			x := margin + float64(c)*cellWidth
+			label := cells[r*columns+c][0]
+			p.fill(l.opts.Color)
+			p.text(x+4, l.y+8, bold, 6.5, label)
+			p.fill(black)
+			for i, line := range lines {
+				p.text(x+4, l.y+17.5+float64(i)*9.5, regular, 8, line)
+			}
+			if c > 0 {
+				p.line(x, l.y, x, l.y+heights[r])
+			}
+		}
+		if r > 0 {
+			p.line(margin, l.y, margin+l.width(), l.y)
+		}
+		l.y += heights[r]
+	}
+	p.stroke(l.opts.Color, 0.8)
+	p.rect(margin, top, l.width(), l.y-top, false, true)
+	l.y += gap
+}
+
+// column columna de la tabla de líneas
+type column struct {
+	title string
+	width float64 // 0 = el ancho restante
+	right bool    // Alineada a la derecha
+}
+
+var columns = []column{
+	{"#", 18, false},
+	{"Código", 58, false},
+	{"Descripción", 0, false},
+	{"Cantidad", 44, true},
+	{"Unidad", 50, false},
+	{"Valor unitario", 68, true},
+	{"% Imp.", 34, true},
+	{"Total", 72, true},
+}
+
+// lines tabla de líneas; repite el encabezado en cada página
+func (l *layout) lines() {
+	widths := make([]float64, len(columns))
+	fixed := 0.0
+	for i, c := range columns {
+		widths[i] = c.width
+		fixed += c.width
+	}
+	for i, c := range columns {
+		if c.width == 0 {
+			widths[i] = l.width() - fixed
+		}
+	}
+
+	header := func() {
+		p := l.page
+		p.fill(l.opts.Color)
+		p.rect(margin, l.y, l.width(), 14, true, false)
+		p.fill(white)
+		x := margin
+		for i, c := range columns {
+			if c.right {
+				p.textRight(x+widths[i]-3, l.y+9.5, bold, 7, c.title)
+			} else {
+				p.text(x+3, l.y+9.5, bold, 7, c.title)
+			}
+			x += widths[i]
+		}
+		l.y += 14
+	}
+
+	l.ensure(14 + 20)
+	header()
+	for _, line := range l.doc.Lines {
+		values := []string{
+			line.ID,
+			line.Code,
+			line.Description,
+			quantity(line.Quantity),
+			nameOf(catalogs.UnitCodes, line.UnitCode),
+			money(line.UnitPrice, ""),
+			percent(line.TaxPercent),
+			money(line.Total, ""),
+		}
+		cells := make([][]string, len(values))
+		height := 0.0
+		for i, v := range values {
+			cells[i] = regular.wrap(v, 7.5, widths[i]-6)
+			height = math.Max(height, float64(len(cells[i]))*9+6)
+		}
+		if l.ensure(height) {
+			header()
+		}
+
+		p := l.page
+		p.fill(black)
+		x := margin
+		for i, lines := range cells {
+			for j, text := range lines {
+				y := l.y + 9.5 + float64(j)*9
+				if columns[i].right {
+					p.textRight(x+widths[i]-3, y, regular, 7.5, text)
+				} else {
+					p.text(x+3, y, regular, 7.5, text)
+				}
+			}
+			x += widths[i]
+		}
+		l.y += height
+		p.stroke(lightGray, 0.5)
+		p.line(margin, l.y, margin+l.width(), l.y)
+	}
+	l.y += gap
+}
+
+// total fila de la tabla de totales
+type total struct {
+	label, value string
+	strong       bool
+}
+
+// summary notas, resolución, QR con el CUFE y totales
+func (l *layout) summary() {
+	d := l.doc
+	const totalsWidth = 200.0
+	leftWidth := l.width() - totalsWidth - 16
+
+	var notes []string
+	for _, note := range d.Notes {
+		notes = append(notes, regular.wrap(note, 7.5, leftWidth)...)
+	}
+	resolution := regular.wrap(resolutionText(d.Resolution), 7.5, leftWidth)
+	if d.Resolution == nil {
+		resolution = nil
+	}
+	uuidWidth := leftWidth - qrSize - 8
+	uuid := regular.wrap(d.UUID, 7, uuidWidth)
+	validated := regular.wrap("Fecha de validación DIAN: "+d.ValidatedAt, 7, uuidWidth)
+	if d.ValidatedAt == "" {
+		validated = nil
+	}
+
+	leftHeight := 0.0
+	if len(notes) > 0 {
+		leftHeight += 11 + float64(len(notes))*9 + 4
+	}
+	if len(resolution) > 0 {
+		leftHeight += float64(len(resolution))*9 + 4
+	}
+	leftHeight += math.Max(qrSize, 11+float64(len(uuid)+len(validated))*9)
+
+	totals := l.totals()
+	withholdings := l.withholdings()
+	rightHeight := float64(len(totals))*13 + 4
+	if len(withholdings) > 0 {
+		rightHeight += 15 + float64(len(withholdings))*11
+	}
+	l.ensure(math.Max(leftHeight, rightHeight))
+
+	p := l.page
+	top := l.y
+
+	// Columna izquierda
+	y := top
+	if len(notes) > 0 {
+		p.fill(l.opts.Color)
+		p.text(margin, y+8, bold, 7.5, "NOTAS")
+		y += 11
+		p.fill(black)
+		for _, line := range notes {
+			p.text(margin, y+7, regular, 7.5, line)
+			y += 9
+		}
+		y += 4
+	}
+	p.fill(gray)
+	for _, line := range resolution {
+		p.text(margin, y+7, regular, 7.5, line)
+		y += 9
+	}
+	if len(resolution) > 0 {
+		y += 4
+	}
+	if l.qr != nil {
+		p.fill(black)
+		p.qr(margin, y, qrSize, l.qr.Size, l.qr.Dark)
+	}
+	x := margin + qrSize + 8
+	p.fill(l.opts.Color)
+	p.text(x, y+8, bold, 7.5, d.uuidLabel())
+	p.fill(black)
+	ty := y + 11
+	for _, line := range append(uuid, validated...) {
+		p.text(x, ty+7, regular, 7, line)
+		ty += 9
+	}
+
+	// Totales
+	tx := margin + l.width() - totalsWidth
+	ry := top
+	for _, t := range totals {
+		f := regular
+		if t.strong {
+			f = bold
+			p.fill(l.opts.Color)
+			p.rect(tx, ry, totalsWidth, 13, true, false)
+			p.fill(white)
+		} else {
+			p.fill(black)
+		}
+		p.text(tx+4, ry+9.5, f, 8, t.label)
+		p.textRight(tx+totalsWidth-4, ry+9.5, f, 8, t.value)
+		ry += 13
+	}
+	p.stroke(l.opts.Color, 0.8)
+	p.rect(tx, top, totalsWidth, ry-top, false, true)
+	if len(withholdings) > 0 {
+		ry += 4
+		p.fill(l.opts.Color)
+		p.text(tx+4, ry+8, bold, 7, "RETENCIONES (INFORMATIVAS)")
+		ry += 11
+		p.fill(black)
+		for _, t := range withholdings {
+			p.text(tx+4, ry+8, regular, 7.5, t.label)
+			p.textRight(tx+totalsWidth-4, ry+8, regular, 7.5, t.value)
+			ry += 11
+		}
+	}
+
+	l.y = top + math.Max(leftHeight, ry-top) + gap
+}
+
+// totals filas de totales con los impuestos por tarifa
+func (l *layout) totals() []total {
+	d := l.doc
+	t := d.Totals
+	list := []total{{label: "Subtotal", value: money(t.LineExtension, d.Currency)}}
+	if !isZero(t.Allowance) {
+		list = append(list, total{label: "Descuentos", value: money(t.Allowance, d.Currency)})
+	}
+	if !isZero(t.Charge) {
+		list = append(list, total{label: "Cargos", value: money(t.Charge, d.Currency)})
+	}
+	for _, tax := range d.Taxes {
+		list = append(list, total{label: taxLabel(tax), value: money(tax.Amount, d.Currency)})
+	}
+	if t.TaxInclusive != "" {
+		list = append(list, total{label: "Total con impuestos", value: money(t.TaxInclusive, d.Currency)})
+	}
+	if !isZero(t.Prepaid) {
+		list = append(list, total{label: "Anticipos", value: money(t.Prepaid, d.Currency)})
+	}
+	return append(list, total{label: "Total a pagar", value: money(t.Payable, d.Currency), strong: true})
+}
+
+func (l *layout) withholdings() []total {
+	var list []total
+	for _, tax := range l.doc.Withholdings {
+		list = append(list, total{label: taxLabel(tax), value: money(tax.Amount, l.doc.Currency)})
+	}
+	return list
+}
+
+// footer leyenda del proveedor tecnológico, pie configurable y paginación
+func (l *layout) footer(p *page, number, count int) {
+	d := l.doc
+	y := l.opts.PageSize.Height - margin - footerHeight + 8
+	width := l.width() - 70
+
+	p.stroke(lightGray, 0.5)
+	p.line(margin, y, margin+l.width(), y)
+
+	legend := "Representación gráfica de " + strings.ToLower(d.title()) + " " + d.Number
+	switch {
+	case l.opts.Provider != "" && d.ProviderID != "":
+		legend += ". Proveedor tecnológico: " + l.opts.Provider + " NIT " + d.ProviderID
+	case l.opts.Provider != "":
+		legend += ". Proveedor tecnológico: " + l.opts.Provider
+	case d.ProviderID != "" && d.ProviderID == d.Supplier.ID:
+		legend += ". Software propio del emisor"
+	case d.ProviderID != "":
+		legend += ". Proveedor tecnológico NIT " + d.ProviderID
+	}
+	lines := regular.wrap(legend, 6.5, width)
+	if l.opts.Footer != "" {
+		lines = append(lines, regular.wrap(l.opts.Footer, 6.5, width)...)
+	}
+
+	p.fill(gray)
+	for i, line := range lines[:min(len(lines), 4)] {
+		p.text(margin, y+9+float64(i)*8, regular, 6.5, line)
+	}
+	p.textRight(margin+l.width(), y+9, regular, 6.5, "Página "+strconv.Itoa(number)+" de "+strconv.Itoa(count))
+}
+
+// resolutionText leyenda de la autorización de numeración
+func resolutionText(r *Resolution) string {
+	if r == nil {
+		return ""
+	}
+	text := "Autorización de numeración de facturación DIAN No. " + r.Number
+	if r.StartDate != "" {
+		text += " del " + r.StartDate
+	}
+	if r.EndDate != "" {
+		text += ", vigente hasta " + r.EndDate
+	}
+	if r.From != "" || r.To != "" {
+		text += ", rango " + r.Prefix + r.From + " al " + r.Prefix + r.To
+	}
+	return text + "."
+}
+
+// partyID tipo y número de identificación ("NIT 900.123.456-8")
+func partyID(p Party) string {
+	if p.ID == "" {
+		return ""
+	}
+	if p.IDType == string(identification.NIT) && p.DV != "" {
+		return "NIT " + identification.ID{Type: identification.NIT, Number: p.ID, DV: p.DV}.Format()
+	}
+	name := catalogs.IdentificationTypes.NameOf(p.IDType)
+	if name == "" {
+		name = "Identificación"
+	}
+	return name + " " + p.ID
+}
+
+// responsibilities responsabilidades fiscales y tributo por nombre
+func responsibilities(p Party) string {
+	var names []string
+	for _, code := range strings.Split(p.TaxLevelCodes, ";") {
+		if code = strings.TrimSpace(code); code != "" {
+			names = append(names, nameOf(catalogs.FiscalResponsibilities, code))
+		}
+	}
+	switch p.TaxScheme {
+	case "", "ZZ":
+	case "01":
+		names = append(names, "Responsable de IVA")
+	default:
+		names = append(names, "Responsable de "+nameOf(catalogs.TaxSchemes, p.TaxScheme))
+	}
+	return strings.Join(names, ", ")
+}
+
+// place ciudad, departamento y país sin repetir
+func place(p Party) string {
+	var parts []string
+	for _, part := range []string{p.City, p.Department, p.Country} {
+		parts = appendUnique(parts, part)
+	}
+	return strings.Join(parts, " - ")
+}
+
+func contact(p Party) string {
+	var parts []string
+	if p.Telephone != "" {
+		parts = append(parts, "Tel. "+p.Telephone)
+	}
+	if p.Email != "" {
+		parts = append(parts, p.Email)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// taxLabel nombre y tarifa de un impuesto ("IVA 19%")
+func taxLabel(t Tax) string {
+	name := t.Name
+	if name == "" {
+		name = nameOf(catalogs.TaxSchemes, t.SchemeID)
+	}
+	if p := percent(t.Percent); p != "" {
+		name += " " + p
+	}
+	return name
+}
+
+// nameOf nombre del código en la lista, o el código si no está
+func nameOf(c *catalogs.Catalog, code string) string {
+	if name := c.NameOf(code); name != "" {
+		return name
+	}
+	return code
+}
+
+func appendUnique(list []string, value string) []string {
+	if value == "" {
+		return list
+	}
+	for _, v := range list {
+		if strings.EqualFold(v, value) {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+// money formato colombiano: "$ 1.234.567,89" (pesos) o "USD 1.234,50"
+func money(value, currency string) string {
+	v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return strings.TrimSpace(value)
+	}
+	text := decimal(strconv.FormatFloat(math.Abs(v), 'f', 2, 64))
+	if v < 0 {
+		text = "-" + text
+	}
+	switch currency {
+	case "":
+		return text
+	case "COP":
+		return "$ " + text
+	}
+	return currency + " " + text
+}
+
+// quantity cantidad sin ceros decimales de más ("2.500000" -> "2,5")
+func quantity(value string) string {
+	v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return strings.TrimSpace(value)
+	}
+	return decimal(strconv.FormatFloat(v, 'f', -1, 64))
+}
+
+// percent tarifa ("19.00" -> "19%")
+func percent(value string) string {
+	if strings.TrimSpace(value) == "" {
+		return ""
+	}
+	return quantity(value) + "%"
+}
+
+func isZero(value string) bool {
+	v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	return err != nil || v == 0
+}
+
+// parseAmount valor numérico de un monto; cero si no es válido
+func parseAmount(value string) float64 {
+	v, _ := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	return v
+}
+
+// decimal separa miles con punto y decimales con coma
+func decimal(number string) string {
+	integer, fraction, _ := strings.Cut(number, ".")
+	negative := strings.HasPrefix(integer, "-")
+	integer = strings.TrimPrefix(integer, "-")
+
+	var b strings.Builder
+	if negative {
+		b.WriteByte('-')
+	}
+	for i, c := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(c)
+	}
+	if fraction != "" {
+		b.WriteString("," + fraction)
+	}
+	return b.String()
+}
+
+// fit escala (w, h) para caber en (maxW, maxH) conservando la proporción
+func fit(w, h, maxW, maxH float64) (float64, float64) {
+	scale := math.Min(maxW/w, maxH/h)
+	return w * scale, h * scale
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
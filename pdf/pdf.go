@@ -0,0 +1,265 @@
+// Package pdf genera la representación gráfica (PDF) de los documentos
+//
+// Produce un PDF 1.4 en Go puro con las fuentes estándar (Helvetica), el QR
+// y los campos exigidos por DIAN: CUFE/CUDE/CUDS, emisor y adquiriente,
+// resolución de numeración, líneas, impuestos, totales y la leyenda del
+// proveedor tecnológico. La salida es determinística (sin fechas de
+// generación) para comparar contra archivos de referencia:
+//
+//	doc, err := pdf.Parse(signedXML)
+//	data, err := pdf.Render(doc, pdf.Options{Logo: logo, Provider: "Mi Software SAS"})
+//
+//	// En el pipeline de emisión
+//	pipeline.Config{Renderer: &pdf.Renderer{Options: opts}}
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"strings"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/documents/creditnote"
+	"github.com/diegofxm/ubl21-dian/documents/debitnote"
+	"github.com/diegofxm/ubl21-dian/documents/invoice"
+	"github.com/diegofxm/ubl21-dian/documents/supportdocument"
+	"github.com/diegofxm/ubl21-dian/pipeline"
+	"github.com/diegofxm/ubl21-dian/qr"
+	"github.com/diegofxm/ubl21-dian/soap/rules"
+)
+
+var (
+	// ErrUnsupported el XML no es factura, nota ni documento soporte
+	ErrUnsupported = errors.New("unsupported document")
+	// ErrInvalidLogo el logo no es una imagen PNG o JPEG válida
+	ErrInvalidLogo = errors.New("invalid logo image")
+)
+
+// PageSize tamaño de página en puntos
+type PageSize struct {
+	Width, Height float64
+}
+
+var (
+	Letter = PageSize{612, 792}
+	A4     = PageSize{595.28, 841.89}
+)
+
+// DefaultColor color de encabezados y bordes por defecto
+var DefaultColor = color.RGBA{R: 0x1F, G: 0x3A, B: 0x68, A: 0xFF}
+
+// Options diseño del PDF
+type Options struct {
+	Logo     []byte     // PNG o JPEG (opcional)
+	Color    color.RGBA // Encabezados y bordes (DefaultColor si es cero)
+	Footer   string     // Texto adicional al pie de cada página
+	Provider string     // Nombre del proveedor tecnológico para la leyenda
+	PageSize PageSize   // Letter si es cero
+}
+
+func (o Options) withDefaults() Options {
+	if o.Color == (color.RGBA{}) {
+		o.Color = DefaultColor
+	}
+	if o.PageSize.Width <= 0 || o.PageSize.Height <= 0 {
+		o.PageSize = Letter
+	}
+	return o
+}
+
+// Render genera el PDF del documento
+func Render(doc *Document, opts Options) ([]byte, error) {
+	opts = opts.withDefaults()
+
+	f := &file{}
+	catalog := f.reserve()
+	pages := f.reserve()
+	fonts := fmt.Sprintf("<< /%s %d 0 R /%s %d 0 R >>", regular.name, f.add(fontObject(regular)), bold.name, f.add(fontObject(bold)))
+
+	l := &layout{doc: doc, opts: opts, file: f}
+	if len(opts.Logo) > 0 {
+		id, w, h, err := f.embedImage(opts.Logo)
+		if err != nil {
+			return nil, err
+		}
+		l.logo = &logo{id: id, width: float64(w), height: float64(h)}
+	}
+	if content := doc.qrContent(); content != "" {
+		code, err := qr.Encode(content, qr.M)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode QR: %w", err)
+		}
+		l.qr = code
+	}
+	l.render()
+
+	kids := make([]string, len(l.pages))
+	for i, p := range l.pages {
+		l.footer(p, i+1, len(l.pages))
+		resources := "/Font " + fonts
+		if len(p.images) > 0 {
+			var images []string
+			for _, name := range sortedKeys(p.images) {
+				images = append(images, fmt.Sprintf("/%s %d 0 R", name, p.images[name]))
+			}
+			resources += " /XObject << " + strings.Join(images, " ") + " >>"
+		}
+		content := f.add(stream("", p.content.Bytes()))
+		id := f.add([]byte(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources << %s >> /Contents %d 0 R >>",
+			pages, num(p.width), num(p.height), resources, content)))
+		kids[i] = fmt.Sprintf("%d 0 R", id)
+	}
+	f.set(pages, []byte(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids))))
+	f.set(catalog, []byte(fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pages)))
+
+	info := fmt.Sprintf("<< /Title %s /Subject %s /Producer (ubl21-dian)", literal(doc.title()+" "+doc.Number), literal(doc.UUID))
+	if date := doc.creationDate(); date != "" {
+		info += " /CreationDate " + literal(date)
+	}
+	return f.bytes(catalog, f.add([]byte(info+" >>"))), nil
+}
+
+// RenderXML genera el PDF de una factura, nota o documento soporte en XML
+func RenderXML(data []byte, opts Options) ([]byte, error) {
+	doc, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return Render(doc, opts)
+}
+
+// RenderInvoice genera el PDF de la factura del builder
+func RenderInvoice(b *invoice.Builder, opts Options) ([]byte, error) {
+	data, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return RenderXML(data, opts)
+}
+
+// RenderCreditNote genera el PDF de la nota crédito del builder
+func RenderCreditNote(b *creditnote.Builder, opts Options) ([]byte, error) {
+	data, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return RenderXML(data, opts)
+}
+
+// RenderDebitNote genera el PDF de la nota débito del builder
+func RenderDebitNote(b *debitnote.Builder, opts Options) ([]byte, error) {
+	data, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return RenderXML(data, opts)
+}
+
+// RenderSupportDocument genera el PDF del documento soporte del builder
+func RenderSupportDocument(b *supportdocument.Builder, opts Options) ([]byte, error) {
+	data, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return RenderXML([]byte(data), opts)
+}
+
+// Renderer implementa pipeline.Renderer con el XML firmado y la fecha de
+// validación del ApplicationResponse
+type Renderer struct {
+	Options Options
+}
+
+// Render implementa pipeline.Renderer
+func (r *Renderer) Render(result *pipeline.Result) ([]byte, error) {
+	doc, err := Parse(result.SignedXML)
+	if err != nil {
+		return nil, err
+	}
+	if ar := result.ApplicationResponse; ar != nil && !ar.IssueDate.IsZero() {
+		doc.ValidatedAt = strings.TrimSpace(ar.IssueDate.Format("2006-01-02") + " " + ar.IssueTime)
+	}
+	return Render(doc, r.Options)
+}
+
+// fontObject diccionario de una fuente estándar
+func fontObject(f *font) []byte {
+	return []byte(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", f.base))
+}
+
+// title título del documento
+func (d *Document) title() string {
+	switch d.Type {
+	case rules.CreditNote:
+		return "Nota crédito electrónica"
+	case rules.DebitNote:
+		return "Nota débito electrónica"
+	case rules.SupportDocument:
+		return "Documento soporte en adquisiciones a no obligados a facturar"
+	}
+	if d.TypeCode == "02" {
+		return "Factura electrónica de exportación"
+	}
+	return "Factura electrónica de venta"
+}
+
+// uuidLabel nombre del código único (CUFE, CUDE, CUDS)
+func (d *Document) uuidLabel() string {
+	if name, _, _ := strings.Cut(d.UUIDScheme, "-"); name != "" {
+		return name
+	}
+	if d.Type == rules.Invoice {
+		return "CUFE"
+	}
+	return "CUDE"
+}
+
+// qrContent contenido del QR: sts:QRCode o el contenido del Anexo Técnico
+// armado con los datos del documento
+func (d *Document) qrContent() string {
+	if d.QRCode != "" {
+		return d.QRCode
+	}
+	if d.UUID == "" {
+		return ""
+	}
+	p := qr.Payload{
+		Document:    qr.Document(d.Type),
+		Number:      d.Number,
+		IssueDate:   d.IssueDate,
+		IssueTime:   d.IssueTime,
+		SupplierID:  d.Supplier.ID,
+		CustomerID:  d.Customer.ID,
+		Subtotal:    parseAmount(d.Totals.TaxExclusive),
+		Total:       parseAmount(d.Totals.Payable),
+		UUID:        d.UUID,
+		Environment: d.Environment,
+	}
+	if d.Type == rules.SupportDocument {
+		// En el documento soporte el emisor es el adquiriente obligado
+		p.SupplierID, p.CustomerID = d.Customer.ID, d.Supplier.ID
+	}
+	for _, tax := range d.Taxes {
+		if tax.SchemeID == "01" {
+			p.IVA += parseAmount(tax.Amount)
+		} else {
+			p.OtherTaxes += parseAmount(tax.Amount)
+		}
+	}
+	return p.String()
+}
+
+// creationDate fecha de emisión en formato PDF (D:AAAAMMDDHHmmSS-05'00')
+func (d *Document) creationDate() string {
+	t, err := time.Parse("2006-01-02 15:04:05-07:00", d.IssueDate+" "+d.IssueTime)
+	if err != nil {
+		return ""
+	}
+	_, offset := t.Zone()
+	sign := "+"
+	if offset < 0 {
+		sign, offset = "-", -offset
+	}
+	return fmt.Sprintf("D:%s%s%02d'%02d'", t.Format("20060102150405"), sign, offset/3600, offset%3600/60)
+}
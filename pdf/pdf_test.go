@@ -0,0 +1,185 @@
+package pdf_test
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/documents/applicationresponse"
+	"github.com/diegofxm/ubl21-dian/documents/invoice"
+	"github.com/diegofxm/ubl21-dian/internal/testutil"
+	"github.com/diegofxm/ubl21-dian/pdf"
+	"github.com/diegofxm/ubl21-dian/pipeline"
+)
+
+var update = flag.Bool("update", false, "actualiza testdata/invoice.pdf")
+
+const cufe = "a4c7ba0cb29a6e8df0b4a7e51e55d72e1b3bf0a5ab3b0f0b2e5b1a5e79e7c0d2c3b1f8f9e4e8e4c2b4a9f6c1d0e3b2a1"
+
+// party parte de testutil con teléfono y correo de contacto
+func party(name, nit string) invoice.PartyTemplateData {
+	p := testutil.Party(name, nit)
+	p.Contact = invoice.ContactTemplateData{Telephone: "6011234567", Email: "facturacion@" + strings.ToLower(strings.Fields(name)[0]) + ".co"}
+	return p
+}
+
+func newInvoice(lines int) *invoice.Builder {
+	tax := invoice.TaxSubtotalTemplateData{
+		TaxableAmount: "100000.00", TaxAmount: "19000.00", CurrencyID: "COP", Percent: "19.00",
+		TaxCategory: invoice.TaxCategoryTemplateData{Percent: "19.00", TaxScheme: invoice.TaxSchemeTemplateData{ID: "01", Name: "IVA"}},
+	}
+	b := invoice.NewBuilder().
+		SetProfileExecutionID("2").
+		SetInvoiceData("SETP990000001", cufe, "2025-06-01", "10:00:00-05:00", "2025-06-30").
+		SetDianExtensions("18760000001", "2019-01-19", "2030-01-19", "SETP", "990000000", "995000000",
+			"900123456", "8", "31", "56f2ae4e-9812-4fad-9255-08fcfcd5ccb0", "", "").
+		SetSupplier(party("MI EMPRESA SAS", "900123456")).
+		SetCustomer(party("CLIENTE SAS", "800111222")).
+		SetPaymentMeans("2", "42", "2025-06-30").
+		SetNote("Gracias por su compra").
+		SetMonetaryTotals("100000.00", "100000.00", "119000.00", "", "119000.00").
+		AddTaxTotal(invoice.TaxTotalTemplateData{TaxAmount: "19000.00", CurrencyID: "COP", TaxSubtotals: []invoice.TaxSubtotalTemplateData{tax}})
+	for i := 1; i <= lines; i++ {
+		b.AddInvoiceLine(invoice.InvoiceLineTemplateData{
+			ID: strconv.Itoa(i), UnitCode: "94", Quantity: "2.500000", LineExtensionAmount: "100000.00", FreeOfChargeIndicator: "false", CurrencyID: "COP",
+			Item:     invoice.ItemTemplateData{Description: "Servicio de consultoría en implementación de facturación electrónica", StandardItemID: invoice.ItemIDTemplateData{ID: "P00" + strconv.Itoa(i), SchemeID: "999"}},
+			Price:    invoice.PriceTemplateData{Amount: "40000.00", BaseQuantity: "1.000000"},
+			TaxTotal: &invoice.TaxTotalTemplateData{TaxAmount: "19000.00", CurrencyID: "COP", TaxSubtotals: []invoice.TaxSubtotalTemplateData{tax}},
+		})
+	}
+	return b
+}
+
+func TestParse(t *testing.T) {
+	data, err := newInvoice(1).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := pdf.Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Number != "SETP990000001" || doc.UUID != cufe || doc.Environment != "2" {
+		t.Errorf("documento = %+v", doc)
+	}
+	if doc.Supplier.Name != "MI EMPRESA SAS" || doc.Supplier.ID != "900123456" || doc.Customer.ID != "800111222" {
+		t.Errorf("partes = %+v / %+v", doc.Supplier, doc.Customer)
+	}
+	if doc.Resolution == nil || doc.Resolution.Number != "18760000001" || doc.Resolution.Prefix != "SETP" {
+		t.Errorf("resolución = %+v", doc.Resolution)
+	}
+	if len(doc.Lines) != 1 || doc.Lines[0].Description == "" || len(doc.Taxes) != 1 || doc.Totals.Payable != "119000.00" {
+		t.Errorf("líneas = %+v, impuestos = %+v, totales = %+v", doc.Lines, doc.Taxes, doc.Totals)
+	}
+
+	if _, err := pdf.Parse([]byte(`<Order xmlns="urn:oasis:names:specification:ubl:schema:xsd:Order-2"/>`)); !errors.Is(err, pdf.ErrUnsupported) {
+		t.Errorf("Parse(Order) = %v, se esperaba ErrUnsupported", err)
+	}
+}
+
+func TestRenderInvoice(t *testing.T) {
+	opts := pdf.Options{Provider: "Mi Software SAS", Footer: "Régimen común"}
+	out, err := pdf.RenderInvoice(newInvoice(1), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Fatal("no es un PDF")
+	}
+	for _, text := range []string{
+		"(FACTURA ELECTR", "(No. SETP990000001)", "(MI EMPRESA SAS)", "(NIT 900.123.456-8)",
+		"(CLIENTE SAS)", "(CUFE)", "($ 119.000,00)", "(2,5)", "Mi Software SAS NIT 900123456)", "(P\xe1gina 1 de 1)",
+	} {
+		if !bytes.Contains(out, []byte(text)) {
+			t.Errorf("falta %q", text)
+		}
+	}
+
+	again, err := pdf.RenderInvoice(newInvoice(1), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, again) {
+		t.Error("la salida no es determinística")
+	}
+
+	golden := filepath.Join("testdata", "invoice.pdf")
+	if *update {
+		if err := os.WriteFile(golden, out, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, want) {
+		t.Error("el PDF difiere de testdata/invoice.pdf (go test ./pdf -update)")
+	}
+}
+
+func TestRenderPages(t *testing.T) {
+	out, err := pdf.RenderInvoice(newInvoice(40), pdf.Options{PageSize: pdf.A4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(out, []byte("/Count 2")) || !bytes.Contains(out, []byte("(P\xe1gina 2 de 2)")) {
+		t.Error("se esperaban dos páginas")
+	}
+	if !bytes.Contains(out, []byte("(Total a pagar)")) {
+		t.Error("faltan los totales")
+	}
+}
+
+func TestRenderLogo(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 20))
+	for x := 0; x < 40; x++ {
+		img.Set(x, x/2, color.NRGBA{R: 0xFF, A: 0x80})
+	}
+	var logo bytes.Buffer
+	if err := png.Encode(&logo, img); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := pdf.RenderInvoice(newInvoice(1), pdf.Options{Logo: logo.Bytes()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(out, []byte("/Im1 Do")) || !bytes.Contains(out, []byte("/SMask")) {
+		t.Error("falta el logo")
+	}
+
+	if _, err := pdf.RenderInvoice(newInvoice(1), pdf.Options{Logo: []byte("no es imagen")}); !errors.Is(err, pdf.ErrInvalidLogo) {
+		t.Errorf("logo inválido = %v, se esperaba ErrInvalidLogo", err)
+	}
+}
+
+func TestRenderer(t *testing.T) {
+	data, err := newInvoice(1).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r pipeline.Renderer = &pdf.Renderer{}
+	out, err := r.Render(&pipeline.Result{
+		SignedXML: data,
+		ApplicationResponse: &applicationresponse.ApplicationResponseData{
+			IssueDate: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+			IssueTime: "10:05:00-05:00",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(out, []byte("(Fecha de validaci\xf3n DIAN: 2025-06-01 10:05:00-05:00)")) {
+		t.Error("falta la fecha de validación")
+	}
+}
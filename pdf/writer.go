@@ -0,0 +1,240 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png" // Logos PNG
+	"strconv"
+)
+
+// file objetos de un PDF 1.4; los números de objeto son el índice + 1
+type file struct {
+	objects [][]byte
+}
+
+// add agrega un objeto y retorna su número
+func (f *file) add(object []byte) int {
+	f.objects = append(f.objects, object)
+	return len(f.objects)
+}
+
+// reserve reserva un número de objeto para escribirlo después (set)
+func (f *file) reserve() int {
+	return f.add(nil)
+}
+
+func (f *file) set(id int, object []byte) {
+	f.objects[id-1] = object
+}
+
+// stream objeto stream con su diccionario
+func stream(dict string, data []byte) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "<< /Length %d%s >>\nstream\n", len(data), dict)
+	b.Write(data)
+	b.WriteString("\nendstream")
+	return b.Bytes()
+}
+
+// bytes serializa el archivo con su tabla xref
+func (f *file) bytes(root, info int) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(f.objects))
+	for i, object := range f.objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n", i+1)
+		b.Write(object)
+		b.WriteString("\nendobj\n")
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(f.objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(f.objects)+1, root, info, xref)
+	return b.Bytes()
+}
+
+// literal string de PDF con escapes
+func literal(s string) string {
+	var b bytes.Buffer
+	b.WriteByte('(')
+	for _, c := range encode(s) {
+		switch c {
+		case '(', ')', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// num número con hasta dos decimales, sin ceros de más
+func num(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// round2 redondea coordenadas a centésimas de punto (salida estable)
+func round2(v float64) float64 {
+	if v < 0 {
+		return -float64(int64(-v*100+0.5)) / 100
+	}
+	return float64(int64(v*100+0.5)) / 100
+}
+
+// page página en construcción; las coordenadas se miden desde arriba
+type page struct {
+	width, height float64
+	content       bytes.Buffer
+	images        map[string]int // Recursos /Im -> objeto
+}
+
+func newPage(width, height float64) *page {
+	return &page{width: width, height: height, images: map[string]int{}}
+}
+
+func (p *page) op(format string, args ...float64) {
+	values := make([]any, len(args))
+	for i, a := range args {
+		values[i] = num(round2(a))
+	}
+	fmt.Fprintf(&p.content, format+"\n", values...)
+}
+
+// fill color de relleno (texto y rectángulos)
+func (p *page) fill(c color.RGBA) {
+	p.op("%s %s %s rg", float64(c.R)/255, float64(c.G)/255, float64(c.B)/255)
+}
+
+// stroke color y grosor de línea
+func (p *page) stroke(c color.RGBA, width float64) {
+	p.op("%s %s %s RG %s w", float64(c.R)/255, float64(c.G)/255, float64(c.B)/255, width)
+}
+
+// text escribe una línea con la base en y
+func (p *page) text(x, y float64, f *font, size float64, s string) {
+	if s == "" {
+		return
+	}
+	fmt.Fprintf(&p.content, "BT /%s %s Tf %s %s Td %s Tj ET\n", f.name, num(size), num(round2(x)), num(round2(p.height-y)), literal(s))
+}
+
+// textRight escribe una línea alineada a la derecha en x
+func (p *page) textRight(x, y float64, f *font, size float64, s string) {
+	p.text(x-f.width(s, size), y, f, size, s)
+}
+
+// textCenter escribe una línea centrada en x
+func (p *page) textCenter(x, y float64, f *font, size float64, s string) {
+	p.text(x-f.width(s, size)/2, y, f, size, s)
+}
+
+// rect rectángulo relleno (fill) y/o con borde (stroke)
+func (p *page) rect(x, y, w, h float64, fill, stroke bool) {
+	operator := "S"
+	switch {
+	case fill && stroke:
+		operator = "B"
+	case fill:
+		operator = "f"
+	}
+	p.op("%s %s %s %s re "+operator, x, p.height-y-h, w, h)
+}
+
+// line segmento de (x1, y1) a (x2, y2)
+func (p *page) line(x1, y1, x2, y2 float64) {
+	p.op("%s %s m %s %s l S", x1, p.height-y1, x2, p.height-y2)
+}
+
+// image dibuja una imagen registrada en el archivo
+func (p *page) image(name string, id int, x, y, w, h float64) {
+	p.images[name] = id
+	p.content.WriteString("q\n")
+	p.op("%s 0 0 %s %s %s cm", w, h, x, p.height-y-h)
+	fmt.Fprintf(&p.content, "/%s Do\nQ\n", name)
+}
+
+// qr dibuja los módulos oscuros como rectángulos, una corrida por fila
+func (p *page) qr(x, y, size float64, modules int, dark func(x, y int) bool) {
+	module := size / float64(modules)
+	for row := 0; row < modules; row++ {
+		for col := 0; col < modules; {
+			if !dark(col, row) {
+				col++
+				continue
+			}
+			start := col
+			for col < modules && dark(col, row) {
+				col++
+			}
+			p.op("%s %s %s %s re", x+float64(start)*module, p.height-y-float64(row+1)*module, float64(col-start)*module, module)
+		}
+	}
+	p.content.WriteString("f\n")
+}
+
+// embedImage agrega una imagen (JPEG directo, otros formatos como RGB
+// comprimido con canal alfa) y retorna el objeto y sus dimensiones
+func (f *file) embedImage(data []byte) (id, width, height int, err error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("%w: %v", ErrInvalidLogo, err)
+	}
+
+	if format == "jpeg" {
+		colorSpace := "/DeviceRGB"
+		switch config.ColorModel {
+		case color.GrayModel:
+			colorSpace = "/DeviceGray"
+		case color.CMYKModel:
+			colorSpace = "/DeviceCMYK"
+		}
+		if _, err := jpeg.Decode(bytes.NewReader(data)); err != nil {
+			return 0, 0, 0, fmt.Errorf("%w: %v", ErrInvalidLogo, err)
+		}
+		dict := fmt.Sprintf(" /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 /Filter /DCTDecode", config.Width, config.Height, colorSpace)
+		return f.add(stream(dict, data)), config.Width, config.Height, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("%w: %v", ErrInvalidLogo, err)
+	}
+	bounds := img.Bounds()
+	rgb := make([]byte, 0, bounds.Dx()*bounds.Dy()*3)
+	alpha := make([]byte, 0, bounds.Dx()*bounds.Dy())
+	opaque := true
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			rgb = append(rgb, c.R, c.G, c.B)
+			alpha = append(alpha, c.A)
+			opaque = opaque && c.A == 0xFF
+		}
+	}
+
+	smask := ""
+	if !opaque {
+		dict := fmt.Sprintf(" /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /FlateDecode", bounds.Dx(), bounds.Dy())
+		smask = fmt.Sprintf(" /SMask %d 0 R", f.add(stream(dict, deflate(alpha))))
+	}
+	dict := fmt.Sprintf(" /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode%s", bounds.Dx(), bounds.Dy(), smask)
+	return f.add(stream(dict, deflate(rgb))), bounds.Dx(), bounds.Dy(), nil
+}
+
+func deflate(data []byte) []byte {
+	var b bytes.Buffer
+	w := zlib.NewWriter(&b)
+	w.Write(data)
+	w.Close()
+	return b.Bytes()
+}
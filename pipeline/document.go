@@ -0,0 +1,382 @@
+package pipeline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/documents/attached"
+	"github.com/diegofxm/ubl21-dian/documents/creditnote"
+	"github.com/diegofxm/ubl21-dian/documents/debitnote"
+	"github.com/diegofxm/ubl21-dian/documents/invoice"
+	"github.com/diegofxm/ubl21-dian/documents/supportdocument"
+	"github.com/diegofxm/ubl21-dian/naming"
+	"github.com/diegofxm/ubl21-dian/qr"
+	"github.com/diegofxm/ubl21-dian/signature"
+)
+
+// DocumentType tipo de documento que emite el pipeline
+type DocumentType string
+
+const (
+	Invoice         DocumentType = "Invoice"
+	CreditNote      DocumentType = "CreditNote"
+	DebitNote       DocumentType = "DebitNote"
+	SupportDocument DocumentType = "SupportDocument"
+)
+
+// documentTypes datos de cada tipo: prefijo de archivo DIAN, prefijo de los
+// artefactos (FE/NC/ND/DS), esquema del UUID y ProfileID del AttachedDocument
+var documentTypes = map[DocumentType]struct {
+	kind    naming.Kind
+	prefix  string
+	scheme  string
+	profile string
+}{
+	Invoice:         {naming.KindInvoice, "FE", "CUFE-SHA384", "Factura Electrónica de Venta"},
+	CreditNote:      {naming.KindCreditNote, "NC", "CUDE-SHA384", "Nota Crédito de Factura Electrónica de Venta"},
+	DebitNote:       {naming.KindDebitNote, "ND", "CUDE-SHA384", "Nota Débito de Factura Electrónica de Venta"},
+	SupportDocument: {naming.KindSupportDocument, "DS", "CUDS-SHA384", "Documento Soporte"},
+}
+
+// Amounts montos que entran en el CUFE/CUDE/CUDS
+type Amounts struct {
+	TaxExclusive float64
+	IVA          float64 // Impuesto 01
+	INC          float64 // Impuesto 04
+	ICA          float64 // Impuesto 03
+	Payable      float64
+}
+
+// Keys valores calculados por el pipeline antes de generar el XML
+type Keys struct {
+	UUID         string // CUFE, CUDE o CUDS
+	SecurityCode string // SoftwareSecurityCode
+	QRCode       string
+}
+
+// Document documento a emitir
+//
+// Normalmente se obtiene de un builder con FromInvoice, FromCreditNote,
+// FromDebitNote o FromSupportDocument; Build recibe las Keys calculadas y
+// genera el XML sin firmar.
+type Document struct {
+	Type         DocumentType
+	Number       string // cbc:ID (prefijo + consecutivo)
+	IssueDate    string // YYYY-MM-DD
+	IssueTime    string // HH:MM:SS-05:00
+	Environment  string // ProfileExecutionID ("1" producción, "2" pruebas)
+	SoftwareID   string
+	Amounts      Amounts
+	SupplierNIT  string // Emisor (en documento soporte, el vendedor no obligado)
+	CustomerNIT  string // Adquiriente (en documento soporte, el obligado que emite)
+	TechnicalKey string // Clave técnica del rango (solo facturas)
+
+	// Partes del AttachedDocument
+	Sender   attached.PartyData
+	Receiver attached.PartyData
+
+	Build func(keys Keys) ([]byte, error)
+}
+
+// keys calcula UUID, código de seguridad y QR del documento
+func (d *Document) keys(softwarePIN string) (Keys, error) {
+	date, err := time.Parse("2006-01-02", d.IssueDate)
+	if err != nil {
+		return Keys{}, fmt.Errorf("invalid issue date %q: %w", d.IssueDate, err)
+	}
+
+	a := d.Amounts
+	var uuid string
+	switch d.Type {
+	case Invoice:
+		if d.TechnicalKey == "" {
+			return Keys{}, fmt.Errorf("invoice %s has no technical key", d.Number)
+		}
+		uuid = signature.CalculateCUFE(d.Number, date, d.IssueTime, a.TaxExclusive, a.IVA, a.INC, a.ICA, a.Payable,
+			d.SupplierNIT, d.CustomerNIT, d.TechnicalKey, d.Environment)
+	case CreditNote, DebitNote:
+		uuid = signature.CalculateCUDE(d.Number, date, d.IssueTime, a.TaxExclusive, a.IVA, a.INC, a.ICA, a.Payable,
+			d.SupplierNIT, d.CustomerNIT, softwarePIN, d.Environment)
+	case SupportDocument:
+		uuid = signature.CalculateCUDS(d.Number, date, d.IssueTime, a.TaxExclusive, a.IVA, a.Payable,
+			d.SupplierNIT, d.CustomerNIT, softwarePIN, d.Environment)
+	default:
+		return Keys{}, fmt.Errorf("unknown document type %q", d.Type)
+	}
+
+	return Keys{
+		UUID:         uuid,
+		SecurityCode: signature.CalculateSoftwareSecurityCode(d.SoftwareID, softwarePIN, d.Number),
+		QRCode: qr.Payload{
+			Document:    qr.Document(d.Type),
+			Number:      d.Number,
+			IssueDate:   d.IssueDate,
+			IssueTime:   d.IssueTime,
+			SupplierID:  d.SupplierNIT,
+			CustomerID:  d.CustomerNIT,
+			Subtotal:    a.TaxExclusive,
+			IVA:         a.IVA,
+			OtherTaxes:  a.INC + a.ICA,
+			Total:       a.Payable,
+			UUID:        uuid,
+			Environment: d.Environment,
+		}.String(),
+	}, nil
+}
+
+// FromInvoice adapta un builder de factura con todos los datos excepto
+// CUFE, código de seguridad y QR, que calcula el pipeline
+func FromInvoice(b *invoice.Builder, technicalKey string) (*Document, error) {
+	data := b.GetData()
+
+	amounts, err := parseAmounts(data.TaxExclusiveAmount, data.PayableAmount)
+	if err != nil {
+		return nil, fmt.Errorf("invoice %s: %w", data.InvoiceNumber, err)
+	}
+	for _, total := range data.TaxTotals {
+		for _, sub := range total.TaxSubtotals {
+			if err := amounts.add(sub.TaxCategory.TaxScheme.ID, sub.TaxAmount); err != nil {
+				return nil, fmt.Errorf("invoice %s: %w", data.InvoiceNumber, err)
+			}
+		}
+	}
+
+	return &Document{
+		Type:         Invoice,
+		Number:       data.InvoiceNumber,
+		IssueDate:    data.IssueDate,
+		IssueTime:    data.IssueTime,
+		Environment:  data.Environment,
+		SoftwareID:   data.SoftwareID,
+		Amounts:      amounts,
+		SupplierNIT:  data.Supplier.TaxScheme.CompanyID,
+		CustomerNIT:  data.Customer.TaxScheme.CompanyID,
+		TechnicalKey: technicalKey,
+		Sender:       invoiceParty(data.Supplier.TaxScheme),
+		Receiver:     invoiceParty(data.Customer.TaxScheme),
+		Build: func(keys Keys) ([]byte, error) {
+			d := b.GetData()
+			b.SetInvoiceData(d.InvoiceNumber, keys.UUID, d.IssueDate, d.IssueTime, d.DueDate)
+			b.SetDianExtensions(d.InvoiceAuthorization, d.AuthPeriodStartDate, d.AuthPeriodEndDate, d.Prefix, d.From, d.To,
+				d.ProviderID, d.ProviderSchemeID, d.ProviderSchemeName, d.SoftwareID, keys.SecurityCode, keys.QRCode)
+			return b.Build()
+		},
+	}, nil
+}
+
+// FromCreditNote adapta un builder de nota crédito (CUDE con el PIN del software)
+func FromCreditNote(b *creditnote.Builder) (*Document, error) {
+	data := b.GetData()
+
+	amounts, err := parseAmounts(data.TaxExclusiveAmount, data.PayableAmount)
+	if err != nil {
+		return nil, fmt.Errorf("credit note %s: %w", data.CreditNoteNumber, err)
+	}
+	for _, total := range data.TaxTotals {
+		for _, sub := range total.TaxSubtotals {
+			if err := amounts.add(sub.TaxCategory.TaxScheme.ID, sub.TaxAmount); err != nil {
+				return nil, fmt.Errorf("credit note %s: %w", data.CreditNoteNumber, err)
+			}
+		}
+	}
+
+	return &Document{
+		Type:        CreditNote,
+		Number:      data.CreditNoteNumber,
+		IssueDate:   data.IssueDate,
+		IssueTime:   data.IssueTime,
+		Environment: data.Environment,
+		SoftwareID:  data.SoftwareID,
+		Amounts:     amounts,
+		SupplierNIT: data.Supplier.TaxScheme.CompanyID,
+		CustomerNIT: data.Customer.TaxScheme.CompanyID,
+		Sender:      creditNoteParty(data.Supplier.TaxScheme),
+		Receiver:    creditNoteParty(data.Customer.TaxScheme),
+		Build: func(keys Keys) ([]byte, error) {
+			d := b.GetData()
+			b.SetCreditNoteData(d.CreditNoteNumber, keys.UUID, d.IssueDate, d.IssueTime)
+			b.SetDianExtensions(d.InvoiceAuthorization, d.AuthPeriodStartDate, d.AuthPeriodEndDate, d.Prefix, d.From, d.To,
+				d.ProviderID, d.ProviderSchemeID, d.ProviderSchemeName, d.SoftwareID, keys.SecurityCode, keys.QRCode)
+			return b.Build()
+		},
+	}, nil
+}
+
+// FromDebitNote adapta un builder de nota débito (CUDE con el PIN del software)
+func FromDebitNote(b *debitnote.Builder) (*Document, error) {
+	data := b.GetData()
+
+	amounts, err := parseAmounts(data.TaxExclusiveAmount, data.PayableAmount)
+	if err != nil {
+		return nil, fmt.Errorf("debit note %s: %w", data.DebitNoteNumber, err)
+	}
+	for _, total := range data.TaxTotals {
+		for _, sub := range total.TaxSubtotals {
+			if err := amounts.add(sub.TaxCategory.TaxScheme.ID, sub.TaxAmount); err != nil {
+				return nil, fmt.Errorf("debit note %s: %w", data.DebitNoteNumber, err)
+			}
+		}
+	}
+
+	return &Document{
+		Type:        DebitNote,
+		Number:      data.DebitNoteNumber,
+		IssueDate:   data.IssueDate,
+		IssueTime:   data.IssueTime,
+		Environment: data.Environment,
+		SoftwareID:  data.SoftwareID,
+		Amounts:     amounts,
+		SupplierNIT: data.Supplier.TaxScheme.CompanyID,
+		CustomerNIT: data.Customer.TaxScheme.CompanyID,
+		Sender:      debitNoteParty(data.Supplier.TaxScheme),
+		Receiver:    debitNoteParty(data.Customer.TaxScheme),
+		Build: func(keys Keys) ([]byte, error) {
+			d := b.GetData()
+			b.SetDebitNoteData(d.DebitNoteNumber, keys.UUID, d.IssueDate, d.IssueTime)
+			b.SetDianExtensions(d.InvoiceAuthorization, d.AuthPeriodStartDate, d.AuthPeriodEndDate, d.Prefix, d.From, d.To,
+				d.ProviderID, d.ProviderSchemeID, d.ProviderSchemeName, d.SoftwareID, keys.SecurityCode, keys.QRCode)
+			return b.Build()
+		},
+	}, nil
+}
+
+// FromSupportDocument adapta un builder de documento soporte
+// El emisor del AttachedDocument es el comprador (obligado a expedirlo).
+func FromSupportDocument(b *supportdocument.Builder) (*Document, error) {
+	data := b.GetData()
+
+	amounts, err := parseAmounts(data.TaxExclusiveAmount, data.PayableAmount)
+	if err != nil {
+		return nil, fmt.Errorf("support document %s: %w", data.SupportDocNumber, err)
+	}
+	for _, total := range data.TaxTotals {
+		for _, sub := range total.TaxSubtotals {
+			if err := amounts.add(sub.TaxCategory.ID, sub.TaxAmount); err != nil {
+				return nil, fmt.Errorf("support document %s: %w", data.SupportDocNumber, err)
+			}
+		}
+	}
+
+	return &Document{
+		Type:        SupportDocument,
+		Number:      data.SupportDocNumber,
+		IssueDate:   data.IssueDate,
+		IssueTime:   data.IssueTime,
+		Environment: data.ProfileExecutionID,
+		SoftwareID:  data.SoftwareID,
+		Amounts:     amounts,
+		SupplierNIT: data.Supplier.ID,
+		CustomerNIT: data.Buyer.ID,
+		Sender:      supportDocumentParty(data.Buyer),
+		Receiver:    supportDocumentParty(data.Supplier),
+		Build: func(keys Keys) ([]byte, error) {
+			d := b.GetData()
+			b.SetCUDS(keys.UUID)
+			b.SetDianExtensions(d.InvoiceAuthorization, d.AuthPeriodStartDate, d.AuthPeriodEndDate, d.Prefix, d.From, d.To,
+				d.ProviderID, d.ProviderSchemeID, d.ProviderSchemeName, d.SoftwareID, keys.SecurityCode, keys.QRCode)
+			xmlDoc, err := b.Build()
+			return []byte(xmlDoc), err
+		},
+	}, nil
+}
+
+// invoiceParty parte del AttachedDocument desde el TaxScheme de la factura
+func invoiceParty(ts invoice.TaxSchemeTemplateData) attached.PartyData {
+	return party(attached.PartyData{
+		RegistrationName: ts.RegistrationName,
+		CompanyID:        ts.CompanyID,
+		SchemeID:         ts.CompanyIDSchemeID,
+		SchemeName:       ts.CompanyIDSchemeName,
+		TaxLevelCode:     ts.TaxLevelCode,
+		TaxSchemeID:      ts.ID,
+		TaxSchemeName:    ts.Name,
+	})
+}
+
+// creditNoteParty parte del AttachedDocument desde el TaxScheme de la nota crédito
+func creditNoteParty(ts creditnote.TaxSchemeTemplateData) attached.PartyData {
+	return party(attached.PartyData{
+		RegistrationName: ts.RegistrationName,
+		CompanyID:        ts.CompanyID,
+		SchemeID:         ts.CompanyIDSchemeID,
+		SchemeName:       ts.CompanyIDSchemeName,
+		TaxLevelCode:     ts.TaxLevelCode,
+		TaxSchemeID:      ts.ID,
+		TaxSchemeName:    ts.Name,
+	})
+}
+
+// debitNoteParty parte del AttachedDocument desde el TaxScheme de la nota débito
+func debitNoteParty(ts debitnote.TaxSchemeTemplateData) attached.PartyData {
+	return party(attached.PartyData{
+		RegistrationName: ts.RegistrationName,
+		CompanyID:        ts.CompanyID,
+		SchemeID:         ts.CompanyIDSchemeID,
+		SchemeName:       ts.CompanyIDSchemeName,
+		TaxLevelCode:     ts.TaxLevelCode,
+		TaxSchemeID:      ts.ID,
+		TaxSchemeName:    ts.Name,
+	})
+}
+
+// supportDocumentParty parte del AttachedDocument en documento soporte
+func supportDocumentParty(p supportdocument.PartyTemplateData) attached.PartyData {
+	return party(attached.PartyData{
+		RegistrationName: p.Name,
+		CompanyID:        p.ID,
+		SchemeID:         p.DV,
+		SchemeName:       p.DocumentType,
+		TaxLevelCode:     p.TaxLevelCode,
+		TaxSchemeID:      p.TaxSchemeID,
+		TaxSchemeName:    p.TaxSchemeName,
+	})
+}
+
+// party completa el esquema de impuestos por defecto (01 IVA)
+func party(p attached.PartyData) attached.PartyData {
+	if p.TaxSchemeID == "" {
+		p.TaxSchemeID, p.TaxSchemeName = "01", "IVA"
+	}
+	return p
+}
+
+// parseAmounts lee los totales del builder
+func parseAmounts(taxExclusive, payable string) (Amounts, error) {
+	var a Amounts
+	var err error
+	if a.TaxExclusive, err = parseAmount(taxExclusive); err != nil {
+		return a, fmt.Errorf("invalid TaxExclusiveAmount: %w", err)
+	}
+	if a.Payable, err = parseAmount(payable); err != nil {
+		return a, fmt.Errorf("invalid PayableAmount: %w", err)
+	}
+	return a, nil
+}
+
+// add suma el impuesto de un subtotal según su esquema (01, 04, 03)
+func (a *Amounts) add(scheme, amount string) error {
+	v, err := parseAmount(amount)
+	if err != nil {
+		return fmt.Errorf("invalid tax amount for %s: %w", scheme, err)
+	}
+	switch scheme {
+	case "01":
+		a.IVA += v
+	case "04":
+		a.INC += v
+	case "03":
+		a.ICA += v
+	}
+	return nil
+}
+
+// parseAmount lee un monto ("" equivale a 0)
+func parseAmount(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
@@ -0,0 +1,356 @@
+// Package pipeline ejecuta el flujo completo de emisión de un documento
+//
+// Une las piezas de documents, signature, naming y soap en el orden de
+// markdown/Flujo_Facturacion_Electronica_DIAN.md:
+//
+//	build → CUFE → firma → ZIP → SOAP → ApplicationResponse → AttachedDocument → firma
+//
+// para facturas, notas crédito, notas débito y documentos soporte:
+//
+//	p, err := pipeline.New(pipeline.Config{
+//		Sender:      client,
+//		Signer:      signer,
+//		Counter:     naming.NewCounter(naming.NewFileStore("consecutivos.json"), "900123456", ""),
+//		SoftwarePIN: "12345",
+//		Hooks: pipeline.Hooks{
+//			AfterStage: func(stage pipeline.Stage, result *pipeline.Result) error {
+//				return guardar(result) // persistencia por etapa
+//			},
+//		},
+//	})
+//	doc, err := pipeline.FromInvoice(builder, technicalKey)
+//	result, err := p.Issue(doc)
+package pipeline
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/documents/applicationresponse"
+	"github.com/diegofxm/ubl21-dian/documents/attached"
+	"github.com/diegofxm/ubl21-dian/naming"
+	"github.com/diegofxm/ubl21-dian/soap"
+	"github.com/diegofxm/ubl21-dian/soap/types"
+)
+
+// Signer firma documentos XML con XAdES (*signature.Signer lo implementa)
+type Signer interface {
+	SignXML(xmlData []byte) ([]byte, error)
+}
+
+// Sender envía el ZIP a DIAN (*soap.Client lo implementa)
+//
+// Si además implementa Use(...soap.Interceptor), el pipeline captura el
+// request SOAP como artefacto (Result.Request). Si implementa
+// ProfileExecutionID() string, los documentos de otro ambiente fallan en la
+// etapa build con ErrEnvironmentMismatch.
+type Sender interface {
+	SendBillSync(req *types.SendBillSyncRequest) (*types.SendBillSyncResponse, error)
+}
+
+// Renderer genera la representación gráfica (PDF) del documento validado
+type Renderer interface {
+	Render(result *Result) ([]byte, error)
+}
+
+// Hooks puntos de extensión por etapa
+type Hooks struct {
+	// AfterStage se llama al terminar cada etapa con los artefactos hasta
+	// ese momento (persistencia). Un error detiene el pipeline.
+	AfterStage func(stage Stage, result *Result) error
+
+	// OnError se llama cuando falla una etapa; su retorno reemplaza el
+	// error de Issue (registro, reintentos encolados, traducción)
+	OnError func(stage Stage, result *Result, err error) error
+}
+
+// Config configuración del pipeline
+type Config struct {
+	Sender      Sender
+	Signer      Signer          // Firma el documento y el AttachedDocument
+	Counter     *naming.Counter // Consecutivo de nombres de archivo del emisor
+	SoftwarePIN string          // PIN del software (CUDE, CUDS y código de seguridad)
+	Renderer    Renderer        // Opcional
+	Hooks       Hooks
+	Now         func() time.Time // Fecha del AttachedDocument (time.Now por defecto)
+}
+
+// Pipeline emite documentos de punta a punta; es seguro para uso concurrente
+// si Sender, Signer y el Store del Counter lo son
+type Pipeline struct {
+	config Config
+
+	mu        sync.Mutex
+	exchanges map[string]*exchange // Intercambios SOAP capturados por nombre de ZIP
+}
+
+// exchange request y respuesta SOAP de un SendBillSync
+type exchange struct {
+	request, response []byte
+}
+
+// New crea un pipeline
+func New(config Config) (*Pipeline, error) {
+	switch {
+	case config.Sender == nil:
+		return nil, fmt.Errorf("%w: Sender is required", ErrConfig)
+	case config.Signer == nil:
+		return nil, fmt.Errorf("%w: Signer is required", ErrConfig)
+	case config.Counter == nil:
+		return nil, fmt.Errorf("%w: Counter is required", ErrConfig)
+	case config.SoftwarePIN == "":
+		return nil, fmt.Errorf("%w: SoftwarePIN is required", ErrConfig)
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+
+	p := &Pipeline{config: config, exchanges: map[string]*exchange{}}
+	if client, ok := config.Sender.(interface{ Use(...soap.Interceptor) }); ok {
+		client.Use(p.capture)
+	}
+	return p, nil
+}
+
+// Issue ejecuta todas las etapas para doc
+//
+// Ante un error retorna el Result parcial y un *StageError. Si DIAN rechaza
+// el documento el error envuelve ErrRejected y Result conserva la respuesta.
+func (p *Pipeline) Issue(doc *Document) (*Result, error) {
+	info, ok := documentTypes[doc.Type]
+	if !ok || doc.Build == nil {
+		return nil, fmt.Errorf("%w: unsupported document %q", ErrConfig, doc.Type)
+	}
+
+	result := &Result{Type: doc.Type, Number: doc.Number}
+	steps := []struct {
+		stage Stage
+		run   func() error
+	}{
+		{StageBuild, func() error { return p.build(doc, result) }},
+		{StageSign, func() error { return p.sign(result) }},
+		{StagePackage, func() error { return p.pack(info.kind, result) }},
+		{StageSend, func() error { return p.send(result) }},
+		{StageResponse, func() error { return p.response(result) }},
+		{StageAttached, func() error { return p.attach(doc, result) }},
+		{StageRender, func() error { return p.render(result) }},
+	}
+
+	for _, step := range steps {
+		err := step.run()
+		if err == nil && p.config.Hooks.AfterStage != nil {
+			err = p.config.Hooks.AfterStage(step.stage, result)
+		}
+		if err != nil {
+			err = &StageError{Stage: step.stage, Err: err}
+			if p.config.Hooks.OnError != nil {
+				err = p.config.Hooks.OnError(step.stage, result, err)
+			}
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// build calcula las Keys y genera el XML sin firmar
+func (p *Pipeline) build(doc *Document, result *Result) error {
+	if sender, ok := p.config.Sender.(interface{ ProfileExecutionID() string }); ok {
+		if want := sender.ProfileExecutionID(); want != "" && doc.Environment != want {
+			return fmt.Errorf("%w: document has ProfileExecutionID %q, Sender expects %q", ErrEnvironmentMismatch, doc.Environment, want)
+		}
+	}
+	keys, err := doc.keys(p.config.SoftwarePIN)
+	if err != nil {
+		return err
+	}
+	xmlData, err := doc.Build(keys)
+	if err != nil {
+		return err
+	}
+	result.UUID = keys.UUID
+	result.UnsignedXML = xmlData
+	return nil
+}
+
+// sign firma el documento sin firmar con Config.Signer
+func (p *Pipeline) sign(result *Result) error {
+	signed, err := p.config.Signer.SignXML(result.UnsignedXML)
+	if err != nil {
+		return err
+	}
+	result.SignedXML = signed
+	return nil
+}
+
+// pack reserva el nombre DIAN y arma el ZIP
+func (p *Pipeline) pack(kind naming.Kind, result *Result) error {
+	name, err := p.config.Counter.Next(kind)
+	if err != nil {
+		return err
+	}
+	pkg, err := naming.PackageDocument(name, result.SignedXML)
+	if err != nil {
+		return err
+	}
+	result.Name = name
+	result.Package = pkg
+	return nil
+}
+
+// send envía el ZIP; capture solo guarda el intercambio del FileName que
+// send registra antes del envío (el cliente puede ser compartido)
+func (p *Pipeline) send(result *Result) error {
+	fileName := result.Package.FileName
+	captured := &exchange{}
+	p.mu.Lock()
+	p.exchanges[fileName] = captured
+	p.mu.Unlock()
+
+	resp, err := p.config.Sender.SendBillSync(&types.SendBillSyncRequest{
+		FileName:    fileName,
+		ContentFile: result.Package.ContentFile,
+	})
+
+	p.mu.Lock()
+	result.Request, result.SOAPResponse = captured.request, captured.response
+	delete(p.exchanges, fileName)
+	p.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	result.Response = resp
+	return nil
+}
+
+// response decodifica el ApplicationResponse y verifica que DIAN validó el documento
+func (p *Pipeline) response(result *Result) error {
+	resp := result.Response
+	if resp.XmlBase64Bytes == "" {
+		return fmt.Errorf("%w: %s (%s) without ApplicationResponse: %v", ErrRejected, resp.StatusCode, resp.StatusDescription, resp.ErrorMessages)
+	}
+
+	arXML, err := base64.StdEncoding.DecodeString(resp.XmlBase64Bytes)
+	if err != nil {
+		return fmt.Errorf("invalid XmlBase64Bytes: %w", err)
+	}
+	ar, err := applicationresponse.ParseFromXML(arXML)
+	if err != nil {
+		return err
+	}
+	result.ApplicationResponseXML = arXML
+	result.ApplicationResponse = ar
+
+	// La respuesta debe ser la del documento enviado antes de adjuntarla
+	if uuid := ar.DocumentReference.UUID; uuid != result.UUID {
+		return fmt.Errorf("%w: ApplicationResponse references %q, expected %q", ErrResponseMismatch, uuid, result.UUID)
+	}
+
+	if !ar.IsValidated() {
+		// Persistir la respuesta antes de reportar el rechazo
+		if p.config.Hooks.AfterStage != nil {
+			if err := p.config.Hooks.AfterStage(StageResponse, result); err != nil {
+				return err
+			}
+		}
+		return fmt.Errorf("%w: ResponseCode %s: %v", ErrRejected, ar.ResponseCode, resp.ErrorMessages)
+	}
+	return nil
+}
+
+// attach arma y firma el AttachedDocument para el adquiriente
+func (p *Pipeline) attach(doc *Document, result *Result) error {
+	info := documentTypes[doc.Type]
+	ar := result.ApplicationResponse
+
+	issueDate, err := time.Parse("2006-01-02", doc.IssueDate)
+	if err != nil {
+		return fmt.Errorf("invalid issue date %q: %w", doc.IssueDate, err)
+	}
+	validationDate, validationTime := ar.IssueDate.Format("2006-01-02"), ar.IssueTime
+	if v := ar.DocumentReference.ValidationResult; v != nil {
+		validationDate, validationTime = v.ValidationDate.Format("2006-01-02"), v.ValidationTime
+	}
+
+	builder := attached.NewBuilder().
+		SetProfileExecutionID(doc.Environment).
+		SetID(result.UUID).
+		SetIssueDate(p.config.Now()).
+		SetParentDocumentID(doc.Number).
+		SetSender(doc.Sender).
+		SetReceiver(doc.Receiver).
+		SetSignedInvoiceXML(string(result.SignedXML)).
+		SetApplicationResponse(attached.ApplicationResponseData{
+			InvoiceID:            doc.Number,
+			CUFE:                 result.UUID,
+			IssueDate:            issueDate,
+			ResponseXML:          string(result.ApplicationResponseXML),
+			ValidationResultCode: ar.ResponseCode,
+			ValidationDate:       validationDate,
+			ValidationTime:       validationTime,
+		})
+
+	model := builder.Build()
+	model.ProfileID.Value = info.profile
+	model.ParentDocumentLineReference.DocumentReference.UUID.SchemeName = info.scheme
+
+	xmlData, err := builder.ToXML()
+	if err != nil {
+		return fmt.Errorf("failed to build AttachedDocument: %w", err)
+	}
+	signed, err := p.config.Signer.SignXML(xmlData)
+	if err != nil {
+		return fmt.Errorf("failed to sign AttachedDocument: %w", err)
+	}
+	result.AttachedDocument = signed
+	return nil
+}
+
+// render genera el PDF con Config.Renderer (opcional)
+func (p *Pipeline) render(result *Result) error {
+	if p.config.Renderer == nil {
+		return nil
+	}
+	pdf, err := p.config.Renderer.Render(result)
+	if err != nil {
+		return err
+	}
+	result.PDF = pdf
+	return nil
+}
+
+// fileNamePattern fileName del body de SendBillSync
+var fileNamePattern = regexp.MustCompile(`<(?:[\w.-]+:)?fileName>([^<]+)</`)
+
+// capture interceptor que guarda el request y la respuesta SOAP de
+// SendBillSync por nombre de ZIP (la respuesta también si hubo fault); los
+// envíos que no hizo send pasan sin guardarse
+func (p *Pipeline) capture(next soap.RoundTrip) soap.RoundTrip {
+	return func(ex *soap.Exchange) error {
+		if ex.Operation != "SendBillSync" {
+			return next(ex)
+		}
+		m := fileNamePattern.FindSubmatch(ex.Request)
+		if m == nil {
+			return next(ex)
+		}
+		p.mu.Lock()
+		captured := p.exchanges[string(m[1])]
+		if captured != nil {
+			captured.request = ex.Request
+		}
+		p.mu.Unlock()
+		if captured == nil {
+			return next(ex)
+		}
+
+		err := next(ex)
+		p.mu.Lock()
+		captured.response = ex.Response
+		p.mu.Unlock()
+		return err
+	}
+}
@@ -0,0 +1,247 @@
+package pipeline_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/documents/applicationresponse"
+	"github.com/diegofxm/ubl21-dian/documents/creditnote"
+	"github.com/diegofxm/ubl21-dian/internal/testutil"
+	"github.com/diegofxm/ubl21-dian/naming"
+	"github.com/diegofxm/ubl21-dian/pipeline"
+	"github.com/diegofxm/ubl21-dian/signature"
+	"github.com/diegofxm/ubl21-dian/soap"
+	"github.com/diegofxm/ubl21-dian/soap/simulator"
+	"github.com/diegofxm/ubl21-dian/soap/types"
+	xmlpkg "github.com/diegofxm/ubl21-dian/xml"
+)
+
+// pdf Renderer de prueba
+type pdf struct{}
+
+func (pdf) Render(result *pipeline.Result) ([]byte, error) {
+	return []byte("%PDF " + result.Number), nil
+}
+
+func setup(t *testing.T, hooks pipeline.Hooks) (*simulator.Simulator, *pipeline.Pipeline) {
+	t.Helper()
+	env := testutil.NewEnv(t)
+	counter := naming.NewCounter(naming.NewMemoryStore(), testutil.SupplierNIT, "")
+	counter.SetClock(func() time.Time { return time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC) })
+	return env.Simulator, env.Pipeline(t, pipeline.Config{Counter: counter, Renderer: pdf{}, Hooks: hooks})
+}
+
+func TestIssueInvoice(t *testing.T) {
+	var stages []pipeline.Stage
+	sim, p := setup(t, pipeline.Hooks{
+		AfterStage: func(stage pipeline.Stage, result *pipeline.Result) error {
+			stages = append(stages, stage)
+			return nil
+		},
+	})
+
+	doc, err := pipeline.FromInvoice(testutil.NewInvoice("SETP990000001"), testutil.TechnicalKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Amounts.IVA != 19000 || doc.Amounts.Payable != 119000 {
+		t.Errorf("Unexpected amounts: %+v", doc.Amounts)
+	}
+
+	result, err := p.Issue(doc)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+
+	if len(stages) != len(pipeline.Stages) {
+		t.Errorf("Expected hooks for every stage, got %v", stages)
+	}
+	wantCUFE := signature.CalculateCUFE("SETP990000001", time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), "10:00:00-05:00",
+		100000, 19000, 0, 0, 119000, "900123456", "800111222", testutil.TechnicalKey, "2")
+	if result.UUID != wantCUFE || !bytes.Contains(result.UnsignedXML, []byte(wantCUFE)) {
+		t.Errorf("CUFE not calculated into the document")
+	}
+	if doc, ok := sim.Document(result.UUID); !ok || !doc.Signed {
+		t.Errorf("Expected signed invoice in simulator, got %+v", doc)
+	}
+	if !result.ApplicationResponse.IsValidated() {
+		t.Errorf("Expected validated ApplicationResponse, got %+v", result.ApplicationResponse)
+	}
+
+	// AttachedDocument firmado con la factura y el ApplicationResponse
+	var ad struct {
+		ParentDocumentID string `xml:"cbc:ParentDocumentID"`
+		UUID             string `xml:"cac:ParentDocumentLineReference>cac:DocumentReference>cbc:UUID"`
+	}
+	if err := xmlpkg.Unmarshal(result.AttachedDocument, &ad); err != nil {
+		t.Fatal(err)
+	}
+	if ad.ParentDocumentID != "SETP990000001" || ad.UUID != wantCUFE {
+		t.Errorf("Unexpected AttachedDocument: %+v", ad)
+	}
+	if !bytes.Contains(result.AttachedDocument, []byte("<ds:SignatureValue")) {
+		t.Error("Expected signed AttachedDocument")
+	}
+
+	want := []string{
+		"FE-SETP990000001.xml",
+		"FES-SETP990000001.xml",
+		"z09001234560002500000001.zip",
+		"ReqFE-SETP990000001.xml",
+		"RespFE-SETP990000001.xml",
+		"RptaFE-SETP990000001.xml",
+		"ad09001234560002500000001.xml",
+		"FES-SETP990000001.pdf",
+	}
+	artifacts := result.Artifacts()
+	if len(artifacts) != len(want) {
+		t.Fatalf("Expected %d artifacts, got %d", len(want), len(artifacts))
+	}
+	for i, a := range artifacts {
+		if a.FileName != want[i] {
+			t.Errorf("Artifact %d: expected %s, got %s", i, want[i], a.FileName)
+		}
+	}
+	if !strings.Contains(string(result.Request), "z09001234560002500000001.zip") {
+		t.Error("Expected captured SOAP request")
+	}
+	if !strings.Contains(string(result.SOAPResponse), "SendBillSyncResponse") {
+		t.Error("Expected captured SOAP response")
+	}
+}
+
+func TestIssueRejected(t *testing.T) {
+	var persisted *pipeline.Result
+	var failed pipeline.Stage
+	sim, p := setup(t, pipeline.Hooks{
+		AfterStage: func(stage pipeline.Stage, result *pipeline.Result) error {
+			persisted = result
+			return nil
+		},
+		OnError: func(stage pipeline.Stage, result *pipeline.Result, err error) error {
+			failed = stage
+			return err
+		},
+	})
+	sim.Enqueue(soap.ActionSendBillSync, simulator.Outcome{Rejections: []string{"Regla: FAD06, Rechazo: Valor del CUFE no está calculado correctamente."}})
+
+	doc, err := pipeline.FromInvoice(testutil.NewInvoice("SETP990000002"), testutil.TechnicalKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := p.Issue(doc)
+	if !errors.Is(err, pipeline.ErrRejected) {
+		t.Fatalf("Expected ErrRejected, got %v", err)
+	}
+	var stageErr *pipeline.StageError
+	if !errors.As(err, &stageErr) || stageErr.Stage != pipeline.StageResponse || failed != pipeline.StageResponse {
+		t.Errorf("Expected failure at response stage, got %v (hook %s)", err, failed)
+	}
+	if result.AttachedDocument != nil || persisted == nil || persisted.ApplicationResponseXML == nil || persisted.SOAPResponse == nil {
+		t.Error("Expected persisted SOAP response and ApplicationResponse and no AttachedDocument")
+	}
+}
+
+// otherDocument Sender que retorna el ApplicationResponse de otro documento
+type otherDocument struct{ pipeline.Sender }
+
+func (s otherDocument) SendBillSync(req *types.SendBillSyncRequest) (*types.SendBillSyncResponse, error) {
+	resp, err := s.Sender.SendBillSync(req)
+	if err != nil {
+		return nil, err
+	}
+	arXML, err := base64.StdEncoding.DecodeString(resp.XmlBase64Bytes)
+	if err != nil {
+		return nil, err
+	}
+	ar, err := applicationresponse.ParseFromXML(arXML)
+	if err != nil {
+		return nil, err
+	}
+	arXML = bytes.ReplaceAll(arXML, []byte(ar.DocumentReference.UUID), []byte("0f0e0d0c"))
+	resp.XmlBase64Bytes = base64.StdEncoding.EncodeToString(arXML)
+	return resp, nil
+}
+
+func TestIssueResponseMismatch(t *testing.T) {
+	env := testutil.NewEnv(t)
+	p := env.Pipeline(t, pipeline.Config{Sender: otherDocument{env.Client}})
+
+	doc, err := pipeline.FromInvoice(testutil.NewInvoice("SETP990000003"), testutil.TechnicalKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := p.Issue(doc)
+	var stageErr *pipeline.StageError
+	if !errors.Is(err, pipeline.ErrResponseMismatch) || !errors.As(err, &stageErr) || stageErr.Stage != pipeline.StageResponse {
+		t.Fatalf("Expected ErrResponseMismatch at the response stage, got %v", err)
+	}
+	if result.AttachedDocument != nil {
+		t.Error("Expected no AttachedDocument with another document's ApplicationResponse")
+	}
+}
+
+func TestIssueEnvironmentMismatch(t *testing.T) {
+	env := testutil.NewEnv(t)
+	client, err := soap.NewClient(&types.Config{
+		Certificate: env.Credentials.CertPath,
+		PrivateKey:  env.Credentials.KeyPath,
+		Environment: types.Produccion,
+		Endpoint:    env.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := env.Pipeline(t, pipeline.Config{Sender: client})
+
+	doc, err := pipeline.FromInvoice(testutil.NewInvoice("SETP990000004"), testutil.TechnicalKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.Issue(doc)
+	var stageErr *pipeline.StageError
+	if !errors.Is(err, pipeline.ErrEnvironmentMismatch) || !errors.As(err, &stageErr) || stageErr.Stage != pipeline.StageBuild {
+		t.Fatalf("Expected ErrEnvironmentMismatch at the build stage, got %v", err)
+	}
+	if got := env.Simulator.Requests(); len(got) != 0 {
+		t.Errorf("Expected nothing sent to DIAN, got %d requests", len(got))
+	}
+}
+
+func TestIssueCreditNote(t *testing.T) {
+	_, p := setup(t, pipeline.Hooks{})
+
+	supplier := testutil.Party("MI EMPRESA SAS", "900123456")
+	customer := testutil.Party("CLIENTE SAS", "800111222")
+	b := creditnote.NewBuilder().
+		SetProfileExecutionID("2").
+		SetCreditNoteData("NC1", "", "2025-06-02", "10:00:00-05:00").
+		SetNote("Anulación").
+		SetDianExtensions("18760000001", "2019-01-19", "2030-01-19", "NC", "1", "1000",
+			"900123456", "8", "31", "56f2ae4e-9812-4fad-9255-08fcfcd5ccb0", "", "").
+		SetBillingReference("SETP990000001", "abc", "2025-06-01").
+		SetSupplier(creditnote.PartyTemplateData{AdditionalAccountID: "1", PartyName: supplier.PartyName, TaxScheme: creditnote.TaxSchemeTemplateData(supplier.TaxScheme)}).
+		SetCustomer(creditnote.PartyTemplateData{AdditionalAccountID: "1", PartyName: customer.PartyName, TaxScheme: creditnote.TaxSchemeTemplateData(customer.TaxScheme)}).
+		SetTotals("100000.00", "100000.00", "100000.00", "100000.00").
+		AddLine(creditnote.CreditNoteLineTemplateData{
+			ID: "1", UnitCode: "94", Quantity: "1.000000", LineExtensionAmount: "100000.00", FreeOfChargeIndicator: "false",
+			Item:  creditnote.ItemTemplateData{Description: "Servicio", StandardItemID: creditnote.ItemIDTemplateData{ID: "P001", SchemeID: "999"}},
+			Price: creditnote.PriceTemplateData{Amount: "100000.00", BaseQuantity: "1.000000"},
+		})
+
+	doc, err := pipeline.FromCreditNote(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := p.Issue(doc)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	if result.Name.Kind != naming.KindCreditNote || !bytes.Contains(result.AttachedDocument, []byte(`schemeName="CUDE-SHA384"`)) {
+		t.Errorf("Unexpected credit note result: %s", result.Name)
+	}
+}
@@ -0,0 +1,110 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/diegofxm/ubl21-dian/documents/applicationresponse"
+	"github.com/diegofxm/ubl21-dian/naming"
+	"github.com/diegofxm/ubl21-dian/soap/types"
+)
+
+// Stage etapa del pipeline
+type Stage string
+
+const (
+	StageBuild    Stage = "build"    // XML sin firmar con CUFE/CUDE/CUDS, código de seguridad y QR
+	StageSign     Stage = "sign"     // Firma XAdES del documento
+	StagePackage  Stage = "package"  // Nombre DIAN y ZIP
+	StageSend     Stage = "send"     // SendBillSync
+	StageReply    Stage = "reply"    // Respuesta SOAP de SendBillSync (artefacto de StageSend, sin hook propio)
+	StageResponse Stage = "response" // ApplicationResponse de DIAN
+	StageAttached Stage = "attached" // AttachedDocument firmado
+	StageRender   Stage = "render"   // Representación gráfica (si hay Renderer)
+)
+
+// Stages etapas en orden de ejecución
+var Stages = []Stage{StageBuild, StageSign, StagePackage, StageSend, StageResponse, StageAttached, StageRender}
+
+// ArtifactStages etapas de los artefactos en el orden de Result.Artifacts
+var ArtifactStages = []Stage{StageBuild, StageSign, StagePackage, StageSend, StageReply, StageResponse, StageAttached, StageRender}
+
+var (
+	// ErrConfig configuración del pipeline incompleta
+	ErrConfig = errors.New("invalid pipeline configuration")
+	// ErrRejected DIAN no validó el documento (ResponseCode distinto de 02)
+	ErrRejected = errors.New("document rejected by DIAN")
+	// ErrResponseMismatch el ApplicationResponse es de otro documento
+	ErrResponseMismatch = errors.New("ApplicationResponse does not reference the sent document")
+	// ErrEnvironmentMismatch el documento es de otro ambiente que el Sender
+	ErrEnvironmentMismatch = errors.New("document environment does not match the Sender")
+)
+
+// StageError error de una etapa; Result conserva los artefactos previos
+type StageError struct {
+	Stage Stage
+	Err   error
+}
+
+func (e *StageError) Error() string {
+	return fmt.Sprintf("pipeline %s: %v", e.Stage, e.Err)
+}
+
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// Result artefactos generados al emitir un documento
+//
+// Corresponden a los siete archivos de markdown/FLUJO_COMPLETO_DIAN.md más la
+// respuesta SOAP de SendBillSync; los de etapas no ejecutadas quedan vacíos.
+type Result struct {
+	Type   DocumentType
+	Number string
+	UUID   string      // CUFE, CUDE o CUDS
+	Name   naming.Name // Nombre DIAN del XML firmado
+
+	UnsignedXML            []byte                      // 1. FE-{numero}.xml
+	SignedXML              []byte                      // 2. FES-{numero}.xml
+	Package                *naming.Package             // 3. ZIP enviado a DIAN
+	Request                []byte                      // 4. ReqFE-{numero}.xml (si el Sender admite interceptores)
+	SOAPResponse           []byte                      // RespFE-{numero}.xml, respuesta SOAP tal como llegó (ídem)
+	Response               *types.SendBillSyncResponse // Respuesta de SendBillSync
+	ApplicationResponseXML []byte                      // 5. RptaFE-{numero}.xml
+	ApplicationResponse    *applicationresponse.ApplicationResponseData
+	AttachedDocument       []byte // 7. AttachedDocument firmado
+	PDF                    []byte // 6. FES-{numero}.pdf (si hay Renderer)
+}
+
+// Artifact archivo generado por el pipeline
+type Artifact struct {
+	Stage    Stage
+	FileName string
+	Data     []byte
+}
+
+// Artifacts archivos generados hasta ahora con sus nombres sugeridos
+func (r *Result) Artifacts() []Artifact {
+	prefix := documentTypes[r.Type].prefix
+	number := strings.ToUpper(r.Number)
+
+	var list []Artifact
+	add := func(stage Stage, fileName string, data []byte) {
+		if len(data) > 0 {
+			list = append(list, Artifact{Stage: stage, FileName: fileName, Data: data})
+		}
+	}
+
+	add(StageBuild, prefix+"-"+number+".xml", r.UnsignedXML)
+	add(StageSign, prefix+"S-"+number+".xml", r.SignedXML)
+	if r.Package != nil {
+		add(StagePackage, r.Package.FileName, r.Package.Content)
+	}
+	add(StageSend, "Req"+prefix+"-"+number+".xml", r.Request)
+	add(StageReply, "Resp"+prefix+"-"+number+".xml", r.SOAPResponse)
+	add(StageResponse, "Rpta"+prefix+"-"+number+".xml", r.ApplicationResponseXML)
+	add(StageAttached, r.Name.WithKind(naming.KindAttachedDocument).String(), r.AttachedDocument)
+	add(StageRender, prefix+"S-"+number+".pdf", r.PDF)
+	return list
+}
@@ -0,0 +1,124 @@
+package qr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Document tipo de documento del contenido del QR
+type Document string
+
+const (
+	Invoice         Document = "Invoice"
+	CreditNote      Document = "CreditNote"
+	DebitNote       Document = "DebitNote"
+	SupportDocument Document = "SupportDocument"
+	POS             Document = "POS"     // Documento equivalente electrónico tiquete POS
+	Payroll         Document = "Payroll" // Nómina individual y de ajuste
+)
+
+// Payload datos del QR de la representación gráfica según el Anexo Técnico
+//
+// Las etiquetas dependen del tipo de documento (NumFac, NumDS, NumNIE, ...):
+//
+//	text := qr.Payload{Document: qr.Invoice, Number: "SETP990000001", ...}.String()
+//	code, err := qr.Encode(text, qr.M)
+type Payload struct {
+	Document    Document
+	Number      string  // NumFac: prefijo y consecutivo
+	IssueDate   string  // FecFac: AAAA-MM-DD
+	IssueTime   string  // HorFac: HH:MM:SS-05:00
+	SupplierID  string  // NitFac: NIT del facturador (vendedor no obligado en documento soporte, empleador en nómina)
+	CustomerID  string  // DocAdq: documento del adquiriente (obligado en documento soporte, empleado en nómina)
+	Subtotal    float64 // ValFac: valor antes de impuestos
+	IVA         float64 // ValIva
+	OtherTaxes  float64 // ValOtroIm: INC, ICA y demás impuestos
+	Total       float64 // ValTolFac: valor total
+	Accrued     float64 // ValDev: devengados (nómina)
+	Deductions  float64 // ValDed: deducciones (nómina)
+	UUID        string  // CUFE, CUDE, CUDS o CUNE
+	Environment string  // "1" = Producción, "2" = Habilitación
+}
+
+// field etiqueta y valor de una línea del QR
+type field struct {
+	label, value string
+}
+
+// String contenido del QR: una línea "Etiqueta: valor" por campo y al final
+// el enlace de consulta
+func (p Payload) String() string {
+	var fields []field
+	switch p.Document {
+	case SupportDocument:
+		fields = []field{
+			{"NumDS", p.Number},
+			{"FecDS", p.IssueDate},
+			{"HorDS", p.IssueTime},
+			{"NumSNO", p.SupplierID},
+			{"DocABS", p.CustomerID},
+			{"ValDS", amount(p.Subtotal)},
+			{"ValIva", amount(p.IVA)},
+			{"ValTolDS", amount(p.Total)},
+			{"CUDS", p.UUID},
+		}
+	case Payroll:
+		fields = []field{
+			{"NumNIE", p.Number},
+			{"FecNIE", p.IssueDate},
+			{"HorNIE", p.IssueTime},
+			{"NitNIE", p.SupplierID},
+			{"DocEmp", p.CustomerID},
+			{"ValDev", amount(p.Accrued)},
+			{"ValDed", amount(p.Deductions)},
+			{"ValTol", amount(p.Total)},
+			{"CUNE", p.UUID},
+		}
+	default:
+		fields = []field{
+			{"NumFac", p.Number},
+			{"FecFac", p.IssueDate},
+			{"HorFac", p.IssueTime},
+			{"NitFac", p.SupplierID},
+			{"DocAdq", p.CustomerID},
+			{"ValFac", amount(p.Subtotal)},
+			{"ValIva", amount(p.IVA)},
+			{"ValOtroIm", amount(p.OtherTaxes)},
+			{"ValTolFac", amount(p.Total)},
+			{p.uuidLabel(), p.UUID},
+		}
+	}
+
+	var b strings.Builder
+	for _, f := range fields {
+		fmt.Fprintf(&b, "%s: %s\n", f.label, f.value)
+	}
+	b.WriteString("QRCode: " + SearchURL(p.UUID, p.Environment))
+	return b.String()
+}
+
+// Encode codifica el contenido con nivel de corrección M
+func (p Payload) Encode() (*Code, error) {
+	return Encode(p.String(), M)
+}
+
+// uuidLabel CUFE para facturas, CUDE para notas y documentos equivalentes
+func (p Payload) uuidLabel() string {
+	if p.Document == Invoice || p.Document == "" {
+		return "CUFE"
+	}
+	return "CUDE"
+}
+
+// SearchURL enlace de consulta del documento en el catálogo de DIAN
+func SearchURL(uuid, environment string) string {
+	host := "catalogo-vpfe.dian.gov.co"
+	if environment == "2" {
+		host = "catalogo-vpfe-hab.dian.gov.co"
+	}
+	return "https://" + host + "/document/searchqr?documentkey=" + uuid
+}
+
+func amount(v float64) string {
+	return fmt.Sprintf("%.2f", v)
+}
@@ -0,0 +1,109 @@
+package qr_test
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/diegofxm/ubl21-dian/qr"
+)
+
+func TestEncode(t *testing.T) {
+	code, err := qr.Encode("https://catalogo-vpfe.dian.gov.co/document/searchqr?documentkey=abc", qr.M)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code.Version != 5 || code.Size != 37 {
+		t.Errorf("versión = %d, tamaño = %d", code.Version, code.Size)
+	}
+
+	// Patrón de búsqueda en la esquina superior izquierda
+	for i := 0; i < 7; i++ {
+		if !code.Dark(i, 0) || !code.Dark(0, i) || !code.Dark(i, 6) || !code.Dark(6, i) {
+			t.Fatalf("borde del patrón de búsqueda en %d", i)
+		}
+	}
+	if code.Dark(1, 1) || !code.Dark(3, 3) || code.Dark(7, 7) {
+		t.Error("patrón de búsqueda inválido")
+	}
+	if code.Dark(-1, 0) || code.Dark(code.Size, 0) {
+		t.Error("los módulos fuera del código deben ser claros")
+	}
+
+	if _, err := qr.Encode(strings.Repeat("x", 3000), qr.H); !errors.Is(err, qr.ErrTooLong) {
+		t.Errorf("Encode(3000 bytes) = %v, se esperaba ErrTooLong", err)
+	}
+}
+
+func TestPayload(t *testing.T) {
+	p := qr.Payload{
+		Document: qr.Invoice, Number: "SETP990000002", IssueDate: "2019-06-21", IssueTime: "07:46:15-05:00",
+		SupplierID: "700085371", CustomerID: "800199436",
+		Subtotal: 1500000, IVA: 285000, Total: 1785000, UUID: "941cf36af6", Environment: "1",
+	}
+	want := "NumFac: SETP990000002\nFecFac: 2019-06-21\nHorFac: 07:46:15-05:00\nNitFac: 700085371\nDocAdq: 800199436\n" +
+		"ValFac: 1500000.00\nValIva: 285000.00\nValOtroIm: 0.00\nValTolFac: 1785000.00\nCUFE: 941cf36af6\n" +
+		"QRCode: https://catalogo-vpfe.dian.gov.co/document/searchqr?documentkey=941cf36af6"
+	if got := p.String(); got != want {
+		t.Errorf("String() =\n%s\nse esperaba\n%s", got, want)
+	}
+
+	tests := []struct {
+		document qr.Document
+		contains []string
+	}{
+		{qr.CreditNote, []string{"NumFac: ", "CUDE: 941cf36af6"}},
+		{qr.POS, []string{"ValOtroIm: ", "CUDE: "}},
+		{qr.SupportDocument, []string{"NumDS: SETP990000002", "NumSNO: 700085371", "DocABS: 800199436", "ValTolDS: 1785000.00", "CUDS: "}},
+		{qr.Payroll, []string{"NumNIE: ", "NitNIE: 700085371", "DocEmp: 800199436", "ValDev: 0.00", "CUNE: "}},
+	}
+	for _, tt := range tests {
+		p.Document = tt.document
+		got := p.String()
+		for _, s := range tt.contains {
+			if !strings.Contains(got, s) {
+				t.Errorf("%s: falta %q en\n%s", tt.document, s, got)
+			}
+		}
+	}
+
+	p.Environment = "2"
+	if !strings.HasSuffix(p.String(), "https://catalogo-vpfe-hab.dian.gov.co/document/searchqr?documentkey=941cf36af6") {
+		t.Error("el enlace de habilitación no corresponde")
+	}
+}
+
+func TestImages(t *testing.T) {
+	code, err := qr.Payload{Document: qr.Invoice, Number: "SETP1", UUID: "abc"}.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := code.PNG(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	side := (code.Size + 8) * 2
+	if b := img.Bounds(); b.Dx() != side || b.Dy() != side {
+		t.Errorf("tamaño = %v, se esperaba %d", b, side)
+	}
+	// Zona de silencio clara y esquina del patrón de búsqueda oscura
+	if r, _, _, _ := img.At(0, 0).RGBA(); r == 0 {
+		t.Error("la zona de silencio debe ser clara")
+	}
+	if r, _, _, _ := img.At(8, 8).RGBA(); r != 0 {
+		t.Error("el patrón de búsqueda debe ser oscuro")
+	}
+
+	svg := string(code.SVG(3))
+	if !strings.HasPrefix(svg, "<svg ") || !strings.Contains(svg, `viewBox="0 0 `+strconv.Itoa((code.Size+8)*3)) || !strings.Contains(svg, "M12 12h21v3h-21z") {
+		t.Errorf("SVG inválido: %.200s", svg)
+	}
+}
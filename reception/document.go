@@ -0,0 +1,363 @@
+package reception
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/soap/rules"
+	xmlpkg "github.com/diegofxm/ubl21-dian/xml"
+)
+
+// Document factura o nota recibida, con montos numéricos para contabilidad
+type Document struct {
+	Type        rules.Document // Invoice, CreditNote o DebitNote
+	TypeCode    string         // InvoiceTypeCode, CreditNoteTypeCode o DebitNoteTypeCode
+	Number      string
+	UUID        string // CUFE o CUDE
+	UUIDScheme  string // "CUFE-SHA384", "CUDE-SHA384"
+	Environment string // "1" = Producción, "2" = Habilitación
+	IssueDate   time.Time
+	IssueTime   string
+	DueDate     time.Time // Zero si no viene
+	Currency    string
+	Notes       []string
+
+	Supplier Party // Facturador (proveedor)
+	Customer Party // Adquiriente (nosotros)
+
+	PaymentMeans []PaymentMeans
+	References   []Reference // Facturas referenciadas por las notas
+
+	Lines        []Line
+	Taxes        []Tax
+	Withholdings []Tax
+	Totals       Totals
+}
+
+// Party emisor o adquiriente
+type Party struct {
+	Name           string
+	CommercialName string // PartyName; vacío si no viene
+	IDType         string // Código de tipo de documento (31 = NIT)
+	ID             string
+	DV             string
+	TaxLevelCodes  string // Responsabilidades fiscales separadas por ";"
+	TaxScheme      string // Tributo (01 = IVA, ZZ = No aplica)
+	TaxSchemeName  string
+	Email          string
+}
+
+// PaymentMeans forma (ID: 1 = contado, 2 = crédito) y medio de pago
+type PaymentMeans struct {
+	ID      string
+	Code    string
+	DueDate time.Time
+}
+
+// Reference documento referenciado
+type Reference struct {
+	Number    string
+	UUID      string
+	IssueDate time.Time
+}
+
+// Line línea del documento
+type Line struct {
+	ID          string
+	Code        string
+	Description string
+	Quantity    float64
+	UnitCode    string
+	UnitPrice   float64
+	Total       float64 // LineExtensionAmount
+	Taxes       []Tax
+}
+
+// Tax subtotal de un tributo o retención
+type Tax struct {
+	SchemeID string
+	Name     string
+	Percent  float64
+	Base     float64
+	Amount   float64
+}
+
+// Totals totales monetarios
+type Totals struct {
+	LineExtension float64
+	TaxExclusive  float64
+	TaxInclusive  float64
+	Allowance     float64
+	Charge        float64
+	Prepaid       float64
+	Payable       float64
+}
+
+type xmlScheme struct {
+	Value      string `xml:",chardata"`
+	SchemeID   string `xml:"schemeID,attr"`
+	SchemeName string `xml:"schemeName,attr"`
+}
+
+type xmlParty struct {
+	Name             string    `xml:"cac:Party>cac:PartyName>cbc:Name"`
+	RegistrationName string    `xml:"cac:Party>cac:PartyTaxScheme>cbc:RegistrationName"`
+	CompanyID        xmlScheme `xml:"cac:Party>cac:PartyTaxScheme>cbc:CompanyID"`
+	TaxLevelCode     string    `xml:"cac:Party>cac:PartyTaxScheme>cbc:TaxLevelCode"`
+	TaxScheme        string    `xml:"cac:Party>cac:PartyTaxScheme>cac:TaxScheme>cbc:ID"`
+	TaxSchemeName    string    `xml:"cac:Party>cac:PartyTaxScheme>cac:TaxScheme>cbc:Name"`
+	LegalName        string    `xml:"cac:Party>cac:PartyLegalEntity>cbc:RegistrationName"`
+	Email            string    `xml:"cac:Party>cac:Contact>cbc:ElectronicMail"`
+}
+
+type xmlSubtotal struct {
+	TaxableAmount string `xml:"cbc:TaxableAmount"`
+	TaxAmount     string `xml:"cbc:TaxAmount"`
+	Percent       string `xml:"cac:TaxCategory>cbc:Percent"`
+	SchemeID      string `xml:"cac:TaxCategory>cac:TaxScheme>cbc:ID"`
+	SchemeName    string `xml:"cac:TaxCategory>cac:TaxScheme>cbc:Name"`
+}
+
+type xmlTaxTotal struct {
+	Subtotals []xmlSubtotal `xml:"cac:TaxSubtotal"`
+}
+
+type xmlQuantity struct {
+	Value    string `xml:",chardata"`
+	UnitCode string `xml:"unitCode,attr"`
+}
+
+type xmlLine struct {
+	ID                  string        `xml:"cbc:ID"`
+	InvoicedQuantity    *xmlQuantity  `xml:"cbc:InvoicedQuantity"`
+	CreditedQuantity    *xmlQuantity  `xml:"cbc:CreditedQuantity"`
+	DebitedQuantity     *xmlQuantity  `xml:"cbc:DebitedQuantity"`
+	LineExtensionAmount string        `xml:"cbc:LineExtensionAmount"`
+	TaxTotals           []xmlTaxTotal `xml:"cac:TaxTotal"`
+	Description         string        `xml:"cac:Item>cbc:Description"`
+	StandardID          string        `xml:"cac:Item>cac:StandardItemIdentification>cbc:ID"`
+	SellersID           string        `xml:"cac:Item>cac:SellersItemIdentification>cbc:ID"`
+	PriceAmount         string        `xml:"cac:Price>cbc:PriceAmount"`
+}
+
+type xmlTotals struct {
+	LineExtensionAmount  string `xml:"cbc:LineExtensionAmount"`
+	TaxExclusiveAmount   string `xml:"cbc:TaxExclusiveAmount"`
+	TaxInclusiveAmount   string `xml:"cbc:TaxInclusiveAmount"`
+	AllowanceTotalAmount string `xml:"cbc:AllowanceTotalAmount"`
+	ChargeTotalAmount    string `xml:"cbc:ChargeTotalAmount"`
+	PrepaidAmount        string `xml:"cbc:PrepaidAmount"`
+	PayableAmount        string `xml:"cbc:PayableAmount"`
+}
+
+// xmlDocument campos de factura y notas
+type xmlDocument struct {
+	XMLName            xml.Name
+	ProfileExecutionID string    `xml:"cbc:ProfileExecutionID"`
+	ID                 string    `xml:"cbc:ID"`
+	UUID               xmlScheme `xml:"cbc:UUID"`
+	IssueDate          string    `xml:"cbc:IssueDate"`
+	IssueTime          string    `xml:"cbc:IssueTime"`
+	DueDate            string    `xml:"cbc:DueDate"`
+	InvoiceTypeCode    string    `xml:"cbc:InvoiceTypeCode"`
+	CreditNoteTypeCode string    `xml:"cbc:CreditNoteTypeCode"`
+	DebitNoteTypeCode  string    `xml:"cbc:DebitNoteTypeCode"`
+	Notes              []string  `xml:"cbc:Note"`
+	CurrencyCode       string    `xml:"cbc:DocumentCurrencyCode"`
+	References         []struct {
+		ID        string `xml:"cbc:ID"`
+		UUID      string `xml:"cbc:UUID"`
+		IssueDate string `xml:"cbc:IssueDate"`
+	} `xml:"cac:BillingReference>cac:InvoiceDocumentReference"`
+	Supplier     xmlParty `xml:"cac:AccountingSupplierParty"`
+	Customer     xmlParty `xml:"cac:AccountingCustomerParty"`
+	PaymentMeans []struct {
+		ID      string `xml:"cbc:ID"`
+		Code    string `xml:"cbc:PaymentMeansCode"`
+		DueDate string `xml:"cbc:PaymentDueDate"`
+	} `xml:"cac:PaymentMeans"`
+	TaxTotals            []xmlTaxTotal `xml:"cac:TaxTotal"`
+	WithholdingTaxTotals []xmlTaxTotal `xml:"cac:WithholdingTaxTotal"`
+	LegalTotal           *xmlTotals    `xml:"cac:LegalMonetaryTotal"`
+	RequestedTotal       *xmlTotals    `xml:"cac:RequestedMonetaryTotal"`
+	InvoiceLines         []xmlLine     `xml:"cac:InvoiceLine"`
+	CreditNoteLines      []xmlLine     `xml:"cac:CreditNoteLine"`
+	DebitNoteLines       []xmlLine     `xml:"cac:DebitNoteLine"`
+}
+
+// ParseDocument lee una factura, nota crédito o nota débito (firmada o no)
+func ParseDocument(data []byte) (*Document, error) {
+	var x xmlDocument
+	if err := xmlpkg.Unmarshal(data, &x); err != nil {
+		return nil, fmt.Errorf("failed to parse document: %w", err)
+	}
+
+	var p parser
+	doc := &Document{
+		Number:      strings.TrimSpace(x.ID),
+		UUID:        strings.TrimSpace(x.UUID.Value),
+		UUIDScheme:  x.UUID.SchemeName,
+		Environment: x.UUID.SchemeID,
+		IssueDate:   p.date("IssueDate", x.IssueDate),
+		IssueTime:   strings.TrimSpace(x.IssueTime),
+		DueDate:     p.date("DueDate", x.DueDate),
+		Currency:    strings.TrimSpace(x.CurrencyCode),
+		Supplier:    x.Supplier.party(),
+		Customer:    x.Customer.party(),
+	}
+	if doc.Environment == "" {
+		doc.Environment = x.ProfileExecutionID
+	}
+	for _, note := range x.Notes {
+		if note = strings.TrimSpace(note); note != "" {
+			doc.Notes = append(doc.Notes, note)
+		}
+	}
+
+	lines := x.InvoiceLines
+	switch x.XMLName.Local {
+	case "Invoice":
+		doc.Type, doc.TypeCode = rules.Invoice, x.InvoiceTypeCode
+		if x.InvoiceTypeCode == "05" || strings.HasPrefix(x.UUID.SchemeName, "CUDS") {
+			return nil, fmt.Errorf("%w: support documents are issued by the buyer", ErrUnsupported)
+		}
+	case "CreditNote":
+		doc.Type, doc.TypeCode, lines = rules.CreditNote, x.CreditNoteTypeCode, x.CreditNoteLines
+	case "DebitNote":
+		doc.Type, doc.TypeCode, lines = rules.DebitNote, x.DebitNoteTypeCode, x.DebitNoteLines
+	default:
+		return nil, fmt.Errorf("%w: root element %q", ErrUnsupported, x.XMLName.Local)
+	}
+
+	for _, r := range x.References {
+		doc.References = append(doc.References, Reference{
+			Number:    strings.TrimSpace(r.ID),
+			UUID:      strings.TrimSpace(r.UUID),
+			IssueDate: p.date("BillingReference IssueDate", r.IssueDate),
+		})
+	}
+	for _, m := range x.PaymentMeans {
+		doc.PaymentMeans = append(doc.PaymentMeans, PaymentMeans{
+			ID:      strings.TrimSpace(m.ID),
+			Code:    strings.TrimSpace(m.Code),
+			DueDate: p.date("PaymentDueDate", m.DueDate),
+		})
+	}
+	doc.Taxes = p.taxes(x.TaxTotals)
+	doc.Withholdings = p.taxes(x.WithholdingTaxTotals)
+	for _, l := range lines {
+		doc.Lines = append(doc.Lines, p.line(l))
+	}
+
+	totals := x.LegalTotal
+	if x.RequestedTotal != nil {
+		totals = x.RequestedTotal
+	}
+	if totals != nil {
+		doc.Totals = Totals{
+			LineExtension: p.amount("LineExtensionAmount", totals.LineExtensionAmount),
+			TaxExclusive:  p.amount("TaxExclusiveAmount", totals.TaxExclusiveAmount),
+			TaxInclusive:  p.amount("TaxInclusiveAmount", totals.TaxInclusiveAmount),
+			Allowance:     p.amount("AllowanceTotalAmount", totals.AllowanceTotalAmount),
+			Charge:        p.amount("ChargeTotalAmount", totals.ChargeTotalAmount),
+			Prepaid:       p.amount("PrepaidAmount", totals.PrepaidAmount),
+			Payable:       p.amount("PayableAmount", totals.PayableAmount),
+		}
+	}
+
+	if p.err != nil {
+		return nil, fmt.Errorf("failed to parse document: %w", p.err)
+	}
+	return doc, nil
+}
+
+func (x xmlParty) party() Party {
+	name := strings.TrimSpace(x.RegistrationName)
+	if name == "" {
+		name = strings.TrimSpace(x.LegalName)
+	}
+	if name == "" {
+		name = strings.TrimSpace(x.Name)
+	}
+	return Party{
+		Name:           name,
+		CommercialName: strings.TrimSpace(x.Name),
+		IDType:         x.CompanyID.SchemeName,
+		ID:             strings.TrimSpace(x.CompanyID.Value),
+		DV:             x.CompanyID.SchemeID,
+		TaxLevelCodes:  strings.TrimSpace(x.TaxLevelCode),
+		TaxScheme:      strings.TrimSpace(x.TaxScheme),
+		TaxSchemeName:  strings.TrimSpace(x.TaxSchemeName),
+		Email:          strings.TrimSpace(x.Email),
+	}
+}
+
+// parser convierte montos y fechas; conserva el primer error
+type parser struct {
+	err error
+}
+
+func (p *parser) amount(field, s string) float64 {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil && p.err == nil {
+		p.err = fmt.Errorf("invalid %s %q: %w", field, s, err)
+	}
+	return v
+}
+
+func (p *parser) date(field, s string) time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil && p.err == nil {
+		p.err = fmt.Errorf("invalid %s %q: %w", field, s, err)
+	}
+	return t
+}
+
+func (p *parser) line(l xmlLine) Line {
+	line := Line{
+		ID:          strings.TrimSpace(l.ID),
+		Code:        strings.TrimSpace(l.StandardID),
+		Description: strings.TrimSpace(l.Description),
+		UnitPrice:   p.amount("PriceAmount", l.PriceAmount),
+		Total:       p.amount("LineExtensionAmount", l.LineExtensionAmount),
+		Taxes:       p.taxes(l.TaxTotals),
+	}
+	if line.Code == "" {
+		line.Code = strings.TrimSpace(l.SellersID)
+	}
+	for _, q := range []*xmlQuantity{l.InvoicedQuantity, l.CreditedQuantity, l.DebitedQuantity} {
+		if q != nil {
+			line.Quantity, line.UnitCode = p.amount("Quantity", q.Value), q.UnitCode
+			break
+		}
+	}
+	return line
+}
+
+// taxes subtotales de los totales de impuestos o retenciones
+func (p *parser) taxes(totals []xmlTaxTotal) []Tax {
+	var list []Tax
+	for _, total := range totals {
+		for _, s := range total.Subtotals {
+			list = append(list, Tax{
+				SchemeID: strings.TrimSpace(s.SchemeID),
+				Name:     strings.TrimSpace(s.SchemeName),
+				Percent:  p.amount("Percent", s.Percent),
+				Base:     p.amount("TaxableAmount", s.TaxableAmount),
+				Amount:   p.amount("TaxAmount", s.TaxAmount),
+			})
+		}
+	}
+	return list
+}
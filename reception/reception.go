@@ -0,0 +1,202 @@
+// Package reception recibe los AttachedDocument que envían los proveedores:
+// extrae la factura y el ApplicationResponse de DIAN, verifica sus firmas
+// XAdES y cruza CUFE y partes antes de entregarlos a contabilidad o de emitir
+// los eventos de acuse y recibo.
+package reception
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/documents/applicationresponse"
+	"github.com/diegofxm/ubl21-dian/documents/attached"
+	"github.com/diegofxm/ubl21-dian/identification"
+	"github.com/diegofxm/ubl21-dian/signature"
+)
+
+var (
+	ErrUnsupported       = errors.New("unsupported document")
+	ErrMissingDocument   = errors.New("AttachedDocument has no signed document")
+	ErrMissingResponse   = errors.New("AttachedDocument has no ApplicationResponse")
+	ErrReferenceMismatch = errors.New("AttachedDocument references a different document")
+	ErrPartyMismatch     = errors.New("AttachedDocument parties do not match the document")
+	ErrNotValidated      = errors.New("document was not validated by DIAN")
+	ErrNotAddressee      = errors.New("document is addressed to another customer")
+	ErrNotIssuedByDIAN   = errors.New("ApplicationResponse was not issued by DIAN")
+	ErrNoTrustAnchor     = errors.New("no trust anchor to validate the signing certificate")
+)
+
+// DIANNIT NIT de DIAN, emisor (SenderParty) del ApplicationResponse de validación
+const DIANNIT = "800197268"
+
+// Options opciones de recepción
+type Options struct {
+	Roots *x509.CertPool // Ancla de confianza del certificado del proveedor (obligatoria)
+	Now   time.Time      // Fecha de validez de los certificados (zero = SigningTime)
+
+	// DIANRoots ancla de confianza del certificado que firma el
+	// ApplicationResponse, obligatoria si viene firmado. Es distinta de Roots
+	// para que un proveedor no pueda firmar la validación de DIAN.
+	DIANRoots *x509.CertPool
+
+	// InsecureSkipChainVerify acepta firmas sin validar la cadena cuando Roots
+	// o DIANRoots son nil; solo para pruebas con certificados desechables
+	InsecureSkipChainVerify bool
+
+	// Receiver NIT del adquiriente esperado (el nuestro); vacío = no se valida
+	Receiver string
+
+	// AllowUnsignedResponse acepta un ApplicationResponse sin firma (simulador
+	// o contenedores armados a mano); si viene firmado se verifica igual
+	AllowUnsignedResponse bool
+}
+
+// Received documento recibido y verificado
+type Received struct {
+	Container *attached.AttachedDocumentData
+	Document  *Document
+	Response  *applicationresponse.ApplicationResponseData
+
+	DocumentXML []byte // Factura o nota firmada por el proveedor
+	ResponseXML []byte // ApplicationResponse de DIAN
+
+	DocumentSignature *signature.Verification
+	ResponseSignature *signature.Verification // nil si se aceptó sin firma
+}
+
+// Receive lee un AttachedDocument recibido
+//
+// Verifica la firma del documento y del ApplicationResponse, que DIAN lo haya
+// validado (ResponseCode 02), que la respuesta la emita DIAN (SenderParty
+// 800197268, firma contra DIANRoots) y que número, CUFE/CUDE y partes
+// coincidan entre el contenedor, el documento y la respuesta. La firma del propio contenedor
+// no se verifica: la del documento y la de DIAN son las que tienen valor legal.
+// Sin Roots (o DIANRoots para una respuesta firmada) retorna ErrNoTrustAnchor,
+// salvo InsecureSkipChainVerify.
+func Receive(data []byte, opts Options) (*Received, error) {
+	if opts.Roots == nil && !opts.InsecureSkipChainVerify {
+		return nil, fmt.Errorf("%w: Roots", ErrNoTrustAnchor)
+	}
+	container, err := attached.ParseFromXML(data)
+	if err != nil {
+		return nil, err
+	}
+	if container.SignedInvoiceXML == "" {
+		return nil, ErrMissingDocument
+	}
+	if container.ApplicationResponse.ResponseXML == "" {
+		return nil, ErrMissingResponse
+	}
+
+	r := &Received{
+		Container:   container,
+		DocumentXML: []byte(container.SignedInvoiceXML),
+		ResponseXML: []byte(container.ApplicationResponse.ResponseXML),
+	}
+	if r.DocumentSignature, err = signature.Verify(r.DocumentXML, signature.VerifyOptions{Roots: opts.Roots, Now: opts.Now}); err != nil {
+		return nil, fmt.Errorf("document signature: %w", err)
+	}
+	if r.Document, err = ParseDocument(r.DocumentXML); err != nil {
+		return nil, err
+	}
+
+	r.ResponseSignature, err = signature.Verify(r.ResponseXML, signature.VerifyOptions{Roots: opts.DIANRoots, Now: opts.Now})
+	if err != nil && !(opts.AllowUnsignedResponse && errors.Is(err, signature.ErrNotSigned)) {
+		return nil, fmt.Errorf("ApplicationResponse signature: %w", err)
+	}
+	if r.ResponseSignature != nil && opts.DIANRoots == nil && !opts.InsecureSkipChainVerify {
+		return nil, fmt.Errorf("%w: DIANRoots", ErrNoTrustAnchor)
+	}
+	if r.Response, err = applicationresponse.ParseFromXML(r.ResponseXML); err != nil {
+		return nil, err
+	}
+
+	if err := r.check(); err != nil {
+		return nil, err
+	}
+	if opts.Receiver != "" && !sameID(opts.Receiver, r.Document.Customer.ID) {
+		return nil, fmt.Errorf("%w: %s", ErrNotAddressee, r.Document.Customer.ID)
+	}
+	return r, nil
+}
+
+// check cruza el contenedor, el documento y el ApplicationResponse
+func (r *Received) check() error {
+	doc, ref := r.Document, r.Response.DocumentReference
+
+	if issuer := r.Response.SenderParty.CompanyID; !sameID(issuer, DIANNIT) {
+		return fmt.Errorf("%w: SenderParty %s", ErrNotIssuedByDIAN, issuer)
+	}
+	if !r.Response.IsValidated() {
+		return fmt.Errorf("%w: ResponseCode %s", ErrNotValidated, r.Response.ResponseCode)
+	}
+
+	if r.Container.ParentDocumentID != doc.Number {
+		return fmt.Errorf("%w: ParentDocumentID %s, document %s", ErrReferenceMismatch, r.Container.ParentDocumentID, doc.Number)
+	}
+	for _, uuid := range []struct{ source, value string }{
+		{"AttachedDocument", r.Container.ApplicationResponse.CUFE},
+		{"ApplicationResponse", ref.UUID},
+	} {
+		if uuid.value != doc.UUID {
+			return fmt.Errorf("%w: %s UUID %s, document %s", ErrReferenceMismatch, uuid.source, uuid.value, doc.UUID)
+		}
+	}
+	if ref.ID != "" && ref.ID != doc.Number {
+		return fmt.Errorf("%w: ApplicationResponse ID %s, document %s", ErrReferenceMismatch, ref.ID, doc.Number)
+	}
+
+	if !sameID(r.Container.Sender.CompanyID, doc.Supplier.ID) {
+		return fmt.Errorf("%w: sender %s, supplier %s", ErrPartyMismatch, r.Container.Sender.CompanyID, doc.Supplier.ID)
+	}
+	if receiver := r.Container.Receiver; receiver != nil && !sameID(receiver.CompanyID, doc.Customer.ID) {
+		return fmt.Errorf("%w: receiver %s, customer %s", ErrPartyMismatch, receiver.CompanyID, doc.Customer.ID)
+	}
+	if issuer := r.Response.ReceiverParty.CompanyID; issuer != "" && !sameID(issuer, doc.Supplier.ID) {
+		return fmt.Errorf("%w: ApplicationResponse receiver %s, supplier %s", ErrPartyMismatch, issuer, doc.Supplier.ID)
+	}
+	return nil
+}
+
+// EventReference referencia al documento para el ApplicationResponse de un
+// evento (030 acuse de recibo, 032 recibo del bien, 033 aceptación expresa)
+func (r *Received) EventReference() applicationresponse.DocumentReferenceData {
+	return applicationresponse.DocumentReferenceData{
+		ID:               r.Document.Number,
+		UUID:             r.Document.UUID,
+		IssueDate:        r.Document.IssueDate,
+		DocumentTypeCode: r.Document.TypeCode,
+	}
+}
+
+// EventParties partes del evento: lo emite el adquiriente y lo recibe el
+// facturador
+func (r *Received) EventParties() (sender, receiver applicationresponse.PartyData) {
+	return eventParty(r.Document.Customer), eventParty(r.Document.Supplier)
+}
+
+func eventParty(p Party) applicationresponse.PartyData {
+	return applicationresponse.PartyData{
+		RegistrationName: p.Name,
+		CompanyID:        p.ID,
+		SchemeID:         p.DV,
+		SchemeName:       p.IDType,
+		TaxLevelCode:     p.TaxLevelCodes,
+		TaxSchemeID:      p.TaxScheme,
+		TaxSchemeName:    p.TaxSchemeName,
+	}
+}
+
+// sameID compara identificaciones sin separadores ni DV
+func sameID(a, b string) bool {
+	return normalizeID(a) == normalizeID(b)
+}
+
+func normalizeID(id string) string {
+	if nit, _, err := identification.SplitNIT(id); err == nil {
+		return nit
+	}
+	return identification.Normalize(identification.NIT, id)
+}
@@ -0,0 +1,355 @@
+package reception_test
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/documents/attached"
+	"github.com/diegofxm/ubl21-dian/internal/testutil"
+	"github.com/diegofxm/ubl21-dian/pipeline"
+	"github.com/diegofxm/ubl21-dian/reception"
+	"github.com/diegofxm/ubl21-dian/signature"
+	"github.com/diegofxm/ubl21-dian/soap/rules"
+	"github.com/diegofxm/ubl21-dian/soap/simulator"
+	xmlpkg "github.com/diegofxm/ubl21-dian/xml"
+)
+
+// issue emite una factura contra el simulador y retorna el resultado del
+// proveedor con su AttachedDocument, el signer y su ancla de confianza
+func issue(t *testing.T) (*pipeline.Result, *signature.Signer, *x509.CertPool) {
+	t.Helper()
+	env := testutil.NewEnv(t)
+	b := testutil.NewInvoice("SETP990000001").
+		SetSupplier(testutil.Party("MI PROVEEDOR SAS", testutil.SupplierNIT)).
+		SetCustomer(testutil.Party("NOSOTROS SAS", testutil.CustomerNIT))
+	return testutil.Issue(t, env.Pipeline(t, pipeline.Config{}), b), env.Signer, env.Roots()
+}
+
+// attach arma un AttachedDocument con el documento y la respuesta dados
+func attach(t *testing.T, result *pipeline.Result, documentXML, responseXML []byte, sender string) []byte {
+	t.Helper()
+	data, err := attached.NewBuilder().
+		SetProfileExecutionID("2").
+		SetID(result.UUID).
+		SetIssueDate(time.Date(2025, 6, 1, 10, 5, 0, 0, time.UTC)).
+		SetParentDocumentID(result.Number).
+		SetSender(attached.PartyData{RegistrationName: "MI PROVEEDOR SAS", CompanyID: sender, SchemeName: "31", TaxSchemeID: "01", TaxSchemeName: "IVA"}).
+		SetReceiver(attached.PartyData{RegistrationName: "NOSOTROS SAS", CompanyID: "800111222", SchemeName: "31", TaxSchemeID: "01", TaxSchemeName: "IVA"}).
+		SetSignedInvoiceXML(string(documentXML)).
+		SetApplicationResponse(attached.ApplicationResponseData{
+			InvoiceID:            result.Number,
+			CUFE:                 result.UUID,
+			IssueDate:            time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+			ResponseXML:          string(responseXML),
+			ValidationResultCode: "02",
+			ValidationDate:       "2025-06-01",
+			ValidationTime:       "10:01:00-05:00",
+		}).
+		ToXML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestReceive(t *testing.T) {
+	result, signer, roots := issue(t)
+
+	// El simulador no firma el ApplicationResponse
+	if _, err := reception.Receive(result.AttachedDocument, reception.Options{Roots: roots}); !errors.Is(err, signature.ErrNotSigned) {
+		t.Fatalf("Expected ErrNotSigned for the ApplicationResponse, got %v", err)
+	}
+	r, err := reception.Receive(result.AttachedDocument, reception.Options{Roots: roots, Receiver: "800.111.222-7", AllowUnsignedResponse: true})
+	if err != nil {
+		t.Fatalf("Receive failed: %v", err)
+	}
+
+	doc := r.Document
+	if doc.Type != rules.Invoice || doc.TypeCode != "01" || doc.Number != "SETP990000001" || doc.UUID != result.UUID {
+		t.Errorf("Unexpected document: %+v", doc)
+	}
+	if doc.Supplier.ID != "900123456" || doc.Customer.ID != "800111222" || doc.Supplier.Name != "MI PROVEEDOR SAS" {
+		t.Errorf("Unexpected parties: %+v / %+v", doc.Supplier, doc.Customer)
+	}
+	if doc.Totals.Payable != 119000 || doc.Totals.TaxExclusive != 100000 {
+		t.Errorf("Unexpected totals: %+v", doc.Totals)
+	}
+	if len(doc.Lines) != 1 || doc.Lines[0].Quantity != 2 || doc.Lines[0].UnitPrice != 50000 || doc.Lines[0].Code != "P001" {
+		t.Errorf("Unexpected lines: %+v", doc.Lines)
+	}
+	if len(doc.Taxes) != 1 || doc.Taxes[0].Amount != 19000 || doc.Taxes[0].Percent != 19 {
+		t.Errorf("Unexpected taxes: %+v", doc.Taxes)
+	}
+	if len(doc.PaymentMeans) != 1 || doc.PaymentMeans[0].ID != "2" || doc.PaymentMeans[0].DueDate.IsZero() {
+		t.Errorf("Unexpected payment means: %+v", doc.PaymentMeans)
+	}
+	if r.DocumentSignature == nil || r.DocumentSignature.Role != "supplier" || !r.DocumentSignature.Trusted || r.ResponseSignature != nil {
+		t.Errorf("Unexpected signatures: %+v / %+v", r.DocumentSignature, r.ResponseSignature)
+	}
+	if !r.Response.IsValidated() {
+		t.Errorf("Expected validated ApplicationResponse")
+	}
+
+	ref := r.EventReference()
+	sender, receiver := r.EventParties()
+	if ref.UUID != result.UUID || ref.DocumentTypeCode != "01" || sender.CompanyID != "800111222" || receiver.CompanyID != "900123456" {
+		t.Errorf("Unexpected event data: %+v %+v %+v", ref, sender, receiver)
+	}
+
+	// ApplicationResponse firmado por DIAN, con su propia ancla de confianza
+	dianCreds, err := simulator.NewTestCredentials(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dianSigner, err := signature.NewSignerFromPEM(dianCreds.CertPath, dianCreds.KeyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dianRoots := x509.NewCertPool()
+	dianRoots.AddCert(dianCreds.Certificate)
+
+	signedResponse, err := dianSigner.SignXML(result.ApplicationResponseXML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err = reception.Receive(attach(t, result, result.SignedXML, signedResponse, "900123456"), reception.Options{Roots: roots, DIANRoots: dianRoots})
+	if err != nil {
+		t.Fatalf("Receive with signed ApplicationResponse failed: %v", err)
+	}
+	if r.ResponseSignature == nil || !r.ResponseSignature.Trusted {
+		t.Errorf("Expected trusted ApplicationResponse signature, got %+v", r.ResponseSignature)
+	}
+
+	// El proveedor no puede firmar la validación de DIAN
+	forged, err := signer.SignXML(result.ApplicationResponseXML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = reception.Receive(attach(t, result, result.SignedXML, forged, "900123456"), reception.Options{Roots: roots, DIANRoots: dianRoots})
+	if !errors.Is(err, signature.ErrInvalidCertificate) {
+		t.Errorf("Expected ErrInvalidCertificate for an ApplicationResponse signed by the supplier, got %v", err)
+	}
+}
+
+func TestReceiveTrustAnchors(t *testing.T) {
+	result, signer, roots := issue(t)
+	signedResponse, err := signer.SignXML(result.ApplicationResponseXML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withResponse := attach(t, result, result.SignedXML, signedResponse, "900123456")
+
+	// Sin anclas de confianza la recepción falla cerrada
+	if _, err := reception.Receive(result.AttachedDocument, reception.Options{AllowUnsignedResponse: true}); !errors.Is(err, reception.ErrNoTrustAnchor) {
+		t.Errorf("Expected ErrNoTrustAnchor without Roots, got %v", err)
+	}
+	if _, err := reception.Receive(withResponse, reception.Options{Roots: roots}); !errors.Is(err, reception.ErrNoTrustAnchor) {
+		t.Errorf("Expected ErrNoTrustAnchor for a signed ApplicationResponse without DIANRoots, got %v", err)
+	}
+
+	r, err := reception.Receive(withResponse, reception.Options{InsecureSkipChainVerify: true})
+	if err != nil {
+		t.Fatalf("Receive with InsecureSkipChainVerify failed: %v", err)
+	}
+	if r.DocumentSignature.Trusted || r.ResponseSignature.Trusted {
+		t.Errorf("Expected untrusted signatures, got %+v / %+v", r.DocumentSignature, r.ResponseSignature)
+	}
+}
+
+func TestReceiveErrors(t *testing.T) {
+	result, _, roots := issue(t)
+	opts := reception.Options{Roots: roots, AllowUnsignedResponse: true}
+
+	tampered := bytes.Replace(result.SignedXML, []byte("Servicio"), []byte("Servicios"), 1)
+	otherUUID := bytes.ReplaceAll(result.ApplicationResponseXML, []byte(result.UUID), []byte("abc123"))
+	notDIAN := bytes.Replace(result.ApplicationResponseXML, []byte(">800197268<"), []byte(">900123456<"), 1)
+
+	tests := []struct {
+		name string
+		data []byte
+		opts reception.Options
+		want error
+	}{
+		{"tampered document", attach(t, result, tampered, result.ApplicationResponseXML, "900123456"), opts, signature.ErrDigestMismatch},
+		{"unsigned document", attach(t, result, result.UnsignedXML, result.ApplicationResponseXML, "900123456"), opts, signature.ErrNotSigned},
+		{"uuid mismatch", attach(t, result, result.SignedXML, otherUUID, "900123456"), opts, reception.ErrReferenceMismatch},
+		{"response not from DIAN", attach(t, result, result.SignedXML, notDIAN, "900123456"), opts, reception.ErrNotIssuedByDIAN},
+		{"sender mismatch", attach(t, result, result.SignedXML, result.ApplicationResponseXML, "901000001"), opts, reception.ErrPartyMismatch},
+		{"another customer", result.AttachedDocument, reception.Options{Roots: roots, Receiver: "901000001", AllowUnsignedResponse: true}, reception.ErrNotAddressee},
+		{"not attached", result.SignedXML, opts, attached.ErrNotAttachedDocument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := reception.Receive(tt.data, tt.opts); !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestReceiveWrapping(t *testing.T) {
+	result, _, roots := issue(t)
+
+	// ApplicationResponse firmado por DIAN en la forma estándar de XMLDSig
+	dianCreds, err := simulator.NewTestCredentials(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dianSigner, err := signature.NewSignerFromPEM(dianCreds.CertPath, dianCreds.KeyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dianRoots := x509.NewCertPool()
+	dianRoots.AddCert(dianCreds.Certificate)
+	signedResponse, err := dianSigner.SignXML(result.ApplicationResponseXML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedResponse = resign(t, signedResponse, dianCreds.PrivateKey)
+	opts := reception.Options{Roots: roots, DIANRoots: dianRoots}
+	if _, err := reception.Receive(attach(t, result, result.SignedXML, signedResponse, "900123456"), opts); err != nil {
+		t.Fatalf("Receive with a standard signature failed: %v", err)
+	}
+
+	t.Run("SignedInfoDecoy", func(t *testing.T) {
+		wrapped := wrapSignedInfo(t, signedResponse, []byte("Documento validado"), []byte("Documento alterado"))
+		_, err := reception.Receive(attach(t, result, result.SignedXML, wrapped, "900123456"), opts)
+		if !errors.Is(err, signature.ErrInvalidSignature) {
+			t.Errorf("Expected ErrInvalidSignature, got %v", err)
+		}
+	})
+
+	t.Run("DuplicateID", func(t *testing.T) {
+		forged := duplicateSignedProperties(t, result.SignedXML)
+		_, err := reception.Receive(attach(t, result, forged, result.ApplicationResponseXML, "900123456"), reception.Options{Roots: roots, AllowUnsignedResponse: true})
+		if !errors.Is(err, signature.ErrInvalidSignature) {
+			t.Errorf("Expected ErrInvalidSignature, got %v", err)
+		}
+	})
+}
+
+const nsDS = "http://www.w3.org/2000/09/xmldsig#"
+
+// resign convierte la firma de SignXML a la forma estándar: referencias y
+// SignedInfo canonicalizados con los namespaces heredados del documento
+func resign(t *testing.T, signed []byte, key *rsa.PrivateKey) []byte {
+	t.Helper()
+	start, end, err := xmlpkg.FindElement(signed, xmlpkg.ByName(nsDS, "Signature"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := func(suffix string) xmlpkg.ElementMatcher {
+		return func(_, _ string, attrs []xml.Attr) bool {
+			for _, attr := range attrs {
+				if attr.Name.Local == "Id" && strings.HasSuffix(attr.Value, suffix) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	keyInfo, err := xmlpkg.ExtractElement(signed, id("-keyinfo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedProps, err := xmlpkg.ExtractElement(signed, id("-signedprops"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	digests := [][]byte{digest(t, append(append([]byte{}, signed[:start]...), signed[end:]...)), digest(t, keyInfo), digest(t, signedProps)}
+	for i, value := range digests {
+		signed = replaceText(t, signed, "ds:DigestValue", start, i, value)
+	}
+
+	signedInfo, err := xmlpkg.ExtractElement(signed, xmlpkg.ByName(nsDS, "SignedInfo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	canonical, err := xmlpkg.Canonicalize(signedInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := sha256.Sum256(canonical)
+	value, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return replaceText(t, signed, "ds:SignatureValue", start, 0, []byte(base64.StdEncoding.EncodeToString(value)))
+}
+
+// digest SHA256 base64 del elemento con C14N inclusivo
+func digest(t *testing.T, element []byte) []byte {
+	t.Helper()
+	canonical, err := xmlpkg.Canonicalize(element)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(canonical)
+	return []byte(base64.StdEncoding.EncodeToString(sum[:]))
+}
+
+// replaceText reemplaza el texto del n-ésimo elemento tag a partir de from
+func replaceText(t *testing.T, data []byte, tag string, from, n int, text []byte) []byte {
+	t.Helper()
+	for i := 0; ; i++ {
+		open := bytes.Index(data[from:], []byte("<"+tag))
+		if open < 0 {
+			t.Fatalf("%s #%d not found", tag, n)
+		}
+		from += open + bytes.IndexByte(data[from+open:], '>') + 1
+		if i == n {
+			break
+		}
+	}
+	to := from + bytes.Index(data[from:], []byte("</"+tag+">"))
+	return append(append(append([]byte{}, data[:from]...), text...), data[to:]...)
+}
+
+// wrapSignedInfo altera el documento y deja una copia del SignedInfo antes
+// de la firma; el digest del documento se recalcula en el SignedInfo real
+func wrapSignedInfo(t *testing.T, signed, find, replace []byte) []byte {
+	t.Helper()
+	start, end, err := xmlpkg.FindElement(signed, xmlpkg.ByName(nsDS, "SignedInfo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoy := `<ext:UBLExtension><ext:ExtensionContent><ds:Object xmlns:ds="` + nsDS + `">` +
+		string(signed[start:end]) + `</ds:Object></ext:ExtensionContent></ext:UBLExtension>`
+	at := bytes.LastIndex(signed, []byte("<ext:UBLExtension><ext:ExtensionContent><ds:Signature"))
+	if at < 0 {
+		t.Fatal("signature extension not found")
+	}
+	wrapped := append(append(append([]byte{}, signed[:at]...), decoy...), signed[at:]...)
+	wrapped = bytes.Replace(wrapped, find, replace, 1)
+
+	start, end, err = xmlpkg.FindElement(wrapped, xmlpkg.ByName(nsDS, "Signature"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return replaceText(t, wrapped, "ds:DigestValue", start, 0, digest(t, append(append([]byte{}, wrapped[:start]...), wrapped[end:]...)))
+}
+
+// duplicateSignedProperties cambia el rol firmado y deja una copia del
+// SignedProperties original, con el mismo Id, dentro de la firma
+func duplicateSignedProperties(t *testing.T, signed []byte) []byte {
+	t.Helper()
+	start, end, err := xmlpkg.FindElement(signed, xmlpkg.ByName("http://uri.etsi.org/01903/v1.3.2#", "SignedProperties"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	role := bytes.LastIndex(signed, []byte(">supplier<"))
+	forged := append(append(append([]byte{}, signed[:role]...), ">third party<"...), signed[role+len(">supplier<"):]...)
+	decoy := "<ds:Object>" + string(signed[start:end]) + "</ds:Object>"
+	at := bytes.Index(forged, []byte("</ds:KeyInfo>")) + len("</ds:KeyInfo>")
+	return append(append(append([]byte{}, forged[:at]...), decoy...), forged[at:]...)
+}
@@ -0,0 +1,278 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/pipeline"
+	"github.com/diegofxm/ubl21-dian/soap/types"
+)
+
+// recordFile metadatos de un registro dentro de su directorio
+const recordFile = "record.json"
+
+// uuidDir índice de CUFE/CUDE/CUDS a clave
+const uuidDir = "uuid"
+
+// FileRepository Repository sobre un directorio
+//
+// Cada documento vive en {dir}/{nit}/{tipo}/{número}/ con sus artefactos con
+// el nombre de pipeline.Result.Artifacts y un record.json con los metadatos;
+// {dir}/uuid/{cufe} apunta a la clave. Es seguro entre goroutines de un mismo
+// proceso; para varios procesos use SQLRepository.
+type FileRepository struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// NewFileRepository crea un Repository en dir (se crea en el primer Save)
+func NewFileRepository(dir string) *FileRepository {
+	return &FileRepository{dir: dir}
+}
+
+// fileRecord record.json
+type fileRecord struct {
+	Key           Key
+	UUID          string
+	Status        Status
+	TrackID       string
+	StatusCode    string
+	StatusMessage string
+	ErrorMessages []types.ErrorMessage `json:",omitempty"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Artifacts     []fileArtifact
+}
+
+type fileArtifact struct {
+	Stage    pipeline.Stage
+	FileName string
+}
+
+func (r *FileRepository) recordDir(key Key) string {
+	return filepath.Join(r.dir, key.NIT, string(key.Type), key.Number)
+}
+
+// Save implementa Repository
+func (r *FileRepository) Save(rec *Record) error {
+	rec.NIT = normalizeNIT(rec.NIT)
+	if err := rec.validate(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if key, err := r.lookup(rec.UUID); err == nil && key != rec.Key {
+		return fmt.Errorf("%w: %s is %s", ErrDuplicateUUID, rec.UUID, key)
+	}
+	dir := r.recordDir(rec.Key)
+	previous, err := r.read(rec.Key)
+	switch {
+	case err == nil:
+		rec.CreatedAt = previous.CreatedAt
+	case !errors.Is(err, ErrNotFound):
+		return err
+	}
+	now := time.Now()
+	if rec.CreatedAt.IsZero() {
+		rec.CreatedAt = now
+	}
+	rec.UpdatedAt = now
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to save %s: %w", rec.Key, err)
+	}
+	meta := fileRecord{
+		Key: rec.Key, UUID: rec.UUID, Status: rec.Status, TrackID: rec.TrackID,
+		StatusCode: rec.StatusCode, StatusMessage: rec.StatusMessage, ErrorMessages: rec.ErrorMessages,
+		CreatedAt: rec.CreatedAt, UpdatedAt: rec.UpdatedAt,
+	}
+	keep := map[string]bool{}
+	for _, a := range rec.Artifacts {
+		if err := writeFile(filepath.Join(dir, a.FileName), a.Data); err != nil {
+			return fmt.Errorf("failed to save %s: %w", rec.Key, err)
+		}
+		meta.Artifacts = append(meta.Artifacts, fileArtifact{Stage: a.Stage, FileName: a.FileName})
+		keep[a.FileName] = true
+	}
+
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(dir, recordFile), data); err != nil {
+		return fmt.Errorf("failed to save %s: %w", rec.Key, err)
+	}
+
+	// Artefactos que ya no están (retención) y UUID anterior
+	if previous != nil {
+		for _, a := range previous.Artifacts {
+			if !keep[a.FileName] {
+				os.Remove(filepath.Join(dir, a.FileName))
+			}
+		}
+		if previous.UUID != rec.UUID {
+			os.Remove(filepath.Join(r.dir, uuidDir, previous.UUID))
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(r.dir, uuidDir), 0o755); err != nil {
+		return fmt.Errorf("failed to save %s: %w", rec.Key, err)
+	}
+	index, err := json.Marshal(rec.Key)
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(r.dir, uuidDir, rec.UUID), index)
+}
+
+// Get implementa Repository
+func (r *FileRepository) Get(key Key) (*Record, error) {
+	key.NIT = normalizeNIT(key.NIT)
+	if err := key.validate(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec, err := r.read(key)
+	if err != nil {
+		return nil, err
+	}
+	dir := r.recordDir(key)
+	for i, a := range rec.Artifacts {
+		if rec.Artifacts[i].Data, err = os.ReadFile(filepath.Join(dir, a.FileName)); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", key, err)
+		}
+	}
+	return rec, nil
+}
+
+// FindByUUID implementa Repository
+func (r *FileRepository) FindByUUID(uuid string) (*Record, error) {
+	if !validPart(uuid) {
+		return nil, fmt.Errorf("%w: UUID %q", ErrNotFound, uuid)
+	}
+	r.mu.Lock()
+	key, err := r.lookup(uuid)
+	r.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return r.Get(key)
+}
+
+// List implementa Repository
+func (r *FileRepository) List(filter Filter) ([]*Record, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	paths, err := filepath.Glob(filepath.Join(r.dir, "*", "*", "*", recordFile))
+	if err != nil {
+		return nil, err
+	}
+	var records []*Record
+	for _, path := range paths {
+		rec, err := readRecord(path)
+		if err != nil {
+			return nil, err
+		}
+		if filter.match(rec) {
+			records = append(records, rec)
+		}
+	}
+	sortRecords(records)
+	return records, nil
+}
+
+// Delete implementa Repository
+func (r *FileRepository) Delete(key Key) error {
+	key.NIT = normalizeNIT(key.NIT)
+	if err := key.validate(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec, err := r.read(key)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(r.recordDir(key)); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	if err := os.Remove(filepath.Join(r.dir, uuidDir, rec.UUID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// read lee los metadatos de key (artefactos sin contenido)
+func (r *FileRepository) read(key Key) (*Record, error) {
+	rec, err := readRecord(filepath.Join(r.recordDir(key), recordFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return rec, err
+}
+
+// lookup clave del documento con ese UUID
+func (r *FileRepository) lookup(uuid string) (Key, error) {
+	data, err := os.ReadFile(filepath.Join(r.dir, uuidDir, uuid))
+	if errors.Is(err, os.ErrNotExist) {
+		return Key{}, fmt.Errorf("%w: UUID %s", ErrNotFound, uuid)
+	}
+	if err != nil {
+		return Key{}, err
+	}
+	var key Key
+	if err := json.Unmarshal(data, &key); err != nil {
+		return Key{}, fmt.Errorf("failed to parse UUID index %s: %w", uuid, err)
+	}
+	return key, nil
+}
+
+func readRecord(path string) (*Record, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var meta fileRecord
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	rec := &Record{
+		Key: meta.Key, UUID: meta.UUID, Status: meta.Status, TrackID: meta.TrackID,
+		StatusCode: meta.StatusCode, StatusMessage: meta.StatusMessage, ErrorMessages: meta.ErrorMessages,
+		CreatedAt: meta.CreatedAt, UpdatedAt: meta.UpdatedAt,
+	}
+	for _, a := range meta.Artifacts {
+		rec.Artifacts = append(rec.Artifacts, pipeline.Artifact{Stage: a.Stage, FileName: a.FileName})
+	}
+	return rec, nil
+}
+
+// writeFile escribe de forma atómica (archivo temporal + rename)
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,311 @@
+// Package repository guarda los artefactos de cada documento emitido
+//
+// Un Record agrupa los archivos del pipeline (XML sin firmar, XML firmado,
+// ZIP, request y respuesta SOAP, ApplicationResponse, AttachedDocument y PDF)
+// con la clave emisor + tipo + número, el CUFE/CUDE/CUDS y el estado ante
+// DIAN con sus mensajes de error. Hay
+// implementaciones sobre el sistema de archivos y sobre database/sql; ambas
+// se conectan al pipeline con Hooks:
+//
+//	repo := repository.NewFileRepository("documentos")
+//	p, err := pipeline.New(pipeline.Config{Hooks: repository.Hooks(repo, "900123456"), ...})
+//
+//	rec, err := repo.FindByUUID(cufe)
+//	junio, err := repo.List(repository.Filter{From: inicio, To: fin})
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/identification"
+	"github.com/diegofxm/ubl21-dian/pipeline"
+	"github.com/diegofxm/ubl21-dian/soap/types"
+)
+
+var (
+	ErrNotFound      = errors.New("document not found")
+	ErrInvalidKey    = errors.New("invalid document key")
+	ErrDuplicateUUID = errors.New("UUID already stored for another document")
+)
+
+// Status estado del documento ante DIAN
+type Status string
+
+const (
+	StatusPending  Status = "pending"  // Generado, aún sin respuesta de DIAN
+	StatusAccepted Status = "accepted" // Validado por DIAN
+	StatusRejected Status = "rejected" // DIAN respondió con errores
+	StatusFailed   Status = "failed"   // Falló una etapa antes de la respuesta
+)
+
+// Key identifica un documento del emisor
+type Key struct {
+	NIT    string // Emisor, sin DV
+	Type   pipeline.DocumentType
+	Number string // Prefijo + consecutivo
+}
+
+func (k Key) String() string {
+	return k.NIT + "/" + string(k.Type) + "/" + k.Number
+}
+
+// validate verifica que la clave sirva como ruta y como llave primaria
+func (k Key) validate() error {
+	for _, part := range []string{k.NIT, string(k.Type), k.Number} {
+		if !validPart(part) {
+			return fmt.Errorf("%w: %q", ErrInvalidKey, k.String())
+		}
+	}
+	return nil
+}
+
+// validPart parte de una clave: no vacía y sin separadores de ruta
+func validPart(part string) bool {
+	return part != "" && part != "." && part != ".." && !strings.ContainsAny(part, `/\`)
+}
+
+// Record documento con sus artefactos y metadatos
+type Record struct {
+	Key
+	UUID          string // CUFE, CUDE o CUDS
+	Status        Status
+	TrackID       string // XmlDocumentKey o ZipKey de DIAN
+	StatusCode    string // StatusCode de la respuesta de DIAN
+	StatusMessage string
+	ErrorMessages []types.ErrorMessage // Reglas reportadas por DIAN en la respuesta
+	CreatedAt     time.Time            // Primera vez que se guardó (no cambia al actualizar)
+	UpdatedAt     time.Time
+	Artifacts     []pipeline.Artifact // Uno por etapa
+}
+
+// Artifact retorna el artefacto de una etapa
+func (r *Record) Artifact(stage pipeline.Stage) (pipeline.Artifact, bool) {
+	for _, a := range r.Artifacts {
+		if a.Stage == stage {
+			return a, true
+		}
+	}
+	return pipeline.Artifact{}, false
+}
+
+// validate verifica la clave, el UUID y los nombres de los artefactos
+func (r *Record) validate() error {
+	if err := r.Key.validate(); err != nil {
+		return err
+	}
+	if !validPart(r.UUID) {
+		return fmt.Errorf("%w: %s has no valid UUID", ErrInvalidKey, r.Key)
+	}
+	seen := map[pipeline.Stage]bool{}
+	for _, a := range r.Artifacts {
+		if !validPart(a.FileName) || a.FileName == recordFile || seen[a.Stage] {
+			return fmt.Errorf("%w: %s artifact %s %q", ErrInvalidKey, r.Key, a.Stage, a.FileName)
+		}
+		seen[a.Stage] = true
+	}
+	return nil
+}
+
+// Filter criterios de List; los campos vacíos no filtran
+type Filter struct {
+	NIT    string
+	Type   pipeline.DocumentType
+	Status Status
+	From   time.Time // CreatedAt >= From
+	To     time.Time // CreatedAt < To
+}
+
+// match indica si el registro cumple el filtro
+func (f Filter) match(r *Record) bool {
+	switch {
+	case f.NIT != "" && normalizeNIT(f.NIT) != r.NIT:
+		return false
+	case f.Type != "" && f.Type != r.Type:
+		return false
+	case f.Status != "" && f.Status != r.Status:
+		return false
+	case !f.From.IsZero() && r.CreatedAt.Before(f.From):
+		return false
+	case !f.To.IsZero() && !r.CreatedAt.Before(f.To):
+		return false
+	}
+	return true
+}
+
+// Repository almacén de documentos emitidos
+//
+// Save reemplaza el registro completo (metadatos y artefactos) conservando
+// su CreatedAt y actualiza en rec el NIT normalizado, CreatedAt y UpdatedAt.
+// List retorna los registros ordenados por CreatedAt con los artefactos sin
+// contenido (Stage y FileName); Get y FindByUUID los traen completos.
+type Repository interface {
+	Save(rec *Record) error
+	Get(key Key) (*Record, error)
+	FindByUUID(uuid string) (*Record, error)
+	List(filter Filter) ([]*Record, error)
+	Delete(key Key) error
+}
+
+// FromResult arma el registro de un resultado del pipeline
+// nit es el emisor (el mismo del naming.Counter del pipeline).
+func FromResult(nit string, r *pipeline.Result) *Record {
+	rec := &Record{
+		Key:       Key{NIT: normalizeNIT(nit), Type: r.Type, Number: r.Number},
+		UUID:      r.UUID,
+		Status:    StatusPending,
+		Artifacts: r.Artifacts(),
+	}
+	if resp := r.Response; resp != nil {
+		rec.TrackID = resp.XmlDocumentKey
+		if rec.TrackID == "" {
+			rec.TrackID = resp.ZipKey
+		}
+		rec.StatusCode = resp.StatusCode
+		rec.StatusMessage = resp.StatusDescription
+		rec.ErrorMessages = resp.ErrorMessages
+		rec.Status = StatusRejected
+		if resp.IsValid {
+			rec.Status = StatusAccepted
+		}
+	}
+	if ar := r.ApplicationResponse; ar != nil && !ar.IsValidated() {
+		rec.Status = StatusRejected
+	}
+	return rec
+}
+
+// Hooks guarda el documento después de cada etapa del pipeline y también
+// cuando una etapa falla (StatusFailed si DIAN no alcanzó a responder)
+func Hooks(repo Repository, nit string) pipeline.Hooks {
+	return pipeline.Hooks{
+		AfterStage: func(stage pipeline.Stage, result *pipeline.Result) error {
+			if result.UUID == "" {
+				return nil
+			}
+			return repo.Save(FromResult(nit, result))
+		},
+		OnError: func(stage pipeline.Stage, result *pipeline.Result, err error) error {
+			if result.UUID == "" {
+				return err
+			}
+			rec := FromResult(nit, result)
+			if rec.Status == StatusPending {
+				rec.Status = StatusFailed
+				rec.StatusMessage = err.Error()
+			}
+			if saveErr := repo.Save(rec); saveErr != nil {
+				return errors.Join(err, saveErr)
+			}
+			return err
+		},
+	}
+}
+
+// Retention política de conservación
+//
+// La norma exige conservar el XML firmado y el AttachedDocument; lo usual es
+// podar antes los artefactos de depuración (request SOAP, XML sin firmar).
+type Retention struct {
+	MaxAge time.Duration                    // Borra registros completos más antiguos; 0 = nunca
+	Stages map[pipeline.Stage]time.Duration // Borra los artefactos de esas etapas más antiguos
+}
+
+// Pruned resultado de aplicar una Retention
+type Pruned struct {
+	Records   int // Registros borrados
+	Artifacts int // Artefactos borrados de registros que se conservan
+}
+
+// Apply aplica la política a los registros creados antes de now
+func (p Retention) Apply(repo Repository, now time.Time) (Pruned, error) {
+	var pruned Pruned
+	youngest := p.MaxAge
+	for _, age := range p.Stages {
+		if age > 0 && (youngest == 0 || age < youngest) {
+			youngest = age
+		}
+	}
+	if youngest == 0 {
+		return pruned, nil
+	}
+
+	records, err := repo.List(Filter{To: now.Add(-youngest)})
+	if err != nil {
+		return pruned, err
+	}
+	for _, rec := range records {
+		age := now.Sub(rec.CreatedAt)
+		if p.MaxAge > 0 && age > p.MaxAge {
+			if err := repo.Delete(rec.Key); err != nil {
+				return pruned, err
+			}
+			pruned.Records++
+			continue
+		}
+
+		expired := func(a pipeline.Artifact) bool {
+			max, ok := p.Stages[a.Stage]
+			return ok && max > 0 && age > max
+		}
+		count := 0
+		for _, a := range rec.Artifacts {
+			if expired(a) {
+				count++
+			}
+		}
+		if count == 0 {
+			continue
+		}
+
+		full, err := repo.Get(rec.Key)
+		if err != nil {
+			return pruned, err
+		}
+		kept := full.Artifacts[:0]
+		for _, a := range full.Artifacts {
+			if !expired(a) {
+				kept = append(kept, a)
+			}
+		}
+		full.Artifacts = kept
+		if err := repo.Save(full); err != nil {
+			return pruned, err
+		}
+		pruned.Artifacts += count
+	}
+	return pruned, nil
+}
+
+// sortRecords ordena por CreatedAt y clave
+func sortRecords(records []*Record) {
+	sort.Slice(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.Key.String() < b.Key.String()
+	})
+}
+
+// sortArtifacts ordena por etapa en el orden del pipeline
+func sortArtifacts(artifacts []pipeline.Artifact) {
+	order := map[pipeline.Stage]int{}
+	for i, stage := range pipeline.ArtifactStages {
+		order[stage] = i
+	}
+	sort.SliceStable(artifacts, func(i, j int) bool {
+		return order[artifacts[i].Stage] < order[artifacts[j].Stage]
+	})
+}
+
+// normalizeNIT NIT sin separadores ni DV
+func normalizeNIT(nit string) string {
+	if n, _, err := identification.SplitNIT(nit); err == nil {
+		return n
+	}
+	return identification.Normalize(identification.NIT, nit)
+}
@@ -0,0 +1,384 @@
+package repository_test
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/internal/testutil"
+	"github.com/diegofxm/ubl21-dian/numbering"
+	"github.com/diegofxm/ubl21-dian/pipeline"
+	"github.com/diegofxm/ubl21-dian/repository"
+	"github.com/diegofxm/ubl21-dian/soap/types"
+)
+
+// backends implementaciones sobre las que corre cada prueba
+func backends(t *testing.T) map[string]repository.Repository {
+	t.Helper()
+	db, err := sql.Open("repository-fake", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	sqlRepo := repository.NewSQLRepository(db, "", numbering.Dollar)
+	if err := sqlRepo.CreateTables("BYTEA"); err != nil {
+		t.Fatal(err)
+	}
+	return map[string]repository.Repository{
+		"file": repository.NewFileRepository(t.TempDir()),
+		"sql":  sqlRepo,
+	}
+}
+
+func record(number, uuid string) *repository.Record {
+	return &repository.Record{
+		Key:    repository.Key{NIT: "900.123.456-8", Type: pipeline.Invoice, Number: number},
+		UUID:   uuid,
+		Status: repository.StatusPending,
+		Artifacts: []pipeline.Artifact{
+			{Stage: pipeline.StageBuild, FileName: "fv-" + number + ".xml", Data: []byte("<Invoice/>")},
+			{Stage: pipeline.StageSign, FileName: "fvS-" + number + ".xml", Data: []byte("<Invoice>firmada</Invoice>")},
+		},
+	}
+}
+
+func TestRepository(t *testing.T) {
+	for name, repo := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			start := time.Now()
+			rec := record("SETP990000001", "cufe-1")
+			if err := repo.Save(rec); err != nil {
+				t.Fatal(err)
+			}
+			if rec.NIT != "900123456" || rec.CreatedAt.IsZero() {
+				t.Errorf("Expected normalized NIT and CreatedAt, got %+v", rec.Key)
+			}
+			created := rec.CreatedAt
+
+			rec.Status = repository.StatusAccepted
+			rec.TrackID = "cufe-1"
+			rec.StatusCode = "00"
+			rec.ErrorMessages = []types.ErrorMessage{{Code: "FAK24", Description: "Regla: FAK24, Notificación: No fue informada la dirección"}}
+			rec.Artifacts = append(rec.Artifacts, pipeline.Artifact{Stage: pipeline.StageRender, FileName: "fvS-SETP990000001.pdf", Data: []byte("%PDF")})
+			if err := repo.Save(rec); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := repo.FindByUUID("cufe-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Key != rec.Key || got.Status != repository.StatusAccepted || got.StatusCode != "00" || !got.CreatedAt.Equal(created) {
+				t.Errorf("Unexpected record: %+v", got)
+			}
+			if len(got.ErrorMessages) != 1 || got.ErrorMessages[0] != rec.ErrorMessages[0] {
+				t.Errorf("Expected stored error messages, got %+v", got.ErrorMessages)
+			}
+			if pdf, ok := got.Artifact(pipeline.StageRender); !ok || !bytes.Equal(pdf.Data, []byte("%PDF")) {
+				t.Errorf("Expected PDF artifact, got %+v", got.Artifacts)
+			}
+			if len(got.Artifacts) != 3 || got.Artifacts[0].Stage != pipeline.StageBuild {
+				t.Errorf("Expected 3 artifacts in stage order, got %+v", got.Artifacts)
+			}
+
+			if err := repo.Save(record("SETP990000002", "cufe-1")); !errors.Is(err, repository.ErrDuplicateUUID) {
+				t.Errorf("Expected ErrDuplicateUUID, got %v", err)
+			}
+			if err := repo.Save(record("../x", "cufe-9")); !errors.Is(err, repository.ErrInvalidKey) {
+				t.Errorf("Expected ErrInvalidKey, got %v", err)
+			}
+
+			second := record("SETP990000002", "cufe-2")
+			second.Type = pipeline.CreditNote
+			if err := repo.Save(second); err != nil {
+				t.Fatal(err)
+			}
+
+			all, err := repo.List(repository.Filter{NIT: "900123456", From: start, To: time.Now().Add(time.Second)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(all) != 2 || all[0].Number != "SETP990000001" || all[1].Number != "SETP990000002" {
+				t.Fatalf("Expected both records by CreatedAt, got %d", len(all))
+			}
+			if all[0].Artifacts[0].Data != nil {
+				t.Error("Expected List without artifact data")
+			}
+			for _, filter := range []repository.Filter{
+				{Type: pipeline.CreditNote},
+				{Status: repository.StatusPending},
+				{From: second.CreatedAt},
+			} {
+				if list, _ := repo.List(filter); len(list) != 1 || list[0].UUID != "cufe-2" {
+					t.Errorf("Filter %+v: expected only cufe-2, got %d", filter, len(list))
+				}
+			}
+			if list, _ := repo.List(repository.Filter{To: start}); len(list) != 0 {
+				t.Errorf("Expected no records before start, got %d", len(list))
+			}
+
+			if err := repo.Delete(second.Key); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := repo.Get(second.Key); !errors.Is(err, repository.ErrNotFound) {
+				t.Errorf("Expected ErrNotFound after Delete, got %v", err)
+			}
+			if _, err := repo.FindByUUID("cufe-2"); !errors.Is(err, repository.ErrNotFound) {
+				t.Errorf("Expected ErrNotFound by UUID after Delete, got %v", err)
+			}
+			if err := repo.Delete(second.Key); !errors.Is(err, repository.ErrNotFound) {
+				t.Errorf("Expected ErrNotFound deleting twice, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRetention(t *testing.T) {
+	for name, repo := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			for i, uuid := range []string{"cufe-1", "cufe-2"} {
+				if err := repo.Save(record("SETP99000000"+strconv.Itoa(i+1), uuid)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			policy := repository.Retention{
+				MaxAge: 10 * 365 * 24 * time.Hour,
+				Stages: map[pipeline.Stage]time.Duration{pipeline.StageBuild: 30 * 24 * time.Hour},
+			}
+
+			pruned, err := policy.Apply(repo, time.Now())
+			if err != nil || pruned != (repository.Pruned{}) {
+				t.Fatalf("Expected nothing pruned yet, got %+v (%v)", pruned, err)
+			}
+
+			pruned, err = policy.Apply(repo, time.Now().Add(31*24*time.Hour))
+			if err != nil || pruned != (repository.Pruned{Artifacts: 2}) {
+				t.Fatalf("Expected unsigned XML pruned, got %+v (%v)", pruned, err)
+			}
+			rec, err := repo.FindByUUID("cufe-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := rec.Artifact(pipeline.StageBuild); ok || len(rec.Artifacts) != 1 {
+				t.Errorf("Expected only the signed XML, got %+v", rec.Artifacts)
+			}
+
+			pruned, err = policy.Apply(repo, time.Now().Add(11*365*24*time.Hour))
+			if err != nil || pruned != (repository.Pruned{Records: 2}) {
+				t.Fatalf("Expected records pruned, got %+v (%v)", pruned, err)
+			}
+			if list, _ := repo.List(repository.Filter{}); len(list) != 0 {
+				t.Errorf("Expected empty repository, got %d", len(list))
+			}
+		})
+	}
+}
+
+func TestHooks(t *testing.T) {
+	for name, repo := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			env := testutil.NewEnv(t)
+			var stages []pipeline.Stage
+			hooks := repository.Hooks(repo, "900123456-8")
+			after := hooks.AfterStage
+			hooks.AfterStage = func(stage pipeline.Stage, result *pipeline.Result) error {
+				stages = append(stages, stage)
+				return after(stage, result)
+			}
+			p := env.Pipeline(t, pipeline.Config{Hooks: hooks})
+			result := testutil.Issue(t, p, testutil.NewInvoice("SETP990000001"))
+
+			rec, err := repo.FindByUUID(result.UUID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rec.Key != (repository.Key{NIT: "900123456", Type: pipeline.Invoice, Number: "SETP990000001"}) {
+				t.Errorf("Unexpected key: %s", rec.Key)
+			}
+			if rec.Status != repository.StatusAccepted || rec.TrackID == "" || rec.StatusCode != "00" {
+				t.Errorf("Expected accepted record with TrackID, got %+v", rec)
+			}
+			if len(rec.Artifacts) != len(result.Artifacts()) {
+				t.Errorf("Expected %d artifacts after %v, got %d", len(result.Artifacts()), stages, len(rec.Artifacts))
+			}
+			signed, ok := rec.Artifact(pipeline.StageSign)
+			if !ok || !bytes.Equal(signed.Data, result.SignedXML) {
+				t.Error("Expected stored signed XML")
+			}
+			reply, ok := rec.Artifact(pipeline.StageReply)
+			if !ok || reply.FileName != "RespFE-SETP990000001.xml" || !bytes.Equal(reply.Data, result.SOAPResponse) {
+				t.Errorf("Expected stored SOAP response, got %+v", reply.FileName)
+			}
+		})
+	}
+}
+
+func TestFromResultRejected(t *testing.T) {
+	result := &pipeline.Result{Type: pipeline.Invoice, Number: "SETP990000001", UUID: "cufe-1"}
+	result.SOAPResponse = []byte("<s:Envelope/>")
+	result.Response = &types.SendBillSyncResponse{Response: types.Response{
+		IsValid:           false,
+		StatusCode:        "99",
+		StatusDescription: "Validación contiene errores en campos mandatorios.",
+		ErrorMessages:     []types.ErrorMessage{{Code: "FAD06", Description: "Regla: FAD06, Rechazo: Valor del CUFE no está calculado correctamente."}},
+		XmlDocumentKey:    "cufe-1",
+	}}
+
+	rec := repository.FromResult("900123456-8", result)
+	if rec.Status != repository.StatusRejected || rec.StatusCode != "99" || len(rec.ErrorMessages) != 1 || rec.ErrorMessages[0].Code != "FAD06" {
+		t.Errorf("Expected rejected record with DIAN errors, got %+v", rec)
+	}
+	if reply, ok := rec.Artifact(pipeline.StageReply); !ok || reply.FileName != "RespFE-SETP990000001.xml" {
+		t.Errorf("Expected SOAP response artifact, got %+v", rec.Artifacts)
+	}
+}
+
+// Driver database/sql mínimo para las sentencias de SQLRepository: tablas en
+// memoria por DSN y condiciones "columna op $n" unidas con AND
+
+func init() {
+	sql.Register("repository-fake", &fakeDriver{dbs: map[string]map[string][]fakeRow{}})
+}
+
+type fakeRow map[string]driver.Value
+
+type fakeDriver struct {
+	mu  sync.Mutex
+	dbs map[string]map[string][]fakeRow
+}
+
+func (d *fakeDriver) Open(dsn string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.dbs[dsn] == nil {
+		d.dbs[dsn] = map[string][]fakeRow{}
+	}
+	return &fakeConn{d: d, tables: d.dbs[dsn]}, nil
+}
+
+type fakeConn struct {
+	d      *fakeDriver
+	tables map[string][]fakeRow
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{c: c, query: query}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	c     *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return strings.Count(s.query, "$") }
+
+// where filas de table que cumplen las condiciones de la sentencia
+func (s *fakeStmt) where(table string, args []driver.Value) (match, rest []fakeRow) {
+	var conditions []string
+	if i := strings.Index(s.query, " WHERE "); i >= 0 {
+		conditions = strings.Split(s.query[i+len(" WHERE "):], " AND ")
+	}
+	for _, row := range s.c.tables[table] {
+		ok := true
+		for _, condition := range conditions {
+			var column, op, marker string
+			fmt.Sscan(condition, &column, &op, &marker)
+			n, _ := strconv.Atoi(strings.TrimPrefix(marker, "$"))
+			ok = ok && compare(row[column], op, args[n-1])
+		}
+		if ok {
+			match = append(match, row)
+		} else {
+			rest = append(rest, row)
+		}
+	}
+	return match, rest
+}
+
+func compare(a driver.Value, op string, b driver.Value) bool {
+	switch op {
+	case ">=":
+		return a.(int64) >= b.(int64)
+	case "<":
+		return a.(int64) < b.(int64)
+	}
+	return a == b
+}
+
+// table nombre de la tabla después de FROM o INTO
+func (s *fakeStmt) table(keyword string) string {
+	fields := strings.Fields(s.query[strings.Index(s.query, keyword)+len(keyword):])
+	return fields[0]
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.c.d.mu.Lock()
+	defer s.c.d.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(s.query, "CREATE"):
+		return driver.RowsAffected(0), nil
+	case strings.HasPrefix(s.query, "INSERT"):
+		open := strings.Index(s.query, "(")
+		columns := strings.Split(s.query[open+1:strings.Index(s.query, ")")], ", ")
+		row := fakeRow{}
+		for i, column := range columns {
+			row[column] = args[i]
+		}
+		table := s.table(" INTO ")
+		s.c.tables[table] = append(s.c.tables[table], row)
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(s.query, "DELETE"):
+		table := s.table(" FROM ")
+		match, rest := s.where(table, args)
+		s.c.tables[table] = rest
+		return driver.RowsAffected(len(match)), nil
+	}
+	return nil, errors.New("unexpected query: " + s.query)
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.c.d.mu.Lock()
+	defer s.c.d.mu.Unlock()
+
+	if !strings.HasPrefix(s.query, "SELECT ") {
+		return nil, errors.New("unexpected query: " + s.query)
+	}
+	columns := strings.Split(s.query[len("SELECT "):strings.Index(s.query, " FROM ")], ", ")
+	match, _ := s.where(s.table(" FROM "), args)
+	return &fakeRows{columns: columns, rows: match}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    []fakeRow
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	for i, column := range r.columns {
+		dest[i] = r.rows[0][column]
+	}
+	r.rows = r.rows[1:]
+	return nil
+}
@@ -0,0 +1,322 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/numbering"
+	"github.com/diegofxm/ubl21-dian/pipeline"
+)
+
+// documentColumns columnas de la tabla de documentos en orden de scan
+const documentColumns = "nit, doc_type, number, uuid, status, track_id, status_code, status_message, error_messages, created_at, updated_at"
+
+// SQLRepository Repository sobre database/sql
+//
+// Usa dos tablas (prefijo dian_ por defecto), con las fechas en
+// nanosegundos Unix (UTC) para no depender del tipo de fecha del motor y los
+// ErrorMessages de DIAN en JSON:
+//
+//	CREATE TABLE dian_documents (
+//	    nit VARCHAR(20), doc_type VARCHAR(20), number VARCHAR(50),
+//	    uuid VARCHAR(100) NOT NULL UNIQUE, status VARCHAR(20) NOT NULL,
+//	    track_id VARCHAR(100), status_code VARCHAR(10), status_message VARCHAR(1000),
+//	    error_messages TEXT, created_at BIGINT NOT NULL, updated_at BIGINT NOT NULL,
+//	    PRIMARY KEY (nit, doc_type, number)
+//	)
+//	CREATE TABLE dian_artifacts (
+//	    nit VARCHAR(20), doc_type VARCHAR(20), number VARCHAR(50),
+//	    stage VARCHAR(20), file_name VARCHAR(200) NOT NULL, data BLOB NOT NULL,
+//	    PRIMARY KEY (nit, doc_type, number, stage)
+//	)
+//
+// Para listar por fechas conviene un índice sobre dian_documents(created_at).
+// Save reemplaza el registro en una transacción, así que varios procesos
+// pueden compartir las tablas.
+type SQLRepository struct {
+	db          *sql.DB
+	documents   string
+	artifacts   string
+	placeholder numbering.Placeholder
+}
+
+// NewSQLRepository crea un Repository con el prefijo de tablas dado ("dian_"
+// si es vacío) y los marcadores del driver (numbering.QuestionMark por
+// defecto, numbering.Dollar en PostgreSQL)
+func NewSQLRepository(db *sql.DB, prefix string, placeholder numbering.Placeholder) *SQLRepository {
+	if prefix == "" {
+		prefix = "dian_"
+	}
+	if placeholder == nil {
+		placeholder = numbering.QuestionMark
+	}
+	return &SQLRepository{db: db, documents: prefix + "documents", artifacts: prefix + "artifacts", placeholder: placeholder}
+}
+
+// CreateTables crea las tablas si no existen
+// blobType es el tipo de los artefactos: "BLOB" si es vacío, "BYTEA" en
+// PostgreSQL o "LONGBLOB" en MySQL.
+func (s *SQLRepository) CreateTables(blobType string) error {
+	if blobType == "" {
+		blobType = "BLOB"
+	}
+	statements := []string{
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (nit VARCHAR(20), doc_type VARCHAR(20), number VARCHAR(50), "+
+			"uuid VARCHAR(100) NOT NULL UNIQUE, status VARCHAR(20) NOT NULL, track_id VARCHAR(100), status_code VARCHAR(10), "+
+			"status_message VARCHAR(1000), error_messages TEXT, created_at BIGINT NOT NULL, updated_at BIGINT NOT NULL, "+
+			"PRIMARY KEY (nit, doc_type, number))", s.documents),
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (nit VARCHAR(20), doc_type VARCHAR(20), number VARCHAR(50), "+
+			"stage VARCHAR(20), file_name VARCHAR(200) NOT NULL, data %s NOT NULL, PRIMARY KEY (nit, doc_type, number, stage))", s.artifacts, blobType),
+	}
+	for _, statement := range statements {
+		if _, err := s.db.Exec(statement); err != nil {
+			return fmt.Errorf("failed to create repository tables: %w", err)
+		}
+	}
+	return nil
+}
+
+// keyWhere condición por clave con los marcadores desde n
+func (s *SQLRepository) keyWhere(n int) string {
+	p := s.placeholder
+	return fmt.Sprintf("nit = %s AND doc_type = %s AND number = %s", p(n), p(n+1), p(n+2))
+}
+
+// Save implementa Repository
+func (s *SQLRepository) Save(rec *Record) error {
+	rec.NIT = normalizeNIT(rec.NIT)
+	if err := rec.validate(); err != nil {
+		return err
+	}
+	p := s.placeholder
+	key := []interface{}{rec.NIT, string(rec.Type), rec.Number}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var other Key
+	err = tx.QueryRow(fmt.Sprintf("SELECT nit, doc_type, number FROM %s WHERE uuid = %s", s.documents, p(1)), rec.UUID).
+		Scan(&other.NIT, &other.Type, &other.Number)
+	switch {
+	case err == nil && other != rec.Key:
+		return fmt.Errorf("%w: %s is %s", ErrDuplicateUUID, rec.UUID, other)
+	case err != nil && !errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("failed to save %s: %w", rec.Key, err)
+	}
+
+	var created int64
+	err = tx.QueryRow(fmt.Sprintf("SELECT created_at FROM %s WHERE %s", s.documents, s.keyWhere(1)), key...).Scan(&created)
+	switch {
+	case err == nil:
+		rec.CreatedAt = time.Unix(0, created).UTC()
+	case !errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("failed to save %s: %w", rec.Key, err)
+	}
+	now := time.Now().UTC()
+	if rec.CreatedAt.IsZero() {
+		rec.CreatedAt = now
+	}
+	rec.UpdatedAt = now
+
+	var errorMessages string
+	if len(rec.ErrorMessages) > 0 {
+		data, err := json.Marshal(rec.ErrorMessages)
+		if err != nil {
+			return fmt.Errorf("failed to save %s: %w", rec.Key, err)
+		}
+		errorMessages = string(data)
+	}
+
+	for _, table := range []string{s.artifacts, s.documents} {
+		if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s", table, s.keyWhere(1)), key...); err != nil {
+			return fmt.Errorf("failed to save %s: %w", rec.Key, err)
+		}
+	}
+	_, err = tx.Exec(fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s)", s.documents, documentColumns,
+		p(1), p(2), p(3), p(4), p(5), p(6), p(7), p(8), p(9), p(10), p(11)),
+		rec.NIT, string(rec.Type), rec.Number, rec.UUID, string(rec.Status), rec.TrackID, rec.StatusCode, rec.StatusMessage,
+		errorMessages, rec.CreatedAt.UnixNano(), rec.UpdatedAt.UnixNano())
+	if err != nil {
+		return fmt.Errorf("failed to save %s: %w", rec.Key, err)
+	}
+	insert := fmt.Sprintf("INSERT INTO %s (nit, doc_type, number, stage, file_name, data) VALUES (%s, %s, %s, %s, %s, %s)",
+		s.artifacts, p(1), p(2), p(3), p(4), p(5), p(6))
+	for _, a := range rec.Artifacts {
+		if _, err := tx.Exec(insert, rec.NIT, string(rec.Type), rec.Number, string(a.Stage), a.FileName, a.Data); err != nil {
+			return fmt.Errorf("failed to save %s artifact %s: %w", rec.Key, a.Stage, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Get implementa Repository
+func (s *SQLRepository) Get(key Key) (*Record, error) {
+	key.NIT = normalizeNIT(key.NIT)
+	if err := key.validate(); err != nil {
+		return nil, err
+	}
+	records, err := s.query(fmt.Sprintf("SELECT %s FROM %s WHERE %s", documentColumns, s.documents, s.keyWhere(1)),
+		key.NIT, string(key.Type), key.Number)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return records[0], s.artifactsOf(records[0], true)
+}
+
+// FindByUUID implementa Repository
+func (s *SQLRepository) FindByUUID(uuid string) (*Record, error) {
+	records, err := s.query(fmt.Sprintf("SELECT %s FROM %s WHERE uuid = %s", documentColumns, s.documents, s.placeholder(1)), uuid)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: UUID %s", ErrNotFound, uuid)
+	}
+	return records[0], s.artifactsOf(records[0], true)
+}
+
+// List implementa Repository
+func (s *SQLRepository) List(filter Filter) ([]*Record, error) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, s.placeholder(len(args))))
+	}
+	if filter.NIT != "" {
+		add("nit = %s", normalizeNIT(filter.NIT))
+	}
+	if filter.Type != "" {
+		add("doc_type = %s", string(filter.Type))
+	}
+	if filter.Status != "" {
+		add("status = %s", string(filter.Status))
+	}
+	if !filter.From.IsZero() {
+		add("created_at >= %s", filter.From.UnixNano())
+	}
+	if !filter.To.IsZero() {
+		add("created_at < %s", filter.To.UnixNano())
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s", documentColumns, s.documents)
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	records, err := s.query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	for _, rec := range records {
+		if err := s.artifactsOf(rec, false); err != nil {
+			return nil, err
+		}
+	}
+	sortRecords(records)
+	return records, nil
+}
+
+// Delete implementa Repository
+func (s *SQLRepository) Delete(key Key) error {
+	key.NIT = normalizeNIT(key.NIT)
+	if err := key.validate(); err != nil {
+		return err
+	}
+	args := []interface{}{key.NIT, string(key.Type), key.Number}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s", s.documents, s.keyWhere(1)), args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s", s.artifacts, s.keyWhere(1)), args...); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return tx.Commit()
+}
+
+// query lee registros de documentos (sin artefactos)
+func (s *SQLRepository) query(query string, args ...interface{}) ([]*Record, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query documents: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*Record
+	for rows.Next() {
+		rec := &Record{}
+		var docType, status string
+		var errorMessages sql.NullString
+		var created, updated int64
+		if err := rows.Scan(&rec.NIT, &docType, &rec.Number, &rec.UUID, &status, &rec.TrackID, &rec.StatusCode,
+			&rec.StatusMessage, &errorMessages, &created, &updated); err != nil {
+			return nil, fmt.Errorf("failed to query documents: %w", err)
+		}
+		if errorMessages.String != "" {
+			if err := json.Unmarshal([]byte(errorMessages.String), &rec.ErrorMessages); err != nil {
+				return nil, fmt.Errorf("failed to parse error messages of %s: %w", rec.Key, err)
+			}
+		}
+		rec.Type, rec.Status = pipeline.DocumentType(docType), Status(status)
+		rec.CreatedAt, rec.UpdatedAt = time.Unix(0, created).UTC(), time.Unix(0, updated).UTC()
+		records = append(records, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query documents: %w", err)
+	}
+	return records, nil
+}
+
+// artifactsOf carga los artefactos del registro, con contenido si withData
+func (s *SQLRepository) artifactsOf(rec *Record, withData bool) error {
+	columns := "stage, file_name"
+	if withData {
+		columns += ", data"
+	}
+	rows, err := s.db.Query(fmt.Sprintf("SELECT %s FROM %s WHERE %s", columns, s.artifacts, s.keyWhere(1)),
+		rec.NIT, string(rec.Type), rec.Number)
+	if err != nil {
+		return fmt.Errorf("failed to query artifacts of %s: %w", rec.Key, err)
+	}
+	defer rows.Close()
+
+	rec.Artifacts = nil
+	for rows.Next() {
+		var a pipeline.Artifact
+		var stage string
+		dest := []interface{}{&stage, &a.FileName}
+		if withData {
+			dest = append(dest, &a.Data)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return fmt.Errorf("failed to query artifacts of %s: %w", rec.Key, err)
+		}
+		a.Stage = pipeline.Stage(stage)
+		rec.Artifacts = append(rec.Artifacts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	sortArtifacts(rec.Artifacts)
+	return nil
+}
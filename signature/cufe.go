@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/diegofxm/ubl21-dian/qr"
 )
 
 // CalculateCUFE calcula el CUFE (Código Único de Factura Electrónica)
@@ -95,6 +97,40 @@ func CalculateCUDE(
 	)
 }
 
+// CalculateCUDS calcula el CUDS (Código Único de Documento Soporte)
+// Algoritmo: SHA-384 de NumDS + FecDS + HorDS + ValDS + 01 + ValImp + ValTol +
+// NIT del vendedor + NIT del adquiriente (obligado) + PIN del software + ambiente
+func CalculateCUDS(
+	documentNumber string,
+	issueDate time.Time,
+	issueTime string,
+	taxExclusiveAmount float64,
+	taxAmount float64, // IVA
+	payableAmount float64,
+	sellerNIT string,
+	buyerNIT string,
+	softwarePIN string,
+	environment string,
+) string {
+	cudsString := strings.Join([]string{
+		documentNumber,
+		issueDate.Format("2006-01-02"),
+		issueTime,
+		fmt.Sprintf("%.2f", taxExclusiveAmount),
+		"01", // Código impuesto IVA
+		fmt.Sprintf("%.2f", taxAmount),
+		fmt.Sprintf("%.2f", payableAmount),
+		sellerNIT,
+		buyerNIT,
+		softwarePIN,
+		environment,
+	}, "")
+
+	hash := sha512.New384()
+	hash.Write([]byte(cudsString))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
 // CalculateSoftwareSecurityCode calcula el código de seguridad del software
 // Algoritmo: SHA-384 de (SoftwareID + SoftwarePIN + InvoiceNumber)
 func CalculateSoftwareSecurityCode(softwareID, softwarePIN, invoiceNumber string) string {
@@ -107,26 +143,35 @@ func CalculateSoftwareSecurityCode(softwareID, softwarePIN, invoiceNumber string
 	return securityCode
 }
 
-// GenerateQRCode genera el contenido del código QR para DIAN
-// Retorna solo la URL según el formato de DIAN
+// GenerateQRCode genera el contenido del código QR de una factura: NumFac,
+// FecFac, HorFac, NitFac, DocAdq, ValFac, ValIva, ValOtroIm, ValTolFac, CUFE y
+// el enlace de consulta. La hora se toma de issueDate y ValOtroIm queda en
+// cero; para otros impuestos, notas, documento soporte, POS o nómina usar
+// qr.Payload
 func GenerateQRCode(
 	invoiceNumber string,
 	issueDate time.Time,
 	supplierNIT string,
 	customerNIT string,
 	taxExclusiveAmount float64,
-	taxAmount float64,
+	taxAmount float64, // IVA
 	payableAmount float64,
 	cufe string,
 	environment string, // "1" = Producción, "2" = Habilitación
 ) string {
-	baseURL := "https://catalogo-vpfe.dian.gov.co"
-	if environment == "2" {
-		baseURL = "https://catalogo-vpfe-hab.dian.gov.co"
-	}
-
-	// Solo retornar la URL como en factura_real.xml
-	return fmt.Sprintf("%s/document/searchqr?documentkey=%s", baseURL, cufe)
+	return qr.Payload{
+		Document:    qr.Invoice,
+		Number:      invoiceNumber,
+		IssueDate:   issueDate.Format("2006-01-02"),
+		IssueTime:   issueDate.Format("15:04:05-07:00"),
+		SupplierID:  supplierNIT,
+		CustomerID:  customerNIT,
+		Subtotal:    taxExclusiveAmount,
+		IVA:         taxAmount,
+		Total:       payableAmount,
+		UUID:        cufe,
+		Environment: environment,
+	}.String()
 }
 
 // CalculateLineExtensionAmount calcula el monto de extensión de línea
@@ -0,0 +1,363 @@
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	_ "crypto/sha1" // Algoritmos de digest de XMLDSig
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	xmlpkg "github.com/diegofxm/ubl21-dian/xml"
+)
+
+var (
+	ErrNotSigned        = errors.New("document is not signed")
+	ErrDigestMismatch   = errors.New("signature digest mismatch")
+	ErrInvalidSignature = errors.New("invalid signature value")
+)
+
+// Algoritmos de XMLDSig admitidos por la política de firma de DIAN
+const (
+	nsDS = "http://www.w3.org/2000/09/xmldsig#"
+
+	algC14N         = "http://www.w3.org/TR/2001/REC-xml-c14n-20010315"
+	algExcC14N      = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	algEnveloped    = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+	typeSignedProps = "http://uri.etsi.org/01903#SignedProperties"
+)
+
+var digestMethods = map[string]crypto.Hash{
+	"http://www.w3.org/2000/09/xmldsig#sha1":        crypto.SHA1,
+	"http://www.w3.org/2001/04/xmlenc#sha256":       crypto.SHA256,
+	"http://www.w3.org/2001/04/xmldsig-more#sha384": crypto.SHA384,
+	"http://www.w3.org/2001/04/xmlenc#sha512":       crypto.SHA512,
+}
+
+var signatureMethods = map[string]crypto.Hash{
+	"http://www.w3.org/2000/09/xmldsig#rsa-sha1":        crypto.SHA1,
+	"http://www.w3.org/2001/04/xmldsig-more#rsa-sha256": crypto.SHA256,
+	"http://www.w3.org/2001/04/xmldsig-more#rsa-sha384": crypto.SHA384,
+	"http://www.w3.org/2001/04/xmldsig-more#rsa-sha512": crypto.SHA512,
+}
+
+// VerifyOptions opciones de verificación de la firma de un documento
+type VerifyOptions struct {
+	Roots *x509.CertPool // Ancla de confianza; nil = no se valida la cadena
+	Now   time.Time      // Fecha de validez del certificado (zero = SigningTime)
+}
+
+// Verification datos de una firma XAdES válida
+type Verification struct {
+	Certificate *x509.Certificate
+	SigningTime time.Time
+	Role        string // ClaimedRole: supplier, third party
+	Policy      string // Identificador de la política de firma
+	Trusted     bool   // La cadena se validó contra VerifyOptions.Roots
+}
+
+// xadesSignature ds:Signature con las propiedades XAdES
+type xadesSignature struct {
+	SignedInfo struct {
+		CanonicalizationMethod struct {
+			Algorithm string `xml:"Algorithm,attr"`
+		} `xml:"http://www.w3.org/2000/09/xmldsig# CanonicalizationMethod"`
+		SignatureMethod struct {
+			Algorithm string `xml:"Algorithm,attr"`
+		} `xml:"http://www.w3.org/2000/09/xmldsig# SignatureMethod"`
+		References []struct {
+			URI        string `xml:"URI,attr"`
+			Type       string `xml:"Type,attr"`
+			Transforms []struct {
+				Algorithm string `xml:"Algorithm,attr"`
+			} `xml:"http://www.w3.org/2000/09/xmldsig# Transforms>Transform"`
+			DigestMethod struct {
+				Algorithm string `xml:"Algorithm,attr"`
+			} `xml:"http://www.w3.org/2000/09/xmldsig# DigestMethod"`
+			DigestValue string `xml:"http://www.w3.org/2000/09/xmldsig# DigestValue"`
+		} `xml:"http://www.w3.org/2000/09/xmldsig# Reference"`
+	} `xml:"http://www.w3.org/2000/09/xmldsig# SignedInfo"`
+	SignatureValue string   `xml:"http://www.w3.org/2000/09/xmldsig# SignatureValue"`
+	Certificates   []string `xml:"http://www.w3.org/2000/09/xmldsig# KeyInfo>X509Data>X509Certificate"`
+	Properties     struct {
+		SigningTime string `xml:"http://uri.etsi.org/01903/v1.3.2# SigningTime"`
+		CertDigest  struct {
+			DigestMethod struct {
+				Algorithm string `xml:"Algorithm,attr"`
+			} `xml:"http://www.w3.org/2000/09/xmldsig# DigestMethod"`
+			DigestValue string `xml:"http://www.w3.org/2000/09/xmldsig# DigestValue"`
+		} `xml:"http://uri.etsi.org/01903/v1.3.2# SigningCertificate>Cert>CertDigest"`
+		Policy string `xml:"http://uri.etsi.org/01903/v1.3.2# SignaturePolicyIdentifier>SignaturePolicyId>SigPolicyId>Identifier"`
+		Role   string `xml:"http://uri.etsi.org/01903/v1.3.2# SignerRole>ClaimedRoles>ClaimedRole"`
+	} `xml:"Object>QualifyingProperties>SignedProperties>SignedSignatureProperties"`
+}
+
+// Verify verifica la firma XAdES-BES de un documento UBL firmado
+//
+// Comprueba el digest de cada referencia (documento con la transformación
+// enveloped, KeyInfo y SignedProperties), el SignatureValue con el
+// certificado de KeyInfo, el CertDigest de SigningCertificate y, si
+// opts.Roots no es nil, la cadena del certificado. Acepta también la forma
+// que produce Signer.SignXML, que canonicaliza KeyInfo, SignedProperties y
+// SignedInfo solo con sus propios namespaces. Rechaza documentos con más de
+// una firma o con Id repetidos (ataques de envoltura).
+func Verify(signedXML []byte, opts VerifyOptions) (*Verification, error) {
+	signedInfo, err := scanSignature(signedXML)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := xmlpkg.FindElement(signedXML, xmlpkg.ByName(nsDS, "Signature"))
+	if errors.Is(err, xmlpkg.ErrElementNotFound) {
+		return nil, ErrNotSigned
+	}
+	if err != nil {
+		return nil, err
+	}
+	if signedInfo < start || signedInfo >= end {
+		return nil, fmt.Errorf("%w: ds:SignedInfo outside ds:Signature", ErrInvalidSignature)
+	}
+	sigXML := signedXML[start:end]
+
+	var sig xadesSignature
+	if err := xml.Unmarshal(sigXML, &sig); err != nil {
+		return nil, fmt.Errorf("invalid ds:Signature: %w", err)
+	}
+	if len(sig.Certificates) == 0 {
+		return nil, fmt.Errorf("%w: no X509Certificate in KeyInfo", ErrInvalidCertificate)
+	}
+	der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(sig.Certificates[0]), ""))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCertificate, err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCertificate, err)
+	}
+
+	// Forma estándar; si falla, la de Signer.SignXML
+	v := &verifier{document: signedXML, signature: sigXML, start: start, end: end, signedInfo: signedInfo, sig: &sig, cert: cert}
+	if err := v.verify(false); err != nil {
+		if v.verify(true) != nil {
+			return nil, err
+		}
+	}
+
+	result := &Verification{
+		Certificate: cert,
+		Role:        strings.TrimSpace(sig.Properties.Role),
+		Policy:      strings.TrimSpace(sig.Properties.Policy),
+	}
+	if t, err := time.Parse(time.RFC3339, strings.TrimSpace(sig.Properties.SigningTime)); err == nil {
+		result.SigningTime = t
+	}
+
+	// SigningCertificate debe ser el certificado de KeyInfo
+	certDigest := sig.Properties.CertDigest
+	if certDigest.DigestValue == "" {
+		return nil, fmt.Errorf("%w: no SigningCertificate", ErrInvalidCertificate)
+	}
+	digest, err := digestOf(certDigest.DigestMethod.Algorithm, cert.Raw)
+	if err != nil {
+		return nil, err
+	}
+	if digest != strings.TrimSpace(certDigest.DigestValue) {
+		return nil, fmt.Errorf("%w: SigningCertificate digest does not match KeyInfo", ErrInvalidCertificate)
+	}
+
+	if opts.Roots != nil {
+		now := opts.Now
+		if now.IsZero() {
+			now = result.SigningTime
+		}
+		if _, err := cert.Verify(x509.VerifyOptions{
+			Roots:       opts.Roots,
+			CurrentTime: now,
+			KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		}); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCertificate, err)
+		}
+		result.Trusted = true
+	}
+	return result, nil
+}
+
+// verifier estado de la verificación de una firma
+type verifier struct {
+	document   []byte
+	signature  []byte // ds:Signature original
+	start, end int    // Posición de la firma en el documento
+	signedInfo int    // Posición del ds:SignedInfo de la firma
+	sig        *xadesSignature
+	cert       *x509.Certificate
+}
+
+// verify comprueba referencias y SignatureValue; isolated = forma de SignXML
+func (v *verifier) verify(isolated bool) error {
+	signedProps := false
+	for _, ref := range v.sig.SignedInfo.References {
+		var element []byte
+		var err error
+		switch {
+		case ref.URI == "":
+			element = v.enveloped(isolated)
+		case isolated:
+			element, err = xmlpkg.ExtractElement(v.signature, xmlpkg.ByID(strings.TrimPrefix(ref.URI, "#")))
+		default:
+			element, err = xmlpkg.ExtractElement(v.document, xmlpkg.ByID(strings.TrimPrefix(ref.URI, "#")))
+		}
+		if err != nil {
+			return fmt.Errorf("%w: reference %q: %v", ErrDigestMismatch, ref.URI, err)
+		}
+
+		method := ""
+		for _, t := range ref.Transforms {
+			if t.Algorithm != algEnveloped {
+				method = t.Algorithm
+			}
+		}
+		canonical, err := canonicalize(method, element)
+		if err != nil {
+			return fmt.Errorf("%w: reference %q: %v", ErrDigestMismatch, ref.URI, err)
+		}
+		digest, err := digestOf(ref.DigestMethod.Algorithm, canonical)
+		if err != nil {
+			return err
+		}
+		if digest != strings.TrimSpace(ref.DigestValue) {
+			return fmt.Errorf("%w: reference %q", ErrDigestMismatch, ref.URI)
+		}
+		signedProps = signedProps || ref.Type == typeSignedProps
+	}
+	if !signedProps {
+		return fmt.Errorf("%w: SignedProperties is not signed", ErrDigestMismatch)
+	}
+
+	// SignXML firma los bytes de SignedInfo tal como quedan en la firma
+	var canonical []byte
+	if isolated {
+		start, end, err := xmlpkg.FindElement(v.signature, xmlpkg.ByName(nsDS, "SignedInfo"))
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+		}
+		canonical = v.signature[start:end]
+	} else {
+		// scanSignature garantiza que el único ds:SignedInfo es el de la firma
+		if start, _, err := xmlpkg.FindElement(v.document, xmlpkg.ByName(nsDS, "SignedInfo")); err != nil || start != v.signedInfo {
+			return fmt.Errorf("%w: ds:SignedInfo not found in ds:Signature", ErrInvalidSignature)
+		}
+		signedInfo, err := xmlpkg.ExtractElement(v.document, xmlpkg.ByName(nsDS, "SignedInfo"))
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+		}
+		if canonical, err = canonicalize(v.sig.SignedInfo.CanonicalizationMethod.Algorithm, signedInfo); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+		}
+	}
+	value, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(v.sig.SignatureValue), ""))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	hashID, ok := signatureMethods[v.sig.SignedInfo.SignatureMethod.Algorithm]
+	if !ok {
+		return fmt.Errorf("%w: unsupported signature method %q", ErrInvalidSignature, v.sig.SignedInfo.SignatureMethod.Algorithm)
+	}
+	key, ok := v.cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("%w: certificate key is not RSA", ErrInvalidSignature)
+	}
+	h := hashID.New()
+	h.Write(canonical)
+	if err := rsa.VerifyPKCS1v15(key, hashID, h.Sum(nil), value); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	return nil
+}
+
+// scanSignature posición del único ds:SignedInfo (-1 si no hay firma)
+//
+// Rechaza más de un ds:Signature o ds:SignedInfo y los Id repetidos, que
+// permitirían verificar un elemento distinto del que se usa.
+func scanSignature(document []byte) (int, error) {
+	signatures, signedInfo := 0, -1
+	ids := map[string]bool{}
+	decoder := xml.NewDecoder(bytes.NewReader(document))
+	for {
+		offset := int(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return signedInfo, nil
+		}
+		if err != nil {
+			return -1, fmt.Errorf("invalid XML: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name {
+		case xml.Name{Space: nsDS, Local: "Signature"}:
+			if signatures++; signatures > 1 {
+				return -1, fmt.Errorf("%w: more than one ds:Signature", ErrInvalidSignature)
+			}
+		case xml.Name{Space: nsDS, Local: "SignedInfo"}:
+			if signedInfo >= 0 {
+				return -1, fmt.Errorf("%w: more than one ds:SignedInfo", ErrInvalidSignature)
+			}
+			signedInfo = offset
+		}
+		for _, attr := range start.Attr {
+			switch attr.Name.Local {
+			case "Id", "ID", "id":
+				if ids[attr.Value] {
+					return -1, fmt.Errorf("%w: duplicate Id %q", ErrInvalidSignature, attr.Value)
+				}
+				ids[attr.Value] = true
+			}
+		}
+	}
+}
+
+// enveloped documento sin la firma; en la forma de SignXML se quita también
+// la UBLExtension que la contiene
+func (v *verifier) enveloped(isolated bool) []byte {
+	start, end := v.start, v.end
+	if isolated {
+		const open, close = "<ext:UBLExtension><ext:ExtensionContent>", "</ext:ExtensionContent></ext:UBLExtension>"
+		if bytes.HasSuffix(v.document[:start], []byte(open)) && bytes.HasPrefix(v.document[end:], []byte(close)) {
+			start, end = start-len(open), end+len(close)
+		}
+	}
+	out := make([]byte, 0, len(v.document)-(end-start))
+	out = append(out, v.document[:start]...)
+	return append(out, v.document[end:]...)
+}
+
+// canonicalize C14N inclusivo (por defecto) o exclusivo
+func canonicalize(method string, data []byte) ([]byte, error) {
+	switch method {
+	case "", algC14N:
+		return xmlpkg.Canonicalize(data)
+	case algExcC14N:
+		return xmlpkg.CanonicalizeExclusive(data, nil)
+	}
+	return nil, fmt.Errorf("unsupported canonicalization method %q", method)
+}
+
+// digestOf digest base64 según el algoritmo de XMLDSig
+func digestOf(algorithm string, data []byte) (string, error) {
+	hashID, ok := digestMethods[algorithm]
+	if !ok {
+		return "", fmt.Errorf("%w: unsupported digest method %q", ErrDigestMismatch, algorithm)
+	}
+	h := hashID.New()
+	h.Write(data)
+	return base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+}
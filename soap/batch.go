@@ -0,0 +1,279 @@
+package soap
+
+import (
+	"encoding/base64"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/documents/applicationresponse"
+	"github.com/diegofxm/ubl21-dian/naming"
+	"github.com/diegofxm/ubl21-dian/soap/types"
+	xmlpkg "github.com/diegofxm/ubl21-dian/xml"
+)
+
+// MaxBatchDocuments máximo de documentos por ZIP que acepta SendBillAsync
+const MaxBatchDocuments = 50
+
+// BatchDocument documento firmado dentro de un lote
+type BatchDocument struct {
+	FileName string // Nombre del XML dentro del ZIP
+	Number   string // cbc:ID (prefijo + consecutivo)
+	UUID     string // CUFE/CUDE/CUDS
+	XML      []byte
+}
+
+// Batch lote de documentos firmados para SendBillAsync o SendTestSetAsync
+//
+//	batch := soap.NewBatch("z0900123456000250000001.zip")
+//	batch.Add("fv0900123456000250000001.xml", signedXML)
+//	submission, err := batch.Submit(client)
+//	...
+//	result, err := submission.Poll(client)
+type Batch struct {
+	FileName  string // Nombre del ZIP (fileName del request)
+	TestSetID string // Si se informa, el lote se envía con SendTestSetAsync
+	documents []BatchDocument
+}
+
+// BatchSender operaciones de DIAN que usa el lote (*Client las implementa)
+type BatchSender interface {
+	SendBillAsync(req *types.SendBillAsyncRequest) (*types.SendBillAsyncResponse, error)
+	SendTestSetAsync(req *types.SendTestSetAsyncRequest) (*types.SendTestSetAsyncResponse, error)
+	GetStatusZip(req *types.GetStatusZipRequest) (*types.GetStatusZipResponse, error)
+}
+
+// BatchSubmission lote enviado, identificado por el ZipKey de DIAN
+type BatchSubmission struct {
+	ZipKey      string
+	FileName    string
+	Documents   []BatchDocument
+	SubmittedAt time.Time
+}
+
+// BatchResult resultado de GetStatusZip mapeado a los documentos del lote
+type BatchResult struct {
+	ZipKey        string
+	StatusCode    string
+	StatusMessage string
+	Pending       bool             // DIAN aún está validando el lote (StatusPending)
+	Documents     []DocumentResult // En el orden del lote
+}
+
+// DocumentResult ApplicationResponse de un documento del lote
+type DocumentResult struct {
+	Document            BatchDocument
+	Found               bool   // DIAN retornó ApplicationResponse para el documento
+	FileName            string // Nombre del ApplicationResponse dentro del ZIP de DIAN
+	XML                 []byte // ApplicationResponse firmado por DIAN
+	ApplicationResponse *applicationresponse.ApplicationResponseData
+}
+
+// IsValid indica que DIAN validó el documento (ResponseCode 02)
+func (r DocumentResult) IsValid() bool {
+	return r.ApplicationResponse != nil && r.ApplicationResponse.IsValidated()
+}
+
+// NewBatch crea un lote vacío
+func NewBatch(fileName string) *Batch {
+	return &Batch{FileName: fileName}
+}
+
+// Add agrega un documento firmado al lote
+// El número y el CUFE/CUDE se leen del XML para mapear luego las respuestas.
+func (b *Batch) Add(fileName string, signedXML []byte) error {
+	if len(b.documents) >= MaxBatchDocuments {
+		return NewSOAPError("Batch", ErrInvalidRequest, fmt.Sprintf("batch already has %d documents", MaxBatchDocuments), nil)
+	}
+	if !strings.EqualFold(path.Ext(fileName), ".xml") || path.Base(fileName) != fileName {
+		return NewSOAPError("Batch", ErrInvalidRequest, fmt.Sprintf("invalid member name %q", fileName), nil)
+	}
+
+	var header struct {
+		ID   string `xml:"cbc:ID"`
+		UUID string `xml:"cbc:UUID"`
+	}
+	if err := xmlpkg.Unmarshal(signedXML, &header); err != nil {
+		return NewSOAPError("Batch", ErrInvalidRequest, fmt.Sprintf("%s is not valid XML", fileName), err)
+	}
+	if header.ID == "" || header.UUID == "" {
+		return NewSOAPError("Batch", ErrInvalidRequest, fmt.Sprintf("%s has no cbc:ID or cbc:UUID", fileName), nil)
+	}
+
+	for _, doc := range b.documents {
+		switch {
+		case strings.EqualFold(doc.FileName, fileName):
+			return NewSOAPError("Batch", ErrInvalidRequest, fmt.Sprintf("duplicate member name %q", fileName), nil)
+		case doc.UUID == header.UUID:
+			return NewSOAPError("Batch", ErrInvalidRequest, fmt.Sprintf("document %s already in batch", header.ID), nil)
+		}
+	}
+
+	b.documents = append(b.documents, BatchDocument{
+		FileName: fileName,
+		Number:   strings.TrimSpace(header.ID),
+		UUID:     strings.TrimSpace(header.UUID),
+		XML:      signedXML,
+	})
+	return nil
+}
+
+// Len número de documentos del lote
+func (b *Batch) Len() int {
+	return len(b.documents)
+}
+
+// Documents documentos del lote en orden
+func (b *Batch) Documents() []BatchDocument {
+	return append([]BatchDocument(nil), b.documents...)
+}
+
+// Package genera el ZIP del lote en base64 (contentFile)
+// El ZIP es determinístico: el mismo lote produce siempre los mismos bytes.
+func (b *Batch) Package() (string, error) {
+	if len(b.documents) == 0 {
+		return "", NewSOAPError("Batch", ErrInvalidRequest, "batch is empty", nil)
+	}
+
+	files := make([]naming.File, len(b.documents))
+	for i, doc := range b.documents {
+		files[i] = naming.File{Name: doc.FileName, Data: doc.XML}
+	}
+	content, err := naming.ZipFiles(files...)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(content), nil
+}
+
+// Submit empaqueta y envía el lote, retornando el ZipKey para consultarlo
+func (b *Batch) Submit(sender BatchSender) (*BatchSubmission, error) {
+	content, err := b.Package()
+	if err != nil {
+		return nil, err
+	}
+
+	var zipKey string
+	if b.TestSetID != "" {
+		resp, err := sender.SendTestSetAsync(&types.SendTestSetAsyncRequest{
+			FileName:    b.FileName,
+			ContentFile: content,
+			TestSetId:   b.TestSetID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		zipKey = resp.ZipKey
+	} else {
+		resp, err := sender.SendBillAsync(&types.SendBillAsyncRequest{
+			FileName:    b.FileName,
+			ContentFile: content,
+		})
+		if err != nil {
+			return nil, err
+		}
+		zipKey = resp.ZipKey
+	}
+
+	if zipKey == "" {
+		return nil, NewSOAPError("Batch", ErrUnexpectedResponse, "DIAN did not return a ZipKey", nil)
+	}
+
+	return &BatchSubmission{
+		ZipKey:      zipKey,
+		FileName:    b.FileName,
+		Documents:   b.Documents(),
+		SubmittedAt: time.Now(),
+	}, nil
+}
+
+// Poll consulta GetStatusZip y mapea cada ApplicationResponse a su documento
+// Si DIAN aún procesa el lote, Pending es true y Documents queda sin respuestas.
+func (s *BatchSubmission) Poll(sender BatchSender) (*BatchResult, error) {
+	resp, err := sender.GetStatusZip(&types.GetStatusZipRequest{TrackId: s.ZipKey})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BatchResult{
+		ZipKey:        s.ZipKey,
+		StatusCode:    resp.StatusCode,
+		StatusMessage: resp.StatusMessage,
+		Pending:       resp.StatusCode == StatusPending,
+	}
+
+	var responses []DocumentResult
+	if resp.ContentFile != "" {
+		responses, err = SplitApplicationResponses(resp.ContentFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Mapear por CUFE/CUDE y, si falta, por número de documento
+	for _, doc := range s.Documents {
+		entry := DocumentResult{Document: doc}
+		for _, r := range responses {
+			ref := r.ApplicationResponse.DocumentReference
+			if ref.UUID == doc.UUID || (ref.UUID == "" && ref.ID == doc.Number) {
+				entry.Found = true
+				entry.FileName = r.FileName
+				entry.XML = r.XML
+				entry.ApplicationResponse = r.ApplicationResponse
+				break
+			}
+		}
+		result.Documents = append(result.Documents, entry)
+	}
+
+	return result, nil
+}
+
+// ByNumber busca el resultado de un documento por su número (cbc:ID)
+func (r *BatchResult) ByNumber(number string) (*DocumentResult, bool) {
+	for i := range r.Documents {
+		if r.Documents[i].Document.Number == number {
+			return &r.Documents[i], true
+		}
+	}
+	return nil, false
+}
+
+// ByUUID busca el resultado de un documento por su CUFE/CUDE
+func (r *BatchResult) ByUUID(uuid string) (*DocumentResult, bool) {
+	for i := range r.Documents {
+		if r.Documents[i].Document.UUID == uuid {
+			return &r.Documents[i], true
+		}
+	}
+	return nil, false
+}
+
+// SplitApplicationResponses separa el ZIP de GetStatusZip en ApplicationResponse por documento
+//
+// Cada resultado trae Document.Number y Document.UUID tomados del
+// DocumentReference del ApplicationResponse.
+func SplitApplicationResponses(contentFile string) ([]DocumentResult, error) {
+	payload, err := DecodePayload(contentFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []DocumentResult
+	for _, doc := range payload.ByKind(KindApplicationResponse) {
+		results = append(results, DocumentResult{
+			Document: BatchDocument{
+				Number: doc.ApplicationResponse.DocumentReference.ID,
+				UUID:   doc.ApplicationResponse.DocumentReference.UUID,
+			},
+			Found:               true,
+			FileName:            doc.FileName,
+			XML:                 doc.Raw,
+			ApplicationResponse: doc.ApplicationResponse,
+		})
+	}
+
+	return results, nil
+}
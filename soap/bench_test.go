@@ -0,0 +1,97 @@
+package soap_test
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/soap"
+	"github.com/diegofxm/ubl21-dian/soap/operations"
+	"github.com/diegofxm/ubl21-dian/soap/security"
+	"github.com/diegofxm/ubl21-dian/soap/simulator"
+	"github.com/diegofxm/ubl21-dian/soap/types"
+)
+
+// BenchmarkHeader costo del WS-Security header por request
+func BenchmarkHeader(b *testing.B) {
+	creds, err := simulator.NewTestCredentials(b.TempDir())
+	if err != nil {
+		b.Fatal(err)
+	}
+	const url = "https://vpfe-hab.dian.gov.co/WcfDianCustomerServices.svc"
+
+	b.Run("FromFiles", func(b *testing.B) {
+		for b.Loop() {
+			h, err := security.NewHeader(creds.CertPath, creds.KeyPath, url, soap.ActionGetStatus)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := h.Generate(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("FromCredentials", func(b *testing.B) {
+		loaded, err := security.LoadCredentials(creds.CertPath, creds.KeyPath)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for b.Loop() {
+			h, err := security.NewHeaderFromCredentials(loaded, url, soap.ActionGetStatus)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := h.Generate(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkParallelSend GetStatus desde 8 goroutines por CPU contra el simulador
+//
+// PerRequest reproduce el cliente anterior: credenciales leídas en cada
+// request y el pool por defecto de net/http (2 conexiones en reposo).
+func BenchmarkParallelSend(b *testing.B) {
+	creds, err := simulator.NewTestCredentials(b.TempDir())
+	if err != nil {
+		b.Fatal(err)
+	}
+	sim := simulator.New()
+	sim.SkipSecurity(true)
+	srv := httptest.NewServer(sim)
+	defer srv.Close()
+
+	req := &types.GetStatusRequest{TrackId: "ffff0d032c292b88b3f839f75a51e8459ab645eda8049b3c221649fd18aaea09d5b31c8787e071c6a7d4db6983faaead"}
+
+	b.Run("PerRequest", func(b *testing.B) {
+		transport := soap.NewTransport(srv.URL, nil, 5*time.Second)
+		transport.SetPool(soap.PoolConfig{MaxIdleConnsPerHost: 2, IdleConnTimeout: 90 * time.Second})
+		b.SetParallelism(8)
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				loaded, err := security.LoadCredentials(creds.CertPath, creds.KeyPath)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := operations.GetStatus(transport, loaded, srv.URL, soap.ActionGetStatus, req); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	})
+
+	b.Run("Shared", func(b *testing.B) {
+		transport := soap.NewTransport(srv.URL, nil, 5*time.Second)
+		loaded := creds.Credentials()
+		b.SetParallelism(8)
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := operations.GetStatus(transport, loaded, srv.URL, soap.ActionGetStatus, req); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/diegofxm/ubl21-dian/soap/operations"
+	"github.com/diegofxm/ubl21-dian/soap/security"
 	"github.com/diegofxm/ubl21-dian/soap/types"
 )
 
@@ -17,10 +18,21 @@ import (
 //   - envelope/: Construcción de SOAP envelopes
 //   - response/: Parsing de respuestas XML
 //   - transport/: Comunicación HTTP/HTTPS con mTLS
+//
+// Los SOAP Faults de DIAN se retornan como *SOAPError con FaultCode,
+// FaultSubcode, FaultReason y FaultDetail (ver IsAuthenticationFault,
+// IsSchemaFault e IsServerFault).
+//
+// Un Client es seguro para uso concurrente: las credenciales se cargan una
+// vez en NewClient y las conexiones HTTP se reutilizan entre goroutines.
+// Config.RateLimit y Config.MaxInFlight limitan la carga hacia DIAN.
 type Client struct {
-	config    *types.Config
-	transport *Transport
-	url       string
+	config      *types.Config
+	credentials *security.Credentials
+	transport   *Transport
+	url         string
+	urls        map[string]string // URL por nombre de operación (Config.OperationURLs)
+	profile     string            // ProfileExecutionID del ambiente ("" si no se conoce)
 }
 
 // NewClient crea un nuevo cliente SOAP configurado para DIAN
@@ -36,23 +48,118 @@ func NewClient(config *types.Config) (*Client, error) {
 		config.Timeout = 180 * time.Second
 	}
 
-	url := GetURL(config.Environment)
+	env, urls, err := resolveEndpoints(config)
+	if err != nil {
+		return nil, NewSOAPError("NewClient", ErrInvalidConfig, "invalid endpoint configuration", err)
+	}
 
-	// Crear TLS config desde certificados
-	tlsConfig, err := LoadClientTLSConfig(config.Certificate, config.PrivateKey)
+	// Cargar certificado y clave una sola vez (WS-Security y mTLS)
+	creds, err := security.LoadCredentials(config.Certificate, config.PrivateKey)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create TLS config: %w", err)
+		return nil, NewSOAPError("NewClient", ErrCertificateLoad, "failed to load credentials", err)
+	}
+
+	transport := NewTransport(env.URL, ClientTLSConfig(creds), config.Timeout)
+	if config.MaxConnections > 0 {
+		transport.SetPool(PoolConfig{
+			MaxConnsPerHost:     config.MaxConnections,
+			MaxIdleConnsPerHost: config.MaxConnections,
+			IdleConnTimeout:     DefaultPool.IdleConnTimeout,
+		})
+	}
+
+	// Límites de carga (antes del resto de interceptores)
+	if config.RateLimit > 0 {
+		transport.Use(RateLimit(config.RateLimit, config.RateBurst))
+	}
+	if config.MaxInFlight > 0 {
+		transport.Use(MaxInFlight(config.MaxInFlight))
 	}
 
-	transport := NewTransport(url, tlsConfig, config.Timeout)
+	// Verificar las respuestas firmadas de DIAN si hay ancla de confianza
+	if config.TrustAnchor != "" {
+		roots, err := security.LoadTrustAnchor(config.TrustAnchor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load trust anchor: %w", err)
+		}
+		transport.VerifyResponses(security.VerifyOptions{Roots: roots})
+	}
+
+	// Volcado de debug opt-in
+	if config.DebugDir != "" {
+		transport.Use(Dump(DumpConfig{Sink: DirSink(config.DebugDir), Redact: true}))
+	}
 
 	return &Client{
-		config:    config,
-		transport: transport,
-		url:       url,
+		config:      config,
+		credentials: creds,
+		transport:   transport,
+		url:         env.URL,
+		urls:        urls,
+		profile:     env.ProfileExecutionID,
 	}, nil
 }
 
+// resolveEndpoints determina el ambiente, con la URL base, y las URLs por
+// operación de config
+//
+// Prioridad: OperationURLs, luego Endpoint, luego la URL del ambiente
+// registrado (Habilitación si Environment y Endpoint están vacíos). Con
+// Endpoint y sin Environment no se conoce el ProfileExecutionID.
+func resolveEndpoints(config *types.Config) (EnvironmentInfo, map[string]string, error) {
+	env := config.Environment
+	if env == "" && config.Endpoint == "" {
+		env = types.Habilitacion
+	}
+	var info EnvironmentInfo
+	if env != "" {
+		var ok bool
+		if info, ok = LookupEnvironment(env); !ok {
+			return info, nil, fmt.Errorf("unknown environment %q (see RegisterEnvironment)", env)
+		}
+	}
+	if config.Endpoint != "" {
+		info.URL = config.Endpoint
+	}
+	if err := validateURL(info.URL); err != nil {
+		return info, nil, err
+	}
+
+	urls := make(map[string]string, len(config.OperationURLs))
+	for op, opURL := range config.OperationURLs {
+		name, ok := lookupOperation(op)
+		if !ok {
+			return info, nil, fmt.Errorf("unknown operation %q in OperationURLs", op)
+		}
+		if err := validateURL(opURL); err != nil {
+			return info, nil, fmt.Errorf("operation %s: %w", op, err)
+		}
+		urls[name] = opURL
+	}
+	return info, urls, nil
+}
+
+// ProfileExecutionID valor de cbc:ProfileExecutionID que corresponde al
+// ambiente del cliente ("1" producción, "2" pruebas); vacío si el cliente
+// usa Endpoint sin Environment
+func (c *Client) ProfileExecutionID() string {
+	return c.profile
+}
+
+// urlFor retorna la URL de destino de una operación según su SOAPAction
+// El mismo valor se firma en wsa:To del header WS-Security.
+func (c *Client) urlFor(action string) string {
+	if url, ok := c.urls[operationName(action)]; ok {
+		return url
+	}
+	return c.url
+}
+
+// Use agrega interceptores al transport del cliente (ver Interceptor)
+func (c *Client) Use(interceptors ...Interceptor) {
+	c.transport.Use(interceptors...)
+}
+
 // ============================================================================
 // GRUPO 1: OPERACIONES DE ENVÍO DE DOCUMENTOS
 // ============================================================================
@@ -60,31 +167,36 @@ func NewClient(config *types.Config) (*Client, error) {
 // SendBillSync envía una factura de forma síncrona
 // Delega a operations.SendBillSync
 func (c *Client) SendBillSync(req *types.SendBillSyncRequest) (*types.SendBillSyncResponse, error) {
-	return operations.SendBillSync(c.transport, c.config.Certificate, c.config.PrivateKey, c.url, ActionSendBillSync, req)
+	resp, err := operations.SendBillSync(c.transport, c.credentials, c.urlFor(ActionSendBillSync), ActionSendBillSync, req)
+	return resp, wrapFault("SendBillSync", err)
 }
 
 // SendBillAsync envía una factura de forma asíncrona
 // Delega a operations.SendBillAsync
 func (c *Client) SendBillAsync(req *types.SendBillAsyncRequest) (*types.SendBillAsyncResponse, error) {
-	return operations.SendBillAsync(c.transport, c.config.Certificate, c.config.PrivateKey, c.url, ActionSendBillAsync, req)
+	resp, err := operations.SendBillAsync(c.transport, c.credentials, c.urlFor(ActionSendBillAsync), ActionSendBillAsync, req)
+	return resp, wrapFault("SendBillAsync", err)
 }
 
 // SendTestSetAsync envía una factura al set de pruebas de DIAN
 // Delega a operations.SendTestSetAsync
 func (c *Client) SendTestSetAsync(req *types.SendTestSetAsyncRequest) (*types.SendTestSetAsyncResponse, error) {
-	return operations.SendTestSetAsync(c.transport, c.config.Certificate, c.config.PrivateKey, c.url, ActionSendTestSetAsync, req)
+	resp, err := operations.SendTestSetAsync(c.transport, c.credentials, c.urlFor(ActionSendTestSetAsync), ActionSendTestSetAsync, req)
+	return resp, wrapFault("SendTestSetAsync", err)
 }
 
 // SendBillAttachmentAsync envía documentos soporte (anexos)
 // Delega a operations.SendBillAttachmentAsync
 func (c *Client) SendBillAttachmentAsync(req *types.SendBillAttachmentAsyncRequest) (*types.SendBillAttachmentAsyncResponse, error) {
-	return operations.SendBillAttachmentAsync(c.transport, c.config.Certificate, c.config.PrivateKey, c.url, ActionSendBillAttachmentAsync, req)
+	resp, err := operations.SendBillAttachmentAsync(c.transport, c.credentials, c.urlFor(ActionSendBillAttachmentAsync), ActionSendBillAttachmentAsync, req)
+	return resp, wrapFault("SendBillAttachmentAsync", err)
 }
 
 // SendNominaSync envía nómina electrónica de forma síncrona
 // Delega a operations.SendNominaSync
 func (c *Client) SendNominaSync(req *types.SendNominaSyncRequest) (*types.SendNominaSyncResponse, error) {
-	return operations.SendNominaSync(c.transport, c.config.Certificate, c.config.PrivateKey, c.url, ActionSendNominaSync, req)
+	resp, err := operations.SendNominaSync(c.transport, c.credentials, c.urlFor(ActionSendNominaSync), ActionSendNominaSync, req)
+	return resp, wrapFault("SendNominaSync", err)
 }
 
 // ============================================================================
@@ -94,19 +206,22 @@ func (c *Client) SendNominaSync(req *types.SendNominaSyncRequest) (*types.SendNo
 // GetStatus consulta el estado de un documento por TrackId
 // Delega a operations.GetStatus
 func (c *Client) GetStatus(req *types.GetStatusRequest) (*types.GetStatusResponse, error) {
-	return operations.GetStatus(c.transport, c.config.Certificate, c.config.PrivateKey, c.url, ActionGetStatus, req)
+	resp, err := operations.GetStatus(c.transport, c.credentials, c.urlFor(ActionGetStatus), ActionGetStatus, req)
+	return resp, wrapFault("GetStatus", err)
 }
 
 // GetStatusZip consulta el estado y descarga el ZIP con ApplicationResponse
 // Delega a operations.GetStatusZip
 func (c *Client) GetStatusZip(req *types.GetStatusZipRequest) (*types.GetStatusZipResponse, error) {
-	return operations.GetStatusZip(c.transport, c.config.Certificate, c.config.PrivateKey, c.url, ActionGetStatusZip, req)
+	resp, err := operations.GetStatusZip(c.transport, c.credentials, c.urlFor(ActionGetStatusZip), ActionGetStatusZip, req)
+	return resp, wrapFault("GetStatusZip", err)
 }
 
 // GetStatusEvent consulta el estado de un evento de documento
 // Delega a operations.GetStatusEvent
 func (c *Client) GetStatusEvent(req *types.GetStatusEventRequest) (*types.GetStatusEventResponse, error) {
-	return operations.GetStatusEvent(c.transport, c.config.Certificate, c.config.PrivateKey, c.url, ActionGetStatusEvent, req)
+	resp, err := operations.GetStatusEvent(c.transport, c.credentials, c.urlFor(ActionGetStatusEvent), ActionGetStatusEvent, req)
+	return resp, wrapFault("GetStatusEvent", err)
 }
 
 // ============================================================================
@@ -116,7 +231,8 @@ func (c *Client) GetStatusEvent(req *types.GetStatusEventRequest) (*types.GetSta
 // SendEventUpdateStatus envía un evento de documento (acuse, rechazo, aceptación)
 // Delega a operations.SendEventUpdateStatus
 func (c *Client) SendEventUpdateStatus(req *types.SendEventRequest) (*types.SendEventResponse, error) {
-	return operations.SendEventUpdateStatus(c.transport, c.config.Certificate, c.config.PrivateKey, c.url, ActionSendEventUpdateStatus, req)
+	resp, err := operations.SendEventUpdateStatus(c.transport, c.credentials, c.urlFor(ActionSendEventUpdateStatus), ActionSendEventUpdateStatus, req)
+	return resp, wrapFault("SendEventUpdateStatus", err)
 }
 
 // ============================================================================
@@ -126,35 +242,41 @@ func (c *Client) SendEventUpdateStatus(req *types.SendEventRequest) (*types.Send
 // GetNumberingRange consulta rangos de numeración autorizados
 // Delega a operations.GetNumberingRange
 func (c *Client) GetNumberingRange(req *types.GetNumberingRangeRequest) (*types.GetNumberingRangeResponse, error) {
-	return operations.GetNumberingRange(c.transport, c.config.Certificate, c.config.PrivateKey, c.url, ActionGetNumberingRange, req)
+	resp, err := operations.GetNumberingRange(c.transport, c.credentials, c.urlFor(ActionGetNumberingRange), ActionGetNumberingRange, req)
+	return resp, wrapFault("GetNumberingRange", err)
 }
 
 // GetXmlByDocumentKey descarga el XML de un documento por CUFE/CUDE
 // Delega a operations.GetXmlByDocumentKey
 func (c *Client) GetXmlByDocumentKey(req *types.GetXmlByDocumentKeyRequest) (*types.GetXmlByDocumentKeyResponse, error) {
-	return operations.GetXmlByDocumentKey(c.transport, c.config.Certificate, c.config.PrivateKey, c.url, ActionGetXmlByDocumentKey, req)
+	resp, err := operations.GetXmlByDocumentKey(c.transport, c.credentials, c.urlFor(ActionGetXmlByDocumentKey), ActionGetXmlByDocumentKey, req)
+	return resp, wrapFault("GetXmlByDocumentKey", err)
 }
 
 // GetReferenceNotes consulta notas crédito/débito asociadas a una factura
 // Delega a operations.GetReferenceNotes
 func (c *Client) GetReferenceNotes(req *types.GetReferenceNotesRequest) (*types.GetReferenceNotesResponse, error) {
-	return operations.GetReferenceNotes(c.transport, c.config.Certificate, c.config.PrivateKey, c.url, ActionGetReferenceNotes, req)
+	resp, err := operations.GetReferenceNotes(c.transport, c.credentials, c.urlFor(ActionGetReferenceNotes), ActionGetReferenceNotes, req)
+	return resp, wrapFault("GetReferenceNotes", err)
 }
 
 // GetDocumentInfo consulta información completa de un documento
 // Delega a operations.GetDocumentInfo
 func (c *Client) GetDocumentInfo(req *types.GetDocumentInfoRequest) (*types.GetDocumentInfoResponse, error) {
-	return operations.GetDocumentInfo(c.transport, c.config.Certificate, c.config.PrivateKey, c.url, ActionGetDocumentInfo, req)
+	resp, err := operations.GetDocumentInfo(c.transport, c.credentials, c.urlFor(ActionGetDocumentInfo), ActionGetDocumentInfo, req)
+	return resp, wrapFault("GetDocumentInfo", err)
 }
 
 // GetAcquirer consulta información del adquiriente (comprador)
 // Delega a operations.GetAcquirer
 func (c *Client) GetAcquirer(req *types.GetAcquirerRequest) (*types.GetAcquirerResponse, error) {
-	return operations.GetAcquirer(c.transport, c.config.Certificate, c.config.PrivateKey, c.url, ActionGetAcquirer, req)
+	resp, err := operations.GetAcquirer(c.transport, c.credentials, c.urlFor(ActionGetAcquirer), ActionGetAcquirer, req)
+	return resp, wrapFault("GetAcquirer", err)
 }
 
 // GetExchangeEmails consulta correos de intercambio configurados
 // Delega a operations.GetExchangeEmails
 func (c *Client) GetExchangeEmails(req *types.GetExchangeEmailsRequest) (*types.GetExchangeEmailsResponse, error) {
-	return operations.GetExchangeEmails(c.transport, c.config.Certificate, c.config.PrivateKey, c.url, ActionGetExchangeEmails, req)
+	resp, err := operations.GetExchangeEmails(c.transport, c.credentials, c.urlFor(ActionGetExchangeEmails), ActionGetExchangeEmails, req)
+	return resp, wrapFault("GetExchangeEmails", err)
 }
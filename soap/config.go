@@ -1,6 +1,12 @@
 package soap
 
 import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
 	"github.com/diegofxm/ubl21-dian/soap/types"
 )
 
@@ -10,10 +16,109 @@ const (
 	URLHabilitacion = "https://vpfe-hab.dian.gov.co/WcfDianCustomerServices.svc"
 )
 
+// EnvironmentInfo datos de un ambiente de DIAN
+type EnvironmentInfo struct {
+	Environment        types.Environment
+	URL                string // Endpoint de WcfDianCustomerServices
+	ProfileExecutionID string // Valor de cbc:ProfileExecutionID ("1" producción, "2" pruebas)
+}
+
+// Registro de ambientes conocidos (ver RegisterEnvironment)
+var (
+	environmentsMu sync.RWMutex
+	environments   = map[types.Environment]EnvironmentInfo{
+		types.Produccion:   {Environment: types.Produccion, URL: URLProduccion, ProfileExecutionID: "1"},
+		types.Habilitacion: {Environment: types.Habilitacion, URL: URLHabilitacion, ProfileExecutionID: "2"},
+	}
+)
+
+// RegisterEnvironment agrega o reemplaza un ambiente en el registro
+//
+// Permite usar nuevos ambientes de DIAN (o uno local) en Config.Environment
+// sin cambiar el código del cliente:
+//
+//	soap.RegisterEnvironment(soap.EnvironmentInfo{
+//	    Environment:        "habilitacion-v2",
+//	    URL:                "https://vpfe-hab2.dian.gov.co/WcfDianCustomerServices.svc",
+//	    ProfileExecutionID: "2",
+//	})
+func RegisterEnvironment(info EnvironmentInfo) error {
+	if info.Environment == "" {
+		return fmt.Errorf("environment name is required")
+	}
+	if err := validateURL(info.URL); err != nil {
+		return fmt.Errorf("environment %s: %w", info.Environment, err)
+	}
+
+	environmentsMu.Lock()
+	defer environmentsMu.Unlock()
+	environments[info.Environment] = info
+	return nil
+}
+
+// LookupEnvironment retorna los datos de un ambiente registrado
+func LookupEnvironment(env types.Environment) (EnvironmentInfo, bool) {
+	environmentsMu.RLock()
+	defer environmentsMu.RUnlock()
+	info, ok := environments[env]
+	return info, ok
+}
+
+// Environments retorna los ambientes registrados ordenados por nombre
+func Environments() []EnvironmentInfo {
+	environmentsMu.RLock()
+	defer environmentsMu.RUnlock()
+
+	list := make([]EnvironmentInfo, 0, len(environments))
+	for _, info := range environments {
+		list = append(list, info)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Environment < list[j].Environment })
+	return list
+}
+
 // GetURL retorna la URL según el environment
+// Ambientes no registrados usan Habilitación.
 func GetURL(env types.Environment) string {
-	if env == types.Produccion {
-		return URLProduccion
+	if info, ok := LookupEnvironment(env); ok {
+		return info.URL
 	}
 	return URLHabilitacion
 }
+
+// actions SOAPAction de las operaciones del cliente
+var actions = []string{
+	ActionSendBillSync, ActionSendBillAsync, ActionSendTestSetAsync, ActionSendBillAttachmentAsync, ActionSendNominaSync,
+	ActionGetStatus, ActionGetStatusZip, ActionGetStatusEvent,
+	ActionSendEventUpdateStatus,
+	ActionGetNumberingRange, ActionGetXmlByDocumentKey, ActionGetReferenceNotes, ActionGetDocumentInfo, ActionGetAcquirer, ActionGetExchangeEmails,
+}
+
+// lookupOperation nombre de la operación para una clave de
+// Config.OperationURLs (nombre o SOAPAction de una operación conocida)
+func lookupOperation(key string) (string, bool) {
+	for _, action := range actions {
+		if name := operationName(action); key == name || key == action {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// operationName extrae el nombre de la operación de un SOAPAction
+// ("http://wcf.dian.colombia/IWcfDianCustomerServices/GetStatus" → "GetStatus")
+func operationName(action string) string {
+	return action[strings.LastIndex(action, "/")+1:]
+}
+
+// validateURL verifica que rawURL sea una URL http(s) absoluta
+func validateURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+	if (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("invalid URL %q: expected http(s)://host/...", rawURL)
+	}
+	return nil
+}
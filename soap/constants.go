@@ -50,3 +50,11 @@ const (
 	IDKeyInfo                = "KI"
 	IDTo                     = "ID"
 )
+
+// Códigos de estado de las consultas (GetStatus, GetStatusZip)
+const (
+	StatusProcessed = "00" // Procesado correctamente
+	StatusNotFound  = "66" // TrackId/CUFE no existe en DIAN
+	StatusPending   = "98" // En proceso de validación
+	StatusRejected  = "99" // Validaciones con rechazo
+)
@@ -2,33 +2,33 @@ package envelope
 
 import (
 	"bytes"
-	"text/template"
 
+	"github.com/diegofxm/ubl21-dian/soap/templates"
 	"github.com/diegofxm/ubl21-dian/soap/types"
 )
 
-// Paths de templates para cada operación
+// Paths de templates para cada operación dentro de templates.FS
 const (
-	sendBillSyncBodyPath            = "ubl21-dian/soap/templates/operations/send_bill_sync_body.tmpl"
-	sendBillAsyncBodyPath           = "ubl21-dian/soap/templates/operations/send_bill_async_body.tmpl"
-	sendTestSetAsyncBodyPath        = "ubl21-dian/soap/templates/operations/send_bill_async_body.tmpl" // Reutiliza async
-	sendBillAttachmentAsyncBodyPath = "ubl21-dian/soap/templates/operations/send_bill_attachment_async_body.tmpl"
-	sendNominaSyncBodyPath          = "ubl21-dian/soap/templates/operations/send_nomina_sync_body.tmpl"
-	sendEventUpdateStatusBodyPath   = "ubl21-dian/soap/templates/operations/send_event_update_status_body.tmpl"
-	getStatusBodyPath               = "ubl21-dian/soap/templates/operations/get_status_body.tmpl"
-	getStatusZipBodyPath            = "ubl21-dian/soap/templates/operations/get_status_zip_body.tmpl"
-	getStatusEventBodyPath          = "ubl21-dian/soap/templates/operations/get_status_event_body.tmpl"
-	getXmlByDocumentKeyBodyPath     = "ubl21-dian/soap/templates/operations/get_xml_by_document_key_body.tmpl"
-	getNumberingRangeBodyPath       = "ubl21-dian/soap/templates/operations/get_numbering_range_body.tmpl"
-	getReferenceNotesBodyPath       = "ubl21-dian/soap/templates/operations/get_reference_notes_body.tmpl"
-	getDocumentInfoBodyPath         = "ubl21-dian/soap/templates/operations/get_document_info_body.tmpl"
-	getAcquirerBodyPath             = "ubl21-dian/soap/templates/operations/get_acquirer_body.tmpl"
-	getExchangeEmailsBodyPath       = "ubl21-dian/soap/templates/operations/get_exchange_emails_body.tmpl"
+	sendBillSyncBodyPath            = "operations/send_bill_sync_body.tmpl"
+	sendBillAsyncBodyPath           = "operations/send_bill_async_body.tmpl"
+	sendTestSetAsyncBodyPath        = "operations/send_test_set_async_body.tmpl"
+	sendBillAttachmentAsyncBodyPath = "operations/send_bill_attachment_async_body.tmpl"
+	sendNominaSyncBodyPath          = "operations/send_nomina_sync_body.tmpl"
+	sendEventUpdateStatusBodyPath   = "operations/send_event_update_status_body.tmpl"
+	getStatusBodyPath               = "operations/get_status_body.tmpl"
+	getStatusZipBodyPath            = "operations/get_status_zip_body.tmpl"
+	getStatusEventBodyPath          = "operations/get_status_event_body.tmpl"
+	getXmlByDocumentKeyBodyPath     = "operations/get_xml_by_document_key_body.tmpl"
+	getNumberingRangeBodyPath       = "operations/get_numbering_range_body.tmpl"
+	getReferenceNotesBodyPath       = "operations/get_reference_notes_body.tmpl"
+	getDocumentInfoBodyPath         = "operations/get_document_info_body.tmpl"
+	getAcquirerBodyPath             = "operations/get_acquirer_body.tmpl"
+	getExchangeEmailsBodyPath       = "operations/get_exchange_emails_body.tmpl"
 )
 
 // BuildSendBillSyncBody construye el body para SendBillSync
 func BuildSendBillSyncBody(req *types.SendBillSyncRequest) string {
-	tmpl, err := template.ParseFiles(sendBillSyncBodyPath)
+	tmpl, err := templates.Parse(sendBillSyncBodyPath)
 	if err != nil {
 		return ""
 	}
@@ -46,7 +46,7 @@ func BuildSendBillSyncBody(req *types.SendBillSyncRequest) string {
 
 // BuildSendBillAsyncBody construye el body para SendBillAsync
 func BuildSendBillAsyncBody(req *types.SendBillAsyncRequest) string {
-	tmpl, err := template.ParseFiles(sendBillAsyncBodyPath)
+	tmpl, err := templates.Parse(sendBillAsyncBodyPath)
 	if err != nil {
 		return ""
 	}
@@ -64,7 +64,7 @@ func BuildSendBillAsyncBody(req *types.SendBillAsyncRequest) string {
 
 // BuildSendTestSetAsyncBody construye el body para SendTestSetAsync
 func BuildSendTestSetAsyncBody(req *types.SendTestSetAsyncRequest) string {
-	tmpl, err := template.ParseFiles(sendTestSetAsyncBodyPath)
+	tmpl, err := templates.Parse(sendTestSetAsyncBodyPath)
 	if err != nil {
 		return ""
 	}
@@ -83,7 +83,7 @@ func BuildSendTestSetAsyncBody(req *types.SendTestSetAsyncRequest) string {
 
 // BuildSendBillAttachmentAsyncBody construye el body para SendBillAttachmentAsync
 func BuildSendBillAttachmentAsyncBody(req *types.SendBillAttachmentAsyncRequest) string {
-	tmpl, err := template.ParseFiles(sendBillAttachmentAsyncBodyPath)
+	tmpl, err := templates.Parse(sendBillAttachmentAsyncBodyPath)
 	if err != nil {
 		return ""
 	}
@@ -101,7 +101,7 @@ func BuildSendBillAttachmentAsyncBody(req *types.SendBillAttachmentAsyncRequest)
 
 // BuildSendNominaSyncBody construye el body para SendNominaSync
 func BuildSendNominaSyncBody(req *types.SendNominaSyncRequest) string {
-	tmpl, err := template.ParseFiles(sendNominaSyncBodyPath)
+	tmpl, err := templates.Parse(sendNominaSyncBodyPath)
 	if err != nil {
 		return ""
 	}
@@ -119,7 +119,7 @@ func BuildSendNominaSyncBody(req *types.SendNominaSyncRequest) string {
 
 // BuildSendEventBody construye el body para SendEvent
 func BuildSendEventBody(req *types.SendEventRequest) string {
-	tmpl, err := template.ParseFiles(sendEventUpdateStatusBodyPath)
+	tmpl, err := templates.Parse(sendEventUpdateStatusBodyPath)
 	if err != nil {
 		return ""
 	}
@@ -137,7 +137,7 @@ func BuildSendEventBody(req *types.SendEventRequest) string {
 
 // BuildGetStatusBody construye el body para GetStatus
 func BuildGetStatusBody(req *types.GetStatusRequest) string {
-	tmpl, err := template.ParseFiles(getStatusBodyPath)
+	tmpl, err := templates.Parse(getStatusBodyPath)
 	if err != nil {
 		return ""
 	}
@@ -154,7 +154,7 @@ func BuildGetStatusBody(req *types.GetStatusRequest) string {
 
 // BuildGetStatusZipBody construye el body para GetStatusZip
 func BuildGetStatusZipBody(req *types.GetStatusZipRequest) string {
-	tmpl, err := template.ParseFiles(getStatusZipBodyPath)
+	tmpl, err := templates.Parse(getStatusZipBodyPath)
 	if err != nil {
 		return ""
 	}
@@ -171,7 +171,7 @@ func BuildGetStatusZipBody(req *types.GetStatusZipRequest) string {
 
 // BuildGetStatusEventBody construye el body para GetStatusEvent
 func BuildGetStatusEventBody(req *types.GetStatusEventRequest) string {
-	tmpl, err := template.ParseFiles(getStatusEventBodyPath)
+	tmpl, err := templates.Parse(getStatusEventBodyPath)
 	if err != nil {
 		return ""
 	}
@@ -188,7 +188,7 @@ func BuildGetStatusEventBody(req *types.GetStatusEventRequest) string {
 
 // BuildGetXmlByDocumentKeyBody construye el body para GetXmlByDocumentKey
 func BuildGetXmlByDocumentKeyBody(req *types.GetXmlByDocumentKeyRequest) string {
-	tmpl, err := template.ParseFiles(getXmlByDocumentKeyBodyPath)
+	tmpl, err := templates.Parse(getXmlByDocumentKeyBodyPath)
 	if err != nil {
 		return ""
 	}
@@ -205,7 +205,7 @@ func BuildGetXmlByDocumentKeyBody(req *types.GetXmlByDocumentKeyRequest) string
 
 // BuildGetNumberingRangeBody construye el body para GetNumberingRange
 func BuildGetNumberingRangeBody(req *types.GetNumberingRangeRequest) string {
-	tmpl, err := template.ParseFiles(getNumberingRangeBodyPath)
+	tmpl, err := templates.Parse(getNumberingRangeBodyPath)
 	if err != nil {
 		return ""
 	}
@@ -224,7 +224,7 @@ func BuildGetNumberingRangeBody(req *types.GetNumberingRangeRequest) string {
 
 // BuildGetReferenceNotesBody construye el body para GetReferenceNotes
 func BuildGetReferenceNotesBody(req *types.GetReferenceNotesRequest) string {
-	tmpl, err := template.ParseFiles(getReferenceNotesBodyPath)
+	tmpl, err := templates.Parse(getReferenceNotesBodyPath)
 	if err != nil {
 		return ""
 	}
@@ -241,7 +241,7 @@ func BuildGetReferenceNotesBody(req *types.GetReferenceNotesRequest) string {
 
 // BuildGetDocumentInfoBody construye el body para GetDocumentInfo
 func BuildGetDocumentInfoBody(req *types.GetDocumentInfoRequest) string {
-	tmpl, err := template.ParseFiles(getDocumentInfoBodyPath)
+	tmpl, err := templates.Parse(getDocumentInfoBodyPath)
 	if err != nil {
 		return ""
 	}
@@ -258,7 +258,7 @@ func BuildGetDocumentInfoBody(req *types.GetDocumentInfoRequest) string {
 
 // BuildGetAcquirerBody construye el body para GetAcquirer
 func BuildGetAcquirerBody(req *types.GetAcquirerRequest) string {
-	tmpl, err := template.ParseFiles(getAcquirerBodyPath)
+	tmpl, err := templates.Parse(getAcquirerBodyPath)
 	if err != nil {
 		return ""
 	}
@@ -266,7 +266,7 @@ func BuildGetAcquirerBody(req *types.GetAcquirerRequest) string {
 	var buffer bytes.Buffer
 	if err := tmpl.Execute(&buffer, map[string]string{
 		"AccountCode":  req.NIT,
-		"AccountCodeT": req.NIT,
+		"AccountCodeT": req.IdentificationNumber,
 	}); err != nil {
 		return ""
 	}
@@ -276,7 +276,7 @@ func BuildGetAcquirerBody(req *types.GetAcquirerRequest) string {
 
 // BuildGetExchangeEmailsBody construye el body para GetExchangeEmails
 func BuildGetExchangeEmailsBody(req *types.GetExchangeEmailsRequest) string {
-	tmpl, err := template.ParseFiles(getExchangeEmailsBodyPath)
+	tmpl, err := templates.Parse(getExchangeEmailsBodyPath)
 	if err != nil {
 		return ""
 	}
@@ -2,13 +2,13 @@ package envelope
 
 import (
 	"bytes"
-	_ "embed"
-	"text/template"
+
+	"github.com/diegofxm/ubl21-dian/soap/templates"
 )
 
-// NOTA: Los templates se cargan desde disco en runtime porque go:embed no soporta paths relativos con ../
+// Path del template dentro de templates.FS
 const (
-	envelopeTemplatePath = "ubl21-dian/soap/templates/envelope.tmpl"
+	envelopeTemplatePath = "envelope.tmpl"
 )
 
 // Builder construye el SOAP Envelope completo
@@ -27,7 +27,7 @@ func New(securityHeader, body string) *Builder {
 
 // Build construye el XML completo del SOAP envelope usando template
 func (e *Builder) Build() string {
-	tmpl, err := template.ParseFiles(envelopeTemplatePath)
+	tmpl, err := templates.Parse(envelopeTemplatePath)
 	if err != nil {
 		return ""
 	}
@@ -1,6 +1,11 @@
 package soap
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/diegofxm/ubl21-dian/soap/response"
+)
 
 // SOAPError error personalizado para operaciones SOAP
 type SOAPError struct {
@@ -8,6 +13,12 @@ type SOAPError struct {
 	Code      string // Código de error
 	Message   string // Mensaje de error
 	Err       error  // Error original
+
+	// Datos del SOAP Fault (solo si DIAN respondió con un Fault)
+	FaultCode    string // Código SOAP 1.2 sin prefijo: Sender, Receiver, etc.
+	FaultSubcode string // Subcódigo WCF sin prefijo: InvalidSecurity, DeserializationFailed, etc.
+	FaultReason  string // Texto del Reason
+	FaultDetail  string // Contenido XML del Detail
 }
 
 // Error implementa la interfaz error
@@ -35,26 +46,109 @@ func NewSOAPError(operation, code, message string, err error) *SOAPError {
 
 // Errores comunes predefinidos
 var (
-	ErrInvalidRequest      = "INVALID_REQUEST"
-	ErrSecurityHeader      = "SECURITY_HEADER_ERROR"
-	ErrHTTPTransport       = "HTTP_TRANSPORT_ERROR"
-	ErrResponseParsing     = "RESPONSE_PARSING_ERROR"
-	ErrUnexpectedResponse  = "UNEXPECTED_RESPONSE"
-	ErrDIANRejection       = "DIAN_REJECTION"
-	ErrCertificateLoad     = "CERTIFICATE_LOAD_ERROR"
-	ErrTimeout             = "TIMEOUT"
+	ErrInvalidRequest       = "INVALID_REQUEST"
+	ErrSecurityHeader       = "SECURITY_HEADER_ERROR"
+	ErrHTTPTransport        = "HTTP_TRANSPORT_ERROR"
+	ErrResponseParsing      = "RESPONSE_PARSING_ERROR"
+	ErrUnexpectedResponse   = "UNEXPECTED_RESPONSE"
+	ErrDIANRejection        = "DIAN_REJECTION"
+	ErrCertificateLoad      = "CERTIFICATE_LOAD_ERROR"
+	ErrTimeout              = "TIMEOUT"
+	ErrAuthenticationFault  = "AUTHENTICATION_FAULT"        // Fault por WS-Security (certificado, firma, timestamp)
+	ErrSchemaFault          = "SCHEMA_FAULT"                // Fault por mensaje mal formado o no reconocido
+	ErrServerFault          = "SERVER_FAULT"                // Fault interno del servicio de DIAN
+	ErrResponseVerification = "RESPONSE_VERIFICATION_ERROR" // Firma WS-Security de la respuesta inválida
+	ErrInvalidConfig        = "INVALID_CONFIG"              // Configuración del cliente inválida (endpoints, ambiente)
 )
 
-// IsSOAPError verifica si un error es un SOAPError
+// authenticationSubcodes subcódigos WS-Security/WCF que indican fallo de autenticación
+var authenticationSubcodes = map[string]bool{
+	"InvalidSecurity":          true,
+	"InvalidSecurityToken":     true,
+	"FailedAuthentication":     true,
+	"FailedCheck":              true,
+	"SecurityTokenUnavailable": true,
+	"UnsupportedSecurityToken": true,
+	"MessageExpired":           true,
+}
+
+// NewFaultError crea un SOAPError a partir de un SOAP Fault de DIAN
+//
+// El Code del error clasifica el Fault:
+//   - ErrAuthenticationFault: subcódigos de WS-Security (InvalidSecurity, FailedAuthentication, ...)
+//   - ErrServerFault: Code "Receiver" (error interno de DIAN, se puede reintentar)
+//   - ErrSchemaFault: cualquier otro Fault del emisor (Sender, MustUnderstand, VersionMismatch, ...)
+func NewFaultError(operation string, fault *response.Fault) *SOAPError {
+	code := ErrSchemaFault
+	switch {
+	case authenticationSubcodes[fault.Subcode]:
+		code = ErrAuthenticationFault
+	case fault.Code == "Receiver":
+		code = ErrServerFault
+	}
+
+	return &SOAPError{
+		Operation:    operation,
+		Code:         code,
+		Message:      fault.Reason,
+		Err:          fault,
+		FaultCode:    fault.Code,
+		FaultSubcode: fault.Subcode,
+		FaultReason:  fault.Reason,
+		FaultDetail:  fault.Detail,
+	}
+}
+
+// IsFault indica si el error proviene de un SOAP Fault
+func (e *SOAPError) IsFault() bool {
+	return e.FaultCode != ""
+}
+
+// IsAuthenticationFault indica si DIAN rechazó el certificado, la firma o el timestamp
+func (e *SOAPError) IsAuthenticationFault() bool {
+	return e.Code == ErrAuthenticationFault
+}
+
+// IsSchemaFault indica si DIAN no pudo interpretar el mensaje enviado
+func (e *SOAPError) IsSchemaFault() bool {
+	return e.Code == ErrSchemaFault
+}
+
+// IsServerFault indica un error interno del servicio de DIAN
+func (e *SOAPError) IsServerFault() bool {
+	return e.Code == ErrServerFault
+}
+
+// IsVerificationFailure indica que la firma de la respuesta de DIAN no se pudo verificar
+// El detalle está en el *security.VerificationError envuelto (errors.As).
+func (e *SOAPError) IsVerificationFailure() bool {
+	return e.Code == ErrResponseVerification
+}
+
+// IsSOAPError verifica si un error es (o envuelve) un SOAPError
 func IsSOAPError(err error) bool {
-	_, ok := err.(*SOAPError)
-	return ok
+	return GetSOAPError(err) != nil
 }
 
 // GetSOAPError extrae el SOAPError de un error
 func GetSOAPError(err error) *SOAPError {
-	if soapErr, ok := err.(*SOAPError); ok {
+	var soapErr *SOAPError
+	if errors.As(err, &soapErr) {
 		return soapErr
 	}
 	return nil
 }
+
+// wrapFault convierte un SOAP Fault retornado por una operación en *SOAPError
+func wrapFault(operation string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var fault *response.Fault
+	if errors.As(err, &fault) {
+		return NewFaultError(operation, fault)
+	}
+
+	return err
+}
@@ -0,0 +1,54 @@
+package soap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/diegofxm/ubl21-dian/soap/response"
+)
+
+func TestFaultClassification(t *testing.T) {
+	tests := []struct {
+		name  string
+		fault response.Fault
+		code  string
+	}{
+		{"invalid security", response.Fault{Code: "Sender", Subcode: "InvalidSecurity"}, ErrAuthenticationFault},
+		{"expired message", response.Fault{Code: "Sender", Subcode: "MessageExpired"}, ErrAuthenticationFault},
+		{"deserialization", response.Fault{Code: "Sender", Subcode: "DeserializationFailed"}, ErrSchemaFault},
+		{"unknown action", response.Fault{Code: "Sender", Subcode: "ActionNotSupported"}, ErrSchemaFault},
+		{"internal error", response.Fault{Code: "Receiver", Subcode: "InternalServiceFault"}, ErrServerFault},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fault := tt.fault
+			// Las operaciones envuelven el error con fmt.Errorf
+			err := wrapFault("GetStatus", fmt.Errorf("GetStatus: failed to parse response: %w", &fault))
+
+			soapErr := GetSOAPError(err)
+			if soapErr == nil {
+				t.Fatalf("Expected *SOAPError, got %T", err)
+			}
+			if soapErr.Code != tt.code {
+				t.Errorf("Expected %s, got %s", tt.code, soapErr.Code)
+			}
+			if soapErr.Operation != "GetStatus" || !soapErr.IsFault() {
+				t.Errorf("Unexpected error %+v", soapErr)
+			}
+			if soapErr.FaultSubcode != tt.fault.Subcode {
+				t.Errorf("Expected subcode %s, got %s", tt.fault.Subcode, soapErr.FaultSubcode)
+			}
+		})
+	}
+
+	t.Run("non fault errors pass through", func(t *testing.T) {
+		err := fmt.Errorf("GetStatus: failed to build request body")
+		if wrapFault("GetStatus", err) != err {
+			t.Error("Expected the original error")
+		}
+		if IsSOAPError(err) {
+			t.Error("Plain errors are not SOAP errors")
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package soap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/soap/security"
+)
+
+// Exchange intercambio request/response que recorre la cadena de interceptores
+type Exchange struct {
+	ID           string // Identificador único (correlación de logs y nombres de archivo)
+	Action       string // wsa:Action del request
+	Operation    string // Nombre de la operación (SendBillSync, GetStatus, ...)
+	URL          string
+	Request      []byte
+	Response     []byte // nil si no hubo respuesta HTTP
+	StatusCode   int
+	Started      time.Time
+	Duration     time.Duration                // Desde Started hasta recibir la respuesta
+	Verification *security.VerificationReport // Reporte de VerifyResponses (si está activo)
+}
+
+// RoundTrip envía el intercambio y completa Response y StatusCode
+type RoundTrip func(ex *Exchange) error
+
+// Interceptor envuelve el envío para logging, archivo, redacción, métricas o tests
+//
+//	func(next soap.RoundTrip) soap.RoundTrip {
+//		return func(ex *soap.Exchange) error {
+//			// antes del envío
+//			err := next(ex)
+//			// después: ex.Response, ex.StatusCode, ex.Duration
+//			return err
+//		}
+//	}
+type Interceptor func(next RoundTrip) RoundTrip
+
+// exchangeSeq secuencia para IDs únicos dentro del proceso
+var exchangeSeq atomic.Uint64
+
+// newExchange crea el intercambio de un request
+func newExchange(url, soapXML string) *Exchange {
+	started := time.Now()
+	ex := &Exchange{
+		ID:      fmt.Sprintf("%s-%06d", started.UTC().Format("20060102T150405.000000000"), exchangeSeq.Add(1)),
+		Action:  actionOf(soapXML),
+		URL:     url,
+		Request: []byte(soapXML),
+		Started: started,
+	}
+	if ex.Action != "" {
+		ex.Operation = path.Base(ex.Action)
+	}
+	return ex
+}
+
+// actionOf extrae el wsa:Action del header del envelope
+func actionOf(soapXML string) string {
+	decoder := xml.NewDecoder(strings.NewReader(soapXML))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch {
+		case start.Name.Space == NSAddressing && start.Name.Local == "Action":
+			var action string
+			if err := decoder.DecodeElement(&action, &start); err != nil {
+				return ""
+			}
+			return strings.TrimSpace(action)
+		case start.Name.Space == NSSOAPEnvelope && start.Name.Local == "Body":
+			return ""
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package soap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testRequest = `<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope" xmlns:wcf="http://wcf.dian.colombia"><soap:Header xmlns:wsa="http://www.w3.org/2005/08/addressing"><wsse:Security xmlns:wsse="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-secext-1.0.xsd"><wsse:BinarySecurityToken>MIICERTIFICADO</wsse:BinarySecurityToken></wsse:Security><wsa:Action>http://wcf.dian.colombia/IWcfDianCustomerServices/SendBillSync</wsa:Action></soap:Header><soap:Body><wcf:SendBillSync><wcf:fileName>fv1.zip</wcf:fileName><wcf:contentFile>UEsDBBQAAAAI</wcf:contentFile></wcf:SendBillSync></soap:Body></soap:Envelope>`
+
+func TestInterceptorChain(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"><s:Body/></s:Envelope>`))
+	}))
+	defer srv.Close()
+
+	transport := NewTransport(srv.URL, nil, 5*time.Second)
+
+	var order []string
+	trace := func(name string) Interceptor {
+		return func(next RoundTrip) RoundTrip {
+			return func(ex *Exchange) error {
+				order = append(order, name+">")
+				err := next(ex)
+				order = append(order, "<"+name)
+				return err
+			}
+		}
+	}
+	rec := &Recorder{}
+	dumps := t.TempDir()
+	transport.Use(trace("outer"), trace("inner"), rec.Intercept, Dump(DumpConfig{Sink: DirSink(dumps), Redact: true}))
+
+	body, err := transport.Send(testRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), "s:Body") {
+		t.Errorf("Unexpected response %s", body)
+	}
+
+	if got := strings.Join(order, " "); got != "outer> inner> <inner <outer" {
+		t.Errorf("Unexpected interceptor order: %s", got)
+	}
+
+	exchanges := rec.Exchanges()
+	if len(exchanges) != 1 || exchanges[0].Operation != "SendBillSync" || exchanges[0].StatusCode != http.StatusOK {
+		t.Fatalf("Unexpected recorded exchanges %+v", exchanges)
+	}
+
+	request, err := os.ReadFile(filepath.Join(dumps, exchanges[0].ID+"_SendBillSync_request.xml"))
+	if err != nil {
+		t.Fatalf("Expected request dump: %v", err)
+	}
+	for _, secret := range []string{"MIICERTIFICADO", "UEsDBBQAAAAI"} {
+		if strings.Contains(string(request), secret) {
+			t.Errorf("Dump should redact %s", secret)
+		}
+	}
+	if !strings.Contains(string(request), "<wcf:fileName>fv1.zip</wcf:fileName>") {
+		t.Errorf("Dump should keep non-sensitive content: %s", request)
+	}
+}
+
+func TestDumpUniqueNames(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"><s:Body/></s:Envelope>`))
+	}))
+	defer srv.Close()
+
+	dumps := t.TempDir()
+	transport := NewTransport(srv.URL, nil, 5*time.Second)
+	transport.Use(Dump(DumpConfig{Sink: DirSink(dumps)}))
+
+	const n = 20
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := transport.Send(testRequest); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	files, _ := filepath.Glob(filepath.Join(dumps, "*_request.xml"))
+	if len(files) != n {
+		t.Errorf("Expected %d request dumps, got %d", n, len(files))
+	}
+}
+
+func TestLimits(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte(`<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"><s:Body/></s:Envelope>`))
+	}))
+	defer srv.Close()
+
+	send := func(transport *Transport, n int) time.Duration {
+		start := time.Now()
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := transport.Send(testRequest); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+		return time.Since(start)
+	}
+
+	t.Run("MaxInFlight", func(t *testing.T) {
+		transport := NewTransport(srv.URL, nil, 5*time.Second)
+		transport.Use(MaxInFlight(3))
+		send(transport, 12)
+		if p := peak.Load(); p > 3 {
+			t.Errorf("Expected at most 3 concurrent requests, got %d", p)
+		}
+	})
+
+	t.Run("RateLimit", func(t *testing.T) {
+		transport := NewTransport(srv.URL, nil, 5*time.Second)
+		transport.Use(RateLimit(50, 2))
+		// 2 en ráfaga + 4 espaciados 20ms = al menos 80ms
+		if elapsed := send(transport, 6); elapsed < 75*time.Millisecond {
+			t.Errorf("Expected rate limited sends to take >= 80ms, took %v", elapsed)
+		}
+	})
+}
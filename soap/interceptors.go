@@ -0,0 +1,131 @@
+package soap
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+)
+
+// Sink destino de los volcados de Dump
+type Sink interface {
+	Write(name string, data []byte) error
+}
+
+// DirSink guarda cada volcado como archivo dentro de un directorio
+type DirSink string
+
+// Write implementa Sink (permisos 0600: los volcados incluyen certificados y documentos)
+func (d DirSink) Write(name string, data []byte) error {
+	if err := os.MkdirAll(string(d), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(string(d), name), data, 0600)
+}
+
+// DumpConfig configuración del interceptor Dump
+type DumpConfig struct {
+	Sink   Sink
+	Redact bool        // Ocultar certificados, firmas y contenido de documentos (ver Redact)
+	Logger *log.Logger // Errores de escritura del sink (nil = se ignoran)
+}
+
+// Dump vuelca request, response y reporte de verificación de cada intercambio
+//
+// Los nombres son únicos por intercambio: <ID>_<Operation>_request.xml,
+// _response.xml y _verification.json. Un error del sink nunca hace fallar
+// el envío.
+func Dump(config DumpConfig) Interceptor {
+	write := func(name string, data []byte) {
+		if err := config.Sink.Write(name, data); err != nil && config.Logger != nil {
+			config.Logger.Printf("soap: failed to dump %s: %v", name, err)
+		}
+	}
+	redact := func(data []byte) []byte {
+		if config.Redact {
+			return Redact(data)
+		}
+		return data
+	}
+
+	return func(next RoundTrip) RoundTrip {
+		return func(ex *Exchange) error {
+			err := next(ex)
+
+			prefix := ex.ID + "_" + ex.Operation
+			write(prefix+"_request.xml", redact(ex.Request))
+			if ex.Response != nil {
+				write(prefix+"_response.xml", redact(ex.Response))
+			}
+			if ex.Verification != nil {
+				report, _ := json.MarshalIndent(ex.Verification, "", "  ")
+				write(prefix+"_verification.json", report)
+			}
+
+			return err
+		}
+	}
+}
+
+// Logging registra una línea por intercambio (operación, status, duración y error)
+func Logging(logger *log.Logger) Interceptor {
+	return func(next RoundTrip) RoundTrip {
+		return func(ex *Exchange) error {
+			err := next(ex)
+			if err != nil {
+				logger.Printf("soap: %s %s status=%d duration=%s error=%v", ex.ID, ex.Operation, ex.StatusCode, ex.Duration, err)
+			} else {
+				logger.Printf("soap: %s %s status=%d duration=%s", ex.ID, ex.Operation, ex.StatusCode, ex.Duration)
+			}
+			return err
+		}
+	}
+}
+
+// Metrics llama observe al terminar cada intercambio (contadores, histogramas, ...)
+func Metrics(observe func(ex *Exchange, err error)) Interceptor {
+	return func(next RoundTrip) RoundTrip {
+		return func(ex *Exchange) error {
+			err := next(ex)
+			observe(ex, err)
+			return err
+		}
+	}
+}
+
+// redactPattern contenido de elementos con certificados, firmas o documentos en base64
+var redactPattern = regexp.MustCompile(`(<(?:[\w.-]+:)?(?:BinarySecurityToken|SignatureValue|DigestValue|contentFile|ContentFile|XmlBase64Bytes|XmlBytes|CsvBase64Bytes)(?:\s[^>]*)?>)[^<]+`)
+
+// Redact reemplaza por [REDACTED] el contenido sensible de un mensaje SOAP
+func Redact(data []byte) []byte {
+	return redactPattern.ReplaceAll(data, []byte("${1}[REDACTED]"))
+}
+
+// Recorder interceptor que guarda los intercambios (útil en tests)
+//
+//	rec := &soap.Recorder{}
+//	transport.Use(rec.Intercept)
+type Recorder struct {
+	mu        sync.Mutex
+	exchanges []Exchange
+}
+
+// Intercept implementa Interceptor
+func (r *Recorder) Intercept(next RoundTrip) RoundTrip {
+	return func(ex *Exchange) error {
+		err := next(ex)
+		r.mu.Lock()
+		r.exchanges = append(r.exchanges, *ex)
+		r.mu.Unlock()
+		return err
+	}
+}
+
+// Exchanges retorna una copia de los intercambios registrados
+func (r *Recorder) Exchanges() []Exchange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Exchange(nil), r.exchanges...)
+}
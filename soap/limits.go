@@ -0,0 +1,85 @@
+package soap
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimit interceptor que limita los requests por segundo hacia DIAN
+//
+// Implementa un token bucket: permite ráfagas de hasta burst requests y
+// luego espaciarlos a perSecond. Con varias goroutines, cada una espera su
+// turno antes de enviar. perSecond <= 0 desactiva el límite.
+func RateLimit(perSecond float64, burst int) Interceptor {
+	if perSecond <= 0 {
+		return func(next RoundTrip) RoundTrip { return next }
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	limiter := &tokenBucket{
+		rate:   perSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+	return func(next RoundTrip) RoundTrip {
+		return func(ex *Exchange) error {
+			limiter.wait()
+			return next(ex)
+		}
+	}
+}
+
+// MaxInFlight interceptor que limita los requests simultáneos
+//
+// Los requests que superan el límite esperan a que termine otro.
+// n <= 0 desactiva el límite.
+func MaxInFlight(n int) Interceptor {
+	if n <= 0 {
+		return func(next RoundTrip) RoundTrip { return next }
+	}
+
+	slots := make(chan struct{}, n)
+	return func(next RoundTrip) RoundTrip {
+		return func(ex *Exchange) error {
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			return next(ex)
+		}
+	}
+}
+
+// tokenBucket limitador de tasa sin dependencias externas
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64 // tokens por segundo
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// wait reserva un token y duerme lo necesario hasta que esté disponible
+func (b *tokenBucket) wait() {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	// Reservar el token aunque quede en negativo: las siguientes llamadas
+	// esperan proporcionalmente más, respetando el orden de llegada
+	b.tokens--
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
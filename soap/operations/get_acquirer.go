@@ -19,9 +19,9 @@ import (
 // Retorna:
 //   - GetAcquirerResponse con datos del adquiriente
 //   - error si falla la comunicación
-func GetAcquirer(transport Transport, certPath, keyPath, url, action string, req *types.GetAcquirerRequest) (*types.GetAcquirerResponse, error) {
+func GetAcquirer(transport Transport, creds *security.Credentials, url, action string, req *types.GetAcquirerRequest) (*types.GetAcquirerResponse, error) {
 	// 1. Crear security header
-	secHeader, err := security.NewHeader(certPath, keyPath, url, action)
+	secHeader, err := security.NewHeaderFromCredentials(creds, url, action)
 	if err != nil {
 		return nil, fmt.Errorf("GetAcquirer: failed to create security header: %w", err)
 	}
@@ -42,7 +42,7 @@ func GetAcquirer(transport Transport, certPath, keyPath, url, action string, req
 	soapXML := env.Build()
 
 	// 4. Enviar request
-	respXML, err := transport.Send(soapXML)
+	respXML, verification, err := send(transport, url, soapXML)
 	if err != nil {
 		return nil, fmt.Errorf("GetAcquirer: %w", err)
 	}
@@ -57,5 +57,9 @@ func GetAcquirer(transport Transport, certPath, keyPath, url, action string, req
 		return nil, fmt.Errorf("GetAcquirer: unexpected response format")
 	}
 
-	return response.ToGetAcquirerResponse(&soapResp.Body.GetAcquirerResponse.Result), nil
+	resp := response.ToGetAcquirerResponse(&soapResp.Body.GetAcquirerResponse.Result)
+	resp.IdentificationNumber = req.IdentificationNumber
+
+	resp.Verification = verification
+	return resp, nil
 }
@@ -19,9 +19,9 @@ import (
 // Retorna:
 //   - GetDocumentInfoResponse con información completa
 //   - error si falla la comunicación
-func GetDocumentInfo(transport Transport, certPath, keyPath, url, action string, req *types.GetDocumentInfoRequest) (*types.GetDocumentInfoResponse, error) {
+func GetDocumentInfo(transport Transport, creds *security.Credentials, url, action string, req *types.GetDocumentInfoRequest) (*types.GetDocumentInfoResponse, error) {
 	// 1. Crear security header
-	secHeader, err := security.NewHeader(certPath, keyPath, url, action)
+	secHeader, err := security.NewHeaderFromCredentials(creds, url, action)
 	if err != nil {
 		return nil, fmt.Errorf("GetDocumentInfo: failed to create security header: %w", err)
 	}
@@ -42,7 +42,7 @@ func GetDocumentInfo(transport Transport, certPath, keyPath, url, action string,
 	soapXML := env.Build()
 
 	// 4. Enviar request
-	respXML, err := transport.Send(soapXML)
+	respXML, verification, err := send(transport, url, soapXML)
 	if err != nil {
 		return nil, fmt.Errorf("GetDocumentInfo: %w", err)
 	}
@@ -57,5 +57,7 @@ func GetDocumentInfo(transport Transport, certPath, keyPath, url, action string,
 		return nil, fmt.Errorf("GetDocumentInfo: unexpected response format")
 	}
 
-	return response.ToGetDocumentInfoResponse(&soapResp.Body.GetDocumentInfoResponse.Result), nil
+	resp := response.ToGetDocumentInfoResponse(&soapResp.Body.GetDocumentInfoResponse.Result)
+	resp.Verification = verification
+	return resp, nil
 }
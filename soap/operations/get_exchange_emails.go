@@ -19,9 +19,9 @@ import (
 // Retorna:
 //   - GetExchangeEmailsResponse con lista de emails
 //   - error si falla la comunicación
-func GetExchangeEmails(transport Transport, certPath, keyPath, url, action string, req *types.GetExchangeEmailsRequest) (*types.GetExchangeEmailsResponse, error) {
+func GetExchangeEmails(transport Transport, creds *security.Credentials, url, action string, req *types.GetExchangeEmailsRequest) (*types.GetExchangeEmailsResponse, error) {
 	// 1. Crear security header
-	secHeader, err := security.NewHeader(certPath, keyPath, url, action)
+	secHeader, err := security.NewHeaderFromCredentials(creds, url, action)
 	if err != nil {
 		return nil, fmt.Errorf("GetExchangeEmails: failed to create security header: %w", err)
 	}
@@ -42,7 +42,7 @@ func GetExchangeEmails(transport Transport, certPath, keyPath, url, action strin
 	soapXML := env.Build()
 
 	// 4. Enviar request
-	respXML, err := transport.Send(soapXML)
+	respXML, verification, err := send(transport, url, soapXML)
 	if err != nil {
 		return nil, fmt.Errorf("GetExchangeEmails: %w", err)
 	}
@@ -57,5 +57,7 @@ func GetExchangeEmails(transport Transport, certPath, keyPath, url, action strin
 		return nil, fmt.Errorf("GetExchangeEmails: unexpected response format")
 	}
 
-	return response.ToGetExchangeEmailsResponse(&soapResp.Body.GetExchangeEmailsResponse.Result), nil
+	resp := response.ToGetExchangeEmailsResponse(&soapResp.Body.GetExchangeEmailsResponse.Result)
+	resp.Verification = verification
+	return resp, nil
 }
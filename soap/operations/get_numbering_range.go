@@ -20,9 +20,9 @@ import (
 // Retorna:
 //   - GetNumberingRangeResponse con lista de rangos activos
 //   - error si falla la comunicación
-func GetNumberingRange(transport Transport, certPath, keyPath, url, action string, req *types.GetNumberingRangeRequest) (*types.GetNumberingRangeResponse, error) {
+func GetNumberingRange(transport Transport, creds *security.Credentials, url, action string, req *types.GetNumberingRangeRequest) (*types.GetNumberingRangeResponse, error) {
 	// 1. Crear security header
-	secHeader, err := security.NewHeader(certPath, keyPath, url, action)
+	secHeader, err := security.NewHeaderFromCredentials(creds, url, action)
 	if err != nil {
 		return nil, fmt.Errorf("GetNumberingRange: failed to create security header: %w", err)
 	}
@@ -43,7 +43,7 @@ func GetNumberingRange(transport Transport, certPath, keyPath, url, action strin
 	soapXML := env.Build()
 
 	// 4. Enviar request
-	respXML, err := transport.Send(soapXML)
+	respXML, verification, err := send(transport, url, soapXML)
 	if err != nil {
 		return nil, fmt.Errorf("GetNumberingRange: %w", err)
 	}
@@ -58,5 +58,10 @@ func GetNumberingRange(transport Transport, certPath, keyPath, url, action strin
 		return nil, fmt.Errorf("GetNumberingRange: unexpected response format")
 	}
 
-	return response.ToGetNumberingRangeResponse(&soapResp.Body.GetNumberingRangeResponse.Result), nil
+	resp, err := response.ToGetNumberingRangeResponse(&soapResp.Body.GetNumberingRangeResponse.Result)
+	if err != nil {
+		return nil, fmt.Errorf("GetNumberingRange: %w", err)
+	}
+	resp.Verification = verification
+	return resp, nil
 }
@@ -19,9 +19,9 @@ import (
 // Retorna:
 //   - GetReferenceNotesResponse con lista de notas relacionadas
 //   - error si falla la comunicación
-func GetReferenceNotes(transport Transport, certPath, keyPath, url, action string, req *types.GetReferenceNotesRequest) (*types.GetReferenceNotesResponse, error) {
+func GetReferenceNotes(transport Transport, creds *security.Credentials, url, action string, req *types.GetReferenceNotesRequest) (*types.GetReferenceNotesResponse, error) {
 	// 1. Crear security header
-	secHeader, err := security.NewHeader(certPath, keyPath, url, action)
+	secHeader, err := security.NewHeaderFromCredentials(creds, url, action)
 	if err != nil {
 		return nil, fmt.Errorf("GetReferenceNotes: failed to create security header: %w", err)
 	}
@@ -42,7 +42,7 @@ func GetReferenceNotes(transport Transport, certPath, keyPath, url, action strin
 	soapXML := env.Build()
 
 	// 4. Enviar request
-	respXML, err := transport.Send(soapXML)
+	respXML, verification, err := send(transport, url, soapXML)
 	if err != nil {
 		return nil, fmt.Errorf("GetReferenceNotes: %w", err)
 	}
@@ -57,5 +57,7 @@ func GetReferenceNotes(transport Transport, certPath, keyPath, url, action strin
 		return nil, fmt.Errorf("GetReferenceNotes: unexpected response format")
 	}
 
-	return response.ToGetReferenceNotesResponse(&soapResp.Body.GetReferenceNotesResponse.Result), nil
+	resp := response.ToGetReferenceNotesResponse(&soapResp.Body.GetReferenceNotesResponse.Result)
+	resp.Verification = verification
+	return resp, nil
 }
@@ -20,9 +20,9 @@ import (
 // Retorna:
 //   - GetStatusResponse con IsValid, StatusCode, ApplicationResponse final en XmlBase64Bytes
 //   - error si falla la comunicación
-func GetStatus(transport Transport, certPath, keyPath, url, action string, req *types.GetStatusRequest) (*types.GetStatusResponse, error) {
+func GetStatus(transport Transport, creds *security.Credentials, url, action string, req *types.GetStatusRequest) (*types.GetStatusResponse, error) {
 	// 1. Crear security header
-	secHeader, err := security.NewHeader(certPath, keyPath, url, action)
+	secHeader, err := security.NewHeaderFromCredentials(creds, url, action)
 	if err != nil {
 		return nil, fmt.Errorf("GetStatus: failed to create security header: %w", err)
 	}
@@ -43,7 +43,7 @@ func GetStatus(transport Transport, certPath, keyPath, url, action string, req *
 	soapXML := env.Build()
 
 	// 4. Enviar request
-	respXML, err := transport.Send(soapXML)
+	respXML, verification, err := send(transport, url, soapXML)
 	if err != nil {
 		return nil, err
 	}
@@ -58,5 +58,7 @@ func GetStatus(transport Transport, certPath, keyPath, url, action string, req *
 		return nil, fmt.Errorf("GetStatus: unexpected response format")
 	}
 
-	return response.ToGetStatusResponse(&soapResp.Body.GetStatusResponse.Result), nil
+	resp := response.ToGetStatusResponse(&soapResp.Body.GetStatusResponse.Result)
+	resp.Verification = verification
+	return resp, nil
 }
@@ -19,8 +19,8 @@ import (
 // Retorna:
 //   - GetStatusEventResponse con estado del evento
 //   - error si falla la comunicación
-func GetStatusEvent(transport Transport, certPath, keyPath, url, action string, req *types.GetStatusEventRequest) (*types.GetStatusEventResponse, error) {
-	secHeader, err := security.NewHeader(certPath, keyPath, url, action)
+func GetStatusEvent(transport Transport, creds *security.Credentials, url, action string, req *types.GetStatusEventRequest) (*types.GetStatusEventResponse, error) {
+	secHeader, err := security.NewHeaderFromCredentials(creds, url, action)
 	if err != nil {
 		return nil, fmt.Errorf("GetStatusEvent: failed to create security header: %w", err)
 	}
@@ -38,7 +38,7 @@ func GetStatusEvent(transport Transport, certPath, keyPath, url, action string,
 	env := envelope.New(securityXML, body)
 	soapXML := env.Build()
 
-	respXML, err := transport.Send(soapXML)
+	respXML, verification, err := send(transport, url, soapXML)
 	if err != nil {
 		return nil, err
 	}
@@ -52,5 +52,7 @@ func GetStatusEvent(transport Transport, certPath, keyPath, url, action string,
 		return nil, fmt.Errorf("GetStatusEvent: unexpected response format")
 	}
 
-	return response.ToGetStatusEventResponse(&soapResp.Body.GetStatusEventResponse.Result), nil
+	resp := response.ToGetStatusEventResponse(&soapResp.Body.GetStatusEventResponse.Result)
+	resp.Verification = verification
+	return resp, nil
 }
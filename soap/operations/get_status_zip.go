@@ -20,9 +20,9 @@ import (
 // Retorna:
 //   - GetStatusZipResponse con ZIP en base64 (ContentFile)
 //   - error si falla la comunicación
-func GetStatusZip(transport Transport, certPath, keyPath, url, action string, req *types.GetStatusZipRequest) (*types.GetStatusZipResponse, error) {
+func GetStatusZip(transport Transport, creds *security.Credentials, url, action string, req *types.GetStatusZipRequest) (*types.GetStatusZipResponse, error) {
 	// 1. Crear security header
-	secHeader, err := security.NewHeader(certPath, keyPath, url, action)
+	secHeader, err := security.NewHeaderFromCredentials(creds, url, action)
 	if err != nil {
 		return nil, fmt.Errorf("GetStatusZip: failed to create security header: %w", err)
 	}
@@ -43,7 +43,7 @@ func GetStatusZip(transport Transport, certPath, keyPath, url, action string, re
 	soapXML := env.Build()
 
 	// 4. Enviar request
-	respXML, err := transport.Send(soapXML)
+	respXML, verification, err := send(transport, url, soapXML)
 	if err != nil {
 		return nil, err
 	}
@@ -58,5 +58,7 @@ func GetStatusZip(transport Transport, certPath, keyPath, url, action string, re
 		return nil, fmt.Errorf("GetStatusZip: unexpected response format")
 	}
 
-	return response.ToGetStatusZipResponse(soapResp.Body.GetStatusZipResponse), nil
+	resp := response.ToGetStatusZipResponse(soapResp.Body.GetStatusZipResponse)
+	resp.Verification = verification
+	return resp, nil
 }
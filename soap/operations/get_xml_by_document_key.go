@@ -20,8 +20,8 @@ import (
 // Retorna:
 //   - GetXmlByDocumentKeyResponse con XML completo en base64
 //   - error si falla la comunicación o documento no existe
-func GetXmlByDocumentKey(transport Transport, certPath, keyPath, url, action string, req *types.GetXmlByDocumentKeyRequest) (*types.GetXmlByDocumentKeyResponse, error) {
-	secHeader, err := security.NewHeader(certPath, keyPath, url, action)
+func GetXmlByDocumentKey(transport Transport, creds *security.Credentials, url, action string, req *types.GetXmlByDocumentKeyRequest) (*types.GetXmlByDocumentKeyResponse, error) {
+	secHeader, err := security.NewHeaderFromCredentials(creds, url, action)
 	if err != nil {
 		return nil, fmt.Errorf("GetXmlByDocumentKey: failed to create security header: %w", err)
 	}
@@ -39,7 +39,7 @@ func GetXmlByDocumentKey(transport Transport, certPath, keyPath, url, action str
 	env := envelope.New(securityXML, body)
 	soapXML := env.Build()
 
-	respXML, err := transport.Send(soapXML)
+	respXML, verification, err := send(transport, url, soapXML)
 	if err != nil {
 		return nil, err
 	}
@@ -53,5 +53,7 @@ func GetXmlByDocumentKey(transport Transport, certPath, keyPath, url, action str
 		return nil, fmt.Errorf("GetXmlByDocumentKey: unexpected response format")
 	}
 
-	return response.ToGetXmlByDocumentKeyResponse(soapResp.Body.GetXmlByDocumentKeyResponse), nil
+	resp := response.ToGetXmlByDocumentKeyResponse(soapResp.Body.GetXmlByDocumentKeyResponse)
+	resp.Verification = verification
+	return resp, nil
 }
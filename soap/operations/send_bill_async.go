@@ -20,8 +20,8 @@ import (
 // Retorna:
 //   - SendBillAsyncResponse con TrackId (XmlDocumentKey) para consultar estado
 //   - error si falla la comunicación
-func SendBillAsync(transport Transport, certPath, keyPath, url, action string, req *types.SendBillAsyncRequest) (*types.SendBillAsyncResponse, error) {
-	secHeader, err := security.NewHeader(certPath, keyPath, url, action)
+func SendBillAsync(transport Transport, creds *security.Credentials, url, action string, req *types.SendBillAsyncRequest) (*types.SendBillAsyncResponse, error) {
+	secHeader, err := security.NewHeaderFromCredentials(creds, url, action)
 	if err != nil {
 		return nil, fmt.Errorf("SendBillAsync: failed to create security header: %w", err)
 	}
@@ -39,7 +39,7 @@ func SendBillAsync(transport Transport, certPath, keyPath, url, action string, r
 	env := envelope.New(securityXML, body)
 	soapXML := env.Build()
 
-	respXML, err := transport.Send(soapXML)
+	respXML, verification, err := send(transport, url, soapXML)
 	if err != nil {
 		return nil, fmt.Errorf("SendBillAsync: %w", err)
 	}
@@ -53,5 +53,7 @@ func SendBillAsync(transport Transport, certPath, keyPath, url, action string, r
 		return nil, fmt.Errorf("SendBillAsync: unexpected response format")
 	}
 
-	return response.ToSendBillAsyncResponse(&soapResp.Body.SendBillAsyncResponse.Result), nil
+	resp := response.ToSendBillAsyncResponse(&soapResp.Body.SendBillAsyncResponse.Result)
+	resp.Verification = verification
+	return resp, nil
 }
@@ -19,8 +19,8 @@ import (
 // Retorna:
 //   - SendBillAttachmentAsyncResponse con TrackId
 //   - error si falla la comunicación
-func SendBillAttachmentAsync(transport Transport, certPath, keyPath, url, action string, req *types.SendBillAttachmentAsyncRequest) (*types.SendBillAttachmentAsyncResponse, error) {
-	secHeader, err := security.NewHeader(certPath, keyPath, url, action)
+func SendBillAttachmentAsync(transport Transport, creds *security.Credentials, url, action string, req *types.SendBillAttachmentAsyncRequest) (*types.SendBillAttachmentAsyncResponse, error) {
+	secHeader, err := security.NewHeaderFromCredentials(creds, url, action)
 	if err != nil {
 		return nil, fmt.Errorf("SendBillAttachmentAsync: failed to create security header: %w", err)
 	}
@@ -38,7 +38,7 @@ func SendBillAttachmentAsync(transport Transport, certPath, keyPath, url, action
 	env := envelope.New(securityXML, body)
 	soapXML := env.Build()
 
-	respXML, err := transport.Send(soapXML)
+	respXML, verification, err := send(transport, url, soapXML)
 	if err != nil {
 		return nil, err
 	}
@@ -52,5 +52,7 @@ func SendBillAttachmentAsync(transport Transport, certPath, keyPath, url, action
 		return nil, fmt.Errorf("SendBillAttachmentAsync: unexpected response format")
 	}
 
-	return response.ToSendBillAttachmentAsyncResponse(&soapResp.Body.SendBillAttachmentAsyncResponse.Result), nil
+	resp := response.ToSendBillAttachmentAsyncResponse(&soapResp.Body.SendBillAttachmentAsyncResponse.Result)
+	resp.Verification = verification
+	return resp, nil
 }
@@ -20,14 +20,32 @@ import (
 // Retorna:
 //   - SendBillSyncResponse con IsValid, StatusCode, XmlDocumentKey, XmlBase64Bytes
 //   - error si falla la comunicación o DIAN rechaza
+//
 // Transport interface para evitar ciclo de importación
+// url es el destino del request (el mismo valor firmado en wsa:To).
 type Transport interface {
-	Send(soapXML string) ([]byte, error)
+	SendTo(url, soapXML string) ([]byte, error)
+}
+
+// VerifyingTransport Transport que además retorna el reporte de verificación
+// de la firma de la respuesta (nil si no verifica respuestas)
+type VerifyingTransport interface {
+	Transport
+	SendVerified(url, soapXML string) ([]byte, *security.VerificationReport, error)
+}
+
+// send envía el request y retorna el reporte si transport verifica respuestas
+func send(transport Transport, url, soapXML string) ([]byte, *security.VerificationReport, error) {
+	if vt, ok := transport.(VerifyingTransport); ok {
+		return vt.SendVerified(url, soapXML)
+	}
+	respXML, err := transport.SendTo(url, soapXML)
+	return respXML, nil, err
 }
 
-func SendBillSync(transport Transport, certPath, keyPath, url, action string, req *types.SendBillSyncRequest) (*types.SendBillSyncResponse, error) {
+func SendBillSync(transport Transport, creds *security.Credentials, url, action string, req *types.SendBillSyncRequest) (*types.SendBillSyncResponse, error) {
 	// 1. Crear security header
-	secHeader, err := security.NewHeader(certPath, keyPath, url, action)
+	secHeader, err := security.NewHeaderFromCredentials(creds, url, action)
 	if err != nil {
 		return nil, fmt.Errorf("SendBillSync: failed to create security header: %w", err)
 	}
@@ -48,7 +66,7 @@ func SendBillSync(transport Transport, certPath, keyPath, url, action string, re
 	soapXML := env.Build()
 
 	// 4. Enviar request
-	respXML, err := transport.Send(soapXML)
+	respXML, verification, err := send(transport, url, soapXML)
 	if err != nil {
 		return nil, fmt.Errorf("SendBillSync: %w", err)
 	}
@@ -63,5 +81,7 @@ func SendBillSync(transport Transport, certPath, keyPath, url, action string, re
 		return nil, fmt.Errorf("SendBillSync: unexpected response format")
 	}
 
-	return response.ToSendBillSyncResponse(&soapResp.Body.SendBillSyncResponse.Result), nil
+	resp := response.ToSendBillSyncResponse(&soapResp.Body.SendBillSyncResponse.Result)
+	resp.Verification = verification
+	return resp, nil
 }
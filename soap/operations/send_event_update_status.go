@@ -24,8 +24,8 @@ import (
 // Retorna:
 //   - SendEventResponse con TrackId del evento
 //   - error si falla la comunicación
-func SendEventUpdateStatus(transport Transport, certPath, keyPath, url, action string, req *types.SendEventRequest) (*types.SendEventResponse, error) {
-	secHeader, err := security.NewHeader(certPath, keyPath, url, action)
+func SendEventUpdateStatus(transport Transport, creds *security.Credentials, url, action string, req *types.SendEventRequest) (*types.SendEventResponse, error) {
+	secHeader, err := security.NewHeaderFromCredentials(creds, url, action)
 	if err != nil {
 		return nil, fmt.Errorf("SendEventUpdateStatus: failed to create security header: %w", err)
 	}
@@ -43,7 +43,7 @@ func SendEventUpdateStatus(transport Transport, certPath, keyPath, url, action s
 	env := envelope.New(securityXML, body)
 	soapXML := env.Build()
 
-	respXML, err := transport.Send(soapXML)
+	respXML, verification, err := send(transport, url, soapXML)
 	if err != nil {
 		return nil, err
 	}
@@ -57,5 +57,7 @@ func SendEventUpdateStatus(transport Transport, certPath, keyPath, url, action s
 		return nil, fmt.Errorf("SendEventUpdateStatus: unexpected response format")
 	}
 
-	return response.ToSendEventResponse(&soapResp.Body.SendEventResponse.Result), nil
+	resp := response.ToSendEventResponse(&soapResp.Body.SendEventResponse.Result)
+	resp.Verification = verification
+	return resp, nil
 }
@@ -20,8 +20,8 @@ import (
 // Retorna:
 //   - SendNominaSyncResponse con validación completa
 //   - error si falla la comunicación o validación
-func SendNominaSync(transport Transport, certPath, keyPath, url, action string, req *types.SendNominaSyncRequest) (*types.SendNominaSyncResponse, error) {
-	secHeader, err := security.NewHeader(certPath, keyPath, url, action)
+func SendNominaSync(transport Transport, creds *security.Credentials, url, action string, req *types.SendNominaSyncRequest) (*types.SendNominaSyncResponse, error) {
+	secHeader, err := security.NewHeaderFromCredentials(creds, url, action)
 	if err != nil {
 		return nil, fmt.Errorf("SendNominaSync: failed to create security header: %w", err)
 	}
@@ -39,7 +39,7 @@ func SendNominaSync(transport Transport, certPath, keyPath, url, action string,
 	env := envelope.New(securityXML, body)
 	soapXML := env.Build()
 
-	respXML, err := transport.Send(soapXML)
+	respXML, verification, err := send(transport, url, soapXML)
 	if err != nil {
 		return nil, err
 	}
@@ -53,5 +53,7 @@ func SendNominaSync(transport Transport, certPath, keyPath, url, action string,
 		return nil, fmt.Errorf("SendNominaSync: unexpected response format")
 	}
 
-	return response.ToSendNominaSyncResponse(&soapResp.Body.SendNominaSyncResponse.Result), nil
+	resp := response.ToSendNominaSyncResponse(&soapResp.Body.SendNominaSyncResponse.Result)
+	resp.Verification = verification
+	return resp, nil
 }
@@ -20,9 +20,9 @@ import (
 // Retorna:
 //   - SendTestSetAsyncResponse con resultado de validación del set de pruebas
 //   - error si falla la comunicación o validación
-func SendTestSetAsync(transport Transport, certPath, keyPath, url, action string, req *types.SendTestSetAsyncRequest) (*types.SendTestSetAsyncResponse, error) {
+func SendTestSetAsync(transport Transport, creds *security.Credentials, url, action string, req *types.SendTestSetAsyncRequest) (*types.SendTestSetAsyncResponse, error) {
 	// 1. Crear security header
-	secHeader, err := security.NewHeader(certPath, keyPath, url, action)
+	secHeader, err := security.NewHeaderFromCredentials(creds, url, action)
 	if err != nil {
 		return nil, fmt.Errorf("SendTestSetAsync: failed to create security header: %w", err)
 	}
@@ -43,7 +43,7 @@ func SendTestSetAsync(transport Transport, certPath, keyPath, url, action string
 	soapXML := env.Build()
 
 	// 4. Enviar request
-	respXML, err := transport.Send(soapXML)
+	respXML, verification, err := send(transport, url, soapXML)
 	if err != nil {
 		return nil, err
 	}
@@ -58,5 +58,7 @@ func SendTestSetAsync(transport Transport, certPath, keyPath, url, action string
 		return nil, fmt.Errorf("SendTestSetAsync: unexpected response format")
 	}
 
-	return response.ToSendTestSetAsyncResponse(&soapResp.Body.SendTestSetAsyncResponse.Result), nil
+	resp := response.ToSendTestSetAsyncResponse(&soapResp.Body.SendTestSetAsyncResponse.Result)
+	resp.Verification = verification
+	return resp, nil
 }
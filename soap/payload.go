@@ -0,0 +1,280 @@
+package soap
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/diegofxm/ubl21-dian/documents/applicationresponse"
+	"github.com/diegofxm/ubl21-dian/documents/attached"
+	"github.com/diegofxm/ubl21-dian/documents/creditnote"
+	"github.com/diegofxm/ubl21-dian/documents/debitnote"
+	"github.com/diegofxm/ubl21-dian/documents/invoice"
+	"github.com/diegofxm/ubl21-dian/documents/supportdocument"
+	"github.com/diegofxm/ubl21-dian/soap/types"
+	xmlpkg "github.com/diegofxm/ubl21-dian/xml"
+)
+
+// DocumentKind tipo de documento identificado en un payload de DIAN
+type DocumentKind string
+
+const (
+	KindInvoice             DocumentKind = "Invoice"
+	KindCreditNote          DocumentKind = "CreditNote"
+	KindDebitNote           DocumentKind = "DebitNote"
+	KindSupportDocument     DocumentKind = "SupportDocument" // Invoice con InvoiceTypeCode 05
+	KindApplicationResponse DocumentKind = "ApplicationResponse"
+	KindAttachedDocument    DocumentKind = "AttachedDocument"
+	KindUnknown             DocumentKind = "Unknown"
+)
+
+// supportDocumentTypeCode InvoiceTypeCode del documento soporte
+const supportDocumentTypeCode = "05"
+
+// Payload contenido decodificado de ContentFile o XmlBase64Bytes
+type Payload struct {
+	Raw       []byte // Bytes decodificados (ZIP o XML), para archivo
+	Zipped    bool
+	Documents []PayloadDocument
+}
+
+// PayloadDocument documento XML del payload
+//
+// Solo el campo correspondiente a Kind queda informado. Raw conserva los
+// bytes originales: los modelos tipados no sirven para re-serializar un
+// documento firmado.
+type PayloadDocument struct {
+	FileName string // Nombre dentro del ZIP ("" si el payload era un XML)
+	Kind     DocumentKind
+	Raw      []byte
+
+	Invoice             *invoice.InvoiceXML
+	CreditNote          *creditnote.CreditNoteXML
+	DebitNote           *debitnote.DebitNoteXML
+	SupportDocument     *supportdocument.SupportDocumentXML
+	ApplicationResponse *applicationresponse.ApplicationResponseData
+	AttachedDocument    *attached.AttachedDocumentXML
+
+	// Documentos en CDATA de un AttachedDocument (factura y ApplicationResponse)
+	Embedded []PayloadDocument
+}
+
+// DecodeStatusZip decodifica el ContentFile de GetStatusZip
+func DecodeStatusZip(resp *types.GetStatusZipResponse) (*Payload, error) {
+	if resp.ContentFile == "" {
+		return nil, NewSOAPError("GetStatusZip", ErrResponseParsing, "response has no ContentFile (status "+resp.StatusCode+")", nil)
+	}
+	return DecodePayload(resp.ContentFile)
+}
+
+// DecodeXmlByDocumentKey decodifica el XmlBase64Bytes de GetXmlByDocumentKey
+func DecodeXmlByDocumentKey(resp *types.GetXmlByDocumentKeyResponse) (*Payload, error) {
+	if resp.XmlBase64Bytes == "" {
+		return nil, NewSOAPError("GetXmlByDocumentKey", ErrResponseParsing, "response has no XmlBase64Bytes (status "+resp.StatusCode+")", nil)
+	}
+	return DecodePayload(resp.XmlBase64Bytes)
+}
+
+// Límites de un ZIP de DIAN (ApplicationResponse, documento y adjuntos):
+// un ZIP con más archivos o con un XML más grande se rechaza
+const (
+	maxZipEntries   = 20
+	maxZipEntrySize = 20 << 20 // 20 MB descomprimidos por archivo
+)
+
+// DecodePayload decodifica base64, descomprime si es ZIP e identifica cada XML
+func DecodePayload(content string) (*Payload, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(content))
+	if err != nil {
+		return nil, NewSOAPError("Payload", ErrResponseParsing, "content is not valid base64", err)
+	}
+
+	payload := &Payload{Raw: raw}
+
+	if !bytes.HasPrefix(raw, []byte("PK\x03\x04")) {
+		doc, err := decodeDocument("", raw)
+		if err != nil {
+			return nil, err
+		}
+		payload.Documents = append(payload.Documents, doc)
+		return payload, nil
+	}
+
+	payload.Zipped = true
+	reader, err := zip.NewReader(bytes.NewReader(raw), int64(len(raw)))
+	if err != nil {
+		return nil, NewSOAPError("Payload", ErrResponseParsing, "content is not a valid ZIP", err)
+	}
+	if len(reader.File) > maxZipEntries {
+		return nil, NewSOAPError("Payload", ErrResponseParsing, fmt.Sprintf("ZIP has %d files, at most %d allowed", len(reader.File), maxZipEntries), nil)
+	}
+
+	for _, f := range reader.File {
+		if f.FileInfo().IsDir() || !strings.EqualFold(path.Ext(f.Name), ".xml") {
+			continue
+		}
+
+		data, err := readZipEntry(f)
+		if err != nil {
+			return nil, NewSOAPError("Payload", ErrResponseParsing, "failed to read "+f.Name, err)
+		}
+
+		doc, err := decodeDocument(f.Name, data)
+		if err != nil {
+			return nil, err
+		}
+		payload.Documents = append(payload.Documents, doc)
+	}
+
+	return payload, nil
+}
+
+// readZipEntry descomprime un archivo del ZIP hasta maxZipEntrySize
+// El tamaño del encabezado puede ser falso: la lectura se corta igual.
+func readZipEntry(f *zip.File) ([]byte, error) {
+	if f.UncompressedSize64 > maxZipEntrySize {
+		return nil, fmt.Errorf("%d bytes uncompressed, at most %d allowed", f.UncompressedSize64, maxZipEntrySize)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxZipEntrySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxZipEntrySize {
+		return nil, fmt.Errorf("more than %d bytes uncompressed", maxZipEntrySize)
+	}
+	return data, nil
+}
+
+// ByKind documentos del tipo indicado (incluye los embebidos en AttachedDocument)
+func (p *Payload) ByKind(kind DocumentKind) []PayloadDocument {
+	var docs []PayloadDocument
+	var walk func([]PayloadDocument)
+	walk = func(list []PayloadDocument) {
+		for _, doc := range list {
+			if doc.Kind == kind {
+				docs = append(docs, doc)
+			}
+			walk(doc.Embedded)
+		}
+	}
+	walk(p.Documents)
+	return docs
+}
+
+// ApplicationResponses ApplicationResponse del payload, ya parseados
+func (p *Payload) ApplicationResponses() []*applicationresponse.ApplicationResponseData {
+	var responses []*applicationresponse.ApplicationResponseData
+	for _, doc := range p.ByKind(KindApplicationResponse) {
+		responses = append(responses, doc.ApplicationResponse)
+	}
+	return responses
+}
+
+// decodeDocument identifica el XML por su elemento raíz y lo deserializa
+func decodeDocument(fileName string, data []byte) (PayloadDocument, error) {
+	doc := PayloadDocument{FileName: fileName, Kind: KindUnknown, Raw: data}
+
+	root, err := rootElement(data)
+	if err != nil {
+		return doc, NewSOAPError("Payload", ErrResponseParsing, fmt.Sprintf("%s is not valid XML", describe(fileName)), err)
+	}
+
+	fail := func(err error) (PayloadDocument, error) {
+		return doc, NewSOAPError("Payload", ErrResponseParsing, fmt.Sprintf("failed to parse %s %s", root, describe(fileName)), err)
+	}
+
+	switch root {
+	case "Invoice":
+		inv, err := invoice.ParseFromXML(data)
+		if err != nil {
+			return fail(err)
+		}
+		if inv.InvoiceTypeCode.Value != supportDocumentTypeCode {
+			doc.Kind, doc.Invoice = KindInvoice, inv
+			break
+		}
+		sd, err := supportdocument.ParseFromXML(data)
+		if err != nil {
+			return fail(err)
+		}
+		doc.Kind, doc.SupportDocument = KindSupportDocument, sd
+
+	case "CreditNote":
+		nc, err := creditnote.ParseFromXML(data)
+		if err != nil {
+			return fail(err)
+		}
+		doc.Kind, doc.CreditNote = KindCreditNote, nc
+
+	case "DebitNote":
+		nd, err := debitnote.ParseFromXML(data)
+		if err != nil {
+			return fail(err)
+		}
+		doc.Kind, doc.DebitNote = KindDebitNote, nd
+
+	case "ApplicationResponse":
+		ar, err := applicationresponse.ParseFromXML(data)
+		if err != nil {
+			return fail(err)
+		}
+		doc.Kind, doc.ApplicationResponse = KindApplicationResponse, ar
+
+	case "AttachedDocument":
+		var ad attached.AttachedDocumentXML
+		if err := xmlpkg.Unmarshal(data, &ad); err != nil {
+			return fail(err)
+		}
+		doc.Kind, doc.AttachedDocument = KindAttachedDocument, &ad
+
+		// Factura y ApplicationResponse embebidos en CDATA
+		for _, embedded := range []string{
+			ad.Attachment.ExternalReference.Description.Value,
+			ad.ParentDocumentLineReference.DocumentReference.Attachment.ExternalReference.Description.Value,
+		} {
+			if strings.TrimSpace(embedded) == "" {
+				continue
+			}
+			inner, err := decodeDocument(fileName, []byte(strings.TrimSpace(embedded)))
+			if err != nil {
+				return doc, err
+			}
+			doc.Embedded = append(doc.Embedded, inner)
+		}
+	}
+
+	return doc, nil
+}
+
+// rootElement nombre local del elemento raíz
+func rootElement(data []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.RawToken()
+		if err != nil {
+			return "", err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+// describe nombre del documento para mensajes de error
+func describe(fileName string) string {
+	if fileName == "" {
+		return "document"
+	}
+	return fileName
+}
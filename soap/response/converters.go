@@ -1,18 +1,33 @@
 package response
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/diegofxm/ubl21-dian/soap/rules"
 	"github.com/diegofxm/ubl21-dian/soap/types"
 )
 
-// convertErrorMessages convierte []ErrorMessageXML a []types.ErrorMessage
-func convertErrorMessages(xmlErrors []ErrorMessageXML) []types.ErrorMessage {
+// convertErrorMessages convierte los ErrorMessage (DianResponse o string) a []types.ErrorMessage
+func convertErrorMessages(xmlResp *ResponseXML) []types.ErrorMessage {
 	var errors []types.ErrorMessage
-	for _, errMsg := range xmlErrors {
+	for _, errMsg := range xmlResp.ErrorMessage {
 		errors = append(errors, types.ErrorMessage{
 			Code:        errMsg.Code,
 			Description: errMsg.Description,
 		})
 	}
+	// Los mensajes en texto traen el código en "Regla: X, ..."
+	for _, description := range xmlResp.ErrorStrings {
+		errors = append(errors, types.ErrorMessage{
+			Code:        rules.Parse("", description).Code,
+			Description: description,
+		})
+	}
 	return errors
 }
 
@@ -24,9 +39,9 @@ func ToSendBillSyncResponse(xmlResp *ResponseXML) *types.SendBillSyncResponse {
 			StatusCode:        xmlResp.StatusCode,
 			StatusDescription: xmlResp.StatusDescription,
 			StatusMessage:     xmlResp.StatusMessage,
-			ErrorMessages:     convertErrorMessages(xmlResp.ErrorMessage),
-			XmlDocumentKey:    xmlResp.XmlDocumentKey,
-			XmlBase64Bytes:    xmlResp.XmlBase64Bytes,
+			ErrorMessages:     convertErrorMessages(xmlResp),
+			XmlDocumentKey:    xmlResp.XmlDocumentKey.Value,
+			XmlBase64Bytes:    xmlResp.XmlBase64Bytes.Value,
 		},
 	}
 	return resp
@@ -40,10 +55,10 @@ func ToSendBillAsyncResponse(xmlResp *ResponseXML) *types.SendBillAsyncResponse
 			StatusCode:        xmlResp.StatusCode,
 			StatusDescription: xmlResp.StatusDescription,
 			StatusMessage:     xmlResp.StatusMessage,
-			ErrorMessages:     convertErrorMessages(xmlResp.ErrorMessage),
-			XmlDocumentKey:    xmlResp.XmlDocumentKey,
-			XmlBase64Bytes:    xmlResp.XmlBase64Bytes,
-			ZipKey:            xmlResp.ZipKey,
+			ErrorMessages:     convertErrorMessages(xmlResp),
+			XmlDocumentKey:    xmlResp.XmlDocumentKey.Value,
+			XmlBase64Bytes:    xmlResp.XmlBase64Bytes.Value,
+			ZipKey:            xmlResp.ZipKey.Value,
 		},
 	}
 	return resp
@@ -57,9 +72,10 @@ func ToSendTestSetAsyncResponse(xmlResp *ResponseXML) *types.SendTestSetAsyncRes
 			StatusCode:        xmlResp.StatusCode,
 			StatusDescription: xmlResp.StatusDescription,
 			StatusMessage:     xmlResp.StatusMessage,
-			ErrorMessages:     convertErrorMessages(xmlResp.ErrorMessage),
-			XmlDocumentKey:    xmlResp.XmlDocumentKey,
-			XmlBase64Bytes:    xmlResp.XmlBase64Bytes,
+			ErrorMessages:     convertErrorMessages(xmlResp),
+			XmlDocumentKey:    xmlResp.XmlDocumentKey.Value,
+			XmlBase64Bytes:    xmlResp.XmlBase64Bytes.Value,
+			ZipKey:            xmlResp.ZipKey.Value,
 		},
 	}
 	return resp
@@ -73,9 +89,9 @@ func ToGetStatusResponse(xmlResp *ResponseXML) *types.GetStatusResponse {
 			StatusCode:        xmlResp.StatusCode,
 			StatusDescription: xmlResp.StatusDescription,
 			StatusMessage:     xmlResp.StatusMessage,
-			ErrorMessages:     convertErrorMessages(xmlResp.ErrorMessage),
-			XmlDocumentKey:    xmlResp.XmlDocumentKey,
-			XmlBase64Bytes:    xmlResp.XmlBase64Bytes,
+			ErrorMessages:     convertErrorMessages(xmlResp),
+			XmlDocumentKey:    xmlResp.XmlDocumentKey.Value,
+			XmlBase64Bytes:    xmlResp.XmlBase64Bytes.Value,
 		},
 	}
 	return resp
@@ -84,8 +100,8 @@ func ToGetStatusResponse(xmlResp *ResponseXML) *types.GetStatusResponse {
 // ToGetStatusZipResponse convierte GetStatusZipResponseXML a GetStatusZipResponse
 func ToGetStatusZipResponse(xmlResp *GetStatusZipResponseXML) *types.GetStatusZipResponse {
 	return &types.GetStatusZipResponse{
-		ZipKey:        xmlResp.ZipKey,
-		ContentFile:   xmlResp.ContentFile,
+		ZipKey:        xmlResp.ZipKey.Value,
+		ContentFile:   xmlResp.ContentFile.Value,
 		StatusCode:    xmlResp.StatusCode,
 		StatusMessage: xmlResp.StatusMessage,
 	}
@@ -94,7 +110,7 @@ func ToGetStatusZipResponse(xmlResp *GetStatusZipResponseXML) *types.GetStatusZi
 // ToGetXmlByDocumentKeyResponse convierte GetXmlByDocumentKeyResponseXML a GetXmlByDocumentKeyResponse
 func ToGetXmlByDocumentKeyResponse(xmlResp *GetXmlByDocumentKeyResponseXML) *types.GetXmlByDocumentKeyResponse {
 	return &types.GetXmlByDocumentKeyResponse{
-		XmlBase64Bytes: xmlResp.XmlBase64Bytes,
+		XmlBase64Bytes: xmlResp.XmlBase64Bytes.Value,
 		StatusCode:     xmlResp.StatusCode,
 		StatusMessage:  xmlResp.StatusMessage,
 	}
@@ -108,9 +124,9 @@ func ToSendBillAttachmentAsyncResponse(xmlResp *ResponseXML) *types.SendBillAtta
 			StatusCode:        xmlResp.StatusCode,
 			StatusDescription: xmlResp.StatusDescription,
 			StatusMessage:     xmlResp.StatusMessage,
-			ErrorMessages:     convertErrorMessages(xmlResp.ErrorMessage),
-			XmlDocumentKey:    xmlResp.XmlDocumentKey,
-			XmlBase64Bytes:    xmlResp.XmlBase64Bytes,
+			ErrorMessages:     convertErrorMessages(xmlResp),
+			XmlDocumentKey:    xmlResp.XmlDocumentKey.Value,
+			XmlBase64Bytes:    xmlResp.XmlBase64Bytes.Value,
 		},
 	}
 	return resp
@@ -124,9 +140,9 @@ func ToSendEventResponse(xmlResp *ResponseXML) *types.SendEventResponse {
 			StatusCode:        xmlResp.StatusCode,
 			StatusDescription: xmlResp.StatusDescription,
 			StatusMessage:     xmlResp.StatusMessage,
-			ErrorMessages:     convertErrorMessages(xmlResp.ErrorMessage),
-			XmlDocumentKey:    xmlResp.XmlDocumentKey,
-			XmlBase64Bytes:    xmlResp.XmlBase64Bytes,
+			ErrorMessages:     convertErrorMessages(xmlResp),
+			XmlDocumentKey:    xmlResp.XmlDocumentKey.Value,
+			XmlBase64Bytes:    xmlResp.XmlBase64Bytes.Value,
 		},
 	}
 	return resp
@@ -140,9 +156,9 @@ func ToSendNominaSyncResponse(xmlResp *ResponseXML) *types.SendNominaSyncRespons
 			StatusCode:        xmlResp.StatusCode,
 			StatusDescription: xmlResp.StatusDescription,
 			StatusMessage:     xmlResp.StatusMessage,
-			ErrorMessages:     convertErrorMessages(xmlResp.ErrorMessage),
-			XmlDocumentKey:    xmlResp.XmlDocumentKey,
-			XmlBase64Bytes:    xmlResp.XmlBase64Bytes,
+			ErrorMessages:     convertErrorMessages(xmlResp),
+			XmlDocumentKey:    xmlResp.XmlDocumentKey.Value,
+			XmlBase64Bytes:    xmlResp.XmlBase64Bytes.Value,
 		},
 	}
 	return resp
@@ -156,49 +172,95 @@ func ToGetStatusEventResponse(xmlResp *ResponseXML) *types.GetStatusEventRespons
 			StatusCode:        xmlResp.StatusCode,
 			StatusDescription: xmlResp.StatusDescription,
 			StatusMessage:     xmlResp.StatusMessage,
-			ErrorMessages:     convertErrorMessages(xmlResp.ErrorMessage),
-			XmlDocumentKey:    xmlResp.XmlDocumentKey,
-			XmlBase64Bytes:    xmlResp.XmlBase64Bytes,
+			ErrorMessages:     convertErrorMessages(xmlResp),
+			XmlDocumentKey:    xmlResp.XmlDocumentKey.Value,
+			XmlBase64Bytes:    xmlResp.XmlBase64Bytes.Value,
 		},
 	}
 	return resp
 }
 
-// ToGetNumberingRangeResponse convierte ResponseXML a GetNumberingRangeResponse
-func ToGetNumberingRangeResponse(xmlResp *ResponseXML) *types.GetNumberingRangeResponse {
+// ToGetNumberingRangeResponse convierte NumberingRangeResultXML a GetNumberingRangeResponse
+// Retorna error si FromNumber o ToNumber de un rango no son numéricos.
+func ToGetNumberingRangeResponse(xmlResp *NumberingRangeResultXML) (*types.GetNumberingRangeResponse, error) {
 	resp := &types.GetNumberingRangeResponse{
-		StatusCode:    xmlResp.StatusCode,
-		StatusMessage: xmlResp.StatusMessage,
+		StatusCode:    xmlResp.OperationCode,
+		StatusMessage: xmlResp.OperationDescription,
 		Ranges:        []types.NumberingRange{},
 	}
-	return resp
+	for i, r := range xmlResp.ResponseList {
+		from, err := parseInt("FromNumber", r.FromNumber)
+		if err != nil {
+			return nil, fmt.Errorf("numbering range %d: %w", i+1, err)
+		}
+		to, err := parseInt("ToNumber", r.ToNumber)
+		if err != nil {
+			return nil, fmt.Errorf("numbering range %d: %w", i+1, err)
+		}
+		resp.Ranges = append(resp.Ranges, types.NumberingRange{
+			Prefix:         strings.TrimSpace(r.Prefix),
+			From:           from,
+			To:             to,
+			DateFrom:       r.ValidDateFrom,
+			DateTo:         r.ValidDateTo,
+			Resolution:     r.ResolutionNumber,
+			ResolutionDate: r.ResolutionDate,
+			TechnicalKey:   r.TechnicalKey,
+		})
+	}
+	return resp, nil
 }
 
-// ToGetReferenceNotesResponse convierte ResponseXML a GetReferenceNotesResponse
-func ToGetReferenceNotesResponse(xmlResp *ResponseXML) *types.GetReferenceNotesResponse {
+// ToGetReferenceNotesResponse convierte ReferenceNotesResultXML a GetReferenceNotesResponse
+func ToGetReferenceNotesResponse(xmlResp *ReferenceNotesResultXML) *types.GetReferenceNotesResponse {
 	resp := &types.GetReferenceNotesResponse{
 		StatusCode:    xmlResp.StatusCode,
 		StatusMessage: xmlResp.StatusMessage,
 		Notes:         []types.ReferenceNote{},
 	}
+	for _, n := range xmlResp.Notes {
+		resp.Notes = append(resp.Notes, types.ReferenceNote{
+			DocumentKey:    n.DocumentKey,
+			DocumentNumber: n.DocumentNumber,
+			TypeCode:       n.DocumentTypeCode,
+			IssueDate:      n.IssueDate,
+			Type:           referenceNoteType(n.DocumentTypeCode, n.DocumentType),
+		})
+	}
 	return resp
 }
 
-// ToGetDocumentInfoResponse convierte ResponseXML a GetDocumentInfoResponse
-func ToGetDocumentInfoResponse(xmlResp *ResponseXML) *types.GetDocumentInfoResponse {
+// ToGetDocumentInfoResponse convierte DocumentInfoResultXML a GetDocumentInfoResponse
+func ToGetDocumentInfoResponse(xmlResp *DocumentInfoResultXML) *types.GetDocumentInfoResponse {
 	resp := &types.GetDocumentInfoResponse{
-		DocumentKey:   xmlResp.XmlDocumentKey,
-		StatusCode:    xmlResp.StatusCode,
-		StatusMessage: xmlResp.StatusMessage,
+		DocumentKey:      xmlResp.DocumentKey,
+		DocumentNumber:   xmlResp.DocumentNumber,
+		DocumentTypeCode: xmlResp.DocumentTypeCode,
+		IssueDate:        xmlResp.IssueDate,
+		SenderNIT:        xmlResp.SenderCode,
+		ReceiverNIT:      xmlResp.ReceiverCode,
+		Status:           xmlResp.Status,
+		StatusCode:       xmlResp.StatusCode,
+		StatusMessage:    xmlResp.StatusMessage,
+	}
+	for _, e := range xmlResp.Events {
+		resp.Events = append(resp.Events, types.DocumentEvent{
+			Code:        e.Code,
+			EventType:   e.Name,
+			EventDate:   e.Date,
+			Description: e.Description,
+		})
 	}
 	return resp
 }
 
-// ToGetAcquirerResponse convierte ResponseXML a GetAcquirerResponse
-func ToGetAcquirerResponse(xmlResp *ResponseXML) *types.GetAcquirerResponse {
+// ToGetAcquirerResponse convierte AcquirerResultXML a GetAcquirerResponse
+func ToGetAcquirerResponse(xmlResp *AcquirerResultXML) *types.GetAcquirerResponse {
 	resp := &types.GetAcquirerResponse{
+		Name:          xmlResp.ReceiverName,
+		Email:         xmlResp.ReceiverEmail,
 		StatusCode:    xmlResp.StatusCode,
-		StatusMessage: xmlResp.StatusMessage,
+		StatusMessage: xmlResp.Message,
 	}
 	return resp
 }
@@ -210,5 +272,46 @@ func ToGetExchangeEmailsResponse(xmlResp *ResponseXML) *types.GetExchangeEmailsR
 		StatusMessage: xmlResp.StatusMessage,
 		Emails:        []string{},
 	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(xmlResp.CsvBase64Bytes.Value))
+	if err != nil || len(data) == 0 {
+		return resp
+	}
+
+	// CSV "NIT,Correo" con una fila por correo; el encabezado no trae "@"
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	records, _ := reader.ReadAll()
+	seen := map[string]bool{}
+	for _, record := range records {
+		if len(record) < 2 || !strings.Contains(record[len(record)-1], "@") {
+			continue
+		}
+		address := types.ExchangeEmail{NIT: strings.TrimSpace(record[0]), Email: strings.TrimSpace(record[len(record)-1])}
+		resp.Addresses = append(resp.Addresses, address)
+		if !seen[strings.ToLower(address.Email)] {
+			seen[strings.ToLower(address.Email)] = true
+			resp.Emails = append(resp.Emails, address.Email)
+		}
+	}
 	return resp
 }
+
+// parseInt convierte el número field de DIAN a int64
+func parseInt(field, value string) (int64, error) {
+	n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", field, value, err)
+	}
+	return n, nil
+}
+
+// referenceNoteType retorna el tipo de nota a partir de su código de documento
+func referenceNoteType(typeCode, typeName string) string {
+	switch typeCode {
+	case "91":
+		return "CreditNote"
+	case "92":
+		return "DebitNote"
+	}
+	return typeName
+}
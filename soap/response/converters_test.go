@@ -0,0 +1,147 @@
+package response
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// loadEnvelope parsea una respuesta de testdata
+//
+// Los fixtures no son respuestas capturadas de DIAN: están escritos a mano a
+// partir del WSDL y del Anexo Técnico, porque capturarlas requiere red y un
+// certificado de habilitación. Nombres de elementos, namespaces y valores
+// pueden diferir de los reales; al capturar respuestas de DIAN deben
+// reemplazarlos.
+func loadEnvelope(t *testing.T, name string) *SOAPEnvelope {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("Error reading fixture %s: %v", name, err)
+	}
+
+	envelope, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Error parsing fixture %s: %v", name, err)
+	}
+
+	return envelope
+}
+
+func TestTypedQueryResponses(t *testing.T) {
+	t.Run("GetNumberingRange", func(t *testing.T) {
+		env := loadEnvelope(t, "get_numbering_range_response.xml")
+		if env.Body.GetNumberingRangeResponse == nil {
+			t.Fatal("Expected GetNumberingRangeResponse")
+		}
+
+		resp, err := ToGetNumberingRangeResponse(&env.Body.GetNumberingRangeResponse.Result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != "100" {
+			t.Errorf("Expected StatusCode 100, got %s", resp.StatusCode)
+		}
+		if len(resp.Ranges) != 2 {
+			t.Fatalf("Expected 2 ranges, got %d", len(resp.Ranges))
+		}
+
+		r := resp.Ranges[0]
+		if r.Prefix != "SETP" || r.From != 990000000 || r.To != 995000000 {
+			t.Errorf("Unexpected range %s %d-%d", r.Prefix, r.From, r.To)
+		}
+		if r.Resolution != "18760000001" || r.ResolutionDate != "2019-01-19" {
+			t.Errorf("Unexpected resolution %s (%s)", r.Resolution, r.ResolutionDate)
+		}
+		if r.DateFrom != "2019-01-19" || r.DateTo != "2030-01-19" {
+			t.Errorf("Unexpected validity %s - %s", r.DateFrom, r.DateTo)
+		}
+		if r.TechnicalKey != "fc8eac422eba16e22ffd8c6f94b3f40a6e38162c" {
+			t.Errorf("Unexpected TechnicalKey %s", r.TechnicalKey)
+		}
+
+		if resp.Ranges[1].TechnicalKey != "" {
+			t.Errorf("Expected empty TechnicalKey for nil element, got %s", resp.Ranges[1].TechnicalKey)
+		}
+	})
+
+	t.Run("GetNumberingRangeMalformed", func(t *testing.T) {
+		data, err := os.ReadFile(filepath.Join("testdata", "get_numbering_range_response.xml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		env, err := Parse(bytes.Replace(data, []byte("<c:FromNumber>990000000"), []byte("<c:FromNumber>99O000000"), 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ToGetNumberingRangeResponse(&env.Body.GetNumberingRangeResponse.Result); err == nil || !strings.Contains(err.Error(), "FromNumber") {
+			t.Errorf("Expected error for a non-numeric FromNumber, got %v", err)
+		}
+	})
+
+	t.Run("GetAcquirer", func(t *testing.T) {
+		env := loadEnvelope(t, "get_acquirer_response.xml")
+		if env.Body.GetAcquirerResponse == nil {
+			t.Fatal("Expected GetAcquirerResponse")
+		}
+
+		resp := ToGetAcquirerResponse(&env.Body.GetAcquirerResponse.Result)
+		if resp.Name != "CLIENTE DE PRUEBAS S.A.S." {
+			t.Errorf("Unexpected Name %s", resp.Name)
+		}
+		if resp.Email != "facturacion@cliente.com.co" {
+			t.Errorf("Unexpected Email %s", resp.Email)
+		}
+		if resp.StatusCode != "200" || resp.StatusMessage != "Consulta exitosa" {
+			t.Errorf("Unexpected status %s - %s", resp.StatusCode, resp.StatusMessage)
+		}
+	})
+
+	t.Run("GetReferenceNotes", func(t *testing.T) {
+		env := loadEnvelope(t, "get_reference_notes_response.xml")
+		if env.Body.GetReferenceNotesResponse == nil {
+			t.Fatal("Expected GetReferenceNotesResponse")
+		}
+
+		resp := ToGetReferenceNotesResponse(&env.Body.GetReferenceNotesResponse.Result)
+		if resp.StatusCode != "00" {
+			t.Errorf("Expected StatusCode 00, got %s", resp.StatusCode)
+		}
+		if len(resp.Notes) != 2 {
+			t.Fatalf("Expected 2 notes, got %d", len(resp.Notes))
+		}
+		if resp.Notes[0].Type != "CreditNote" || resp.Notes[0].DocumentNumber != "NC1" {
+			t.Errorf("Unexpected first note %+v", resp.Notes[0])
+		}
+		if resp.Notes[1].Type != "DebitNote" || resp.Notes[1].IssueDate != "2025-02-05" {
+			t.Errorf("Unexpected second note %+v", resp.Notes[1])
+		}
+	})
+
+	t.Run("GetDocumentInfo", func(t *testing.T) {
+		env := loadEnvelope(t, "get_document_info_response.xml")
+		if env.Body.GetDocumentInfoResponse == nil {
+			t.Fatal("Expected GetDocumentInfoResponse")
+		}
+
+		resp := ToGetDocumentInfoResponse(&env.Body.GetDocumentInfoResponse.Result)
+		if resp.DocumentNumber != "SETP990000001" || resp.DocumentTypeCode != "01" {
+			t.Errorf("Unexpected document %s (%s)", resp.DocumentNumber, resp.DocumentTypeCode)
+		}
+		if resp.SenderNIT != "800197268" || resp.ReceiverNIT != "900123456" {
+			t.Errorf("Unexpected parties %s -> %s", resp.SenderNIT, resp.ReceiverNIT)
+		}
+		if resp.Status != "Aprobado" {
+			t.Errorf("Unexpected Status %s", resp.Status)
+		}
+		if len(resp.Events) != 2 {
+			t.Fatalf("Expected 2 events, got %d", len(resp.Events))
+		}
+		if resp.Events[0].Code != "030" || resp.Events[0].EventType != "Acuse de recibo" {
+			t.Errorf("Unexpected first event %+v", resp.Events[0])
+		}
+	})
+}
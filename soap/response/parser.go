@@ -6,37 +6,57 @@ import (
 	"strings"
 )
 
+// nsXMLSchemaInstance namespace del atributo i:nil
+const nsXMLSchemaInstance = "http://www.w3.org/2001/XMLSchema-instance"
+
 // SOAPEnvelope estructura para parsear respuesta SOAP
 type SOAPEnvelope struct {
-	XMLName xml.Name `xml:"Envelope"`
-	Body    SOAPBody `xml:"Body"`
+	XMLName xml.Name `xml:"http://www.w3.org/2003/05/soap-envelope Envelope"`
+	Body    SOAPBody `xml:"http://www.w3.org/2003/05/soap-envelope Body"`
 }
 
 // SOAPBody body del SOAP
 type SOAPBody struct {
-	SendBillSyncResponse            *SendBillSyncResponseXML            `xml:"SendBillSyncResponse"`
-	SendBillAsyncResponse           *SendBillAsyncResponseXML           `xml:"SendBillAsyncResponse"`
-	SendTestSetAsyncResponse        *SendTestSetAsyncResponseXML        `xml:"SendTestSetAsyncResponse"`
-	GetStatusResponse               *GetStatusResponseXML               `xml:"GetStatusResponse"`
-	GetStatusZipResponse            *GetStatusZipResponseXML            `xml:"GetStatusZipResponse"`
-	GetXmlByDocumentKeyResponse     *GetXmlByDocumentKeyResponseXML     `xml:"GetXmlByDocumentKeyResponse"`
-	SendBillAttachmentAsyncResponse *SendBillAttachmentAsyncResponseXML `xml:"SendBillAttachmentAsyncResponse"`
-	SendEventResponse               *SendEventResponseXML               `xml:"SendEventResponse"`
-	SendNominaSyncResponse          *SendNominaSyncResponseXML          `xml:"SendNominaSyncResponse"`
-	GetStatusEventResponse          *GetStatusEventResponseXML          `xml:"GetStatusEventResponse"`
-	GetNumberingRangeResponse       *GetNumberingRangeResponseXML       `xml:"GetNumberingRangeResponse"`
-	GetReferenceNotesResponse       *GetReferenceNotesResponseXML       `xml:"GetReferenceNotesResponse"`
-	GetDocumentInfoResponse         *GetDocumentInfoResponseXML         `xml:"GetDocumentInfoResponse"`
-	GetAcquirerResponse             *GetAcquirerResponseXML             `xml:"GetAcquirerResponse"`
-	GetExchangeEmailsResponse       *GetExchangeEmailsResponseXML       `xml:"GetExchangeEmailsResponse"`
-	Fault                           *SOAPFault                          `xml:"Fault"`
-}
-
-// SOAPFault error SOAP
+	SendBillSyncResponse            *SendBillSyncResponseXML            `xml:"http://wcf.dian.colombia SendBillSyncResponse"`
+	SendBillAsyncResponse           *SendBillAsyncResponseXML           `xml:"http://wcf.dian.colombia SendBillAsyncResponse"`
+	SendTestSetAsyncResponse        *SendTestSetAsyncResponseXML        `xml:"http://wcf.dian.colombia SendTestSetAsyncResponse"`
+	GetStatusResponse               *GetStatusResponseXML               `xml:"http://wcf.dian.colombia GetStatusResponse"`
+	GetStatusZipResponse            *GetStatusZipResponseXML            `xml:"http://wcf.dian.colombia GetStatusZipResponse"`
+	GetXmlByDocumentKeyResponse     *GetXmlByDocumentKeyResponseXML     `xml:"http://wcf.dian.colombia GetXmlByDocumentKeyResponse"`
+	SendBillAttachmentAsyncResponse *SendBillAttachmentAsyncResponseXML `xml:"http://wcf.dian.colombia SendBillAttachmentAsyncResponse"`
+	SendEventResponse               *SendEventResponseXML               `xml:"http://wcf.dian.colombia SendEventUpdateStatusResponse"`
+	SendNominaSyncResponse          *SendNominaSyncResponseXML          `xml:"http://wcf.dian.colombia SendNominaSyncResponse"`
+	GetStatusEventResponse          *GetStatusEventResponseXML          `xml:"http://wcf.dian.colombia GetStatusEventResponse"`
+	GetNumberingRangeResponse       *GetNumberingRangeResponseXML       `xml:"http://wcf.dian.colombia GetNumberingRangeResponse"`
+	GetReferenceNotesResponse       *GetReferenceNotesResponseXML       `xml:"http://wcf.dian.colombia GetReferenceNotesResponse"`
+	GetDocumentInfoResponse         *GetDocumentInfoResponseXML         `xml:"http://wcf.dian.colombia GetDocumentInfoResponse"`
+	GetAcquirerResponse             *GetAcquirerResponseXML             `xml:"http://wcf.dian.colombia GetAcquirerResponse"`
+	GetExchangeEmailsResponse       *GetExchangeEmailsResponseXML       `xml:"http://wcf.dian.colombia GetExchangeEmailsResponse"`
+	Fault                           *SOAPFault                          `xml:"http://www.w3.org/2003/05/soap-envelope Fault"`
+}
+
+// SOAPFault error SOAP 1.2
 type SOAPFault struct {
-	Code   string `xml:"Code>Value"`
-	Reason string `xml:"Reason>Text"`
-	Detail string `xml:"Detail"`
+	Code   FaultCodeXML    `xml:"http://www.w3.org/2003/05/soap-envelope Code"`
+	Reason []FaultTextXML  `xml:"http://www.w3.org/2003/05/soap-envelope Reason>Text"`
+	Detail *FaultDetailXML `xml:"http://www.w3.org/2003/05/soap-envelope Detail"`
+}
+
+// FaultCodeXML código (y subcódigo anidado) del Fault
+type FaultCodeXML struct {
+	Value   string        `xml:"http://www.w3.org/2003/05/soap-envelope Value"`
+	Subcode *FaultCodeXML `xml:"http://www.w3.org/2003/05/soap-envelope Subcode"`
+}
+
+// FaultTextXML texto del Reason con su idioma
+type FaultTextXML struct {
+	Lang  string `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	Value string `xml:",chardata"`
+}
+
+// FaultDetailXML contenido del Detail tal como llega
+type FaultDetailXML struct {
+	InnerXML string `xml:",innerxml"`
 }
 
 // SendBillSyncResponseXML respuesta XML de SendBillSync
@@ -61,17 +81,17 @@ type GetStatusResponseXML struct {
 
 // GetStatusZipResponseXML respuesta XML de GetStatusZip
 type GetStatusZipResponseXML struct {
-	ZipKey        string `xml:"GetStatusZipResult>ZipKey"`
-	ContentFile   string `xml:"GetStatusZipResult>ContentFile"`
-	StatusCode    string `xml:"GetStatusZipResult>StatusCode"`
-	StatusMessage string `xml:"GetStatusZipResult>StatusMessage"`
+	ZipKey        NillableString `xml:"GetStatusZipResult>ZipKey"`
+	ContentFile   NillableString `xml:"GetStatusZipResult>ContentFile"`
+	StatusCode    string         `xml:"GetStatusZipResult>StatusCode"`
+	StatusMessage string         `xml:"GetStatusZipResult>StatusMessage"`
 }
 
 // GetXmlByDocumentKeyResponseXML respuesta XML de GetXmlByDocumentKey
 type GetXmlByDocumentKeyResponseXML struct {
-	XmlBase64Bytes string `xml:"GetXmlByDocumentKeyResult>XmlBase64Bytes"`
-	StatusCode     string `xml:"GetXmlByDocumentKeyResult>StatusCode"`
-	StatusMessage  string `xml:"GetXmlByDocumentKeyResult>StatusMessage"`
+	XmlBase64Bytes NillableString `xml:"GetXmlByDocumentKeyResult>XmlBase64Bytes"`
+	StatusCode     string         `xml:"GetXmlByDocumentKeyResult>StatusCode"`
+	StatusMessage  string         `xml:"GetXmlByDocumentKeyResult>StatusMessage"`
 }
 
 // SendBillAttachmentAsyncResponseXML respuesta XML de SendBillAttachmentAsync
@@ -81,7 +101,7 @@ type SendBillAttachmentAsyncResponseXML struct {
 
 // SendEventResponseXML respuesta XML de SendEvent
 type SendEventResponseXML struct {
-	Result ResponseXML `xml:"SendEventResult"`
+	Result ResponseXML `xml:"SendEventUpdateStatusResult"`
 }
 
 // SendNominaSyncResponseXML respuesta XML de SendNominaSync
@@ -96,22 +116,87 @@ type GetStatusEventResponseXML struct {
 
 // GetNumberingRangeResponseXML respuesta XML de GetNumberingRange
 type GetNumberingRangeResponseXML struct {
-	Result ResponseXML `xml:"GetNumberingRangeResult"`
+	Result NumberingRangeResultXML `xml:"GetNumberingRangeResult"`
+}
+
+// NumberingRangeResultXML resultado de GetNumberingRange (NumberRangeResponseList)
+type NumberingRangeResultXML struct {
+	OperationCode        string              `xml:"OperationCode"`
+	OperationDescription string              `xml:"OperationDescription"`
+	ResponseList         []NumberingRangeXML `xml:"ResponseList>NumberRangeResponse"`
+}
+
+// NumberingRangeXML rango de numeración autorizado (NumberRangeResponse)
+type NumberingRangeXML struct {
+	ResolutionNumber string `xml:"ResolutionNumber"`
+	ResolutionDate   string `xml:"ResolutionDate"`
+	Prefix           string `xml:"Prefix"`
+	FromNumber       string `xml:"FromNumber"`
+	ToNumber         string `xml:"ToNumber"`
+	ValidDateFrom    string `xml:"ValidDateFrom"`
+	ValidDateTo      string `xml:"ValidDateTo"`
+	TechnicalKey     string `xml:"TechnicalKey"`
 }
 
 // GetReferenceNotesResponseXML respuesta XML de GetReferenceNotes
 type GetReferenceNotesResponseXML struct {
-	Result ResponseXML `xml:"GetReferenceNotesResult"`
+	Result ReferenceNotesResultXML `xml:"GetReferenceNotesResult"`
+}
+
+// ReferenceNotesResultXML resultado de GetReferenceNotes
+type ReferenceNotesResultXML struct {
+	StatusCode    string             `xml:"StatusCode"`
+	StatusMessage string             `xml:"StatusMessage"`
+	Notes         []ReferenceNoteXML `xml:"ReferenceNotes>ReferenceNote"`
+}
+
+// ReferenceNoteXML nota crédito/débito asociada a una factura
+type ReferenceNoteXML struct {
+	DocumentKey      string `xml:"DocumentKey"`
+	DocumentNumber   string `xml:"DocumentNumber"`
+	DocumentTypeCode string `xml:"DocumentTypeCode"`
+	DocumentType     string `xml:"DocumentType"`
+	IssueDate        string `xml:"IssueDate"`
 }
 
 // GetDocumentInfoResponseXML respuesta XML de GetDocumentInfo
 type GetDocumentInfoResponseXML struct {
-	Result ResponseXML `xml:"GetDocumentInfoResult"`
+	Result DocumentInfoResultXML `xml:"GetDocumentInfoResult"`
+}
+
+// DocumentInfoResultXML resultado de GetDocumentInfo
+type DocumentInfoResultXML struct {
+	StatusCode       string             `xml:"StatusCode"`
+	StatusMessage    string             `xml:"StatusMessage"`
+	DocumentKey      string             `xml:"DocumentKey"`
+	DocumentNumber   string             `xml:"DocumentNumber"`
+	DocumentTypeCode string             `xml:"DocumentTypeCode"`
+	IssueDate        string             `xml:"IssueDate"`
+	SenderCode       string             `xml:"SenderCode"`
+	ReceiverCode     string             `xml:"ReceiverCode"`
+	Status           string             `xml:"Status"`
+	Events           []DocumentEventXML `xml:"Events>Event"`
+}
+
+// DocumentEventXML evento registrado sobre un documento (acuse, aceptación, etc.)
+type DocumentEventXML struct {
+	Code        string `xml:"Code"`
+	Name        string `xml:"Name"`
+	Date        string `xml:"Date"`
+	Description string `xml:"Description"`
 }
 
 // GetAcquirerResponseXML respuesta XML de GetAcquirer
 type GetAcquirerResponseXML struct {
-	Result ResponseXML `xml:"GetAcquirerResult"`
+	Result AcquirerResultXML `xml:"GetAcquirerResult"`
+}
+
+// AcquirerResultXML resultado de GetAcquirer
+type AcquirerResultXML struct {
+	StatusCode    string `xml:"StatusCode"`
+	Message       string `xml:"Message"`
+	ReceiverName  string `xml:"ReceiverName"`
+	ReceiverEmail string `xml:"ReceiverEmail"`
 }
 
 // GetExchangeEmailsResponseXML respuesta XML de GetExchangeEmails
@@ -126,9 +211,11 @@ type ResponseXML struct {
 	StatusDescription string            `xml:"StatusDescription"`
 	StatusMessage     string            `xml:"StatusMessage"`
 	ErrorMessage      []ErrorMessageXML `xml:"ErrorMessage>DianResponse"`
-	XmlDocumentKey    string            `xml:"XmlDocumentKey"`
-	XmlBase64Bytes    string            `xml:"XmlBase64Bytes"`
-	ZipKey            string            `xml:"ZipKey"`
+	ErrorStrings      []string          `xml:"ErrorMessage>string"` // Formato WCF: <c:string>Regla: X, Rechazo: ...</c:string>
+	XmlDocumentKey    NillableString    `xml:"XmlDocumentKey"`
+	XmlBase64Bytes    NillableString    `xml:"XmlBase64Bytes"`
+	ZipKey            NillableString    `xml:"ZipKey"`
+	CsvBase64Bytes    NillableString    `xml:"CsvBase64Bytes"` // GetExchangeEmails
 }
 
 // ErrorMessageXML mensaje de error XML
@@ -137,25 +224,102 @@ type ErrorMessageXML struct {
 	Description string `xml:"Description"`
 }
 
+// NillableString texto de un elemento que puede venir con i:nil="true"
+type NillableString struct {
+	Value string
+	Nil   bool
+}
+
+// UnmarshalXML implementa xml.Unmarshaler respetando el atributo i:nil
+func (n *NillableString) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	*n = NillableString{Nil: isNil(start)}
+
+	var value string
+	if err := d.DecodeElement(&value, &start); err != nil {
+		return err
+	}
+	if !n.Nil {
+		n.Value = value
+	}
+	return nil
+}
+
+// isNil indica si el elemento trae xsi:nil="true"
+func isNil(start xml.StartElement) bool {
+	for _, attr := range start.Attr {
+		if attr.Name.Space == nsXMLSchemaInstance && attr.Name.Local == "nil" {
+			return strings.TrimSpace(attr.Value) == "true"
+		}
+	}
+	return false
+}
+
+// Fault SOAP Fault retornado por DIAN
+//
+// Code y Subcode se exponen sin prefijo (ej: "Sender", "InvalidSecurity").
+type Fault struct {
+	Code    string
+	Subcode string
+	Reason  string
+	Detail  string
+}
+
+// Error implementa la interfaz error
+func (f *Fault) Error() string {
+	code := f.Code
+	if f.Subcode != "" {
+		code += "/" + f.Subcode
+	}
+	return fmt.Sprintf("SOAP Fault: %s - %s", code, f.Reason)
+}
+
+// toFault convierte el SOAPFault parseado en un Fault
+func (sf *SOAPFault) toFault() *Fault {
+	fault := &Fault{
+		Code: localName(sf.Code.Value),
+	}
+
+	// El subcódigo más específico es el último de la cadena
+	for sub := sf.Code.Subcode; sub != nil; sub = sub.Subcode {
+		fault.Subcode = localName(sub.Value)
+	}
+
+	for _, text := range sf.Reason {
+		if fault.Reason == "" || strings.HasPrefix(strings.ToLower(text.Lang), "es") {
+			fault.Reason = strings.TrimSpace(text.Value)
+		}
+	}
+
+	if sf.Detail != nil {
+		fault.Detail = strings.TrimSpace(sf.Detail.InnerXML)
+	}
+
+	return fault
+}
+
+// localName elimina el prefijo de un QName (ej: "s:Sender" -> "Sender")
+func localName(qname string) string {
+	qname = strings.TrimSpace(qname)
+	if idx := strings.LastIndex(qname, ":"); idx != -1 {
+		return qname[idx+1:]
+	}
+	return qname
+}
+
 // Parse parsea la respuesta SOAP
+//
+// Los elementos se resuelven por namespace URI, sin depender de los prefijos
+// que use DIAN. Si la respuesta es un SOAP Fault se retorna un *Fault.
 func Parse(xmlData []byte) (*SOAPEnvelope, error) {
 	var envelope SOAPEnvelope
 
-	// Limpiar namespaces para facilitar el parsing
-	xmlStr := string(xmlData)
-	xmlStr = strings.ReplaceAll(xmlStr, "s:", "")
-	xmlStr = strings.ReplaceAll(xmlStr, "a:", "")
-	xmlStr = strings.ReplaceAll(xmlStr, "b:", "")
-	xmlStr = strings.ReplaceAll(xmlStr, "i:", "")
-
-	err := xml.Unmarshal([]byte(xmlStr), &envelope)
-	if err != nil {
+	if err := xml.Unmarshal(xmlData, &envelope); err != nil {
 		return nil, fmt.Errorf("failed to parse SOAP response: %w", err)
 	}
 
 	// Verificar si hay un SOAP Fault
 	if envelope.Body.Fault != nil {
-		return nil, fmt.Errorf("SOAP Fault: %s - %s", envelope.Body.Fault.Code, envelope.Body.Fault.Reason)
+		return nil, envelope.Body.Fault.toFault()
 	}
 
 	return &envelope, nil
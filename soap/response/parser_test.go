@@ -0,0 +1,93 @@
+package response
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("Preserves content that looks like prefixes", func(t *testing.T) {
+		// Prefijos distintos a los habituales y contenido con "s:", "a:", "b:" e "i:"
+		xmlData := `<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope">
+<env:Body>
+<x:SendBillSyncResponse xmlns:x="http://wcf.dian.colombia">
+<x:SendBillSyncResult xmlns:d="http://schemas.datacontract.org/2004/07/DianResponse" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+<d:ErrorMessage>
+<d:DianResponse><d:Code>FAD06</d:Code><d:Description>Regla: FAD06, Rechazo: Valor del CUFE no coincide (ver a: b: i: s:)</d:Description></d:DianResponse>
+</d:ErrorMessage>
+<d:IsValid>false</d:IsValid>
+<d:StatusCode>99</d:StatusCode>
+<d:XmlBase64Bytes>PHM6YTpiOmk6Pg==</d:XmlBase64Bytes>
+<d:XmlDocumentKey xsi:nil="true"/>
+</x:SendBillSyncResult>
+</x:SendBillSyncResponse>
+</env:Body>
+</env:Envelope>`
+
+		env, err := Parse([]byte(xmlData))
+		if err != nil {
+			t.Fatalf("Error parsing response: %v", err)
+		}
+		if env.Body.SendBillSyncResponse == nil {
+			t.Fatal("Expected SendBillSyncResponse")
+		}
+
+		result := env.Body.SendBillSyncResponse.Result
+		if result.XmlBase64Bytes.Value != "PHM6YTpiOmk6Pg==" {
+			t.Errorf("XmlBase64Bytes corrupted: %s", result.XmlBase64Bytes.Value)
+		}
+		if !result.XmlDocumentKey.Nil || result.XmlDocumentKey.Value != "" {
+			t.Errorf("Expected nil XmlDocumentKey, got %+v", result.XmlDocumentKey)
+		}
+		if len(result.ErrorMessage) != 1 {
+			t.Fatalf("Expected 1 error message, got %d", len(result.ErrorMessage))
+		}
+		expected := "Regla: FAD06, Rechazo: Valor del CUFE no coincide (ver a: b: i: s:)"
+		if result.ErrorMessage[0].Description != expected {
+			t.Errorf("Description corrupted: %s", result.ErrorMessage[0].Description)
+		}
+	})
+
+	t.Run("Ignores elements from other namespaces", func(t *testing.T) {
+		xmlData := `<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope"><s:Body>
+<GetStatusResponse xmlns="http://example.com/other"><GetStatusResult/></GetStatusResponse>
+</s:Body></s:Envelope>`
+
+		env, err := Parse([]byte(xmlData))
+		if err != nil {
+			t.Fatalf("Error parsing response: %v", err)
+		}
+		if env.Body.GetStatusResponse != nil {
+			t.Error("GetStatusResponse from a foreign namespace should be ignored")
+		}
+	})
+
+	t.Run("Fault", func(t *testing.T) {
+		xmlData := `<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope" xmlns:a="http://www.w3.org/2005/08/addressing"><s:Body>
+<s:Fault>
+<s:Code><s:Value>s:Sender</s:Value><s:Subcode><s:Value xmlns:a="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-secext-1.0.xsd">a:InvalidSecurity</s:Value></s:Subcode></s:Code>
+<s:Reason><s:Text xml:lang="en-US">An error occurred when verifying security for the message.</s:Text></s:Reason>
+<s:Detail><Info>certificate expired</Info></s:Detail>
+</s:Fault>
+</s:Body></s:Envelope>`
+
+		env, err := Parse([]byte(xmlData))
+		if env != nil {
+			t.Error("Expected nil envelope for a Fault")
+		}
+
+		var fault *Fault
+		if !errors.As(err, &fault) {
+			t.Fatalf("Expected *Fault, got %T: %v", err, err)
+		}
+		if fault.Code != "Sender" || fault.Subcode != "InvalidSecurity" {
+			t.Errorf("Unexpected code %s/%s", fault.Code, fault.Subcode)
+		}
+		if fault.Reason != "An error occurred when verifying security for the message." {
+			t.Errorf("Unexpected reason %s", fault.Reason)
+		}
+		if fault.Detail != "<Info>certificate expired</Info>" {
+			t.Errorf("Unexpected detail %s", fault.Detail)
+		}
+	})
+}
@@ -0,0 +1,207 @@
+package rules
+
+import "strings"
+
+// catalog reglas de validación más frecuentes del Anexo Técnico (v1.9)
+//
+// No es el anexo completo. Están las reglas de factura más frecuentes; de
+// notas, documento soporte, eventos y nómina solo las que no tienen
+// equivalente en factura. Lookup deriva las demás de notas y documento
+// soporte de la regla de factura (ver derived). Las reglas de nómina (NIE,
+// NIAE) no catalogadas se clasifican como generales: su numeración no sigue
+// los grupos de la factura.
+//
+// Prefijos: FA factura, CA nota crédito, DA nota débito, DSA documento
+// soporte, AA eventos (ApplicationResponse), NIE nómina individual. Z y 90
+// son reglas transversales del servicio. Los XPath usan fe: para Invoice
+// (también el documento soporte), nc: y nd: para las notas; ds: es XMLDSig.
+var catalog = index(
+	// Transversales
+	Rule{"90", Rejection, CategoryDuplicate, AnyDocument, "/*/cbc:UUID",
+		"Documento procesado anteriormente",
+		"El documento ya existe en DIAN: consulte su estado con GetStatus o GetXmlByDocumentKey en vez de reenviarlo"},
+	Rule{"ZB01", Rejection, CategorySchema, AnyDocument, "/*",
+		"Fallo en el esquema XML del archivo",
+		"Valide el XML contra los XSD de UBL 2.1 y las extensiones DIAN antes de firmarlo"},
+	Rule{"ZE02", Rejection, CategoryCertificate, AnyDocument, "/*/ext:UBLExtensions/ext:UBLExtension/ext:ExtensionContent/ds:Signature",
+		"Valor de la firma inválido",
+		"Firme después de la última modificación del XML y no reformatee el documento firmado"},
+	Rule{"ZD05", Rejection, CategoryCertificate, AnyDocument, "/*/ext:UBLExtensions/ext:UBLExtension/ext:ExtensionContent/ds:Signature/ds:KeyInfo/ds:X509Data",
+		"Certificado de firma vencido o revocado",
+		"Renueve el certificado de firma digital con una entidad de certificación autorizada"},
+	Rule{"ZD06", Rejection, CategoryCertificate, AnyDocument, "/*/ext:UBLExtensions/ext:UBLExtension/ext:ExtensionContent/ds:Signature/xades:Object/xades:QualifyingProperties/xades:SignedProperties",
+		"Política de firma o propiedades XAdES inválidas",
+		"Use la política de firma v2 de DIAN y el hash de la política publicado en el Anexo Técnico"},
+
+	// Factura electrónica
+	Rule{"FAD01", Rejection, CategoryGeneral, Invoice, "/fe:Invoice/cbc:UBLVersionID",
+		"Versión del UBL no válida",
+		`UBLVersionID debe ser "UBL 2.1"`},
+	Rule{"FAD02", Rejection, CategoryGeneral, Invoice, "/fe:Invoice/cbc:CustomizationID",
+		"Tipo de operación no válido",
+		"Use un tipo de operación de la tabla 13.1.5 (10 estándar, 09 AIU, 11 mandatos, ...)"},
+	Rule{"FAD03", Rejection, CategoryGeneral, Invoice, "/fe:Invoice/cbc:ProfileID",
+		"Versión del formato no válida",
+		`ProfileID debe ser "DIAN 2.1: Factura Electrónica de Venta"`},
+	Rule{"FAD04", Rejection, CategoryGeneral, Invoice, "/fe:Invoice/cbc:ProfileExecutionID",
+		"Ambiente no válido",
+		"Use 1 en producción y 2 en habilitación, igual que en el cálculo del CUFE"},
+	Rule{"FAD05", Rejection, CategoryNumbering, Invoice, "/fe:Invoice/cbc:ID",
+		"Número de la factura no válido",
+		"El ID debe ser el prefijo seguido del consecutivo, sin espacios ni guiones"},
+	Rule{"FAD06", Rejection, CategoryUUID, Invoice, "/fe:Invoice/cbc:UUID",
+		"Valor del CUFE no está calculado correctamente",
+		"Recalcule el CUFE con los mismos valores del XML (totales con dos decimales, fecha con zona horaria y clave técnica del rango)"},
+	Rule{"FAD07", Rejection, CategoryUUID, Invoice, "/fe:Invoice/cbc:UUID/@schemeName",
+		"Algoritmo del CUFE no válido",
+		`schemeName debe ser "CUFE-SHA384"`},
+	Rule{"FAD09e", Rejection, CategoryGeneral, Invoice, "/fe:Invoice/cbc:IssueDate",
+		"Fecha de emisión posterior a la fecha de recepción",
+		"La fecha de emisión no puede estar en el futuro; revise la zona horaria (-05:00)"},
+	Rule{"FAD09a", Notification, CategoryGeneral, Invoice, "/fe:Invoice/cbc:IssueDate",
+		"Fecha de emisión anterior a la fecha de recepción",
+		"Envíe las facturas el mismo día de su emisión"},
+	Rule{"FAB05b", Rejection, CategoryNumbering, Invoice, "/fe:Invoice/ext:UBLExtensions/ext:UBLExtension/ext:ExtensionContent/sts:DianExtensions/sts:InvoiceControl/sts:AuthorizedInvoices/sts:From",
+		"Número de la factura fuera del rango de numeración autorizado",
+		"Consulte el rango vigente con GetNumberingRange y use un consecutivo dentro de él"},
+	Rule{"FAB07b", Rejection, CategoryNumbering, Invoice, "/fe:Invoice/ext:UBLExtensions/ext:UBLExtension/ext:ExtensionContent/sts:DianExtensions/sts:InvoiceControl/sts:AuthorizationPeriod",
+		"Fecha de emisión fuera de la vigencia de la resolución",
+		"Solicite una nueva resolución de numeración o use un rango vigente"},
+	Rule{"FAB10b", Rejection, CategoryNumbering, Invoice, "/fe:Invoice/ext:UBLExtensions/ext:UBLExtension/ext:ExtensionContent/sts:DianExtensions/sts:InvoiceControl/sts:AuthorizedInvoices/sts:Prefix",
+		"Prefijo no corresponde al de la resolución",
+		"Use exactamente el prefijo autorizado en la resolución de numeración"},
+	Rule{"FAB19b", Rejection, CategoryNumbering, Invoice, "/fe:Invoice/ext:UBLExtensions/ext:UBLExtension/ext:ExtensionContent/sts:DianExtensions/sts:SoftwareProvider/sts:SoftwareID",
+		"Software no autorizado para el emisor",
+		"Verifique el SoftwareID y que el software esté asociado al NIT en el catálogo de participantes"},
+	Rule{"FAB27b", Rejection, CategoryNumbering, Invoice, "/fe:Invoice/ext:UBLExtensions/ext:UBLExtension/ext:ExtensionContent/sts:DianExtensions/sts:SoftwareSecurityCode",
+		"Código de seguridad del software no válido",
+		"SoftwareSecurityCode es SHA-384(SoftwareID + PIN + número del documento)"},
+	Rule{"FAJ07", Rejection, CategoryParty, Invoice, "/fe:Invoice/cac:AccountingSupplierParty/cac:Party/cac:PartyTaxScheme/cbc:RegistrationName",
+		"Nombre o razón social del emisor no informado",
+		"Informe la razón social en PartyTaxScheme/RegistrationName"},
+	Rule{"FAJ21", Rejection, CategoryParty, Invoice, "/fe:Invoice/cac:AccountingSupplierParty/cac:Party/cac:PartyTaxScheme/cbc:CompanyID",
+		"NIT del emisor no válido",
+		"Use el NIT sin dígito de verificación, puntos ni guiones"},
+	Rule{"FAJ24", Rejection, CategoryParty, Invoice, "/fe:Invoice/cac:AccountingSupplierParty/cac:Party/cac:PartyTaxScheme/cbc:CompanyID/@schemeID",
+		"Dígito de verificación del emisor no corresponde",
+		"Calcule el DV con el algoritmo módulo 11 de DIAN"},
+	Rule{"FAJ43b", Notification, CategoryParty, Invoice, "/fe:Invoice/cac:AccountingSupplierParty/cac:Party/cac:PartyName/cbc:Name",
+		"Nombre del emisor no corresponde al registrado en el RUT",
+		"Use la razón social exactamente como aparece en el RUT"},
+	Rule{"FAJ73", Notification, CategoryParty, Invoice, "/fe:Invoice/cac:AccountingSupplierParty/cac:Party/cac:PartyTaxScheme/cac:RegistrationAddress/cbc:ID",
+		"Código de municipio del emisor no válido",
+		"Use el código DIVIPOLA de cinco dígitos del municipio"},
+	Rule{"FAK07", Rejection, CategoryParty, Invoice, "/fe:Invoice/cac:AccountingCustomerParty/cac:Party/cac:PartyTaxScheme/cbc:RegistrationName",
+		"Nombre o razón social del adquirente no informado",
+		"Informe el nombre del adquirente en PartyTaxScheme/RegistrationName"},
+	Rule{"FAK21", Rejection, CategoryParty, Invoice, "/fe:Invoice/cac:AccountingCustomerParty/cac:Party/cac:PartyTaxScheme/cbc:CompanyID",
+		"Identificación del adquirente no válida",
+		"Revise el tipo de documento (schemeName) y el número del adquirente"},
+	Rule{"FAK24", Rejection, CategoryParty, Invoice, "/fe:Invoice/cac:AccountingCustomerParty/cac:Party/cac:PartyTaxScheme/cbc:CompanyID/@schemeID",
+		"Dígito de verificación del adquirente no corresponde",
+		"Calcule el DV con el algoritmo módulo 11 de DIAN (solo para NIT, schemeName 31)"},
+	Rule{"FAK61", Notification, CategoryParty, Invoice, "/fe:Invoice/cac:AccountingCustomerParty/cac:Party/cac:Contact/cbc:ElectronicMail",
+		"Correo electrónico del adquirente no informado",
+		"Informe el correo de recepción del adquirente (GetAcquirer)"},
+	Rule{"FAS01b", Rejection, CategoryTax, Invoice, "/fe:Invoice/cac:TaxTotal/cbc:TaxAmount",
+		"Total del impuesto no corresponde a la suma de sus subtotales",
+		"TaxTotal/TaxAmount debe ser la suma de TaxSubtotal/TaxAmount del mismo tributo"},
+	Rule{"FAS07", Rejection, CategoryTax, Invoice, "/fe:Invoice/cac:TaxTotal/cac:TaxSubtotal/cbc:TaxAmount",
+		"Valor del impuesto no corresponde a base por tarifa",
+		"TaxAmount = TaxableAmount × Percent / 100, redondeado a dos decimales"},
+	Rule{"FAU01", Rejection, CategoryTotals, Invoice, "/fe:Invoice/cac:LegalMonetaryTotal/cbc:LineExtensionAmount/@currencyID",
+		"Moneda del valor no corresponde a DocumentCurrencyCode",
+		"Todos los atributos currencyID deben ser iguales a DocumentCurrencyCode"},
+	Rule{"FAU02", Rejection, CategoryTotals, Invoice, "/fe:Invoice/cac:LegalMonetaryTotal/cbc:LineExtensionAmount",
+		"Valor bruto no corresponde a la suma de las líneas",
+		"LineExtensionAmount debe ser la suma de InvoiceLine/LineExtensionAmount"},
+	Rule{"FAU04", Rejection, CategoryTotals, Invoice, "/fe:Invoice/cac:LegalMonetaryTotal/cbc:TaxInclusiveAmount",
+		"Total con impuestos no corresponde",
+		"TaxInclusiveAmount = TaxExclusiveAmount + impuestos (sin retenciones)"},
+	Rule{"FAU06", Rejection, CategoryTotals, Invoice, "/fe:Invoice/cac:LegalMonetaryTotal/cbc:PayableAmount",
+		"Valor a pagar no corresponde",
+		"PayableAmount = TaxInclusiveAmount - descuentos + cargos - anticipos ± redondeo"},
+	Rule{"FAU14", Notification, CategoryTotals, Invoice, "/fe:Invoice/cac:LegalMonetaryTotal/cbc:PayableRoundingAmount",
+		"Redondeo fuera del rango permitido",
+		"El ajuste al peso no debe superar la tolerancia del Anexo Técnico"},
+	Rule{"FAV02", Rejection, CategoryLines, Invoice, "/fe:Invoice/cbc:LineCountNumeric",
+		"Número de líneas no corresponde",
+		"LineCountNumeric debe ser el número de InvoiceLine"},
+	Rule{"FAX07", Rejection, CategoryLines, Invoice, "/fe:Invoice/cac:InvoiceLine/cbc:LineExtensionAmount",
+		"Valor de la línea no corresponde a cantidad por precio",
+		"LineExtensionAmount = InvoicedQuantity × PriceAmount - descuentos + cargos de la línea"},
+
+	// Notas crédito y débito
+	Rule{"CAD06", Rejection, CategoryUUID, CreditNote, "/nc:CreditNote/cbc:UUID",
+		"Valor del CUDE no está calculado correctamente",
+		"Recalcule el CUDE con el PIN del software (no la clave técnica)"},
+	Rule{"CAD05", Rejection, CategoryNumbering, CreditNote, "/nc:CreditNote/cbc:ID",
+		"Número de la nota crédito no válido",
+		"El ID debe ser el prefijo seguido del consecutivo"},
+	Rule{"CBF01", Rejection, CategoryReference, CreditNote, "/nc:CreditNote/cac:BillingReference/cac:InvoiceDocumentReference/cbc:UUID",
+		"Factura referenciada no existe en DIAN",
+		"Referencie el CUFE de una factura validada; para notas sin referencia use CustomizationID 22"},
+	Rule{"CAU06", Rejection, CategoryTotals, CreditNote, "/nc:CreditNote/cac:LegalMonetaryTotal/cbc:PayableAmount",
+		"Valor a pagar de la nota crédito no corresponde",
+		"PayableAmount = TaxInclusiveAmount - descuentos + cargos ± redondeo"},
+	Rule{"DAD06", Rejection, CategoryUUID, DebitNote, "/nd:DebitNote/cbc:UUID",
+		"Valor del CUDE no está calculado correctamente",
+		"Recalcule el CUDE con el PIN del software (no la clave técnica)"},
+	Rule{"DBF01", Rejection, CategoryReference, DebitNote, "/nd:DebitNote/cac:BillingReference/cac:InvoiceDocumentReference/cbc:UUID",
+		"Factura referenciada no existe en DIAN",
+		"Referencie el CUFE de una factura validada"},
+	Rule{"DAU06", Rejection, CategoryTotals, DebitNote, "/nd:DebitNote/cac:RequestedMonetaryTotal/cbc:PayableAmount",
+		"Valor a pagar de la nota débito no corresponde",
+		"PayableAmount = TaxInclusiveAmount - descuentos + cargos ± redondeo"},
+
+	// Documento soporte
+	Rule{"DSAD06", Rejection, CategoryUUID, SupportDocument, "/fe:Invoice/cbc:UUID",
+		"Valor del CUDS no está calculado correctamente",
+		"Recalcule el CUDS con el PIN del software"},
+	Rule{"DSAB05b", Rejection, CategoryNumbering, SupportDocument, "/fe:Invoice/ext:UBLExtensions/ext:UBLExtension/ext:ExtensionContent/sts:DianExtensions/sts:InvoiceControl/sts:AuthorizedInvoices",
+		"Número del documento soporte fuera del rango autorizado",
+		"Use un consecutivo dentro del rango autorizado para documento soporte"},
+	Rule{"DSAJ21", Rejection, CategoryParty, SupportDocument, "/fe:Invoice/cac:AccountingSupplierParty/cac:Party/cac:PartyTaxScheme/cbc:CompanyID",
+		"Identificación del vendedor no válida",
+		"El vendedor es el sujeto no obligado a facturar; revise tipo y número de identificación"},
+
+	// Eventos (ApplicationResponse)
+	Rule{"AAD06", Rejection, CategoryUUID, Event, "/ApplicationResponse/cbc:UUID",
+		"Valor del CUDE del evento no está calculado correctamente",
+		"Recalcule el CUDE del evento con el PIN del software"},
+	Rule{"AAH03", Rejection, CategoryEvent, Event, "/ApplicationResponse/cac:DocumentResponse/cac:Response/cbc:ResponseCode",
+		"Evento no permitido para el estado del documento",
+		"Respete el orden de eventos: 030 acuse, 032 recibo del bien, 033 aceptación expresa o 031 reclamo"},
+	Rule{"AAH07", Rejection, CategoryReference, Event, "/ApplicationResponse/cac:DocumentResponse/cac:DocumentReference/cbc:UUID",
+		"Documento referenciado no existe en DIAN",
+		"Referencie el CUFE de una factura validada"},
+	Rule{"AAH32", Rejection, CategoryDuplicate, Event, "/ApplicationResponse/cac:DocumentResponse/cac:Response/cbc:ResponseCode",
+		"Evento registrado previamente",
+		"El evento ya existe para el documento; consulte GetStatusEvent"},
+
+	// Nómina electrónica
+	Rule{"NIE024", Rejection, CategoryUUID, Payroll, "/NominaIndividual/InformacionGeneral/@CUNE",
+		"Valor del CUNE no está calculado correctamente",
+		"Recalcule el CUNE con el PIN del software y los totales devengados, deducidos y pagados"},
+	Rule{"NIE031", Rejection, CategoryParty, Payroll, "/NominaIndividual/Empleador/@NIT",
+		"NIT del empleador no válido",
+		"Use el NIT del empleador sin dígito de verificación"},
+	Rule{"NIE065", Rejection, CategoryParty, Payroll, "/NominaIndividual/Trabajador/@NumeroDocumento",
+		"Documento del trabajador no válido",
+		"Revise el tipo y número de documento del trabajador"},
+	Rule{"NIE187", Rejection, CategoryTotals, Payroll, "/NominaIndividual/DevengadosTotal",
+		"Total devengado no corresponde a la suma de devengados",
+		"DevengadosTotal debe ser la suma de todos los conceptos devengados"},
+	Rule{"NIE199", Rejection, CategoryTotals, Payroll, "/NominaIndividual/ComprobanteTotal",
+		"Total del comprobante no corresponde",
+		"ComprobanteTotal = DevengadosTotal - DeduccionesTotal"},
+)
+
+// index construye el mapa del catálogo por código
+func index(rules ...Rule) map[string]Rule {
+	m := make(map[string]Rule, len(rules))
+	for _, rule := range rules {
+		m[strings.ToUpper(rule.Code)] = rule
+	}
+	return m
+}
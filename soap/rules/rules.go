@@ -0,0 +1,212 @@
+package rules
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Severity gravedad de una regla de validación de DIAN
+type Severity string
+
+const (
+	// Rejection la regla impide la validación del documento (Rechazo)
+	Rejection Severity = "Rechazo"
+	// Notification el documento se valida con observaciones (Notificación)
+	Notification Severity = "Notificación"
+)
+
+// Category grupo funcional de la regla
+type Category string
+
+const (
+	CategoryGeneral     Category = "general"     // Encabezado, fechas, versiones
+	CategorySchema      Category = "schema"      // Estructura XML / XSD
+	CategoryCertificate Category = "certificate" // Firma digital y certificado
+	CategoryDuplicate   Category = "duplicate"   // Documento enviado anteriormente
+	CategoryUUID        Category = "uuid"        // CUFE, CUDE, CUDS, CUNE
+	CategoryNumbering   Category = "numbering"   // Resolución, prefijo y rango de numeración
+	CategoryParty       Category = "party"       // Emisor, adquirente, empleador, trabajador
+	CategoryTax         Category = "tax"         // Impuestos y retenciones
+	CategoryTotals      Category = "totals"      // Totales y redondeos
+	CategoryLines       Category = "lines"       // Líneas del documento
+	CategoryReference   Category = "reference"   // Referencias a otros documentos
+	CategoryEvent       Category = "event"       // Eventos (ApplicationResponse)
+)
+
+// Document tipo de documento al que aplica la regla
+type Document string
+
+const (
+	Invoice         Document = "Invoice"
+	CreditNote      Document = "CreditNote"
+	DebitNote       Document = "DebitNote"
+	SupportDocument Document = "SupportDocument"
+	Event           Document = "ApplicationResponse"
+	Payroll         Document = "NominaIndividual"
+	AnyDocument     Document = ""
+)
+
+// Rule regla de validación del Anexo Técnico de DIAN
+type Rule struct {
+	Code        string
+	Severity    Severity // Gravedad por defecto (la respuesta de DIAN prevalece)
+	Category    Category
+	Document    Document
+	XPath       string // Elemento que origina la regla
+	Description string
+	Fix         string // Sugerencia de corrección
+}
+
+// Violation regla reportada por DIAN en ErrorMessage
+type Violation struct {
+	Code     string
+	Severity Severity
+	Message  string // Mensaje de DIAN sin el prefijo "Regla: X, Rechazo:"
+	Rule     Rule   // Entrada del catálogo (Rule.Code vacío si no está catalogada)
+}
+
+// IsFatal indica que la violación impide la validación del documento
+func (v Violation) IsFatal() bool {
+	return v.Severity == Rejection
+}
+
+// IsWarning indica una notificación que no impide la validación
+func (v Violation) IsWarning() bool {
+	return v.Severity == Notification
+}
+
+// IsDuplicate indica que DIAN ya había recibido el documento (regla 90)
+func (v Violation) IsDuplicate() bool {
+	return v.Rule.Category == CategoryDuplicate
+}
+
+// IsCertificateProblem indica un problema de firma digital o certificado
+func (v Violation) IsCertificateProblem() bool {
+	return v.Rule.Category == CategoryCertificate
+}
+
+// Known indica si la regla está en el catálogo
+func (v Violation) Known() bool {
+	return v.Rule.Code != ""
+}
+
+// messagePattern formato de DIAN: "Regla: FAD06, Rechazo: Valor del CUFE..."
+var messagePattern = regexp.MustCompile(`^\s*Regla:\s*([^,\s]+)\s*,\s*(Rechazo|Notificaci[oó]n)\s*:\s*(.*)$`)
+
+// derived documentos cuyas reglas no catalogadas se derivan de la regla de
+// factura con el mismo sufijo, con la raíz del XPath del documento
+var derived = []struct {
+	prefix   string
+	document Document
+	root     string
+}{
+	{"CA", CreditNote, "/nc:CreditNote"},
+	{"DA", DebitNote, "/nd:DebitNote"},
+	{"DSA", SupportDocument, "/fe:Invoice"},
+}
+
+// Lookup busca una regla en el catálogo (sin distinguir mayúsculas)
+//
+// Las reglas de notas y documento soporte que no están en el catálogo se
+// derivan de la regla de factura con el mismo sufijo (CAJ21 de FAJ21): el
+// Anexo Técnico numera igual las reglas de los elementos comunes. Las de
+// nómina no se derivan.
+func Lookup(code string) (Rule, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if rule, ok := catalog[code]; ok {
+		return rule, true
+	}
+	for _, d := range derived {
+		suffix, ok := strings.CutPrefix(code, d.prefix)
+		if !ok {
+			continue
+		}
+		rule, ok := catalog["FA"+suffix]
+		if !ok {
+			return Rule{}, false
+		}
+		rule.Code = d.prefix + strings.TrimPrefix(rule.Code, "FA")
+		rule.Document = d.document
+		rule.XPath = d.root + strings.TrimPrefix(rule.XPath, "/fe:Invoice")
+		if d.document == DebitNote {
+			rule.XPath = strings.Replace(rule.XPath, "cac:LegalMonetaryTotal", "cac:RequestedMonetaryTotal", 1)
+		}
+		return rule, true
+	}
+	return Rule{}, false
+}
+
+// Parse interpreta un ErrorMessage de DIAN
+//
+// code puede venir vacío: DIAN suele enviar solo la descripción con el
+// formato "Regla: X, Rechazo|Notificación: mensaje".
+func Parse(code, description string) Violation {
+	v := Violation{
+		Code:    strings.TrimSpace(code),
+		Message: strings.TrimSpace(description),
+	}
+
+	if m := messagePattern.FindStringSubmatch(description); m != nil {
+		if v.Code == "" {
+			v.Code = m[1]
+		}
+		if m[2] == "Rechazo" {
+			v.Severity = Rejection
+		} else {
+			v.Severity = Notification
+		}
+		v.Message = strings.TrimSpace(m[3])
+	}
+
+	if rule, ok := Lookup(v.Code); ok {
+		v.Rule = rule
+		if v.Severity == "" {
+			v.Severity = rule.Severity
+		}
+	} else {
+		v.Rule.Category = categoryOf(v.Code)
+	}
+
+	if v.Severity == "" {
+		v.Severity = Rejection
+	}
+	return v
+}
+
+// categoryOf categoría aproximada de una regla no catalogada por su prefijo
+func categoryOf(code string) Category {
+	code = strings.ToUpper(code)
+	switch {
+	case code == "90":
+		return CategoryDuplicate
+	case strings.HasPrefix(code, "ZB"):
+		return CategorySchema
+	case strings.HasPrefix(code, "ZE"), strings.HasPrefix(code, "ZD"):
+		return CategoryCertificate
+	}
+
+	// Prefijo de documento (FA, CA, DA, DSA, NIE, AA) + grupo del Anexo Técnico
+	group := code
+	for _, prefix := range []string{"DSA", "NIE", "NIAE", "FA", "CA", "DA", "AA"} {
+		if strings.HasPrefix(group, prefix) {
+			group = strings.TrimPrefix(group, prefix)
+			break
+		}
+	}
+	if group == "" {
+		return CategoryGeneral
+	}
+	switch group[0] {
+	case 'B':
+		return CategoryNumbering
+	case 'J', 'K':
+		return CategoryParty
+	case 'S', 'T':
+		return CategoryTax
+	case 'U':
+		return CategoryTotals
+	case 'V', 'X':
+		return CategoryLines
+	}
+	return CategoryGeneral
+}
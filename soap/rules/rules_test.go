@@ -0,0 +1,87 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		code        string
+		description string
+		wantCode    string
+		severity    Severity
+		category    Category
+		known       bool
+	}{
+		{"rejection text", "", "Regla: FAD06, Rechazo: Valor del CUFE no está calculado correctamente", "FAD06", Rejection, CategoryUUID, true},
+		{"notification text", "", "Regla: FAJ43b, Notificación: Nombre informado no corresponde al registrado en el RUT", "FAJ43b", Notification, CategoryParty, true},
+		{"duplicate", "", "Regla: 90, Rechazo: Documento procesado anteriormente.", "90", Rejection, CategoryDuplicate, true},
+		{"explicit code", "ZE02", "Valor de la firma inválido", "ZE02", Rejection, CategoryCertificate, true},
+		{"catalog severity", "FAK61", "Correo no informado", "FAK61", Notification, CategoryParty, true},
+		{"response overrides catalog", "", "Regla: FAK61, Rechazo: Correo no informado", "FAK61", Rejection, CategoryParty, true},
+		{"unknown by prefix", "", "Regla: FAU99, Rechazo: Total no corresponde", "FAU99", Rejection, CategoryTotals, false},
+		{"derived from invoice", "", "Regla: CAJ21, Rechazo: NIT del emisor no válido", "CAJ21", Rejection, CategoryParty, true},
+		{"payroll not derived", "", "Regla: NIE020, Rechazo: Valor no válido", "NIE020", Rejection, CategoryGeneral, false},
+		{"free text", "", "Error interno", "", Rejection, CategoryGeneral, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := Parse(tt.code, tt.description)
+			if v.Code != tt.wantCode {
+				t.Errorf("Expected code %q, got %q", tt.wantCode, v.Code)
+			}
+			if v.Severity != tt.severity {
+				t.Errorf("Expected severity %s, got %s", tt.severity, v.Severity)
+			}
+			if v.Rule.Category != tt.category {
+				t.Errorf("Expected category %s, got %s", tt.category, v.Rule.Category)
+			}
+			if v.Known() != tt.known {
+				t.Errorf("Expected known=%v", tt.known)
+			}
+		})
+	}
+}
+
+func TestCatalogEntries(t *testing.T) {
+	for code, rule := range catalog {
+		if rule.Severity != Rejection && rule.Severity != Notification {
+			t.Errorf("%s: invalid severity %q", code, rule.Severity)
+		}
+		if rule.Description == "" || rule.Fix == "" || rule.XPath == "" {
+			t.Errorf("%s: incomplete entry", code)
+		}
+		// ds: es el prefijo de XMLDSig; solo puede aparecer dentro de la firma
+		if strings.HasPrefix(rule.XPath, "/ds:") {
+			t.Errorf("%s: document root uses the XMLDSig prefix: %s", code, rule.XPath)
+		}
+	}
+}
+
+func TestLookupDerived(t *testing.T) {
+	tests := []struct {
+		code     string
+		document Document
+		xpath    string
+	}{
+		{"CAU04", CreditNote, "/nc:CreditNote/cac:LegalMonetaryTotal/"},
+		{"DAU04", DebitNote, "/nd:DebitNote/cac:RequestedMonetaryTotal/"},
+		{"dsaj21", SupportDocument, "/fe:Invoice/cac:AccountingSupplierParty/"},
+		{"CAD09e", CreditNote, "/nc:CreditNote/cbc:IssueDate"},
+	}
+	for _, tt := range tests {
+		rule, ok := Lookup(tt.code)
+		if !ok || !strings.EqualFold(rule.Code, tt.code) || rule.Document != tt.document || !strings.HasPrefix(rule.XPath, tt.xpath) {
+			t.Errorf("%s: unexpected rule %+v", tt.code, rule)
+		}
+	}
+	if rule, _ := Lookup("CAD06"); rule.Description != catalog["CAD06"].Description {
+		t.Error("Catalog entry must take precedence over the derived rule")
+	}
+	if _, ok := Lookup("CAU99"); ok {
+		t.Error("Expected no rule without an invoice equivalent")
+	}
+}
@@ -0,0 +1,93 @@
+package security
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Credentials certificado y clave privada para firmar los requests
+//
+// Se cargan una sola vez y son de solo lectura: un mismo *Credentials puede
+// usarse desde varias goroutines.
+type Credentials struct {
+	Certificate *x509.Certificate
+	PrivateKey  *rsa.PrivateKey
+}
+
+// LoadCredentials lee el certificado y la clave privada de archivos PEM
+//
+// certPath puede contener ambos; si la clave no está ahí se busca en keyPath.
+func LoadCredentials(certPath, keyPath string) (*Credentials, error) {
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate: %w", err)
+	}
+
+	creds := &Credentials{}
+	if err := creds.decode(certPEM); err != nil {
+		return nil, err
+	}
+
+	if creds.PrivateKey == nil && keyPath != "" && keyPath != certPath {
+		keyPEM, err := os.ReadFile(keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read private key: %w", err)
+		}
+		if err := creds.decode(keyPEM); err != nil {
+			return nil, err
+		}
+	}
+
+	if creds.Certificate == nil {
+		return nil, errors.New("no certificate found in PEM file")
+	}
+	if creds.PrivateKey == nil {
+		return nil, errors.New("no private key found in PEM file")
+	}
+	return creds, nil
+}
+
+// decode recorre los bloques PEM y toma el primer certificado y la clave RSA
+func (c *Credentials) decode(data []byte) error {
+	rest := data
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil
+		}
+
+		switch block.Type {
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return fmt.Errorf("failed to parse certificate: %w", err)
+			}
+			if c.Certificate == nil {
+				c.Certificate = cert
+			}
+
+		case "RSA PRIVATE KEY":
+			key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+			if err != nil {
+				return fmt.Errorf("failed to parse RSA private key: %w", err)
+			}
+			c.PrivateKey = key
+
+		case "PRIVATE KEY":
+			key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return fmt.Errorf("failed to parse private key: %w", err)
+			}
+			rsaKey, ok := key.(*rsa.PrivateKey)
+			if !ok {
+				return errors.New("private key is not RSA")
+			}
+			c.PrivateKey = rsaKey
+		}
+	}
+}
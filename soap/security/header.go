@@ -7,24 +7,21 @@ import (
 	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/x509"
-	_ "embed"
 	"encoding/base64"
 	"encoding/hex"
-	"encoding/pem"
 	"errors"
 	"fmt"
-	"os"
-	"text/template"
 	"time"
 
+	"github.com/diegofxm/ubl21-dian/soap/templates"
 	xmlpkg "github.com/diegofxm/ubl21-dian/xml"
 )
 
-// NOTA: Los templates se cargan desde disco en runtime porque go:embed no soporta paths relativos con ../
+// Paths de los templates dentro de templates.FS
 const (
-	securityHeaderTemplatePath = "ubl21-dian/soap/templates/security/security_header.tmpl"
-	signedInfoTemplatePath     = "ubl21-dian/soap/templates/security/signed_info.tmpl"
-	toElementTemplatePath      = "ubl21-dian/soap/templates/security/to_element.tmpl"
+	securityHeaderTemplatePath = "security/security_header.tmpl"
+	signedInfoTemplatePath     = "security/signed_info.tmpl"
+	toElementTemplatePath      = "security/to_element.tmpl"
 )
 
 // Header genera el WS-Security header para SOAP
@@ -43,69 +40,30 @@ type Header struct {
 	idTo                     string
 }
 
-// NewHeader crea un nuevo security header
+// NewHeader crea un nuevo security header leyendo las credenciales de disco
+//
+// Para enviar muchos requests, cargue las credenciales una vez con
+// LoadCredentials y use NewHeaderFromCredentials.
 func NewHeader(certPath, keyPath, toURL, action string) (*Header, error) {
-	// Leer certificado y clave privada
-	certPEM, err := os.ReadFile(certPath)
+	creds, err := LoadCredentials(certPath, keyPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read certificate: %w", err)
-	}
-
-	var certificate *x509.Certificate
-	var privateKey *rsa.PrivateKey
-
-	// Decodificar todos los bloques PEM
-	rest := certPEM
-	for {
-		var block *pem.Block
-		block, rest = pem.Decode(rest)
-		if block == nil {
-			break
-		}
-
-		switch block.Type {
-		case "CERTIFICATE":
-			cert, err := x509.ParseCertificate(block.Bytes)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse certificate: %w", err)
-			}
-			if certificate == nil {
-				certificate = cert
-			}
-
-		case "RSA PRIVATE KEY":
-			key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse RSA private key: %w", err)
-			}
-			privateKey = key
-
-		case "PRIVATE KEY":
-			key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse private key: %w", err)
-			}
-			rsaKey, ok := key.(*rsa.PrivateKey)
-			if !ok {
-				return nil, errors.New("private key is not RSA")
-			}
-			privateKey = rsaKey
-		}
+		return nil, err
 	}
+	return NewHeaderFromCredentials(creds, toURL, action)
+}
 
-	if certificate == nil {
-		return nil, errors.New("no certificate found in PEM file")
-	}
-	if privateKey == nil {
-		return nil, errors.New("no private key found in PEM file")
+// NewHeaderFromCredentials crea un security header con credenciales ya cargadas
+func NewHeaderFromCredentials(creds *Credentials, toURL, action string) (*Header, error) {
+	if creds == nil || creds.Certificate == nil || creds.PrivateKey == nil {
+		return nil, errors.New("credentials are not loaded")
 	}
 
 	// Generar IDs únicos para este request
 	uniqueID := generateUniqueID()
-	
+
 	return &Header{
-		privateKey:               privateKey,
-		certificate:              certificate,
+		privateKey:               creds.PrivateKey,
+		certificate:              creds.Certificate,
 		toURL:                    toURL,
 		action:                   action,
 		timestamp:                time.Now().UTC(),
@@ -128,7 +86,7 @@ func (sh *Header) Generate() (string, error) {
 	certB64 := base64.StdEncoding.EncodeToString(sh.certificate.Raw)
 
 	// 3. Calcular digest del wsa:To usando template
-	tmplTo, err := template.ParseFiles(toElementTemplatePath)
+	tmplTo, err := templates.Parse(toElementTemplatePath)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse to template: %w", err)
 	}
@@ -152,7 +110,7 @@ func (sh *Header) Generate() (string, error) {
 	toDigestB64 := base64.StdEncoding.EncodeToString(toDigest[:])
 
 	// 4. Construir SignedInfo usando template
-	tmplSignedInfo, err := template.ParseFiles(signedInfoTemplatePath)
+	tmplSignedInfo, err := templates.Parse(signedInfoTemplatePath)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse signedInfo template: %w", err)
 	}
@@ -182,7 +140,7 @@ func (sh *Header) Generate() (string, error) {
 	signatureB64 := base64.StdEncoding.EncodeToString(signature)
 
 	// 7. Construir el security header final usando template
-	tmplHeader, err := template.ParseFiles(securityHeaderTemplatePath)
+	tmplHeader, err := templates.Parse(securityHeaderTemplatePath)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse security header template: %w", err)
 	}
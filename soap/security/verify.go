@@ -0,0 +1,514 @@
+package security
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	xmlpkg "github.com/diegofxm/ubl21-dian/xml"
+)
+
+// Checks de la verificación de respuestas
+const (
+	CheckHeader      = "header"      // Falta el header WS-Security o la firma
+	CheckTimestamp   = "timestamp"   // Timestamp ausente, vencido o en el futuro
+	CheckDigest      = "digest"      // DigestValue de una parte firmada no coincide
+	CheckSignature   = "signature"   // SignatureValue no corresponde al SignedInfo
+	CheckCertificate = "certificate" // Certificado inválido o fuera del ancla de confianza
+)
+
+// Algoritmos soportados en respuestas de DIAN (WCF usa SHA1 o SHA256)
+const (
+	algDigestSHA1    = "http://www.w3.org/2000/09/xmldsig#sha1"
+	algDigestSHA256  = "http://www.w3.org/2001/04/xmlenc#sha256"
+	algSignRSASHA1   = "http://www.w3.org/2000/09/xmldsig#rsa-sha1"
+	algSignRSASHA256 = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	algExcC14N       = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	nsXMLDSig        = "http://www.w3.org/2000/09/xmldsig#"
+	nsSOAP12         = "http://www.w3.org/2003/05/soap-envelope"
+	nsWSSE           = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-secext-1.0.xsd"
+)
+
+// defaultClockSkew tolerancia de reloj por defecto
+const defaultClockSkew = 5 * time.Minute
+
+// VerificationError error tipado de la verificación de una respuesta firmada
+type VerificationError struct {
+	Check  string // CheckHeader, CheckTimestamp, CheckDigest, CheckSignature o CheckCertificate
+	Reason string
+	Err    error
+}
+
+// Error implementa la interfaz error
+func (e *VerificationError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("response verification failed (%s): %s: %v", e.Check, e.Reason, e.Err)
+	}
+	return fmt.Sprintf("response verification failed (%s): %s", e.Check, e.Reason)
+}
+
+// Unwrap permite usar errors.Unwrap
+func (e *VerificationError) Unwrap() error {
+	return e.Err
+}
+
+// VerifyOptions opciones de verificación de respuestas
+type VerifyOptions struct {
+	Roots     *x509.CertPool // Ancla de confianza para el certificado de DIAN (obligatoria)
+	Now       time.Time      // Hora de referencia (zero = time.Now)
+	ClockSkew time.Duration  // Tolerancia de reloj para Created/Expires (default 5 min)
+}
+
+// VerificationReport resultado detallado de la verificación
+type VerificationReport struct {
+	VerifiedAt     time.Time         `json:"verified_at"`
+	Valid          bool              `json:"valid"`
+	Check          string            `json:"failed_check,omitempty"`
+	Error          string            `json:"error,omitempty"`
+	Timestamp      TimestampReport   `json:"timestamp"`
+	Certificate    CertificateReport `json:"certificate"`
+	References     []ReferenceReport `json:"references"`
+	SignatureValid bool              `json:"signature_valid"`
+}
+
+// TimestampReport datos del wsu:Timestamp de la respuesta
+type TimestampReport struct {
+	Created string `json:"created"`
+	Expires string `json:"expires"`
+	Fresh   bool   `json:"fresh"`
+}
+
+// CertificateReport datos del certificado firmante
+type CertificateReport struct {
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	Serial    string    `json:"serial"`
+	NotBefore time.Time `json:"not_before"`
+	NotAfter  time.Time `json:"not_after"`
+	Trusted   bool      `json:"trusted"`
+}
+
+// ReferenceReport parte firmada de la respuesta
+type ReferenceReport struct {
+	URI         string `json:"uri"`
+	Element     string `json:"element"`
+	DigestValid bool   `json:"digest_valid"`
+}
+
+// responseEnvelope header WS-Security de una respuesta
+type responseEnvelope struct {
+	Header struct {
+		Security *struct {
+			Timestamp *struct {
+				ID      string `xml:"http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd Id,attr"`
+				Created string `xml:"http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd Created"`
+				Expires string `xml:"http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd Expires"`
+			} `xml:"http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd Timestamp"`
+			Tokens []struct {
+				ID    string `xml:"http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd Id,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-secext-1.0.xsd BinarySecurityToken"`
+			Signature *dsSignature `xml:"http://www.w3.org/2000/09/xmldsig# Signature"`
+		} `xml:"http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-secext-1.0.xsd Security"`
+	} `xml:"http://www.w3.org/2003/05/soap-envelope Header"`
+}
+
+// dsSignature elemento ds:Signature
+type dsSignature struct {
+	SignedInfo struct {
+		CanonicalizationMethod dsTransform `xml:"http://www.w3.org/2000/09/xmldsig# CanonicalizationMethod"`
+		SignatureMethod        struct {
+			Algorithm string `xml:"Algorithm,attr"`
+		} `xml:"http://www.w3.org/2000/09/xmldsig# SignatureMethod"`
+		References []struct {
+			URI          string        `xml:"URI,attr"`
+			Transforms   []dsTransform `xml:"http://www.w3.org/2000/09/xmldsig# Transforms>Transform"`
+			DigestMethod struct {
+				Algorithm string `xml:"Algorithm,attr"`
+			} `xml:"http://www.w3.org/2000/09/xmldsig# DigestMethod"`
+			DigestValue string `xml:"http://www.w3.org/2000/09/xmldsig# DigestValue"`
+		} `xml:"http://www.w3.org/2000/09/xmldsig# Reference"`
+	} `xml:"http://www.w3.org/2000/09/xmldsig# SignedInfo"`
+	SignatureValue string `xml:"http://www.w3.org/2000/09/xmldsig# SignatureValue"`
+	KeyInfo        struct {
+		Reference struct {
+			URI string `xml:"URI,attr"`
+		} `xml:"http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-secext-1.0.xsd SecurityTokenReference>Reference"`
+	} `xml:"http://www.w3.org/2000/09/xmldsig# KeyInfo"`
+}
+
+// dsTransform algoritmo con su PrefixList de exc-c14n
+type dsTransform struct {
+	Algorithm           string `xml:"Algorithm,attr"`
+	InclusiveNamespaces struct {
+		PrefixList string `xml:"PrefixList,attr"`
+	} `xml:"http://www.w3.org/2001/10/xml-exc-c14n# InclusiveNamespaces"`
+}
+
+// LoadTrustAnchor carga uno o más certificados PEM como ancla de confianza
+func LoadTrustAnchor(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trust anchor: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	found := false
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse trust anchor: %w", err)
+		}
+		pool.AddCert(cert)
+		found = true
+	}
+
+	if !found {
+		return nil, errors.New("no certificate found in trust anchor file")
+	}
+	return pool, nil
+}
+
+// VerifyResponse verifica el header WS-Security de una respuesta de DIAN
+//
+// Valida la vigencia del Timestamp, los digests de cada parte referenciada
+// (deben estar firmados el Timestamp y el soap:Body hijo del Envelope, y los
+// Id no pueden repetirse, para evitar ataques de envoltura), la firma del
+// SignedInfo de wsse:Security/ds:Signature (único en el documento) y la cadena
+// del certificado contra opts.Roots. Siempre retorna el reporte; si algún check
+// falla, el error es un *VerificationError.
+func VerifyResponse(raw []byte, opts VerifyOptions) (*VerificationReport, error) {
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	skew := opts.ClockSkew
+	if skew == 0 {
+		skew = defaultClockSkew
+	}
+
+	report := &VerificationReport{VerifiedAt: now}
+	fail := func(check, reason string, err error) (*VerificationReport, error) {
+		verr := &VerificationError{Check: check, Reason: reason, Err: err}
+		report.Check = check
+		report.Error = verr.Error()
+		return report, verr
+	}
+
+	var env responseEnvelope
+	if err := xml.Unmarshal(raw, &env); err != nil {
+		return fail(CheckHeader, "response is not a SOAP envelope", err)
+	}
+
+	layout, err := scanEnvelope(raw)
+	if err != nil {
+		return fail(CheckHeader, "invalid envelope", err)
+	}
+
+	sec := env.Header.Security
+	if sec == nil || sec.Signature == nil {
+		return fail(CheckHeader, "response is not signed", nil)
+	}
+	if sec.Timestamp == nil {
+		return fail(CheckTimestamp, "response has no wsu:Timestamp", nil)
+	}
+
+	// 1. Timestamp
+	report.Timestamp = TimestampReport{Created: sec.Timestamp.Created, Expires: sec.Timestamp.Expires}
+	created, err := time.Parse(time.RFC3339, strings.TrimSpace(sec.Timestamp.Created))
+	if err != nil {
+		return fail(CheckTimestamp, "invalid wsu:Created", err)
+	}
+	expires, err := time.Parse(time.RFC3339, strings.TrimSpace(sec.Timestamp.Expires))
+	if err != nil {
+		return fail(CheckTimestamp, "invalid wsu:Expires", err)
+	}
+	if created.After(now.Add(skew)) {
+		return fail(CheckTimestamp, "wsu:Created is in the future", nil)
+	}
+	if !expires.Add(skew).After(now) {
+		return fail(CheckTimestamp, "response expired", nil)
+	}
+	report.Timestamp.Fresh = true
+
+	// 2. Certificado firmante
+	signature := sec.Signature
+	tokenID := strings.TrimPrefix(signature.KeyInfo.Reference.URI, "#")
+	var tokenValue string
+	for _, token := range sec.Tokens {
+		if tokenValue == "" || token.ID == tokenID {
+			tokenValue = token.Value
+		}
+	}
+	if tokenValue == "" {
+		return fail(CheckCertificate, "no BinarySecurityToken in response", nil)
+	}
+	certDER, err := base64.StdEncoding.DecodeString(strings.TrimSpace(tokenValue))
+	if err != nil {
+		return fail(CheckCertificate, "invalid BinarySecurityToken", err)
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		return fail(CheckCertificate, "invalid X509 certificate", err)
+	}
+	report.Certificate = CertificateReport{
+		Subject:   cert.Subject.String(),
+		Issuer:    cert.Issuer.String(),
+		Serial:    cert.SerialNumber.String(),
+		NotBefore: cert.NotBefore,
+		NotAfter:  cert.NotAfter,
+	}
+
+	// 3. Digests de las partes firmadas
+	signedInfo := signature.SignedInfo
+	timestampSigned, bodySigned := false, false
+	for _, ref := range signedInfo.References {
+		id := strings.TrimPrefix(ref.URI, "#")
+		if id == sec.Timestamp.ID && id != "" {
+			timestampSigned = true
+		}
+
+		start, ok := layout.ids[id]
+		if !ok || !strings.HasPrefix(ref.URI, "#") {
+			return fail(CheckDigest, "referenced element "+ref.URI+" not found", nil)
+		}
+		if start == layout.body {
+			bodySigned = true
+		}
+		element, err := xmlpkg.ExtractElement(raw, xmlpkg.ByID(id))
+		if err != nil {
+			return fail(CheckDigest, "referenced element "+ref.URI+" not found", err)
+		}
+
+		entry := ReferenceReport{URI: ref.URI, Element: elementName(element)}
+		canonical, err := xmlpkg.CanonicalizeExclusive(element, transformPrefixes(ref.Transforms))
+		if err != nil {
+			return fail(CheckDigest, "failed to canonicalize "+ref.URI, err)
+		}
+		digest, err := digestFor(ref.DigestMethod.Algorithm, canonical)
+		if err != nil {
+			return fail(CheckDigest, ref.URI, err)
+		}
+		entry.DigestValid = digest == strings.TrimSpace(ref.DigestValue)
+		report.References = append(report.References, entry)
+
+		if !entry.DigestValid {
+			return fail(CheckDigest, "digest mismatch for "+ref.URI, nil)
+		}
+	}
+	if !timestampSigned {
+		return fail(CheckDigest, "wsu:Timestamp is not covered by the signature", nil)
+	}
+	if !bodySigned {
+		return fail(CheckDigest, "soap:Body is not covered by the signature", nil)
+	}
+
+	// 4. Firma del SignedInfo: el único, hijo del ds:Signature de wsse:Security
+	if start, _, err := xmlpkg.FindElement(raw, xmlpkg.ByName(nsXMLDSig, "SignedInfo")); err != nil || start != layout.signedInfo {
+		return fail(CheckSignature, "SignedInfo not found in wsse:Security", err)
+	}
+	signedInfoElement, err := xmlpkg.ExtractElement(raw, xmlpkg.ByName(nsXMLDSig, "SignedInfo"))
+	if err != nil {
+		return fail(CheckSignature, "SignedInfo not found", err)
+	}
+	canonical, err := xmlpkg.CanonicalizeExclusive(signedInfoElement, strings.Fields(signedInfo.CanonicalizationMethod.InclusiveNamespaces.PrefixList))
+	if err != nil {
+		return fail(CheckSignature, "failed to canonicalize SignedInfo", err)
+	}
+	signatureValue, err := base64.StdEncoding.DecodeString(strings.TrimSpace(signature.SignatureValue))
+	if err != nil {
+		return fail(CheckSignature, "invalid SignatureValue", err)
+	}
+	publicKey, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return fail(CheckSignature, "certificate key is not RSA", nil)
+	}
+	if err := verifySignature(signedInfo.SignatureMethod.Algorithm, publicKey, canonical, signatureValue); err != nil {
+		return fail(CheckSignature, "SignatureValue does not match SignedInfo", err)
+	}
+	report.SignatureValid = true
+
+	// 5. Ancla de confianza
+	if opts.Roots == nil {
+		return fail(CheckCertificate, "no trust anchor configured", nil)
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:       opts.Roots,
+		CurrentTime: now,
+		KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return fail(CheckCertificate, "certificate is not trusted", err)
+	}
+	report.Certificate.Trusted = true
+
+	report.Valid = true
+	return report, nil
+}
+
+// envelopeLayout posición del soap:Body, del ds:SignedInfo y de los elementos con Id
+type envelopeLayout struct {
+	body       int            // Offset del soap:Body hijo directo de soap:Envelope
+	signedInfo int            // Offset del ds:SignedInfo de wsse:Security/ds:Signature
+	ids        map[string]int // Id (wsu:Id, Id o ID) -> offset del elemento
+}
+
+// Ruta del ds:SignedInfo que se verifica: Envelope/Header/Security/Signature/SignedInfo
+var signedInfoPath = []xml.Name{
+	{Space: nsSOAP12, Local: "Envelope"},
+	{Space: nsSOAP12, Local: "Header"},
+	{Space: nsWSSE, Local: "Security"},
+	{Space: nsXMLDSig, Local: "Signature"},
+	{Space: nsXMLDSig, Local: "SignedInfo"},
+}
+
+// scanEnvelope ubica el soap:Body y el ds:SignedInfo por posición y rechaza Id
+// repetidos y firmas adicionales, que permitirían verificar un elemento
+// distinto del que se usa (ataques de envoltura)
+func scanEnvelope(raw []byte) (*envelopeLayout, error) {
+	layout := &envelopeLayout{body: -1, signedInfo: -1, ids: map[string]int{}}
+	decoder := xml.NewDecoder(bytes.NewReader(raw))
+	var path []xml.Name
+	depth := 0
+	for {
+		offset := int(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			path = append(path, t.Name)
+			switch t.Name {
+			case signedInfoPath[2]:
+				if depth != 3 || path[1] != signedInfoPath[1] {
+					return nil, errors.New("wsse:Security outside soap:Header")
+				}
+			case signedInfoPath[3]:
+				if depth != 4 || path[2] != signedInfoPath[2] {
+					return nil, errors.New("ds:Signature outside wsse:Security")
+				}
+			case signedInfoPath[4]:
+				if layout.signedInfo >= 0 {
+					return nil, errors.New("more than one ds:SignedInfo")
+				}
+				if !samePath(path, signedInfoPath) {
+					return nil, errors.New("ds:SignedInfo outside wsse:Security/ds:Signature")
+				}
+				layout.signedInfo = offset
+			}
+			switch {
+			case depth == 1 && t.Name != xml.Name{Space: nsSOAP12, Local: "Envelope"}:
+				return nil, fmt.Errorf("root element is %s, not soap:Envelope", t.Name.Local)
+			case depth == 2 && t.Name == xml.Name{Space: nsSOAP12, Local: "Body"}:
+				if layout.body >= 0 {
+					return nil, errors.New("more than one soap:Body")
+				}
+				layout.body = offset
+			}
+			for _, attr := range t.Attr {
+				switch attr.Name.Local {
+				case "Id", "ID", "id":
+					if _, dup := layout.ids[attr.Value]; dup {
+						return nil, fmt.Errorf("duplicate Id %q", attr.Value)
+					}
+					layout.ids[attr.Value] = offset
+				}
+			}
+		case xml.EndElement:
+			depth--
+			path = path[:len(path)-1]
+		}
+	}
+	if layout.body < 0 {
+		return nil, errors.New("no soap:Body in envelope")
+	}
+	return layout, nil
+}
+
+// samePath indica si dos rutas de elementos son iguales
+func samePath(a, b []xml.Name) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// transformPrefixes PrefixList del Transform exc-c14n
+func transformPrefixes(transforms []dsTransform) []string {
+	for _, t := range transforms {
+		if t.Algorithm == algExcC14N {
+			return strings.Fields(t.InclusiveNamespaces.PrefixList)
+		}
+	}
+	return nil
+}
+
+// digestFor calcula el digest base64 según el algoritmo
+func digestFor(algorithm string, data []byte) (string, error) {
+	switch algorithm {
+	case algDigestSHA256:
+		sum := sha256.Sum256(data)
+		return base64.StdEncoding.EncodeToString(sum[:]), nil
+	case algDigestSHA1:
+		sum := sha1.Sum(data)
+		return base64.StdEncoding.EncodeToString(sum[:]), nil
+	}
+	return "", fmt.Errorf("unsupported digest algorithm %q", algorithm)
+}
+
+// verifySignature verifica una firma RSA PKCS#1 v1.5 según el algoritmo
+func verifySignature(algorithm string, key *rsa.PublicKey, data, signature []byte) error {
+	switch algorithm {
+	case algSignRSASHA256:
+		sum := sha256.Sum256(data)
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], signature)
+	case algSignRSASHA1:
+		sum := sha1.Sum(data)
+		return rsa.VerifyPKCS1v15(key, crypto.SHA1, sum[:], signature)
+	}
+	return fmt.Errorf("unsupported signature algorithm %q", algorithm)
+}
+
+// elementName nombre local del elemento extraído (para el reporte)
+func elementName(element []byte) string {
+	decoder := xml.NewDecoder(strings.NewReader(string(element)))
+	for {
+		token, err := decoder.RawToken()
+		if err != nil {
+			return ""
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local
+		}
+	}
+}
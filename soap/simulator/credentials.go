@@ -0,0 +1,92 @@
+package simulator
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/soap/security"
+)
+
+// TestCredentials certificado autofirmado para firmar requests contra el simulador
+type TestCredentials struct {
+	CertPath    string // PEM único con certificado y clave (Config.Certificate)
+	KeyPath     string // PEM con la clave privada PKCS#8 (Config.PrivateKey)
+	Certificate *x509.Certificate
+	PrivateKey  *rsa.PrivateKey
+}
+
+// NewTestCredentials genera un certificado RSA autofirmado en dir
+//
+// Escribe dir/cert.pem (certificado + clave) y dir/key.pem. Usar con
+// t.TempDir() en tests.
+func NewTestCredentials(dir string) (*TestCredentials, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 62))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial: %w", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName:   "Simulador DIAN - Certificado de pruebas",
+			Organization: []string{"Pruebas"},
+			Country:      []string{"CO"},
+		},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal key: %w", err)
+	}
+
+	creds := &TestCredentials{
+		CertPath:    filepath.Join(dir, "cert.pem"),
+		KeyPath:     filepath.Join(dir, "key.pem"),
+		Certificate: cert,
+		PrivateKey:  key,
+	}
+
+	// Certificado y clave en el mismo archivo (Config.Certificate)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(creds.CertPath, append(certPEM, keyPEM...), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write certificate: %w", err)
+	}
+	if err := os.WriteFile(creds.KeyPath, keyPEM, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write private key: %w", err)
+	}
+
+	return creds, nil
+}
+
+// Credentials credenciales ya cargadas para operations.* y NewHeaderFromCredentials
+func (c *TestCredentials) Credentials() *security.Credentials {
+	return &security.Credentials{Certificate: c.Certificate, PrivateKey: c.PrivateKey}
+}
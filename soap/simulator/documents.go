@@ -0,0 +1,352 @@
+package simulator
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/documents/applicationresponse"
+	"github.com/diegofxm/ubl21-dian/soap"
+)
+
+// Reglas de validación que aplica el simulador (mismos códigos que DIAN)
+const (
+	RuleDuplicate = "90"   // Documento procesado anteriormente
+	RuleSchema    = "ZB01" // Fallo en el esquema XML del archivo
+	RuleSignature = "ZE02" // Firma digital ausente o inválida
+)
+
+// ruleUUID regla del CUFE/CUDE por tipo de documento
+var ruleUUID = map[string]string{
+	"Invoice":    "FAD06",
+	"CreditNote": "CAD06",
+	"DebitNote":  "DAD06",
+}
+
+// allowedRoots elementos raíz aceptados por cada operación de envío
+var allowedRoots = map[string][]string{
+	soap.ActionSendBillSync:            {"Invoice", "CreditNote", "DebitNote"},
+	soap.ActionSendBillAsync:           {"Invoice", "CreditNote", "DebitNote"},
+	soap.ActionSendTestSetAsync:        {"Invoice", "CreditNote", "DebitNote"},
+	soap.ActionSendBillAttachmentAsync: {"AttachedDocument"},
+	soap.ActionSendEventUpdateStatus:   {"ApplicationResponse"},
+	soap.ActionSendNominaSync:          {"NominaIndividual", "NominaIndividualDeAjuste"},
+}
+
+// typeCodes código de tipo de documento por elemento raíz
+var typeCodes = map[string]string{
+	"Invoice":                  "01",
+	"CreditNote":               "91",
+	"DebitNote":                "92",
+	"ApplicationResponse":      "96",
+	"AttachedDocument":         "89",
+	"NominaIndividual":         "102",
+	"NominaIndividualDeAjuste": "103",
+}
+
+// Document documento recibido por el simulador
+type Document struct {
+	Kind         string // Elemento raíz: Invoice, CreditNote, ApplicationResponse, ...
+	Number       string // cbc:ID
+	Key          string // CUFE/CUDE/CUNE
+	TypeCode     string // 01, 91, 92, ...
+	IssueDate    string
+	Profile      string // ProfileExecutionID
+	SenderNIT    string
+	SenderName   string
+	ReceiverNIT  string
+	ReferenceKey string // CUFE referenciado (notas y eventos)
+	ResponseCode string // Código del evento (ApplicationResponse)
+	FileName     string
+	XML          []byte
+	Signed       bool
+
+	Valid               bool
+	Errors              []string
+	ApplicationResponse []byte // ApplicationResponse de DIAN (sin firmar)
+	Events              []Event
+}
+
+// Event evento registrado sobre un documento (SendEventUpdateStatus)
+type Event struct {
+	Code        string
+	Name        string
+	Date        string
+	Description string
+}
+
+// unzipDocuments descomprime el contentFile y retorna los XML que contiene
+func unzipDocuments(contentFile string) (map[string][]byte, []string, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(contentFile))
+	if err != nil {
+		return nil, nil, fmt.Errorf("contentFile no es base64 válido")
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("contentFile no es un archivo ZIP válido")
+	}
+
+	files := map[string][]byte{}
+	var names []string
+	for _, f := range reader.File {
+		if f.FileInfo().IsDir() || !strings.EqualFold(path.Ext(f.Name), ".xml") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, nil, fmt.Errorf("no se pudo leer %s del ZIP", f.Name)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("no se pudo leer %s del ZIP", f.Name)
+		}
+		files[f.Name] = content
+		names = append(names, f.Name)
+	}
+
+	if len(names) == 0 {
+		return nil, nil, fmt.Errorf("el ZIP no contiene documentos XML")
+	}
+	return files, names, nil
+}
+
+// parseDocument extrae los datos del documento necesarios para validar y responder
+func parseDocument(fileName string, data []byte) (*Document, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	doc := &Document{FileName: fileName, XML: data}
+	values := map[string]string{}
+	var stack []string
+	var text strings.Builder
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if doc.Kind == "" {
+				doc.Kind = t.Name.Local
+				continue
+			}
+			if t.Name.Space == soap.NSXMLDSig && t.Name.Local == "Signature" {
+				doc.Signed = true
+			}
+			stack = append(stack, t.Name.Local)
+			p := strings.Join(stack, "/")
+			for _, attr := range t.Attr {
+				if _, ok := values[p+"@"+attr.Name.Local]; !ok {
+					values[p+"@"+attr.Name.Local] = attr.Value
+				}
+			}
+			text.Reset()
+
+		case xml.CharData:
+			text.Write(t)
+
+		case xml.EndElement:
+			if len(stack) == 0 {
+				continue
+			}
+			p := strings.Join(stack, "/")
+			if v := strings.TrimSpace(text.String()); v != "" {
+				if _, ok := values[p]; !ok {
+					values[p] = v
+				}
+			}
+			text.Reset()
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	if doc.Kind == "" {
+		return nil, fmt.Errorf("documento vacío")
+	}
+
+	doc.TypeCode = typeCodes[doc.Kind]
+	if code := values["InvoiceTypeCode"]; code != "" {
+		doc.TypeCode = code
+	}
+
+	switch doc.Kind {
+	case "NominaIndividual", "NominaIndividualDeAjuste":
+		doc.Number = values["NumeroSecuenciaXML@Numero"]
+		doc.Key = values["InformacionGeneral@CUNE"]
+		doc.IssueDate = values["InformacionGeneral@FechaGen"]
+		doc.SenderNIT = values["Empleador@NIT"]
+		doc.SenderName = values["Empleador@RazonSocial"]
+		doc.ReceiverNIT = values["Trabajador@NumeroDocumento"]
+		doc.Profile = values["InformacionGeneral@TipoAmbiente"]
+
+	case "ApplicationResponse", "AttachedDocument":
+		doc.Number = values["ID"]
+		doc.Key = values["UUID"]
+		doc.IssueDate = values["IssueDate"]
+		doc.Profile = values["ProfileExecutionID"]
+		doc.SenderNIT = values["SenderParty/PartyTaxScheme/CompanyID"]
+		doc.SenderName = values["SenderParty/PartyTaxScheme/RegistrationName"]
+		doc.ReceiverNIT = values["ReceiverParty/PartyTaxScheme/CompanyID"]
+		doc.ResponseCode = values["DocumentResponse/Response/ResponseCode"]
+		doc.ReferenceKey = values["DocumentResponse/DocumentReference/UUID"]
+
+	default:
+		doc.Number = values["ID"]
+		doc.Key = values["UUID"]
+		doc.IssueDate = values["IssueDate"]
+		doc.Profile = values["ProfileExecutionID"]
+		doc.SenderNIT = values["AccountingSupplierParty/Party/PartyTaxScheme/CompanyID"]
+		doc.SenderName = values["AccountingSupplierParty/Party/PartyTaxScheme/RegistrationName"]
+		doc.ReceiverNIT = values["AccountingCustomerParty/Party/PartyTaxScheme/CompanyID"]
+		doc.ReferenceKey = values["BillingReference/InvoiceDocumentReference/UUID"]
+	}
+
+	return doc, nil
+}
+
+// validateDocument aplica las validaciones básicas de DIAN
+// Debe llamarse con s.mu tomado.
+func (s *Simulator) validateDocument(action string, doc *Document) []string {
+	var errs []string
+
+	if !contains(allowedRoots[action], doc.Kind) {
+		errs = append(errs, rejection(RuleSchema, fmt.Sprintf("El documento %s no corresponde a la operación %s", doc.Kind, path.Base(action))))
+	}
+	if doc.Number == "" {
+		errs = append(errs, rejection(RuleSchema, "No se encontró el número del documento"))
+	}
+	if doc.Key == "" {
+		rule, ok := ruleUUID[doc.Kind]
+		if !ok {
+			rule = RuleSchema
+		}
+		errs = append(errs, rejection(rule, "No se encontró el CUFE/CUDE/CUNE del documento"))
+	}
+	if !doc.Signed {
+		errs = append(errs, rejection(RuleSignature, "El documento no contiene firma digital"))
+	}
+	if prev, ok := s.documents[doc.Key]; ok && doc.Key != "" && prev.Valid {
+		errs = append(errs, rejection(RuleDuplicate, "Documento procesado anteriormente."))
+	}
+
+	return errs
+}
+
+// applicationResponse genera el ApplicationResponse de DIAN para el documento
+func (s *Simulator) applicationResponse(doc *Document, now time.Time) ([]byte, error) {
+	s.sequence++
+
+	code, description := "02", "Documento validado por la DIAN"
+	if !doc.Valid {
+		code, description = "04", "Documento rechazado por la DIAN"
+	}
+
+	issueDate, err := time.Parse("2006-01-02", doc.IssueDate)
+	if err != nil {
+		issueDate = now
+	}
+
+	profile := doc.Profile
+	if profile == "" {
+		profile = "2"
+	}
+
+	id := strconv.Itoa(s.sequence)
+	cude := sha512.Sum384([]byte(id + doc.Key + now.Format(time.RFC3339Nano)))
+
+	dian := applicationresponse.PartyData{
+		RegistrationName: "Unidad Especial Dirección de Impuestos y Aduanas Nacionales",
+		CompanyID:        "800197268",
+		SchemeID:         "4",
+		SchemeName:       "31",
+		TaxLevelCode:     "O-13",
+		TaxSchemeID:      "01",
+		TaxSchemeName:    "IVA",
+	}
+	issuer := applicationresponse.PartyData{
+		RegistrationName: doc.SenderName,
+		CompanyID:        doc.SenderNIT,
+		SchemeName:       "31",
+		TaxLevelCode:     "O-13",
+		TaxSchemeID:      "01",
+		TaxSchemeName:    "IVA",
+	}
+
+	xmlStr, err := applicationresponse.NewBuilder().
+		SetID(id).
+		SetCUDE(hex.EncodeToString(cude[:])).
+		SetIssueDate(now.Format("2006-01-02")).
+		SetIssueTime(now.Format("15:04:05-07:00")).
+		SetProfileExecutionID(profile).
+		SetSenderParty(dian).
+		SetReceiverParty(issuer).
+		SetResponse(code, append([]string{description}, doc.Errors...)...).
+		SetDocumentReference(applicationresponse.DocumentReferenceData{
+			ID:               doc.Number,
+			UUID:             doc.Key,
+			IssueDate:        issueDate,
+			DocumentTypeCode: doc.TypeCode,
+			DocumentType:     doc.Kind,
+			ValidationResult: &applicationresponse.ValidationResultData{
+				ValidatorID:          dian.RegistrationName,
+				ValidationResultCode: code,
+				ValidationDate:       now,
+				ValidationTime:       now.Format("15:04:05-07:00"),
+			},
+		}).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build ApplicationResponse: %w", err)
+	}
+
+	return []byte(xmlStr), nil
+}
+
+// zipApplicationResponses empaqueta los ApplicationResponse en un ZIP base64
+func zipApplicationResponses(docs []*Document) (string, error) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, doc := range docs {
+		f, err := w.Create("ApplicationResponse-" + doc.Number + ".xml")
+		if err != nil {
+			return "", err
+		}
+		if _, err := f.Write(doc.ApplicationResponse); err != nil {
+			return "", err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// rejection formatea un error como lo hace DIAN
+func rejection(rule, message string) string {
+	return fmt.Sprintf("Regla: %s, Rechazo: %s", rule, message)
+}
+
+// contains indica si value está en list
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
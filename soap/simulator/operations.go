@@ -0,0 +1,430 @@
+package simulator
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/catalogs"
+	"github.com/diegofxm/ubl21-dian/soap"
+	"github.com/diegofxm/ubl21-dian/soap/types"
+)
+
+// Códigos de estado que retorna el simulador (los mismos de DIAN)
+const (
+	StatusProcessed = soap.StatusProcessed
+	StatusNotFound  = soap.StatusNotFound
+	StatusPending   = soap.StatusPending
+	StatusRejected  = soap.StatusRejected
+)
+
+// actionPrefix prefijo común de las SOAP actions (ver soap/constants.go)
+const actionPrefix = "http://wcf.dian.colombia/IWcfDianCustomerServices/"
+
+// documentLabels nombre del documento en el StatusMessage
+var documentLabels = map[string]string{
+	"Invoice":    "La Factura electrónica",
+	"CreditNote": "La Nota Crédito electrónica",
+	"DebitNote":  "La Nota Débito electrónica",
+}
+
+// sendBillSync procesa SendBillSync, SendNominaSync y SendEventUpdateStatus
+func (s *Simulator) sendBillSync(op *operationXML, outcome Outcome) (string, error) {
+	docs, zipErrs, err := s.receive(op, outcome)
+	if err != nil {
+		return "", err
+	}
+
+	result := dianResult{}
+	switch {
+	case len(zipErrs) > 0:
+		result = rejectedResult(zipErrs)
+	case len(docs) != 1:
+		result = rejectedResult([]string{rejection(RuleSchema, "El envío síncrono admite un solo documento por ZIP")})
+	default:
+		s.mu.Lock()
+		result = resultFor(docs[0])
+		s.mu.Unlock()
+	}
+
+	result.Operation = op.XMLName.Local
+	return render("dianResponse", result)
+}
+
+// sendAsync procesa SendBillAsync, SendTestSetAsync y SendBillAttachmentAsync
+//
+// El ZipKey retornado se consulta con GetStatus/GetStatusZip; Outcome.Pending
+// define cuántas consultas responden "en proceso" antes del resultado.
+func (s *Simulator) sendAsync(op *operationXML, outcome Outcome) (string, error) {
+	docs, zipErrs, err := s.receive(op, outcome)
+	if err != nil {
+		return "", err
+	}
+
+	zipKey := newUUID()
+
+	s.mu.Lock()
+	s.tracks[zipKey] = &track{documents: docs, errors: zipErrs, pending: outcome.Pending}
+	s.mu.Unlock()
+
+	return render("uploadResponse", map[string]string{
+		"Operation": op.XMLName.Local,
+		"ZipKey":    zipKey,
+	})
+}
+
+// receive descomprime, valida y almacena los documentos del contentFile
+// Retorna los errores del ZIP por separado de los errores de cada documento.
+func (s *Simulator) receive(op *operationXML, outcome Outcome) ([]*Document, []string, error) {
+	action := actionPrefix + op.XMLName.Local
+
+	files, names, err := unzipDocuments(op.ContentFile)
+	if err != nil {
+		return nil, append([]string{rejection(RuleSchema, err.Error())}, outcome.Rejections...), nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+
+	var docs []*Document
+	for _, name := range names {
+		doc, err := parseDocument(name, files[name])
+		if err != nil {
+			doc = &Document{FileName: name, XML: files[name]}
+			doc.Errors = []string{rejection(RuleSchema, "El XML no está bien formado")}
+		} else {
+			doc.Errors = s.validateDocument(action, doc)
+		}
+		doc.Errors = append(doc.Errors, outcome.Rejections...)
+		doc.Valid = len(doc.Errors) == 0
+
+		ar, err := s.applicationResponse(doc, now)
+		if err != nil {
+			return nil, nil, err
+		}
+		doc.ApplicationResponse = ar
+
+		s.store(doc, now)
+		docs = append(docs, doc)
+	}
+
+	return docs, nil, nil
+}
+
+// store registra el documento y, si es un evento válido, lo asocia a su factura
+// Debe llamarse con s.mu tomado.
+func (s *Simulator) store(doc *Document, now time.Time) {
+	if doc.Key == "" {
+		return
+	}
+	if prev, ok := s.documents[doc.Key]; ok && prev.Valid {
+		return
+	}
+	s.documents[doc.Key] = doc
+
+	if doc.Kind != "ApplicationResponse" || !doc.Valid {
+		return
+	}
+	if referenced, ok := s.documents[doc.ReferenceKey]; ok {
+		referenced.Events = append(referenced.Events, Event{
+			Code:        doc.ResponseCode,
+			Name:        catalogs.Events.NameOf(doc.ResponseCode),
+			Date:        now.Format("2006-01-02T15:04:05"),
+			Description: doc.Number,
+		})
+	}
+}
+
+// getStatus retorna el resultado de un envío (ZipKey) o de un documento (CUFE)
+func (s *Simulator) getStatus(op *operationXML, _ Outcome) (string, error) {
+	s.mu.Lock()
+	result := s.statusOf(op.TrackId)
+	s.mu.Unlock()
+
+	result.Operation = op.XMLName.Local
+	return render("dianResponse", result)
+}
+
+// getStatusEvent retorna el estado de un documento o evento por su CUFE/CUDE
+func (s *Simulator) getStatusEvent(op *operationXML, _ Outcome) (string, error) {
+	s.mu.Lock()
+	result := notFoundResult()
+	if doc, ok := s.documents[op.TrackId]; ok {
+		result = resultFor(doc)
+	}
+	s.mu.Unlock()
+
+	result.Operation = op.XMLName.Local
+	return render("dianResponse", result)
+}
+
+// statusOf resuelve un trackId; con un ZipKey de varios documentos se reporta el primero
+// Debe llamarse con s.mu tomado.
+func (s *Simulator) statusOf(trackID string) dianResult {
+	if t, ok := s.tracks[trackID]; ok {
+		switch {
+		case t.pending > 0:
+			t.pending--
+			return dianResult{
+				StatusCode:        StatusPending,
+				StatusDescription: "Batch en proceso de validación.",
+				StatusMessage:     "Batch en proceso de validación.",
+			}
+		case len(t.errors) > 0:
+			return rejectedResult(t.errors)
+		default:
+			return resultFor(t.documents[0])
+		}
+	}
+
+	if doc, ok := s.documents[trackID]; ok {
+		return resultFor(doc)
+	}
+	return notFoundResult()
+}
+
+// getStatusZip retorna los ApplicationResponse de un envío en un ZIP base64
+func (s *Simulator) getStatusZip(op *operationXML, _ Outcome) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := map[string]string{
+		"ZipKey":        op.TrackId,
+		"StatusCode":    StatusNotFound,
+		"StatusMessage": "TrackId no existe en los registros de la DIAN.",
+	}
+
+	var docs []*Document
+	if t, ok := s.tracks[op.TrackId]; ok {
+		switch {
+		case t.pending > 0:
+			t.pending--
+			data["StatusCode"] = StatusPending
+			data["StatusMessage"] = "Batch en proceso de validación."
+			return render("statusZip", data)
+		case len(t.errors) > 0:
+			data["StatusCode"] = StatusRejected
+			data["StatusMessage"] = strings.Join(t.errors, "; ")
+			return render("statusZip", data)
+		}
+		docs = t.documents
+	} else if doc, ok := s.documents[op.TrackId]; ok {
+		docs = []*Document{doc}
+	}
+
+	if len(docs) > 0 {
+		content, err := zipApplicationResponses(docs)
+		if err != nil {
+			return "", fmt.Errorf("failed to zip ApplicationResponse: %w", err)
+		}
+		data["ContentFile"] = content
+		data["StatusCode"] = StatusProcessed
+		data["StatusMessage"] = "Procesado Correctamente."
+	}
+
+	return render("statusZip", data)
+}
+
+// getXmlByDocumentKey retorna el XML recibido con ese CUFE/CUDE
+func (s *Simulator) getXmlByDocumentKey(op *operationXML, _ Outcome) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := map[string]string{
+		"StatusCode":    StatusNotFound,
+		"StatusMessage": "El documento no existe en los registros de la DIAN.",
+	}
+	if doc, ok := s.documents[op.TrackId]; ok {
+		data["StatusCode"] = StatusProcessed
+		data["StatusMessage"] = "Documento encontrado."
+		data["XmlBase64Bytes"] = base64.StdEncoding.EncodeToString(doc.XML)
+	}
+
+	return render("xmlByDocumentKey", data)
+}
+
+// getNumberingRange retorna los rangos registrados para el NIT y software
+func (s *Simulator) getNumberingRange(op *operationXML, _ Outcome) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ranges []types.NumberingRange
+	for _, r := range s.ranges[op.AccountCode] {
+		if r.softwareID == "" || r.softwareID == op.SoftwareCode {
+			ranges = append(ranges, r.rng)
+		}
+	}
+
+	code, description := "100", "Acción completada OK."
+	if len(ranges) == 0 {
+		code, description = "301", "No se encontraron rangos de numeración para el NIT y software consultados."
+	}
+
+	return render("numberingRange", map[string]interface{}{
+		"OperationCode":        code,
+		"OperationDescription": description,
+		"Ranges":               ranges,
+	})
+}
+
+// getReferenceNotes retorna las notas crédito/débito aceptadas que referencian el CUFE
+func (s *Simulator) getReferenceNotes(op *operationXML, _ Outcome) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := map[string]interface{}{
+		"StatusCode":    StatusNotFound,
+		"StatusMessage": "El documento no existe en los registros de la DIAN.",
+	}
+	if _, ok := s.documents[op.DocumentKey]; !ok {
+		return render("referenceNotes", data)
+	}
+
+	var notes []*Document
+	for _, doc := range s.documents {
+		if doc.Valid && doc.ReferenceKey == op.DocumentKey && (doc.Kind == "CreditNote" || doc.Kind == "DebitNote") {
+			notes = append(notes, doc)
+		}
+	}
+	sort.Slice(notes, func(i, j int) bool {
+		if notes[i].IssueDate != notes[j].IssueDate {
+			return notes[i].IssueDate < notes[j].IssueDate
+		}
+		return notes[i].Number < notes[j].Number
+	})
+
+	data["Notes"] = notes
+	data["StatusCode"] = StatusProcessed
+	data["StatusMessage"] = "Procesado Correctamente."
+	return render("referenceNotes", data)
+}
+
+// getDocumentInfo retorna los datos y eventos de un documento
+func (s *Simulator) getDocumentInfo(op *operationXML, _ Outcome) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := map[string]interface{}{
+		"StatusCode":    StatusNotFound,
+		"StatusMessage": "El documento no existe en los registros de la DIAN.",
+	}
+	if doc, ok := s.documents[op.DocumentKey]; ok {
+		status := "Aprobado"
+		if !doc.Valid {
+			status = "Rechazado"
+		}
+		data["Document"] = doc
+		data["Status"] = status
+		data["StatusCode"] = StatusProcessed
+		data["StatusMessage"] = "Procesado Correctamente."
+	}
+
+	return render("documentInfo", data)
+}
+
+// getAcquirer retorna el nombre y correo registrados del adquiriente
+func (s *Simulator) getAcquirer(op *operationXML, _ Outcome) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := map[string]string{
+		"StatusCode": "404",
+		"Message":    "No se encontró el adquiriente.",
+		"Name":       "",
+		"Email":      "",
+	}
+	if acquirer, ok := s.acquirers[op.AccountCodeT]; ok {
+		data["StatusCode"] = "200"
+		data["Message"] = "Consulta exitosa"
+		data["Name"] = acquirer.Name
+		data["Email"] = acquirer.Email
+	}
+
+	return render("acquirer", data)
+}
+
+// getExchangeEmails retorna en CSV base64 los correos de intercambio de todos
+// los facturadores registrados, como DIAN
+func (s *Simulator) getExchangeEmails(_ *operationXML, _ Outcome) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := map[string]string{
+		"StatusCode":    StatusProcessed,
+		"StatusMessage": "Procesado Correctamente.",
+	}
+	nits := make([]string, 0, len(s.emails))
+	for nit := range s.emails {
+		nits = append(nits, nit)
+	}
+	sort.Strings(nits)
+	if len(nits) > 0 {
+		csv := "NIT,Correo\n"
+		for _, nit := range nits {
+			for _, email := range s.emails[nit] {
+				csv += nit + "," + email + "\n"
+			}
+		}
+		data["CsvBase64Bytes"] = base64.StdEncoding.EncodeToString([]byte(csv))
+	}
+
+	return render("exchangeEmails", data)
+}
+
+// resultFor arma la respuesta DianResponse de un documento procesado
+func resultFor(doc *Document) dianResult {
+	if !doc.Valid {
+		result := rejectedResult(doc.Errors)
+		result.XmlDocumentKey = doc.Key
+		result.XmlFileName = strings.TrimSuffix(doc.FileName, ".xml")
+		result.XmlBase64Bytes = base64.StdEncoding.EncodeToString(doc.ApplicationResponse)
+		return result
+	}
+
+	message := fmt.Sprintf("El documento %s ha sido procesado.", doc.Number)
+	if label, ok := documentLabels[doc.Kind]; ok {
+		message = fmt.Sprintf("%s %s, ha sido autorizada.", label, doc.Number)
+	}
+
+	return dianResult{
+		IsValid:           true,
+		StatusCode:        StatusProcessed,
+		StatusDescription: "Procesado Correctamente.",
+		StatusMessage:     message,
+		XmlBase64Bytes:    base64.StdEncoding.EncodeToString(doc.ApplicationResponse),
+		XmlDocumentKey:    doc.Key,
+		XmlFileName:       strings.TrimSuffix(doc.FileName, ".xml"),
+	}
+}
+
+// rejectedResult respuesta de documento rechazado
+func rejectedResult(errs []string) dianResult {
+	return dianResult{
+		StatusCode:        StatusRejected,
+		StatusDescription: "Validación contiene errores en campos mandatorios.",
+		StatusMessage:     "Documento con errores en campos mandatorios.",
+		Errors:            errs,
+	}
+}
+
+// notFoundResult respuesta de trackId inexistente
+func notFoundResult() dianResult {
+	return dianResult{
+		StatusCode:        StatusNotFound,
+		StatusDescription: "TrackId no existe en los registros de la DIAN.",
+		StatusMessage:     "TrackId no existe en los registros de la DIAN.",
+	}
+}
+
+// newUUID genera un UUID v4 para los ZipKey
+func newUUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,90 @@
+package simulator
+
+import (
+	"fmt"
+	"time"
+)
+
+// AnyAction aplica un Outcome a cualquier operación
+const AnyAction = "*"
+
+// Outcome resultado programado para una llamada al simulador
+//
+// Los campos se combinan: primero se espera Delay y luego se responde con
+// HTTPStatus, Fault o con la operación normal (aplicando Rejections y Pending).
+type Outcome struct {
+	Delay      time.Duration // Espera antes de responder (respeta la cancelación del request)
+	HTTPStatus int           // Status HTTP sin cuerpo SOAP (ej: 503). Con Fault define el status del Fault
+	Fault      *Fault        // Responde con un SOAP Fault en lugar de la operación
+	Rejections []string      // Rechaza el documento con estas reglas ("Regla: FAD06, Rechazo: ...")
+	Pending    int           // Consultas GetStatus/GetStatusZip "en proceso" antes del resultado (envíos asíncronos)
+}
+
+// Fault SOAP Fault que retorna el simulador
+//
+// Code y Subcode van sin prefijo, igual que en response.Fault.
+type Fault struct {
+	Code    string // "Sender" o "Receiver"
+	Subcode string // ej: "InvalidSecurity", "DeserializationFailed"
+	Reason  string
+	Detail  string // XML dentro de s:Detail (opcional)
+}
+
+// Error implementa la interfaz error
+func (f *Fault) Error() string {
+	return fmt.Sprintf("simulated fault %s/%s: %s", f.Code, f.Subcode, f.Reason)
+}
+
+// SecurityFault Fault de WS-Security como el que retorna WCF
+func SecurityFault(subcode, reason string) *Fault {
+	return &Fault{Code: "Sender", Subcode: subcode, Reason: reason}
+}
+
+// ServerFault Fault interno del servicio (Code Receiver)
+func ServerFault(reason string) *Fault {
+	return &Fault{Code: "Receiver", Subcode: "InternalServiceFault", Reason: reason}
+}
+
+// Enqueue programa resultados para las próximas llamadas a action (en orden)
+//
+// action es una de las constantes soap.Action* o AnyAction.
+func (s *Simulator) Enqueue(action string, outcomes ...Outcome) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue[action] = append(s.queue[action], outcomes...)
+}
+
+// SetDefault define el resultado de action cuando no hay resultados en cola
+func (s *Simulator) SetDefault(action string, outcome Outcome) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.defaults[action] = outcome
+}
+
+// Reset elimina los resultados programados
+func (s *Simulator) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = map[string][]Outcome{}
+	s.defaults = map[string]Outcome{}
+}
+
+// nextOutcome toma el siguiente resultado para action
+// Prioridad: cola de la acción, cola general, default de la acción, default general
+func (s *Simulator) nextOutcome(action string) Outcome {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range []string{action, AnyAction} {
+		if queued := s.queue[key]; len(queued) > 0 {
+			s.queue[key] = queued[1:]
+			return queued[0]
+		}
+	}
+	for _, key := range []string{action, AnyAction} {
+		if outcome, ok := s.defaults[key]; ok {
+			return outcome
+		}
+	}
+	return Outcome{}
+}
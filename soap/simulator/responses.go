@@ -0,0 +1,107 @@
+package simulator
+
+import (
+	"bytes"
+	"embed"
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"text/template"
+
+	"github.com/diegofxm/ubl21-dian/soap"
+)
+
+//go:embed templates/*.tmpl
+var templatesFS embed.FS
+
+// responses templates de las respuestas SOAP del simulador
+var responses = template.Must(template.New("").Funcs(template.FuncMap{
+	"x": escape,
+}).ParseFS(templatesFS, "templates/*.tmpl"))
+
+// subcodeNamespaces namespace del prefijo de cada subcódigo de Fault
+var subcodeNamespaces = map[string]string{
+	"ActionNotSupported":     soap.NSAddressing,
+	"DestinationUnreachable": soap.NSAddressing,
+	"DeserializationFailed":  "http://schemas.microsoft.com/net/2005/12/windowscommunicationfoundation/dispatcher",
+	"InternalServiceFault":   "http://schemas.microsoft.com/net/2005/12/windowscommunicationfoundation/dispatcher",
+}
+
+// dianResult datos de la respuesta común de DIAN (DianResponse)
+type dianResult struct {
+	Operation         string
+	IsValid           bool
+	StatusCode        string
+	StatusDescription string
+	StatusMessage     string
+	Errors            []string
+	XmlBase64Bytes    string
+	XmlDocumentKey    string
+	XmlFileName       string
+}
+
+// render ejecuta un template de respuesta
+func render(name string, data interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := responses.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// writeEnvelope escribe una respuesta SOAP exitosa, firmada si hay credenciales
+func (s *Simulator) writeEnvelope(w http.ResponseWriter, action, body string) {
+	s.mu.Lock()
+	signer, now := s.signer, s.now()
+	s.mu.Unlock()
+
+	var out string
+	var err error
+	if signer != nil {
+		out, err = signEnvelope(signer, now, action, body)
+	} else {
+		out, err = render("envelope", map[string]string{
+			"Action": action,
+			"Body":   body,
+		})
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/soap+xml; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(out))
+}
+
+// writeFault escribe un SOAP Fault con el status HTTP indicado
+func writeFault(w http.ResponseWriter, status int, fault *Fault) {
+	subcodeNS, ok := subcodeNamespaces[fault.Subcode]
+	if !ok {
+		subcodeNS = soap.NSWSSecurity
+	}
+
+	out, err := render("fault", map[string]string{
+		"Code":      fault.Code,
+		"Subcode":   fault.Subcode,
+		"SubcodeNS": subcodeNS,
+		"Reason":    fault.Reason,
+		"Detail":    fault.Detail,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/soap+xml; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write([]byte(out))
+}
+
+// escape escapa texto para incluirlo en XML
+func escape(value string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
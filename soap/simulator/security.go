@@ -0,0 +1,212 @@
+package simulator
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/soap"
+	xmlpkg "github.com/diegofxm/ubl21-dian/xml"
+)
+
+// requestEnvelope request SOAP recibido
+type requestEnvelope struct {
+	XMLName xml.Name      `xml:"http://www.w3.org/2003/05/soap-envelope Envelope"`
+	Header  requestHeader `xml:"http://www.w3.org/2003/05/soap-envelope Header"`
+	Body    requestBody   `xml:"http://www.w3.org/2003/05/soap-envelope Body"`
+}
+
+// requestHeader header WS-Security y WS-Addressing
+type requestHeader struct {
+	Security *securityXML `xml:"http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-secext-1.0.xsd Security"`
+	Action   string       `xml:"http://www.w3.org/2005/08/addressing Action"`
+	To       toXML        `xml:"http://www.w3.org/2005/08/addressing To"`
+}
+
+// toXML elemento wsa:To firmado
+type toXML struct {
+	ID    string `xml:"http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd Id,attr"`
+	Value string `xml:",chardata"`
+}
+
+// securityXML elemento wsse:Security
+type securityXML struct {
+	Timestamp struct {
+		Created string `xml:"http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd Created"`
+		Expires string `xml:"http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd Expires"`
+	} `xml:"http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd Timestamp"`
+	BinarySecurityToken string       `xml:"http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-secext-1.0.xsd BinarySecurityToken"`
+	Signature           signatureXML `xml:"http://www.w3.org/2000/09/xmldsig# Signature"`
+}
+
+// signatureXML elemento ds:Signature del header
+type signatureXML struct {
+	SignedInfo struct {
+		CanonicalizationMethod transformXML `xml:"http://www.w3.org/2000/09/xmldsig# CanonicalizationMethod"`
+		Reference              struct {
+			URI         string         `xml:"URI,attr"`
+			Transforms  []transformXML `xml:"http://www.w3.org/2000/09/xmldsig# Transforms>Transform"`
+			DigestValue string         `xml:"http://www.w3.org/2000/09/xmldsig# DigestValue"`
+		} `xml:"http://www.w3.org/2000/09/xmldsig# Reference"`
+	} `xml:"http://www.w3.org/2000/09/xmldsig# SignedInfo"`
+	SignatureValue string `xml:"http://www.w3.org/2000/09/xmldsig# SignatureValue"`
+}
+
+// transformXML algoritmo de canonicalización con su PrefixList
+type transformXML struct {
+	Algorithm           string `xml:"Algorithm,attr"`
+	InclusiveNamespaces struct {
+		PrefixList string `xml:"PrefixList,attr"`
+	} `xml:"http://www.w3.org/2001/10/xml-exc-c14n# InclusiveNamespaces"`
+}
+
+// requestBody contenido del soap:Body
+type requestBody struct {
+	Operation *operationXML `xml:",any"`
+}
+
+// operationXML parámetros de cualquiera de las operaciones
+type operationXML struct {
+	XMLName      xml.Name
+	FileName     string `xml:"http://wcf.dian.colombia fileName"`
+	ContentFile  string `xml:"http://wcf.dian.colombia contentFile"`
+	TestSetId    string `xml:"http://wcf.dian.colombia testSetId"`
+	TrackId      string `xml:"http://wcf.dian.colombia trackId"`
+	DocumentKey  string `xml:"http://wcf.dian.colombia documentKey"`
+	AccountCode  string `xml:"http://wcf.dian.colombia accountCode"`
+	AccountCodeT string `xml:"http://wcf.dian.colombia accountCodeT"`
+	SoftwareCode string `xml:"http://wcf.dian.colombia softwareCode"`
+}
+
+// verifySecurity valida timestamp, certificado, digest de wsa:To y firma del SignedInfo
+func (s *Simulator) verifySecurity(raw []byte, env *requestEnvelope, url string) error {
+	s.mu.Lock()
+	skip, now, skew, trusted := s.skipSecurity, s.now(), s.clockSkew, s.trusted
+	s.mu.Unlock()
+
+	if skip {
+		return nil
+	}
+
+	sec := env.Header.Security
+	if sec == nil {
+		return SecurityFault("InvalidSecurity", "El mensaje no contiene el header wsse:Security")
+	}
+
+	// 1. Timestamp
+	created, err := time.Parse(time.RFC3339, strings.TrimSpace(sec.Timestamp.Created))
+	if err != nil {
+		return SecurityFault("InvalidSecurity", "wsu:Created inválido")
+	}
+	expires, err := time.Parse(time.RFC3339, strings.TrimSpace(sec.Timestamp.Expires))
+	if err != nil {
+		return SecurityFault("InvalidSecurity", "wsu:Expires inválido")
+	}
+	if created.After(now.Add(skew)) {
+		return SecurityFault("MessageExpired", "wsu:Created está en el futuro")
+	}
+	if !expires.After(now) {
+		return SecurityFault("MessageExpired", "El mensaje expiró (wsu:Expires)")
+	}
+
+	// 2. Certificado
+	certDER, err := base64.StdEncoding.DecodeString(strings.TrimSpace(sec.BinarySecurityToken))
+	if err != nil {
+		return SecurityFault("InvalidSecurityToken", "BinarySecurityToken no es base64 válido")
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		return SecurityFault("InvalidSecurityToken", "BinarySecurityToken no contiene un certificado X509")
+	}
+	if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return SecurityFault("FailedAuthentication", "El certificado no está vigente")
+	}
+	if len(trusted) > 0 && !isTrusted(cert, trusted) {
+		return SecurityFault("FailedAuthentication", "El certificado no está autorizado")
+	}
+	publicKey, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return SecurityFault("UnsupportedSecurityToken", "El certificado no tiene clave RSA")
+	}
+
+	// 3. Digest del elemento referenciado (wsa:To)
+	signedInfo := sec.Signature.SignedInfo
+	toID := env.Header.To.ID
+	if toID == "" || signedInfo.Reference.URI != "#"+toID {
+		return SecurityFault("FailedCheck", "La firma no referencia el elemento wsa:To")
+	}
+
+	toElement, err := xmlpkg.ExtractElement(raw, xmlpkg.ByID(toID))
+	if err != nil {
+		return SecurityFault("FailedCheck", "No se encontró el elemento referenciado #"+toID)
+	}
+	toC14N, err := xmlpkg.CanonicalizeExclusive(toElement, referencePrefixes(signedInfo.Reference.Transforms))
+	if err != nil {
+		return fmt.Errorf("failed to canonicalize wsa:To: %w", err)
+	}
+	digest := sha256.Sum256(toC14N)
+	if base64.StdEncoding.EncodeToString(digest[:]) != strings.TrimSpace(signedInfo.Reference.DigestValue) {
+		return SecurityFault("FailedCheck", "El DigestValue de wsa:To no coincide")
+	}
+
+	// 4. Firma del SignedInfo
+	signedInfoElement, err := xmlpkg.ExtractElement(raw, xmlpkg.ByName(soap.NSXMLDSig, "SignedInfo"))
+	if err != nil {
+		return SecurityFault("FailedCheck", "No se encontró ds:SignedInfo")
+	}
+	signedInfoC14N, err := xmlpkg.CanonicalizeExclusive(signedInfoElement, prefixList(signedInfo.CanonicalizationMethod))
+	if err != nil {
+		return fmt.Errorf("failed to canonicalize SignedInfo: %w", err)
+	}
+	signatureValue, err := base64.StdEncoding.DecodeString(strings.TrimSpace(sec.Signature.SignatureValue))
+	if err != nil {
+		return SecurityFault("FailedCheck", "SignatureValue no es base64 válido")
+	}
+	hash := sha256.Sum256(signedInfoC14N)
+	if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hash[:], signatureValue); err != nil {
+		return SecurityFault("FailedCheck", "La firma del SignedInfo no es válida")
+	}
+
+	// 5. Destino (WCF aplica AddressFilter sobre wsa:To)
+	if strings.TrimRight(strings.TrimSpace(env.Header.To.Value), "/") != strings.TrimRight(url, "/") {
+		return &Fault{
+			Code:    "Sender",
+			Subcode: "DestinationUnreachable",
+			Reason:  "The message with To '" + env.Header.To.Value + "' cannot be processed at the receiver, due to an AddressFilter mismatch at the EndpointDispatcher.",
+		}
+	}
+
+	return nil
+}
+
+// referencePrefixes PrefixList del Transform exc-c14n de la referencia
+func referencePrefixes(transforms []transformXML) []string {
+	for _, t := range transforms {
+		if t.Algorithm == soap.NSExcC14N {
+			return prefixList(t)
+		}
+	}
+	return nil
+}
+
+// prefixList separa el atributo PrefixList de InclusiveNamespaces
+func prefixList(t transformXML) []string {
+	return strings.Fields(t.InclusiveNamespaces.PrefixList)
+}
+
+// isTrusted indica si el certificado está entre los autorizados
+func isTrusted(cert *x509.Certificate, trusted []*x509.Certificate) bool {
+	for _, t := range trusted {
+		if bytes.Equal(cert.Raw, t.Raw) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package simulator
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	xmlpkg "github.com/diegofxm/ubl21-dian/xml"
+)
+
+// responseTTL vigencia del Timestamp de las respuestas firmadas (igual que WCF)
+const responseTTL = 5 * time.Minute
+
+// SignResponses firma las respuestas exitosas con WS-Security como DIAN
+//
+// Agrega Timestamp, BinarySecurityToken y una firma RSA-SHA256 sobre el
+// Timestamp y el Body, para probar la verificación de respuestas del cliente.
+// nil desactiva la firma.
+func (s *Simulator) SignResponses(creds *TestCredentials) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.signer = creds
+}
+
+// signEnvelope renderiza la respuesta firmada con las credenciales indicadas
+func signEnvelope(creds *TestCredentials, now time.Time, action, body string) (string, error) {
+	now = now.UTC()
+	data := map[string]string{
+		"Action":      action,
+		"Body":        body,
+		"Created":     now.Format("2006-01-02T15:04:05.000Z"),
+		"Expires":     now.Add(responseTTL).Format("2006-01-02T15:04:05.000Z"),
+		"TokenID":     newUUID(),
+		"Certificate": base64.StdEncoding.EncodeToString(creds.Certificate.Raw),
+		// Se completan en cada paso; el template no admite claves ausentes
+		"TimestampDigest": "",
+		"BodyDigest":      "",
+		"SignatureValue":  "",
+	}
+
+	// 1. Digests de Timestamp y Body
+	unsigned, err := render("signedEnvelope", data)
+	if err != nil {
+		return "", err
+	}
+	if data["TimestampDigest"], err = digestOf([]byte(unsigned), "_0"); err != nil {
+		return "", err
+	}
+	if data["BodyDigest"], err = digestOf([]byte(unsigned), "_1"); err != nil {
+		return "", err
+	}
+
+	// 2. Firma del SignedInfo canonicalizado
+	digested, err := render("signedEnvelope", data)
+	if err != nil {
+		return "", err
+	}
+	signedInfo, err := xmlpkg.ExtractElement([]byte(digested), xmlpkg.ByName("http://www.w3.org/2000/09/xmldsig#", "SignedInfo"))
+	if err != nil {
+		return "", fmt.Errorf("failed to extract SignedInfo: %w", err)
+	}
+	canonical, err := xmlpkg.CanonicalizeExclusive(signedInfo, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to canonicalize SignedInfo: %w", err)
+	}
+	hash := sha256.Sum256(canonical)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, creds.PrivateKey, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign response: %w", err)
+	}
+	data["SignatureValue"] = base64.StdEncoding.EncodeToString(signature)
+
+	return render("signedEnvelope", data)
+}
+
+// digestOf calcula el digest SHA256 del elemento con el Id indicado
+func digestOf(envelope []byte, id string) (string, error) {
+	element, err := xmlpkg.ExtractElement(envelope, xmlpkg.ByID(id))
+	if err != nil {
+		return "", fmt.Errorf("failed to extract %s: %w", id, err)
+	}
+	canonical, err := xmlpkg.CanonicalizeExclusive(element, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to canonicalize %s: %w", id, err)
+	}
+	sum := sha256.Sum256(canonical)
+	return base64.StdEncoding.EncodeToString(sum[:]), nil
+}
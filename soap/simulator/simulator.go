@@ -0,0 +1,305 @@
+package simulator
+
+import (
+	"crypto/x509"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/soap"
+	"github.com/diegofxm/ubl21-dian/soap/types"
+)
+
+// Simulator emula el servicio WcfDianCustomerServices de DIAN
+//
+// Implementa las 15 operaciones de soap/constants.go sobre un almacenamiento en
+// memoria: verifica el header WS-Security, descomprime y valida los documentos
+// enviados y retorna respuestas con el formato de DIAN (TrackId, CUFE,
+// ApplicationResponse). Es un http.Handler pensado para usarse con httptest:
+//
+//	sim := simulator.New()
+//	srv := httptest.NewServer(sim)
+//	defer srv.Close()
+//
+//	creds, err := simulator.NewTestCredentials(t.TempDir())
+//	transport := soap.NewTransport(srv.URL, nil, 10*time.Second)
+//	resp, err := operations.SendBillSync(transport, creds.Credentials(), srv.URL, soap.ActionSendBillSync, req)
+type Simulator struct {
+	mu sync.Mutex
+
+	now          func() time.Time
+	clockSkew    time.Duration
+	trusted      []*x509.Certificate
+	skipSecurity bool
+	signer       *TestCredentials // Credenciales para firmar respuestas (SignResponses)
+
+	documents map[string]*Document // Por CUFE/CUDE/CUNE
+	tracks    map[string]*track    // Por ZipKey de envíos asíncronos
+	ranges    map[string][]numberingRange
+	acquirers map[string]types.GetAcquirerResponse
+	emails    map[string][]string
+
+	queue    map[string][]Outcome
+	defaults map[string]Outcome
+	requests []Request
+	sequence int
+}
+
+// Request llamada recibida por el simulador
+type Request struct {
+	Action    string // SOAP action (wsa:Action)
+	Operation string // Nombre de la operación (SendBillSync, GetStatus, ...)
+	FileName  string // fileName de los envíos
+	Key       string // trackId o documentKey de las consultas
+	Received  time.Time
+}
+
+// track envío asíncrono identificado por su ZipKey
+type track struct {
+	documents []*Document
+	errors    []string // Errores del ZIP (no asociados a un documento)
+	pending   int
+}
+
+// numberingRange rango de numeración asociado a un software
+type numberingRange struct {
+	softwareID string
+	rng        types.NumberingRange
+}
+
+// handler implementación de una operación
+type handler func(s *Simulator, op *operationXML, outcome Outcome) (string, error)
+
+// handlers operaciones soportadas por SOAP action
+var handlers = map[string]handler{
+	soap.ActionSendBillSync:            (*Simulator).sendBillSync,
+	soap.ActionSendBillAsync:           (*Simulator).sendAsync,
+	soap.ActionSendTestSetAsync:        (*Simulator).sendAsync,
+	soap.ActionSendBillAttachmentAsync: (*Simulator).sendAsync,
+	soap.ActionSendNominaSync:          (*Simulator).sendBillSync,
+	soap.ActionGetStatus:               (*Simulator).getStatus,
+	soap.ActionGetStatusZip:            (*Simulator).getStatusZip,
+	soap.ActionGetStatusEvent:          (*Simulator).getStatusEvent,
+	soap.ActionSendEventUpdateStatus:   (*Simulator).sendBillSync,
+	soap.ActionGetNumberingRange:       (*Simulator).getNumberingRange,
+	soap.ActionGetXmlByDocumentKey:     (*Simulator).getXmlByDocumentKey,
+	soap.ActionGetReferenceNotes:       (*Simulator).getReferenceNotes,
+	soap.ActionGetDocumentInfo:         (*Simulator).getDocumentInfo,
+	soap.ActionGetAcquirer:             (*Simulator).getAcquirer,
+	soap.ActionGetExchangeEmails:       (*Simulator).getExchangeEmails,
+}
+
+// New crea un simulador vacío
+//
+// Por defecto acepta cualquier certificado con firma válida; use TrustCertificate
+// para restringirlo.
+func New() *Simulator {
+	return &Simulator{
+		now:       time.Now,
+		clockSkew: 5 * time.Minute,
+		documents: map[string]*Document{},
+		tracks:    map[string]*track{},
+		ranges:    map[string][]numberingRange{},
+		acquirers: map[string]types.GetAcquirerResponse{},
+		emails:    map[string][]string{},
+		queue:     map[string][]Outcome{},
+		defaults:  map[string]Outcome{},
+	}
+}
+
+// SetClock reemplaza el reloj usado para validar timestamps y fechar respuestas
+func (s *Simulator) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// TrustCertificate restringe los certificados aceptados en BinarySecurityToken
+func (s *Simulator) TrustCertificate(cert *x509.Certificate) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trusted = append(s.trusted, cert)
+}
+
+// SkipSecurity desactiva la verificación del header WS-Security
+func (s *Simulator) SkipSecurity(skip bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.skipSecurity = skip
+}
+
+// AddNumberingRange registra un rango para GetNumberingRange
+// softwareID vacío aplica a cualquier software del NIT
+func (s *Simulator) AddNumberingRange(nit, softwareID string, rng types.NumberingRange) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ranges[nit] = append(s.ranges[nit], numberingRange{softwareID: softwareID, rng: rng})
+}
+
+// AddAcquirer registra un adquiriente para GetAcquirer
+func (s *Simulator) AddAcquirer(identificationNumber, name, email string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.acquirers[identificationNumber] = types.GetAcquirerResponse{
+		IdentificationNumber: identificationNumber,
+		Name:                 name,
+		Email:                email,
+	}
+}
+
+// AddExchangeEmails registra los correos de intercambio de un NIT
+func (s *Simulator) AddExchangeEmails(nit string, emails ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.emails[nit] = append(s.emails[nit], emails...)
+}
+
+// Document retorna una copia del documento recibido con ese CUFE/CUDE/CUNE
+func (s *Simulator) Document(key string) (Document, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	doc, ok := s.documents[key]
+	if !ok {
+		return Document{}, false
+	}
+	return *doc, true
+}
+
+// Requests retorna las llamadas recibidas en orden
+func (s *Simulator) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// ServeHTTP implementa http.Handler
+func (s *Simulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	var env requestEnvelope
+	if err := xml.Unmarshal(raw, &env); err != nil {
+		writeFault(w, http.StatusBadRequest, &Fault{
+			Code:    "Sender",
+			Subcode: "DeserializationFailed",
+			Reason:  "The message could not be parsed: " + err.Error(),
+		})
+		return
+	}
+
+	action := strings.TrimSpace(env.Header.Action)
+	outcome := s.nextOutcome(action)
+	s.record(action, env.Body.Operation)
+
+	if outcome.Delay > 0 {
+		select {
+		case <-time.After(outcome.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if outcome.Fault != nil {
+		status := outcome.HTTPStatus
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+		writeFault(w, status, outcome.Fault)
+		return
+	}
+	if outcome.HTTPStatus != 0 && outcome.HTTPStatus != http.StatusOK {
+		http.Error(w, http.StatusText(outcome.HTTPStatus), outcome.HTTPStatus)
+		return
+	}
+
+	if err := s.verifySecurity(raw, &env, requestURL(r)); err != nil {
+		writeFault(w, http.StatusInternalServerError, toFault(err))
+		return
+	}
+
+	handle, ok := handlers[action]
+	if !ok {
+		writeFault(w, http.StatusInternalServerError, &Fault{
+			Code:    "Sender",
+			Subcode: "ActionNotSupported",
+			Reason:  "The message with Action '" + action + "' cannot be processed at the receiver.",
+		})
+		return
+	}
+
+	op := env.Body.Operation
+	if op == nil || op.XMLName.Space != soap.NSDIANColombia || op.XMLName.Local != path.Base(action) {
+		writeFault(w, http.StatusInternalServerError, &Fault{
+			Code:    "Sender",
+			Subcode: "DeserializationFailed",
+			Reason:  "The body does not contain the " + path.Base(action) + " operation.",
+		})
+		return
+	}
+
+	body, err := handle(s, op, outcome)
+	if err != nil {
+		writeFault(w, http.StatusInternalServerError, toFault(err))
+		return
+	}
+
+	s.writeEnvelope(w, action+"Response", body)
+}
+
+// record registra la llamada recibida
+func (s *Simulator) record(action string, op *operationXML) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	req := Request{
+		Action:    action,
+		Operation: path.Base(action),
+		Received:  s.now(),
+	}
+	if op != nil {
+		req.FileName = op.FileName
+		req.Key = firstNonEmpty(op.TrackId, op.DocumentKey, op.AccountCodeT)
+	}
+	s.requests = append(s.requests, req)
+}
+
+// toFault convierte un error en Fault (Receiver si no es un Fault)
+func toFault(err error) *Fault {
+	var fault *Fault
+	if errors.As(err, &fault) {
+		return fault
+	}
+	return ServerFault(err.Error())
+}
+
+// requestURL URL con la que el cliente llamó al simulador (para validar wsa:To)
+func requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + r.URL.Path
+}
+
+// firstNonEmpty retorna el primer valor no vacío
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
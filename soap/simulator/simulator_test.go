@@ -0,0 +1,835 @@
+package simulator_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/naming"
+	"github.com/diegofxm/ubl21-dian/soap"
+	"github.com/diegofxm/ubl21-dian/soap/operations"
+	"github.com/diegofxm/ubl21-dian/soap/response"
+	"github.com/diegofxm/ubl21-dian/soap/security"
+	"github.com/diegofxm/ubl21-dian/soap/simulator"
+	"github.com/diegofxm/ubl21-dian/soap/types"
+	xmlpkg "github.com/diegofxm/ubl21-dian/xml"
+)
+
+const (
+	testCUFE = "ffff0d032c292b88b3f839f75a51e8459ab645eda8049b3c221649fd18aaea09d5b31c8787e071c6a7d4db6983faaead"
+	testCUDE = "aaaa0d032c292b88b3f839f75a51e8459ab645eda8049b3c221649fd18aaea09d5b31c8787e071c6a7d4db6983faaead"
+)
+
+// env entorno de prueba: simulador + credenciales + transport
+type env struct {
+	sim       *simulator.Simulator
+	url       string
+	creds     *simulator.TestCredentials
+	transport *soap.Transport
+}
+
+func newEnv(t *testing.T) *env {
+	t.Helper()
+
+	creds, err := simulator.NewTestCredentials(t.TempDir())
+	if err != nil {
+		t.Fatalf("Error creating credentials: %v", err)
+	}
+
+	sim := simulator.New()
+	srv := httptest.NewServer(sim)
+	t.Cleanup(srv.Close)
+
+	return &env{
+		sim:       sim,
+		url:       srv.URL,
+		creds:     creds,
+		transport: soap.NewTransport(srv.URL, nil, 5*time.Second),
+	}
+}
+
+func (e *env) sendBillSync(t *testing.T, fileName, xmlDoc string) (*types.SendBillSyncResponse, error) {
+	t.Helper()
+	return operations.SendBillSync(e.transport, e.creds.Credentials(), e.url, soap.ActionSendBillSync,
+		&types.SendBillSyncRequest{FileName: fileName + ".zip", ContentFile: zipBase64(t, fileName+".xml", xmlDoc)})
+}
+
+func zipBase64(t *testing.T, name, content string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	f, err := w.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte(content))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+// ublDocument documento UBL mínimo con firma (el simulador solo verifica su presencia)
+func ublDocument(root, id, uuid, reference string) string {
+	billing := ""
+	if reference != "" {
+		billing = fmt.Sprintf(`<cac:BillingReference><cac:InvoiceDocumentReference><cbc:ID>SETP990000001</cbc:ID><cbc:UUID schemeName="CUFE-SHA384">%s</cbc:UUID></cac:InvoiceDocumentReference></cac:BillingReference>`, reference)
+	}
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<%[1]s xmlns="urn:oasis:names:specification:ubl:schema:xsd:%[1]s-2" xmlns:cac="urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2" xmlns:cbc="urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2" xmlns:ext="urn:oasis:names:specification:ubl:schema:xsd:CommonExtensionComponents-2" xmlns:ds="http://www.w3.org/2000/09/xmldsig#">
+<ext:UBLExtensions><ext:UBLExtension><ext:ExtensionContent><ds:Signature Id="xmldsig"/></ext:ExtensionContent></ext:UBLExtension></ext:UBLExtensions>
+<cbc:ProfileExecutionID>2</cbc:ProfileExecutionID>
+<cbc:ID>%[2]s</cbc:ID>
+<cbc:UUID schemeName="CUFE-SHA384">%[3]s</cbc:UUID>
+<cbc:IssueDate>2025-01-31</cbc:IssueDate>
+%[4]s
+<cac:AccountingSupplierParty><cac:Party><cac:PartyTaxScheme><cbc:RegistrationName>MI EMPRESA SAS</cbc:RegistrationName><cbc:CompanyID schemeID="1" schemeName="31">900123456</cbc:CompanyID></cac:PartyTaxScheme></cac:Party></cac:AccountingSupplierParty>
+<cac:AccountingCustomerParty><cac:Party><cac:PartyTaxScheme><cbc:RegistrationName>CLIENTE SAS</cbc:RegistrationName><cbc:CompanyID schemeID="2" schemeName="31">800111222</cbc:CompanyID></cac:PartyTaxScheme></cac:Party></cac:AccountingCustomerParty>
+</%[1]s>`, root, id, uuid, billing)
+}
+
+// eventDocument ApplicationResponse de evento RADIAN sobre una factura
+func eventDocument(id, cude, code, reference string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<ApplicationResponse xmlns="urn:oasis:names:specification:ubl:schema:xsd:ApplicationResponse-2" xmlns:cac="urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2" xmlns:cbc="urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2" xmlns:ds="http://www.w3.org/2000/09/xmldsig#">
+<ds:Signature/>
+<cbc:ID>%s</cbc:ID>
+<cbc:UUID schemeName="CUDE-SHA384">%s</cbc:UUID>
+<cbc:IssueDate>2025-02-01</cbc:IssueDate>
+<cac:SenderParty><cac:PartyTaxScheme><cbc:CompanyID>800111222</cbc:CompanyID></cac:PartyTaxScheme></cac:SenderParty>
+<cac:ReceiverParty><cac:PartyTaxScheme><cbc:CompanyID>900123456</cbc:CompanyID></cac:PartyTaxScheme></cac:ReceiverParty>
+<cac:DocumentResponse><cac:Response><cbc:ResponseCode>%s</cbc:ResponseCode></cac:Response><cac:DocumentReference><cbc:ID>SETP990000001</cbc:ID><cbc:UUID>%s</cbc:UUID></cac:DocumentReference></cac:DocumentResponse>
+</ApplicationResponse>`, id, cude, code, reference)
+}
+
+func TestSendBillSync(t *testing.T) {
+	e := newEnv(t)
+
+	resp, err := e.sendBillSync(t, "fv0900123456000250000001", ublDocument("Invoice", "SETP990000001", testCUFE, ""))
+	if err != nil {
+		t.Fatalf("SendBillSync failed: %v", err)
+	}
+	if !resp.IsValid || resp.StatusCode != "00" {
+		t.Fatalf("Expected valid response, got %s - %s", resp.StatusCode, resp.StatusDescription)
+	}
+	if resp.XmlDocumentKey != testCUFE {
+		t.Errorf("Expected CUFE as XmlDocumentKey, got %s", resp.XmlDocumentKey)
+	}
+
+	ar, err := base64.StdEncoding.DecodeString(resp.XmlBase64Bytes)
+	if err != nil {
+		t.Fatalf("Invalid XmlBase64Bytes: %v", err)
+	}
+	if !strings.Contains(string(ar), "<ApplicationResponse") || !strings.Contains(string(ar), testCUFE) {
+		t.Error("Expected ApplicationResponse referencing the invoice CUFE")
+	}
+	if !strings.Contains(string(ar), "<cbc:ResponseCode>02</cbc:ResponseCode>") {
+		t.Error("Expected ResponseCode 02 in ApplicationResponse")
+	}
+
+	t.Run("Duplicate", func(t *testing.T) {
+		resp, err := e.sendBillSync(t, "fv0900123456000250000001", ublDocument("Invoice", "SETP990000001", testCUFE, ""))
+		if err != nil {
+			t.Fatalf("SendBillSync failed: %v", err)
+		}
+		if resp.IsValid || resp.StatusCode != "99" {
+			t.Errorf("Expected duplicate rejection, got %s", resp.StatusCode)
+		}
+		if rejections := resp.Rejections(); len(rejections) != 1 || !rejections[0].IsDuplicate() {
+			t.Errorf("Expected duplicate rule, got %+v", rejections)
+		}
+	})
+
+	t.Run("Unsigned", func(t *testing.T) {
+		doc := strings.Replace(ublDocument("Invoice", "SETP990000002", testCUDE, ""), `<ds:Signature Id="xmldsig"/>`, "", 1)
+		resp, err := e.sendBillSync(t, "fv0900123456000250000002", doc)
+		if err != nil {
+			t.Fatalf("SendBillSync failed: %v", err)
+		}
+		if resp.IsValid {
+			t.Error("Expected unsigned document to be rejected")
+		}
+		stored, ok := e.sim.Document(testCUDE)
+		if !ok || len(stored.Errors) != 1 || !strings.Contains(stored.Errors[0], simulator.RuleSignature) {
+			t.Errorf("Expected signature rule, got %v", stored.Errors)
+		}
+	})
+}
+
+func TestAsyncSubmission(t *testing.T) {
+	e := newEnv(t)
+	e.sim.Enqueue(soap.ActionSendTestSetAsync, simulator.Outcome{Pending: 1})
+
+	resp, err := operations.SendTestSetAsync(e.transport, e.creds.Credentials(), e.url, soap.ActionSendTestSetAsync,
+		&types.SendTestSetAsyncRequest{
+			FileName:    "z0900123456000250000001.zip",
+			ContentFile: zipBase64(t, "fv0900123456000250000001.xml", ublDocument("Invoice", "SETP990000001", testCUFE, "")),
+			TestSetId:   "b3f8c2d1-0000-4000-8000-000000000001",
+		})
+	if err != nil {
+		t.Fatalf("SendTestSetAsync failed: %v", err)
+	}
+	if resp.ZipKey == "" {
+		t.Fatal("Expected ZipKey")
+	}
+
+	statusReq := &types.GetStatusRequest{TrackId: resp.ZipKey}
+
+	status, err := operations.GetStatus(e.transport, e.creds.Credentials(), e.url, soap.ActionGetStatus, statusReq)
+	if err != nil {
+		t.Fatalf("GetStatus failed: %v", err)
+	}
+	if status.StatusCode != simulator.StatusPending {
+		t.Errorf("Expected pending status, got %s", status.StatusCode)
+	}
+
+	status, err = operations.GetStatus(e.transport, e.creds.Credentials(), e.url, soap.ActionGetStatus, statusReq)
+	if err != nil {
+		t.Fatalf("GetStatus failed: %v", err)
+	}
+	if !status.IsValid || status.XmlDocumentKey != testCUFE {
+		t.Errorf("Expected processed invoice, got %s (%s)", status.StatusCode, status.XmlDocumentKey)
+	}
+
+	zipResp, err := operations.GetStatusZip(e.transport, e.creds.Credentials(), e.url, soap.ActionGetStatusZip,
+		&types.GetStatusZipRequest{TrackId: resp.ZipKey})
+	if err != nil {
+		t.Fatalf("GetStatusZip failed: %v", err)
+	}
+	payload, err := soap.DecodeStatusZip(zipResp)
+	if err != nil {
+		t.Fatalf("DecodeStatusZip failed: %v", err)
+	}
+	if ars := payload.ApplicationResponses(); !payload.Zipped || len(ars) != 1 || ars[0].DocumentReference.UUID != testCUFE || !ars[0].IsValidated() {
+		t.Errorf("Expected validated ApplicationResponse for the invoice, got %+v", ars)
+	}
+
+	requests := e.sim.Requests()
+	if len(requests) != 4 || requests[0].Operation != "SendTestSetAsync" {
+		t.Errorf("Unexpected request log %+v", requests)
+	}
+}
+
+func TestDecodePayloadLimits(t *testing.T) {
+	zipOf := func(files map[string][]byte) string {
+		var buf bytes.Buffer
+		w := zip.NewWriter(&buf)
+		for name, data := range files {
+			f, err := w.Create(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := f.Write(data); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return base64.StdEncoding.EncodeToString(buf.Bytes())
+	}
+
+	many := map[string][]byte{}
+	for i := 0; i < 21; i++ {
+		many[fmt.Sprintf("ar%02d.xml", i)] = []byte("<ApplicationResponse/>")
+	}
+	tests := map[string]string{
+		"TooManyEntries": zipOf(many),
+		"EntryTooLarge":  zipOf(map[string][]byte{"ar.xml": bytes.Repeat([]byte(" "), 20<<20+1)}),
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			var soapErr *soap.SOAPError
+			if _, err := soap.DecodePayload(content); !errors.As(err, &soapErr) || soapErr.Code != soap.ErrResponseParsing {
+				t.Errorf("Expected ErrResponseParsing, got %v", err)
+			}
+		})
+	}
+}
+
+func TestScriptedOutcomes(t *testing.T) {
+	e := newEnv(t)
+
+	t.Run("Rejection", func(t *testing.T) {
+		e.sim.Enqueue(soap.ActionSendBillSync, simulator.Outcome{
+			Rejections: []string{"Regla: FAK24, Rechazo: No fue informado el documento del adquiriente"},
+		})
+		resp, err := e.sendBillSync(t, "fv1", ublDocument("Invoice", "SETP990000010", "cufe-rechazo", ""))
+		if err != nil {
+			t.Fatalf("SendBillSync failed: %v", err)
+		}
+		if resp.IsValid || resp.StatusCode != simulator.StatusRejected {
+			t.Errorf("Expected scripted rejection, got %s", resp.StatusCode)
+		}
+		if rejections := resp.Rejections(); len(rejections) != 1 || rejections[0].Code != "FAK24" || rejections[0].Rule.Fix == "" {
+			t.Errorf("Expected catalogued FAK24 rejection, got %+v", rejections)
+		}
+	})
+
+	t.Run("Fault", func(t *testing.T) {
+		e.sim.Enqueue(simulator.AnyAction, simulator.Outcome{Fault: simulator.ServerFault("Servicio no disponible")})
+		_, err := e.sendBillSync(t, "fv2", ublDocument("Invoice", "SETP990000011", "cufe-fault", ""))
+
+		var fault *response.Fault
+		if !errors.As(err, &fault) {
+			t.Fatalf("Expected *response.Fault, got %v", err)
+		}
+		if fault.Code != "Receiver" || fault.Reason != "Servicio no disponible" {
+			t.Errorf("Unexpected fault %+v", fault)
+		}
+	})
+
+	t.Run("HTTPStatus", func(t *testing.T) {
+		e.sim.Enqueue(soap.ActionSendBillSync, simulator.Outcome{HTTPStatus: 503})
+		_, err := e.sendBillSync(t, "fv3", ublDocument("Invoice", "SETP990000012", "cufe-503", ""))
+		if err == nil || !strings.Contains(err.Error(), "503") {
+			t.Errorf("Expected HTTP 503 error, got %v", err)
+		}
+	})
+
+	t.Run("Delay", func(t *testing.T) {
+		e.sim.Enqueue(soap.ActionGetStatus, simulator.Outcome{Delay: 500 * time.Millisecond})
+		transport := soap.NewTransport(e.url, nil, 100*time.Millisecond)
+		_, err := operations.GetStatus(transport, e.creds.Credentials(), e.url, soap.ActionGetStatus,
+			&types.GetStatusRequest{TrackId: "x"})
+		if err == nil {
+			t.Error("Expected timeout error")
+		}
+	})
+}
+
+func TestSecurityVerification(t *testing.T) {
+	t.Run("Expired", func(t *testing.T) {
+		e := newEnv(t)
+		e.sim.SetClock(func() time.Time { return time.Now().Add(30 * 24 * time.Hour) })
+
+		_, err := e.sendBillSync(t, "fv1", ublDocument("Invoice", "SETP990000001", testCUFE, ""))
+		var fault *response.Fault
+		if !errors.As(err, &fault) || fault.Subcode != "MessageExpired" {
+			t.Errorf("Expected MessageExpired fault, got %v", err)
+		}
+	})
+
+	t.Run("Untrusted", func(t *testing.T) {
+		e := newEnv(t)
+		other, err := simulator.NewTestCredentials(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.sim.TrustCertificate(other.Certificate)
+
+		_, err = e.sendBillSync(t, "fv1", ublDocument("Invoice", "SETP990000001", testCUFE, ""))
+		soapErr := soap.NewFaultError("SendBillSync", asFault(t, err))
+		if !soapErr.IsAuthenticationFault() {
+			t.Errorf("Expected authentication fault, got %v", soapErr)
+		}
+	})
+
+	t.Run("WrongDestination", func(t *testing.T) {
+		e := newEnv(t)
+		// wsa:To apunta a DIAN pero el request llega al simulador
+		_, err := operations.GetStatus(fixedTransport{e.transport}, e.creds.Credentials(), "https://vpfe-hab.dian.gov.co/WcfDianCustomerServices.svc",
+			soap.ActionGetStatus, &types.GetStatusRequest{TrackId: "x"})
+		if fault := asFault(t, err); fault.Subcode != "DestinationUnreachable" {
+			t.Errorf("Expected DestinationUnreachable, got %+v", fault)
+		}
+	})
+}
+
+// fixedTransport envía siempre a la URL del transport, ignorando la de la operación
+type fixedTransport struct {
+	transport *soap.Transport
+}
+
+func (f fixedTransport) SendTo(_, soapXML string) ([]byte, error) {
+	return f.transport.Send(soapXML)
+}
+
+func asFault(t *testing.T, err error) *response.Fault {
+	t.Helper()
+	var fault *response.Fault
+	if !errors.As(err, &fault) {
+		t.Fatalf("Expected *response.Fault, got %v", err)
+	}
+	return fault
+}
+
+func TestQueries(t *testing.T) {
+	e := newEnv(t)
+	e.sim.AddNumberingRange("900123456", "sw-1", types.NumberingRange{
+		Prefix: "SETP", From: 990000000, To: 995000000,
+		Resolution: "18760000001", ResolutionDate: "2019-01-19",
+		DateFrom: "2019-01-19", DateTo: "2030-01-19", TechnicalKey: "fc8eac422eba16e22ffd8c6f94b3f40a6e38162c",
+	})
+	e.sim.AddAcquirer("800111222", "CLIENTE SAS", "facturas@cliente.com.co")
+	e.sim.AddExchangeEmails("800111222", "recepcion@cliente.com.co")
+	e.sim.AddExchangeEmails("901000001", "fe@otro.com.co", "recepcion@cliente.com.co")
+
+	if _, err := e.sendBillSync(t, "fv1", ublDocument("Invoice", "SETP990000001", testCUFE, "")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.sendBillSync(t, "nc1", ublDocument("CreditNote", "NC1", testCUDE, testCUFE)); err != nil {
+		t.Fatal(err)
+	}
+	event, err := operations.SendEventUpdateStatus(e.transport, e.creds.Credentials(), e.url, soap.ActionSendEventUpdateStatus,
+		&types.SendEventRequest{FileName: "ar1.zip", ContentFile: zipBase64(t, "ar1.xml", eventDocument("1", "cude-evento-030", "030", testCUFE))})
+	if err != nil {
+		t.Fatalf("SendEventUpdateStatus failed: %v", err)
+	}
+	if !event.IsValid {
+		t.Errorf("Expected valid event, got %s", event.StatusCode)
+	}
+
+	t.Run("GetNumberingRange", func(t *testing.T) {
+		resp, err := operations.GetNumberingRange(e.transport, e.creds.Credentials(), e.url, soap.ActionGetNumberingRange,
+			&types.GetNumberingRangeRequest{NIT: "900123456", SoftwareID: "sw-1"})
+		if err != nil {
+			t.Fatalf("GetNumberingRange failed: %v", err)
+		}
+		if len(resp.Ranges) != 1 || resp.Ranges[0].Prefix != "SETP" || resp.Ranges[0].To != 995000000 {
+			t.Errorf("Unexpected ranges %+v", resp.Ranges)
+		}
+	})
+
+	t.Run("GetAcquirer", func(t *testing.T) {
+		resp, err := operations.GetAcquirer(e.transport, e.creds.Credentials(), e.url, soap.ActionGetAcquirer,
+			&types.GetAcquirerRequest{NIT: "900123456", IdentificationNumber: "800111222"})
+		if err != nil {
+			t.Fatalf("GetAcquirer failed: %v", err)
+		}
+		if resp.Name != "CLIENTE SAS" || resp.Email != "facturas@cliente.com.co" {
+			t.Errorf("Unexpected acquirer %+v", resp)
+		}
+	})
+
+	t.Run("GetExchangeEmails", func(t *testing.T) {
+		resp, err := operations.GetExchangeEmails(e.transport, e.creds.Credentials(), e.url, soap.ActionGetExchangeEmails,
+			&types.GetExchangeEmailsRequest{NIT: "900123456"})
+		if err != nil {
+			t.Fatalf("GetExchangeEmails failed: %v", err)
+		}
+		if len(resp.Addresses) != 3 || resp.Addresses[0] != (types.ExchangeEmail{NIT: "800111222", Email: "recepcion@cliente.com.co"}) {
+			t.Errorf("Unexpected addresses %+v", resp.Addresses)
+		}
+		if len(resp.Emails) != 2 || resp.Emails[1] != "fe@otro.com.co" {
+			t.Errorf("Unexpected emails %v", resp.Emails)
+		}
+	})
+
+	t.Run("GetReferenceNotes", func(t *testing.T) {
+		resp, err := operations.GetReferenceNotes(e.transport, e.creds.Credentials(), e.url, soap.ActionGetReferenceNotes,
+			&types.GetReferenceNotesRequest{DocumentKey: testCUFE})
+		if err != nil {
+			t.Fatalf("GetReferenceNotes failed: %v", err)
+		}
+		if len(resp.Notes) != 1 || resp.Notes[0].DocumentKey != testCUDE || resp.Notes[0].Type != "CreditNote" {
+			t.Errorf("Unexpected notes %+v", resp.Notes)
+		}
+	})
+
+	t.Run("GetDocumentInfo", func(t *testing.T) {
+		resp, err := operations.GetDocumentInfo(e.transport, e.creds.Credentials(), e.url, soap.ActionGetDocumentInfo,
+			&types.GetDocumentInfoRequest{DocumentKey: testCUFE})
+		if err != nil {
+			t.Fatalf("GetDocumentInfo failed: %v", err)
+		}
+		if resp.DocumentNumber != "SETP990000001" || resp.SenderNIT != "900123456" || resp.Status != "Aprobado" {
+			t.Errorf("Unexpected document info %+v", resp)
+		}
+		if len(resp.Events) != 1 || resp.Events[0].Code != "030" {
+			t.Errorf("Expected acuse de recibo event, got %+v", resp.Events)
+		}
+	})
+
+	t.Run("GetXmlByDocumentKey", func(t *testing.T) {
+		resp, err := operations.GetXmlByDocumentKey(e.transport, e.creds.Credentials(), e.url, soap.ActionGetXmlByDocumentKey,
+			&types.GetXmlByDocumentKeyRequest{TrackId: testCUFE})
+		if err != nil {
+			t.Fatalf("GetXmlByDocumentKey failed: %v", err)
+		}
+		payload, err := soap.DecodeXmlByDocumentKey(resp)
+		if err != nil {
+			t.Fatalf("DecodeXmlByDocumentKey failed: %v", err)
+		}
+		invoices := payload.ByKind(soap.KindInvoice)
+		if len(invoices) != 1 || invoices[0].Invoice.ID.Value != "SETP990000001" {
+			t.Fatalf("Expected typed invoice, got %+v", payload.Documents)
+		}
+		if party := invoices[0].Invoice.AccountingCustomerParty; !strings.Contains(string(invoices[0].Raw), "CLIENTE SAS") || len(party.Party.PartyTaxScheme) != 1 || party.Party.PartyTaxScheme[0].CompanyID.Value != "800111222" {
+			t.Errorf("Expected customer party and raw bytes, got %+v", party)
+		}
+	})
+}
+
+func TestResponseVerification(t *testing.T) {
+	// newSignedEnv simulador que firma respuestas; tamper modifica la respuesta en tránsito
+	newSignedEnv := func(t *testing.T, anchor *x509.Certificate, tamper func([]byte) []byte) *env {
+		t.Helper()
+		e := newEnv(t)
+		e.sim.SignResponses(e.creds)
+		if anchor == nil {
+			anchor = e.creds.Certificate
+		}
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := httptest.NewRecorder()
+			e.sim.ServeHTTP(rec, r)
+			body := rec.Body.Bytes()
+			if tamper != nil {
+				body = tamper(body)
+			}
+			w.WriteHeader(rec.Code)
+			w.Write(body)
+		}))
+		t.Cleanup(srv.Close)
+
+		roots := x509.NewCertPool()
+		roots.AddCert(anchor)
+		e.url = srv.URL
+		e.transport = soap.NewTransport(srv.URL, nil, 5*time.Second)
+		e.transport.VerifyResponses(security.VerifyOptions{Roots: roots})
+		return e
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		e := newSignedEnv(t, nil, nil)
+		dumps := t.TempDir()
+		e.transport.Use(soap.Dump(soap.DumpConfig{Sink: soap.DirSink(dumps)}))
+
+		resp, err := e.sendBillSync(t, "fv1", ublDocument("Invoice", "SETP990000001", testCUFE, ""))
+		if err != nil {
+			t.Fatalf("Expected verified response, got %v", err)
+		}
+		if !resp.IsValid {
+			t.Errorf("Expected IsValid, got %+v", resp)
+		}
+		if v := resp.Verification; v == nil || !v.Valid || len(v.References) != 2 {
+			t.Errorf("Expected verification report on the response, got %+v", v)
+		}
+
+		reports, _ := filepath.Glob(filepath.Join(dumps, "*_SendBillSync_verification.json"))
+		if len(reports) == 0 {
+			t.Fatal("Expected verification report next to the raw response")
+		}
+		data, _ := os.ReadFile(reports[0])
+		if !strings.Contains(string(data), `"valid": true`) {
+			t.Errorf("Expected valid report, got %s", data)
+		}
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		e := newSignedEnv(t, nil, func(body []byte) []byte {
+			return bytes.Replace(body, []byte("<b:IsValid>true"), []byte("<b:IsValid>false"), 1)
+		})
+		_, err := e.sendBillSync(t, "fv1", ublDocument("Invoice", "SETP990000001", testCUFE, ""))
+
+		var verr *security.VerificationError
+		if !errors.As(err, &verr) || verr.Check != security.CheckDigest {
+			t.Fatalf("Expected digest verification error, got %v", err)
+		}
+		if soapErr := soap.GetSOAPError(err); soapErr == nil || !soapErr.IsVerificationFailure() {
+			t.Errorf("Expected verification SOAPError, got %v", err)
+		}
+	})
+
+	// Ataques con firma válida: el SignedInfo se vuelve a firmar con la clave
+	// de confianza, así que solo la cobertura de la firma los detecta
+	attacks := map[string]struct {
+		tamper func(body []byte) []byte
+		check  string
+	}{
+		"OnlyTimestampSigned": {
+			tamper: func(body []byte) []byte {
+				start := bytes.Index(body, []byte(`<Reference URI="#_1">`))
+				end := start + bytes.Index(body[start:], []byte("</Reference>")) + len("</Reference>")
+				return append(append([]byte{}, body[:start]...), body[end:]...)
+			},
+			check: security.CheckDigest,
+		},
+		"WrappedBody": {
+			tamper: func(body []byte) []byte {
+				start := bytes.Index(body, []byte(`<s:Body`))
+				signed := body[start : len(body)-len("</s:Envelope>")]
+				forged := bytes.Replace(bytes.Replace(signed, []byte("<b:IsValid>false"), []byte("<b:IsValid>true"), 1), []byte(` u:Id="_1"`), nil, 1)
+				wrapped := bytes.Replace(body[:start], []byte("</s:Header>"), append(append([]byte(`<w:Wrapper xmlns:w="urn:attacker">`), signed...), "</w:Wrapper></s:Header>"...), 1)
+				return append(append(wrapped, forged...), "</s:Envelope>"...)
+			},
+			check: security.CheckDigest,
+		},
+		"DuplicateID": {
+			tamper: func(body []byte) []byte {
+				start := bytes.Index(body, []byte(`<s:Body`))
+				signed := body[start : len(body)-len("</s:Envelope>")]
+				forged := bytes.Replace(signed, []byte("<b:IsValid>false"), []byte("<b:IsValid>true"), 1)
+				wrapped := bytes.Replace(body[:start], []byte("</s:Header>"), append(append([]byte(`<w:Wrapper xmlns:w="urn:attacker">`), signed...), "</w:Wrapper></s:Header>"...), 1)
+				return append(append(wrapped, forged...), "</s:Envelope>"...)
+			},
+			check: security.CheckHeader,
+		},
+	}
+	for name, attack := range attacks {
+		t.Run(name, func(t *testing.T) {
+			var e *env
+			e = newSignedEnv(t, nil, func(body []byte) []byte {
+				return resign(t, e.creds, attack.tamper(body))
+			})
+			e.sim.Enqueue(soap.ActionSendBillSync, simulator.Outcome{Rejections: []string{"Regla: FAD06, Rechazo: valor del CUFE no está calculado correctamente."}})
+			_, err := e.sendBillSync(t, "fv1", ublDocument("Invoice", "SETP990000001", testCUFE, ""))
+
+			var verr *security.VerificationError
+			if !errors.As(err, &verr) || verr.Check != attack.check {
+				t.Fatalf("Expected %s verification error, got %v", attack.check, err)
+			}
+		})
+	}
+
+	// SignedInfo legítimo como señuelo antes de wsse:Security y, dentro de la
+	// firma, un SignedInfo con el digest del Body alterado (sin volver a firmar)
+	t.Run("SignedInfoDecoy", func(t *testing.T) {
+		e := newSignedEnv(t, nil, func(body []byte) []byte {
+			start := bytes.Index(body, []byte("<SignedInfo>"))
+			end := bytes.Index(body, []byte("</SignedInfo>")) + len("</SignedInfo>")
+			decoy := append([]byte(`<w:Decoy xmlns:w="urn:attacker"><SignedInfo xmlns="http://www.w3.org/2000/09/xmldsig#">`), body[start+len("<SignedInfo>"):end]...)
+			decoy = append(decoy, "</w:Decoy>"...)
+			forged := bytes.Replace(body, []byte("<s:Header>"), append([]byte("<s:Header>"), decoy...), 1)
+			forged = bytes.Replace(forged, []byte("<b:IsValid>false"), []byte("<b:IsValid>true"), 1)
+
+			element, err := xmlpkg.ExtractElement(forged, xmlpkg.ByID("_1"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			canonical, err := xmlpkg.CanonicalizeExclusive(element, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sum := sha256.Sum256(canonical)
+			digestStart := bytes.LastIndex(forged, []byte("<DigestValue>")) + len("<DigestValue>")
+			digestEnd := bytes.LastIndex(forged, []byte("</DigestValue>"))
+			return append(append(append([]byte{}, forged[:digestStart]...), base64.StdEncoding.EncodeToString(sum[:])...), forged[digestEnd:]...)
+		})
+		e.sim.Enqueue(soap.ActionSendBillSync, simulator.Outcome{Rejections: []string{"Regla: FAD06, Rechazo: valor del CUFE no está calculado correctamente."}})
+		resp, err := e.sendBillSync(t, "fv1", ublDocument("Invoice", "SETP990000001", testCUFE, ""))
+
+		var verr *security.VerificationError
+		if !errors.As(err, &verr) || verr.Check != security.CheckHeader {
+			t.Fatalf("Expected header verification error, got %v (%+v)", err, resp)
+		}
+	})
+
+	t.Run("Untrusted", func(t *testing.T) {
+		other, err := simulator.NewTestCredentials(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		e := newSignedEnv(t, other.Certificate, nil)
+		_, err = e.sendBillSync(t, "fv1", ublDocument("Invoice", "SETP990000001", testCUFE, ""))
+
+		var verr *security.VerificationError
+		if !errors.As(err, &verr) || verr.Check != security.CheckCertificate {
+			t.Errorf("Expected certificate verification error, got %v", err)
+		}
+	})
+}
+
+// sender adapta operations.* a soap.BatchSender contra el simulador
+type sender struct{ e *env }
+
+func (s sender) SendBillAsync(req *types.SendBillAsyncRequest) (*types.SendBillAsyncResponse, error) {
+	return operations.SendBillAsync(s.e.transport, s.e.creds.Credentials(), s.e.url, soap.ActionSendBillAsync, req)
+}
+
+func (s sender) SendTestSetAsync(req *types.SendTestSetAsyncRequest) (*types.SendTestSetAsyncResponse, error) {
+	return operations.SendTestSetAsync(s.e.transport, s.e.creds.Credentials(), s.e.url, soap.ActionSendTestSetAsync, req)
+}
+
+func (s sender) GetStatusZip(req *types.GetStatusZipRequest) (*types.GetStatusZipResponse, error) {
+	return operations.GetStatusZip(s.e.transport, s.e.creds.Credentials(), s.e.url, soap.ActionGetStatusZip, req)
+}
+
+func TestBatch(t *testing.T) {
+	e := newEnv(t)
+	e.sim.Enqueue(soap.ActionSendBillAsync, simulator.Outcome{Pending: 1})
+
+	batch := soap.NewBatch("z0900123456000250000001.zip")
+	if err := batch.Add("fv0900123456000250000001.xml", []byte(ublDocument("Invoice", "SETP990000001", testCUFE, ""))); err != nil {
+		t.Fatal(err)
+	}
+	unsigned := strings.Replace(ublDocument("Invoice", "SETP990000002", testCUDE, ""), `<ds:Signature Id="xmldsig"/>`, "", 1)
+	if err := batch.Add("fv0900123456000250000002.xml", []byte(unsigned)); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.Add("fv0900123456000250000002.xml", []byte(unsigned)); err == nil {
+		t.Error("Expected duplicate member error")
+	}
+
+	content, err := batch.Package()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := naming.ZipFiles(
+		naming.File{Name: "fv0900123456000250000001.xml", Data: []byte(ublDocument("Invoice", "SETP990000001", testCUFE, ""))},
+		naming.File{Name: "fv0900123456000250000002.xml", Data: []byte(unsigned)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != base64.StdEncoding.EncodeToString(want) {
+		t.Error("Expected the deterministic naming.ZipFiles package")
+	}
+
+	submission, err := batch.Submit(sender{e})
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+
+	result, err := submission.Poll(sender{e})
+	if err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+	if !result.Pending {
+		t.Fatalf("Expected pending batch, got %s", result.StatusCode)
+	}
+
+	result, err = submission.Poll(sender{e})
+	if err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+	if result.Pending || len(result.Documents) != 2 {
+		t.Fatalf("Expected 2 document results, got %+v", result)
+	}
+
+	valid, ok := result.ByUUID(testCUFE)
+	if !ok || !valid.Found || !valid.IsValid() {
+		t.Errorf("Expected validated invoice, got %+v", valid)
+	}
+	rejected, ok := result.ByNumber("SETP990000002")
+	if !ok || !rejected.Found || rejected.IsValid() {
+		t.Errorf("Expected rejected invoice, got %+v", rejected)
+	}
+}
+
+func TestConcurrentRequests(t *testing.T) {
+	e := newEnv(t)
+	creds := e.creds.Credentials()
+	e.transport.Use(soap.MaxInFlight(4))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := operations.GetStatus(e.transport, creds, e.url, soap.ActionGetStatus, &types.GetStatusRequest{TrackId: testCUFE})
+			if err != nil || resp.StatusCode != soap.StatusNotFound {
+				t.Errorf("Unexpected result: %+v, %v", resp, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestClientEndpoints(t *testing.T) {
+	e := newEnv(t)
+	status := simulator.New()
+	statusSrv := httptest.NewServer(status)
+	t.Cleanup(statusSrv.Close)
+
+	client, err := soap.NewClient(&types.Config{
+		Certificate:   e.creds.CertPath,
+		PrivateKey:    e.creds.KeyPath,
+		Endpoint:      e.url,
+		OperationURLs: map[string]string{"GetStatusZip": statusSrv.URL},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.ProfileExecutionID() != "" {
+		t.Errorf("Expected unknown ProfileExecutionID with Endpoint, got %q", client.ProfileExecutionID())
+	}
+
+	// wsa:To sigue la URL de cada operación: el simulador rechaza otro destino
+	if _, err := client.GetStatus(&types.GetStatusRequest{TrackId: testCUFE}); err != nil {
+		t.Fatalf("GetStatus failed: %v", err)
+	}
+	if _, err := client.GetStatusZip(&types.GetStatusZipRequest{TrackId: testCUFE}); err != nil {
+		t.Fatalf("GetStatusZip failed: %v", err)
+	}
+	if got := e.sim.Requests(); len(got) != 1 || got[0].Operation != "GetStatus" {
+		t.Errorf("Expected only GetStatus on the endpoint, got %+v", got)
+	}
+	if got := status.Requests(); len(got) != 1 || got[0].Operation != "GetStatusZip" {
+		t.Errorf("Expected only GetStatusZip on the override, got %+v", got)
+	}
+
+	// Ambientes registrados como datos
+	if err := soap.RegisterEnvironment(soap.EnvironmentInfo{Environment: "local", URL: e.url, ProfileExecutionID: "2"}); err != nil {
+		t.Fatal(err)
+	}
+	if soap.GetURL("local") != e.url {
+		t.Errorf("Expected registered URL, got %s", soap.GetURL("local"))
+	}
+	client, err = soap.NewClient(&types.Config{Certificate: e.creds.CertPath, PrivateKey: e.creds.KeyPath, Environment: "local"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetStatus(&types.GetStatusRequest{TrackId: testCUFE}); err != nil {
+		t.Errorf("GetStatus on registered environment failed: %v", err)
+	}
+	if client.ProfileExecutionID() != "2" {
+		t.Errorf("Expected ProfileExecutionID of the environment, got %q", client.ProfileExecutionID())
+	}
+	client, err = soap.NewClient(&types.Config{Certificate: e.creds.CertPath, PrivateKey: e.creds.KeyPath, Environment: types.Produccion, Endpoint: e.url})
+	if err != nil || client.ProfileExecutionID() != "1" {
+		t.Errorf("Expected ProfileExecutionID 1 for production behind an Endpoint, got %v", err)
+	}
+
+	for name, config := range map[string]*types.Config{
+		"UnknownEnvironment": {Environment: "staging"},
+		"InvalidEndpoint":    {Endpoint: "vpfe.dian.gov.co"},
+		"InvalidOperation":   {OperationURLs: map[string]string{"GetStatus": "ftp://dian"}},
+		"UnknownOperation":   {OperationURLs: map[string]string{"GetStatuz": "https://dian"}},
+		"UnknownAction":      {OperationURLs: map[string]string{"http://example.com/GetStatus": "https://dian"}},
+	} {
+		config.Certificate, config.PrivateKey = e.creds.CertPath, e.creds.KeyPath
+		var soapErr *soap.SOAPError
+		if _, err := soap.NewClient(config); !errors.As(err, &soapErr) || soapErr.Code != soap.ErrInvalidConfig {
+			t.Errorf("%s: expected ErrInvalidConfig, got %v", name, err)
+		}
+	}
+}
+
+// resign vuelve a firmar el SignedInfo de una respuesta alterada
+func resign(t *testing.T, creds *simulator.TestCredentials, body []byte) []byte {
+	t.Helper()
+	signedInfo, err := xmlpkg.ExtractElement(body, xmlpkg.ByName("http://www.w3.org/2000/09/xmldsig#", "SignedInfo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	canonical, err := xmlpkg.CanonicalizeExclusive(signedInfo, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := sha256.Sum256(canonical)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, creds.PrivateKey, crypto.SHA256, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := bytes.Index(body, []byte("<SignatureValue>")) + len("<SignatureValue>")
+	end := bytes.Index(body, []byte("</SignatureValue>"))
+	return append(append(append([]byte{}, body[:start]...), base64.StdEncoding.EncodeToString(signature)...), body[end:]...)
+}
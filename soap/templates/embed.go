@@ -0,0 +1,31 @@
+package templates
+
+import (
+	"embed"
+	"sync"
+	"text/template"
+)
+
+// FS contiene los templates SOAP embebidos (envelope, security y operations)
+//
+//go:embed envelope.tmpl security/*.tmpl operations/*.tmpl
+var FS embed.FS
+
+// parsed templates ya parseados por path (FS es inmutable)
+var parsed sync.Map
+
+// Parse retorna el template de path, parseándolo solo la primera vez
+//
+// *template.Template es seguro para Execute concurrente.
+func Parse(path string) (*template.Template, error) {
+	if tmpl, ok := parsed.Load(path); ok {
+		return tmpl.(*template.Template), nil
+	}
+
+	tmpl, err := template.ParseFS(FS, path)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := parsed.LoadOrStore(path, tmpl)
+	return actual.(*template.Template), nil
+}
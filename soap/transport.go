@@ -4,81 +4,188 @@ import (
 	"bytes"
 	"crypto/tls"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"sync"
 	"time"
+
+	"github.com/diegofxm/ubl21-dian/soap/response"
+	"github.com/diegofxm/ubl21-dian/soap/security"
 )
 
 // Transport maneja el transporte HTTP/HTTPS con mTLS
+//
+// Es seguro para uso concurrente: las conexiones keep-alive se reutilizan
+// entre goroutines según PoolConfig.
 type Transport struct {
-	httpClient *http.Client
-	url        string
-	debugDir   string
+	httpClient   *http.Client
+	pool         *http.Transport
+	url          string
+	mu           sync.RWMutex
+	verify       *security.VerifyOptions
+	interceptors []Interceptor
+}
+
+// PoolConfig conexiones HTTP keep-alive hacia DIAN
+type PoolConfig struct {
+	MaxConnsPerHost     int           // Conexiones simultáneas (0 = sin límite)
+	MaxIdleConnsPerHost int           // Conexiones en reposo para reutilizar
+	IdleConnTimeout     time.Duration // Tiempo máximo de una conexión en reposo
+}
+
+// DefaultPool pool por defecto: http.Transport solo conserva 2 conexiones en
+// reposo por host, lo que obliga a repetir el handshake mTLS con carga concurrente
+var DefaultPool = PoolConfig{
+	MaxIdleConnsPerHost: 32,
+	IdleConnTimeout:     90 * time.Second,
 }
 
-// NewTransport crea un nuevo transport SOAP
+// NewTransport crea un nuevo transport SOAP con DefaultPool
+//
+// No escribe nada a disco; use Use(Dump(...)) para volcar requests y responses.
 func NewTransport(url string, tlsConfig *tls.Config, timeout time.Duration) *Transport {
-	return &Transport{
-		httpClient: &http.Client{
-			Timeout: timeout,
-			Transport: &http.Transport{
-				TLSClientConfig: tlsConfig,
-			},
-		},
-		url:      url,
-		debugDir: "storage/logs/debug/soap",
+	pool := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		TLSClientConfig:     tlsConfig,
+		TLSHandshakeTimeout: 10 * time.Second,
+		ForceAttemptHTTP2:   true,
+	}
+	t := &Transport{
+		httpClient: &http.Client{Timeout: timeout, Transport: pool},
+		pool:       pool,
+		url:        url,
 	}
+	t.SetPool(DefaultPool)
+	return t
+}
+
+// SetPool ajusta el pool de conexiones; debe llamarse antes del primer Send
+func (t *Transport) SetPool(config PoolConfig) {
+	t.pool.MaxConnsPerHost = config.MaxConnsPerHost
+	t.pool.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+	t.pool.MaxIdleConns = config.MaxIdleConnsPerHost
+	t.pool.IdleConnTimeout = config.IdleConnTimeout
+}
+
+// VerifyResponses activa la verificación de la firma WS-Security de las respuestas
+//
+// Una respuesta que no verifica hace fallar la operación con
+// ErrResponseVerification. El reporte de cada verificación queda en
+// Exchange.Verification (Dump lo guarda junto a la respuesta) y en el campo
+// Verification de la respuesta tipada de cada operación.
+func (t *Transport) VerifyResponses(opts security.VerifyOptions) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.verify = &opts
+}
+
+// Use agrega interceptores a la cadena; el primero registrado es el más externo
+func (t *Transport) Use(interceptors ...Interceptor) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.interceptors = append(t.interceptors, interceptors...)
 }
 
 // Send envía un request SOAP y retorna la respuesta
 func (t *Transport) Send(soapXML string) ([]byte, error) {
-	// Guardar request para debugging
-	timestamp := time.Now().Format("20060102_150405")
-	os.MkdirAll(t.debugDir, 0755)
-	
-	debugRequestPath := fmt.Sprintf("%s/soap_request_%s.xml", t.debugDir, timestamp)
-	if err := os.WriteFile(debugRequestPath, []byte(soapXML), 0644); err != nil {
-		fmt.Printf("Warning: Failed to save debug request: %v\n", err)
+	return t.SendTo(t.url, soapXML)
+}
+
+// SendTo envía un request SOAP a url y retorna la respuesta
+// Con url vacío se usa la URL de NewTransport.
+func (t *Transport) SendTo(url, soapXML string) ([]byte, error) {
+	respXML, _, err := t.SendVerified(url, soapXML)
+	return respXML, err
+}
+
+// SendVerified igual que SendTo, pero retorna también el reporte de la
+// verificación de la respuesta (nil si VerifyResponses no está activo)
+func (t *Transport) SendVerified(url, soapXML string) ([]byte, *security.VerificationReport, error) {
+	if url == "" {
+		url = t.url
+	}
+	ex := newExchange(url, soapXML)
+
+	t.mu.RLock()
+	send := RoundTrip(t.roundTrip)
+	for i := len(t.interceptors) - 1; i >= 0; i-- {
+		send = t.interceptors[i](send)
 	}
+	t.mu.RUnlock()
+
+	if err := send(ex); err != nil {
+		return nil, nil, err
+	}
+	return ex.Response, ex.Verification, nil
+}
 
+// roundTrip envía el request por HTTP y valida la respuesta
+func (t *Transport) roundTrip(ex *Exchange) error {
 	// Crear request HTTP
-	req, err := http.NewRequest("POST", t.url, bytes.NewBufferString(soapXML))
+	req, err := http.NewRequest("POST", ex.URL, bytes.NewReader(ex.Request))
 	if err != nil {
-		return nil, NewSOAPError("Transport", ErrHTTPTransport, "failed to create HTTP request", err)
+		return NewSOAPError("Transport", ErrHTTPTransport, "failed to create HTTP request", err)
 	}
 
 	req.Header.Set("Content-Type", "application/soap+xml; charset=utf-8")
 	req.Header.Set("Accept", "application/xml")
-	req.Header.Set("Content-Length", fmt.Sprintf("%d", len(soapXML)))
+	req.Header.Set("Content-Length", fmt.Sprintf("%d", len(ex.Request)))
 
 	// Enviar request
 	resp, err := t.httpClient.Do(req)
+	ex.Duration = time.Since(ex.Started)
 	if err != nil {
-		return nil, NewSOAPError("Transport", ErrHTTPTransport, "failed to send HTTP request", err)
+		return NewSOAPError("Transport", ErrHTTPTransport, "failed to send HTTP request", err)
 	}
 	defer resp.Body.Close()
 
 	// Leer respuesta
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, NewSOAPError("Transport", ErrHTTPTransport, "failed to read response body", err)
+		return NewSOAPError("Transport", ErrHTTPTransport, "failed to read response body", err)
 	}
+	ex.StatusCode = resp.StatusCode
+	ex.Response = body
 
 	// Verificar status code
 	if resp.StatusCode != http.StatusOK {
-		return nil, NewSOAPError("Transport", ErrHTTPTransport, 
+		// DIAN (WCF) retorna los SOAP Faults con HTTP 500
+		var fault *response.Fault
+		if _, err := response.Parse(body); errors.As(err, &fault) {
+			return fault
+		}
+		return NewSOAPError("Transport", ErrHTTPTransport,
 			fmt.Sprintf("HTTP error %d: %s", resp.StatusCode, string(body)), nil)
 	}
 
-	// Guardar response para debugging
-	debugResponsePath := fmt.Sprintf("%s/soap_response_%s.xml", t.debugDir, timestamp)
-	if err := os.WriteFile(debugResponsePath, body, 0644); err != nil {
-		fmt.Printf("Warning: Failed to save debug response: %v\n", err)
+	// Verificar firma de la respuesta (opcional)
+	t.mu.RLock()
+	verify := t.verify
+	t.mu.RUnlock()
+	if verify != nil {
+		report, err := security.VerifyResponse(body, *verify)
+		ex.Verification = report
+		if err != nil {
+			return NewSOAPError("Transport", ErrResponseVerification, "response signature verification failed", err)
+		}
 	}
 
-	return body, nil
+	return nil
+}
+
+// ClientTLSConfig configuración mTLS con credenciales ya cargadas
+func ClientTLSConfig(creds *security.Credentials) *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{{
+			Certificate: [][]byte{creds.Certificate.Raw},
+			PrivateKey:  creds.PrivateKey,
+			Leaf:        creds.Certificate,
+		}},
+		MinVersion: tls.VersionTLS12,
+	}
 }
 
 // LoadClientTLSConfig carga el certificado y clave privada para mTLS
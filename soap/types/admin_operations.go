@@ -1,5 +1,7 @@
 package types
 
+import "github.com/diegofxm/ubl21-dian/soap/security"
+
 // GetNumberingRangeRequest request para obtener rangos de numeración
 type GetNumberingRangeRequest struct {
 	NIT        string // NIT del emisor (AccountCode)
@@ -11,16 +13,19 @@ type GetNumberingRangeResponse struct {
 	Ranges        []NumberingRange
 	StatusCode    string
 	StatusMessage string
+	Verification  *security.VerificationReport // Firma WS-Security de la respuesta (nil si no se verifica)
 }
 
 // NumberingRange rango de numeración autorizado
 type NumberingRange struct {
-	Prefix     string
-	From       int64
-	To         int64
-	DateFrom   string
-	DateTo     string
-	Resolution string
+	Prefix         string
+	From           int64
+	To             int64
+	DateFrom       string
+	DateTo         string
+	Resolution     string
+	ResolutionDate string
+	TechnicalKey   string // Clave técnica para el cálculo del CUFE
 }
 
 // GetAcquirerRequest request para obtener información del adquiriente
@@ -37,6 +42,7 @@ type GetAcquirerResponse struct {
 	Address              string
 	StatusCode           string
 	StatusMessage        string
+	Verification         *security.VerificationReport // Firma WS-Security de la respuesta (nil si no se verifica)
 }
 
 // GetExchangeEmailsRequest request para obtener correos de intercambio
@@ -46,7 +52,15 @@ type GetExchangeEmailsRequest struct {
 
 // GetExchangeEmailsResponse respuesta de GetExchangeEmails
 type GetExchangeEmailsResponse struct {
-	Emails        []string
+	Emails        []string        // Correos sin repetir, en el orden del CSV
+	Addresses     []ExchangeEmail // Filas del CSV (NIT y correo)
 	StatusCode    string
 	StatusMessage string
+	Verification  *security.VerificationReport // Firma WS-Security de la respuesta (nil si no se verifica)
+}
+
+// ExchangeEmail correo de recepción registrado por un facturador
+type ExchangeEmail struct {
+	NIT   string
+	Email string
 }
@@ -1,6 +1,11 @@
 package types
 
-import "time"
+import (
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/soap/rules"
+	"github.com/diegofxm/ubl21-dian/soap/security"
+)
 
 // Config configuración del cliente SOAP
 type Config struct {
@@ -8,6 +13,18 @@ type Config struct {
 	Certificate string        // Ruta al certificado PEM
 	PrivateKey  string        // Ruta a la clave privada PEM (opcional si está en Certificate)
 	Timeout     time.Duration // Timeout para requests HTTP
+	TrustAnchor string        // Ruta al certificado raíz (PEM) para verificar la firma de las respuestas de DIAN (opcional)
+	DebugDir    string        // Directorio para volcar requests/responses redactados (opcional, desactivado por defecto)
+
+	// Concurrencia (0 = valor por defecto / sin límite)
+	MaxConnections int     // Conexiones HTTP simultáneas hacia DIAN (keep-alive)
+	RateLimit      float64 // Requests por segundo
+	RateBurst      int     // Ráfaga máxima sobre RateLimit (1 por defecto)
+	MaxInFlight    int     // Requests simultáneos; el resto espera turno
+
+	// Endpoints (opcionales; por defecto la URL registrada para Environment)
+	Endpoint      string            // URL de WcfDianCustomerServices para todas las operaciones
+	OperationURLs map[string]string // URL por operación ("GetStatus" o su SOAPAction); prevalece sobre Endpoint. Una clave desconocida hace fallar NewClient
 }
 
 // Environment representa el ambiente de DIAN
@@ -27,9 +44,10 @@ type Response struct {
 	StatusDescription string
 	StatusMessage     string
 	ErrorMessages     []ErrorMessage
-	XmlDocumentKey    string // TrackId para consultas GetStatus
-	XmlBase64Bytes    string // XML firmado en base64
-	ZipKey            string // TrackId para SendBillAsync (lotes)
+	XmlDocumentKey    string                       // TrackId para consultas GetStatus
+	XmlBase64Bytes    string                       // XML firmado en base64
+	ZipKey            string                       // TrackId para SendBillAsync (lotes)
+	Verification      *security.VerificationReport // Firma WS-Security de la respuesta (nil si no se verifica)
 }
 
 // ErrorMessage mensaje de error de DIAN
@@ -37,3 +55,39 @@ type ErrorMessage struct {
 	Code        string
 	Description string
 }
+
+// Violation clasifica el mensaje con el catálogo de reglas de DIAN
+func (e ErrorMessage) Violation() rules.Violation {
+	return rules.Parse(e.Code, e.Description)
+}
+
+// Violations reglas reportadas por DIAN, clasificadas con el catálogo
+func (r Response) Violations() []rules.Violation {
+	violations := make([]rules.Violation, 0, len(r.ErrorMessages))
+	for _, msg := range r.ErrorMessages {
+		violations = append(violations, msg.Violation())
+	}
+	return violations
+}
+
+// Rejections reglas que impidieron la validación del documento
+func (r Response) Rejections() []rules.Violation {
+	var rejections []rules.Violation
+	for _, v := range r.Violations() {
+		if v.IsFatal() {
+			rejections = append(rejections, v)
+		}
+	}
+	return rejections
+}
+
+// Notifications reglas informativas (el documento puede ser válido con ellas)
+func (r Response) Notifications() []rules.Violation {
+	var notifications []rules.Violation
+	for _, v := range r.Violations() {
+		if v.IsWarning() {
+			notifications = append(notifications, v)
+		}
+	}
+	return notifications
+}
@@ -1,5 +1,7 @@
 package types
 
+import "github.com/diegofxm/ubl21-dian/soap/security"
+
 // GetStatusRequest request para consultar estado
 type GetStatusRequest struct {
 	TrackId string // ID de seguimiento retornado por DIAN
@@ -21,6 +23,7 @@ type GetStatusZipResponse struct {
 	ContentFile   string // ZIP en base64
 	StatusCode    string
 	StatusMessage string
+	Verification  *security.VerificationReport // Firma WS-Security de la respuesta (nil si no se verifica)
 }
 
 // GetStatusEventRequest request para consultar estado de evento
@@ -43,6 +46,7 @@ type GetXmlByDocumentKeyResponse struct {
 	XmlBase64Bytes string
 	StatusCode     string
 	StatusMessage  string
+	Verification   *security.VerificationReport // Firma WS-Security de la respuesta (nil si no se verifica)
 }
 
 // GetReferenceNotesRequest request para obtener notas de referencia
@@ -55,13 +59,16 @@ type GetReferenceNotesResponse struct {
 	Notes         []ReferenceNote
 	StatusCode    string
 	StatusMessage string
+	Verification  *security.VerificationReport // Firma WS-Security de la respuesta (nil si no se verifica)
 }
 
 // ReferenceNote nota de referencia (crédito/débito)
 type ReferenceNote struct {
-	DocumentKey string
-	IssueDate   string
-	Type        string // CreditNote, DebitNote
+	DocumentKey    string
+	DocumentNumber string
+	TypeCode       string // "91" = Nota Crédito, "92" = Nota Débito
+	IssueDate      string
+	Type           string // CreditNote, DebitNote
 }
 
 // GetDocumentInfoRequest request para obtener información de documento
@@ -71,16 +78,22 @@ type GetDocumentInfoRequest struct {
 
 // GetDocumentInfoResponse respuesta de GetDocumentInfo
 type GetDocumentInfoResponse struct {
-	DocumentKey   string
-	IssueDate     string
-	Status        string
-	Events        []DocumentEvent
-	StatusCode    string
-	StatusMessage string
+	DocumentKey      string
+	DocumentNumber   string
+	DocumentTypeCode string // "01" = Factura, "91" = Nota Crédito, "92" = Nota Débito, etc.
+	IssueDate        string
+	SenderNIT        string
+	ReceiverNIT      string
+	Status           string
+	Events           []DocumentEvent
+	StatusCode       string
+	StatusMessage    string
+	Verification     *security.VerificationReport // Firma WS-Security de la respuesta (nil si no se verifica)
 }
 
 // DocumentEvent evento de documento
 type DocumentEvent struct {
+	Code        string // "030" = Acuse de recibo, "032" = Recibo del bien, "033" = Aceptación expresa, etc.
 	EventType   string
 	EventDate   string
 	Description string
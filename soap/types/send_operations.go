@@ -2,7 +2,7 @@ package types
 
 // SendBillSyncRequest request para envío síncrono de factura
 type SendBillSyncRequest struct {
-	FileName    string // Nombre del archivo ZIP (ej: "FES-990000001.zip")
+	FileName    string // Nombre del archivo ZIP (ej: "z0900123456000250000001.zip", ver paquete naming)
 	ContentFile string // Contenido del ZIP en base64
 }
 
@@ -0,0 +1,430 @@
+package testset
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/documents/creditnote"
+	"github.com/diegofxm/ubl21-dian/documents/debitnote"
+	"github.com/diegofxm/ubl21-dian/documents/invoice"
+	"github.com/diegofxm/ubl21-dian/qr"
+	"github.com/diegofxm/ubl21-dian/signature"
+)
+
+// environment ProfileExecutionID del set de pruebas (Habilitación)
+const environment = "2"
+
+// colombia zona horaria de las fechas de emisión
+var colombia = time.FixedZone("COT", -5*60*60)
+
+// totals totales calculados de las líneas de la plantilla
+type totals struct {
+	lineExtension float64
+	tax           float64
+	byPercent     map[float64][2]float64 // porcentaje -> {base, impuesto}
+	percents      []float64              // porcentajes en orden de aparición
+}
+
+// generate construye y firma todos los documentos del plan
+//
+// Las facturas usan Next, Next+1, ...; las notas continúan el mismo
+// consecutivo con su propio prefijo y referencian las facturas del set.
+func (r *Runner) generate() ([]DocumentState, error) {
+	plan := r.config.Plan
+	n := r.config.Numbering
+	now := r.config.Now().In(colombia)
+
+	var docs []DocumentState
+	next := n.Next
+
+	for i := 0; i < plan.Invoices; i++ {
+		doc, err := r.invoice(n.Prefix+strconv.FormatInt(next, 10), now)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+		next++
+	}
+
+	for i := 0; i < plan.CreditNotes; i++ {
+		ref := docs[i%plan.Invoices]
+		doc, err := r.creditNote(n.CreditNotePrefix+strconv.FormatInt(next, 10), ref, now)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+		next++
+	}
+
+	for i := 0; i < plan.DebitNotes; i++ {
+		ref := docs[i%plan.Invoices]
+		doc, err := r.debitNote(n.DebitNotePrefix+strconv.FormatInt(next, 10), ref, now)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+		next++
+	}
+
+	return docs, nil
+}
+
+// invoice genera una factura de venta con la plantilla
+func (r *Runner) invoice(number string, now time.Time) (DocumentState, error) {
+	cfg := r.config
+	t := r.totals()
+	issueDate, issueTime := now.Format("2006-01-02"), now.Format("15:04:05-07:00")
+
+	cufe := signature.CalculateCUFE(number, now, issueTime,
+		t.lineExtension, t.tax, 0, 0, t.lineExtension+t.tax,
+		supplierNIT(cfg.Template), customerNIT(cfg.Template), cfg.Numbering.TechnicalKey, environment)
+	qrCode := signature.GenerateQRCode(number, now, supplierNIT(cfg.Template), customerNIT(cfg.Template),
+		t.lineExtension, t.tax, t.lineExtension+t.tax, cufe, environment)
+
+	b := invoice.NewBuilder().
+		SetProfileExecutionID(environment).
+		SetInvoiceData(number, cufe, issueDate, issueTime, issueDate).
+		SetDianExtensions(r.extensions(number, qrCode)).
+		SetSupplier(cfg.Template.Supplier).
+		SetCustomer(cfg.Template.Customer).
+		SetPaymentMeans("1", cfg.Template.PaymentMeansCode, issueDate).
+		SetMonetaryTotals(amount(t.lineExtension), amount(t.lineExtension), amount(t.lineExtension+t.tax), "", amount(t.lineExtension+t.tax))
+	if cfg.Template.Note != "" {
+		b.SetNote(cfg.Template.Note)
+	}
+	if t.tax > 0 {
+		b.AddTaxTotal(t.taxTotal())
+	}
+
+	for i, line := range cfg.Template.Lines {
+		ext := round(line.Quantity * line.UnitPrice)
+		tl := invoice.InvoiceLineTemplateData{
+			ID:                    strconv.Itoa(i + 1),
+			UnitCode:              unitCode(line),
+			Quantity:              quantity(line.Quantity),
+			LineExtensionAmount:   amount(ext),
+			FreeOfChargeIndicator: "false",
+			CurrencyID:            "COP",
+			Item:                  invoice.ItemTemplateData{Description: line.Description, StandardItemID: invoice.ItemIDTemplateData{ID: line.Code, SchemeID: "999"}},
+			Price:                 invoice.PriceTemplateData{Amount: amount(line.UnitPrice), BaseQuantity: quantity(line.Quantity)},
+		}
+		if line.TaxPercent > 0 {
+			lt := lineTaxTotal(ext, line.TaxPercent)
+			tl.TaxTotal = &lt
+		}
+		b.AddInvoiceLine(tl)
+	}
+
+	xmlData, err := b.Build()
+	if err != nil {
+		return DocumentState{}, fmt.Errorf("failed to build invoice %s: %w", number, err)
+	}
+	return r.sign(KindInvoice, number, cufe, issueDate, "fv", xmlData, nil)
+}
+
+// creditNote genera una nota crédito que anula la factura ref
+func (r *Runner) creditNote(number string, ref DocumentState, now time.Time) (DocumentState, error) {
+	cfg := r.config
+	t := r.totals()
+	issueDate, issueTime := now.Format("2006-01-02"), now.Format("15:04:05-07:00")
+
+	cude := signature.CalculateCUDE(number, now, issueTime,
+		t.lineExtension, t.tax, 0, 0, t.lineExtension+t.tax,
+		supplierNIT(cfg.Template), customerNIT(cfg.Template), cfg.Software.PIN, environment)
+	qrCode := qr.Payload{
+		Document: qr.CreditNote, Number: number, IssueDate: issueDate, IssueTime: issueTime,
+		SupplierID: supplierNIT(cfg.Template), CustomerID: customerNIT(cfg.Template),
+		Subtotal: t.lineExtension, IVA: t.tax, Total: t.lineExtension + t.tax, UUID: cude, Environment: environment,
+	}.String()
+
+	b := creditnote.NewBuilder().
+		SetProfileExecutionID(environment).
+		SetCreditNoteData(number, cude, issueDate, issueTime).
+		SetNote("Anulación de la factura "+ref.Number).
+		SetDianExtensions(r.extensions(number, qrCode)).
+		SetBillingReference(ref.Number, ref.UUID, ref.IssueDate).
+		SetSupplier(creditNoteParty(cfg.Template.Supplier)).
+		SetCustomer(creditNoteParty(cfg.Template.Customer)).
+		SetTotals(amount(t.lineExtension), amount(t.lineExtension), amount(t.lineExtension+t.tax), amount(t.lineExtension+t.tax))
+	if t.tax > 0 {
+		b.AddTaxTotal(creditNoteTaxTotal(t.taxTotal()))
+	}
+
+	for i, line := range cfg.Template.Lines {
+		ext := round(line.Quantity * line.UnitPrice)
+		tl := creditnote.CreditNoteLineTemplateData{
+			ID:                    strconv.Itoa(i + 1),
+			UnitCode:              unitCode(line),
+			Quantity:              quantity(line.Quantity),
+			LineExtensionAmount:   amount(ext),
+			FreeOfChargeIndicator: "false",
+			CurrencyID:            "COP",
+			Item:                  creditnote.ItemTemplateData{Description: line.Description, StandardItemID: creditnote.ItemIDTemplateData{ID: line.Code, SchemeID: "999"}},
+			Price:                 creditnote.PriceTemplateData{Amount: amount(line.UnitPrice), BaseQuantity: quantity(line.Quantity)},
+		}
+		if line.TaxPercent > 0 {
+			lt := creditNoteTaxTotal(lineTaxTotal(ext, line.TaxPercent))
+			tl.TaxTotal = &lt
+		}
+		b.AddLine(tl)
+	}
+
+	xmlData, err := b.Build()
+	if err != nil {
+		return DocumentState{}, fmt.Errorf("failed to build credit note %s: %w", number, err)
+	}
+	return r.sign(KindCreditNote, number, cude, issueDate, "nc", xmlData, &ref)
+}
+
+// debitNote genera una nota débito sobre la factura ref
+func (r *Runner) debitNote(number string, ref DocumentState, now time.Time) (DocumentState, error) {
+	cfg := r.config
+	t := r.totals()
+	issueDate, issueTime := now.Format("2006-01-02"), now.Format("15:04:05-07:00")
+
+	cude := signature.CalculateCUDE(number, now, issueTime,
+		t.lineExtension, t.tax, 0, 0, t.lineExtension+t.tax,
+		supplierNIT(cfg.Template), customerNIT(cfg.Template), cfg.Software.PIN, environment)
+	qrCode := qr.Payload{
+		Document: qr.DebitNote, Number: number, IssueDate: issueDate, IssueTime: issueTime,
+		SupplierID: supplierNIT(cfg.Template), CustomerID: customerNIT(cfg.Template),
+		Subtotal: t.lineExtension, IVA: t.tax, Total: t.lineExtension + t.tax, UUID: cude, Environment: environment,
+	}.String()
+
+	b := debitnote.NewBuilder().
+		SetProfileExecutionID(environment).
+		SetDebitNoteData(number, cude, issueDate, issueTime).
+		SetNote("Ajuste a la factura "+ref.Number).
+		SetDianExtensions(r.extensions(number, qrCode)).
+		SetBillingReference(ref.Number, ref.UUID, ref.IssueDate).
+		SetSupplier(debitNoteParty(cfg.Template.Supplier)).
+		SetCustomer(debitNoteParty(cfg.Template.Customer)).
+		SetTotals(amount(t.lineExtension), amount(t.lineExtension), amount(t.lineExtension+t.tax), amount(t.lineExtension+t.tax))
+	if t.tax > 0 {
+		b.AddTaxTotal(debitNoteTaxTotal(t.taxTotal()))
+	}
+
+	for i, line := range cfg.Template.Lines {
+		ext := round(line.Quantity * line.UnitPrice)
+		tl := debitnote.DebitNoteLineTemplateData{
+			ID:                    strconv.Itoa(i + 1),
+			UnitCode:              unitCode(line),
+			Quantity:              quantity(line.Quantity),
+			LineExtensionAmount:   amount(ext),
+			FreeOfChargeIndicator: "false",
+			CurrencyID:            "COP",
+			Item:                  debitnote.ItemTemplateData{Description: line.Description, StandardItemID: debitnote.ItemIDTemplateData{ID: line.Code, SchemeID: "999"}},
+			Price:                 debitnote.PriceTemplateData{Amount: amount(line.UnitPrice), BaseQuantity: quantity(line.Quantity)},
+		}
+		if line.TaxPercent > 0 {
+			lt := debitNoteTaxTotal(lineTaxTotal(ext, line.TaxPercent))
+			tl.TaxTotal = &lt
+		}
+		b.AddLine(tl)
+	}
+
+	xmlData, err := b.Build()
+	if err != nil {
+		return DocumentState{}, fmt.Errorf("failed to build debit note %s: %w", number, err)
+	}
+	return r.sign(KindDebitNote, number, cude, issueDate, "nd", xmlData, &ref)
+}
+
+// sign firma el documento y arma su estado inicial
+func (r *Runner) sign(kind Kind, number, uuid, issueDate, filePrefix string, xmlData []byte, ref *DocumentState) (DocumentState, error) {
+	signed, err := r.config.Signer.SignXML(xmlData)
+	if err != nil {
+		return DocumentState{}, fmt.Errorf("failed to sign %s: %w", number, err)
+	}
+
+	doc := DocumentState{
+		Kind:      kind,
+		Number:    number,
+		UUID:      uuid,
+		IssueDate: issueDate,
+		FileName:  filePrefix + strings.ToLower(number) + ".xml",
+		XML:       signed,
+		Status:    StatusGenerated,
+	}
+	if ref != nil {
+		doc.Reference = ref.Number
+	}
+	return doc, nil
+}
+
+// extensions argumentos de SetDianExtensions (iguales en los tres builders)
+func (r *Runner) extensions(number, qr string) (auth, startDate, endDate, prefix, from, to, providerID, providerSchemeID, providerSchemeName, softwareID, securityCode, qrCode string) {
+	n := r.config.Numbering
+	s := r.config.Software
+	return n.Resolution,
+		n.StartDate.Format("2006-01-02"),
+		n.EndDate.Format("2006-01-02"),
+		n.Prefix,
+		strconv.FormatInt(n.From, 10),
+		strconv.FormatInt(n.To, 10),
+		s.ProviderID,
+		s.ProviderDV,
+		"31",
+		s.ID,
+		signature.CalculateSoftwareSecurityCode(s.ID, s.PIN, number),
+		qr
+}
+
+// totals suma las líneas de la plantilla agrupando el IVA por porcentaje
+func (r *Runner) totals() totals {
+	t := totals{byPercent: map[float64][2]float64{}}
+	for _, line := range r.config.Template.Lines {
+		ext := round(line.Quantity * line.UnitPrice)
+		t.lineExtension += ext
+		if line.TaxPercent <= 0 {
+			continue
+		}
+		tax := round(ext * line.TaxPercent / 100)
+		t.tax += tax
+
+		group, ok := t.byPercent[line.TaxPercent]
+		if !ok {
+			t.percents = append(t.percents, line.TaxPercent)
+		}
+		t.byPercent[line.TaxPercent] = [2]float64{group[0] + ext, group[1] + tax}
+	}
+	t.lineExtension, t.tax = round(t.lineExtension), round(t.tax)
+	return t
+}
+
+// taxTotal TaxTotal de IVA del documento
+func (t totals) taxTotal() invoice.TaxTotalTemplateData {
+	total := invoice.TaxTotalTemplateData{TaxAmount: amount(t.tax), CurrencyID: "COP"}
+	for _, percent := range t.percents {
+		group := t.byPercent[percent]
+		total.TaxSubtotals = append(total.TaxSubtotals, taxSubtotal(group[0], group[1], percent))
+	}
+	return total
+}
+
+// lineTaxTotal TaxTotal de IVA de una línea
+func lineTaxTotal(ext, percent float64) invoice.TaxTotalTemplateData {
+	tax := round(ext * percent / 100)
+	return invoice.TaxTotalTemplateData{
+		TaxAmount:    amount(tax),
+		CurrencyID:   "COP",
+		TaxSubtotals: []invoice.TaxSubtotalTemplateData{taxSubtotal(ext, tax, percent)},
+	}
+}
+
+// taxSubtotal subtotal de IVA para un porcentaje
+func taxSubtotal(base, tax, percent float64) invoice.TaxSubtotalTemplateData {
+	return invoice.TaxSubtotalTemplateData{
+		TaxableAmount: amount(base),
+		TaxAmount:     amount(tax),
+		CurrencyID:    "COP",
+		Percent:       amount(percent),
+		TaxCategory: invoice.TaxCategoryTemplateData{
+			Percent:   amount(percent),
+			TaxScheme: invoice.TaxSchemeTemplateData{ID: "01", Name: "IVA"},
+		},
+	}
+}
+
+// creditNoteParty convierte la parte de la plantilla al tipo de nota crédito
+func creditNoteParty(p invoice.PartyTemplateData) creditnote.PartyTemplateData {
+	return creditnote.PartyTemplateData{
+		AdditionalAccountID:        p.AdditionalAccountID,
+		PartyName:                  p.PartyName,
+		IndustryClassificationCode: p.IndustryClassificationCode,
+		Address:                    creditnote.AddressTemplateData(p.Address),
+		TaxScheme:                  creditnote.TaxSchemeTemplateData(p.TaxScheme),
+		LegalEntity:                creditnote.LegalEntityTemplateData(p.LegalEntity),
+		Contact:                    creditnote.ContactTemplateData(p.Contact),
+	}
+}
+
+// debitNoteParty convierte la parte de la plantilla al tipo de nota débito
+func debitNoteParty(p invoice.PartyTemplateData) debitnote.PartyTemplateData {
+	return debitnote.PartyTemplateData{
+		AdditionalAccountID:        p.AdditionalAccountID,
+		PartyName:                  p.PartyName,
+		IndustryClassificationCode: p.IndustryClassificationCode,
+		Address:                    debitnote.AddressTemplateData(p.Address),
+		TaxScheme:                  debitnote.TaxSchemeTemplateData(p.TaxScheme),
+		LegalEntity:                debitnote.LegalEntityTemplateData(p.LegalEntity),
+		Contact:                    debitnote.ContactTemplateData(p.Contact),
+	}
+}
+
+// creditNoteTaxTotal convierte el TaxTotal de IVA al tipo de nota crédito
+func creditNoteTaxTotal(t invoice.TaxTotalTemplateData) creditnote.TaxTotalTemplateData {
+	total := creditnote.TaxTotalTemplateData{TaxAmount: t.TaxAmount, CurrencyID: t.CurrencyID}
+	for _, st := range t.TaxSubtotals {
+		total.TaxSubtotals = append(total.TaxSubtotals, creditnote.TaxSubtotalTemplateData{
+			TaxableAmount: st.TaxableAmount,
+			TaxAmount:     st.TaxAmount,
+			CurrencyID:    st.CurrencyID,
+			Percent:       st.Percent,
+			TaxCategory: creditnote.TaxCategoryTemplateData{
+				Percent:   st.TaxCategory.Percent,
+				TaxScheme: creditnote.TaxSchemeTemplateData(st.TaxCategory.TaxScheme),
+			},
+		})
+	}
+	return total
+}
+
+// debitNoteTaxTotal convierte el TaxTotal de IVA al tipo de nota débito
+func debitNoteTaxTotal(t invoice.TaxTotalTemplateData) debitnote.TaxTotalTemplateData {
+	total := debitnote.TaxTotalTemplateData{TaxAmount: t.TaxAmount, CurrencyID: t.CurrencyID}
+	for _, st := range t.TaxSubtotals {
+		total.TaxSubtotals = append(total.TaxSubtotals, debitnote.TaxSubtotalTemplateData{
+			TaxableAmount: st.TaxableAmount,
+			TaxAmount:     st.TaxAmount,
+			CurrencyID:    st.CurrencyID,
+			Percent:       st.Percent,
+			TaxCategory: debitnote.TaxCategoryTemplateData{
+				Percent:   st.TaxCategory.Percent,
+				TaxScheme: debitnote.TaxSchemeTemplateData(st.TaxCategory.TaxScheme),
+			},
+		})
+	}
+	return total
+}
+
+// supplierNIT NIT del emisor de la plantilla
+func supplierNIT(t Template) string {
+	return strings.TrimSpace(t.Supplier.TaxScheme.CompanyID)
+}
+
+// customerNIT identificación del adquirente de la plantilla
+func customerNIT(t Template) string {
+	return strings.TrimSpace(t.Customer.TaxScheme.CompanyID)
+}
+
+// unitCode unidad de medida de la línea ("94" = unidad por defecto)
+func unitCode(line Line) string {
+	if line.UnitCode == "" {
+		return "94"
+	}
+	return line.UnitCode
+}
+
+// round redondea a 2 decimales
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// amount formatea un valor monetario con 2 decimales
+func amount(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// quantity formatea una cantidad con 6 decimales
+func quantity(v float64) string {
+	return strconv.FormatFloat(v, 'f', 6, 64)
+}
+
+// zipName nombre del ZIP de envío para un documento ("fv..." -> "z...")
+func zipName(fileName string) string {
+	return "z" + strings.TrimSuffix(fileName, ".xml")[2:] + ".zip"
+}
@@ -0,0 +1,100 @@
+package testset
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/diegofxm/ubl21-dian/soap/rules"
+)
+
+// Counts conteo de documentos por estado
+type Counts struct {
+	Total    int
+	Accepted int
+	Rejected int
+	Pending  int // Generados o enviados sin resultado
+}
+
+// Rejection documento rechazado con las reglas reportadas por DIAN
+type Rejection struct {
+	Kind       Kind
+	Number     string
+	UUID       string
+	Errors     []string
+	Violations []rules.Violation // Mensajes con formato "Regla: X, Rechazo: ..."
+}
+
+// Report resultado del set de pruebas
+type Report struct {
+	TestSetID  string
+	Counts     Counts
+	ByKind     map[Kind]Counts
+	Rejections []Rejection
+}
+
+// Complete indica que todos los documentos tienen resultado de DIAN
+func (r *Report) Complete() bool {
+	return r.Counts.Pending == 0
+}
+
+// Passed indica que todos los documentos del set fueron aceptados
+func (r *Report) Passed() bool {
+	return r.Counts.Total > 0 && r.Counts.Accepted == r.Counts.Total
+}
+
+// String resumen legible del reporte
+func (r *Report) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Set de pruebas %s: %d aceptados, %d rechazados, %d pendientes de %d\n",
+		r.TestSetID, r.Counts.Accepted, r.Counts.Rejected, r.Counts.Pending, r.Counts.Total)
+	for _, kind := range []Kind{KindInvoice, KindCreditNote, KindDebitNote} {
+		c, ok := r.ByKind[kind]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&sb, "  %-10s %d aceptados, %d rechazados, %d pendientes de %d\n",
+			kind, c.Accepted, c.Rejected, c.Pending, c.Total)
+	}
+	for _, rej := range r.Rejections {
+		fmt.Fprintf(&sb, "  Rechazado %s: %s\n", rej.Number, strings.Join(rej.Errors, "; "))
+	}
+	return sb.String()
+}
+
+// Report resume el estado actual del set
+func (s *State) Report() *Report {
+	report := &Report{TestSetID: s.TestSetID, ByKind: map[Kind]Counts{}}
+
+	for _, doc := range s.Documents {
+		c := report.ByKind[doc.Kind]
+		c.Total++
+		report.Counts.Total++
+
+		switch doc.Status {
+		case StatusAccepted:
+			c.Accepted++
+			report.Counts.Accepted++
+		case StatusRejected:
+			c.Rejected++
+			report.Counts.Rejected++
+			report.Rejections = append(report.Rejections, rejectionOf(doc))
+		default:
+			c.Pending++
+			report.Counts.Pending++
+		}
+		report.ByKind[doc.Kind] = c
+	}
+
+	return report
+}
+
+// rejectionOf interpreta los mensajes de un documento rechazado
+func rejectionOf(doc DocumentState) Rejection {
+	rej := Rejection{Kind: doc.Kind, Number: doc.Number, UUID: doc.UUID, Errors: doc.Errors}
+	for _, msg := range doc.Errors {
+		if v := rules.Parse("", msg); v.Code != "" {
+			rej.Violations = append(rej.Violations, v)
+		}
+	}
+	return rej
+}
@@ -0,0 +1,113 @@
+package testset
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Status estado de un documento del set de pruebas
+type Status string
+
+const (
+	StatusGenerated Status = "generated" // Firmado, pendiente de envío
+	StatusSubmitted Status = "submitted" // Enviado, esperando resultado de DIAN
+	StatusAccepted  Status = "accepted"  // Validado por DIAN
+	StatusRejected  Status = "rejected"  // Rechazado por DIAN
+)
+
+// DocumentState progreso de un documento del set
+type DocumentState struct {
+	Kind        Kind
+	Number      string
+	UUID        string // CUFE/CUDE
+	IssueDate   string
+	Reference   string `json:",omitempty"` // Factura referenciada (notas)
+	FileName    string
+	XML         []byte // Documento firmado (se reenvía igual al reanudar)
+	Status      Status
+	ZipKey      string    `json:",omitempty"`
+	SubmittedAt time.Time `json:",omitempty"`
+	Polls       int       `json:",omitempty"` // Consultas acumuladas de todas las ejecuciones
+	StatusCode  string    `json:",omitempty"` // Último StatusCode de GetStatusZip
+
+	Errors              []string `json:",omitempty"` // Mensajes del ApplicationResponse o del rechazo del ZIP
+	ApplicationResponse []byte   `json:",omitempty"`
+}
+
+// State progreso del set de pruebas, persistido en Config.StatePath
+type State struct {
+	TestSetID string
+	Plan      Plan
+	StartedAt time.Time
+	UpdatedAt time.Time
+	Documents []DocumentState
+}
+
+// LoadState lee el progreso guardado de un set de pruebas
+func LoadState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse test set state %s: %w", path, err)
+	}
+	return &state, nil
+}
+
+// Save escribe el estado de forma atómica (archivo temporal + rename)
+func (s *State) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode test set state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save test set state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save test set state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save test set state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to save test set state: %w", err)
+	}
+	return nil
+}
+
+// loadState retoma el estado de StatePath o inicia uno nuevo
+func (r *Runner) loadState() (*State, error) {
+	state, err := LoadState(r.config.StatePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return &State{
+			TestSetID: r.config.TestSetID,
+			Plan:      r.config.Plan,
+			StartedAt: r.config.Now(),
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if state.TestSetID != r.config.TestSetID {
+		return nil, fmt.Errorf("%w: state %s belongs to test set %s", ErrConfig, r.config.StatePath, state.TestSetID)
+	}
+	return state, nil
+}
+
+// save actualiza UpdatedAt y guarda el estado
+func (r *Runner) save(state *State) error {
+	state.UpdatedAt = r.config.Now()
+	return state.Save(r.config.StatePath)
+}
@@ -0,0 +1,305 @@
+// Package testset ejecuta el set de pruebas de habilitación de DIAN
+//
+// Genera las facturas y notas del set a partir de una plantilla, las numera
+// dentro del rango de pruebas, las firma y las envía con SendTestSetAsync.
+// El progreso se guarda en un archivo JSON para poder reanudar la ejecución.
+//
+//	runner, err := testset.NewRunner(testset.Config{
+//		TestSetID: "f1d2...",
+//		Numbering: testset.Numbering{Prefix: "SETP", From: 990000000, To: 995000000, ...},
+//		Software:  testset.Software{ID: "...", PIN: "12345", ProviderID: "900123456", ProviderDV: "8"},
+//		Template:  template,
+//		Signer:    signer,
+//		StatePath: "habilitacion.json",
+//	})
+//	report, err := runner.Run(client)
+package testset
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/documents/invoice"
+	"github.com/diegofxm/ubl21-dian/soap"
+)
+
+// Kind tipo de documento del set de pruebas
+type Kind string
+
+const (
+	KindInvoice    Kind = "Invoice"
+	KindCreditNote Kind = "CreditNote"
+	KindDebitNote  Kind = "DebitNote"
+)
+
+// Plan cantidad de documentos por tipo que exige el set de pruebas
+type Plan struct {
+	Invoices    int
+	CreditNotes int
+	DebitNotes  int
+}
+
+// DefaultPlan set de pruebas de facturación electrónica de DIAN
+var DefaultPlan = Plan{Invoices: 30, CreditNotes: 10, DebitNotes: 10}
+
+// Total número de documentos del plan
+func (p Plan) Total() int {
+	return p.Invoices + p.CreditNotes + p.DebitNotes
+}
+
+// Numbering resolución y rango de numeración de pruebas
+type Numbering struct {
+	Resolution   string // InvoiceAuthorization (ej: "18760000001")
+	StartDate    time.Time
+	EndDate      time.Time
+	Prefix       string // Prefijo de facturas (ej: "SETP")
+	From         int64
+	To           int64
+	Next         int64  // Primer consecutivo a usar (From si es 0)
+	TechnicalKey string // Clave técnica del rango (CUFE)
+
+	CreditNotePrefix string // Prefijo de notas crédito ("NC" por defecto)
+	DebitNotePrefix  string // Prefijo de notas débito ("ND" por defecto)
+}
+
+// Software datos del software en habilitación
+type Software struct {
+	ID         string
+	PIN        string // PIN del software (código de seguridad y CUDE)
+	ProviderID string // NIT del proveedor tecnológico
+	ProviderDV string
+}
+
+// Line línea de la plantilla, repetida en cada documento
+type Line struct {
+	Code        string
+	Description string
+	UnitCode    string // "94" por defecto
+	Quantity    float64
+	UnitPrice   float64
+	TaxPercent  float64 // IVA (0 = sin impuesto)
+}
+
+// Template plantilla con la que se generan los documentos del set
+type Template struct {
+	Supplier         invoice.PartyTemplateData
+	Customer         invoice.PartyTemplateData
+	Lines            []Line
+	PaymentMeansCode string // "10" (efectivo) por defecto
+	Note             string
+}
+
+// Signer firma los documentos generados (*signature.Signer lo implementa)
+type Signer interface {
+	SignXML(xmlData []byte) ([]byte, error)
+}
+
+// Config configuración del set de pruebas
+type Config struct {
+	TestSetID string
+	Plan      Plan // DefaultPlan si está vacío
+	Numbering Numbering
+	Software  Software
+	Template  Template
+	Signer    Signer
+	StatePath string // Archivo JSON con el progreso (requerido para reanudar)
+
+	PollInterval time.Duration    // Espera entre consultas GetStatusZip (5s por defecto)
+	MaxPolls     int              // Consultas por documento en cada Run antes de dejarlo pendiente (60 por defecto)
+	Now          func() time.Time // Reloj para fechas de emisión (time.Now por defecto)
+}
+
+// Runner ejecuta el set de pruebas
+type Runner struct {
+	config Config
+}
+
+// ErrConfig error en la configuración del set de pruebas
+var ErrConfig = errors.New("invalid test set config")
+
+// NewRunner valida la configuración y crea el runner
+func NewRunner(config Config) (*Runner, error) {
+	if config.Plan == (Plan{}) {
+		config.Plan = DefaultPlan
+	}
+	if config.Numbering.Next == 0 {
+		config.Numbering.Next = config.Numbering.From
+	}
+	if config.Numbering.CreditNotePrefix == "" {
+		config.Numbering.CreditNotePrefix = "NC"
+	}
+	if config.Numbering.DebitNotePrefix == "" {
+		config.Numbering.DebitNotePrefix = "ND"
+	}
+	if config.Template.PaymentMeansCode == "" {
+		config.Template.PaymentMeansCode = "10"
+	}
+	if config.PollInterval == 0 {
+		config.PollInterval = 5 * time.Second
+	}
+	if config.MaxPolls == 0 {
+		config.MaxPolls = 60
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+
+	n := config.Numbering
+	switch {
+	case config.TestSetID == "":
+		return nil, fmt.Errorf("%w: TestSetID is required", ErrConfig)
+	case config.StatePath == "":
+		return nil, fmt.Errorf("%w: StatePath is required", ErrConfig)
+	case config.Signer == nil:
+		return nil, fmt.Errorf("%w: Signer is required", ErrConfig)
+	case config.Plan.Invoices <= 0:
+		return nil, fmt.Errorf("%w: the plan needs at least one invoice", ErrConfig)
+	case config.Plan.CreditNotes < 0 || config.Plan.DebitNotes < 0:
+		return nil, fmt.Errorf("%w: negative document count", ErrConfig)
+	case n.Prefix == "" || n.TechnicalKey == "":
+		return nil, fmt.Errorf("%w: numbering prefix and technical key are required", ErrConfig)
+	case n.Next < n.From || n.Next+int64(config.Plan.Total())-1 > n.To:
+		return nil, fmt.Errorf("%w: %d documents starting at %d do not fit in range %d-%d",
+			ErrConfig, config.Plan.Total(), n.Next, n.From, n.To)
+	case config.Software.ID == "" || config.Software.PIN == "":
+		return nil, fmt.Errorf("%w: software ID and PIN are required", ErrConfig)
+	case len(config.Template.Lines) == 0:
+		return nil, fmt.Errorf("%w: the template needs at least one line", ErrConfig)
+	}
+
+	return &Runner{config: config}, nil
+}
+
+// Run genera, envía y consulta el set de pruebas, reanudando desde StatePath
+//
+// El estado se guarda después de cada paso. Si la ejecución se interrumpe
+// (error de red, MaxPolls agotado), basta con volver a llamar Run: los
+// documentos ya enviados no se reenvían y solo se consultan los pendientes.
+func (r *Runner) Run(sender soap.BatchSender) (*Report, error) {
+	state, err := r.loadState()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(state.Documents) == 0 {
+		docs, err := r.generate()
+		if err != nil {
+			return nil, err
+		}
+		state.Documents = docs
+		if err := r.save(state); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.submit(sender, state); err != nil {
+		return state.Report(), err
+	}
+	if err := r.poll(sender, state); err != nil {
+		return state.Report(), err
+	}
+
+	return state.Report(), nil
+}
+
+// submit envía los documentos generados que aún no tienen ZipKey
+func (r *Runner) submit(sender soap.BatchSender, state *State) error {
+	for i := range state.Documents {
+		doc := &state.Documents[i]
+		if doc.Status != StatusGenerated {
+			continue
+		}
+
+		batch := soap.NewBatch(zipName(doc.FileName))
+		batch.TestSetID = r.config.TestSetID
+		if err := batch.Add(doc.FileName, doc.XML); err != nil {
+			return fmt.Errorf("failed to package %s: %w", doc.Number, err)
+		}
+
+		submission, err := batch.Submit(sender)
+		if err != nil {
+			return fmt.Errorf("failed to submit %s: %w", doc.Number, err)
+		}
+
+		doc.Status = StatusSubmitted
+		doc.ZipKey = submission.ZipKey
+		doc.SubmittedAt = submission.SubmittedAt
+		if err := r.save(state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// poll consulta GetStatusZip hasta que todos los envíos tengan resultado
+// MaxPolls cuenta las consultas de esta ejecución: al reanudar, los
+// documentos que lo agotaron se vuelven a consultar.
+func (r *Runner) poll(sender soap.BatchSender, state *State) error {
+	polls := make([]int, len(state.Documents))
+	for {
+		pending := 0
+		for i := range state.Documents {
+			doc := &state.Documents[i]
+			if doc.Status != StatusSubmitted || polls[i] >= r.config.MaxPolls {
+				continue
+			}
+
+			polls[i]++
+			if err := r.check(sender, doc); err != nil {
+				return err
+			}
+			if doc.Status == StatusSubmitted {
+				pending++
+			}
+			if err := r.save(state); err != nil {
+				return err
+			}
+		}
+
+		if pending == 0 {
+			return nil
+		}
+		time.Sleep(r.config.PollInterval)
+	}
+}
+
+// check consulta el ZipKey de un documento y registra el resultado
+func (r *Runner) check(sender soap.BatchSender, doc *DocumentState) error {
+	submission := &soap.BatchSubmission{
+		ZipKey:   doc.ZipKey,
+		FileName: zipName(doc.FileName),
+		Documents: []soap.BatchDocument{{
+			FileName: doc.FileName,
+			Number:   doc.Number,
+			UUID:     doc.UUID,
+		}},
+		SubmittedAt: doc.SubmittedAt,
+	}
+
+	doc.Polls++
+	result, err := submission.Poll(sender)
+	if err != nil {
+		return fmt.Errorf("failed to poll %s: %w", doc.Number, err)
+	}
+	doc.StatusCode = result.StatusCode
+
+	switch {
+	case result.Pending:
+		return nil
+	case result.StatusCode == soap.StatusRejected && !result.Documents[0].Found:
+		// Rechazo del ZIP completo, sin ApplicationResponse por documento
+		doc.Status = StatusRejected
+		doc.Errors = []string{result.StatusMessage}
+	case result.Documents[0].Found:
+		res := result.Documents[0]
+		doc.ApplicationResponse = res.XML
+		doc.Errors = res.ApplicationResponse.Descriptions
+		if res.IsValid() {
+			doc.Status = StatusAccepted
+		} else {
+			doc.Status = StatusRejected
+		}
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package testset_test
+
+import (
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/documents/invoice"
+	"github.com/diegofxm/ubl21-dian/signature"
+	"github.com/diegofxm/ubl21-dian/soap"
+	"github.com/diegofxm/ubl21-dian/soap/operations"
+	"github.com/diegofxm/ubl21-dian/soap/simulator"
+	"github.com/diegofxm/ubl21-dian/soap/types"
+	"github.com/diegofxm/ubl21-dian/testset"
+	"github.com/diegofxm/ubl21-dian/validation"
+)
+
+// sender adapta operations.* a soap.BatchSender contra el simulador
+// Con failAt > 0, el envío número failAt falla antes de llegar al simulador.
+type sender struct {
+	transport *soap.Transport
+	creds     *simulator.TestCredentials
+	url       string
+	sent      int
+	failAt    int
+}
+
+func (s *sender) SendBillAsync(req *types.SendBillAsyncRequest) (*types.SendBillAsyncResponse, error) {
+	return operations.SendBillAsync(s.transport, s.creds.Credentials(), s.url, soap.ActionSendBillAsync, req)
+}
+
+func (s *sender) SendTestSetAsync(req *types.SendTestSetAsyncRequest) (*types.SendTestSetAsyncResponse, error) {
+	s.sent++
+	if s.sent == s.failAt {
+		return nil, errors.New("connection reset by peer")
+	}
+	return operations.SendTestSetAsync(s.transport, s.creds.Credentials(), s.url, soap.ActionSendTestSetAsync, req)
+}
+
+func (s *sender) GetStatusZip(req *types.GetStatusZipRequest) (*types.GetStatusZipResponse, error) {
+	return operations.GetStatusZip(s.transport, s.creds.Credentials(), s.url, soap.ActionGetStatusZip, req)
+}
+
+func setup(t *testing.T) (*simulator.Simulator, *sender, testset.Config) {
+	t.Helper()
+
+	creds, err := simulator.NewTestCredentials(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := signature.NewSignerFromPEM(creds.CertPath, creds.KeyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sim := simulator.New()
+	srv := httptest.NewServer(sim)
+	t.Cleanup(srv.Close)
+
+	party := func(name, nit string) invoice.PartyTemplateData {
+		return invoice.PartyTemplateData{
+			AdditionalAccountID: "1",
+			PartyName:           name,
+			Address:             invoice.AddressTemplateData{ID: "11001", CityName: "Bogotá", CountrySubentity: "Bogotá", CountrySubentityCode: "11", Line: "Calle 1 # 2-3", CountryCode: "CO", CountryName: "Colombia"},
+			TaxScheme:           invoice.TaxSchemeTemplateData{RegistrationName: name, CompanyID: nit, CompanyIDSchemeID: "", CompanyIDSchemeName: "31", TaxLevelCode: "O-13", ID: "01", Name: "IVA"},
+			LegalEntity:         invoice.LegalEntityTemplateData{RegistrationName: name, CompanyID: nit, CompanyIDSchemeID: "", CompanyIDSchemeName: "31"},
+		}
+	}
+
+	config := testset.Config{
+		TestSetID: "f1d2c3b4-0000-4000-8000-000000000001",
+		Plan:      testset.Plan{Invoices: 2, CreditNotes: 1, DebitNotes: 1},
+		Numbering: testset.Numbering{
+			Resolution:   "18760000001",
+			StartDate:    time.Date(2019, 1, 19, 0, 0, 0, 0, time.UTC),
+			EndDate:      time.Date(2030, 1, 19, 0, 0, 0, 0, time.UTC),
+			Prefix:       "SETP",
+			From:         990000000,
+			To:           995000000,
+			TechnicalKey: "fc8eac422eba16e22ffd8c6f94b3f40a6e38162c",
+		},
+		Software: testset.Software{ID: "56f2ae4e-9812-4fad-9255-08fcfcd5ccb0", PIN: "12345", ProviderID: "900123456", ProviderDV: "8"},
+		Template: testset.Template{
+			Supplier: party("MI EMPRESA SAS", "900123456"),
+			Customer: party("CLIENTE SAS", "800111222"),
+			Lines: []testset.Line{
+				{Code: "P001", Description: "Servicio de consultoría", Quantity: 2, UnitPrice: 50000, TaxPercent: 19},
+				{Code: "P002", Description: "Transporte", Quantity: 1, UnitPrice: 10000},
+			},
+		},
+		Signer:       signer,
+		StatePath:    filepath.Join(t.TempDir(), "habilitacion.json"),
+		PollInterval: time.Millisecond,
+	}
+
+	return sim, &sender{
+		transport: soap.NewTransport(srv.URL, nil, 5*time.Second),
+		creds:     creds,
+		url:       srv.URL,
+	}, config
+}
+
+func TestRun(t *testing.T) {
+	sim, s, config := setup(t)
+	sim.Enqueue(soap.ActionSendTestSetAsync,
+		simulator.Outcome{Rejections: []string{"Regla: FAD06, Rechazo: Valor del CUFE no está calculado correctamente."}},
+		simulator.Outcome{Pending: 2},
+	)
+
+	runner, err := testset.NewRunner(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := runner.Run(s)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if !report.Complete() || report.Passed() {
+		t.Fatalf("Expected complete set with rejections:\n%s", report)
+	}
+	if report.Counts.Total != 4 || report.Counts.Accepted != 3 || report.Counts.Rejected != 1 {
+		t.Errorf("Unexpected counts: %+v", report.Counts)
+	}
+	if c := report.ByKind[testset.KindInvoice]; c.Total != 2 || c.Rejected != 1 {
+		t.Errorf("Unexpected invoice counts: %+v", c)
+	}
+
+	rej := report.Rejections[0]
+	if rej.Number != "SETP990000000" || len(rej.Violations) != 1 || rej.Violations[0].Code != "FAD06" {
+		t.Errorf("Unexpected rejection: %+v", rej)
+	}
+
+	// Numeración dentro del rango y notas referenciando facturas del set
+	state, err := testset.LoadState(config.StatePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"SETP990000000", "SETP990000001", "NC990000002", "ND990000003"}
+	for i, doc := range state.Documents {
+		if doc.Number != want[i] {
+			t.Errorf("Document %d: expected %s, got %s", i, want[i], doc.Number)
+		}
+	}
+	if ref := state.Documents[2].Reference; ref != "SETP990000000" {
+		t.Errorf("Expected credit note to reference the first invoice, got %q", ref)
+	}
+	if doc, ok := sim.Document(state.Documents[1].UUID); !ok || !doc.Signed {
+		t.Errorf("Expected signed invoice in simulator, got %+v", doc)
+	}
+}
+
+func TestResume(t *testing.T) {
+	sim, s, config := setup(t)
+	s.failAt = 3
+
+	runner, err := testset.NewRunner(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := runner.Run(s)
+	if err == nil {
+		t.Fatal("Expected submission error")
+	}
+	if report.Counts.Pending != 4 {
+		t.Errorf("Expected 4 pending documents, got %+v", report.Counts)
+	}
+
+	// Nuevo runner sobre el mismo estado: solo envía lo que faltaba
+	runner, err = testset.NewRunner(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err = runner.Run(s)
+	if err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	if !report.Passed() {
+		t.Fatalf("Expected all documents accepted:\n%s", report)
+	}
+
+	submitted := 0
+	for _, req := range sim.Requests() {
+		if req.Action == soap.ActionSendTestSetAsync {
+			submitted++
+		}
+	}
+	if submitted != 4 {
+		t.Errorf("Expected 4 submissions reaching DIAN, got %d", submitted)
+	}
+
+	// Otro TestSetId no puede reutilizar el estado
+	config.TestSetID = "otro"
+	runner, _ = testset.NewRunner(config)
+	if _, err := runner.Run(s); !errors.Is(err, testset.ErrConfig) {
+		t.Errorf("Expected ErrConfig for foreign state, got %v", err)
+	}
+	if _, err := os.Stat(config.StatePath); err != nil {
+		t.Errorf("State file missing: %v", err)
+	}
+}
+
+func TestResumeAfterMaxPolls(t *testing.T) {
+	sim, s, config := setup(t)
+	config.MaxPolls = 1
+	sim.Enqueue(soap.ActionSendTestSetAsync, simulator.Outcome{Pending: 1})
+
+	runner, err := testset.NewRunner(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := runner.Run(s)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.Counts.Pending != 1 {
+		t.Fatalf("Expected 1 pending document after MaxPolls, got %+v", report.Counts)
+	}
+
+	// Volver a llamar Run consulta de nuevo el documento pendiente
+	runner, err = testset.NewRunner(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err = runner.Run(s)
+	if err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	if !report.Passed() {
+		t.Fatalf("Expected all documents accepted after resuming:\n%s", report)
+	}
+
+	state, err := testset.LoadState(config.StatePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if polls := state.Documents[0].Polls; polls != 2 {
+		t.Errorf("Expected 2 accumulated polls, got %d", polls)
+	}
+}
+
+func TestGeneratedDocumentsValidate(t *testing.T) {
+	_, s, config := setup(t)
+
+	runner, err := testset.NewRunner(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runner.Run(s); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	state, err := testset.LoadState(config.StatePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Las notas llevan el IVA de la plantilla: TaxTotal, CUDE y totales con impuestos
+	for _, doc := range state.Documents {
+		report, err := validation.Validate(doc.XML, validation.Options{
+			TechnicalKey: config.Numbering.TechnicalKey,
+			SoftwarePIN:  config.Software.PIN,
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", doc.Number, err)
+		}
+		for _, f := range report.Rejections() {
+			t.Errorf("%s: %s", doc.Number, f)
+		}
+		if doc.Kind == testset.KindInvoice {
+			continue
+		}
+		xml := string(doc.XML)
+		if !strings.Contains(xml, `<cbc:TaxAmount currencyID="COP">19000.00</cbc:TaxAmount>`) {
+			t.Errorf("%s: expected IVA TaxTotal of 19000.00", doc.Number)
+		}
+		if !strings.Contains(xml, `<cbc:PayableAmount currencyID="COP">129000.00</cbc:PayableAmount>`) {
+			t.Errorf("%s: expected payable amount including IVA", doc.Number)
+		}
+	}
+}
@@ -0,0 +1,435 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/identification"
+	"github.com/diegofxm/ubl21-dian/signature"
+	"github.com/diegofxm/ubl21-dian/soap/rules"
+	xmlpkg "github.com/diegofxm/ubl21-dian/xml"
+)
+
+// prefixes prefijo de las reglas del Anexo Técnico por tipo de documento
+var prefixes = map[rules.Document]string{
+	rules.Invoice:         "FA",
+	rules.CreditNote:      "CA",
+	rules.DebitNote:       "DA",
+	rules.SupportDocument: "DSA",
+}
+
+// schemeNames algoritmo esperado en cbc:UUID/@schemeName
+var schemeNames = map[rules.Document]string{
+	rules.Invoice:         "CUFE-SHA384",
+	rules.CreditNote:      "CUDE-SHA384",
+	rules.DebitNote:       "CUDE-SHA384",
+	rules.SupportDocument: "CUDS-SHA384",
+}
+
+// colombia zona horaria de las fechas de emisión
+var colombia = time.FixedZone("COT", -5*60*60)
+
+// checker aplica las reglas a un documento
+type checker struct {
+	doc    *document
+	kind   rules.Document
+	opts   Options
+	report *Report
+}
+
+func (c *checker) run() {
+	c.general()
+	c.currency()
+	if c.kind == rules.Invoice || c.kind == rules.SupportDocument {
+		c.numbering()
+	}
+	c.uuid()
+	c.parties()
+	c.taxes()
+	c.totals()
+	c.lines()
+}
+
+// rule regla del tipo de documento por sufijo ("D06" -> FAD06, CAD06, ...)
+func (c *checker) rule(suffix string) rules.Rule {
+	code := prefixes[c.kind] + suffix
+	if rule, ok := rules.Lookup(code); ok {
+		return rule
+	}
+	return rules.Rule{Code: code, Severity: rules.Rejection, Document: c.kind}
+}
+
+// add registra un hallazgo de la regla con sufijo suffix
+func (c *checker) add(suffix, path, format string, args ...interface{}) {
+	c.addRule(c.rule(suffix), path, format, args...)
+}
+
+// addRule registra un hallazgo de una regla transversal (ZB01, ...)
+func (c *checker) addRule(rule rules.Rule, path, format string, args ...interface{}) {
+	severity := rule.Severity
+	if severity == "" {
+		severity = rules.Rejection
+	}
+	c.report.Findings = append(c.report.Findings, Finding{
+		Code:     rule.Code,
+		Severity: severity,
+		Path:     "/" + c.doc.XMLName.Local + "/" + path,
+		Message:  fmt.Sprintf(format, args...),
+		Rule:     rule,
+	})
+}
+
+// schema hallazgo de estructura (ZB01)
+func (c *checker) schema(path, format string, args ...interface{}) {
+	rule, _ := rules.Lookup("ZB01")
+	c.addRule(rule, path, format, args...)
+}
+
+// structure valida el XML contra los esquemas embebidos (un ZB01 por error)
+func (c *checker) structure(data []byte) error {
+	err := xmlpkg.ValidateSchema(data)
+	var errs xmlpkg.SchemaErrors
+	if !errors.As(err, &errs) {
+		return err
+	}
+
+	rule, _ := rules.Lookup("ZB01")
+	for _, e := range errs {
+		c.report.Findings = append(c.report.Findings, Finding{
+			Code:     rule.Code,
+			Severity: rule.Severity,
+			Path:     fmt.Sprintf("line %d", e.Line),
+			Message:  e.Message,
+			Rule:     rule,
+		})
+	}
+	return nil
+}
+
+// equal compara dos valores con la tolerancia configurada
+func (c *checker) equal(a, b float64) bool {
+	return math.Abs(a-b) <= c.opts.Tolerance+1e-9
+}
+
+// number lee un valor numérico ("" equivale a 0; inválidos se reportan en currency)
+func number(s string) float64 {
+	v, _ := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return v
+}
+
+// general encabezado: versión, ambiente, número, algoritmo y fecha
+func (c *checker) general() {
+	d := c.doc
+	if d.UBLVersionID != "UBL 2.1" {
+		c.add("D01", "cbc:UBLVersionID", "UBLVersionID is %q, expected \"UBL 2.1\"", d.UBLVersionID)
+	}
+	if d.ProfileExecutionID != "1" && d.ProfileExecutionID != "2" {
+		c.add("D04", "cbc:ProfileExecutionID", "ProfileExecutionID is %q, expected 1 or 2", d.ProfileExecutionID)
+	} else if d.UUID.SchemeID != "" && d.UUID.SchemeID != d.ProfileExecutionID {
+		c.add("D04", "cbc:UUID/@schemeID", "UUID schemeID %q does not match ProfileExecutionID %q", d.UUID.SchemeID, d.ProfileExecutionID)
+	}
+	if strings.TrimSpace(d.ID) == "" || strings.ContainsAny(d.ID, " -") {
+		c.add("D05", "cbc:ID", "document number %q is empty or contains spaces or dashes", d.ID)
+	}
+	if want := schemeNames[c.kind]; d.UUID.SchemeName != want {
+		c.add("D07", "cbc:UUID/@schemeName", "schemeName is %q, expected %q", d.UUID.SchemeName, want)
+	}
+
+	issued, err := time.ParseInLocation("2006-01-02", d.IssueDate, colombia)
+	if err != nil {
+		c.schema("cbc:IssueDate", "invalid IssueDate %q", d.IssueDate)
+		return
+	}
+	if today := c.opts.Now().In(colombia).Format("2006-01-02"); issued.Format("2006-01-02") > today {
+		c.add("D09e", "cbc:IssueDate", "IssueDate %s is after the reception date %s", d.IssueDate, today)
+	}
+}
+
+// currency atributos currencyID y formato de los valores
+func (c *checker) currency() {
+	d := c.doc
+	if len(d.CurrencyCode) != 3 {
+		c.schema("cbc:DocumentCurrencyCode", "invalid DocumentCurrencyCode %q", d.CurrencyCode)
+	}
+
+	check := func(path string, a amount) {
+		if _, err := strconv.ParseFloat(strings.TrimSpace(a.Value), 64); err != nil {
+			c.schema(path, "invalid amount %q", a.Value)
+		}
+		if a.Currency != d.CurrencyCode {
+			c.add("U01", path+"/@currencyID", "currencyID %q does not match DocumentCurrencyCode %q", a.Currency, d.CurrencyCode)
+		}
+	}
+	optional := func(path string, a *amount) {
+		if a != nil {
+			check(path, *a)
+		}
+	}
+
+	for i, total := range d.TaxTotals {
+		path := fmt.Sprintf("cac:TaxTotal[%d]", i+1)
+		check(path+"/cbc:TaxAmount", total.TaxAmount)
+		for j, sub := range total.Subtotals {
+			check(fmt.Sprintf("%s/cac:TaxSubtotal[%d]/cbc:TaxableAmount", path, j+1), sub.TaxableAmount)
+			check(fmt.Sprintf("%s/cac:TaxSubtotal[%d]/cbc:TaxAmount", path, j+1), sub.TaxAmount)
+		}
+	}
+	if t := d.totals(); t != nil {
+		path := c.totalsPath()
+		check(path+"/cbc:LineExtensionAmount", t.LineExtensionAmount)
+		check(path+"/cbc:TaxExclusiveAmount", t.TaxExclusiveAmount)
+		check(path+"/cbc:TaxInclusiveAmount", t.TaxInclusiveAmount)
+		optional(path+"/cbc:AllowanceTotalAmount", t.AllowanceTotalAmount)
+		optional(path+"/cbc:ChargeTotalAmount", t.ChargeTotalAmount)
+		optional(path+"/cbc:PrepaidAmount", t.PrepaidAmount)
+		optional(path+"/cbc:PayableRoundingAmount", t.PayableRoundingAmount)
+		check(path+"/cbc:PayableAmount", t.PayableAmount)
+	}
+	for i, l := range d.lines() {
+		path := fmt.Sprintf("%s[%d]", c.linePath(), i+1)
+		check(path+"/cbc:LineExtensionAmount", l.LineExtensionAmount)
+		check(path+"/cac:Price/cbc:PriceAmount", l.PriceAmount)
+	}
+}
+
+// numbering prefijo, rango y vigencia de la resolución
+func (c *checker) numbering() {
+	d := c.doc
+	ext := d.extensions
+	if ext == nil || ext.Authorization == "" {
+		c.schema("ext:UBLExtensions", "sts:DianExtensions/sts:InvoiceControl is missing")
+		return
+	}
+
+	prefix := strings.TrimSpace(ext.Prefix)
+	if !strings.HasPrefix(d.ID, prefix) {
+		c.add("B10b", "cbc:ID", "document number %q does not start with the authorized prefix %q", d.ID, prefix)
+	} else {
+		n, err := strconv.ParseInt(strings.TrimPrefix(d.ID, prefix), 10, 64)
+		from, ferr := strconv.ParseInt(strings.TrimSpace(ext.From), 10, 64)
+		to, terr := strconv.ParseInt(strings.TrimSpace(ext.To), 10, 64)
+		switch {
+		case ferr != nil || terr != nil:
+			c.schema("sts:AuthorizedInvoices", "invalid authorized range %q-%q", ext.From, ext.To)
+		case err != nil || n < from || n > to:
+			c.add("B05b", "cbc:ID", "document number %q is outside the authorized range %s%d-%s%d", d.ID, prefix, from, prefix, to)
+		}
+	}
+
+	start := strings.TrimSpace(ext.StartDate)
+	end := strings.TrimSpace(ext.EndDate)
+	if d.IssueDate < start || d.IssueDate > end {
+		c.add("B07b", "cbc:IssueDate", "IssueDate %s is outside the authorization period %s to %s", d.IssueDate, start, end)
+	}
+}
+
+// taxAmounts suma los impuestos por tributo (01 IVA, 04 INC, 03 ICA)
+func (c *checker) taxAmounts() (iva, inc, ica, total float64) {
+	for _, t := range c.doc.TaxTotals {
+		total += number(t.TaxAmount.Value)
+		for _, sub := range t.Subtotals {
+			switch strings.TrimSpace(sub.SchemeID) {
+			case "01":
+				iva += number(sub.TaxAmount.Value)
+			case "04":
+				inc += number(sub.TaxAmount.Value)
+			case "03":
+				ica += number(sub.TaxAmount.Value)
+			}
+		}
+	}
+	return
+}
+
+// uuid recalcula CUFE/CUDE/CUDS y el código de seguridad del software
+func (c *checker) uuid() {
+	d := c.doc
+	t := d.totals()
+	date, err := time.Parse("2006-01-02", d.IssueDate)
+	if t == nil || err != nil {
+		return
+	}
+
+	iva, inc, ica, _ := c.taxAmounts()
+	taxExclusive := number(t.TaxExclusiveAmount.Value)
+	payable := number(t.PayableAmount.Value)
+	supplier := strings.TrimSpace(d.Supplier.CompanyID.Value)
+	customer := strings.TrimSpace(d.Customer.CompanyID.Value)
+	env := d.ProfileExecutionID
+
+	var want string
+	switch c.kind {
+	case rules.Invoice:
+		if c.opts.TechnicalKey != "" {
+			want = signature.CalculateCUFE(d.ID, date, d.IssueTime, taxExclusive, iva, inc, ica, payable,
+				supplier, customer, c.opts.TechnicalKey, env)
+		}
+	case rules.CreditNote, rules.DebitNote:
+		if c.opts.SoftwarePIN != "" {
+			want = signature.CalculateCUDE(d.ID, date, d.IssueTime, taxExclusive, iva, inc, ica, payable,
+				supplier, customer, c.opts.SoftwarePIN, env)
+		}
+	case rules.SupportDocument:
+		// En el documento soporte el emisor (AccountingSupplierParty) es el adquiriente
+		if c.opts.SoftwarePIN != "" {
+			want = signature.CalculateCUDS(d.ID, date, d.IssueTime, taxExclusive, iva, payable,
+				customer, supplier, c.opts.SoftwarePIN, env)
+		}
+	}
+	if want != "" && strings.TrimSpace(d.UUID.Value) != want {
+		c.add("D06", "cbc:UUID", "%s does not match the value calculated from the document (%s)", schemeNames[c.kind], want)
+	}
+
+	if c.opts.SoftwarePIN != "" && d.extensions != nil {
+		code := signature.CalculateSoftwareSecurityCode(strings.TrimSpace(d.extensions.SoftwareID), c.opts.SoftwarePIN, d.ID)
+		if strings.TrimSpace(d.extensions.SecurityCode) != code {
+			c.add("B27b", "ext:UBLExtensions/sts:DianExtensions/sts:SoftwareSecurityCode",
+				"SoftwareSecurityCode does not match SHA-384(SoftwareID + PIN + %s)", d.ID)
+		}
+	}
+}
+
+// parties campos obligatorios y dígito de verificación de emisor y adquirente
+func (c *checker) parties() {
+	c.party(c.doc.Supplier, "cac:AccountingSupplierParty", "J")
+	c.party(c.doc.Customer, "cac:AccountingCustomerParty", "K")
+}
+
+func (c *checker) party(p party, path, group string) {
+	id := strings.TrimSpace(p.CompanyID.Value)
+	idPath := path + "/cac:Party/cac:PartyTaxScheme/cbc:CompanyID"
+
+	if strings.TrimSpace(p.RegistrationName) == "" && strings.TrimSpace(p.Name) == "" {
+		c.add(group+"07", path+"/cac:Party/cac:PartyTaxScheme/cbc:RegistrationName", "party name is missing")
+	}
+
+	// NIT (31) y cédula (13) son numéricos; otros documentos pueden ser alfanuméricos
+	numeric := p.CompanyID.SchemeName == "31" || p.CompanyID.SchemeName == "13"
+	if id == "" || (numeric && strings.Trim(id, "0123456789") != "") {
+		c.add(group+"21", idPath, "invalid identification %q (schemeName %q)", id, p.CompanyID.SchemeName)
+		return
+	}
+
+	if p.CompanyID.SchemeName == "31" {
+		want, err := identification.CheckDigit(id)
+		if err != nil {
+			c.add(group+"21", idPath, "invalid identification %q (schemeName %q)", id, p.CompanyID.SchemeName)
+			return
+		}
+		if p.CompanyID.SchemeID != want {
+			c.add(group+"24", idPath+"/@schemeID", "verification digit of NIT %s is %q, expected %s", id, p.CompanyID.SchemeID, want)
+		}
+	}
+}
+
+// taxes subtotales de impuestos
+func (c *checker) taxes() {
+	for i, t := range c.doc.TaxTotals {
+		path := fmt.Sprintf("cac:TaxTotal[%d]", i+1)
+
+		var sum float64
+		for j, sub := range t.Subtotals {
+			tax := number(sub.TaxAmount.Value)
+			sum += tax
+			if sub.Percent == "" {
+				continue
+			}
+			want := math.Round(number(sub.TaxableAmount.Value)*number(sub.Percent)) / 100
+			if !c.equal(tax, want) {
+				c.add("S07", fmt.Sprintf("%s/cac:TaxSubtotal[%d]/cbc:TaxAmount", path, j+1),
+					"tax amount %s is not %s × %s%% = %.2f", sub.TaxAmount.Value, sub.TaxableAmount.Value, sub.Percent, want)
+			}
+		}
+		if len(t.Subtotals) > 0 && !c.equal(number(t.TaxAmount.Value), sum) {
+			c.add("S01b", path+"/cbc:TaxAmount", "tax total %s is not the sum of its subtotals %.2f", t.TaxAmount.Value, sum)
+		}
+	}
+}
+
+// totals LegalMonetaryTotal / RequestedMonetaryTotal
+func (c *checker) totals() {
+	t := c.doc.totals()
+	path := c.totalsPath()
+	if t == nil {
+		c.schema(path, "%s is missing", path)
+		return
+	}
+
+	var lines float64
+	for _, l := range c.doc.lines() {
+		lines += number(l.LineExtensionAmount.Value)
+	}
+	if !c.equal(number(t.LineExtensionAmount.Value), lines) {
+		c.add("U02", path+"/cbc:LineExtensionAmount", "LineExtensionAmount %s is not the sum of the lines %.2f", t.LineExtensionAmount.Value, lines)
+	}
+
+	_, _, _, taxes := c.taxAmounts()
+	inclusive := number(t.TaxExclusiveAmount.Value) + taxes
+	if !c.equal(number(t.TaxInclusiveAmount.Value), inclusive) {
+		c.add("U04", path+"/cbc:TaxInclusiveAmount", "TaxInclusiveAmount %s is not TaxExclusiveAmount + taxes = %.2f", t.TaxInclusiveAmount.Value, inclusive)
+	}
+
+	payable := number(t.TaxInclusiveAmount.Value)
+	if t.AllowanceTotalAmount != nil {
+		payable -= number(t.AllowanceTotalAmount.Value)
+	}
+	if t.ChargeTotalAmount != nil {
+		payable += number(t.ChargeTotalAmount.Value)
+	}
+	if t.PrepaidAmount != nil {
+		payable -= number(t.PrepaidAmount.Value)
+	}
+	if t.PayableRoundingAmount != nil {
+		payable += number(t.PayableRoundingAmount.Value)
+	}
+	if !c.equal(number(t.PayableAmount.Value), payable) {
+		c.add("U06", path+"/cbc:PayableAmount", "PayableAmount %s does not match the totals (%.2f)", t.PayableAmount.Value, payable)
+	}
+}
+
+// lines número de líneas y valor de cada línea
+func (c *checker) lines() {
+	lines := c.doc.lines()
+	if c.doc.LineCountNumeric != strconv.Itoa(len(lines)) {
+		c.add("V02", "cbc:LineCountNumeric", "LineCountNumeric is %q but the document has %d lines", c.doc.LineCountNumeric, len(lines))
+	}
+
+	for i, l := range lines {
+		if l.FreeOfCharge == "true" {
+			continue
+		}
+		want := number(l.quantity()) * number(l.PriceAmount.Value)
+		for _, ac := range l.AllowanceCharges {
+			if ac.ChargeIndicator == "true" {
+				want += number(ac.Amount.Value)
+			} else {
+				want -= number(ac.Amount.Value)
+			}
+		}
+		if !c.equal(number(l.LineExtensionAmount.Value), math.Round(want*100)/100) {
+			c.add("X07", fmt.Sprintf("%s[%d]/cbc:LineExtensionAmount", c.linePath(), i+1),
+				"line %s: LineExtensionAmount %s is not quantity × price = %.2f", l.ID, l.LineExtensionAmount.Value, want)
+		}
+	}
+}
+
+func (c *checker) totalsPath() string {
+	if c.kind == rules.DebitNote {
+		return "cac:RequestedMonetaryTotal"
+	}
+	return "cac:LegalMonetaryTotal"
+}
+
+func (c *checker) linePath() string {
+	switch c.kind {
+	case rules.CreditNote:
+		return "cac:CreditNoteLine"
+	case rules.DebitNote:
+		return "cac:DebitNoteLine"
+	}
+	return "cac:InvoiceLine"
+}
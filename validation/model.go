@@ -0,0 +1,182 @@
+package validation
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/diegofxm/ubl21-dian/soap/rules"
+	xmlpkg "github.com/diegofxm/ubl21-dian/xml"
+)
+
+// nsSTS namespace de las extensiones DIAN
+const nsSTS = "dian:gov:co:facturaelectronica:Structures-2-1"
+
+// amount valor con su moneda
+type amount struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"currencyID,attr"`
+}
+
+// scheme identificador con atributos de esquema
+type scheme struct {
+	Value      string `xml:",chardata"`
+	SchemeID   string `xml:"schemeID,attr"`
+	SchemeName string `xml:"schemeName,attr"`
+}
+
+type party struct {
+	Name             string `xml:"cac:Party>cac:PartyName>cbc:Name"`
+	RegistrationName string `xml:"cac:Party>cac:PartyTaxScheme>cbc:RegistrationName"`
+	CompanyID        scheme `xml:"cac:Party>cac:PartyTaxScheme>cbc:CompanyID"`
+	LegalCompanyID   scheme `xml:"cac:Party>cac:PartyLegalEntity>cbc:CompanyID"`
+}
+
+type taxSubtotal struct {
+	TaxableAmount amount `xml:"cbc:TaxableAmount"`
+	TaxAmount     amount `xml:"cbc:TaxAmount"`
+	Percent       string `xml:"cac:TaxCategory>cbc:Percent"`
+	SchemeID      string `xml:"cac:TaxCategory>cac:TaxScheme>cbc:ID"`
+}
+
+type taxTotal struct {
+	TaxAmount amount        `xml:"cbc:TaxAmount"`
+	Subtotals []taxSubtotal `xml:"cac:TaxSubtotal"`
+}
+
+type monetaryTotal struct {
+	LineExtensionAmount   amount  `xml:"cbc:LineExtensionAmount"`
+	TaxExclusiveAmount    amount  `xml:"cbc:TaxExclusiveAmount"`
+	TaxInclusiveAmount    amount  `xml:"cbc:TaxInclusiveAmount"`
+	AllowanceTotalAmount  *amount `xml:"cbc:AllowanceTotalAmount"`
+	ChargeTotalAmount     *amount `xml:"cbc:ChargeTotalAmount"`
+	PrepaidAmount         *amount `xml:"cbc:PrepaidAmount"`
+	PayableRoundingAmount *amount `xml:"cbc:PayableRoundingAmount"`
+	PayableAmount         amount  `xml:"cbc:PayableAmount"`
+}
+
+type line struct {
+	ID                  string   `xml:"cbc:ID"`
+	InvoicedQuantity    *amount  `xml:"cbc:InvoicedQuantity"`
+	CreditedQuantity    *amount  `xml:"cbc:CreditedQuantity"`
+	DebitedQuantity     *amount  `xml:"cbc:DebitedQuantity"`
+	LineExtensionAmount amount   `xml:"cbc:LineExtensionAmount"`
+	FreeOfCharge        string   `xml:"cbc:FreeOfChargeIndicator"`
+	PriceAmount         amount   `xml:"cac:Price>cbc:PriceAmount"`
+	BaseQuantity        string   `xml:"cac:Price>cbc:BaseQuantity"`
+	AllowanceCharges    []charge `xml:"cac:AllowanceCharge"`
+}
+
+// quantity cantidad de la línea según el tipo de documento
+func (l line) quantity() string {
+	switch {
+	case l.InvoicedQuantity != nil:
+		return l.InvoicedQuantity.Value
+	case l.CreditedQuantity != nil:
+		return l.CreditedQuantity.Value
+	case l.DebitedQuantity != nil:
+		return l.DebitedQuantity.Value
+	}
+	return ""
+}
+
+type charge struct {
+	ChargeIndicator string `xml:"cbc:ChargeIndicator"`
+	Amount          amount `xml:"cbc:Amount"`
+}
+
+// document campos de factura, notas y documento soporte usados por las reglas
+type document struct {
+	XMLName            xml.Name
+	UBLVersionID       string         `xml:"cbc:UBLVersionID"`
+	CustomizationID    string         `xml:"cbc:CustomizationID"`
+	ProfileExecutionID string         `xml:"cbc:ProfileExecutionID"`
+	ID                 string         `xml:"cbc:ID"`
+	UUID               scheme         `xml:"cbc:UUID"`
+	IssueDate          string         `xml:"cbc:IssueDate"`
+	IssueTime          string         `xml:"cbc:IssueTime"`
+	InvoiceTypeCode    string         `xml:"cbc:InvoiceTypeCode"`
+	CurrencyCode       string         `xml:"cbc:DocumentCurrencyCode"`
+	LineCountNumeric   string         `xml:"cbc:LineCountNumeric"`
+	Supplier           party          `xml:"cac:AccountingSupplierParty"`
+	Customer           party          `xml:"cac:AccountingCustomerParty"`
+	TaxTotals          []taxTotal     `xml:"cac:TaxTotal"`
+	LegalTotal         *monetaryTotal `xml:"cac:LegalMonetaryTotal"`
+	RequestedTotal     *monetaryTotal `xml:"cac:RequestedMonetaryTotal"`
+	InvoiceLines       []line         `xml:"cac:InvoiceLine"`
+	CreditNoteLines    []line         `xml:"cac:CreditNoteLine"`
+	DebitNoteLines     []line         `xml:"cac:DebitNoteLine"`
+
+	extensions *extensions
+}
+
+// extensions sts:DianExtensions (nil si el documento no las tiene)
+type extensions struct {
+	Authorization string `xml:"sts:InvoiceControl>sts:InvoiceAuthorization"`
+	StartDate     string `xml:"sts:InvoiceControl>sts:AuthorizationPeriod>cbc:StartDate"`
+	EndDate       string `xml:"sts:InvoiceControl>sts:AuthorizationPeriod>cbc:EndDate"`
+	Prefix        string `xml:"sts:InvoiceControl>sts:AuthorizedInvoices>sts:Prefix"`
+	From          string `xml:"sts:InvoiceControl>sts:AuthorizedInvoices>sts:From"`
+	To            string `xml:"sts:InvoiceControl>sts:AuthorizedInvoices>sts:To"`
+	SoftwareID    string `xml:"sts:SoftwareProvider>sts:SoftwareID"`
+	SecurityCode  string `xml:"sts:SoftwareSecurityCode"`
+}
+
+// kind tipo de documento según el elemento raíz
+func (d *document) kind() (rules.Document, error) {
+	switch d.XMLName.Local {
+	case "Invoice":
+		// El documento soporte también es un Invoice (tipo 05, CUDS)
+		if d.InvoiceTypeCode == "05" || strings.HasPrefix(d.UUID.SchemeName, "CUDS") {
+			return rules.SupportDocument, nil
+		}
+		return rules.Invoice, nil
+	case "CreditNote":
+		return rules.CreditNote, nil
+	case "DebitNote":
+		return rules.DebitNote, nil
+	}
+	return "", fmt.Errorf("%w: unsupported root element %q", ErrUnsupported, d.XMLName.Local)
+}
+
+// lines líneas del documento
+func (d *document) lines() []line {
+	switch {
+	case len(d.CreditNoteLines) > 0:
+		return d.CreditNoteLines
+	case len(d.DebitNoteLines) > 0:
+		return d.DebitNoteLines
+	}
+	return d.InvoiceLines
+}
+
+// totals LegalMonetaryTotal o RequestedMonetaryTotal (nota débito)
+func (d *document) totals() *monetaryTotal {
+	if d.RequestedTotal != nil {
+		return d.RequestedTotal
+	}
+	return d.LegalTotal
+}
+
+// parse lee el XML del documento y sus extensiones DIAN
+func parse(data []byte) (*document, error) {
+	var doc document
+	if err := xmlpkg.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse document: %w", err)
+	}
+
+	element, err := xmlpkg.ExtractElement(data, xmlpkg.ByName(nsSTS, "DianExtensions"))
+	switch {
+	case errors.Is(err, xmlpkg.ErrElementNotFound):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read DianExtensions: %w", err)
+	default:
+		var ext extensions
+		if err := xmlpkg.Unmarshal(element, &ext); err != nil {
+			return nil, fmt.Errorf("failed to parse DianExtensions: %w", err)
+		}
+		doc.extensions = &ext
+	}
+	return &doc, nil
+}
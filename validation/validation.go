@@ -0,0 +1,186 @@
+// Package validation aplica localmente reglas del Anexo Técnico de DIAN
+//
+// Validate revisa el XML de una factura, nota crédito, nota débito o
+// documento soporte antes de enviarlo y reporta los hallazgos con los mismos
+// códigos de regla que usaría DIAN (FAD06, CAU06, DSAB05b, ...), de modo que
+// se pueden consultar en el catálogo de soap/rules:
+//
+//	report, err := validation.Validate(xmlBytes, validation.Options{
+//		TechnicalKey: claveTecnica, // CUFE
+//		SoftwarePIN:  "12345",      // CUDE, CUDS y código de seguridad
+//	})
+//	for _, f := range report.Rejections() {
+//		fmt.Println(f) // FAU06 Rechazo: Valor a pagar no corresponde (...)
+//	}
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/documents/creditnote"
+	"github.com/diegofxm/ubl21-dian/documents/debitnote"
+	"github.com/diegofxm/ubl21-dian/documents/invoice"
+	"github.com/diegofxm/ubl21-dian/documents/supportdocument"
+	"github.com/diegofxm/ubl21-dian/soap/rules"
+)
+
+var (
+	// ErrUnsupported el XML no es una factura, nota o documento soporte
+	ErrUnsupported = errors.New("unsupported document")
+	// ErrInvalid el documento tiene hallazgos de rechazo
+	ErrInvalid = errors.New("document does not pass DIAN validation rules")
+)
+
+// DefaultTolerance diferencia máxima aceptada al comparar valores
+const DefaultTolerance = 0.01
+
+// Options datos externos al XML que necesitan algunas reglas
+type Options struct {
+	TechnicalKey string           // Clave técnica del rango (CUFE); sin ella no se verifica FAD06
+	SoftwarePIN  string           // PIN del software (CUDE/CUDS y SoftwareSecurityCode); sin él no se verifican
+	Tolerance    float64          // DefaultTolerance si es 0
+	Now          func() time.Time // Fecha de recepción para FAD09e (time.Now por defecto)
+	Schema       bool             // Valida también contra los XSD de UBL 2.1 y DIAN (ZB01 por cada error)
+}
+
+// Finding hallazgo de una regla
+type Finding struct {
+	Code     string
+	Severity rules.Severity
+	Path     string // Elemento del documento que origina el hallazgo
+	Message  string
+	Rule     rules.Rule // Entrada del catálogo (descripción y sugerencia)
+}
+
+// IsFatal indica que DIAN rechazaría el documento
+func (f Finding) IsFatal() bool {
+	return f.Severity == rules.Rejection
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s %s: %s (%s)", f.Code, f.Severity, f.Message, f.Path)
+}
+
+// Report resultado de la validación
+type Report struct {
+	Document rules.Document
+	Number   string
+	Findings []Finding
+}
+
+// Valid indica que no hay hallazgos de rechazo (puede haber notificaciones)
+func (r *Report) Valid() bool {
+	return len(r.Rejections()) == 0
+}
+
+// Rejections hallazgos que DIAN rechazaría
+func (r *Report) Rejections() []Finding {
+	return r.filter(rules.Rejection)
+}
+
+// Notifications hallazgos que DIAN aceptaría con observaciones
+func (r *Report) Notifications() []Finding {
+	return r.filter(rules.Notification)
+}
+
+// Has indica si el reporte contiene la regla
+func (r *Report) Has(code string) bool {
+	for _, f := range r.Findings {
+		if strings.EqualFold(f.Code, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// Err retorna ErrInvalid con los códigos de rechazo, o nil si es válido
+func (r *Report) Err() error {
+	rejections := r.Rejections()
+	if len(rejections) == 0 {
+		return nil
+	}
+	codes := make([]string, len(rejections))
+	for i, f := range rejections {
+		codes[i] = f.Code
+	}
+	return fmt.Errorf("%w: %s %s: %s", ErrInvalid, r.Document, r.Number, strings.Join(codes, ", "))
+}
+
+func (r *Report) filter(severity rules.Severity) []Finding {
+	var list []Finding
+	for _, f := range r.Findings {
+		if f.Severity == severity {
+			list = append(list, f)
+		}
+	}
+	return list
+}
+
+// Validate aplica las reglas al XML (sin firmar o firmado) del documento
+// El error solo indica un XML ilegible o de tipo no soportado; las reglas
+// incumplidas se reportan en Report.Findings.
+func Validate(data []byte, opts Options) (*Report, error) {
+	if opts.Tolerance == 0 {
+		opts.Tolerance = DefaultTolerance
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	doc, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	kind, err := doc.kind()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &checker{doc: doc, kind: kind, opts: opts, report: &Report{Document: kind, Number: doc.ID}}
+	if opts.Schema {
+		if err := c.structure(data); err != nil {
+			return nil, err
+		}
+	}
+	c.run()
+	return c.report, nil
+}
+
+// ValidateInvoice genera el XML del builder y lo valida
+func ValidateInvoice(b *invoice.Builder, opts Options) (*Report, error) {
+	data, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return Validate(data, opts)
+}
+
+// ValidateCreditNote genera el XML del builder y lo valida
+func ValidateCreditNote(b *creditnote.Builder, opts Options) (*Report, error) {
+	data, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return Validate(data, opts)
+}
+
+// ValidateDebitNote genera el XML del builder y lo valida
+func ValidateDebitNote(b *debitnote.Builder, opts Options) (*Report, error) {
+	data, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return Validate(data, opts)
+}
+
+// ValidateSupportDocument genera el XML del builder y lo valida
+func ValidateSupportDocument(b *supportdocument.Builder, opts Options) (*Report, error) {
+	data, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return Validate([]byte(data), opts)
+}
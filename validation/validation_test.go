@@ -0,0 +1,370 @@
+package validation_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/diegofxm/ubl21-dian/documents/common/types"
+	"github.com/diegofxm/ubl21-dian/documents/creditnote"
+	"github.com/diegofxm/ubl21-dian/documents/debitnote"
+	"github.com/diegofxm/ubl21-dian/documents/invoice"
+	"github.com/diegofxm/ubl21-dian/documents/supportdocument"
+	"github.com/diegofxm/ubl21-dian/internal/testutil"
+	"github.com/diegofxm/ubl21-dian/signature"
+	"github.com/diegofxm/ubl21-dian/soap/rules"
+	"github.com/diegofxm/ubl21-dian/validation"
+	xmlpkg "github.com/diegofxm/ubl21-dian/xml"
+)
+
+var options = validation.Options{
+	TechnicalKey: testutil.TechnicalKey,
+	SoftwarePIN:  testutil.SoftwarePIN,
+	Now:          func() time.Time { return time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC) },
+}
+
+// party parte de testutil con dígito de verificación dv
+func party(name, nit, dv string) invoice.PartyTemplateData {
+	p := testutil.Party(name, nit)
+	p.TaxScheme.CompanyIDSchemeID = dv
+	p.LegalEntity.CompanyIDSchemeID = dv
+	return p
+}
+
+// newInvoice factura con CUFE y código de seguridad correctos; edit permite alterarla
+func newInvoice(number string, edit func(b *invoice.Builder)) *invoice.Builder {
+	tax := invoice.TaxSubtotalTemplateData{
+		TaxableAmount: "100000.00", TaxAmount: "19000.00", CurrencyID: "COP", Percent: "19.00",
+		TaxCategory: invoice.TaxCategoryTemplateData{Percent: "19.00", TaxScheme: invoice.TaxSchemeTemplateData{ID: "01", Name: "IVA"}},
+	}
+	b := invoice.NewBuilder().
+		SetProfileExecutionID("2").
+		SetSupplier(party("MI EMPRESA SAS", "900123456", "8")).
+		SetCustomer(party("CLIENTE SAS", "800111222", "7")).
+		SetPaymentMeans("1", "10", "2025-06-01").
+		SetMonetaryTotals("100000.00", "100000.00", "119000.00", "", "119000.00").
+		AddTaxTotal(invoice.TaxTotalTemplateData{TaxAmount: "19000.00", CurrencyID: "COP", TaxSubtotals: []invoice.TaxSubtotalTemplateData{tax}}).
+		AddInvoiceLine(invoice.InvoiceLineTemplateData{
+			ID: "1", UnitCode: "94", Quantity: "1.000000", LineExtensionAmount: "100000.00", FreeOfChargeIndicator: "false", CurrencyID: "COP",
+			Item:  invoice.ItemTemplateData{Description: "Servicio", StandardItemID: invoice.ItemIDTemplateData{ID: "P001", SchemeID: "999"}},
+			Price: invoice.PriceTemplateData{Amount: "100000.00", BaseQuantity: "1.000000"},
+		})
+	if edit != nil {
+		edit(b)
+	}
+
+	d := b.GetData()
+	cufe := signature.CalculateCUFE(number, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), "10:00:00-05:00",
+		100000, 19000, 0, 0, 119000, "900123456", "800111222", testutil.TechnicalKey, "2")
+	b.SetInvoiceData(number, cufe, "2025-06-01", "10:00:00-05:00", "2025-06-01")
+	if d.InvoiceAuthorization == "" {
+		b.SetDianExtensions("18760000001", "2019-01-19", "2030-01-19", "SETP", "990000000", "995000000",
+			"900123456", "8", "31", testutil.SoftwareID, signature.CalculateSoftwareSecurityCode(testutil.SoftwareID, testutil.SoftwarePIN, number), "")
+	}
+	return b
+}
+
+func TestValidateInvoice(t *testing.T) {
+	report, err := validation.ValidateInvoice(newInvoice("SETP990000001", nil), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Document != rules.Invoice || report.Number != "SETP990000001" {
+		t.Errorf("Unexpected report header: %s %s", report.Document, report.Number)
+	}
+	if len(report.Findings) != 0 || report.Err() != nil {
+		t.Errorf("Expected no findings, got %v", report.Findings)
+	}
+}
+
+func TestValidateInvoiceFindings(t *testing.T) {
+	b := newInvoice("SETP1", func(b *invoice.Builder) {
+		b.SetSupplier(party("MI EMPRESA SAS", "900123456", "8"))
+		b.SetCustomer(party("", "800111222", "7"))
+		b.SetMonetaryTotals("100000.00", "100000.00", "119000.00", "", "120000.00")
+		b.AddInvoiceLine(invoice.InvoiceLineTemplateData{
+			ID: "2", UnitCode: "94", Quantity: "2.000000", LineExtensionAmount: "50.00", FreeOfChargeIndicator: "false", CurrencyID: "USD",
+			Price: invoice.PriceTemplateData{Amount: "30.00", BaseQuantity: "1.000000"},
+		})
+	})
+
+	data, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// El builder rechaza un DV incorrecto; se altera el XML generado
+	data = []byte(strings.ReplaceAll(string(data), `<cbc:CompanyID schemeID="8"`, `<cbc:CompanyID schemeID="3"`))
+
+	report, err := validation.Validate(data, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// SETP1 fuera del rango 990000000-995000000; el CUFE se calculó con otros totales
+	for _, code := range []string{"FAB05b", "FAD06", "FAJ24", "FAK07", "FAU01", "FAU02", "FAU06", "FAX07"} {
+		if !report.Has(code) {
+			t.Errorf("Expected finding %s", code)
+		}
+	}
+	if report.Has("FAV02") || report.Has("FAS07") {
+		t.Errorf("Unexpected findings: %v", report.Findings)
+	}
+	if report.Valid() || report.Err() == nil {
+		t.Error("Expected invalid report")
+	}
+
+	for _, f := range report.Findings {
+		if f.Code == "FAU06" && (f.Rule.Category != rules.CategoryTotals || !f.IsFatal() || !strings.Contains(f.Path, "PayableAmount")) {
+			t.Errorf("Unexpected FAU06 finding: %+v", f)
+		}
+	}
+}
+
+func TestValidateCreditNote(t *testing.T) {
+	supplier := party("MI EMPRESA SAS", "900123456", "8")
+	customer := party("CLIENTE SAS", "800111222", "7")
+	b := creditnote.NewBuilder().
+		SetProfileExecutionID("2").
+		SetCreditNoteData("NC1", "no-calculado", "2025-06-02", "10:00:00-05:00").
+		SetNote("Anulación").
+		SetDianExtensions("18760000001", "2019-01-19", "2030-01-19", "NC", "1", "1000",
+			"900123456", "8", "31", testutil.SoftwareID, "", "").
+		SetBillingReference("SETP990000001", "abc", "2025-06-01").
+		SetSupplier(creditnote.PartyTemplateData{AdditionalAccountID: "1", PartyName: supplier.PartyName, TaxScheme: creditnote.TaxSchemeTemplateData(supplier.TaxScheme)}).
+		SetCustomer(creditnote.PartyTemplateData{AdditionalAccountID: "1", PartyName: customer.PartyName, TaxScheme: creditnote.TaxSchemeTemplateData(customer.TaxScheme)}).
+		SetTotals("100000.00", "100000.00", "100000.00", "90000.00").
+		AddLine(creditnote.CreditNoteLineTemplateData{
+			ID: "1", UnitCode: "94", Quantity: "1.000000", LineExtensionAmount: "100000.00", FreeOfChargeIndicator: "false",
+			Item:  creditnote.ItemTemplateData{Description: "Servicio", StandardItemID: creditnote.ItemIDTemplateData{ID: "P001", SchemeID: "999"}},
+			Price: creditnote.PriceTemplateData{Amount: "100000.00", BaseQuantity: "1.000000"},
+		})
+
+	report, err := validation.ValidateCreditNote(b, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Document != rules.CreditNote || !report.Has("CAD06") || !report.Has("CAU06") {
+		t.Errorf("Expected CAD06 and CAU06, got %v", report.Findings)
+	}
+	// Las notas no tienen rango de numeración propio
+	if report.Has("CAB05b") {
+		t.Errorf("Unexpected numbering finding: %v", report.Findings)
+	}
+}
+
+func TestValidateSupportDocument(t *testing.T) {
+	doc := `<?xml version="1.0" encoding="UTF-8"?>
+<Invoice xmlns="urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"
+  xmlns:cac="urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"
+  xmlns:cbc="urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2"
+  xmlns:ext="urn:oasis:names:specification:ubl:schema:xsd:CommonExtensionComponents-2"
+  xmlns:sts="dian:gov:co:facturaelectronica:Structures-2-1">
+  <ext:UBLExtensions><ext:UBLExtension><ext:ExtensionContent><sts:DianExtensions>
+    <sts:InvoiceControl>
+      <sts:InvoiceAuthorization>18760000002</sts:InvoiceAuthorization>
+      <sts:AuthorizationPeriod><cbc:StartDate>2025-01-01</cbc:StartDate><cbc:EndDate>2025-03-31</cbc:EndDate></sts:AuthorizationPeriod>
+      <sts:AuthorizedInvoices><sts:Prefix>DS</sts:Prefix><sts:From>1</sts:From><sts:To>100</sts:To></sts:AuthorizedInvoices>
+    </sts:InvoiceControl>
+  </sts:DianExtensions></ext:ExtensionContent></ext:UBLExtension></ext:UBLExtensions>
+  <cbc:UBLVersionID>UBL 2.1</cbc:UBLVersionID>
+  <cbc:ProfileExecutionID>2</cbc:ProfileExecutionID>
+  <cbc:ID>DS101</cbc:ID>
+  <cbc:UUID schemeName="CUDS-SHA384">abc</cbc:UUID>
+  <cbc:IssueDate>2025-05-01</cbc:IssueDate>
+  <cbc:IssueTime>10:00:00-05:00</cbc:IssueTime>
+  <cbc:InvoiceTypeCode>05</cbc:InvoiceTypeCode>
+  <cbc:DocumentCurrencyCode>COP</cbc:DocumentCurrencyCode>
+  <cbc:LineCountNumeric>1</cbc:LineCountNumeric>
+  <cac:AccountingSupplierParty><cac:Party><cac:PartyTaxScheme>
+    <cbc:RegistrationName>MI EMPRESA SAS</cbc:RegistrationName>
+    <cbc:CompanyID schemeID="8" schemeName="31">900123456</cbc:CompanyID>
+  </cac:PartyTaxScheme></cac:Party></cac:AccountingSupplierParty>
+  <cac:AccountingCustomerParty><cac:Party><cac:PartyTaxScheme>
+    <cbc:RegistrationName>VENDEDOR</cbc:RegistrationName>
+    <cbc:CompanyID schemeName="13">1020304050</cbc:CompanyID>
+  </cac:PartyTaxScheme></cac:Party></cac:AccountingCustomerParty>
+  <cac:LegalMonetaryTotal>
+    <cbc:LineExtensionAmount currencyID="COP">5000.00</cbc:LineExtensionAmount>
+    <cbc:TaxExclusiveAmount currencyID="COP">0.00</cbc:TaxExclusiveAmount>
+    <cbc:TaxInclusiveAmount currencyID="COP">5000.00</cbc:TaxInclusiveAmount>
+    <cbc:PayableAmount currencyID="COP">5000.00</cbc:PayableAmount>
+  </cac:LegalMonetaryTotal>
+  <cac:InvoiceLine>
+    <cbc:ID>1</cbc:ID>
+    <cbc:InvoicedQuantity unitCode="94">1</cbc:InvoicedQuantity>
+    <cbc:LineExtensionAmount currencyID="COP">5000.00</cbc:LineExtensionAmount>
+    <cac:Price><cbc:PriceAmount currencyID="COP">5000.00</cbc:PriceAmount></cac:Price>
+  </cac:InvoiceLine>
+</Invoice>`
+
+	report, err := validation.Validate([]byte(doc), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Document != rules.SupportDocument {
+		t.Fatalf("Expected support document, got %s", report.Document)
+	}
+	for _, code := range []string{"DSAB05b", "DSAB07b", "DSAD06", "DSAU04"} {
+		if !report.Has(code) {
+			t.Errorf("Expected finding %s, got %v", code, report.Findings)
+		}
+	}
+
+	if _, err := validation.Validate([]byte(`<ApplicationResponse/>`), options); err == nil {
+		t.Error("Expected error for unsupported document")
+	}
+}
+
+func TestValidateSchema(t *testing.T) {
+	opts := options
+	opts.Schema = true
+
+	report, err := validation.ValidateInvoice(newInvoice("SETP990000001", nil), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Has("ZB01") {
+		t.Errorf("Unexpected schema findings: %v", report.Findings)
+	}
+
+	// Línea sin FreeOfChargeIndicator (xs:boolean vacío)
+	b := newInvoice("SETP990000001", func(b *invoice.Builder) {
+		b.AddInvoiceLine(invoice.InvoiceLineTemplateData{
+			ID: "2", UnitCode: "94", Quantity: "1.000000", LineExtensionAmount: "0.00", CurrencyID: "COP",
+			Price: invoice.PriceTemplateData{Amount: "0.00", BaseQuantity: "1.000000"},
+		})
+	})
+	report, err = validation.ValidateInvoice(b, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Has("ZB01") || report.Valid() {
+		t.Fatalf("Expected ZB01, got %v", report.Findings)
+	}
+	for _, f := range report.Findings {
+		if f.Code == "ZB01" && (!strings.HasPrefix(f.Path, "line ") || !strings.Contains(f.Message, "FreeOfChargeIndicator")) {
+			t.Errorf("Unexpected schema finding: %+v", f)
+		}
+	}
+}
+
+// TestValidateSchemaDocuments la salida de cada builder cumple los esquemas embebidos
+func TestValidateSchemaDocuments(t *testing.T) {
+	opts := options
+	opts.Schema = true
+
+	supplier := party("MI EMPRESA SAS", "900123456", "8")
+	customer := party("CLIENTE SAS", "800111222", "7")
+	tax := invoice.TaxSubtotalTemplateData{
+		TaxableAmount: "100000.00", TaxAmount: "19000.00", CurrencyID: "COP", Percent: "19.00",
+		TaxCategory: invoice.TaxCategoryTemplateData{Percent: "19.00", TaxScheme: invoice.TaxSchemeTemplateData{ID: "01", Name: "IVA"}},
+	}
+
+	creditNote := creditnote.NewBuilder().
+		SetProfileExecutionID("2").
+		SetCreditNoteData("NC1", "cude123", "2025-06-02", "10:00:00-05:00").
+		SetNote("Anulación").
+		SetDianExtensions("18760000001", "2019-01-19", "2030-01-19", "NC", "1", "1000",
+			"900123456", "8", "31", testutil.SoftwareID, "", "").
+		SetBillingReference("SETP990000001", "cufe123", "2025-06-01").
+		SetSupplier(creditnote.PartyTemplateData{AdditionalAccountID: "1", PartyName: supplier.PartyName, TaxScheme: creditnote.TaxSchemeTemplateData(supplier.TaxScheme)}).
+		SetCustomer(creditnote.PartyTemplateData{AdditionalAccountID: "1", PartyName: customer.PartyName, TaxScheme: creditnote.TaxSchemeTemplateData(customer.TaxScheme)}).
+		SetTotals("100000.00", "100000.00", "119000.00", "119000.00").
+		AddTaxTotal(creditnote.TaxTotalTemplateData{TaxAmount: "19000.00", CurrencyID: "COP", TaxSubtotals: []creditnote.TaxSubtotalTemplateData{{
+			TaxableAmount: "100000.00", TaxAmount: "19000.00", CurrencyID: "COP", Percent: "19.00",
+			TaxCategory: creditnote.TaxCategoryTemplateData{Percent: "19.00", TaxScheme: creditnote.TaxSchemeTemplateData{ID: "01", Name: "IVA"}},
+		}}}).
+		AddLine(creditnote.CreditNoteLineTemplateData{
+			ID: "1", UnitCode: "94", Quantity: "1.000000", LineExtensionAmount: "100000.00", FreeOfChargeIndicator: "false", CurrencyID: "COP",
+			Item:  creditnote.ItemTemplateData{Description: "Servicio", StandardItemID: creditnote.ItemIDTemplateData{ID: "P001", SchemeID: "999"}},
+			Price: creditnote.PriceTemplateData{Amount: "100000.00", BaseQuantity: "1.000000"},
+		})
+
+	debitNote := debitnote.NewBuilder().
+		SetProfileExecutionID("2").
+		SetDebitNoteData("ND1", "cude456", "2025-06-03", "10:00:00-05:00").
+		SetNote("Intereses").
+		SetDianExtensions("18760000001", "2019-01-19", "2030-01-19", "ND", "1", "1000",
+			"900123456", "8", "31", testutil.SoftwareID, "", "").
+		SetBillingReference("SETP990000001", "cufe123", "2025-06-01").
+		SetSupplier(debitnote.PartyTemplateData{AdditionalAccountID: "1", PartyName: supplier.PartyName, TaxScheme: debitnote.TaxSchemeTemplateData(supplier.TaxScheme)}).
+		SetCustomer(debitnote.PartyTemplateData{AdditionalAccountID: "1", PartyName: customer.PartyName, TaxScheme: debitnote.TaxSchemeTemplateData(customer.TaxScheme)}).
+		SetTotals("5000.00", "5000.00", "5000.00", "5000.00").
+		AddLine(debitnote.DebitNoteLineTemplateData{
+			ID: "1", UnitCode: "94", Quantity: "1.000000", LineExtensionAmount: "5000.00", FreeOfChargeIndicator: "false", CurrencyID: "COP",
+			Item:  debitnote.ItemTemplateData{Description: "Intereses", StandardItemID: debitnote.ItemIDTemplateData{ID: "P002", SchemeID: "999"}},
+			Price: debitnote.PriceTemplateData{Amount: "5000.00", BaseQuantity: "1.000000"},
+		})
+
+	sdParty := func(name, id, documentType string) supportdocument.PartyTemplateData {
+		return supportdocument.PartyTemplateData{
+			PersonType: "2", ID: id, DocumentType: documentType, Name: name, TaxLevelCode: "R-99-PN", TaxSchemeID: "ZZ", TaxSchemeName: "No aplica",
+			Address: supportdocument.AddressTemplateData{ID: "11001", CityName: "Bogotá", CountrySubentity: "Bogotá", CountrySubentityCode: "11",
+				AddressLine: "Calle 1 # 2-3", CountryCode: "CO", CountryName: "Colombia"},
+			Contact: supportdocument.ContactTemplateData{Email: "compras@example.com"},
+		}
+	}
+	supportDocument := supportdocument.NewBuilder().
+		SetProfileExecutionID("2").
+		SetSupportDocumentData("DS1", "cuds123", "2025-06-04", "10:00:00-05:00").
+		AddNote("Compra a no obligado").
+		SetDianExtensions("18760000002", "2025-01-01", "2025-12-31", "DS", "1", "1000",
+			"900123456", "8", "31", testutil.SoftwareID, "", "").
+		SetBuyer(sdParty("MI EMPRESA SAS", "900123456", "31")).
+		SetSupplier(sdParty("VENDEDOR", "1020304050", "13")).
+		SetTotals("50000.00", "0.00", "50000.00", "50000.00").
+		AddLine(supportdocument.SupportDocumentLineTemplateData{
+			ID: "1", UnitCode: "94", Quantity: "1.000000", LineExtensionAmount: "50000.00", FreeOfChargeIndicator: "false", CurrencyID: "COP",
+			Item:  supportdocument.ItemTemplateData{Description: "Asesoría", StandardItemID: supportdocument.ItemIDTemplateData{ID: "P003", SchemeID: "999"}},
+			Price: supportdocument.PriceTemplateData{Amount: "50000.00", BaseQuantity: "1.000000"},
+		})
+
+	// Factura con términos de pago (cac:PaymentTerms del modelo) y tasa de cambio
+	built, err := newInvoice("SETP990000001", func(b *invoice.Builder) {
+		b.AddTaxTotal(invoice.TaxTotalTemplateData{TaxAmount: "0.00", CurrencyID: "COP", TaxSubtotals: []invoice.TaxSubtotalTemplateData{tax}})
+	}).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	model, err := invoice.ParseFromXML(built)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model.PaymentTerms = []types.PaymentTermsXML{{
+		ReferenceEventCode: &types.CBCElement{Value: "2"},
+		SettlementPeriod:   &types.SettlementPeriodXML{DurationMeasure: types.DurationMeasureElement{UnitCode: "DAY", Value: "30"}},
+	}}
+	withTerms, err := xmlpkg.Marshal(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withTerms = bytes.Replace(withTerms, []byte("<cac:TaxTotal>"), []byte(`<cac:PaymentExchangeRate>
+    <cbc:SourceCurrencyCode>COP</cbc:SourceCurrencyCode><cbc:SourceCurrencyBaseRate>1.00</cbc:SourceCurrencyBaseRate>
+    <cbc:TargetCurrencyCode>COP</cbc:TargetCurrencyCode><cbc:TargetCurrencyBaseRate>1.00</cbc:TargetCurrencyBaseRate>
+    <cbc:CalculationRate>1.00</cbc:CalculationRate><cbc:Date>2025-06-01</cbc:Date>
+  </cac:PaymentExchangeRate><cac:TaxTotal>`), 1)
+
+	tests := []struct {
+		name     string
+		validate func() (*validation.Report, error)
+	}{
+		{"credit note", func() (*validation.Report, error) { return validation.ValidateCreditNote(creditNote, opts) }},
+		{"debit note", func() (*validation.Report, error) { return validation.ValidateDebitNote(debitNote, opts) }},
+		{"support document", func() (*validation.Report, error) { return validation.ValidateSupportDocument(supportDocument, opts) }},
+		{"payment terms", func() (*validation.Report, error) { return validation.Validate(withTerms, opts) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := tt.validate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, f := range report.Findings {
+				if f.Code == "ZB01" {
+					t.Errorf("Unexpected schema finding: %+v", f)
+				}
+			}
+		})
+	}
+}
@@ -17,9 +17,9 @@ import (
 // Usa libxml2 (la misma librería que usa PHP DOMDocument::C14N)
 // Como lo requiere el Anexo Técnico de DIAN sección 10.7
 func Canonicalize(xmlData []byte) ([]byte, error) {
-	// Inicializar libxml2 parser
+	// Inicializar libxml2 parser (sin xmlCleanupParser: libera el estado global
+	// que usan otros hilos y el esquema de ValidateSchema)
 	C.xmlInitParser()
-	defer C.xmlCleanupParser()
 
 	// Parse XML con libxml2
 	cXML := C.CString(string(xmlData))
@@ -62,9 +62,9 @@ func Canonicalize(xmlData []byte) ([]byte, error) {
 // CanonicalizeExclusive canonicaliza XML según Exclusive C14N (xml-exc-c14n#)
 // Usado para SOAP Security Headers según WS-Security
 func CanonicalizeExclusive(xmlData []byte, inclusiveNamespaces []string) ([]byte, error) {
-	// Inicializar libxml2 parser
+	// Inicializar libxml2 parser (sin xmlCleanupParser: libera el estado global
+	// que usan otros hilos y el esquema de ValidateSchema)
 	C.xmlInitParser()
-	defer C.xmlCleanupParser()
 
 	// Parse XML con libxml2
 	cXML := C.CString(string(xmlData))
@@ -6,4 +6,7 @@ var (
 	ErrTemplateNotFound = errors.New("template not found")
 	ErrInvalidTemplate  = errors.New("invalid template")
 	ErrRenderFailed     = errors.New("render failed")
+	ErrElementNotFound  = errors.New("element not found")
+	ErrSchemaInvalid    = errors.New("document does not conform to UBL 2.1 schema")
+	ErrUnboundPrefix    = errors.New("namespace prefix not declared")
 )
@@ -0,0 +1,173 @@
+package xml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// ElementMatcher decide si un elemento es el buscado
+// space es el namespace URI ya resuelto y attrs los atributos tal como vienen
+type ElementMatcher func(space, local string, attrs []xml.Attr) bool
+
+// ByName retorna un matcher por namespace URI y nombre local
+func ByName(space, local string) ElementMatcher {
+	return func(s, l string, _ []xml.Attr) bool {
+		return s == space && l == local
+	}
+}
+
+// ByID retorna un matcher por atributo Id (wsu:Id, Id o ID), como en URI="#id"
+func ByID(id string) ElementMatcher {
+	return func(_, _ string, attrs []xml.Attr) bool {
+		for _, attr := range attrs {
+			switch attr.Name.Local {
+			case "Id", "ID", "id":
+				if attr.Value == id {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+// ExtractElement extrae los bytes originales del primer elemento que cumpla match
+//
+// Los namespaces declarados en ancestros se agregan al elemento extraído para
+// que pueda canonicalizarse de forma aislada (C14N sobre un subárbol).
+func ExtractElement(data []byte, match ElementMatcher) ([]byte, error) {
+	start, end, found, err := findElement(data, match)
+	if err != nil {
+		return nil, err
+	}
+	return injectNamespaces(data[start:end], found.start, found.scope, found.declared), nil
+}
+
+// FindElement posición del primer elemento que cumpla match: data[start:end]
+// son sus bytes originales, sin los namespaces heredados
+func FindElement(data []byte, match ElementMatcher) (start, end int, err error) {
+	start, end, _, err = findElement(data, match)
+	return start, end, err
+}
+
+// foundElement tag de apertura y namespaces del elemento encontrado
+type foundElement struct {
+	start           xml.StartElement
+	scope, declared map[string]string
+}
+
+func findElement(data []byte, match ElementMatcher) (int, int, foundElement, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	// Pila de scopes: prefijo -> URI ("" = namespace por defecto)
+	scopes := []map[string]string{{}}
+
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			return 0, 0, foundElement{}, ErrElementNotFound
+		}
+		if err != nil {
+			return 0, 0, foundElement{}, fmt.Errorf("failed to read XML: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			declared := declaredNamespaces(t)
+			scope := make(map[string]string, len(scopes[len(scopes)-1])+len(declared))
+			for prefix, uri := range scopes[len(scopes)-1] {
+				scope[prefix] = uri
+			}
+			for prefix, uri := range declared {
+				scope[prefix] = uri
+			}
+
+			if match(scope[t.Name.Space], t.Name.Local, t.Attr) {
+				if err := skipElement(decoder); err != nil {
+					return 0, 0, foundElement{}, fmt.Errorf("failed to read element %s: %w", t.Name.Local, err)
+				}
+				return int(offset), int(decoder.InputOffset()), foundElement{t, scope, declared}, nil
+			}
+
+			scopes = append(scopes, scope)
+
+		case xml.EndElement:
+			if len(scopes) > 1 {
+				scopes = scopes[:len(scopes)-1]
+			}
+		}
+	}
+}
+
+// skipElement consume tokens hasta el cierre del elemento actual
+// (Decoder.Skip no sirve aquí porque mezcla Token con RawToken)
+func skipElement(decoder *xml.Decoder) error {
+	for depth := 1; depth > 0; {
+		token, err := decoder.RawToken()
+		if err != nil {
+			return err
+		}
+		switch token.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+	}
+	return nil
+}
+
+// declaredNamespaces retorna las declaraciones xmlns presentes en el elemento
+func declaredNamespaces(start xml.StartElement) map[string]string {
+	declared := map[string]string{}
+	for _, attr := range start.Attr {
+		switch {
+		case attr.Name.Space == "xmlns":
+			declared[attr.Name.Local] = attr.Value
+		case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+			declared[""] = attr.Value
+		}
+	}
+	return declared
+}
+
+// injectNamespaces agrega al tag de apertura los namespaces heredados que no declara
+func injectNamespaces(element []byte, start xml.StartElement, scope, declared map[string]string) []byte {
+	prefixes := make([]string, 0, len(scope))
+	for prefix := range scope {
+		if _, ok := declared[prefix]; !ok {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	if len(prefixes) == 0 {
+		return element
+	}
+	sort.Strings(prefixes)
+
+	var decls bytes.Buffer
+	for _, prefix := range prefixes {
+		if prefix == "" {
+			decls.WriteString(` xmlns="`)
+		} else {
+			decls.WriteString(` xmlns:` + prefix + `="`)
+		}
+		xml.EscapeText(&decls, []byte(scope[prefix]))
+		decls.WriteString(`"`)
+	}
+
+	// Posición justo después del nombre del elemento: "<prefix:local"
+	nameEnd := 1 + len(start.Name.Local)
+	if start.Name.Space != "" {
+		nameEnd += len(start.Name.Space) + 1
+	}
+
+	result := make([]byte, 0, len(element)+decls.Len())
+	result = append(result, element[:nameEnd]...)
+	result = append(result, decls.Bytes()...)
+	result = append(result, element[nameEnd:]...)
+	return result
+}
@@ -1,7 +1,10 @@
 package xml
 
 import (
+	"bytes"
 	"encoding/xml"
+	"fmt"
+	"strings"
 )
 
 // Marshal serializa una estructura a XML sin indentación
@@ -26,3 +29,212 @@ func Marshal(v interface{}) ([]byte, error) {
 func MarshalNoHeader(v interface{}) ([]byte, error) {
 	return xml.Marshal(v)
 }
+
+// Namespaces prefijo canónico de cada namespace de UBL 2.1 y DIAN ("" para
+// los documentos raíz)
+var Namespaces = map[string]string{
+	"urn:oasis:names:specification:ubl:schema:xsd:Invoice-2":                   "",
+	"urn:oasis:names:specification:ubl:schema:xsd:CreditNote-2":                "",
+	"urn:oasis:names:specification:ubl:schema:xsd:DebitNote-2":                 "",
+	"urn:oasis:names:specification:ubl:schema:xsd:ApplicationResponse-2":       "",
+	"urn:oasis:names:specification:ubl:schema:xsd:AttachedDocument-2":          "",
+	"urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2": "cac",
+	"urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2":     "cbc",
+	"urn:oasis:names:specification:ubl:schema:xsd:CommonExtensionComponents-2": "ext",
+	"urn:un:unece:uncefact:data:specification:CoreComponentTypeSchemaModule:2": "ccts",
+	"dian:gov:co:facturaelectronica:Structures-2-1":                            "sts",
+	"http://www.w3.org/2000/09/xmldsig#":                                       "ds",
+	"http://uri.etsi.org/01903/v1.3.2#":                                        "xades",
+	"http://uri.etsi.org/01903/v1.4.1#":                                        "xades141",
+	"http://www.w3.org/2001/XMLSchema-instance":                                "xsi",
+}
+
+// Unmarshal deserializa XML en los modelos del proyecto (tags con prefijo, ej: "cbc:ID")
+//
+// encoding/xml resuelve los prefijos a namespaces URI, por lo que los tags
+// prefijados nunca coinciden. Aquí cada elemento y atributo llega con el
+// prefijo canónico de su namespace (Namespaces), sin importar el que use el
+// documento: <b:ID xmlns:b="...CommonBasicComponents-2"> se lee como "cbc:ID".
+// Los elementos y atributos de namespaces desconocidos llegan como
+// "{uri}local", y un prefijo sin declarar es ErrUnboundPrefix.
+//
+// Con este decoder los campos ",innerxml" quedan vacíos; los tipos que
+// necesiten el XML interno deben implementar xml.Unmarshaler con InnerXML.
+func Unmarshal(data []byte, v interface{}) error {
+	reader := &qualifiedReader{decoder: xml.NewDecoder(bytes.NewReader(data))}
+	return xml.NewTokenDecoder(reader).Decode(v)
+}
+
+// InnerXML contenido de start como XML, para usar en UnmarshalXML con el
+// decoder de Unmarshal (nombres con prefijo canónico). Los nombres
+// "{uri}local" de namespaces desconocidos vuelven al prefijo que los declara
+// dentro del contenido o, si se declaró afuera, a un xmlns propio
+func InnerXML(d *xml.Decoder, start xml.StartElement) (string, error) {
+	var b bytes.Buffer
+	encoder := xml.NewEncoder(&b)
+	var scopes []map[string]string // Declaraciones URI -> prefijo de cada elemento abierto
+	for depth := 0; ; {
+		token, err := d.Token()
+		if err != nil {
+			return "", err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			var scope map[string]string
+			for _, attr := range t.Attr {
+				if prefix, ok := strings.CutPrefix(attr.Name.Local, "xmlns:"); ok {
+					if scope == nil {
+						scope = map[string]string{}
+					}
+					scope[attr.Value] = prefix
+				}
+			}
+			scopes = append(scopes, scope)
+			t.Name = unqualify(t.Name.Local, scopes) // Namespace por defecto del documento; el prefijo ya va en Local
+			attrs := make([]xml.Attr, len(t.Attr))
+			for i, attr := range t.Attr {
+				attrs[i] = xml.Attr{Name: unqualify(attr.Name.Local, scopes), Value: attr.Value}
+			}
+			t.Attr = attrs
+			token = t
+		case xml.EndElement:
+			if depth == 0 {
+				if err := encoder.Flush(); err != nil {
+					return "", err
+				}
+				return b.String(), nil
+			}
+			depth--
+			t.Name = unqualify(t.Name.Local, scopes)
+			scopes = scopes[:len(scopes)-1]
+			token = t
+		case xml.ProcInst:
+			continue
+		}
+		if err := encoder.EncodeToken(token); err != nil {
+			return "", err
+		}
+	}
+}
+
+// unqualify nombre para el encoder: "prefijo:local" tal cual y "{uri}local"
+// con el prefijo declarado para uri en scopes o, sin declaración, con Space
+func unqualify(local string, scopes []map[string]string) xml.Name {
+	uri, name, ok := strings.Cut(strings.TrimPrefix(local, "{"), "}")
+	if !strings.HasPrefix(local, "{") || !ok {
+		return xml.Name{Local: local}
+	}
+	for i := len(scopes) - 1; i >= 0; i-- {
+		if prefix, declared := scopes[i][uri]; declared {
+			return xml.Name{Local: prefix + ":" + name}
+		}
+	}
+	return xml.Name{Space: uri, Local: name}
+}
+
+// qualifiedReader TokenReader que entrega nombres "prefijo:local" con el
+// prefijo canónico del namespace
+type qualifiedReader struct {
+	decoder *xml.Decoder
+	scopes  []map[string]string // Declaraciones prefijo -> URI de cada elemento abierto
+}
+
+// Token implementa xml.TokenReader
+func (r *qualifiedReader) Token() (xml.Token, error) {
+	token, err := r.decoder.RawToken()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := token.(type) {
+	case xml.StartElement:
+		var scope map[string]string
+		for _, attr := range t.Attr {
+			switch {
+			case attr.Name.Space == "xmlns":
+				if scope == nil {
+					scope = map[string]string{}
+				}
+				scope[attr.Name.Local] = attr.Value
+			case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+				if scope == nil {
+					scope = map[string]string{}
+				}
+				scope[""] = attr.Value
+			}
+		}
+		r.scopes = append(r.scopes, scope)
+
+		name, err := r.qualify(t.Name, true)
+		if err != nil {
+			return nil, err
+		}
+		start := xml.StartElement{Name: name, Attr: make([]xml.Attr, len(t.Attr))}
+		for i, attr := range t.Attr {
+			name, err := r.qualify(attr.Name, false)
+			if err != nil {
+				return nil, err
+			}
+			if attr.Name.Space == "xmlns" {
+				// Declaraciones con el prefijo canónico: el modelo las re-serializa
+				if prefix := Namespaces[attr.Value]; prefix != "" {
+					name = xml.Name{Local: "xmlns:" + prefix}
+				}
+			}
+			start.Attr[i] = xml.Attr{Name: name, Value: attr.Value}
+		}
+		return start, nil
+	case xml.EndElement:
+		name, err := r.qualify(t.Name, true)
+		if err != nil {
+			return nil, err
+		}
+		if len(r.scopes) > 0 {
+			r.scopes = r.scopes[:len(r.scopes)-1]
+		}
+		return xml.EndElement{Name: name}, nil
+	}
+	return xml.CopyToken(token), nil
+}
+
+// qualify convierte {Space: prefijo, Local: nombre} en {Local: "prefijo:nombre"}
+// con el prefijo canónico del namespace al que resuelve el prefijo; el prefijo
+// del documento no se usa, y los namespaces desconocidos quedan como
+// "{uri}nombre". Los atributos sin prefijo y los elementos sin namespace
+// por defecto no tienen namespace
+func (r *qualifiedReader) qualify(name xml.Name, element bool) (xml.Name, error) {
+	switch {
+	case name.Space == "" && (!element || name.Local == "xmlns"):
+		return name, nil
+	case name.Space == "xmlns" || name.Space == "xml":
+		return xml.Name{Local: name.Space + ":" + name.Local}, nil
+	}
+	uri, ok := r.lookup(name.Space)
+	switch {
+	case !ok && name.Space == "":
+		return name, nil
+	case !ok:
+		return xml.Name{}, fmt.Errorf("%w: %s:%s", ErrUnboundPrefix, name.Space, name.Local)
+	case uri == "" && name.Space == "":
+		return xml.Name{Local: name.Local}, nil // xmlns="" anula el namespace por defecto
+	}
+	prefix, known := Namespaces[uri]
+	switch {
+	case !known:
+		return xml.Name{Local: "{" + uri + "}" + name.Local}, nil
+	case prefix == "":
+		return xml.Name{Local: name.Local}, nil
+	}
+	return xml.Name{Local: prefix + ":" + name.Local}, nil
+}
+
+// lookup namespace declarado para el prefijo en los elementos abiertos
+func (r *qualifiedReader) lookup(prefix string) (string, bool) {
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		if uri, ok := r.scopes[i][prefix]; ok {
+			return uri, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,72 @@
+package xml
+
+import (
+	"embed"
+	"fmt"
+	"strings"
+)
+
+// Esquemas UBL 2.1 (maindoc y common) y extensión DIAN (sts) embebidos
+//
+// No son los XSD oficiales sino una transcripción parcial: los documentos y
+// los agregados que usa DIAN tienen la secuencia completa de UBL 2.1 (sin
+// anotaciones); los agregados que DIAN no usa aceptan cualquier contenido.
+// schemas/README.md explica cómo reemplazarlos por los oficiales.
+//
+//go:embed schemas
+var schemaFS embed.FS
+
+// schemaRoot esquema de entrada que importa Invoice, CreditNote y DebitNote
+const schemaRoot = "schemas/UBL-DIAN-2.1.xsd"
+
+// SchemaError error de validación con su posición en el documento
+type SchemaError struct {
+	Line    int
+	Column  int // 0 si no se conoce
+	Message string
+}
+
+func (e SchemaError) Error() string {
+	if e.Column > 0 {
+		return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// SchemaErrors errores de validación del documento (errors.Is ErrSchemaInvalid)
+type SchemaErrors []SchemaError
+
+func (e SchemaErrors) Error() string {
+	if len(e) == 0 {
+		return ErrSchemaInvalid.Error()
+	}
+	msg := fmt.Sprintf("%v: %v", ErrSchemaInvalid, e[0])
+	if len(e) > 1 {
+		msg += fmt.Sprintf(" (and %d more)", len(e)-1)
+	}
+	return msg
+}
+
+// Is permite errors.Is(err, ErrSchemaInvalid)
+func (e SchemaErrors) Is(target error) bool {
+	return target == ErrSchemaInvalid
+}
+
+// shortenNames reemplaza "{namespace}Nombre" por "prefijo:Nombre" en un mensaje
+func shortenNames(msg string) string {
+	for uri, prefix := range Namespaces {
+		if prefix != "" {
+			prefix += ":"
+		}
+		msg = strings.ReplaceAll(msg, "{"+uri+"}", prefix)
+	}
+	return msg
+}
+
+// displayName nombre de un elemento o atributo en los mensajes
+func displayName(space, local string) string {
+	if space == "" {
+		return local
+	}
+	return shortenNames("{" + space + "}" + local)
+}
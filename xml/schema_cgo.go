@@ -0,0 +1,214 @@
+//go:build cgo
+
+package xml
+
+/*
+#cgo pkg-config: libxml-2.0
+#include <libxml/parser.h>
+#include <libxml/xmlerror.h>
+#include <libxml/xmlschemas.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+// schema_errors mensajes acumulados, uno por línea: "línea\tcolumna\tmensaje\n"
+typedef struct {
+	char *data;
+	size_t len;
+} schema_errors;
+
+// schema_collect callback de errores estructurados de libxml2
+// El error se recibe como void* porque su tipo cambió a const en libxml2 2.12.
+static void schema_collect(void *ctx, void *err) {
+	schema_errors *out = (schema_errors *)ctx;
+	xmlErrorPtr e = (xmlErrorPtr)err;
+	if (out == NULL || e == NULL || e->message == NULL || e->level < XML_ERR_ERROR) {
+		return;
+	}
+
+	char head[48];
+	int n = snprintf(head, sizeof(head), "%d\t%d\t", e->line, e->int2);
+	size_t size = strlen(e->message);
+	while (size > 0 && (e->message[size-1] == '\n' || e->message[size-1] == '\r')) {
+		size--;
+	}
+
+	char *data = realloc(out->data, out->len + n + size + 2);
+	if (data == NULL) {
+		return;
+	}
+	memcpy(data + out->len, head, n);
+	for (size_t i = 0; i < size; i++) {
+		char c = e->message[i];
+		data[out->len + n + i] = (c == '\n' || c == '\t') ? ' ' : c;
+	}
+	out->len += n + size;
+	data[out->len++] = '\n';
+	data[out->len] = 0;
+	out->data = data;
+}
+
+// schema_load parsea el esquema xsd; NULL si no carga
+static xmlSchemaPtr schema_load(const char *xsd, schema_errors *out) {
+	xmlSchemaPtr schema = NULL;
+
+	xmlSetStructuredErrorFunc(out, (xmlStructuredErrorFunc)schema_collect);
+	xmlSchemaParserCtxtPtr parser = xmlSchemaNewParserCtxt(xsd);
+	if (parser != NULL) {
+		xmlSchemaSetParserStructuredErrors(parser, (xmlStructuredErrorFunc)schema_collect, out);
+		schema = xmlSchemaParse(parser);
+		xmlSchemaFreeParserCtxt(parser);
+	}
+	xmlSetStructuredErrorFunc(NULL, NULL);
+	return schema;
+}
+
+// schema_validate valida el documento contra el esquema ya parseado
+// Retorna -1 si falla libxml2, 0 si el documento es válido y >0 si no.
+// El esquema es de solo lectura: cada llamada usa su propio contexto.
+static int schema_validate(xmlSchemaPtr schema, const char *doc, int size, schema_errors *out) {
+	int rc = -1;
+	xmlSchemaValidCtxtPtr valid = NULL;
+	xmlDocPtr parsed = NULL;
+
+	xmlSetStructuredErrorFunc(out, (xmlStructuredErrorFunc)schema_collect);
+
+	parsed = xmlReadMemory(doc, size, NULL, NULL, XML_PARSE_NONET);
+	if (parsed == NULL) {
+		rc = 1;
+		goto done;
+	}
+
+	valid = xmlSchemaNewValidCtxt(schema);
+	if (valid == NULL) {
+		goto done;
+	}
+	xmlSchemaSetValidStructuredErrors(valid, (xmlStructuredErrorFunc)schema_collect, out);
+	rc = xmlSchemaValidateDoc(valid, parsed);
+
+done:
+	if (valid != NULL) xmlSchemaFreeValidCtxt(valid);
+	if (parsed != NULL) xmlFreeDoc(parsed);
+	xmlSetStructuredErrorFunc(NULL, NULL);
+	return rc;
+}
+*/
+import "C"
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// ValidateSchema valida el documento contra los esquemas UBL 2.1 y DIAN embebidos
+// Usa libxml2 (como xmllint --schema); sin cgo usa ValidateSchemaGo.
+// Retorna SchemaErrors con línea y columna si el documento no es válido.
+func ValidateSchema(doc []byte) error {
+	schema, err := loadSchema()
+	if err != nil {
+		return err
+	}
+
+	cDoc := C.CBytes(doc)
+	defer C.free(cDoc)
+	out := newSchemaErrors()
+	defer freeSchemaErrors(out)
+
+	rc := C.schema_validate(schema, (*C.char)(cDoc), C.int(len(doc)), out)
+	errs := parseSchemaErrors(C.GoStringN(out.data, C.int(out.len)))
+
+	switch {
+	case rc < 0:
+		return fmt.Errorf("failed to validate XML schemas: %v", errs)
+	case rc == 0:
+		return nil
+	case len(errs) == 0:
+		return SchemaErrors{{Message: fmt.Sprintf("validation failed with code %d", rc)}}
+	}
+	return errs
+}
+
+// newSchemaErrors buffer de mensajes para schema_collect
+func newSchemaErrors() *C.schema_errors {
+	return (*C.schema_errors)(C.calloc(1, C.size_t(unsafe.Sizeof(C.schema_errors{}))))
+}
+
+func freeSchemaErrors(out *C.schema_errors) {
+	C.free(unsafe.Pointer(out.data))
+	C.free(unsafe.Pointer(out))
+}
+
+// parseSchemaErrors convierte los mensajes acumulados por schema_collect
+func parseSchemaErrors(data string) SchemaErrors {
+	var errs SchemaErrors
+	for _, record := range strings.Split(strings.TrimSuffix(data, "\n"), "\n") {
+		fields := strings.SplitN(record, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		line, _ := strconv.Atoi(fields[0])
+		column, _ := strconv.Atoi(fields[1])
+		errs = append(errs, SchemaError{Line: line, Column: column, Message: shortenNames(fields[2])})
+	}
+	return errs
+}
+
+var (
+	schemaOnce sync.Once
+	schemaPtr  C.xmlSchemaPtr
+	schemaErr  error
+)
+
+// loadSchema parsea los esquemas embebidos una sola vez por proceso
+func loadSchema() (C.xmlSchemaPtr, error) {
+	schemaOnce.Do(func() {
+		schemaPtr, schemaErr = parseSchemas()
+	})
+	return schemaPtr, schemaErr
+}
+
+// parseSchemas copia los esquemas a un directorio temporal propio y los parsea
+// libxml2 resuelve los xsd:import por archivo; el esquema queda en memoria y
+// el directorio se borra, así ningún otro proceso puede reemplazar los xsd.
+func parseSchemas() (C.xmlSchemaPtr, error) {
+	dir, err := os.MkdirTemp("", "ubl21-dian-xsd-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract schemas: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = fs.WalkDir(schemaFS, "schemas", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(path))
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o700)
+		}
+		content, err := fs.ReadFile(schemaFS, path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, content, 0o600)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract schemas: %w", err)
+	}
+
+	cXSD := C.CString(filepath.Join(dir, filepath.FromSlash(schemaRoot)))
+	defer C.free(unsafe.Pointer(cXSD))
+	out := newSchemaErrors()
+	defer freeSchemaErrors(out)
+
+	C.xmlInitParser()
+	schema := C.schema_load(cXSD, out)
+	if schema == nil {
+		return nil, fmt.Errorf("failed to load XML schemas: %v", parseSchemaErrors(C.GoStringN(out.data, C.int(out.len))))
+	}
+	return schema, nil
+}
@@ -0,0 +1,10 @@
+//go:build !cgo
+
+package xml
+
+// ValidateSchema valida el documento contra los esquemas UBL 2.1 y DIAN embebidos
+// Sin cgo no hay libxml2 y se usa la implementación en Go (ValidateSchemaGo).
+// Retorna SchemaErrors con línea y columna si el documento no es válido.
+func ValidateSchema(doc []byte) error {
+	return ValidateSchemaGo(doc)
+}
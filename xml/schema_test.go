@@ -0,0 +1,165 @@
+package xml_test
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	xmlpkg "github.com/diegofxm/ubl21-dian/xml"
+)
+
+const invoice = `<?xml version="1.0" encoding="UTF-8"?>
+<Invoice xmlns="urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"
+  xmlns:cac="urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"
+  xmlns:cbc="urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2"
+  xmlns:ds="http://www.w3.org/2000/09/xmldsig#"
+  xmlns:ext="urn:oasis:names:specification:ubl:schema:xsd:CommonExtensionComponents-2"
+  xmlns:sts="dian:gov:co:facturaelectronica:Structures-2-1">
+  <ext:UBLExtensions>
+    <ext:UBLExtension><ext:ExtensionContent><sts:DianExtensions>
+      <sts:SoftwareProvider>
+        <sts:ProviderID schemeID="8" schemeName="31">900123456</sts:ProviderID>
+        <sts:SoftwareID>56f2ae4e-9812-4fad-9255-08fcfcd5ccb0</sts:SoftwareID>
+      </sts:SoftwareProvider>
+      <sts:SoftwareSecurityCode>abc</sts:SoftwareSecurityCode>
+      <sts:AuthorizationProvider><sts:AuthorizationProviderID>800197268</sts:AuthorizationProviderID></sts:AuthorizationProvider>
+    </sts:DianExtensions></ext:ExtensionContent></ext:UBLExtension>
+    <ext:UBLExtension><ext:ExtensionContent><ds:Signature Id="firma"/></ext:ExtensionContent></ext:UBLExtension>
+  </ext:UBLExtensions>
+  <cbc:UBLVersionID>UBL 2.1</cbc:UBLVersionID>
+  <cbc:ID>SETP990000001</cbc:ID>
+  <cbc:IssueDate>2025-06-01</cbc:IssueDate>
+  <cbc:IssueTime>10:00:00-05:00</cbc:IssueTime>
+  <cbc:InvoiceTypeCode>01</cbc:InvoiceTypeCode>
+  <cac:AccountingSupplierParty><cac:Party><cac:PartyTaxScheme>
+    <cbc:RegistrationName>MI EMPRESA SAS</cbc:RegistrationName>
+    <cac:TaxScheme><cbc:ID>01</cbc:ID></cac:TaxScheme>
+  </cac:PartyTaxScheme></cac:Party></cac:AccountingSupplierParty>
+  <cac:AccountingCustomerParty><cac:Party/></cac:AccountingCustomerParty>
+  <cac:LegalMonetaryTotal>
+    <cbc:PayableAmount currencyID="COP">5000.00</cbc:PayableAmount>
+  </cac:LegalMonetaryTotal>
+  <cac:InvoiceLine>
+    <cbc:ID>1</cbc:ID>
+    <cbc:InvoicedQuantity unitCode="94">1</cbc:InvoicedQuantity>
+    <cbc:LineExtensionAmount currencyID="COP">5000.00</cbc:LineExtensionAmount>
+    <cac:Item><cbc:Description>Servicio</cbc:Description></cac:Item>
+    <cac:Price><cbc:PriceAmount currencyID="COP">5000.00</cbc:PriceAmount></cac:Price>
+  </cac:InvoiceLine>
+</Invoice>`
+
+var validators = map[string]func([]byte) error{
+	"ValidateSchema":   xmlpkg.ValidateSchema,
+	"ValidateSchemaGo": xmlpkg.ValidateSchemaGo,
+}
+
+func TestValidateSchema(t *testing.T) {
+	for name, validate := range validators {
+		if err := validate([]byte(invoice)); err != nil {
+			t.Errorf("%s: expected valid invoice, got %v", name, err)
+		}
+	}
+}
+
+// TestValidateSchemaConcurrent el esquema parseado se comparte entre
+// validaciones concurrentes y sobrevive a la canonicalización
+func TestValidateSchemaConcurrent(t *testing.T) {
+	invalid := strings.Replace(invoice, "<cbc:ID>SETP990000001</cbc:ID>", "", 1)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				if err := xmlpkg.ValidateSchema([]byte(invoice)); err != nil {
+					t.Errorf("Expected valid invoice, got %v", err)
+				}
+				if err := xmlpkg.ValidateSchema([]byte(invalid)); !errors.Is(err, xmlpkg.ErrSchemaInvalid) {
+					t.Errorf("Expected ErrSchemaInvalid, got %v", err)
+				}
+				if _, err := xmlpkg.Canonicalize([]byte(invoice)); err != nil {
+					t.Errorf("Canonicalize failed: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// TestValidateSchemaFullSequence elementos de UBL 2.1 que la librería no genera
+func TestValidateSchemaFullSequence(t *testing.T) {
+	doc := strings.NewReplacer(
+		"<cac:AccountingSupplierParty>", "<cac:Signature><cbc:ID>firma</cbc:ID><cac:SignatoryParty/></cac:Signature><cac:AccountingSupplierParty>",
+		"<cac:AccountingCustomerParty><cac:Party/></cac:AccountingCustomerParty>", `<cac:AccountingCustomerParty><cac:Party/></cac:AccountingCustomerParty>
+  <cac:PaymentMeans><cbc:ID>2</cbc:ID><cbc:PaymentMeansCode>10</cbc:PaymentMeansCode><cbc:PaymentDueDate>2025-06-30</cbc:PaymentDueDate></cac:PaymentMeans>
+  <cac:PaymentTerms>
+    <cbc:ReferenceEventCode>2</cbc:ReferenceEventCode>
+    <cac:SettlementPeriod><cbc:DurationMeasure unitCode="DAY">30</cbc:DurationMeasure></cac:SettlementPeriod>
+  </cac:PaymentTerms>
+  <cac:PaymentExchangeRate>
+    <cbc:SourceCurrencyCode>USD</cbc:SourceCurrencyCode>
+    <cbc:SourceCurrencyBaseRate>1.00</cbc:SourceCurrencyBaseRate>
+    <cbc:TargetCurrencyCode>COP</cbc:TargetCurrencyCode>
+    <cbc:TargetCurrencyBaseRate>1.00</cbc:TargetCurrencyBaseRate>
+    <cbc:CalculationRate>4000.00</cbc:CalculationRate>
+    <cbc:Date>2025-06-01</cbc:Date>
+  </cac:PaymentExchangeRate>`,
+		"<cac:Item><cbc:Description>Servicio</cbc:Description></cac:Item>",
+		`<cac:Item><cbc:Description>Servicio</cbc:Description><cac:StandardItemIdentification><cbc:ID schemeID="999">P001</cbc:ID></cac:StandardItemIdentification></cac:Item>`,
+	).Replace(invoice)
+	misplaced := strings.Replace(doc, "<cbc:CalculationRate>4000.00</cbc:CalculationRate>\n    <cbc:Date>2025-06-01</cbc:Date>",
+		"<cbc:Date>2025-06-01</cbc:Date>\n    <cbc:CalculationRate>4000.00</cbc:CalculationRate>", 1)
+	if misplaced == doc {
+		t.Fatal("Test document without cbc:CalculationRate")
+	}
+
+	for name, validate := range validators {
+		if err := validate([]byte(doc)); err != nil {
+			t.Errorf("%s: expected valid invoice, got %v", name, err)
+		}
+		if err := validate([]byte(misplaced)); !errors.Is(err, xmlpkg.ErrSchemaInvalid) || !strings.Contains(err.Error(), "CalculationRate") {
+			t.Errorf("%s: expected error for cbc:CalculationRate after cbc:Date, got %v", name, err)
+		}
+	}
+}
+
+func TestValidateSchemaErrors(t *testing.T) {
+	tests := []struct {
+		name, old, new string
+		line           int
+		message        string
+	}{
+		{"order", "<cbc:IssueTime>", "<cbc:Note>x</cbc:Note><cbc:IssueTime>", 22,
+			"Element 'cbc:IssueTime': This element is not expected."},
+		{"attribute", `<cbc:PayableAmount currencyID="COP">`, "<cbc:PayableAmount>", 30,
+			"Element 'cbc:PayableAmount': The attribute 'currencyID' is required but missing."},
+		{"value", "<cbc:IssueDate>2025-06-01", "<cbc:IssueDate>2025-13-01", 21,
+			"'2025-13-01' is not a valid value of the atomic type 'xs:date'."},
+		{"missing", "<sts:SoftwareSecurityCode>abc</sts:SoftwareSecurityCode>", "", 15,
+			"Element 'sts:AuthorizationProvider': This element is not expected. Expected is ( sts:SoftwareSecurityCode )."},
+		{"malformed", "</Invoice>", "", 0, ""},
+	}
+
+	for name, validate := range validators {
+		for _, tt := range tests {
+			doc := strings.Replace(invoice, tt.old, tt.new, 1)
+			err := validate([]byte(doc))
+			if !errors.Is(err, xmlpkg.ErrSchemaInvalid) {
+				t.Errorf("%s %s: expected ErrSchemaInvalid, got %v", name, tt.name, err)
+				continue
+			}
+
+			var errs xmlpkg.SchemaErrors
+			if !errors.As(err, &errs) || len(errs) == 0 {
+				t.Fatalf("%s %s: expected SchemaErrors, got %T", name, tt.name, err)
+			}
+			if tt.message == "" {
+				continue
+			}
+			if errs[0].Line != tt.line || !strings.Contains(errs[0].Message, tt.message) {
+				t.Errorf("%s %s: unexpected error %v", name, tt.name, errs[0])
+			}
+		}
+	}
+}
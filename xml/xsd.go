@@ -0,0 +1,610 @@
+package xml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	nsXSD = "http://www.w3.org/2001/XMLSchema"
+	nsXSI = "http://www.w3.org/2001/XMLSchema-instance"
+)
+
+// ValidateSchemaGo valida el documento contra los esquemas embebidos sin libxml2
+//
+// Interpreta el subconjunto de XSD que usan los esquemas de la librería
+// (secuencias, referencias a elementos globales, xsd:any y contenido simple
+// con atributos). Es la implementación de ValidateSchema cuando se compila
+// sin cgo; los mensajes siguen el formato de libxml2.
+func ValidateSchemaGo(doc []byte) error {
+	set, err := loadSchemaSet()
+	if err != nil {
+		return err
+	}
+	root, err := parseTree(doc)
+	if err != nil {
+		return err
+	}
+
+	v := &validator{set: set}
+	v.root(root)
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+// node elemento de un árbol XML con namespaces resueltos
+type node struct {
+	space, local string
+	attrs        []xml.Attr // Sin declaraciones xmlns; Name.Space es el URI
+	scope        map[string]string
+	children     []*node
+	text         string
+	line, column int
+}
+
+func (n *node) name() string {
+	return displayName(n.space, n.local)
+}
+
+// resolve resuelve un QName ("cbc:ID") con los namespaces del elemento
+func (n *node) resolve(value string) (qname, error) {
+	prefix, local := "", value
+	if i := strings.IndexByte(value, ':'); i >= 0 {
+		prefix, local = value[:i], value[i+1:]
+	}
+	space, ok := n.scope[prefix]
+	if !ok && prefix != "" {
+		return qname{}, fmt.Errorf("undeclared namespace prefix %q in %q", prefix, value)
+	}
+	return qname{space, local}, nil
+}
+
+func (n *node) attr(local string) (string, bool) {
+	for _, attr := range n.attrs {
+		if attr.Name.Space == "" && attr.Name.Local == local {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// parseTree lee el documento; los errores de sintaxis se retornan como SchemaErrors
+func parseTree(data []byte) (*node, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	scopes := []map[string]string{{"xml": "http://www.w3.org/XML/1998/namespace"}}
+	var stack []*node
+	var names []xml.Name
+	var root *node
+
+	fail := func(line, column int, format string, args ...interface{}) error {
+		return SchemaErrors{{Line: line, Column: column, Message: fmt.Sprintf(format, args...)}}
+	}
+
+	for {
+		line, column := decoder.InputPos()
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var syntax *xml.SyntaxError
+			if errors.As(err, &syntax) {
+				line, column = decoder.InputPos()
+				return nil, fail(syntax.Line, column, "%s", syntax.Msg)
+			}
+			return nil, fail(line, column, "%v", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if root != nil && len(stack) == 0 {
+				return nil, fail(line, column, "Extra content at the end of the document")
+			}
+
+			scope := make(map[string]string, len(scopes[len(scopes)-1]))
+			for prefix, uri := range scopes[len(scopes)-1] {
+				scope[prefix] = uri
+			}
+			for prefix, uri := range declaredNamespaces(t) {
+				scope[prefix] = uri
+			}
+
+			n := &node{local: t.Name.Local, scope: scope, line: line, column: column}
+			space, ok := scope[t.Name.Space]
+			if !ok && t.Name.Space != "" {
+				return nil, fail(line, column, "Namespace prefix %s on %s is not defined", t.Name.Space, t.Name.Local)
+			}
+			n.space = space
+
+			for _, attr := range t.Attr {
+				if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+					continue
+				}
+				if attr.Name.Space != "" {
+					uri, ok := scope[attr.Name.Space]
+					if !ok {
+						return nil, fail(line, column, "Namespace prefix %s for %s on %s is not defined",
+							attr.Name.Space, attr.Name.Local, t.Name.Local)
+					}
+					attr.Name.Space = uri
+				}
+				n.attrs = append(n.attrs, attr)
+			}
+
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			} else {
+				root = n
+			}
+			stack = append(stack, n)
+			names = append(names, t.Name)
+			scopes = append(scopes, scope)
+
+		case xml.EndElement:
+			if len(stack) == 0 || names[len(names)-1] != t.Name {
+				return nil, fail(line, column, "Opening and ending tag mismatch: %s", t.Name.Local)
+			}
+			stack = stack[:len(stack)-1]
+			names = names[:len(names)-1]
+			scopes = scopes[:len(scopes)-1]
+
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(t)
+			} else if len(bytes.TrimSpace(t)) > 0 {
+				return nil, fail(line, column, "Start tag expected, '<' not found")
+			}
+		}
+	}
+
+	line, column := decoder.InputPos()
+	switch {
+	case root == nil:
+		return nil, fail(line, column, "Document is empty")
+	case len(stack) > 0:
+		return nil, fail(line, column, "Premature end of data in tag %s", stack[len(stack)-1].local)
+	}
+	return root, nil
+}
+
+// qname nombre calificado
+type qname struct {
+	space, local string
+}
+
+// xsdParticle elemento (ref) o xsd:any de una secuencia
+type xsdParticle struct {
+	ref      qname
+	any      bool
+	min, max int // max < 0 = unbounded
+}
+
+func (p xsdParticle) matches(n *node) bool {
+	return p.any || (p.ref.space == n.space && p.ref.local == n.local)
+}
+
+func (p xsdParticle) name() string {
+	if p.any {
+		return "##any"
+	}
+	return displayName(p.ref.space, p.ref.local)
+}
+
+// xsdAttribute atributo de un tipo con contenido simple
+type xsdAttribute struct {
+	name     string
+	base     string // Tipo XSD predefinido
+	required bool
+}
+
+// xsdType tipo complejo: secuencia de elementos o contenido simple con atributos
+type xsdType struct {
+	base       string // Tipo XSD predefinido del contenido simple; vacío si tiene elementos
+	attributes []xsdAttribute
+	sequence   []xsdParticle
+}
+
+// xsdSet declaraciones globales de todos los esquemas embebidos
+type xsdSet struct {
+	elements map[qname]qname // Elemento -> tipo
+	types    map[qname]*xsdType
+}
+
+var (
+	schemaSetOnce sync.Once
+	schemaSet     *xsdSet
+	schemaSetErr  error
+)
+
+// loadSchemaSet interpreta los esquemas embebidos una sola vez
+func loadSchemaSet() (*xsdSet, error) {
+	schemaSetOnce.Do(func() {
+		schemaSet, schemaSetErr = readSchemaSet(schemaFS)
+	})
+	return schemaSet, schemaSetErr
+}
+
+func readSchemaSet(fsys fs.FS) (*xsdSet, error) {
+	set := &xsdSet{elements: map[qname]qname{}, types: map[qname]*xsdType{}}
+
+	err := fs.WalkDir(fsys, "schemas", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".xsd") {
+			return err
+		}
+		content, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+		root, err := parseTree(content)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if err := set.add(root); err != nil {
+			return fmt.Errorf("failed to load %s: %w", path, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return set, set.check()
+}
+
+// add registra las declaraciones globales de un xsd:schema
+func (s *xsdSet) add(schema *node) error {
+	if schema.space != nsXSD || schema.local != "schema" {
+		return fmt.Errorf("unexpected root element %s", schema.name())
+	}
+	target, _ := schema.attr("targetNamespace")
+
+	for _, child := range schema.children {
+		if child.space != nsXSD {
+			return fmt.Errorf("line %d: unexpected element %s", child.line, child.name())
+		}
+		name, _ := child.attr("name")
+
+		switch child.local {
+		case "annotation", "import", "include":
+			// Todos los archivos embebidos se cargan; no hay que resolver schemaLocation
+		case "element":
+			value, ok := child.attr("type")
+			if !ok {
+				return fmt.Errorf("line %d: element %s without type", child.line, name)
+			}
+			typ, err := child.resolve(value)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", child.line, err)
+			}
+			s.elements[qname{target, name}] = typ
+		case "complexType":
+			typ, err := readComplexType(child)
+			if err != nil {
+				return fmt.Errorf("line %d: complexType %s: %w", child.line, name, err)
+			}
+			s.types[qname{target, name}] = typ
+		default:
+			return fmt.Errorf("line %d: unsupported construct xsd:%s", child.line, child.local)
+		}
+	}
+	return nil
+}
+
+func readComplexType(n *node) (*xsdType, error) {
+	typ := &xsdType{}
+	for _, child := range n.children {
+		switch child.local {
+		case "annotation":
+		case "sequence":
+			for _, item := range child.children {
+				particle, err := readParticle(item)
+				if err != nil {
+					return nil, err
+				}
+				typ.sequence = append(typ.sequence, particle)
+			}
+		case "simpleContent":
+			if len(child.children) != 1 || child.children[0].local != "extension" {
+				return nil, errors.New("simpleContent must contain one extension")
+			}
+			extension := child.children[0]
+			base, err := readBuiltin(extension, "base")
+			if err != nil {
+				return nil, err
+			}
+			typ.base = base
+			for _, attr := range extension.children {
+				if attr.local != "attribute" {
+					return nil, fmt.Errorf("unsupported construct xsd:%s", attr.local)
+				}
+				name, _ := attr.attr("name")
+				base, err := readBuiltin(attr, "type")
+				if err != nil {
+					return nil, err
+				}
+				use, _ := attr.attr("use")
+				typ.attributes = append(typ.attributes, xsdAttribute{name: name, base: base, required: use == "required"})
+			}
+		default:
+			return nil, fmt.Errorf("unsupported construct xsd:%s", child.local)
+		}
+	}
+	return typ, nil
+}
+
+func readParticle(n *node) (xsdParticle, error) {
+	particle := xsdParticle{min: 1, max: 1}
+	switch n.local {
+	case "element":
+		value, ok := n.attr("ref")
+		if !ok {
+			return particle, errors.New("only element references are supported in sequences")
+		}
+		ref, err := n.resolve(value)
+		if err != nil {
+			return particle, err
+		}
+		particle.ref = ref
+	case "any":
+		// processContents="lax": se validan solo los elementos declarados
+		particle.any = true
+	default:
+		return particle, fmt.Errorf("unsupported construct xsd:%s in sequence", n.local)
+	}
+
+	if value, ok := n.attr("minOccurs"); ok {
+		min, err := strconv.Atoi(value)
+		if err != nil {
+			return particle, fmt.Errorf("invalid minOccurs %q", value)
+		}
+		particle.min = min
+	}
+	if value, ok := n.attr("maxOccurs"); ok {
+		if value == "unbounded" {
+			particle.max = -1
+		} else {
+			max, err := strconv.Atoi(value)
+			if err != nil {
+				return particle, fmt.Errorf("invalid maxOccurs %q", value)
+			}
+			particle.max = max
+		}
+	}
+	return particle, nil
+}
+
+// readBuiltin lee un atributo que referencia un tipo XSD predefinido soportado
+func readBuiltin(n *node, attr string) (string, error) {
+	value, _ := n.attr(attr)
+	name, err := n.resolve(value)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := builtinTypes[name.local]; name.space != nsXSD || !ok {
+		return "", fmt.Errorf("unsupported type %q", value)
+	}
+	return name.local, nil
+}
+
+// check verifica que todas las referencias estén declaradas
+func (s *xsdSet) check() error {
+	for element, typ := range s.elements {
+		if _, ok := s.types[typ]; !ok {
+			return fmt.Errorf("element %s: undefined type %s", displayName(element.space, element.local), typ.local)
+		}
+	}
+	for name, typ := range s.types {
+		for _, particle := range typ.sequence {
+			if _, ok := s.elements[particle.ref]; !particle.any && !ok {
+				return fmt.Errorf("complexType %s: undefined element %s", name.local, particle.name())
+			}
+		}
+	}
+	return nil
+}
+
+var (
+	patternDecimal  = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)$`)
+	patternDate     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}(Z|[+-]\d{2}:\d{2})?$`)
+	patternTime     = regexp.MustCompile(`^\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})?$`)
+	patternLanguage = regexp.MustCompile(`^[a-zA-Z]{1,8}(-[a-zA-Z0-9]{1,8})*$`)
+)
+
+// builtinTypes tipos XSD predefinidos soportados y su verificación
+var builtinTypes = map[string]func(string) bool{
+	"string":           func(string) bool { return true },
+	"normalizedString": func(string) bool { return true },
+	"token":            func(string) bool { return true },
+	"anyURI":           func(string) bool { return true },
+	"language":         patternLanguage.MatchString,
+	"decimal":          patternDecimal.MatchString,
+	"boolean": func(v string) bool {
+		return v == "true" || v == "false" || v == "1" || v == "0"
+	},
+	"date": func(v string) bool {
+		if !patternDate.MatchString(v) {
+			return false
+		}
+		_, err := time.Parse("2006-01-02", v[:10])
+		return err == nil
+	},
+	"time": func(v string) bool {
+		if !patternTime.MatchString(v) {
+			return false
+		}
+		_, err := time.Parse("15:04:05", v[:8])
+		return err == nil
+	},
+}
+
+// validator acumula los errores de validación
+type validator struct {
+	set  *xsdSet
+	errs SchemaErrors
+}
+
+func (v *validator) errorf(n *node, format string, args ...interface{}) {
+	v.errs = append(v.errs, SchemaError{
+		Line:    n.line,
+		Column:  n.column,
+		Message: fmt.Sprintf("Element '%s': ", n.name()) + fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) root(n *node) {
+	typ, ok := v.set.elements[qname{n.space, n.local}]
+	if !ok {
+		v.errorf(n, "No matching global declaration available for the validation root.")
+		return
+	}
+	v.element(n, v.set.types[typ])
+}
+
+// element valida atributos y contenido del elemento según su tipo
+func (v *validator) element(n *node, typ *xsdType) {
+	v.attributes(n, typ)
+
+	if typ.base != "" {
+		if len(n.children) > 0 {
+			v.errorf(n, "Element content is not allowed, because the content type is a simple type definition.")
+			return
+		}
+		if value := collapse(n.text, typ.base); !builtinTypes[typ.base](value) {
+			v.errorf(n, "'%s' is not a valid value of the atomic type 'xs:%s'.", value, typ.base)
+		}
+		return
+	}
+
+	if strings.TrimSpace(n.text) != "" {
+		v.errorf(n, "Character content other than whitespace is not allowed because the content type is 'element-only'.")
+	}
+	v.sequence(n, typ.sequence)
+}
+
+func (v *validator) attributes(n *node, typ *xsdType) {
+	for _, attr := range n.attrs {
+		if attr.Name.Space == nsXSI {
+			continue
+		}
+		name := displayName(attr.Name.Space, attr.Name.Local)
+
+		var decl *xsdAttribute
+		for i := range typ.attributes {
+			if attr.Name.Space == "" && typ.attributes[i].name == attr.Name.Local {
+				decl = &typ.attributes[i]
+			}
+		}
+		switch {
+		case decl == nil:
+			v.errorf(n, "The attribute '%s' is not allowed.", name)
+		case !builtinTypes[decl.base](collapse(attr.Value, decl.base)):
+			v.errorf(n, "'%s' is not a valid value of the atomic type 'xs:%s' (attribute '%s').", attr.Value, decl.base, name)
+		}
+	}
+
+	for _, decl := range typ.attributes {
+		if _, ok := n.attr(decl.name); decl.required && !ok {
+			v.errorf(n, "The attribute '%s' is required but missing.", decl.name)
+		}
+	}
+}
+
+// sequence valida los hijos en orden; se detiene en el primer error del elemento
+// como libxml2
+func (v *validator) sequence(n *node, particles []xsdParticle) {
+	i, count := 0, 0
+	for _, child := range n.children {
+		start, startCount := i, count
+		for {
+			if i == len(particles) {
+				v.unexpected(child, particles, start, startCount)
+				return
+			}
+			p := particles[i]
+			if p.matches(child) && (p.max < 0 || count < p.max) {
+				count++
+				v.child(child, p)
+				break
+			}
+			if count < p.min {
+				v.unexpected(child, particles, start, startCount)
+				return
+			}
+			i, count = i+1, 0
+		}
+	}
+
+	for ; i < len(particles); i, count = i+1, 0 {
+		if count < particles[i].min {
+			v.errorf(n, "Missing child element(s). Expected is %s.", expected(particles, i, count))
+			return
+		}
+	}
+}
+
+func (v *validator) unexpected(n *node, particles []xsdParticle, i, count int) {
+	if list := expected(particles, i, count); list != "" {
+		v.errorf(n, "This element is not expected. Expected is %s.", list)
+		return
+	}
+	v.errorf(n, "This element is not expected.")
+}
+
+// expected elementos aceptables en la posición, hasta el siguiente obligatorio
+func expected(particles []xsdParticle, i, count int) string {
+	var names []string
+	for ; i < len(particles); i, count = i+1, 0 {
+		p := particles[i]
+		if p.max < 0 || count < p.max {
+			names = append(names, p.name())
+		}
+		if count < p.min {
+			break
+		}
+	}
+	switch len(names) {
+	case 0:
+		return ""
+	case 1:
+		return "( " + names[0] + " )"
+	}
+	return "one of ( " + strings.Join(names, ", ") + " )"
+}
+
+func (v *validator) child(n *node, p xsdParticle) {
+	if !p.any {
+		v.element(n, v.set.types[v.set.elements[p.ref]])
+		return
+	}
+	v.lax(n)
+}
+
+// lax valida los elementos declarados e ignora los demás (ds:Signature, ...)
+func (v *validator) lax(n *node) {
+	if typ, ok := v.set.elements[qname{n.space, n.local}]; ok {
+		v.element(n, v.set.types[typ])
+		return
+	}
+	for _, child := range n.children {
+		v.lax(child)
+	}
+}
+
+// collapse normaliza espacios según el tipo (string conserva el valor)
+func collapse(value, base string) string {
+	if base == "string" {
+		return value
+	}
+	return strings.Join(strings.Fields(value), " ")
+}